@@ -0,0 +1,216 @@
+// Package compare sends scheduler requests to two base URLs and reports the
+// differences between their responses.
+package compare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/jsondiff"
+	"local-dev-tools/dynamic-request-scheduler/pkg/requests"
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+// Response is what one side returned
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       interface{}
+	Duration   time.Duration
+}
+
+// Result is the comparison of one request
+type Result struct {
+	Name   string
+	Method string
+
+	// Path is the request's path and query, the same on both sides
+	Path string
+
+	Base      *Response
+	Candidate *Response
+
+	// Changes lead with the status and headers, then the body, as changes
+	// from the base to the candidate
+	Changes []jsondiff.Change
+
+	// Err is set when the request could not be resolved or either side
+	// failed to respond
+	Err error
+}
+
+// Comparer sends each request to both base URLs
+type Comparer struct {
+	// Base is the reference, such as the main branch, and Candidate the
+	// version under test
+	Base      string
+	Candidate string
+
+	Client *http.Client
+
+	// Ignore lists body paths left out of every comparison, in addition to
+	// each request's diff.ignore
+	Ignore []string
+
+	// Headers names the response headers compared
+	Headers []string
+}
+
+// Compare resolves the request's templates once and sends the same request
+// to both sides at the same time
+func (c *Comparer) Compare(ctx context.Context, engine *templating.Engine, req *requests.Request) Result {
+	result := Result{Name: req.Name, Method: req.HTTP.Method}
+	resolved, err := requests.ResolveHTTP(engine, req.HTTP)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Method = resolved.Method
+	result.Path = pathOf(resolved.URL)
+
+	var (
+		wg                    sync.WaitGroup
+		baseErr, candidateErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		result.Base, baseErr = c.send(ctx, resolved, c.Base)
+	}()
+	go func() {
+		defer wg.Done()
+		result.Candidate, candidateErr = c.send(ctx, resolved, c.Candidate)
+	}()
+	wg.Wait()
+
+	switch {
+	case baseErr != nil:
+		result.Err = fmt.Errorf("base: %w", baseErr)
+	case candidateErr != nil:
+		result.Err = fmt.Errorf("candidate: %w", candidateErr)
+	default:
+		var ignore []string
+		ignore = append(ignore, c.Ignore...)
+		if req.Diff != nil {
+			ignore = append(ignore, req.Diff.Ignore...)
+		}
+		result.Changes = c.changes(result.Base, result.Candidate, ignore)
+	}
+	return result
+}
+
+// changes lists the differences from base to candidate
+func (c *Comparer) changes(base, candidate *Response, ignore []string) []jsondiff.Change {
+	var changes []jsondiff.Change
+	if base.StatusCode != candidate.StatusCode {
+		changes = append(changes, jsondiff.Change{Path: "(status)", Kind: jsondiff.Changed, Old: base.StatusCode, New: candidate.StatusCode})
+	}
+	for _, name := range c.Headers {
+		old, new := base.Header.Get(name), candidate.Header.Get(name)
+		path := "(header " + http.CanonicalHeaderKey(name) + ")"
+		switch {
+		case old == new:
+		case old == "":
+			changes = append(changes, jsondiff.Change{Path: path, Kind: jsondiff.Added, New: new})
+		case new == "":
+			changes = append(changes, jsondiff.Change{Path: path, Kind: jsondiff.Removed, Old: old})
+		default:
+			changes = append(changes, jsondiff.Change{Path: path, Kind: jsondiff.Changed, Old: old, New: new})
+		}
+	}
+	return append(changes, jsondiff.Compare(base.Body, candidate.Body, ignore)...)
+}
+
+// send makes the request against one base URL, the way the scheduler sends
+// it: bodies as JSON, with a JSON content type unless one is set
+func (c *Comparer) send(ctx context.Context, resolved *requests.Resolved, base string) (*Response, error) {
+	target, err := Rebase(resolved.URL, base)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if resolved.Body != nil && resolved.Method != "GET" && resolved.Method != "HEAD" {
+		data, err := json.Marshal(resolved.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, resolved.Method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range resolved.Headers {
+		req.Header.Set(name, value)
+	}
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	start := time.Now()
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       jsondiff.Decode(data),
+		Duration:   time.Since(start),
+	}, nil
+}
+
+// Rebase moves a request URL onto a base URL, keeping its path and query.
+// The base's own path is a prefix, so http://localhost:8081/v2 sends
+// http://api.example.com/users to http://localhost:8081/v2/users. A request
+// URL that is only a path is appended to the base.
+func Rebase(rawURL, base string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid request URL %q: %w", rawURL, err)
+	}
+	b, err := url.Parse(base)
+	if err != nil || b.Scheme == "" || b.Host == "" {
+		return "", fmt.Errorf("invalid base URL %q: must be like http://localhost:8080", base)
+	}
+
+	path := u.EscapedPath()
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	rebased := b.Scheme + "://" + b.Host + strings.TrimSuffix(b.EscapedPath(), "/") + path
+	if u.RawQuery != "" {
+		rebased += "?" + u.RawQuery
+	}
+	return rebased, nil
+}
+
+// pathOf returns the path and query of a URL, for display
+func pathOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
@@ -0,0 +1,172 @@
+package compare
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	"local-dev-tools/dynamic-request-scheduler/pkg/requests"
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+// jsonServer answers every request with status and body, recording each
+// request as "METHOD path body" in seen
+func jsonServer(t *testing.T, status int, body string, seen *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if seen != nil {
+			*seen = append(*seen, r.Method+" "+r.URL.RequestURI()+" "+string(data))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newRequest(method, url string, body interface{}) *requests.Request {
+	return &requests.Request{
+		Name: "test",
+		HTTP: requests.HTTP{Method: method, URL: requests.LiteralString(url), Body: requests.LiteralBody(body)},
+	}
+}
+
+func changeStrings(r Result) []string {
+	var out []string
+	for _, c := range r.Changes {
+		out = append(out, c.String())
+	}
+	return out
+}
+
+func TestCompare_Same(t *testing.T) {
+	var baseSeen, candidateSeen []string
+	base := jsonServer(t, 200, `{"id": 1, "tags": ["a"]}`, &baseSeen)
+	candidate := jsonServer(t, 200, `{"tags": ["a"], "id": 1}`, &candidateSeen)
+
+	c := &Comparer{Base: base.URL, Candidate: candidate.URL + "/", Client: http.DefaultClient, Headers: []string{"Content-Type"}}
+	req := newRequest("POST", "http://api.example.com/users?page=2", map[string]interface{}{"name": "Ada"})
+	result := c.Compare(context.Background(), templating.New(nil, 0), req)
+
+	if result.Err != nil {
+		t.Fatalf("Unexpected error: %v", result.Err)
+	}
+	if len(result.Changes) != 0 {
+		t.Errorf("Expected no changes, got %v", changeStrings(result))
+	}
+	if result.Path != "/users?page=2" || result.Method != "POST" {
+		t.Errorf("Got %s %s", result.Method, result.Path)
+	}
+	want := `POST /users?page=2 {"name":"Ada"}`
+	if len(baseSeen) != 1 || baseSeen[0] != want || len(candidateSeen) != 1 || candidateSeen[0] != want {
+		t.Errorf("Expected both sides to receive %q, got %v and %v", want, baseSeen, candidateSeen)
+	}
+}
+
+func TestCompare_Changes(t *testing.T) {
+	base := jsonServer(t, 200, `{"id": 1, "name": "Ada", "meta": {"at": "10:00"}}`, nil)
+	candidate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(404)
+		io.WriteString(w, `{"id": 1, "name": "Grace", "meta": {"at": "10:01"}, "extra": true}`)
+	}))
+	defer candidate.Close()
+
+	c := &Comparer{
+		Base:      base.URL,
+		Candidate: candidate.URL,
+		Client:    http.DefaultClient,
+		Ignore:    []string{"meta.at"},
+		Headers:   []string{"content-type"},
+	}
+	result := c.Compare(context.Background(), templating.New(nil, 0), newRequest("GET", "/users/1", nil))
+	if result.Err != nil {
+		t.Fatalf("Unexpected error: %v", result.Err)
+	}
+	want := []string{
+		"~ (status): 200 -> 404",
+		`~ (header Content-Type): "application/json" -> "application/problem+json"`,
+		"+ extra: true",
+		`~ name: "Ada" -> "Grace"`,
+	}
+	if got := changeStrings(result); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Got changes:\n%s\nWant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCompare_RequestIgnore(t *testing.T) {
+	base := jsonServer(t, 200, `{"id": "a", "items": [{"id": 1, "sku": "X"}]}`, nil)
+	candidate := jsonServer(t, 200, `{"id": "b", "items": [{"id": 2, "sku": "X"}]}`, nil)
+
+	// diff.ignore from the request adds to the comparer's own ignore list
+	var req requests.Request
+	if err := yaml.Unmarshal([]byte(`
+name: orders
+http: {method: GET, url: /orders}
+diff: {ignore: ["items[*].id"]}
+`), &req); err != nil {
+		t.Fatalf("Failed to parse request: %v", err)
+	}
+	c := &Comparer{Base: base.URL, Candidate: candidate.URL, Client: http.DefaultClient, Ignore: []string{"id"}}
+	result := c.Compare(context.Background(), templating.New(nil, 0), &req)
+	if result.Err != nil || len(result.Changes) != 0 {
+		t.Errorf("Expected no changes, got %v (err %v)", changeStrings(result), result.Err)
+	}
+
+	req.Diff = nil
+	result = c.Compare(context.Background(), templating.New(nil, 0), &req)
+	if got := changeStrings(result); len(got) != 1 || got[0] != "~ items[0].id: 1 -> 2" {
+		t.Errorf("Expected only the item id to change, got %v", got)
+	}
+}
+
+func TestCompare_Errors(t *testing.T) {
+	base := jsonServer(t, 200, `{}`, nil)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	c := &Comparer{Base: base.URL, Candidate: down.URL, Client: http.DefaultClient}
+	result := c.Compare(context.Background(), templating.New(nil, 0), newRequest("GET", "/", nil))
+	if result.Err == nil || !strings.HasPrefix(result.Err.Error(), "candidate: ") {
+		t.Errorf("Expected a candidate error, got %v", result.Err)
+	}
+
+	result = c.Compare(context.Background(), templating.New(nil, 0), newRequest("GET", "/{{ nope }}", nil))
+	if result.Err == nil {
+		t.Errorf("Expected a template error")
+	}
+}
+
+func TestRebase(t *testing.T) {
+	tests := []struct {
+		url, base, want string
+	}{
+		{"http://api.example.com/users?page=2", "http://localhost:8080", "http://localhost:8080/users?page=2"},
+		{"https://api.example.com/users", "http://localhost:8081/v2/", "http://localhost:8081/v2/users"},
+		{"/health", "http://localhost:8080/", "http://localhost:8080/health"},
+		{"http://api.example.com", "http://localhost:8080", "http://localhost:8080/"},
+		{"http://api.example.com/a%2Fb", "http://localhost:8080", "http://localhost:8080/a%2Fb"},
+	}
+	for _, tt := range tests {
+		got, err := Rebase(tt.url, tt.base)
+		if err != nil {
+			t.Errorf("Rebase(%q, %q) failed: %v", tt.url, tt.base, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Rebase(%q, %q) = %q, want %q", tt.url, tt.base, got, tt.want)
+		}
+	}
+
+	for _, base := range []string{"localhost:8080", "/v2", ""} {
+		if _, err := Rebase("/", base); err == nil {
+			t.Errorf("Expected base %q to be rejected", base)
+		}
+	}
+}
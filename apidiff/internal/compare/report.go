@@ -0,0 +1,67 @@
+package compare
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Summary counts the results by outcome
+type Summary struct {
+	Same    int
+	Changed int
+	Failed  int
+}
+
+// Add counts a result
+func (s *Summary) Add(r Result) {
+	switch {
+	case r.Err != nil:
+		s.Failed++
+	case len(r.Changes) > 0:
+		s.Changed++
+	default:
+		s.Same++
+	}
+}
+
+// String describes the counts, such as "5 requests: 3 same, 1 changed,
+// 1 failed"
+func (s Summary) String() string {
+	total := s.Same + s.Changed + s.Failed
+	return fmt.Sprintf("%d %s: %d same, %d changed, %d failed", total, plural(total, "request"), s.Same, s.Changed, s.Failed)
+}
+
+// WriteResult prints one result, followed by its changes
+func WriteResult(w io.Writer, r Result) {
+	switch {
+	case r.Err != nil:
+		fmt.Fprintf(w, "! %s: %s %s: %v\n", r.Name, r.Method, r.Path, r.Err)
+	case len(r.Changes) > 0:
+		fmt.Fprintf(w, "✗ %s: %s %s (%d %s, %s)\n", r.Name, r.Method, r.Path, len(r.Changes), plural(len(r.Changes), "change"), timing(r))
+		for _, change := range r.Changes {
+			fmt.Fprintf(w, "    %s\n", change)
+		}
+	default:
+		fmt.Fprintf(w, "✓ %s: %s %s (%d, %s)\n", r.Name, r.Method, r.Path, r.Base.StatusCode, timing(r))
+	}
+}
+
+// timing compares the two durations
+func timing(r Result) string {
+	return fmt.Sprintf("%s vs %s", round(r.Base.Duration), round(r.Candidate.Duration))
+}
+
+func round(d time.Duration) time.Duration {
+	if d > time.Second {
+		return d.Round(10 * time.Millisecond)
+	}
+	return d.Round(100 * time.Microsecond)
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
@@ -0,0 +1,41 @@
+package compare
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/jsondiff"
+)
+
+func TestWriteResult(t *testing.T) {
+	ok := &Response{StatusCode: 200, Duration: 12 * time.Millisecond}
+	slow := &Response{StatusCode: 200, Duration: 1234 * time.Millisecond}
+
+	results := []Result{
+		{Name: "List users", Method: "GET", Path: "/users", Base: ok, Candidate: slow},
+		{Name: "Get user", Method: "GET", Path: "/users/1", Base: ok, Candidate: ok, Changes: []jsondiff.Change{
+			{Path: "name", Kind: jsondiff.Changed, Old: "Ada", New: "Grace"},
+		}},
+		{Name: "Create user", Method: "POST", Path: "/users", Err: errors.New("candidate: connection refused")},
+	}
+
+	var buf bytes.Buffer
+	var summary Summary
+	for _, r := range results {
+		WriteResult(&buf, r)
+		summary.Add(r)
+	}
+	want := `✓ List users: GET /users (200, 12ms vs 1.23s)
+✗ Get user: GET /users/1 (1 change, 12ms vs 12ms)
+    ~ name: "Ada" -> "Grace"
+! Create user: POST /users: candidate: connection refused
+`
+	if buf.String() != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", buf.String(), want)
+	}
+	if got := summary.String(); got != "3 requests: 1 same, 1 changed, 1 failed" {
+		t.Errorf("Got summary %q", got)
+	}
+}
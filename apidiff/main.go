@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"local-dev-tools/apidiff/internal/compare"
+	"local-dev-tools/dynamic-request-scheduler/pkg/requests"
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+// Process exit codes
+const (
+	exitOK = 0
+	// exitChanged means at least one response differed
+	exitChanged = 1
+	// exitConfigError means the flags or config were invalid
+	exitConfigError = 2
+	// exitFailed means a request failed on either side
+	exitFailed = 3
+)
+
+// listFlag collects a repeatable, comma-separated flag
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, splitList(value)...)
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run compares every selected request and returns the process exit code
+func run(args []string) int {
+	fs := flag.NewFlagSet("apidiff", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s --config api.yaml --base <url> --candidate <url> [options]\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", "", "Scheduler config whose http requests are sent (required)")
+	base := fs.String("base", "", "Base URL of the reference version, e.g. http://localhost:8080 (required)")
+	candidate := fs.String("candidate", "", "Base URL of the version under test, e.g. http://localhost:8081 (required)")
+	match := fs.String("match", "", "Only send requests whose names match these comma-separated globs or /regexps/")
+	tags := fs.String("tags", "", "Only send requests with any of these comma-separated tags")
+	headers := fs.String("headers", "Content-Type", "Comma-separated response headers to compare (empty for none)")
+	timeout := fs.Duration("timeout", 10*time.Second, "Timeout for each request")
+	var ignore listFlag
+	fs.Var(&ignore, "ignore", "Body path to leave out of every comparison, e.g. meta.timestamp or items[*].id (repeatable)")
+	fs.Parse(args)
+
+	if *configPath == "" || *base == "" || *candidate == "" {
+		fs.Usage()
+		return exitConfigError
+	}
+	for _, u := range []string{*base, *candidate} {
+		if _, err := compare.Rebase("/", u); err != nil {
+			log.Printf("Error: %v", err)
+			return exitConfigError
+		}
+	}
+
+	loaded, err := requests.LoadConfig(*configPath)
+	if err != nil {
+		log.Printf("Error loading config: %v", err)
+		return exitConfigError
+	}
+	filter, err := requests.NewFilter(*match, splitList(*tags))
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	var selected []requests.Request
+	skipped := 0
+	for _, req := range filter.Apply(loaded) {
+		if req.Type() != requests.TypeHTTP {
+			skipped++
+			continue
+		}
+		selected = append(selected, req)
+	}
+	if skipped > 0 {
+		log.Printf("Skipping %d requests that are not plain HTTP", skipped)
+	}
+	if len(selected) == 0 {
+		log.Printf("Error: no http requests selected from %s", *configPath)
+		return exitConfigError
+	}
+
+	comparer := &compare.Comparer{
+		Base:      *base,
+		Candidate: *candidate,
+		Client:    &http.Client{Timeout: *timeout},
+		Ignore:    ignore,
+		Headers:   splitList(*headers),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("Comparing %s (base) with %s (candidate)\n\n", *base, *candidate)
+	engine := templating.New(nil, 0)
+	var summary compare.Summary
+	for i := range selected {
+		if ctx.Err() != nil {
+			break
+		}
+		result := comparer.Compare(ctx, engine, &selected[i])
+		compare.WriteResult(os.Stdout, result)
+		summary.Add(result)
+	}
+	fmt.Printf("\n%s\n", summary)
+
+	switch {
+	case summary.Failed > 0:
+		return exitFailed
+	case summary.Changed > 0:
+		return exitChanged
+	default:
+		return exitOK
+	}
+}
@@ -0,0 +1,112 @@
+package chaos
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the proxy's configuration file
+type Config struct {
+	// Target is the service being fronted: http://host:port proxies HTTP,
+	// tcp://host:port relays raw TCP
+	Target string `yaml:"target"`
+
+	// Listen defaults to :8081
+	Listen string `yaml:"listen,omitempty"`
+
+	// Default applies to requests no rule matches, and to every TCP connection
+	Default Faults `yaml:"default,omitempty"`
+
+	// Rules override the default faults for matching HTTP requests; the
+	// first match wins and replaces the defaults entirely
+	Rules []Rule `yaml:"rules,omitempty"`
+}
+
+// Rule applies faults to HTTP requests matching a method and path
+type Rule struct {
+	// Method matches any method when empty
+	Method string `yaml:"method,omitempty"`
+
+	// Path matches exactly, or as a prefix when it ends in *
+	Path string `yaml:"path,omitempty"`
+
+	Faults `yaml:",inline"`
+}
+
+// LoadConfig reads a YAML or JSON config file. It is validated once flags
+// have been applied.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return &config, nil
+}
+
+// Validate checks the target and every set of faults
+func (c *Config) Validate() error {
+	if c.Target == "" {
+		return fmt.Errorf("target is required (e.g. http://localhost:3000 or tcp://localhost:5432)")
+	}
+	u, err := url.Parse(c.Target)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "tcp") {
+		return fmt.Errorf("invalid target %q: use http://, https:// or tcp://host:port", c.Target)
+	}
+	if c.Listen == "" {
+		c.Listen = ":8081"
+	}
+
+	if err := c.Default.Validate(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		rule.Method = strings.ToUpper(rule.Method)
+		if rule.Path != "" && !strings.HasPrefix(rule.Path, "/") {
+			return fmt.Errorf("rule %d: path must start with /", i)
+		}
+		if err := rule.Faults.Validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	if c.IsTCP() && len(c.Rules) > 0 {
+		return fmt.Errorf("rules only apply to HTTP targets")
+	}
+	return nil
+}
+
+// IsTCP reports whether the target is relayed as raw TCP
+func (c *Config) IsTCP() bool {
+	return strings.HasPrefix(c.Target, "tcp://")
+}
+
+// faultsFor returns the faults of the first rule matching the request, or
+// the defaults
+func (c *Config) faultsFor(method, path string) (*Faults, string) {
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if rule.Method != "" && rule.Method != method {
+			continue
+		}
+		if rule.matchesPath(path) {
+			return &rule.Faults, fmt.Sprintf("rule %d", i)
+		}
+	}
+	return &c.Default, "default"
+}
+
+func (r *Rule) matchesPath(path string) bool {
+	if prefix, ok := strings.CutSuffix(r.Path, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return r.Path == "" || r.Path == path
+}
@@ -0,0 +1,68 @@
+package chaos
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chaos.yaml")
+	os.WriteFile(path, []byte(`
+target: http://localhost:3000
+default:
+  latency: 50ms
+rules:
+  - method: post
+    path: /api/payments*
+    error_rate: 0.2
+    error_status: 503
+  - path: /downloads/*
+    bandwidth: 256KB
+`), 0o644)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if config.Listen != ":8081" || config.IsTCP() {
+		t.Errorf("Unexpected defaults %+v", config)
+	}
+
+	tests := []struct {
+		method, path, want string
+	}{
+		{"POST", "/api/payments/42", "rule 0"},
+		{"GET", "/api/payments/42", "default"},
+		{"GET", "/downloads/big.iso", "rule 1"},
+		{"GET", "/downloads", "default"},
+	}
+	for _, tt := range tests {
+		if _, source := config.faultsFor(tt.method, tt.path); source != tt.want {
+			t.Errorf("faultsFor(%s %s) = %s, want %s", tt.method, tt.path, source, tt.want)
+		}
+	}
+	if faults, _ := config.faultsFor("POST", "/api/payments"); faults.ErrorStatus != 503 || faults.latency != 0 {
+		t.Errorf("Expected the rule to replace the defaults, got %+v", faults)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]Config{
+		"target is required":       {},
+		"invalid target":           {Target: "localhost:3000"},
+		"default: invalid latency": {Target: "http://localhost", Default: Faults{Latency: "x"}},
+		"rule 0: path must start":  {Target: "http://localhost", Rules: []Rule{{Path: "api"}}},
+		"rule 1: drop_rate":        {Target: "http://localhost", Rules: []Rule{{}, {Faults: Faults{DropRate: 2}}}},
+		"only apply to HTTP":       {Target: "tcp://localhost:5432", Rules: []Rule{{Path: "/"}}},
+	}
+	for want, config := range tests {
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %v", want, err)
+		}
+	}
+}
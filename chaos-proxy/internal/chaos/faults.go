@@ -0,0 +1,96 @@
+// Package chaos degrades traffic to a local service: it adds latency and
+// jitter, limits bandwidth, drops connections and replaces responses with
+// errors, over HTTP or raw TCP.
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/shaping"
+)
+
+// Faults are the degradations applied to a request or connection
+type Faults struct {
+	// Latency delays each request, or each chunk relayed over TCP, varied by
+	// up to ±Jitter
+	Latency string `yaml:"latency,omitempty"`
+	Jitter  string `yaml:"jitter,omitempty"`
+
+	// Bandwidth caps the transfer rate (e.g. "64KB" per second)
+	Bandwidth string `yaml:"bandwidth,omitempty"`
+
+	// DropRate is the fraction of connections closed without a response
+	DropRate float64 `yaml:"drop_rate,omitempty"`
+
+	// ErrorRate is the fraction of HTTP requests answered with ErrorStatus
+	// (default 500) and ErrorBody instead of being proxied
+	ErrorRate   float64 `yaml:"error_rate,omitempty"`
+	ErrorStatus int     `yaml:"error_status,omitempty"`
+	ErrorBody   string  `yaml:"error_body,omitempty"`
+
+	latency   time.Duration
+	jitter    time.Duration
+	bandwidth int64
+}
+
+// Validate checks the faults and parses their durations and sizes
+func (f *Faults) Validate() error {
+	var err error
+	if f.latency, err = parseDuration("latency", f.Latency); err != nil {
+		return err
+	}
+	if f.jitter, err = parseDuration("jitter", f.Jitter); err != nil {
+		return err
+	}
+	if f.bandwidth, err = shaping.ParseBandwidth(f.Bandwidth); err != nil {
+		return err
+	}
+	if f.DropRate < 0 || f.DropRate > 1 {
+		return fmt.Errorf("drop_rate must be between 0 and 1")
+	}
+	if f.ErrorRate < 0 || f.ErrorRate > 1 {
+		return fmt.Errorf("error_rate must be between 0 and 1")
+	}
+	if f.ErrorStatus == 0 {
+		f.ErrorStatus = http.StatusInternalServerError
+	}
+	if f.ErrorStatus < 100 || f.ErrorStatus > 599 {
+		return fmt.Errorf("invalid error_status %d", f.ErrorStatus)
+	}
+	return nil
+}
+
+// String summarises the faults for the startup banner
+func (f *Faults) String() string {
+	var parts []string
+	if f.latency > 0 || f.jitter > 0 {
+		parts = append(parts, fmt.Sprintf("latency %v±%v", f.latency, f.jitter))
+	}
+	if f.bandwidth > 0 {
+		parts = append(parts, "bandwidth "+f.Bandwidth+"/s")
+	}
+	if f.DropRate > 0 {
+		parts = append(parts, fmt.Sprintf("drop %g%%", f.DropRate*100))
+	}
+	if f.ErrorRate > 0 {
+		parts = append(parts, fmt.Sprintf("errors %g%% (%d)", f.ErrorRate*100, f.ErrorStatus))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func parseDuration(field, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", field, value)
+	}
+	return d, nil
+}
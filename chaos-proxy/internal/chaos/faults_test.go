@@ -0,0 +1,32 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFaults_Validate(t *testing.T) {
+	faults := Faults{Latency: "100ms", Jitter: "20ms", Bandwidth: "1KB", ErrorRate: 0.5}
+	if err := faults.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if faults.latency != 100*time.Millisecond || faults.jitter != 20*time.Millisecond || faults.bandwidth != 1024 || faults.ErrorStatus != 500 {
+		t.Errorf("Unexpected parsed faults %+v", faults)
+	}
+	if got := faults.String(); got != "latency 100ms±20ms, bandwidth 1KB/s, errors 50% (500)" {
+		t.Errorf("Unexpected summary %q", got)
+	}
+
+	invalid := []Faults{
+		{Latency: "soon"},
+		{Jitter: "-1s"},
+		{DropRate: 1.5},
+		{ErrorRate: -0.1},
+		{ErrorStatus: 99},
+	}
+	for _, f := range invalid {
+		if err := f.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", f)
+		}
+	}
+}
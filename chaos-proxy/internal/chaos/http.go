@@ -0,0 +1,119 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/shaping"
+)
+
+// HTTPProxy forwards HTTP requests to the target, degrading them according
+// to the config
+type HTTPProxy struct {
+	config *Config
+	proxy  *httputil.ReverseProxy
+	dice   *shaping.Dice
+	logf   func(format string, args ...interface{})
+}
+
+// NewHTTPProxy creates a proxy for a validated config with an http(s)
+// target. seed makes the random faults reproducible; logf receives one line
+// per request and may be nil.
+func NewHTTPProxy(config *Config, seed int64, logf func(format string, args ...interface{})) (*HTTPProxy, error) {
+	target, err := url.Parse(config.Target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target: %w", err)
+	}
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	// Stream responses so bandwidth limits are visible to the client
+	proxy.FlushInterval = -1
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		http.Error(w, fmt.Sprintf("chaos-proxy: upstream error: %v", err), http.StatusBadGateway)
+	}
+
+	return &HTTPProxy{config: config, proxy: proxy, dice: shaping.NewDice(seed), logf: logf}, nil
+}
+
+// ServeHTTP applies the faults for the request, then proxies it unless it
+// was dropped or answered with an injected error
+func (p *HTTPProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	faults, source := p.config.faultsFor(r.Method, r.URL.Path)
+
+	if delay := p.dice.Jittered(faults.latency, faults.jitter); delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if p.dice.Roll(faults.DropRate) {
+		p.drop(w)
+		p.logf("%s %s -> dropped (%s)", r.Method, r.URL.Path, source)
+		return
+	}
+
+	if p.dice.Roll(faults.ErrorRate) {
+		body := faults.ErrorBody
+		if body == "" {
+			body = http.StatusText(faults.ErrorStatus)
+		}
+		http.Error(w, body, faults.ErrorStatus)
+		p.logf("%s %s -> %d (%s, injected error, %v)", r.Method, r.URL.Path, faults.ErrorStatus, source, since(start))
+		return
+	}
+
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	if faults.bandwidth > 0 {
+		recorder.body = shaping.NewThrottledWriter(w, faults.bandwidth)
+	}
+	p.proxy.ServeHTTP(recorder, r)
+	p.logf("%s %s -> %d (%s, %v)", r.Method, r.URL.Path, recorder.status, source, since(start))
+}
+
+// drop closes the client connection without writing a response
+func (p *HTTPProxy) drop(w http.ResponseWriter) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		// HTTP/2 connections cannot be hijacked; abort the stream instead
+		panic(http.ErrAbortHandler)
+	}
+	conn.Close()
+}
+
+// statusRecorder captures the response status and optionally throttles the
+// body
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	body   *shaping.ThrottledWriter
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.body != nil {
+		return r.body.Write(p)
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController flush the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func since(start time.Time) time.Duration {
+	return time.Since(start).Round(time.Millisecond)
+}
@@ -0,0 +1,103 @@
+package chaos
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestProxy(t *testing.T, config *Config) *httptest.Server {
+	t.Helper()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", "yes")
+		fmt.Fprint(w, strings.Repeat("x", 2<<10))
+	}))
+	t.Cleanup(upstream.Close)
+
+	config.Target = upstream.URL
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Invalid config: %v", err)
+	}
+	proxy, err := NewHTTPProxy(config, 1, nil)
+	if err != nil {
+		t.Fatalf("NewHTTPProxy failed: %v", err)
+	}
+	server := httptest.NewServer(proxy)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPProxy(t *testing.T) {
+	server := newTestProxy(t, &Config{
+		Default: Faults{Latency: "100ms"},
+		Rules: []Rule{
+			{Path: "/errors", Faults: Faults{ErrorRate: 1, ErrorStatus: 503, ErrorBody: "overloaded"}},
+			{Path: "/drop", Faults: Faults{DropRate: 1}},
+			{Path: "/slow/*", Faults: Faults{Bandwidth: "8KB"}},
+		},
+	})
+
+	start := time.Now()
+	resp, err := http.Get(server.URL + "/anything")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 200 || resp.Header.Get("X-Upstream") != "yes" || len(body) != 2<<10 {
+		t.Errorf("Expected proxied response, got %d %v (%d bytes)", resp.StatusCode, resp.Header, len(body))
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected 100ms latency, took %v", elapsed)
+	}
+
+	resp, err = http.Get(server.URL + "/errors")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 503 || strings.TrimSpace(string(body)) != "overloaded" || resp.Header.Get("X-Upstream") != "" {
+		t.Errorf("Expected injected 503, got %d %q", resp.StatusCode, body)
+	}
+
+	if _, err := http.Get(server.URL + "/drop"); err == nil {
+		t.Error("Expected the dropped connection to fail the request")
+	}
+
+	start = time.Now()
+	resp, err = http.Get(server.URL + "/slow/file")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	// 2KB at 8KB/s takes about 250ms
+	if elapsed := time.Since(start); len(body) != 2<<10 || elapsed < 200*time.Millisecond {
+		t.Errorf("Expected throttled transfer, got %d bytes in %v", len(body), elapsed)
+	}
+}
+
+func TestHTTPProxy_UpstreamDown(t *testing.T) {
+	config := &Config{Target: "http://127.0.0.1:1"}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Invalid config: %v", err)
+	}
+	proxy, _ := NewHTTPProxy(config, 1, nil)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("Expected 502, got %d", resp.StatusCode)
+	}
+}
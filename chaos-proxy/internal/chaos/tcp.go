@@ -0,0 +1,116 @@
+package chaos
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/shaping"
+)
+
+// TCPProxy relays raw TCP connections to the target, degrading them
+// according to the config's default faults
+type TCPProxy struct {
+	target string
+	faults *Faults
+	dice   *shaping.Dice
+	logf   func(format string, args ...interface{})
+}
+
+// NewTCPProxy creates a relay for a validated config with a tcp:// target
+func NewTCPProxy(config *Config, seed int64, logf func(format string, args ...interface{})) *TCPProxy {
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+	return &TCPProxy{
+		target: strings.TrimPrefix(config.Target, "tcp://"),
+		faults: &config.Default,
+		dice:   shaping.NewDice(seed),
+		logf:   logf,
+	}
+}
+
+// Serve accepts connections until the listener is closed
+func (p *TCPProxy) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go p.handle(conn)
+	}
+}
+
+func (p *TCPProxy) handle(client net.Conn) {
+	defer client.Close()
+	start := time.Now()
+	remote := client.RemoteAddr().String()
+
+	if p.dice.Roll(p.faults.DropRate) {
+		p.logf("%s -> dropped", remote)
+		return
+	}
+
+	upstream, err := net.DialTimeout("tcp", p.target, 10*time.Second)
+	if err != nil {
+		p.logf("%s -> upstream error: %v", remote, err)
+		return
+	}
+	defer upstream.Close()
+
+	var wg sync.WaitGroup
+	var sent, received int64
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		sent = p.relay(upstream, client)
+	}()
+	go func() {
+		defer wg.Done()
+		received = p.relay(client, upstream)
+	}()
+	wg.Wait()
+
+	p.logf("%s -> closed after %v (%d bytes sent, %d received)", remote, since(start), sent, received)
+}
+
+// relay copies src to dst, delaying each chunk and limiting bandwidth, then
+// closes dst for writing so the other side sees EOF
+func (p *TCPProxy) relay(dst, src net.Conn) int64 {
+	var w io.Writer = dst
+	if p.faults.bandwidth > 0 {
+		w = shaping.NewThrottledWriter(dst, p.faults.bandwidth)
+	}
+
+	var total int64
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if delay := p.dice.Jittered(p.faults.latency, p.faults.jitter); delay > 0 {
+				time.Sleep(delay)
+			}
+			written, werr := w.Write(buf[:n])
+			total += int64(written)
+			if werr != nil {
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if tcp, ok := dst.(*net.TCPConn); ok {
+		tcp.CloseWrite()
+	} else {
+		dst.Close()
+	}
+	return total
+}
@@ -0,0 +1,85 @@
+package chaos
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// startEcho runs a TCP server echoing everything it receives
+func startEcho(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func startTCPProxy(t *testing.T, faults Faults) string {
+	t.Helper()
+
+	config := &Config{Target: "tcp://" + startEcho(t), Default: faults}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Invalid config: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go NewTCPProxy(config, 1, nil).Serve(listener)
+	return listener.Addr().String()
+}
+
+func TestTCPProxy_Relay(t *testing.T) {
+	addr := startTCPProxy(t, Faults{Latency: "50ms"})
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	conn.Write([]byte("ping"))
+	reply := make([]byte, 4)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadFull(conn, reply); err != nil || string(reply) != "ping" {
+		t.Fatalf("Expected echo, got %q %v", reply, err)
+	}
+	// The chunk is delayed once in each direction
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected at least 100ms round trip, took %v", elapsed)
+	}
+}
+
+func TestTCPProxy_Drop(t *testing.T) {
+	addr := startTCPProxy(t, Faults{DropRate: 1})
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("Expected the dropped connection to be closed")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"local-dev-tools/chaos-proxy/internal/chaos"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run proxies traffic until interrupted and returns the process exit code
+func run() int {
+	configPath := flag.String("config", "", "Path to a config file with per-path rules (YAML or JSON)")
+	target := flag.String("target", "", "Service to front: http://host:port, or tcp://host:port for raw TCP")
+	listen := flag.String("listen", ":8081", "Address to listen on")
+	latency := flag.String("latency", "", "Delay added to each request, or each TCP chunk (e.g. 200ms)")
+	jitter := flag.String("jitter", "", "Random ± variation of the latency (e.g. 50ms)")
+	bandwidth := flag.String("bandwidth", "", "Transfer rate limit per connection (e.g. 64KB or 1MB per second)")
+	dropRate := flag.Float64("drop-rate", 0, "Fraction of connections closed without a response (0-1)")
+	errorRate := flag.Float64("error-rate", 0, "Fraction of HTTP requests answered with --error-status instead of being proxied (0-1)")
+	errorStatus := flag.Int("error-status", http.StatusInternalServerError, "Status code of injected errors")
+	seed := flag.Int64("seed", 0, "Seed for the random faults (0 picks one from the clock)")
+	quiet := flag.Bool("quiet", false, "Do not log each request or connection")
+	flag.Parse()
+
+	config := &chaos.Config{}
+	if *configPath != "" {
+		var err error
+		if config, err = chaos.LoadConfig(*configPath); err != nil {
+			log.Printf("Error loading config: %v", err)
+			return 2
+		}
+	}
+
+	// Flags given explicitly take precedence over the config file
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "target":
+			config.Target = *target
+		case "listen":
+			config.Listen = *listen
+		case "latency":
+			config.Default.Latency = *latency
+		case "jitter":
+			config.Default.Jitter = *jitter
+		case "bandwidth":
+			config.Default.Bandwidth = *bandwidth
+		case "drop-rate":
+			config.Default.DropRate = *dropRate
+		case "error-rate":
+			config.Default.ErrorRate = *errorRate
+		case "error-status":
+			config.Default.ErrorStatus = *errorStatus
+		}
+	})
+	if config.Listen == "" {
+		config.Listen = *listen
+	}
+	if err := config.Validate(); err != nil {
+		log.Printf("Error: %v", err)
+		return 2
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	logf := log.Printf
+	if *quiet {
+		logf = nil
+	}
+
+	listener, err := net.Listen("tcp", config.Listen)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 3
+	}
+
+	fmt.Printf("Proxying %s -> %s (default faults: %s", config.Listen, config.Target, &config.Default)
+	if len(config.Rules) > 0 {
+		fmt.Printf("; %d rules", len(config.Rules))
+	}
+	fmt.Println(")")
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	if config.IsTCP() {
+		go func() {
+			<-sigChan
+			fmt.Println("\nShutting down...")
+			listener.Close()
+		}()
+		if err := chaos.NewTCPProxy(config, *seed, logf).Serve(listener); err != nil {
+			log.Printf("Proxy error: %v", err)
+			return 3
+		}
+		return 0
+	}
+
+	proxy, err := chaos.NewHTTPProxy(config, *seed, logf)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 2
+	}
+	server := &http.Server{Handler: proxy}
+	go func() {
+		<-sigChan
+		fmt.Println("\nShutting down...")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Proxy error: %v", err)
+		return 3
+	}
+	return 0
+}
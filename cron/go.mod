@@ -0,0 +1,9 @@
+module local-dev-tools/cron
+
+go 1.21
+
+require local-dev-tools/dynamic-request-scheduler v0.0.0
+
+require github.com/robfig/cron/v3 v3.0.1 // indirect
+
+replace local-dev-tools/dynamic-request-scheduler => ../dynamic-request-scheduler
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/cronexpr"
+)
+
+// Process exit codes
+const (
+	exitOK = 0
+	// exitMissed means cron test found a time the expression does not fire at
+	exitMissed = 1
+	// exitConfigError means the flags or expression were invalid
+	exitConfigError = 2
+)
+
+func main() {
+	log.SetFlags(0)
+	os.Exit(run(os.Args[1:]))
+}
+
+// run dispatches to explain or test and returns the process exit code. An
+// expression given without a command is explained.
+func run(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "explain":
+			return explain(args[1:])
+		case "test":
+			return test(args[1:])
+		}
+	}
+	return explain(args)
+}
+
+// explain describes an expression and lists its next fire times
+func explain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [explain] [options] \"<expression>\"\n       %s test [--tz zone] \"<expression>\" <time>...\n\nOptions:\n", os.Args[0], os.Args[0])
+		fs.PrintDefaults()
+	}
+	next := fs.Int("next", 5, "Number of upcoming fire times to show")
+	zone := fs.String("tz", "", "Time zone the expression fires in, unless it sets CRON_TZ (default local)")
+	from := fs.String("from", "", "List fire times after this time instead of now (RFC 3339, e.g. 2025-03-01T09:00:00Z)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitConfigError
+	}
+	expr, err := cronexpr.ParseInZone(fs.Arg(0), *zone)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	now := time.Now()
+	if *from != "" {
+		if now, err = parseTime(*from); err != nil {
+			log.Printf("Error parsing --from: %v", err)
+			return exitConfigError
+		}
+	}
+	if *next < 1 {
+		*next = 1
+	}
+
+	expr.Report(os.Stdout, now, *next)
+	return exitOK
+}
+
+// test reports whether an expression fires at each of the given times, and
+// why not when it misses
+func test(args []string) int {
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s test [--tz zone] \"<expression>\" <time>...\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	zone := fs.String("tz", "", "Time zone the expression fires in, unless it sets CRON_TZ (default local)")
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return exitConfigError
+	}
+	expr, err := cronexpr.ParseInZone(fs.Arg(0), *zone)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	code := exitOK
+	for _, value := range fs.Args()[1:] {
+		t, err := parseTime(value)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitConfigError
+		}
+
+		shown := t.In(expr.Location).Format("Mon 2006-01-02 15:04 MST")
+		reasons := expr.Check(t)
+		if reasons == nil {
+			fmt.Printf("FIRES  %s\n", shown)
+			continue
+		}
+		code = exitMissed
+		fmt.Printf("MISSES %s\n", shown)
+		for _, reason := range reasons {
+			fmt.Printf("       %s\n", reason)
+		}
+	}
+	return code
+}
+
+// parseTime accepts RFC 3339, or a local date and time such as
+// 2025-03-01 09:00, as the scheduler's --at does
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (expected e.g. 2025-03-01T09:00:00Z)", value)
+}
@@ -0,0 +1,202 @@
+// Package dns is a small authoritative DNS server answering from records in
+// a config file, with wildcard names and optional forwarding of other
+// names upstream.
+package dns
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultTTL is short so edited records take effect quickly
+const defaultTTL = 10
+
+// Config is the DNS stub's configuration file
+type Config struct {
+	// TTL is the default time to live of answers in seconds
+	TTL uint32 `yaml:"ttl,omitempty"`
+
+	// Upstream is a host:port resolver that names without records are
+	// forwarded to; they are answered with NXDOMAIN when empty
+	Upstream string `yaml:"upstream,omitempty"`
+
+	// Records maps names, which may start with a "*." wildcard label, to
+	// their records
+	Records map[string]RecordSet `yaml:"records"`
+}
+
+// RecordSet is the records of one name. In the config file it may also be
+// an address or a list of addresses, sorted into A and AAAA records.
+type RecordSet struct {
+	A     []string `yaml:"a,omitempty"`
+	AAAA  []string `yaml:"aaaa,omitempty"`
+	CNAME string   `yaml:"cname,omitempty"`
+	TXT   []string `yaml:"txt,omitempty"`
+	MX    []MX     `yaml:"mx,omitempty"`
+
+	// TTL overrides the default time to live of these records
+	TTL uint32 `yaml:"ttl,omitempty"`
+}
+
+// MX is a mail exchanger record
+type MX struct {
+	Host string `yaml:"host"`
+
+	// Priority is the preference, lower first, 10 by default
+	Priority uint16 `yaml:"priority,omitempty"`
+}
+
+// UnmarshalYAML accepts the address shorthands as well as a mapping
+func (r *RecordSet) UnmarshalYAML(node *yaml.Node) error {
+	var addresses []string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		addresses = []string{node.Value}
+	case yaml.SequenceNode:
+		if err := node.Decode(&addresses); err != nil {
+			return err
+		}
+	default:
+		type plain RecordSet
+		return node.Decode((*plain)(r))
+	}
+
+	for _, address := range addresses {
+		ip := net.ParseIP(address)
+		switch {
+		case ip == nil:
+			return fmt.Errorf("line %d: %q is not an IP address", node.Line, address)
+		case ip.To4() != nil:
+			r.A = append(r.A, address)
+		default:
+			r.AAAA = append(r.AAAA, address)
+		}
+	}
+	return nil
+}
+
+// LoadConfig reads and validates a YAML or JSON config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks the names, addresses and upstream
+func (c *Config) Validate() error {
+	if c.TTL == 0 {
+		c.TTL = defaultTTL
+	}
+	if c.Upstream != "" {
+		if _, _, err := net.SplitHostPort(c.Upstream); err != nil {
+			return fmt.Errorf("upstream must be host:port: %v", err)
+		}
+	}
+
+	if len(c.Records) == 0 {
+		return fmt.Errorf("at least one record must be defined")
+	}
+	seen := make(map[string]string)
+	for _, name := range c.Names() {
+		normalized := normalizeName(name)
+		if err := validateName(normalized); err != nil {
+			return fmt.Errorf("record %q: %w", name, err)
+		}
+		if other, ok := seen[normalized]; ok {
+			return fmt.Errorf("record %q: duplicates %q", name, other)
+		}
+		seen[normalized] = name
+
+		set := c.Records[name]
+		if err := set.validate(); err != nil {
+			return fmt.Errorf("record %q: %w", name, err)
+		}
+		c.Records[name] = set
+	}
+	return nil
+}
+
+// Names returns the record names, sorted
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Records))
+	for name := range c.Records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *RecordSet) validate() error {
+	if len(r.A)+len(r.AAAA)+len(r.TXT)+len(r.MX) == 0 && r.CNAME == "" {
+		return fmt.Errorf("no records")
+	}
+	if r.CNAME != "" {
+		if len(r.A)+len(r.AAAA)+len(r.TXT)+len(r.MX) > 0 {
+			return fmt.Errorf("a cname cannot be combined with other records")
+		}
+		if err := validateName(normalizeName(r.CNAME)); err != nil || strings.HasPrefix(r.CNAME, "*") {
+			return fmt.Errorf("invalid cname %q", r.CNAME)
+		}
+	}
+	for _, address := range r.A {
+		if ip := net.ParseIP(address); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("invalid IPv4 address %q", address)
+		}
+	}
+	for _, address := range r.AAAA {
+		if ip := net.ParseIP(address); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("invalid IPv6 address %q", address)
+		}
+	}
+	for i := range r.MX {
+		if err := validateName(normalizeName(r.MX[i].Host)); err != nil || strings.HasPrefix(r.MX[i].Host, "*") {
+			return fmt.Errorf("invalid mx host %q", r.MX[i].Host)
+		}
+		if r.MX[i].Priority == 0 {
+			r.MX[i].Priority = 10
+		}
+	}
+	return nil
+}
+
+// normalizeName lowercases a name and removes any trailing dot
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
+
+// validateName checks a normalized name, allowing a leading "*" label
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("empty name")
+	}
+	if len(name) > 253 {
+		return fmt.Errorf("name longer than 253 characters")
+	}
+	for i, label := range strings.Split(name, ".") {
+		if label == "*" && i == 0 {
+			continue
+		}
+		if label == "" || len(label) > 63 {
+			return fmt.Errorf("labels must be 1 to 63 characters")
+		}
+		if strings.ContainsAny(label, "* \t") {
+			return fmt.Errorf("invalid label %q; \"*\" is only allowed as the first label", label)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package dns
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dns.yaml")
+	os.WriteFile(path, []byte(`
+upstream: 1.1.1.1:53
+records:
+  myapp.test: 127.0.0.1
+  api.myapp.test: [127.0.0.1, "::1"]
+  "*.myapp.test.":
+    a: [10.0.0.1]
+    ttl: 60
+  mail.myapp.test:
+    mx:
+      - host: localhost
+`), 0o644)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.TTL != defaultTTL || config.Upstream != "1.1.1.1:53" {
+		t.Errorf("Unexpected config %+v", config)
+	}
+	if api := config.Records["api.myapp.test"]; !reflect.DeepEqual(api.A, []string{"127.0.0.1"}) || !reflect.DeepEqual(api.AAAA, []string{"::1"}) {
+		t.Errorf("Expected the address list sorted into A and AAAA, got %+v", api)
+	}
+	if wildcard := config.Records["*.myapp.test."]; wildcard.TTL != 60 || len(wildcard.A) != 1 {
+		t.Errorf("Unexpected wildcard records %+v", wildcard)
+	}
+	if mx := config.Records["mail.myapp.test"].MX; mx[0].Priority != 10 {
+		t.Errorf("Expected the default MX priority, got %+v", mx)
+	}
+}
+
+func TestLoadConfig_InvalidAddress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dns.yaml")
+	os.WriteFile(path, []byte("records:\n  myapp.test: localhost\n"), 0o644)
+
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), `"localhost" is not an IP address`) {
+		t.Errorf("Expected an invalid address error, got %v", err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]Config{
+		"at least one record":       {},
+		"upstream must be":          {Upstream: "1.1.1.1", Records: map[string]RecordSet{"a.test": {A: []string{"127.0.0.1"}}}},
+		"no records":                {Records: map[string]RecordSet{"a.test": {}}},
+		"duplicates":                {Records: map[string]RecordSet{"a.test": {A: []string{"127.0.0.1"}}, "A.test.": {A: []string{"127.0.0.1"}}}},
+		"only allowed as the first": {Records: map[string]RecordSet{"a.*.test": {A: []string{"127.0.0.1"}}}},
+		"labels must be":            {Records: map[string]RecordSet{"a..test": {A: []string{"127.0.0.1"}}}},
+		"cannot be combined":        {Records: map[string]RecordSet{"a.test": {CNAME: "b.test", A: []string{"127.0.0.1"}}}},
+		"invalid cname":             {Records: map[string]RecordSet{"a.test": {CNAME: "*.test"}}},
+		"invalid IPv4":              {Records: map[string]RecordSet{"a.test": {A: []string{"::1"}}}},
+		"invalid IPv6":              {Records: map[string]RecordSet{"a.test": {AAAA: []string{"127.0.0.1"}}}},
+		"invalid mx host":           {Records: map[string]RecordSet{"a.test": {MX: []MX{{}}}}},
+	}
+	for want, config := range tests {
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Record types
+const (
+	TypeA     uint16 = 1
+	TypeCNAME uint16 = 5
+	TypeMX    uint16 = 15
+	TypeTXT   uint16 = 16
+	TypeAAAA  uint16 = 28
+	TypeANY   uint16 = 255
+
+	classIN  uint16 = 1
+	classANY uint16 = 255
+)
+
+// Response codes
+const (
+	RcodeSuccess  = 0
+	RcodeFormErr  = 1
+	RcodeServFail = 2
+	RcodeNXDomain = 3
+	RcodeNotImp   = 4
+	RcodeRefused  = 5
+)
+
+// Header flag bits
+const (
+	flagQR = 1 << 15
+	flagAA = 1 << 10
+	flagTC = 1 << 9
+	flagRD = 1 << 8
+	flagRA = 1 << 7
+)
+
+const (
+	headerSize = 12
+
+	// maxUDPSize is the classic UDP payload limit; larger answers are
+	// truncated so the client retries over TCP
+	maxUDPSize = 512
+
+	// maxPointers bounds the compression pointers followed in one name
+	maxPointers = 16
+)
+
+var typeNames = map[uint16]string{
+	TypeA: "A", TypeCNAME: "CNAME", TypeMX: "MX", TypeTXT: "TXT", TypeAAAA: "AAAA", TypeANY: "ANY",
+}
+
+// TypeName returns the mnemonic of a record type, or TYPE<n>
+func TypeName(t uint16) string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("TYPE%d", t)
+}
+
+// Query is a parsed DNS query
+type Query struct {
+	ID uint16
+
+	// Opcode is 0 for standard queries
+	Opcode int
+
+	// RecursionDesired is echoed in the response
+	RecursionDesired bool
+
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+// Record is an answer resource record
+type Record struct {
+	Name string
+	Type uint16
+	TTL  uint32
+
+	// Data is the encoded record data
+	Data []byte
+}
+
+var (
+	errShortMessage = errors.New("message too short")
+
+	// errResponse is returned for responses, which are never answered so
+	// that two servers cannot loop
+	errResponse = errors.New("message is a response")
+)
+
+// ParseQuery decodes a query with a single question. When the header could
+// be read but not the rest, the returned query still carries its ID so a
+// FORMERR response can be sent.
+func ParseQuery(msg []byte) (*Query, error) {
+	if len(msg) < headerSize {
+		return nil, errShortMessage
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	query := &Query{
+		ID:               binary.BigEndian.Uint16(msg),
+		Opcode:           int(flags>>11) & 0xF,
+		RecursionDesired: flags&flagRD != 0,
+	}
+	if flags&flagQR != 0 {
+		return query, errResponse
+	}
+	if count := binary.BigEndian.Uint16(msg[4:]); count != 1 {
+		return query, fmt.Errorf("expected 1 question, got %d", count)
+	}
+
+	name, offset, err := readName(msg, headerSize)
+	if err != nil {
+		return query, err
+	}
+	if len(msg) < offset+4 {
+		return query, errShortMessage
+	}
+	query.Name = name
+	query.Type = binary.BigEndian.Uint16(msg[offset:])
+	query.Class = binary.BigEndian.Uint16(msg[offset+2:])
+	return query, nil
+}
+
+// readName decodes the possibly compressed name at offset and returns it
+// with the offset following it
+func readName(msg []byte, offset int) (string, int, error) {
+	var labels []string
+	end := -1
+	for pointers := 0; ; {
+		if offset >= len(msg) {
+			return "", 0, errShortMessage
+		}
+		length := int(msg[offset])
+		switch {
+		case length == 0:
+			if end < 0 {
+				end = offset + 1
+			}
+			return strings.Join(labels, "."), end, nil
+		case length&0xC0 == 0xC0:
+			if offset+1 >= len(msg) {
+				return "", 0, errShortMessage
+			}
+			if pointers++; pointers > maxPointers {
+				return "", 0, errors.New("too many compression pointers")
+			}
+			if end < 0 {
+				end = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(msg[offset:]) & 0x3FFF)
+		case length&0xC0 != 0:
+			return "", 0, fmt.Errorf("unsupported label type %#x", length&0xC0)
+		default:
+			if offset+1+length > len(msg) {
+				return "", 0, errShortMessage
+			}
+			labels = append(labels, string(msg[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+}
+
+// appendName encodes name uncompressed
+func appendName(b []byte, name string) []byte {
+	name = strings.TrimSuffix(name, ".")
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			b = append(b, byte(len(label)))
+			b = append(b, label...)
+		}
+	}
+	return append(b, 0)
+}
+
+// Response builds the response to query with the given code and answers.
+// Answers that would take the message past limit bytes are dropped and the
+// truncated flag set; limit 0 means no limit.
+func Response(query *Query, rcode int, answers []Record, authoritative, recursionAvailable bool, limit int) []byte {
+	flags := uint16(flagQR) | uint16(query.Opcode&0xF)<<11 | uint16(rcode&0xF)
+	if query.RecursionDesired {
+		flags |= flagRD
+	}
+	if authoritative {
+		flags |= flagAA
+	}
+	if recursionAvailable {
+		flags |= flagRA
+	}
+
+	msg := make([]byte, headerSize, maxUDPSize)
+	binary.BigEndian.PutUint16(msg, query.ID)
+	if query.Name != "" || query.Type != 0 {
+		binary.BigEndian.PutUint16(msg[4:], 1)
+		msg = appendName(msg, query.Name)
+		msg = binary.BigEndian.AppendUint16(msg, query.Type)
+		msg = binary.BigEndian.AppendUint16(msg, query.Class)
+	}
+
+	count := 0
+	for _, answer := range answers {
+		record := appendName(nil, answer.Name)
+		record = binary.BigEndian.AppendUint16(record, answer.Type)
+		record = binary.BigEndian.AppendUint16(record, classIN)
+		record = binary.BigEndian.AppendUint32(record, answer.TTL)
+		record = binary.BigEndian.AppendUint16(record, uint16(len(answer.Data)))
+		record = append(record, answer.Data...)
+		if limit > 0 && len(msg)+len(record) > limit {
+			flags |= flagTC
+			break
+		}
+		msg = append(msg, record...)
+		count++
+	}
+	binary.BigEndian.PutUint16(msg[2:], flags)
+	binary.BigEndian.PutUint16(msg[6:], uint16(count))
+	return msg
+}
+
+// Rcode returns the response code of a response message
+func Rcode(msg []byte) int {
+	if len(msg) < headerSize {
+		return -1
+	}
+	return int(msg[3] & 0xF)
+}
+
+// txtData encodes a TXT value as character strings of at most 255 bytes
+func txtData(value string) []byte {
+	var data []byte
+	for {
+		chunk := value
+		if len(chunk) > 255 {
+			chunk = chunk[:255]
+		}
+		data = append(data, byte(len(chunk)))
+		data = append(data, chunk...)
+		value = value[len(chunk):]
+		if value == "" {
+			return data
+		}
+	}
+}
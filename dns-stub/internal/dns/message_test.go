@@ -0,0 +1,109 @@
+package dns
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// buildQuery encodes a standard query with recursion desired
+func buildQuery(id uint16, name string, qtype uint16) []byte {
+	msg := make([]byte, headerSize)
+	binary.BigEndian.PutUint16(msg, id)
+	binary.BigEndian.PutUint16(msg[2:], flagRD)
+	binary.BigEndian.PutUint16(msg[4:], 1)
+	msg = appendName(msg, name)
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	return binary.BigEndian.AppendUint16(msg, classIN)
+}
+
+func TestParseQuery(t *testing.T) {
+	query, err := ParseQuery(buildQuery(0xBEEF, "api.myapp.test.", TypeAAAA))
+	if err != nil {
+		t.Fatalf("ParseQuery failed: %v", err)
+	}
+	if query.ID != 0xBEEF || !query.RecursionDesired || query.Name != "api.myapp.test" || query.Type != TypeAAAA || query.Class != classIN {
+		t.Errorf("Unexpected query %+v", query)
+	}
+}
+
+func TestParseQuery_Invalid(t *testing.T) {
+	valid := buildQuery(1, "a.test", TypeA)
+
+	if _, err := ParseQuery(valid[:5]); err != errShortMessage {
+		t.Errorf("Expected a short message error, got %v", err)
+	}
+	if query, err := ParseQuery(valid[:len(valid)-2]); query == nil || query.ID != 1 || err == nil {
+		t.Errorf("Expected the ID with an error for a truncated question, got %+v, %v", query, err)
+	}
+
+	response := append([]byte(nil), valid...)
+	response[2] |= flagQR >> 8
+	if _, err := ParseQuery(response); err != errResponse {
+		t.Errorf("Expected responses to be rejected, got %v", err)
+	}
+
+	// A name pointing at itself
+	looped := append(append([]byte(nil), valid[:headerSize]...), 0xC0, headerSize, 0, 1, 0, 1)
+	if _, err := ParseQuery(looped); err == nil || !strings.Contains(err.Error(), "compression pointers") {
+		t.Errorf("Expected a pointer loop to be rejected, got %v", err)
+	}
+}
+
+func TestReadName_Compressed(t *testing.T) {
+	// "myapp.test" at 0, then "api" followed by a pointer to it
+	msg := appendName(nil, "myapp.test")
+	msg = append(msg, 3, 'a', 'p', 'i', 0xC0, 0)
+
+	name, end, err := readName(msg, 12)
+	if err != nil || name != "api.myapp.test" || end != len(msg) {
+		t.Errorf("readName = %q, %d, %v; want api.myapp.test, %d", name, end, err, len(msg))
+	}
+}
+
+func TestResponse(t *testing.T) {
+	query, _ := ParseQuery(buildQuery(7, "a.test", TypeA))
+	answers := []Record{{Name: "a.test", Type: TypeA, TTL: 30, Data: []byte{127, 0, 0, 1}}}
+	msg := Response(query, RcodeSuccess, answers, true, false, maxUDPSize)
+
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if binary.BigEndian.Uint16(msg) != 7 || flags&flagQR == 0 || flags&flagAA == 0 || flags&flagRD == 0 || flags&flagRA != 0 {
+		t.Errorf("Unexpected header %x", msg[:headerSize])
+	}
+	if qd, an := binary.BigEndian.Uint16(msg[4:]), binary.BigEndian.Uint16(msg[6:]); qd != 1 || an != 1 {
+		t.Errorf("Expected 1 question and 1 answer, got %d and %d", qd, an)
+	}
+	if !strings.HasSuffix(string(msg), "\x00\x00\x00\x1e\x00\x04\x7f\x00\x00\x01") {
+		t.Errorf("Expected the answer's TTL and address at the end, got %x", msg)
+	}
+}
+
+func TestResponse_Truncated(t *testing.T) {
+	query, _ := ParseQuery(buildQuery(7, "a.test", TypeTXT))
+	var answers []Record
+	for i := 0; i < 10; i++ {
+		answers = append(answers, Record{Name: "a.test", Type: TypeTXT, Data: txtData(strings.Repeat("x", 100))})
+	}
+
+	msg := Response(query, RcodeSuccess, answers, true, false, maxUDPSize)
+	if len(msg) > maxUDPSize || binary.BigEndian.Uint16(msg[2:])&flagTC == 0 {
+		t.Errorf("Expected a truncated response within %d bytes, got %d bytes", maxUDPSize, len(msg))
+	}
+	if an := binary.BigEndian.Uint16(msg[6:]); an != 4 {
+		t.Errorf("Expected the 4 answers that fit, got %d", an)
+	}
+
+	if msg := Response(query, RcodeSuccess, answers, true, false, 0); binary.BigEndian.Uint16(msg[6:]) != 10 {
+		t.Errorf("Expected every answer without a limit")
+	}
+}
+
+func TestTXTData(t *testing.T) {
+	data := txtData(strings.Repeat("x", 300))
+	if len(data) != 302 || data[0] != 255 || data[256] != 45 {
+		t.Errorf("Expected 255 and 45 byte strings, got %d bytes", len(data))
+	}
+	if data := txtData(""); len(data) != 1 || data[0] != 0 {
+		t.Errorf("Expected one empty string, got %x", data)
+	}
+}
@@ -0,0 +1,259 @@
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// forwardTimeout bounds an upstream exchange
+	forwardTimeout = 3 * time.Second
+
+	// tcpIdleTimeout closes TCP connections without a query for this long
+	tcpIdleTimeout = 10 * time.Second
+)
+
+// Server answers queries from a zone over UDP and TCP
+type Server struct {
+	zone     *Zone
+	upstream string
+	logf     func(format string, args ...interface{})
+
+	mu      sync.Mutex
+	packets []net.PacketConn
+	streams []net.Listener
+	closed  bool
+}
+
+// NewServer creates a server answering from config's records. logf receives
+// one line per query and may be nil.
+func NewServer(config *Config, logf func(format string, args ...interface{})) *Server {
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+	return &Server{zone: NewZone(config), upstream: config.Upstream, logf: logf}
+}
+
+// ServeUDP answers queries on conn until Close is called
+func (s *Server) ServeUDP(conn net.PacketConn) error {
+	if !s.track(conn, nil) {
+		return net.ErrClosed
+	}
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if s.isClosed() {
+				return net.ErrClosed
+			}
+			return err
+		}
+		msg := append([]byte(nil), buf[:n]...)
+		go func() {
+			if response := s.Handle(msg, "udp"); response != nil {
+				conn.WriteTo(response, addr)
+			}
+		}()
+	}
+}
+
+// ServeTCP answers length-prefixed queries on l's connections until Close
+// is called
+func (s *Server) ServeTCP(l net.Listener) error {
+	if !s.track(nil, l) {
+		return net.ErrClosed
+	}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return net.ErrClosed
+			}
+			return err
+		}
+		go s.serveStream(conn)
+	}
+}
+
+func (s *Server) serveStream(conn net.Conn) {
+	defer conn.Close()
+	for {
+		conn.SetDeadline(time.Now().Add(tcpIdleTimeout))
+		msg, err := readStreamMessage(conn)
+		if err != nil {
+			return
+		}
+		response := s.Handle(msg, "tcp")
+		if response == nil {
+			return
+		}
+		if err := writeStreamMessage(conn, response); err != nil {
+			return
+		}
+	}
+}
+
+// Close stops serving
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for _, conn := range s.packets {
+		conn.Close()
+	}
+	for _, l := range s.streams {
+		l.Close()
+	}
+	return nil
+}
+
+func (s *Server) track(conn net.PacketConn, l net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	if conn != nil {
+		s.packets = append(s.packets, conn)
+	}
+	if l != nil {
+		s.streams = append(s.streams, l)
+	}
+	return true
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// Handle returns the response to a query received over network, "udp" or
+// "tcp", or nil when the message should be ignored
+func (s *Server) Handle(msg []byte, network string) []byte {
+	limit := 0
+	if network == "udp" {
+		limit = maxUDPSize
+	}
+	forwarding := s.upstream != ""
+
+	query, err := ParseQuery(msg)
+	if query == nil || errors.Is(err, errResponse) {
+		return nil
+	}
+	if err != nil {
+		s.logf("Malformed query: %v", err)
+		return Response(&Query{ID: query.ID, Opcode: query.Opcode}, RcodeFormErr, nil, false, forwarding, limit)
+	}
+	if query.Opcode != 0 {
+		return Response(query, RcodeNotImp, nil, false, forwarding, limit)
+	}
+	if query.Class != classIN && query.Class != classANY {
+		return Response(query, RcodeRefused, nil, false, forwarding, limit)
+	}
+
+	answers, found := s.zone.Lookup(query.Name, query.Type)
+	if found {
+		s.logf("%s %s → %s", TypeName(query.Type), query.Name, describe(answers))
+		return Response(query, RcodeSuccess, answers, true, forwarding, limit)
+	}
+
+	if !forwarding {
+		s.logf("%s %s → NXDOMAIN", TypeName(query.Type), query.Name)
+		return Response(query, RcodeNXDomain, nil, true, false, limit)
+	}
+	response, err := s.forward(msg, network)
+	if err != nil {
+		s.logf("%s %s → SERVFAIL: %v", TypeName(query.Type), query.Name, err)
+		return Response(query, RcodeServFail, nil, false, true, limit)
+	}
+	s.logf("%s %s → forwarded to %s (rcode %d)", TypeName(query.Type), query.Name, s.upstream, Rcode(response))
+	return response
+}
+
+// forward exchanges the query with the upstream resolver over the network
+// it arrived on, so truncated answers are retried over TCP by the client
+func (s *Server) forward(msg []byte, network string) ([]byte, error) {
+	conn, err := net.DialTimeout(network, s.upstream, forwardTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(forwardTimeout))
+
+	if network == "tcp" {
+		if err := writeStreamMessage(conn, msg); err != nil {
+			return nil, err
+		}
+		return readStreamMessage(conn)
+	}
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		// Ignore stray datagrams that do not answer this query
+		if n >= 2 && buf[0] == msg[0] && buf[1] == msg[1] {
+			return buf[:n], nil
+		}
+	}
+}
+
+func readStreamMessage(r io.Reader) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func writeStreamMessage(w io.Writer, msg []byte) error {
+	if len(msg) > 65535 {
+		return errors.New("message too long")
+	}
+	_, err := w.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(msg))), msg...))
+	return err
+}
+
+// describe formats answers for the query log
+func describe(answers []Record) string {
+	if len(answers) == 0 {
+		return "no records"
+	}
+	values := make([]string, len(answers))
+	for i, answer := range answers {
+		var value string
+		switch answer.Type {
+		case TypeA, TypeAAAA:
+			value = net.IP(answer.Data).String()
+		case TypeCNAME:
+			value, _, _ = readName(answer.Data, 0)
+		case TypeMX:
+			host, _, _ := readName(answer.Data, 2)
+			value = fmt.Sprintf("%d %s", binary.BigEndian.Uint16(answer.Data), host)
+		case TypeTXT:
+			var parts []string
+			for data := answer.Data; len(data) > 0 && len(data) > int(data[0]); data = data[1+int(data[0]):] {
+				parts = append(parts, string(data[1:1+int(data[0])]))
+			}
+			value = strconv.Quote(strings.Join(parts, ""))
+		}
+		values[i] = TypeName(answer.Type) + " " + value
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,134 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// startServer serves config on a loopback port over UDP and TCP
+func startServer(t *testing.T, config *Config) string {
+	t.Helper()
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	listener, err := net.Listen("tcp", packetConn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := NewServer(config, t.Logf)
+	go server.ServeUDP(packetConn)
+	go server.ServeTCP(listener)
+	t.Cleanup(func() { server.Close() })
+	return packetConn.LocalAddr().String()
+}
+
+// resolver sends queries to addr over the network they are dialled with
+func resolver(addr string, network string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+func TestServer_Resolve(t *testing.T) {
+	addr := startServer(t, &Config{Records: map[string]RecordSet{
+		"api.myapp.test": {A: []string{"127.0.0.1"}, AAAA: []string{"::1"}},
+		"*.myapp.test":   {A: []string{"127.0.0.2"}},
+		"www.myapp.test": {CNAME: "api.myapp.test"},
+	}})
+
+	for _, network := range []string{"udp", "tcp"} {
+		r := resolver(addr, network)
+		ctx := context.Background()
+
+		addrs, err := r.LookupHost(ctx, "www.myapp.test")
+		sort.Strings(addrs)
+		if err != nil || !reflect.DeepEqual(addrs, []string{"127.0.0.1", "::1"}) {
+			t.Errorf("%s: LookupHost(www.myapp.test) = %v, %v", network, addrs, err)
+		}
+		if addrs, err := r.LookupHost(ctx, "db.myapp.test"); err != nil || !reflect.DeepEqual(addrs, []string{"127.0.0.2"}) {
+			t.Errorf("%s: LookupHost(db.myapp.test) = %v, %v", network, addrs, err)
+		}
+
+		_, err = r.LookupHost(ctx, "other.test")
+		var dnsErr *net.DNSError
+		if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			t.Errorf("%s: Expected other.test not to be found, got %v", network, err)
+		}
+	}
+}
+
+func TestServer_Forward(t *testing.T) {
+	upstream := startServer(t, &Config{Records: map[string]RecordSet{"example.com": {A: []string{"192.0.2.1"}}}})
+	addr := startServer(t, &Config{
+		Upstream: upstream,
+		Records:  map[string]RecordSet{"myapp.test": {A: []string{"127.0.0.1"}}},
+	})
+
+	for _, network := range []string{"udp", "tcp"} {
+		addrs, err := resolver(addr, network).LookupHost(context.Background(), "example.com")
+		if err != nil || !reflect.DeepEqual(addrs, []string{"192.0.2.1"}) {
+			t.Errorf("%s: Expected example.com from upstream, got %v, %v", network, addrs, err)
+		}
+	}
+}
+
+func TestServer_ForwardFailure(t *testing.T) {
+	// Nothing listens on the upstream port, so the exchange fails
+	conn, _ := net.ListenPacket("udp", "127.0.0.1:0")
+	upstream := conn.LocalAddr().String()
+	conn.Close()
+
+	config := &Config{Upstream: upstream, Records: map[string]RecordSet{"myapp.test": {A: []string{"127.0.0.1"}}}}
+	config.Validate()
+	server := NewServer(config, nil)
+
+	response := server.Handle(buildQuery(9, "example.com", TypeA), "tcp")
+	if Rcode(response) != RcodeServFail || binary.BigEndian.Uint16(response) != 9 {
+		t.Errorf("Expected SERVFAIL, got rcode %d", Rcode(response))
+	}
+}
+
+func TestServer_Handle(t *testing.T) {
+	config := &Config{Records: map[string]RecordSet{"myapp.test": {A: []string{"127.0.0.1"}}}}
+	config.Validate()
+	server := NewServer(config, nil)
+
+	valid := buildQuery(3, "myapp.test", TypeA)
+	if response := server.Handle(valid[:4], "udp"); response != nil {
+		t.Errorf("Expected messages without a header to be ignored")
+	}
+
+	response := append([]byte(nil), valid...)
+	response[2] |= flagQR >> 8
+	if server.Handle(response, "udp") != nil {
+		t.Errorf("Expected responses to be ignored")
+	}
+
+	if rcode := Rcode(server.Handle(valid[:len(valid)-1], "udp")); rcode != RcodeFormErr {
+		t.Errorf("Expected FORMERR for a truncated question, got %d", rcode)
+	}
+
+	notify := append([]byte(nil), valid...)
+	notify[2] |= 4 << 3
+	if rcode := Rcode(server.Handle(notify, "udp")); rcode != RcodeNotImp {
+		t.Errorf("Expected NOTIMP for a NOTIFY, got %d", rcode)
+	}
+
+	chaos := append(valid[:len(valid)-2], 0, 3)
+	if rcode := Rcode(server.Handle(chaos, "udp")); rcode != RcodeRefused {
+		t.Errorf("Expected REFUSED for the CH class, got %d", rcode)
+	}
+}
@@ -0,0 +1,104 @@
+package dns
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+)
+
+// maxCNAMEChain bounds the CNAMEs followed within the zone for one answer
+const maxCNAMEChain = 8
+
+// Zone answers lookups from the configured records
+type Zone struct {
+	ttl       uint32
+	names     map[string]*RecordSet
+	wildcards map[string]*RecordSet
+}
+
+// NewZone indexes a validated config's records
+func NewZone(c *Config) *Zone {
+	z := &Zone{ttl: c.TTL, names: make(map[string]*RecordSet), wildcards: make(map[string]*RecordSet)}
+	for name, set := range c.Records {
+		set := set
+		name = normalizeName(name)
+		switch {
+		case name == "*":
+			z.wildcards[""] = &set
+		case strings.HasPrefix(name, "*."):
+			z.wildcards[name[2:]] = &set
+		default:
+			z.names[name] = &set
+		}
+	}
+	return z
+}
+
+// Lookup returns the answers for a name and type, and whether the zone has
+// records for the name at all. A name with records but none of the type
+// gets no answers; a CNAME is returned with any records of its target in
+// the zone.
+func (z *Zone) Lookup(name string, qtype uint16) ([]Record, bool) {
+	var answers []Record
+	owner := strings.TrimSuffix(name, ".")
+	for i := 0; i < maxCNAMEChain; i++ {
+		set := z.find(normalizeName(owner))
+		if set == nil {
+			// A CNAME pointing outside the zone is still an answer
+			return answers, i > 0
+		}
+		ttl := z.ttl
+		if set.TTL > 0 {
+			ttl = set.TTL
+		}
+
+		if set.CNAME != "" && qtype != TypeCNAME {
+			answers = append(answers, Record{Name: owner, Type: TypeCNAME, TTL: ttl, Data: appendName(nil, set.CNAME)})
+			owner = strings.TrimSuffix(set.CNAME, ".")
+			continue
+		}
+		return append(answers, set.records(owner, qtype, ttl)...), true
+	}
+	return answers, true
+}
+
+// find returns the records of a normalized name, falling back to the most
+// specific wildcard covering it
+func (z *Zone) find(name string) *RecordSet {
+	if set, ok := z.names[name]; ok {
+		return set
+	}
+	for parent := name; parent != ""; {
+		_, parent, _ = strings.Cut(parent, ".")
+		if set, ok := z.wildcards[parent]; ok {
+			return set
+		}
+	}
+	return nil
+}
+
+// records returns the set's records of a type, or all of them for ANY
+func (r *RecordSet) records(owner string, qtype uint16, ttl uint32) []Record {
+	var records []Record
+	add := func(t uint16, data []byte) {
+		if qtype == t || qtype == TypeANY {
+			records = append(records, Record{Name: owner, Type: t, TTL: ttl, Data: data})
+		}
+	}
+	if r.CNAME != "" {
+		add(TypeCNAME, appendName(nil, r.CNAME))
+	}
+	for _, address := range r.A {
+		add(TypeA, net.ParseIP(address).To4())
+	}
+	for _, address := range r.AAAA {
+		add(TypeAAAA, net.ParseIP(address).To16())
+	}
+	for _, mx := range r.MX {
+		add(TypeMX, appendName(binary.BigEndian.AppendUint16(nil, mx.Priority), mx.Host))
+	}
+	for _, value := range r.TXT {
+		add(TypeTXT, txtData(value))
+	}
+	return records
+}
@@ -0,0 +1,83 @@
+package dns
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testZone(t *testing.T) *Zone {
+	t.Helper()
+	config := &Config{Records: map[string]RecordSet{
+		"myapp.test":       {A: []string{"127.0.0.1"}},
+		"*.myapp.test":     {A: []string{"127.0.0.2"}, TTL: 60},
+		"*.api.myapp.test": {A: []string{"127.0.0.3"}},
+		"www.myapp.test":   {CNAME: "myapp.test"},
+		"ext.myapp.test":   {CNAME: "example.com."},
+		"loop.myapp.test":  {CNAME: "loop.myapp.test"},
+		"mail.myapp.test":  {MX: []MX{{Host: "localhost"}}, TXT: []string{"v=spf1 -all"}},
+	}}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	return NewZone(config)
+}
+
+func TestZone_Lookup(t *testing.T) {
+	zone := testZone(t)
+	tests := []struct {
+		name  string
+		qtype uint16
+		want  string
+	}{
+		{"myapp.test", TypeA, "A 127.0.0.1"},
+		{"MyApp.Test.", TypeA, "A 127.0.0.1"},
+		{"anything.myapp.test", TypeA, "A 127.0.0.2"},
+		{"a.b.c.myapp.test", TypeA, "A 127.0.0.2"},
+		// The most specific wildcard wins
+		{"v1.api.myapp.test", TypeA, "A 127.0.0.3"},
+		{"myapp.test", TypeAAAA, "no records"},
+		{"www.myapp.test", TypeA, "CNAME myapp.test, A 127.0.0.1"},
+		{"www.myapp.test", TypeCNAME, "CNAME myapp.test"},
+		{"ext.myapp.test", TypeA, "CNAME example.com"},
+		{"mail.myapp.test", TypeANY, `MX 10 localhost, TXT "v=spf1 -all"`},
+	}
+	for _, test := range tests {
+		answers, found := zone.Lookup(test.name, test.qtype)
+		if got := describe(answers); !found || got != test.want {
+			t.Errorf("Lookup(%s, %s) = %q, %v; want %q", test.name, TypeName(test.qtype), got, found, test.want)
+		}
+	}
+
+	if answers, found := zone.Lookup("other.test", TypeA); found || answers != nil {
+		t.Errorf("Expected other.test to be unknown, got %v", answers)
+	}
+	if answers, found := zone.Lookup("loop.myapp.test", TypeA); !found || len(answers) != maxCNAMEChain {
+		t.Errorf("Expected a CNAME loop to stop after %d answers, got %d", maxCNAMEChain, len(answers))
+	}
+}
+
+func TestZone_LookupOwnerAndTTL(t *testing.T) {
+	zone := testZone(t)
+	answers, _ := zone.Lookup("X.myapp.test.", TypeA)
+	want := []Record{{Name: "X.myapp.test", Type: TypeA, TTL: 60, Data: []byte{127, 0, 0, 2}}}
+	if !reflect.DeepEqual(answers, want) {
+		t.Errorf("Expected the queried name and the set's TTL, got %+v", answers)
+	}
+
+	answers, _ = zone.Lookup("myapp.test", TypeA)
+	if answers[0].TTL != defaultTTL {
+		t.Errorf("Expected the default TTL, got %d", answers[0].TTL)
+	}
+}
+
+func TestZone_CatchAllWildcard(t *testing.T) {
+	config := &Config{Records: map[string]RecordSet{"*": {A: []string{"127.0.0.1"}}}}
+	config.Validate()
+	zone := NewZone(config)
+
+	for _, name := range []string{"localhost", "a.b.example.com"} {
+		if answers, found := zone.Lookup(name, TypeA); !found || describe(answers) != "A 127.0.0.1" {
+			t.Errorf("Expected * to match %s, got %v", name, answers)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"local-dev-tools/dns-stub/internal/dns"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run answers DNS queries until interrupted and returns the process exit
+// code
+func run() int {
+	configPath := flag.String("config", "dns.yaml", "Path to the records file (YAML or JSON)")
+	addr := flag.String("addr", "127.0.0.1:5353", "Address to serve DNS on, over UDP and TCP")
+	upstream := flag.String("upstream", "", "Resolver (host:port) to forward names without records to, overriding the config")
+	quiet := flag.Bool("quiet", false, "Do not log each query")
+	flag.Parse()
+
+	config, err := dns.LoadConfig(*configPath)
+	if err != nil {
+		log.Printf("Error loading config: %v", err)
+		return 2
+	}
+	if *upstream != "" {
+		config.Upstream = *upstream
+		if err := config.Validate(); err != nil {
+			log.Printf("Error: %v", err)
+			return 2
+		}
+	}
+
+	logf := log.Printf
+	if *quiet {
+		logf = nil
+	}
+	server := dns.NewServer(config, logf)
+
+	packetConn, err := net.ListenPacket("udp", *addr)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 3
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		packetConn.Close()
+		return 3
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		fmt.Println("\nShutting down...")
+		server.Close()
+	}()
+
+	errs := make(chan error, 2)
+	go func() { errs <- server.ServeUDP(packetConn) }()
+	go func() { errs <- server.ServeTCP(listener) }()
+
+	forwarding := "answering NXDOMAIN for other names"
+	if config.Upstream != "" {
+		forwarding = "forwarding other names to " + config.Upstream
+	}
+	fmt.Printf("Serving %d names from %s on %s, %s\n", len(config.Records), *configPath, *addr, forwarding)
+
+	// Both stop on shutdown; either failing earlier is fatal
+	code := 0
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil && !errors.Is(err, net.ErrClosed) {
+			log.Printf("Server error: %v", err)
+			code = 3
+			server.Close()
+		}
+	}
+	return code
+}
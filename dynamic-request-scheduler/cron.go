@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/cronexpr"
+)
+
+// runCronCommand implements the `cron` subcommand: `cron explain` describes
+// an expression and lists its next fire times, and `cron test` checks
+// whether it fires at given times, exiting 1 when any of them miss
+func runCronCommand(args []string) int {
+	if len(args) == 0 {
+		log.Printf("cron requires a command: explain or test")
+		return exitConfigError
+	}
+
+	switch args[0] {
+	case "explain":
+		return runCronExplain(args[1:])
+	case "test":
+		return runCronTest(args[1:])
+	default:
+		log.Printf("Unknown cron command %q (expected explain or test)", args[0])
+		return exitConfigError
+	}
+}
+
+func runCronExplain(args []string) int {
+	fs := flag.NewFlagSet("cron explain", flag.ExitOnError)
+	next := fs.Int("next", 5, "Number of upcoming fire times to show")
+	zone := fs.String("tz", "", "Time zone the expression fires in, unless it sets CRON_TZ (default local)")
+	from := fs.String("from", "", "List fire times after this time instead of now (RFC 3339, e.g. 2025-03-01T09:00:00Z)")
+	fs.Parse(args)
+	if err := applyEnvDefaults(fs); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	if fs.NArg() != 1 {
+		log.Printf("cron explain takes one quoted expression, e.g. drs cron explain \"*/5 9-17 * * 1-5\"")
+		return exitConfigError
+	}
+	expr, err := cronexpr.ParseInZone(fs.Arg(0), *zone)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	now := time.Now()
+	if *from != "" {
+		if now, err = parseAt(*from); err != nil {
+			log.Printf("Error parsing --from: %v", err)
+			return exitConfigError
+		}
+	}
+	if *next < 1 {
+		*next = 1
+	}
+
+	expr.Report(os.Stdout, now, *next)
+	return exitOK
+}
+
+func runCronTest(args []string) int {
+	fs := flag.NewFlagSet("cron test", flag.ExitOnError)
+	zone := fs.String("tz", "", "Time zone the expression fires in, unless it sets CRON_TZ (default local)")
+	fs.Parse(args)
+	if err := applyEnvDefaults(fs); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	if fs.NArg() < 2 {
+		log.Printf("cron test takes an expression and one or more times, e.g. drs cron test \"0 9 * * 1-5\" 2025-03-03T09:00")
+		return exitConfigError
+	}
+	expr, err := cronexpr.ParseInZone(fs.Arg(0), *zone)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	code := exitOK
+	for _, value := range fs.Args()[1:] {
+		t, err := parseAt(value)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitConfigError
+		}
+
+		shown := t.In(expr.Location).Format("Mon 2006-01-02 15:04 MST")
+		reasons := expr.Check(t)
+		if reasons == nil {
+			fmt.Printf("FIRES  %s\n", shown)
+			continue
+		}
+		code = exitFailure
+		fmt.Printf("MISSES %s\n", shown)
+		for _, reason := range reasons {
+			fmt.Printf("       %s\n", reason)
+		}
+	}
+	return code
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/daemon"
+)
+
+// defaultPIDFile is where --daemon, stop and status look for the background scheduler
+const defaultPIDFile = "drs.pid"
+
+// runStatusCommand implements the `status` subcommand, reporting whether a
+// background scheduler is running. It exits 1 when it is not.
+func runStatusCommand(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	pidFile := fs.String("pid-file", defaultPIDFile, "Path to the pid file written by --daemon")
+	fs.Parse(args)
+	if err := applyEnvDefaults(fs); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	pid, running, err := daemon.Status(*pidFile)
+	switch {
+	case err != nil:
+		log.Printf("Error reading pid file: %v", err)
+		return exitRuntimeError
+	case running:
+		fmt.Printf("Scheduler is running (pid %d)\n", pid)
+		return exitOK
+	case pid != 0:
+		fmt.Printf("Scheduler is not running (stale pid file %s for pid %d)\n", *pidFile, pid)
+		return exitFailure
+	default:
+		fmt.Println("Scheduler is not running")
+		return exitFailure
+	}
+}
+
+// runStopCommand implements the `stop` subcommand, shutting down a background
+// scheduler gracefully so it writes its summary to the log file
+func runStopCommand(args []string) int {
+	fs := flag.NewFlagSet("stop", flag.ExitOnError)
+	pidFile := fs.String("pid-file", defaultPIDFile, "Path to the pid file written by --daemon")
+	timeout := fs.Duration("timeout", 30*time.Second, "How long to wait for the scheduler to exit")
+	fs.Parse(args)
+	if err := applyEnvDefaults(fs); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	pid, err := daemon.Stop(*pidFile, *timeout)
+	if errors.Is(err, daemon.ErrNotRunning) {
+		fmt.Println("Scheduler is not running")
+		return exitFailure
+	}
+	if err != nil {
+		log.Printf("Error stopping scheduler: %v", err)
+		return exitRuntimeError
+	}
+	fmt.Printf("Stopped scheduler (pid %d)\n", pid)
+	return exitOK
+}
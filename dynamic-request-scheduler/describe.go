@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/grpc"
+)
+
+// runDescribeCommand implements the `describe` subcommand, which lists the
+// services and methods of a gRPC server through server reflection, or shows
+// one service, method or message with the types it uses
+func runDescribeCommand(args []string) int {
+	fs := flag.NewFlagSet("describe", flag.ExitOnError)
+	useTLS := fs.Bool("tls", false, "Connect over TLS instead of cleartext HTTP/2")
+	timeout := fs.Duration("timeout", 10*time.Second, "Time allowed for the server to answer")
+	fs.Parse(args)
+	if err := applyEnvDefaults(fs); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		log.Printf("describe takes a server address and optionally a service, method or message, e.g. drs describe localhost:50051 orders.v1.Orders/GetOrder")
+		return exitConfigError
+	}
+	client := grpc.NewClient(*timeout)
+	target := grpc.Target{Address: fs.Arg(0), TLS: *useTLS}
+
+	if fs.NArg() == 1 {
+		services, err := client.Services(context.Background(), target)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitRuntimeError
+		}
+		if len(services) == 0 {
+			fmt.Println("The server offers no services besides reflection")
+		}
+		for i, service := range services {
+			if i > 0 {
+				fmt.Println()
+			}
+			service.Report(os.Stdout)
+		}
+		return exitOK
+	}
+
+	symbol, err := client.Describe(context.Background(), target, fs.Arg(1))
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+	symbol.Report(os.Stdout)
+	return exitOK
+}
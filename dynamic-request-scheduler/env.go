@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envPrefix is prepended to flag names to form their environment variables
+const envPrefix = "DRS_"
+
+// envName returns the environment variable for a flag, e.g. DRS_METRICS_ADDR for --metrics-addr
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// explicitFlags returns the names of the flags given on the command line.
+// Call it before applying defaults, which mark the flags they set as given.
+func explicitFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
+
+// applyEnvDefaults sets every flag not given on the command line from its
+// DRS_* environment variable, so command line flags always take precedence
+func applyEnvDefaults(fs *flag.FlagSet) error {
+	set := explicitFlags(fs)
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if set[f.Name] || err != nil {
+			return
+		}
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value %q for %s: %w", value, envName(f.Name), setErr)
+		}
+	})
+	return err
+}
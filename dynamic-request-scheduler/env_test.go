@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testFlags returns a flag set with one flag of each kind the scheduler uses
+func testFlags() (*flag.FlagSet, *int, *bool, *time.Duration, *string) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	concurrency := fs.Int("concurrency", 10, "")
+	noColor := fs.Bool("no-color", false, "")
+	timeout := fs.Duration("timeout", 30*time.Second, "")
+	metricsAddr := fs.String("metrics-addr", "", "")
+	return fs, concurrency, noColor, timeout, metricsAddr
+}
+
+func TestEnvName(t *testing.T) {
+	if got := envName("metrics-addr"); got != "DRS_METRICS_ADDR" {
+		t.Errorf("envName(metrics-addr) = %s, want DRS_METRICS_ADDR", got)
+	}
+}
+
+func TestApplyEnvDefaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		env         map[string]string
+		concurrency int
+		noColor     bool
+		timeout     time.Duration
+		metricsAddr string
+		wantErr     string
+	}{
+		{
+			name:        "defaults without env",
+			concurrency: 10,
+			timeout:     30 * time.Second,
+		},
+		{
+			name:        "env overrides defaults",
+			env:         map[string]string{"DRS_CONCURRENCY": "20", "DRS_METRICS_ADDR": ":9090"},
+			concurrency: 20,
+			timeout:     30 * time.Second,
+			metricsAddr: ":9090",
+		},
+		{
+			name:        "explicit flags beat env",
+			args:        []string{"--concurrency", "5", "--metrics-addr", ":8080"},
+			env:         map[string]string{"DRS_CONCURRENCY": "20", "DRS_METRICS_ADDR": ":9090"},
+			concurrency: 5,
+			timeout:     30 * time.Second,
+			metricsAddr: ":8080",
+		},
+		{
+			name:        "bool and duration parsing",
+			env:         map[string]string{"DRS_NO_COLOR": "1", "DRS_TIMEOUT": "1m30s"},
+			concurrency: 10,
+			noColor:     true,
+			timeout:     90 * time.Second,
+		},
+		{
+			name:        "explicit false beats env true",
+			args:        []string{"--no-color=false"},
+			env:         map[string]string{"DRS_NO_COLOR": "true"},
+			concurrency: 10,
+			timeout:     30 * time.Second,
+		},
+		{
+			name:    "invalid int",
+			env:     map[string]string{"DRS_CONCURRENCY": "lots"},
+			wantErr: `invalid value "lots" for DRS_CONCURRENCY`,
+		},
+		{
+			name:    "invalid bool",
+			env:     map[string]string{"DRS_NO_COLOR": "sometimes"},
+			wantErr: `invalid value "sometimes" for DRS_NO_COLOR`,
+		},
+		{
+			name:    "invalid duration",
+			env:     map[string]string{"DRS_TIMEOUT": "30"},
+			wantErr: `invalid value "30" for DRS_TIMEOUT`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			fs, concurrency, noColor, timeout, metricsAddr := testFlags()
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+
+			err := applyEnvDefaults(fs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyEnvDefaults failed: %v", err)
+			}
+
+			if *concurrency != tt.concurrency || *noColor != tt.noColor || *timeout != tt.timeout || *metricsAddr != tt.metricsAddr {
+				t.Errorf("Got concurrency=%d no-color=%v timeout=%v metrics-addr=%q, want %d %v %v %q",
+					*concurrency, *noColor, *timeout, *metricsAddr, tt.concurrency, tt.noColor, tt.timeout, tt.metricsAddr)
+			}
+		})
+	}
+}
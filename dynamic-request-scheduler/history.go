@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/history"
+)
+
+// runHistoryCommand implements the `history` subcommand for querying past executions
+func runHistoryCommand(args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	dbPath := fs.String("db", "drs-history.db", "Path to SQLite history database")
+	name := fs.String("name", "", "Only show executions of the named request")
+	runID := fs.String("run", "", "Only show executions from the given run ID")
+	failed := fs.Bool("failed", false, "Only show failed executions")
+	since := fs.Duration("since", 0, "Only show executions started within this duration (e.g. 1h)")
+	limit := fs.Int("limit", 20, "Maximum number of executions to show (0 for all)")
+	fs.Parse(args)
+	if err := applyEnvDefaults(fs); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	if _, err := os.Stat(*dbPath); err != nil {
+		log.Fatalf("History database not found: %v", err)
+	}
+
+	store, err := history.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("Error opening history database: %v", err)
+	}
+	defer store.Close()
+
+	filter := history.Filter{
+		Name:       *name,
+		RunID:      *runID,
+		FailedOnly: *failed,
+		Limit:      *limit,
+	}
+	if *since > 0 {
+		filter.Since = time.Now().Add(-*since)
+	}
+
+	entries, err := store.Query(filter)
+	if err != nil {
+		log.Fatalf("Error querying history: %v", err)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No executions found")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STARTED\tEXECUTION\tNAME\tMETHOD\tURL\tSTATUS\tDURATION\tERROR")
+	for _, entry := range entries {
+		status := "-"
+		if entry.StatusCode != 0 {
+			status = fmt.Sprintf("%d", entry.StatusCode)
+		}
+		problem := entry.Error
+		if problem == "" && len(entry.Unmet) > 0 {
+			problem = "unmet expectation: " + strings.Join(entry.Unmet, "; ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%v\t%s\n",
+			entry.StartedAt.Local().Format(time.RFC3339),
+			orDash(entry.ExecutionID),
+			entry.RequestName,
+			entry.Method,
+			entry.URL,
+			status,
+			entry.Duration.Round(time.Millisecond),
+			problem,
+		)
+	}
+	w.Flush()
+}
+
+// orDash renders empty values as "-" in tabular output
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
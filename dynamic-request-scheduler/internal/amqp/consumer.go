@@ -0,0 +1,247 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Classes and methods used by the consumer
+const (
+	classQueue = 50
+
+	methodQueuePurge   = 30
+	methodQueuePurgeOk = 31
+
+	methodBasicGet      = 70
+	methodBasicGetOk    = 71
+	methodBasicGetEmpty = 72
+)
+
+// Delivery is a message taken from a queue
+type Delivery struct {
+	Exchange    string
+	RoutingKey  string
+	Redelivered bool
+
+	// Remaining is how many messages the queue held after this one
+	Remaining int
+
+	Properties Properties
+	Body       []byte
+
+	tag uint64
+}
+
+// Consumer takes messages from one queue. Messages stay unacknowledged, and
+// so hidden from other consumers, until Ack removes them or Close returns
+// them to the queue.
+type Consumer struct {
+	client *Client
+	conn   *connection
+	queue  string
+}
+
+// NewConsumer connects to the broker at rawURL to take messages from queue
+func (c *Client) NewConsumer(ctx context.Context, rawURL, queue string) (*Consumer, error) {
+	conn, err := c.connect(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Consumer{client: c, conn: conn, queue: queue}, nil
+}
+
+// Get takes the next message, returning nil when the queue is empty
+func (r *Consumer) Get(ctx context.Context) (*Delivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(r.client.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	r.conn.SetDeadline(deadline)
+
+	get := method(classBasic, methodBasicGet)
+	get.short(0)
+	get.shortstr(r.queue)
+	get.octet(0) // acknowledged explicitly
+	if err := r.conn.send(publishChannel, get); err != nil {
+		return nil, err
+	}
+
+	f, d, err := r.conn.next()
+	if err != nil {
+		return nil, err
+	}
+	if f.kind != frameMethod {
+		return nil, fmt.Errorf("unexpected frame type %d from broker", f.kind)
+	}
+	class, id := d.short(), d.short()
+	switch {
+	case class == classBasic && id == methodBasicGetEmpty:
+		return nil, nil
+	case class == classBasic && id == methodBasicGetOk:
+	default:
+		if err := r.conn.closed(f.channel, class, id, d); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("unexpected method %d.%d from broker", class, id)
+	}
+
+	delivery := &Delivery{tag: d.longlong(), Redelivered: d.octet()&1 != 0}
+	delivery.Exchange = d.shortstr()
+	delivery.RoutingKey = d.shortstr()
+	delivery.Remaining = int(d.long())
+	if d.err != nil {
+		return nil, d.err
+	}
+	if err := r.conn.readContent(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Ack removes a message taken by Get from the queue
+func (r *Consumer) Ack(delivery *Delivery) error {
+	ack := method(classBasic, methodBasicAck)
+	ack.longlong(delivery.tag)
+	ack.octet(0)
+	return r.conn.send(publishChannel, ack)
+}
+
+// Close closes the connection, which returns every message that was not
+// acknowledged to the queue, marked as redelivered
+func (r *Consumer) Close() error {
+	r.conn.SetDeadline(time.Now().Add(r.client.timeout))
+	r.conn.close()
+	return r.conn.Close()
+}
+
+// Purge removes every ready message from queue and returns how many there
+// were. Messages held unacknowledged by consumers are not removed.
+func (c *Client) Purge(ctx context.Context, rawURL, queue string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	conn, err := c.connect(ctx, rawURL)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	defer conn.close()
+
+	purge := method(classQueue, methodQueuePurge)
+	purge.short(0)
+	purge.shortstr(queue)
+	purge.octet(0)
+	if err := conn.send(publishChannel, purge); err != nil {
+		return 0, err
+	}
+	d, err := conn.expect(publishChannel, classQueue, methodQueuePurgeOk)
+	if err != nil {
+		return 0, err
+	}
+	count := d.long()
+	return int(count), d.err
+}
+
+// readContent reads the content header and body frames that follow a
+// delivery
+func (c *connection) readContent(delivery *Delivery) error {
+	f, d, err := c.next()
+	if err != nil {
+		return err
+	}
+	if f.kind != frameHeader {
+		return fmt.Errorf("unexpected frame type %d from broker (want content header)", f.kind)
+	}
+	d.short() // class
+	d.short() // weight
+	size := d.longlong()
+	delivery.Properties = decodeProperties(d)
+	if d.err != nil {
+		return d.err
+	}
+
+	delivery.Body = make([]byte, 0, min(size, maxFrameSize))
+	for uint64(len(delivery.Body)) < size {
+		f, _, err := c.next()
+		if err != nil {
+			return err
+		}
+		if f.kind != frameBody {
+			return fmt.Errorf("unexpected frame type %d from broker (want content body)", f.kind)
+		}
+		delivery.Body = append(delivery.Body, f.payload...)
+	}
+	return nil
+}
+
+// decodeProperties reads the property flags and the properties they mark,
+// the reverse of Properties.encode. Header values are formatted as text.
+func decodeProperties(d *decoder) Properties {
+	var p Properties
+	flags := d.short()
+	if flags&(1<<15) != 0 {
+		p.ContentType = d.shortstr()
+	}
+	if flags&(1<<14) != 0 {
+		p.ContentEncoding = d.shortstr()
+	}
+	if flags&(1<<13) != 0 {
+		p.Headers = make(map[string]string)
+		for name, value := range d.table() {
+			p.Headers[name] = formatField(value)
+		}
+	}
+	if flags&(1<<12) != 0 {
+		p.DeliveryMode = d.octet()
+	}
+	if flags&(1<<11) != 0 {
+		priority := d.octet()
+		p.Priority = &priority
+	}
+	if flags&(1<<10) != 0 {
+		p.CorrelationID = d.shortstr()
+	}
+	if flags&(1<<9) != 0 {
+		p.ReplyTo = d.shortstr()
+	}
+	if flags&(1<<8) != 0 {
+		p.Expiration = d.shortstr()
+	}
+	if flags&(1<<7) != 0 {
+		p.MessageID = d.shortstr()
+	}
+	if flags&(1<<6) != 0 {
+		p.Timestamp = time.Unix(int64(d.longlong()), 0).UTC()
+	}
+	if flags&(1<<5) != 0 {
+		p.Type = d.shortstr()
+	}
+	if flags&(1<<4) != 0 {
+		p.UserID = d.shortstr()
+	}
+	if flags&(1<<3) != 0 {
+		p.AppID = d.shortstr()
+	}
+	if flags&(1<<2) != 0 {
+		d.shortstr() // cluster ID, deprecated
+	}
+	return p
+}
+
+// formatField renders a header value as text
+func formatField(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
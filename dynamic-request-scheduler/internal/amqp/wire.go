@@ -0,0 +1,256 @@
+package amqp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// Frame types and the end marker that terminates every frame
+const (
+	frameMethod    = 1
+	frameHeader    = 2
+	frameBody      = 3
+	frameHeartbeat = 8
+	frameEnd       = 0xCE
+)
+
+// frameOverhead is the size of a frame's header and end marker
+const frameOverhead = 8
+
+// errShortRead is returned when a frame ends before a field is complete
+var errShortRead = errors.New("amqp: truncated frame")
+
+// encoder builds AMQP 0-9-1 method arguments and content headers
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) octet(v uint8) {
+	e.WriteByte(v)
+}
+
+func (e *encoder) short(v uint16) {
+	e.Write(binary.BigEndian.AppendUint16(nil, v))
+}
+
+func (e *encoder) long(v uint32) {
+	e.Write(binary.BigEndian.AppendUint32(nil, v))
+}
+
+func (e *encoder) longlong(v uint64) {
+	e.Write(binary.BigEndian.AppendUint64(nil, v))
+}
+
+// shortstr writes a string of at most 255 bytes with an octet length
+func (e *encoder) shortstr(s string) {
+	if len(s) > 255 {
+		s = s[:255]
+	}
+	e.octet(uint8(len(s)))
+	e.WriteString(s)
+}
+
+func (e *encoder) longstr(s string) {
+	e.long(uint32(len(s)))
+	e.WriteString(s)
+}
+
+// table writes a field table holding string values, in key order
+func (e *encoder) table(fields map[string]string) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var t encoder
+	for _, key := range keys {
+		t.shortstr(key)
+		t.octet('S')
+		t.longstr(fields[key])
+	}
+	e.long(uint32(t.Len()))
+	e.Write(t.Bytes())
+}
+
+// method starts a method frame payload for class and method
+func method(class, id uint16) *encoder {
+	e := &encoder{}
+	e.short(class)
+	e.short(id)
+	return e
+}
+
+// decoder reads method arguments. The first error is kept and every later
+// read returns a zero value, so callers check err once.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.buf) < n {
+		d.err = errShortRead
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) octet() uint8 {
+	if b := d.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) short() uint16 {
+	if b := d.take(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) long() uint32 {
+	if b := d.take(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) longlong() uint64 {
+	if b := d.take(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) shortstr() string {
+	return string(d.take(int(d.octet())))
+}
+
+func (d *decoder) longstr() string {
+	return string(d.take(int(d.long())))
+}
+
+// skipTable skips a field table whose contents the client does not need
+func (d *decoder) skipTable() {
+	d.take(int(d.long()))
+}
+
+// table reads a field table such as message headers
+func (d *decoder) table() map[string]interface{} {
+	t := &decoder{buf: d.take(int(d.long()))}
+	fields := make(map[string]interface{})
+	for len(t.buf) > 0 && t.err == nil {
+		name := t.shortstr()
+		fields[name] = t.field()
+	}
+	if t.err != nil && d.err == nil {
+		d.err = t.err
+	}
+	return fields
+}
+
+// field reads a typed field value, using RabbitMQ's type codes where they
+// differ from the specification
+func (d *decoder) field() interface{} {
+	switch kind := d.octet(); kind {
+	case 't':
+		return d.octet() != 0
+	case 'b':
+		return int8(d.octet())
+	case 'B':
+		return d.octet()
+	case 's':
+		return int16(d.short())
+	case 'u':
+		return d.short()
+	case 'I':
+		return int32(d.long())
+	case 'i':
+		return d.long()
+	case 'l':
+		return int64(d.longlong())
+	case 'f':
+		return math.Float32frombits(d.long())
+	case 'd':
+		return math.Float64frombits(d.longlong())
+	case 'D':
+		scale := d.octet()
+		return float64(int32(d.long())) / math.Pow10(int(scale))
+	case 'S', 'x':
+		return d.longstr()
+	case 'A':
+		a := &decoder{buf: d.take(int(d.long()))}
+		var values []interface{}
+		for len(a.buf) > 0 && a.err == nil {
+			values = append(values, a.field())
+		}
+		if a.err != nil && d.err == nil {
+			d.err = a.err
+		}
+		return values
+	case 'T':
+		return time.Unix(int64(d.longlong()), 0).UTC()
+	case 'F':
+		return d.table()
+	case 'V':
+		return nil
+	default:
+		if d.err == nil {
+			d.err = fmt.Errorf("amqp: unsupported field type %q", kind)
+		}
+		return nil
+	}
+}
+
+// frame is a single frame read from the connection
+type frame struct {
+	kind    uint8
+	channel uint16
+	payload []byte
+}
+
+// writeFrame sends one frame
+func writeFrame(w io.Writer, kind uint8, channel uint16, payload []byte) error {
+	buf := make([]byte, 0, len(payload)+frameOverhead)
+	buf = append(buf, kind)
+	buf = binary.BigEndian.AppendUint16(buf, channel)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(payload)))
+	buf = append(buf, payload...)
+	buf = append(buf, frameEnd)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readFrame reads one frame, rejecting frames larger than max
+func readFrame(r io.Reader, max uint32) (frame, error) {
+	var header [7]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return frame{}, err
+	}
+	size := binary.BigEndian.Uint32(header[3:])
+	if size > max {
+		return frame{}, fmt.Errorf("amqp: frame of %d bytes exceeds limit %d", size, max)
+	}
+
+	payload := make([]byte, size+1)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return frame{}, err
+	}
+	if payload[size] != frameEnd {
+		return frame{}, errors.New("amqp: malformed frame end")
+	}
+	return frame{kind: header[0], channel: binary.BigEndian.Uint16(header[1:]), payload: payload[:size]}, nil
+}
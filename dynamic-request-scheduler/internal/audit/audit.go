@@ -0,0 +1,168 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+// Control actions recorded in the audit log
+const (
+	ActionPause   = "pause"
+	ActionResume  = "resume"
+	ActionTrigger = "trigger"
+	ActionStop    = "stop"
+)
+
+// Entry is a single audit log record
+type Entry struct {
+	Time    time.Time `json:"time"`
+	RunID   string    `json:"run_id,omitempty"`
+	Actor   string    `json:"actor"`
+	Source  string    `json:"source"`
+	Action  string    `json:"action"`
+	Request string    `json:"request,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Log is an append-only JSONL file of control actions
+type Log struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Open opens path for appending, creating it if needed
+func Open(path string) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Log{file: file}, nil
+}
+
+// Write appends an entry as a single JSON line
+func (l *Log) Write(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// CurrentActor identifies the local user driving this process as user@host
+func CurrentActor() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name = u.Username
+	}
+	if name == "" {
+		name = "unknown"
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return name + "@" + host
+	}
+	return name
+}
+
+// Target is the scheduler control surface being audited
+type Target interface {
+	Statuses() []engine.RequestStatus
+	Pressure() engine.Pressure
+	Recent(name string) ([]engine.RecentExecution, error)
+	Pause(name string) error
+	Resume(name string) error
+	IsPaused(name string) bool
+	Trigger(name string) error
+	Stop()
+}
+
+// Controller wraps a Target, writing an audit entry for every control action
+type Controller struct {
+	Target
+
+	log    *Log
+	runID  string
+	actor  string
+	source string
+	now    func() time.Time
+	errLog func(format string, args ...interface{})
+}
+
+// NewController audits actions performed on target by actor through source
+// (for example "tui"). Failures to write the log are reported through logf.
+func NewController(target Target, log *Log, runID, actor, source string, logf func(format string, args ...interface{})) *Controller {
+	return &Controller{
+		Target: target,
+		log:    log,
+		runID:  runID,
+		actor:  actor,
+		source: source,
+		now:    time.Now,
+		errLog: logf,
+	}
+}
+
+// Pause pauses a request and records the action
+func (c *Controller) Pause(name string) error {
+	err := c.Target.Pause(name)
+	c.write(ActionPause, name, err)
+	return err
+}
+
+// Resume resumes a request and records the action
+func (c *Controller) Resume(name string) error {
+	err := c.Target.Resume(name)
+	c.write(ActionResume, name, err)
+	return err
+}
+
+// Trigger runs a request immediately and records the action
+func (c *Controller) Trigger(name string) error {
+	err := c.Target.Trigger(name)
+	c.write(ActionTrigger, name, err)
+	return err
+}
+
+// Stop stops the scheduler and records the action
+func (c *Controller) Stop() {
+	c.write(ActionStop, "", nil)
+	c.Target.Stop()
+}
+
+// write appends an entry for an action, reporting log failures without
+// interrupting the action itself
+func (c *Controller) write(action, request string, actionErr error) {
+	entry := Entry{
+		Time:    c.now().UTC(),
+		RunID:   c.runID,
+		Actor:   c.actor,
+		Source:  c.source,
+		Action:  action,
+		Request: request,
+	}
+	if actionErr != nil {
+		entry.Error = actionErr.Error()
+	}
+	if err := c.log.Write(entry); err != nil && c.errLog != nil {
+		c.errLog("Error writing audit log: %v", err)
+	}
+}
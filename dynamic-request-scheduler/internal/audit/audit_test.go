@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+// fakeTarget records calls and fails for unknown requests
+type fakeTarget struct {
+	calls   []string
+	stopped bool
+}
+
+func (f *fakeTarget) Statuses() []engine.RequestStatus { return nil }
+func (f *fakeTarget) Pressure() engine.Pressure        { return engine.Pressure{} }
+func (f *fakeTarget) IsPaused(name string) bool        { return false }
+func (f *fakeTarget) Stop()                            { f.stopped = true }
+
+func (f *fakeTarget) Recent(name string) ([]engine.RecentExecution, error) { return nil, nil }
+
+func (f *fakeTarget) Pause(name string) error   { return f.call("pause", name) }
+func (f *fakeTarget) Resume(name string) error  { return f.call("resume", name) }
+func (f *fakeTarget) Trigger(name string) error { return f.call("trigger", name) }
+
+func (f *fakeTarget) call(action, name string) error {
+	f.calls = append(f.calls, action+" "+name)
+	if name != "health" {
+		return errors.New("unknown request: " + name)
+	}
+	return nil
+}
+
+func readEntries(t *testing.T, path string) []Entry {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Invalid audit line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestController_RecordsActions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := os.WriteFile(path, []byte(`{"action":"earlier"}`+"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to seed audit log: %v", err)
+	}
+
+	log, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	target := &fakeTarget{}
+	ctrl := NewController(target, log, "run-1", "alice@devbox", "tui", nil)
+	ctrl.now = func() time.Time { return time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+	ctrl.Pause("health")
+	ctrl.Resume("health")
+	if err := ctrl.Trigger("missing"); err == nil {
+		t.Error("Expected trigger error to be passed through")
+	}
+	ctrl.Stop()
+	log.Close()
+
+	if strings.Join(target.calls, ",") != "pause health,resume health,trigger missing" || !target.stopped {
+		t.Errorf("Expected actions to reach the target, got %v (stopped=%v)", target.calls, target.stopped)
+	}
+
+	entries := readEntries(t, path)
+	if len(entries) != 5 || entries[0].Action != "earlier" {
+		t.Fatalf("Expected 4 entries appended after the existing one, got %+v", entries)
+	}
+
+	pause := entries[1]
+	if pause.Action != ActionPause || pause.Request != "health" || pause.Actor != "alice@devbox" ||
+		pause.Source != "tui" || pause.RunID != "run-1" || !pause.Time.Equal(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected pause entry: %+v", pause)
+	}
+	if trigger := entries[3]; trigger.Action != ActionTrigger || trigger.Error != "unknown request: missing" {
+		t.Errorf("Expected failed trigger to be audited with its error, got %+v", trigger)
+	}
+	if stop := entries[4]; stop.Action != ActionStop || stop.Request != "" {
+		t.Errorf("Unexpected stop entry: %+v", stop)
+	}
+}
+
+func TestController_ReportsWriteErrors(t *testing.T) {
+	log, err := Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	log.Close()
+
+	var reported []string
+	ctrl := NewController(&fakeTarget{}, log, "", "bob", "tui", func(format string, args ...interface{}) {
+		reported = append(reported, format)
+	})
+
+	if err := ctrl.Pause("health"); err != nil {
+		t.Errorf("Expected action to succeed despite audit failure, got %v", err)
+	}
+	if len(reported) != 1 {
+		t.Errorf("Expected audit write failure to be reported, got %v", reported)
+	}
+}
+
+func TestCurrentActor(t *testing.T) {
+	if actor := CurrentActor(); actor == "" {
+		t.Errorf("Expected a non-empty actor, got %q", actor)
+	}
+}
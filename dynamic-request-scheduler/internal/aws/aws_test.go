@@ -0,0 +1,209 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignV4(t *testing.T) {
+	// Example request from the AWS Signature Version 4 documentation
+	req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	creds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+	signV4(req, nil, creds, "us-east-1", "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Unexpected signature:\n got %s\nwant %s", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("Unexpected X-Amz-Date %s", got)
+	}
+}
+
+// fakeEndpoint records the form of each request and answers like the query
+// API: queues and topics named "missing" do not exist
+func fakeEndpoint(t *testing.T, requests *[]*http.Request) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		*requests = append(*requests, r)
+
+		if strings.HasSuffix(r.URL.Path, "/missing") || strings.HasSuffix(r.PostForm.Get("TopicArn"), ":missing") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>NotFound</Code><Message>does not exist</Message></Error></ErrorResponse>`)
+			return
+		}
+		switch r.PostForm.Get("Action") {
+		case "SendMessage":
+			fmt.Fprint(w, `<SendMessageResponse><SendMessageResult><MessageId>sqs-1</MessageId></SendMessageResult></SendMessageResponse>`)
+		case "Publish":
+			fmt.Fprint(w, `<PublishResponse><PublishResult><MessageId>sns-1</MessageId></PublishResult></PublishResponse>`)
+		case "ReceiveMessage":
+			fmt.Fprint(w, `<ReceiveMessageResponse><ReceiveMessageResult>
+<Message><MessageId>m-1</MessageId><ReceiptHandle>h-1</ReceiptHandle><Body>{"id":1}</Body>
+<Attribute><Name>SentTimestamp</Name><Value>1700000000123</Value></Attribute>
+<Attribute><Name>ApproximateReceiveCount</Name><Value>2</Value></Attribute>
+<Attribute><Name>MessageGroupId</Name><Value>orders</Value></Attribute>
+<MessageAttribute><Name>trace</Name><Value><StringValue>abc</StringValue><DataType>String</DataType></Value></MessageAttribute>
+</Message>
+<Message><MessageId>m-2</MessageId><ReceiptHandle>h-2</ReceiptHandle><Body>plain</Body></Message>
+</ReceiveMessageResult></ReceiveMessageResponse>`)
+		case "DeleteMessage", "ChangeMessageVisibility", "PurgeQueue":
+			fmt.Fprintf(w, `<%sResponse><ResponseMetadata><RequestId>r</RequestId></ResponseMetadata></%sResponse>`, r.PostForm.Get("Action"), r.PostForm.Get("Action"))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestClient() *Client {
+	return &Client{
+		client:      http.DefaultClient,
+		credentials: Credentials{AccessKeyID: "test", SecretAccessKey: "test"},
+		region:      "us-east-1",
+	}
+}
+
+func TestClient_SendMessage(t *testing.T) {
+	var requests []*http.Request
+	server := fakeEndpoint(t, &requests)
+	client := newTestClient()
+
+	delay := 5
+	id, err := client.SendMessage(context.Background(), "eu-west-1", SQSMessage{
+		QueueURL:     server.URL + "/000000000000/orders.fifo",
+		Body:         `{"id":1}`,
+		Attributes:   map[string]string{"trace": "abc", "source": "drs"},
+		DelaySeconds: &delay,
+		GroupID:      "orders",
+	})
+	if err != nil || id != "sqs-1" {
+		t.Fatalf("Expected message ID sqs-1, got %q %v", id, err)
+	}
+
+	req := requests[0]
+	want := url.Values{
+		"Action":                               {"SendMessage"},
+		"Version":                              {sqsVersion},
+		"MessageBody":                          {`{"id":1}`},
+		"DelaySeconds":                         {"5"},
+		"MessageGroupId":                       {"orders"},
+		"MessageAttribute.1.Name":              {"source"},
+		"MessageAttribute.1.Value.DataType":    {"String"},
+		"MessageAttribute.1.Value.StringValue": {"drs"},
+		"MessageAttribute.2.Name":              {"trace"},
+		"MessageAttribute.2.Value.DataType":    {"String"},
+		"MessageAttribute.2.Value.StringValue": {"abc"},
+	}
+	if got := req.PostForm.Encode(); got != want.Encode() {
+		t.Errorf("Unexpected form:\n got %s\nwant %s", got, want.Encode())
+	}
+	if req.URL.Path != "/000000000000/orders.fifo" {
+		t.Errorf("Expected request to the queue URL, got %s", req.URL.Path)
+	}
+	if auth := req.Header.Get("Authorization"); !strings.Contains(auth, "Credential=test/") || !strings.Contains(auth, "/eu-west-1/sqs/aws4_request") {
+		t.Errorf("Unexpected Authorization header %s", auth)
+	}
+
+	_, err = client.SendMessage(context.Background(), "", SQSMessage{QueueURL: server.URL + "/000000000000/missing"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Code != "NotFound" || apiErr.StatusCode != 400 {
+		t.Errorf("Expected NotFound error, got %v", err)
+	}
+}
+
+func TestClient_Publish(t *testing.T) {
+	var requests []*http.Request
+	server := fakeEndpoint(t, &requests)
+	client := newTestClient()
+
+	id, err := client.Publish(context.Background(), server.URL, "", SNSMessage{
+		TopicARN:   "arn:aws:sns:us-east-1:000000000000:deploys",
+		Subject:    "Deploy",
+		Message:    "finished",
+		Attributes: map[string]string{"env": "local"},
+	})
+	if err != nil || id != "sns-1" {
+		t.Fatalf("Expected message ID sns-1, got %q %v", id, err)
+	}
+
+	form := requests[0].PostForm
+	if form.Get("TopicArn") != "arn:aws:sns:us-east-1:000000000000:deploys" || form.Get("Subject") != "Deploy" || form.Get("Message") != "finished" {
+		t.Errorf("Unexpected form %v", form)
+	}
+	if form.Get("MessageAttributes.entry.1.Name") != "env" || form.Get("MessageAttributes.entry.1.Value.StringValue") != "local" {
+		t.Errorf("Expected message attributes, got %v", form)
+	}
+	if auth := requests[0].Header.Get("Authorization"); !strings.Contains(auth, "/us-east-1/sns/aws4_request") {
+		t.Errorf("Expected default region, got %s", auth)
+	}
+
+	_, err = client.Publish(context.Background(), server.URL, "", SNSMessage{TopicARN: "arn:aws:sns:us-east-1:000000000000:missing"})
+	if err == nil || !strings.Contains(err.Error(), "NotFound: does not exist (HTTP 400)") {
+		t.Errorf("Expected NotFound error, got %v", err)
+	}
+}
+
+func TestClient_ReceiveMessages(t *testing.T) {
+	var requests []*http.Request
+	server := fakeEndpoint(t, &requests)
+	client := newTestClient()
+	ctx := context.Background()
+	queueURL := server.URL + "/000000000000/orders.fifo"
+
+	messages, err := client.ReceiveMessages(ctx, "", queueURL, 50, 2*time.Second, 30*time.Second)
+	if err != nil {
+		t.Fatalf("ReceiveMessages failed: %v", err)
+	}
+	form := requests[0].PostForm
+	if form.Get("MaxNumberOfMessages") != "10" || form.Get("WaitTimeSeconds") != "2" || form.Get("VisibilityTimeout") != "30" || form.Get("MessageAttributeName.1") != "All" {
+		t.Errorf("Unexpected form %v", form)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	m := messages[0]
+	if m.MessageID != "m-1" || m.ReceiptHandle != "h-1" || m.Body != `{"id":1}` || m.ReceiveCount != 2 || m.GroupID != "orders" || m.Attributes["trace"] != "abc" {
+		t.Errorf("Unexpected message %+v", m)
+	}
+	if m.SentAt.UnixMilli() != 1700000000123 {
+		t.Errorf("Unexpected sent time %v", m.SentAt)
+	}
+	if messages[1].Attributes != nil || !messages[1].SentAt.IsZero() {
+		t.Errorf("Expected no attributes, got %+v", messages[1])
+	}
+
+	if err := client.ChangeMessageVisibility(ctx, "", queueURL, "h-2", 0); err != nil {
+		t.Errorf("ChangeMessageVisibility failed: %v", err)
+	}
+	if err := client.DeleteMessage(ctx, "", queueURL, "h-1"); err != nil {
+		t.Errorf("DeleteMessage failed: %v", err)
+	}
+	if err := client.PurgeQueue(ctx, "", queueURL); err != nil {
+		t.Errorf("PurgeQueue failed: %v", err)
+	}
+	if got := requests[1].PostForm; got.Get("ReceiptHandle") != "h-2" || got.Get("VisibilityTimeout") != "0" {
+		t.Errorf("Unexpected visibility change %v", got)
+	}
+	if got := requests[2].PostForm; got.Get("Action") != "DeleteMessage" || got.Get("ReceiptHandle") != "h-1" {
+		t.Errorf("Unexpected delete %v", got)
+	}
+
+	err = client.PurgeQueue(ctx, "", server.URL+"/000000000000/missing")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Code != "NotFound" {
+		t.Errorf("Expected NotFound error, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package aws
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// maxReceive is the most messages SQS returns from one receive
+const maxReceive = 10
+
+// ReceivedMessage is a message received from a queue. It stays in the queue,
+// hidden from other receivers until its visibility timeout ends, unless it
+// is deleted.
+type ReceivedMessage struct {
+	MessageID     string
+	ReceiptHandle string
+	Body          string
+
+	// Attributes are the message attributes set by the sender
+	Attributes map[string]string
+
+	SentAt       time.Time
+	ReceiveCount int
+
+	// GroupID is set for messages from FIFO queues
+	GroupID string
+}
+
+// ReceiveMessages receives up to max messages (at most 10) from the queue,
+// waiting up to wait for one to arrive, and hides them for visibility. An
+// empty region uses the client's default.
+func (c *Client) ReceiveMessages(ctx context.Context, region, queueURL string, max int, wait, visibility time.Duration) ([]ReceivedMessage, error) {
+	if max < 1 || max > maxReceive {
+		max = maxReceive
+	}
+	params := url.Values{
+		"Action":                 {"ReceiveMessage"},
+		"Version":                {sqsVersion},
+		"MaxNumberOfMessages":    {strconv.Itoa(max)},
+		"WaitTimeSeconds":        {strconv.Itoa(int(wait / time.Second))},
+		"VisibilityTimeout":      {strconv.Itoa(int(visibility / time.Second))},
+		"AttributeName.1":        {"All"},
+		"MessageAttributeName.1": {"All"},
+	}
+
+	var response struct {
+		Messages []struct {
+			MessageID     string `xml:"MessageId"`
+			ReceiptHandle string `xml:"ReceiptHandle"`
+			Body          string `xml:"Body"`
+			Attributes    []struct {
+				Name  string `xml:"Name"`
+				Value string `xml:"Value"`
+			} `xml:"Attribute"`
+			MessageAttributes []struct {
+				Name  string `xml:"Name"`
+				Value string `xml:"Value>StringValue"`
+			} `xml:"MessageAttribute"`
+		} `xml:"ReceiveMessageResult>Message"`
+	}
+	if err := c.call(ctx, queueURL, "sqs", region, params, &response); err != nil {
+		return nil, err
+	}
+
+	messages := make([]ReceivedMessage, 0, len(response.Messages))
+	for _, m := range response.Messages {
+		msg := ReceivedMessage{MessageID: m.MessageID, ReceiptHandle: m.ReceiptHandle, Body: m.Body}
+		for _, a := range m.Attributes {
+			switch a.Name {
+			case "SentTimestamp":
+				if ms, err := strconv.ParseInt(a.Value, 10, 64); err == nil {
+					msg.SentAt = time.UnixMilli(ms)
+				}
+			case "ApproximateReceiveCount":
+				msg.ReceiveCount, _ = strconv.Atoi(a.Value)
+			case "MessageGroupId":
+				msg.GroupID = a.Value
+			}
+		}
+		if len(m.MessageAttributes) > 0 {
+			msg.Attributes = make(map[string]string)
+			for _, a := range m.MessageAttributes {
+				msg.Attributes[a.Name] = a.Value
+			}
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// DeleteMessage removes a received message from the queue
+func (c *Client) DeleteMessage(ctx context.Context, region, queueURL, receiptHandle string) error {
+	params := url.Values{
+		"Action":        {"DeleteMessage"},
+		"Version":       {sqsVersion},
+		"ReceiptHandle": {receiptHandle},
+	}
+	var response struct{}
+	return c.call(ctx, queueURL, "sqs", region, params, &response)
+}
+
+// ChangeMessageVisibility hides a received message for visibility from now;
+// zero makes it visible to other receivers again at once
+func (c *Client) ChangeMessageVisibility(ctx context.Context, region, queueURL, receiptHandle string, visibility time.Duration) error {
+	params := url.Values{
+		"Action":            {"ChangeMessageVisibility"},
+		"Version":           {sqsVersion},
+		"ReceiptHandle":     {receiptHandle},
+		"VisibilityTimeout": {strconv.Itoa(int(visibility / time.Second))},
+	}
+	var response struct{}
+	return c.call(ctx, queueURL, "sqs", region, params, &response)
+}
+
+// PurgeQueue deletes every message in the queue. SQS allows one purge per
+// queue every 60 seconds.
+func (c *Client) PurgeQueue(ctx context.Context, region, queueURL string) error {
+	params := url.Values{
+		"Action":  {"PurgeQueue"},
+		"Version": {sqsVersion},
+	}
+	var response struct{}
+	return c.call(ctx, queueURL, "sqs", region, params, &response)
+}
@@ -0,0 +1,457 @@
+// Package cronexpr describes cron expressions in words and works out when
+// they fire, parsing them exactly as cron schedules in a config are parsed
+package cronexpr
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// parser accepts what spec.ScheduleEngine does: five fields or a
+// descriptor such as @daily, optionally prefixed by CRON_TZ=<zone>
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// starBit marks a field written as * or ?, as the cron library does. Days
+// of the month and of the week are ORed only when neither is starred.
+const starBit = 1 << 63
+
+// descriptors are the fixed-time descriptors and the fields they stand for
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = []string{"", "January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+
+var dayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+// Expr is a parsed cron expression
+type Expr struct {
+	// Source is the expression as given
+	Source string
+
+	// Location is the zone the expression fires in: its CRON_TZ, or the
+	// local zone
+	Location *time.Location
+
+	schedule cron.Schedule
+
+	// fields are the minute, hour, day of month, month and day of week
+	// fields, with descriptors expanded; nil for @every
+	fields []string
+	every  time.Duration
+}
+
+// Parse parses an expression as the scheduler does
+func Parse(expr string) (*Expr, error) {
+	expr = strings.TrimSpace(expr)
+	schedule, err := parser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+
+	e := &Expr{Source: expr, Location: time.Local, schedule: schedule}
+	body := expr
+	if strings.HasPrefix(body, "TZ=") || strings.HasPrefix(body, "CRON_TZ=") {
+		zone, rest, _ := strings.Cut(body, " ")
+		_, name, _ := strings.Cut(zone, "=")
+		if e.Location, err = time.LoadLocation(name); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
+		}
+		body = strings.TrimSpace(rest)
+	}
+
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		e.every = every.Delay
+		return e, nil
+	}
+	if fields, ok := descriptors[strings.ToLower(body)]; ok {
+		body = fields
+	}
+	e.fields = strings.Fields(body)
+	return e, nil
+}
+
+// ParseInZone parses an expression that fires in the named zone unless it
+// sets its own CRON_TZ. An empty zone leaves it in the local zone.
+func ParseInZone(expr, zone string) (*Expr, error) {
+	expr = strings.TrimSpace(expr)
+	if zone != "" && !strings.HasPrefix(expr, "TZ=") && !strings.HasPrefix(expr, "CRON_TZ=") {
+		if _, err := time.LoadLocation(zone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", zone, err)
+		}
+		expr = "CRON_TZ=" + zone + " " + expr
+	}
+	return Parse(expr)
+}
+
+// Next returns the next n times the expression fires after from. @every
+// expressions are counted from from, as a schedule starting then would be.
+func (e *Expr) Next(from time.Time, n int) []time.Time {
+	runs := make([]time.Time, 0, n)
+	for len(runs) < n {
+		from = e.schedule.Next(from)
+		if from.IsZero() {
+			break
+		}
+		runs = append(runs, from)
+	}
+	return runs
+}
+
+// Check returns why the expression does not fire in the minute holding t,
+// or nil when it does
+func (e *Expr) Check(t time.Time) []string {
+	spec, ok := e.schedule.(*cron.SpecSchedule)
+	if !ok {
+		return []string{"@every schedules fire at intervals from when they start, not at fixed times"}
+	}
+
+	t = t.In(e.Location)
+	var reasons []string
+	if spec.Minute&(1<<uint(t.Minute())) == 0 {
+		reasons = append(reasons, fmt.Sprintf("minute %d is not in %s", t.Minute(), e.fields[0]))
+	}
+	if spec.Hour&(1<<uint(t.Hour())) == 0 {
+		reasons = append(reasons, fmt.Sprintf("hour %d is not in %s", t.Hour(), e.fields[1]))
+	}
+	if spec.Month&(1<<uint(t.Month())) == 0 {
+		reasons = append(reasons, fmt.Sprintf("month %d (%s) is not in %s", t.Month(), t.Month(), e.fields[3]))
+	}
+
+	domMatch := spec.Dom&(1<<uint(t.Day())) != 0
+	dowMatch := spec.Dow&(1<<uint(t.Weekday())) != 0
+	domReason := fmt.Sprintf("day of month %d is not in %s", t.Day(), e.fields[2])
+	dowReason := fmt.Sprintf("day of week %d (%s) is not in %s", t.Weekday(), t.Weekday(), e.fields[4])
+	switch {
+	case spec.Dom&starBit != 0 || spec.Dow&starBit != 0:
+		if !domMatch {
+			reasons = append(reasons, domReason)
+		}
+		if !dowMatch {
+			reasons = append(reasons, dowReason)
+		}
+	case !domMatch && !dowMatch:
+		reasons = append(reasons, domReason+" and "+dowReason)
+	}
+	return reasons
+}
+
+// Explain describes when the expression fires, such as "Every 5 minutes,
+// between 09:00 and 17:59, on Monday through Friday"
+func (e *Expr) Explain() string {
+	if e.fields == nil {
+		return "Every " + formatDuration(e.every) + ", counted from when the schedule starts"
+	}
+
+	parts := []string{e.timePhrase()}
+	days := e.dayPhrase()
+	if days != "" {
+		parts = append(parts, days)
+	}
+	if months := e.monthPhrase(); months != "" {
+		parts = append(parts, months)
+	}
+	phrase := strings.Join(parts, ", ")
+	if e.Location != time.Local {
+		phrase += " (" + e.Location.String() + " time)"
+	}
+	return strings.ToUpper(phrase[:1]) + phrase[1:]
+}
+
+// Report writes the expression, its explanation and its next n fire times
+// after from, shown in the expression's zone
+func (e *Expr) Report(w io.Writer, from time.Time, n int) {
+	fmt.Fprintln(w, e.Source)
+	fmt.Fprintln(w, e.Explain())
+	fmt.Fprintln(w)
+
+	runs := e.Next(from, n)
+	fmt.Fprintf(w, "Next %d runs (%s):\n", len(runs), e.Location)
+	for _, run := range runs {
+		fmt.Fprintf(w, "  %s  in %s\n", run.In(e.Location).Format("Mon 2006-01-02 15:04 MST"), formatUntil(run.Sub(from).Round(time.Minute)))
+	}
+}
+
+// timePhrase describes the minute and hour fields
+func (e *Expr) timePhrase() string {
+	minutes := parseField(e.fields[0], 0, 59)
+	hours := parseField(e.fields[1], 0, 23)
+	minuteValues, fixedMinutes := singles(minutes)
+	hourValues, fixedHours := singles(hours)
+
+	// A handful of fixed times read best as a list of clock times
+	if fixedMinutes && fixedHours && len(minuteValues)*len(hourValues) <= 6 {
+		var times []string
+		for _, h := range hourValues {
+			for _, m := range minuteValues {
+				times = append(times, fmt.Sprintf("%02d:%02d", h, m))
+			}
+		}
+		return "at " + joinList(times)
+	}
+
+	if fixedMinutes {
+		phrase := "at minute " + joinList(itoas(minuteValues))
+		if len(minuteValues) > 1 {
+			phrase = "at minutes " + joinList(itoas(minuteValues))
+		}
+		switch {
+		case len(hours) == 1 && hours[0].isAll():
+			return phrase + " of every hour"
+		case fixedHours:
+			return phrase + " of hours " + joinList(itoas(hourValues))
+		case len(hours) == 1 && hours[0].step == 1:
+			return fmt.Sprintf("%s of every hour from %02d:00 through %02d:00", phrase, hours[0].start, hours[0].end)
+		case len(hours) == 1:
+			return phrase + " of " + hours[0].describe("hour", 23, formatHour)
+		}
+		return phrase + " of hours " + describeItems(hours, "hour", 23, formatHour)
+	}
+
+	var phrase string
+	if len(minutes) == 1 {
+		phrase = minutes[0].describe("minute", 59, func(m int) string { return "minute " + strconv.Itoa(m) })
+	} else {
+		phrase = "at minutes " + describeItems(minutes, "minute", 59, strconv.Itoa)
+	}
+
+	switch {
+	case len(hours) == 1 && hours[0].isAll():
+		return phrase
+	case len(hours) == 1 && hours[0].step == 1:
+		return fmt.Sprintf("%s, between %02d:00 and %02d:59", phrase, hours[0].start, hours[0].end)
+	case len(hours) == 1:
+		return phrase + ", during " + hours[0].describe("hour", 23, formatHour)
+	case fixedHours:
+		return phrase + ", during hours " + joinList(itoas(hourValues))
+	}
+	return phrase + ", during hours " + describeItems(hours, "hour", 23, formatHour)
+}
+
+// dayPhrase describes the day of month and day of week fields, which cron
+// ORs when both are restricted and neither is starred
+func (e *Expr) dayPhrase() string {
+	dom := parseField(e.fields[2], 1, 31)
+	dow := parseField(e.fields[4], 0, 6)
+	var parts []string
+
+	if !(len(dom) == 1 && dom[0].isAll()) {
+		if values, ok := singles(dom); ok {
+			noun := "day "
+			if len(values) > 1 {
+				noun = "days "
+			}
+			parts = append(parts, "on "+noun+joinList(itoas(values))+" of the month")
+		} else {
+			parts = append(parts, "on "+describeItems(dom, "day", 31, func(d int) string { return "day " + strconv.Itoa(d) })+" of the month")
+		}
+	}
+	if !(len(dow) == 1 && dow[0].isAll()) {
+		parts = append(parts, "on "+describeItems(dow, "day of the week", 6, func(d int) string { return dayNames[d] }))
+	}
+
+	if len(parts) == 2 && !starred(e.fields[2]) && !starred(e.fields[4]) {
+		return parts[0] + " or " + parts[1]
+	}
+	return strings.Join(parts, " and ")
+}
+
+// monthPhrase describes the month field
+func (e *Expr) monthPhrase() string {
+	months := parseField(e.fields[3], 1, 12)
+	if len(months) == 1 && months[0].isAll() {
+		return ""
+	}
+	phrase := describeItems(months, "month", 12, func(m int) string { return monthNames[m] })
+	if strings.HasPrefix(phrase, "every") {
+		return phrase
+	}
+	return "in " + phrase
+}
+
+// item is one comma-separated part of a field: a value, a range or a
+// stepped range
+type item struct {
+	start, end, step int
+	star             bool
+	bounds           [2]int
+}
+
+func (i item) isAll() bool {
+	return i.star && i.step == 1
+}
+
+// describe words an item, such as "every 2nd hour from 08:00 through
+// 18:00", with format naming a single value
+func (i item) describe(unit string, max int, format func(int) string) string {
+	if i.step == 1 {
+		switch {
+		case i.star:
+			return "every " + unit
+		case i.start == i.end:
+			return format(i.start)
+		}
+		if unit == "minute" {
+			return fmt.Sprintf("every minute from minute %d through %d", i.start, i.end)
+		}
+		return format(i.start) + " through " + format(i.end)
+	}
+
+	every := "every " + ordinal(i.step) + " " + unit
+	if unit == "minute" {
+		every = fmt.Sprintf("every %d minutes", i.step)
+	}
+	switch {
+	case i.start == i.bounds[0] && i.end == max:
+		return every
+	case i.end == max:
+		return every + " from " + format(i.start)
+	}
+	return every + " from " + format(i.start) + " through " + format(i.end)
+}
+
+// parseField splits an expression field, already validated by the cron
+// parser, into its items
+func parseField(field string, min, max int) []item {
+	var items []item
+	for _, part := range strings.Split(field, ",") {
+		it := item{start: min, end: max, step: 1, bounds: [2]int{min, max}}
+		rangePart, step, hasStep := strings.Cut(part, "/")
+		if hasStep {
+			it.step, _ = strconv.Atoi(step)
+		}
+
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			it.star = true
+		default:
+			low, high, isRange := strings.Cut(rangePart, "-")
+			it.start = fieldValue(low, min)
+			it.end = it.start
+			if isRange {
+				it.end = fieldValue(high, min)
+			} else if hasStep {
+				// a/n runs from a to the end of the field
+				it.end = max
+			}
+		}
+		items = append(items, it)
+	}
+	return items
+}
+
+// fieldValue reads a number, or a month or day name such as JAN or MON
+func fieldValue(s string, min int) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	names := dayNames
+	if min == 1 {
+		names = monthNames
+	}
+	for i, name := range names {
+		if len(name) >= 3 && strings.EqualFold(name[:3], s) {
+			return i
+		}
+	}
+	return 0
+}
+
+// singles returns the values of a field made only of single values, in
+// order
+func singles(items []item) ([]int, bool) {
+	var values []int
+	for _, it := range items {
+		if it.star || it.start != it.end {
+			return nil, false
+		}
+		values = append(values, it.start)
+	}
+	return values, true
+}
+
+func describeItems(items []item, unit string, max int, format func(int) string) string {
+	parts := make([]string, len(items))
+	for i, it := range items {
+		parts[i] = it.describe(unit, max, format)
+	}
+	return joinList(parts)
+}
+
+// starred reports whether a field is written as * or ?, with or without a
+// step
+func starred(field string) bool {
+	return strings.HasPrefix(field, "*") || strings.HasPrefix(field, "?")
+}
+
+func formatHour(h int) string {
+	return fmt.Sprintf("%02d:00", h)
+}
+
+func itoas(values []int) []string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = strconv.Itoa(v)
+	}
+	return s
+}
+
+// joinList joins words as "a, b and c"
+func joinList(words []string) string {
+	if len(words) <= 1 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
+
+// ordinal returns 2nd, 3rd, 11th and so on
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// formatUntil shows a wait in days, hours and minutes, such as 2d15h8m
+func formatUntil(d time.Duration) string {
+	day := 24 * time.Hour
+	if d < day {
+		return formatDuration(d)
+	}
+	days := fmt.Sprintf("%dd", d/day)
+	if d%day == 0 {
+		return days
+	}
+	return days + formatDuration(d%day)
+}
+
+// formatDuration shows a duration without zero trailing units, such as
+// 1h30m rather than 1h30m0s
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
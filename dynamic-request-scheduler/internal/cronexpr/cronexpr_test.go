@@ -0,0 +1,168 @@
+package cronexpr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"* * * * *", "Every minute"},
+		{"*/5 9-17 * * 1-5", "Every 5 minutes, between 09:00 and 17:59, on Monday through Friday"},
+		{"0 9 * * *", "At 09:00"},
+		{"30 9,17 * * *", "At 09:30 and 17:30"},
+		{"15 * * * *", "At minute 15 of every hour"},
+		{"0 9-17 * * 1-5", "At minute 0 of every hour from 09:00 through 17:00, on Monday through Friday"},
+		{"0 */2 * * *", "At minute 0 of every 2nd hour"},
+		{"0 8-18/2 * * *", "At minute 0 of every 2nd hour from 08:00 through 18:00"},
+		{"0,30 * * * *", "At minutes 0 and 30 of every hour"},
+		{"0 0 1,15 * 1", "At 00:00, on days 1 and 15 of the month or on Monday"},
+		{"0 0 */2 * MON", "At 00:00, on every 2nd day of the month and on Monday"},
+		{"0 6 * JAN-MAR SAT,SUN", "At 06:00, on Saturday and Sunday, in January through March"},
+		{"0 0 1 */3 *", "At 00:00, on day 1 of the month, every 3rd month"},
+		{"10-20 * * * *", "Every minute from minute 10 through 20"},
+		{"@daily", "At 00:00"},
+		{"@hourly", "At minute 0 of every hour"},
+		{"@weekly", "At 00:00, on Sunday"},
+		{"@every 1h30m", "Every 1h30m, counted from when the schedule starts"},
+		{"CRON_TZ=Europe/London 0 9 * * *", "At 09:00 (Europe/London time)"},
+	}
+
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		if got := e.Explain(); got != tt.want {
+			t.Errorf("Explain(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * *", "61 * * * *", "* * * * * *", "@often", "CRON_TZ=Nowhere/Else * * * * *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Expected an error parsing %q", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	e, err := Parse("*/5 9-17 * * 1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Friday 17:52 rolls over the weekend to Monday morning
+	from := time.Date(2025, 3, 7, 17, 52, 0, 0, time.Local)
+	runs := e.Next(from, 3)
+	want := []time.Time{
+		time.Date(2025, 3, 7, 17, 55, 0, 0, time.Local),
+		time.Date(2025, 3, 10, 9, 0, 0, 0, time.Local),
+		time.Date(2025, 3, 10, 9, 5, 0, 0, time.Local),
+	}
+	if len(runs) != len(want) {
+		t.Fatalf("Got %d runs, want %d", len(runs), len(want))
+	}
+	for i := range want {
+		if !runs[i].Equal(want[i]) {
+			t.Errorf("Run %d = %v, want %v", i, runs[i], want[i])
+		}
+	}
+}
+
+func TestNext_TimeZone(t *testing.T) {
+	e, err := Parse("CRON_TZ=America/New_York 0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := e.Next(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 1)
+	if want := time.Date(2025, 1, 1, 14, 0, 0, 0, time.UTC); len(runs) != 1 || !runs[0].Equal(want) {
+		t.Errorf("Got %v, want %v", runs, want)
+	}
+}
+
+func TestParseInZone(t *testing.T) {
+	e, err := ParseInZone("0 9 * * *", "Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Location.String() != "Asia/Tokyo" || e.Explain() != "At 09:00 (Asia/Tokyo time)" {
+		t.Errorf("Expected the zone to apply, got %s: %q", e.Location, e.Explain())
+	}
+
+	// The expression's own zone wins
+	if e, err = ParseInZone("CRON_TZ=UTC 0 9 * * *", "Asia/Tokyo"); err != nil || e.Location != time.UTC {
+		t.Errorf("Expected CRON_TZ to take precedence, got %v, %v", e, err)
+	}
+	if _, err := ParseInZone("0 9 * * *", "Nowhere/Else"); err == nil {
+		t.Errorf("Expected an error for an unknown zone")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	e, err := Parse("*/5 9-17 * * 1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Monday 3 March 2025
+	if reasons := e.Check(time.Date(2025, 3, 3, 9, 15, 30, 0, time.Local)); reasons != nil {
+		t.Errorf("Expected 09:15 on a Monday to fire, got %q", reasons)
+	}
+	reasons := e.Check(time.Date(2025, 3, 2, 8, 7, 0, 0, time.Local))
+	want := []string{
+		"minute 7 is not in */5",
+		"hour 8 is not in 9-17",
+		"day of week 0 (Sunday) is not in 1-5",
+	}
+	if strings.Join(reasons, "; ") != strings.Join(want, "; ") {
+		t.Errorf("Got reasons %q, want %q", reasons, want)
+	}
+}
+
+func TestCheck_DaysOred(t *testing.T) {
+	e, err := Parse("0 0 1 * 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Monday 3 March fires on the day of week alone
+	if reasons := e.Check(time.Date(2025, 3, 3, 0, 0, 0, 0, time.Local)); reasons != nil {
+		t.Errorf("Expected a Monday to fire, got %q", reasons)
+	}
+	reasons := e.Check(time.Date(2025, 3, 4, 0, 0, 0, 0, time.Local))
+	if len(reasons) != 1 || !strings.Contains(reasons[0], "day of month 4") || !strings.Contains(reasons[0], "(Tuesday)") {
+		t.Errorf("Expected one combined day reason, got %q", reasons)
+	}
+}
+
+func TestCheck_Every(t *testing.T) {
+	e, err := Parse("@every 10m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reasons := e.Check(time.Now()); len(reasons) != 1 {
+		t.Errorf("Expected @every to be reported as untestable, got %q", reasons)
+	}
+}
+
+func TestReport(t *testing.T) {
+	e, err := Parse("CRON_TZ=UTC 0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2025, 3, 3, 7, 30, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	e.Report(&buf, from, 2)
+
+	out := buf.String()
+	for _, want := range []string{"At 09:00 (UTC time)\n", "Next 2 runs (UTC):", "Mon 2025-03-03 09:00 UTC  in 1h30m", "Tue 2025-03-04 09:00 UTC  in 1d1h30m"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// childEnv marks a process started by Start as the detached scheduler
+const childEnv = "DRS_DAEMON_CHILD"
+
+// ErrNotRunning is returned by Stop when no live process owns the pid file
+var ErrNotRunning = errors.New("scheduler is not running")
+
+// IsChild reports whether this process was started in the background by Start
+func IsChild() bool {
+	return os.Getenv(childEnv) == "1"
+}
+
+// Start re-executes the current binary with args, detached from the terminal
+// and with its output appended to logFile, and records its pid in pidFile.
+// It fails if pidFile already belongs to a live process.
+func Start(args []string, pidFile, logFile string) (int, error) {
+	if pid, running, err := Status(pidFile); err != nil {
+		return 0, err
+	} else if running {
+		return 0, fmt.Errorf("scheduler is already running (pid %d, %s)", pid, pidFile)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	out, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer out.Close()
+
+	cmd := exec.Command(exe, args...)
+	cmd.Env = append(os.Environ(), childEnv+"=1")
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.SysProcAttr = detachAttr()
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start background process: %w", err)
+	}
+
+	pid := cmd.Process.Pid
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(pid)+"\n"), 0o644); err != nil {
+		cmd.Process.Kill()
+		return 0, fmt.Errorf("failed to write pid file: %w", err)
+	}
+	cmd.Process.Release()
+	return pid, nil
+}
+
+// ReadPID reads the pid recorded in pidFile
+func ReadPID(pidFile string) (int, error) {
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pid file %s", pidFile)
+	}
+	return pid, nil
+}
+
+// Status reports the pid recorded in pidFile and whether that process is
+// still alive. A missing pid file means not running.
+func Status(pidFile string) (int, bool, error) {
+	pid, err := ReadPID(pidFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return pid, processAlive(pid), nil
+}
+
+// RemovePIDFile deletes pidFile if it still records this process, so a
+// restarted scheduler's pid file is never removed by the old one
+func RemovePIDFile(pidFile string) {
+	if pid, err := ReadPID(pidFile); err == nil && pid == os.Getpid() {
+		os.Remove(pidFile)
+	}
+}
+
+// Stop asks the process recorded in pidFile to shut down gracefully and
+// waits up to timeout for it to exit. A stale pid file is removed.
+func Stop(pidFile string, timeout time.Duration) (int, error) {
+	pid, running, err := Status(pidFile)
+	if err != nil {
+		return 0, err
+	}
+	if !running {
+		if pid != 0 {
+			os.Remove(pidFile)
+		}
+		return pid, ErrNotRunning
+	}
+
+	if err := terminate(pid); err != nil {
+		return pid, fmt.Errorf("failed to signal pid %d: %w", pid, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			return pid, fmt.Errorf("pid %d did not exit within %v", pid, timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	os.Remove(pidFile)
+	return pid, nil
+}
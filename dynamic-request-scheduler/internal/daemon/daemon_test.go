@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func writePID(t *testing.T, path string, pid int) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strconv.Itoa(pid)+"\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+// exitedPID returns the pid of a process that has already exited and been reaped
+func exitedPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("helper process failed: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestStatus(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "drs.pid")
+
+	if pid, running, err := Status(pidFile); err != nil || running || pid != 0 {
+		t.Errorf("Expected missing pid file to mean not running, got %d %v %v", pid, running, err)
+	}
+
+	writePID(t, pidFile, os.Getpid())
+	if pid, running, err := Status(pidFile); err != nil || !running || pid != os.Getpid() {
+		t.Errorf("Expected own pid to be running, got %d %v %v", pid, running, err)
+	}
+
+	stale := exitedPID(t)
+	writePID(t, pidFile, stale)
+	if pid, running, err := Status(pidFile); err != nil || running || pid != stale {
+		t.Errorf("Expected stale pid to be reported as not running, got %d %v %v", pid, running, err)
+	}
+
+	os.WriteFile(pidFile, []byte("garbage"), 0o644)
+	if _, _, err := Status(pidFile); err == nil {
+		t.Error("Expected error for invalid pid file")
+	}
+}
+
+func TestRemovePIDFile(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "drs.pid")
+
+	// A pid file taken over by another process is left alone
+	writePID(t, pidFile, os.Getpid()+1)
+	RemovePIDFile(pidFile)
+	if _, err := os.Stat(pidFile); err != nil {
+		t.Errorf("Expected foreign pid file to be kept: %v", err)
+	}
+
+	writePID(t, pidFile, os.Getpid())
+	RemovePIDFile(pidFile)
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Errorf("Expected own pid file to be removed: %v", err)
+	}
+}
+
+func TestStop(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "drs.pid")
+
+	if _, err := Stop(pidFile, time.Second); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Expected ErrNotRunning without a pid file, got %v", err)
+	}
+
+	writePID(t, pidFile, exitedPID(t))
+	if _, err := Stop(pidFile, time.Second); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Expected ErrNotRunning for a stale pid file, got %v", err)
+	}
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Errorf("Expected stale pid file to be removed: %v", err)
+	}
+
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	cmd := exec.Command(sleep, "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start sleep: %v", err)
+	}
+	// Reap the process so it does not linger as a zombie once signalled
+	go cmd.Wait()
+
+	writePID(t, pidFile, cmd.Process.Pid)
+	pid, err := Stop(pidFile, 5*time.Second)
+	if err != nil || pid != cmd.Process.Pid {
+		t.Fatalf("Stop failed: %d %v", pid, err)
+	}
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Errorf("Expected pid file to be removed after stop: %v", err)
+	}
+}
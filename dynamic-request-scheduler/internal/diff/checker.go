@@ -0,0 +1,199 @@
+package diff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// Baseline is the stored reference response for a request
+type Baseline struct {
+	Request    string      `json:"request"`
+	RecordedAt time.Time   `json:"recorded_at"`
+	StatusCode int         `json:"status_code"`
+	Body       interface{} `json:"body"`
+}
+
+// Checker compares each response against a baseline stored on disk, saving
+// the first response it sees for a request as that request's baseline
+type Checker struct {
+	dir     string
+	update  bool
+	ignore  []string
+	perName map[string][]string
+
+	// mu serializes baseline file access and guards changes
+	mu      sync.Mutex
+	changes map[string][]Change
+}
+
+// NewChecker creates a checker storing baselines in dir. Global ignore paths
+// apply to every request, in addition to each request's diff.ignore list.
+// With update set, every response overwrites its baseline instead of being compared.
+func NewChecker(dir string, update bool, ignore []string, requests []spec.ScheduledRequest) (*Checker, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create baseline directory: %w", err)
+	}
+
+	perName := make(map[string][]string)
+	for _, req := range requests {
+		if req.Diff != nil {
+			perName[req.Name] = req.Diff.Ignore
+		}
+	}
+
+	return &Checker{
+		dir:     dir,
+		update:  update,
+		ignore:  ignore,
+		perName: perName,
+		changes: make(map[string][]Change),
+	}, nil
+}
+
+// Record implements engine.ResultRecorder
+func (c *Checker) Record(result engine.ExecutionResult) error {
+	// Only completed exchanges have a response to compare
+	if result.Error != "" {
+		return nil
+	}
+
+	current := Baseline{
+		Request:    result.RequestName,
+		RecordedAt: result.StartedAt.UTC(),
+		StatusCode: result.StatusCode,
+		Body:       DecodeBody(result.ResponseBody),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.baselinePath(result.RequestName)
+	baseline, err := loadBaseline(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && c.update) {
+		if err := saveBaseline(path, current); err != nil {
+			return err
+		}
+		log.Printf("Saved baseline for '%s' to %s", result.RequestName, path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var changes []Change
+	if baseline.StatusCode != current.StatusCode {
+		changes = append(changes, Change{Path: "(status)", Kind: Changed, Old: baseline.StatusCode, New: current.StatusCode})
+	}
+	ignore := append(append([]string(nil), c.ignore...), c.perName[result.RequestName]...)
+	changes = append(changes, Compare(baseline.Body, current.Body, ignore)...)
+
+	if len(changes) == 0 {
+		delete(c.changes, result.RequestName)
+		return nil
+	}
+
+	c.changes[result.RequestName] = changes
+	log.Printf("Response for '%s' differs from baseline (%d change(s)):", result.RequestName, len(changes))
+	for _, change := range changes {
+		log.Printf("  %s", change)
+	}
+	return nil
+}
+
+// Changed returns the number of requests whose latest response differed from its baseline
+func (c *Checker) Changed() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.changes)
+}
+
+// WriteReport prints the latest differences for every changed request
+func (c *Checker) WriteReport(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.changes) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(c.changes))
+	for name := range c.changes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Response changes")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s:\n", name)
+		for _, change := range c.changes[name] {
+			fmt.Fprintf(w, "  %s\n", change)
+		}
+	}
+}
+
+// baselinePath returns the file holding the baseline for a request
+func (c *Checker) baselinePath(name string) string {
+	return filepath.Join(c.dir, baselineFileName(name))
+}
+
+// baselineFileName turns a request name into a safe file name
+func baselineFileName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String() + ".json"
+}
+
+// DecodeBody parses a JSON response body, falling back to the raw text
+func DecodeBody(body []byte) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return string(body)
+	}
+	return decoded
+}
+
+func loadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	return &baseline, nil
+}
+
+func saveBaseline(path string, baseline Baseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package diff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func response(name string, status int, body string) engine.ExecutionResult {
+	return engine.ExecutionResult{
+		RequestName:  name,
+		StartedAt:    time.Now(),
+		StatusCode:   status,
+		ResponseBody: []byte(body),
+	}
+}
+
+func TestChecker_BaselineAndCompare(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "baselines")
+	requests := []spec.ScheduledRequest{
+		{Name: "Get User", Diff: &spec.DiffSpec{Ignore: []string{"fetched_at"}}},
+	}
+
+	checker, err := NewChecker(dir, false, []string{"request_id"}, requests)
+	if err != nil {
+		t.Fatalf("NewChecker failed: %v", err)
+	}
+
+	// First response becomes the baseline
+	if err := checker.Record(response("Get User", 200, `{"name": "a", "fetched_at": 1, "request_id": "x"}`)); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "get_user.json")); err != nil {
+		t.Fatalf("Expected baseline file: %v", err)
+	}
+
+	// Only ignored fields differ
+	checker.Record(response("Get User", 200, `{"name": "a", "fetched_at": 2, "request_id": "y"}`))
+	if checker.Changed() != 0 {
+		t.Errorf("Expected ignored fields not to count as changes")
+	}
+
+	// Errors have no response to compare
+	checker.Record(engine.ExecutionResult{RequestName: "Get User", Error: "connection refused"})
+	if checker.Changed() != 0 {
+		t.Errorf("Expected errored executions to be skipped")
+	}
+
+	checker.Record(response("Get User", 404, `{"name": "b"}`))
+	if checker.Changed() != 1 {
+		t.Fatalf("Expected 1 changed request, got %d", checker.Changed())
+	}
+
+	var buf bytes.Buffer
+	checker.WriteReport(&buf)
+	report := buf.String()
+	for _, want := range []string{"Response changes", "Get User:", "~ (status): 200 -> 404", `~ name: "a" -> "b"`} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to contain %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "fetched_at") {
+		t.Errorf("Expected removal of an ignored field not to be reported:\n%s", report)
+	}
+
+	// A matching response clears the request's changes
+	checker.Record(response("Get User", 200, `{"name": "a", "fetched_at": 3, "request_id": "z"}`))
+	if checker.Changed() != 0 {
+		t.Errorf("Expected matching response to clear changes")
+	}
+}
+
+func TestChecker_Update(t *testing.T) {
+	dir := t.TempDir()
+
+	checker, _ := NewChecker(dir, false, nil, nil)
+	checker.Record(response("text", 200, "v1"))
+
+	updater, _ := NewChecker(dir, true, nil, nil)
+	updater.Record(response("text", 200, "v2"))
+	if updater.Changed() != 0 {
+		t.Errorf("Expected update mode not to report changes")
+	}
+
+	checker.Record(response("text", 200, "v2"))
+	if checker.Changed() != 0 {
+		t.Errorf("Expected updated baseline to match")
+	}
+	checker.Record(response("text", 200, "v3"))
+	if checker.Changed() != 1 {
+		t.Errorf("Expected plain text change to be detected")
+	}
+}
+
+func TestBaselineFileName(t *testing.T) {
+	if got := baselineFileName("Orders / List v2"); got != "orders___list_v2.json" {
+		t.Errorf("Unexpected file name: %s", got)
+	}
+}
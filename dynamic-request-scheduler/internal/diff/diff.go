@@ -0,0 +1,194 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of change reported by Compare
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// maxValueLength bounds how much of a value is shown when formatting a change
+const maxValueLength = 80
+
+// Change describes a single difference between a baseline and a current value
+type Change struct {
+	// Path locates the value, e.g. "items[0].name"; empty means the whole value
+	Path string
+	Kind string
+	Old  interface{}
+	New  interface{}
+}
+
+// String renders the change as a single line
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "(body)"
+	}
+
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s: %s", path, formatValue(c.New))
+	case Removed:
+		return fmt.Sprintf("- %s: %s", path, formatValue(c.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", path, formatValue(c.Old), formatValue(c.New))
+	}
+}
+
+// Compare walks two decoded JSON values and returns their differences, in
+// path order. Paths matching any ignore pattern are skipped.
+func Compare(baseline, current interface{}, ignore []string) []Change {
+	matcher := newIgnoreMatcher(ignore)
+	var changes []Change
+	compare("", baseline, current, matcher, &changes)
+	return changes
+}
+
+func compare(path string, baseline, current interface{}, matcher *ignoreMatcher, changes *[]Change) {
+	if matcher.matches(path) {
+		return
+	}
+
+	switch oldValue := baseline.(type) {
+	case map[string]interface{}:
+		newValue, ok := current.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(oldValue)+len(newValue))
+		for key := range oldValue {
+			keys = append(keys, key)
+		}
+		for key := range newValue {
+			if _, ok := oldValue[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			child := joinKey(path, key)
+			oldChild, inOld := oldValue[key]
+			newChild, inNew := newValue[key]
+			switch {
+			case !inNew:
+				if !matcher.matches(child) {
+					*changes = append(*changes, Change{Path: child, Kind: Removed, Old: oldChild})
+				}
+			case !inOld:
+				if !matcher.matches(child) {
+					*changes = append(*changes, Change{Path: child, Kind: Added, New: newChild})
+				}
+			default:
+				compare(child, oldChild, newChild, matcher, changes)
+			}
+		}
+		return
+
+	case []interface{}:
+		newValue, ok := current.([]interface{})
+		if !ok {
+			break
+		}
+
+		for i := 0; i < len(oldValue) || i < len(newValue); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(newValue):
+				if !matcher.matches(child) {
+					*changes = append(*changes, Change{Path: child, Kind: Removed, Old: oldValue[i]})
+				}
+			case i >= len(oldValue):
+				if !matcher.matches(child) {
+					*changes = append(*changes, Change{Path: child, Kind: Added, New: newValue[i]})
+				}
+			default:
+				compare(child, oldValue[i], newValue[i], matcher, changes)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(baseline, current) {
+		*changes = append(*changes, Change{Path: path, Kind: Changed, Old: baseline, New: current})
+	}
+}
+
+// joinKey appends an object key to a path
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// formatValue renders a value as compact JSON, truncated for display
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	s := string(data)
+	if len(s) > maxValueLength {
+		s = s[:maxValueLength-3] + "..."
+	}
+	return s
+}
+
+// ignoreMatcher matches paths against ignore patterns. A pattern matches the
+// path itself and everything beneath it; "*" matches a single key and "[*]"
+// any array index.
+type ignoreMatcher struct {
+	patterns []*regexp.Regexp
+}
+
+func newIgnoreMatcher(ignore []string) *ignoreMatcher {
+	m := &ignoreMatcher{}
+	for _, pattern := range ignore {
+		pattern = strings.TrimPrefix(strings.TrimPrefix(pattern, "$"), ".")
+		if pattern == "" {
+			continue
+		}
+
+		var expr strings.Builder
+		expr.WriteString("^")
+		for i := 0; i < len(pattern); i++ {
+			switch {
+			case strings.HasPrefix(pattern[i:], "[*]"):
+				expr.WriteString(`\[\d+\]`)
+				i += 2
+			case pattern[i] == '*':
+				expr.WriteString(`[^.\[\]]+`)
+			default:
+				expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		}
+		expr.WriteString(`(?:$|[.\[])`)
+
+		m.patterns = append(m.patterns, regexp.MustCompile(expr.String()))
+	}
+	return m
+}
+
+func (m *ignoreMatcher) matches(path string) bool {
+	if path == "" {
+		return false
+	}
+	for _, pattern := range m.patterns {
+		if pattern.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid JSON %q: %v", s, err)
+	}
+	return v
+}
+
+func paths(changes []Change) []string {
+	var out []string
+	for _, change := range changes {
+		out = append(out, change.Kind+" "+change.Path)
+	}
+	return out
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseline string
+		current  string
+		ignore   []string
+		want     []string
+	}{
+		{
+			name:     "identical",
+			baseline: `{"a": 1, "b": [1, 2]}`,
+			current:  `{"b": [1, 2], "a": 1}`,
+		},
+		{
+			name:     "changed, added and removed keys",
+			baseline: `{"a": 1, "b": "x", "gone": true}`,
+			current:  `{"a": 2, "b": "x", "new": null}`,
+			want:     []string{"changed a", "removed gone", "added new"},
+		},
+		{
+			name:     "nested arrays",
+			baseline: `{"items": [{"id": 1}, {"id": 2}]}`,
+			current:  `{"items": [{"id": 1}, {"id": 3}, {"id": 4}]}`,
+			want:     []string{"changed items[1].id", "added items[2]"},
+		},
+		{
+			name:     "type change",
+			baseline: `{"a": {"b": 1}}`,
+			current:  `{"a": [1]}`,
+			want:     []string{"changed a"},
+		},
+		{
+			name:     "ignored paths",
+			baseline: `{"meta": {"ts": 1, "host": "a"}, "items": [{"id": 1, "at": "x"}], "name": "n"}`,
+			current:  `{"meta": {"ts": 2, "host": "b"}, "items": [{"id": 1, "at": "y"}], "name": "m"}`,
+			ignore:   []string{"$.meta", "items[*].at"},
+			want:     []string{"changed name"},
+		},
+		{
+			name:     "wildcard key",
+			baseline: `{"a": {"updated": 1, "v": 1}, "b": {"updated": 1}}`,
+			current:  `{"a": {"updated": 2, "v": 1}, "b": {"updated": 2}}`,
+			ignore:   []string{"*.updated"},
+		},
+		{
+			name:     "prefix does not match sibling keys",
+			baseline: `{"meta": 1, "metadata": 1}`,
+			current:  `{"meta": 2, "metadata": 2}`,
+			ignore:   []string{"meta"},
+			want:     []string{"changed metadata"},
+		},
+		{
+			name:     "plain text bodies",
+			baseline: `"ok"`,
+			current:  `"degraded"`,
+			want:     []string{"changed "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := paths(Compare(decode(t, tt.baseline), decode(t, tt.current), tt.ignore))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChange_String(t *testing.T) {
+	tests := []struct {
+		change Change
+		want   string
+	}{
+		{Change{Path: "a.b", Kind: Changed, Old: 1.0, New: "x"}, `~ a.b: 1 -> "x"`},
+		{Change{Path: "items[2]", Kind: Added, New: map[string]interface{}{"id": 4.0}}, `+ items[2]: {"id":4}`},
+		{Change{Path: "gone", Kind: Removed, Old: true}, `- gone: true`},
+		{Change{Kind: Changed, Old: "ok", New: "down"}, `~ (body): "ok" -> "down"`},
+	}
+
+	for _, tt := range tests {
+		if got := tt.change.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
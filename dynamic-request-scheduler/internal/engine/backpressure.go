@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// dispatchInterval is how often workers evaluate schedules normally
+	dispatchInterval = time.Second
+	// maxSlowdown caps how far dispatch is slowed while saturated
+	maxSlowdown = 10
+	// latencyRise is how far recent latency must rise above its baseline to
+	// count as the target struggling
+	latencyRise = 2.0
+	// latencyWeight is the weight of each new sample in the latency average
+	latencyWeight = 0.2
+	// minLatencySamples is how many completions are needed before latency
+	// is compared to its baseline
+	minLatencySamples = 5
+)
+
+// Pressure is a point-in-time view of dispatch load in continuous mode
+type Pressure struct {
+	// Busy is how many runners are executing a request, out of Runners
+	Busy    int
+	Runners int
+	// Queued is how many due executions are waiting for a runner
+	Queued int
+
+	// Latency is the recent average latency and Baseline the lowest it has
+	// been; both are zero until enough executions completed
+	Latency  time.Duration
+	Baseline time.Duration
+
+	// Saturated is set while every runner is busy and either executions are
+	// waiting or latency has risen well above its baseline. Dispatch is then
+	// slowed to Interval between scheduling passes.
+	Saturated bool
+	Interval  time.Duration
+}
+
+// LatencyRatio returns how many times the baseline recent latency is, or 0
+// before a baseline is known
+func (p Pressure) LatencyRatio() float64 {
+	if p.Baseline <= 0 {
+		return 0
+	}
+	return float64(p.Latency) / float64(p.Baseline)
+}
+
+// backpressure tracks runner load and target latency, slowing dispatch while
+// the pool is saturated so a struggling target gets room to recover instead
+// of a queue that only grows
+type backpressure struct {
+	mu        sync.Mutex
+	busy      int
+	samples   int
+	latency   float64
+	baseline  float64
+	saturated bool
+	interval  time.Duration
+}
+
+// begin records a runner starting an execution
+func (b *backpressure) begin() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.busy++
+}
+
+// end records a runner finishing an execution
+func (b *backpressure) end() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.busy--
+}
+
+// observe adds the latency of a completed execution
+func (b *backpressure) observe(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.samples++
+	if b.samples == 1 {
+		b.latency = float64(d)
+	} else {
+		b.latency += latencyWeight * (float64(d) - b.latency)
+	}
+	if b.samples >= minLatencySamples && (b.baseline == 0 || b.latency < b.baseline) {
+		b.baseline = b.latency
+	}
+}
+
+// snapshot returns the current pressure for a pool of runners with queued
+// executions waiting
+func (b *backpressure) snapshot(runners, queued int) Pressure {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	p := Pressure{
+		Busy:      b.busy,
+		Runners:   runners,
+		Queued:    queued,
+		Saturated: b.saturated,
+		Interval:  b.interval,
+	}
+	if b.baseline > 0 {
+		p.Latency = time.Duration(b.latency)
+		p.Baseline = time.Duration(b.baseline)
+	}
+	if p.Interval == 0 {
+		p.Interval = dispatchInterval
+	}
+	return p
+}
+
+// adjust re-evaluates saturation and returns the pause before the next
+// scheduling pass. Dispatch slows in proportion to how far latency has risen,
+// at least doubling the interval and at most slowing it by maxSlowdown.
+func (b *backpressure) adjust(runners, queued int) Pressure {
+	p := b.snapshot(runners, queued)
+	ratio := p.LatencyRatio()
+	saturated := p.Busy >= p.Runners && (p.Queued > 0 || ratio >= latencyRise)
+
+	slowdown := 1.0
+	if saturated {
+		slowdown = min(max(ratio, latencyRise), maxSlowdown)
+	}
+	interval := time.Duration(slowdown * float64(dispatchInterval))
+
+	b.mu.Lock()
+	changed := saturated != b.saturated
+	b.saturated = saturated
+	b.interval = interval
+	b.mu.Unlock()
+
+	p.Saturated = saturated
+	p.Interval = interval
+	if changed {
+		if saturated {
+			log.Printf("Dispatch saturated: %d/%d runners busy, %d queued, latency %.1fx baseline; slowing dispatch to every %v",
+				p.Busy, p.Runners, p.Queued, ratio, interval)
+		} else {
+			log.Printf("Dispatch recovered: %d/%d runners busy, %d queued", p.Busy, p.Runners, p.Queued)
+		}
+	}
+	return p
+}
+
+// Pressure returns the current dispatch load of a continuously running
+// scheduler
+func (s *Scheduler) Pressure() Pressure {
+	return s.pressure.snapshot(s.concurrency, s.queuedCount())
+}
+
+// queuedCount returns how many executions wait in the dispatch queue
+func (s *Scheduler) queuedCount() int {
+	s.mu.Lock()
+	queue := s.queue
+	s.mu.Unlock()
+	if queue == nil {
+		return 0
+	}
+	return queue.len()
+}
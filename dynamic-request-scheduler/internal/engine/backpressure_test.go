@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestBackpressure_Saturation(t *testing.T) {
+	var b backpressure
+
+	if p := b.adjust(2, 5); p.Saturated || p.Interval != dispatchInterval {
+		t.Errorf("Expected idle runners not to be saturated, got %+v", p)
+	}
+
+	b.begin()
+	b.begin()
+	if p := b.adjust(2, 0); p.Saturated {
+		t.Errorf("Expected busy runners with nothing waiting and no latency rise not to be saturated, got %+v", p)
+	}
+	p := b.adjust(2, 3)
+	if !p.Saturated || p.Interval != time.Duration(latencyRise*float64(dispatchInterval)) {
+		t.Errorf("Expected queued work on busy runners to at least double the interval, got %+v", p)
+	}
+
+	b.end()
+	if p := b.adjust(2, 3); p.Saturated || p.Interval != dispatchInterval {
+		t.Errorf("Expected a free runner to end saturation, got %+v", p)
+	}
+}
+
+func TestBackpressure_Latency(t *testing.T) {
+	var b backpressure
+	for i := 0; i < minLatencySamples; i++ {
+		b.observe(100 * time.Millisecond)
+	}
+	if p := b.snapshot(1, 0); p.Baseline != 100*time.Millisecond || p.LatencyRatio() != 1 {
+		t.Fatalf("Expected a 100ms baseline, got %+v", p)
+	}
+
+	// Latency climbing to about 5x the baseline slows dispatch about 5x
+	for i := 0; i < 30; i++ {
+		b.observe(500 * time.Millisecond)
+	}
+	b.begin()
+	p := b.adjust(1, 0)
+	if !p.Saturated || p.LatencyRatio() < 4.5 || p.Interval < 4*time.Second || p.Interval > 5*time.Second {
+		t.Errorf("Expected saturation from rising latency alone, got %+v (ratio %.1f)", p, p.LatencyRatio())
+	}
+
+	// The slowdown is capped
+	for i := 0; i < 50; i++ {
+		b.observe(10 * time.Second)
+	}
+	if p := b.adjust(1, 0); p.Interval != maxSlowdown*dispatchInterval {
+		t.Errorf("Expected the interval capped at %v, got %v", maxSlowdown*dispatchInterval, p.Interval)
+	}
+}
+
+func TestScheduler_Pressure(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{{
+		Name:     "hanging",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+	}}
+	scheduler := NewScheduler(requests, SchedulerConfig{Concurrency: 1})
+	done := make(chan error, 1)
+	go func() { done <- scheduler.Start() }()
+	defer func() {
+		scheduler.Stop()
+		<-done
+	}()
+	defer close(release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		p := scheduler.Pressure()
+		if p.Busy == 1 && p.Runners == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the single runner reported busy, got %+v", p)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultMaxResponseBytes is how much of each response body is kept when
+	// no limit is configured
+	DefaultMaxResponseBytes = 10 << 20
+	// maxPooledBody is the largest marshalled body whose buffer is returned to
+	// the pool; rarer, larger bodies are left to the garbage collector
+	maxPooledBody = 16 << 20
+)
+
+// bodyBuffers reuses the buffers request bodies are marshalled into, so
+// schedules with large templated bodies don't allocate a fresh one per firing
+var bodyBuffers = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// pooledBody is a marshalled request body in a pooled buffer. The buffer goes
+// back to the pool once the sender and every reader handed to the transport
+// have released it, since the transport may close a body after Do returns.
+type pooledBody struct {
+	buf  *bytes.Buffer
+	refs atomic.Int32
+}
+
+// marshalBody encodes v as JSON into a pooled buffer, held by the caller
+// until it calls release
+func marshalBody(v interface{}) (*pooledBody, error) {
+	buf := bodyBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		bodyBuffers.Put(buf)
+		return nil, err
+	}
+	// Match json.Marshal, which adds no trailing newline
+	buf.Truncate(buf.Len() - 1)
+
+	body := &pooledBody{buf: buf}
+	body.refs.Store(1)
+	return body, nil
+}
+
+// Len returns the size of the marshalled body
+func (b *pooledBody) Len() int {
+	return b.buf.Len()
+}
+
+// reader returns a new reader over the body, holding it until closed
+func (b *pooledBody) reader() io.ReadCloser {
+	b.refs.Add(1)
+	return &pooledReader{Reader: bytes.NewReader(b.buf.Bytes()), body: b}
+}
+
+// release drops one hold on the body, pooling the buffer after the last
+func (b *pooledBody) release() {
+	if b.refs.Add(-1) != 0 {
+		return
+	}
+	if b.buf.Cap() <= maxPooledBody {
+		bodyBuffers.Put(b.buf)
+	}
+}
+
+// attach sets the body on req, which may rewind it for redirects and retries
+func (b *pooledBody) attach(req *http.Request) {
+	req.Body = b.reader()
+	req.ContentLength = int64(b.Len())
+	req.GetBody = func() (io.ReadCloser, error) { return b.reader(), nil }
+}
+
+// pooledReader reads a pooledBody, releasing it once on Close
+type pooledReader struct {
+	*bytes.Reader
+	body   *pooledBody
+	closed atomic.Bool
+}
+
+func (r *pooledReader) Close() error {
+	if r.closed.CompareAndSwap(false, true) {
+		r.body.release()
+	}
+	return nil
+}
+
+// attachFile streams the file at path as the body of req, reopening it when
+// the body is rewound, and returns the content type its extension implies
+func attachFile(req *http.Request, path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open body file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to stat body file: %w", err)
+	}
+
+	req.Body = file
+	req.ContentLength = info.Size()
+	req.GetBody = func() (io.ReadCloser, error) { return os.Open(path) }
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return contentType, nil
+}
+
+// readLimited reads up to limit bytes of r, discarding the rest, and returns
+// what was kept along with the full size of r. A limit below 1 keeps
+// everything.
+func readLimited(r io.Reader, limit int64) ([]byte, int64, error) {
+	if limit <= 0 {
+		data, err := io.ReadAll(r)
+		return data, int64(len(data)), err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit))
+	if err != nil {
+		return data, int64(len(data)), err
+	}
+	rest, err := io.Copy(io.Discard, r)
+	return data, int64(len(data)) + rest, err
+}
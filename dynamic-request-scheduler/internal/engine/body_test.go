@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestMarshalBody(t *testing.T) {
+	value := map[string]interface{}{"id": 1, "note": "<b>&</b>"}
+	body, err := marshalBody(value)
+	if err != nil {
+		t.Fatalf("marshalBody failed: %v", err)
+	}
+	want, _ := json.Marshal(value)
+	if got := body.buf.String(); got != string(want) {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	// The buffer stays valid while a reader holds the body
+	reader := body.reader()
+	body.release()
+	data, _ := io.ReadAll(reader)
+	if string(data) != string(want) {
+		t.Errorf("Expected reader to see %s, got %s", want, data)
+	}
+	reader.Close()
+	reader.Close()
+	if refs := body.refs.Load(); refs != 0 {
+		t.Errorf("Expected every hold released once, got %d refs", refs)
+	}
+
+	if _, err := marshalBody(func() {}); err == nil {
+		t.Error("Expected an error marshalling a function")
+	}
+}
+
+func TestReadLimited(t *testing.T) {
+	data, size, err := readLimited(strings.NewReader("0123456789"), 4)
+	if err != nil || string(data) != "0123" || size != 10 {
+		t.Errorf("Expected 0123 of 10 bytes, got %q of %d (%v)", data, size, err)
+	}
+
+	data, size, err = readLimited(strings.NewReader("0123456789"), 0)
+	if err != nil || string(data) != "0123456789" || size != 10 {
+		t.Errorf("Expected the whole body without a limit, got %q of %d (%v)", data, size, err)
+	}
+}
+
+func TestHTTPClient_SendRequest_LimitResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("x"), 1000))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(time.Second)
+	client.LimitResponse(100)
+	resp, err := client.SendRequest(context.Background(), &spec.ResolvedRequest{Method: "GET", URL: server.URL})
+	if err != nil {
+		t.Fatalf("SendRequest failed: %v", err)
+	}
+	if len(resp.Body) != 100 || resp.ContentLength != 1000 || !resp.Truncated {
+		t.Errorf("Expected 100 of 1000 bytes kept, got %d of %d (truncated %v)", len(resp.Body), resp.ContentLength, resp.Truncated)
+	}
+	if !strings.Contains(resp.String(), "1000 bytes, truncated") {
+		t.Errorf("Expected truncation in %q", resp.String())
+	}
+}
+
+func TestHTTPClient_SendRequest_BodyFile(t *testing.T) {
+	payload := strings.Repeat(`{"id":1}`, 1000)
+	path := filepath.Join(t.TempDir(), "payload.json")
+	if err := os.WriteFile(path, []byte(payload), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if string(data) != payload || r.ContentLength != int64(len(payload)) {
+			t.Errorf("Expected the file streamed with its length, got %d bytes (Content-Length %d)", len(data), r.ContentLength)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Expected a content type from the file extension, got %s", got)
+		}
+		// A redirect that keeps the method rewinds the body by reopening the file
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/moved", http.StatusTemporaryRedirect)
+		}
+	}))
+	defer server.Close()
+
+	resolved := &spec.ResolvedRequest{Method: "PUT", URL: server.URL + "/", BodyFile: path}
+	resp, err := NewHTTPClient(time.Second).SendRequest(context.Background(), resolved)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the redirected upload to succeed, got %v (%v)", resp, err)
+	}
+
+	resolved.BodyFile = filepath.Join(t.TempDir(), "missing.json")
+	if _, err := NewHTTPClient(time.Second).SendRequest(context.Background(), resolved); err == nil {
+		t.Error("Expected an error for a missing body file")
+	}
+}
+
+func TestHTTPClient_SendRequest_PooledBodyRedirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["id"] != "abc" {
+			t.Errorf("Expected the body on %s, got %v (%v)", r.URL.Path, body, err)
+		}
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/moved", http.StatusPermanentRedirect)
+		}
+	}))
+	defer server.Close()
+
+	resolved := &spec.ResolvedRequest{Method: "POST", URL: server.URL + "/", Body: map[string]interface{}{"id": "abc"}}
+	resp, err := NewHTTPClient(time.Second).SendRequest(context.Background(), resolved)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the redirected request to succeed, got %v (%v)", resp, err)
+	}
+}
+
+func BenchmarkHTTPClient_SendRequest_LargeBody(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+
+	items := make([]interface{}, 20000)
+	for i := range items {
+		items[i] = map[string]interface{}{"sku": "ABC-123", "quantity": i}
+	}
+	resolved := &spec.ResolvedRequest{Method: "POST", URL: server.URL, Body: map[string]interface{}{"items": items}}
+	client := NewHTTPClient(time.Second)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.SendRequest(context.Background(), resolved); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package engine
+
+// ANSI escape sequences used for colored log output
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiCyan   = "\033[36m"
+)
+
+// classColors maps status classes to their log color
+var classColors = map[string]string{
+	Class2xx:     ansiGreen,
+	Class3xx:     ansiCyan,
+	Class4xx:     ansiYellow,
+	Class5xx:     ansiRed,
+	ClassTimeout: ansiRed,
+	ClassError:   ansiRed,
+}
+
+// colorize wraps text in the color for a status class when color output is enabled
+func (s *Scheduler) colorize(class, text string) string {
+	return s.paint(classColors[class], text)
+}
+
+// paint wraps text in an ANSI color when color output is enabled
+func (s *Scheduler) paint(color, text string) string {
+	if !s.color {
+		return text
+	}
+	return color + text + ansiReset
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestScheduler_Colorize(t *testing.T) {
+	plain := NewScheduler(nil, SchedulerConfig{})
+	if got := plain.colorize(Class5xx, "500"); got != "500" {
+		t.Errorf("Expected no color when disabled, got %q", got)
+	}
+
+	colored := NewScheduler(nil, SchedulerConfig{Color: true})
+	tests := map[string]string{
+		Class2xx:     ansiGreen,
+		Class3xx:     ansiCyan,
+		Class4xx:     ansiYellow,
+		Class5xx:     ansiRed,
+		ClassTimeout: ansiRed,
+		ClassError:   ansiRed,
+	}
+	for class, color := range tests {
+		if got := colored.colorize(class, "x"); got != color+"x"+ansiReset {
+			t.Errorf("%s: unexpected colorized text %q", class, got)
+		}
+	}
+}
+
+func TestScheduler_ColoredLogOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	scheduler := NewScheduler([]spec.ScheduledRequest{{
+		Name:     "missing",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+	}}, SchedulerConfig{Once: true, Color: true})
+
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "completed: "+ansiYellow+"404 Not Found"+ansiReset) {
+		t.Errorf("Expected yellow 404 status in log output:\n%s", buf.String())
+	}
+}
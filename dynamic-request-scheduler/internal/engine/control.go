@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// RequestStatus is a point-in-time view of a scheduled request
+type RequestStatus struct {
+	Name     string
+	NextRun  time.Time
+	LastRun  time.Time
+	Paused   bool
+	InFlight int
+}
+
+// Statuses returns the current status of every configured request, in config order
+func (s *Scheduler) Statuses() []RequestStatus {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	now := time.Now()
+	statuses := make([]RequestStatus, 0, len(s.requests))
+	for _, req := range s.requests {
+		status := RequestStatus{
+			Name:     req.Name,
+			LastRun:  s.lastRun[req.Name],
+			Paused:   s.paused[req.Name],
+			InFlight: s.inFlight[req.Name],
+		}
+
+		base := s.startedAt
+		if !status.LastRun.IsZero() {
+			base = status.LastRun
+		}
+		if base.IsZero() {
+			base = now
+		}
+		switch {
+		case s.completed(&req):
+			// Completed schedules have no next run
+		case req.Schedule.Template != nil && !s.templateDue[req.Name].IsZero():
+			status.NextRun = s.templateDue[req.Name]
+		default:
+			status.NextRun = s.nextRunAfter(base, req.Schedule)
+		}
+
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// nextRunAfter computes the next fire time of a schedule relative to base.
+// Template schedules are evaluated against a throwaway context so previews
+// don't advance the sequence used by real executions.
+func (s *Scheduler) nextRunAfter(base time.Time, schedule spec.ScheduleSpec) time.Time {
+	scheduleEngine := spec.NewScheduleEngine()
+
+	var next time.Time
+	var err error
+	if schedule.Template != nil {
+		next, err = scheduleEngine.ComputeNextRunWithTemplate(base, schedule, spec.NewTemplateEngine(nil))
+	} else {
+		next, err = scheduleEngine.ComputeNextRun(base, schedule)
+	}
+	if err != nil {
+		return time.Time{}
+	}
+	return next
+}
+
+// Pause stops the named request from being dispatched until resumed
+func (s *Scheduler) Pause(name string) error {
+	return s.setPaused(name, true)
+}
+
+// Resume re-enables dispatch of a paused request
+func (s *Scheduler) Resume(name string) error {
+	return s.setPaused(name, false)
+}
+
+func (s *Scheduler) setPaused(name string, paused bool) error {
+	if s.findRequest(name) == nil {
+		return fmt.Errorf("unknown request: %s", name)
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.paused == nil {
+		s.paused = make(map[string]bool)
+	}
+	s.paused[name] = paused
+	return nil
+}
+
+// IsPaused reports whether the named request is paused
+func (s *Scheduler) IsPaused(name string) bool {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.paused[name]
+}
+
+// Trigger executes the named request immediately, outside of its schedule.
+// Triggered executions still respect the concurrency limit.
+func (s *Scheduler) Trigger(name string) error {
+	req := s.findRequest(name)
+	if req == nil {
+		return fmt.Errorf("unknown request: %s", name)
+	}
+
+	s.mu.Lock()
+	evaluator := s.evaluator
+	queue := s.queue
+	s.mu.Unlock()
+
+	if evaluator == nil || !queue.push(dispatch{req: req}) {
+		return fmt.Errorf("scheduler is not running")
+	}
+
+	log.Printf("Manually triggering request '%s'", name)
+	return nil
+}
+
+// findRequest returns the configured request with the given name, or nil
+func (s *Scheduler) findRequest(name string) *spec.ScheduledRequest {
+	for i := range s.requests {
+		if s.requests[i].Name == name {
+			return &s.requests[i]
+		}
+	}
+	return nil
+}
+
+// markStarted records that an execution of the named request has begun
+func (s *Scheduler) markStarted(name string, at time.Time) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.inFlight == nil {
+		s.inFlight = make(map[string]int)
+		s.lastRun = make(map[string]time.Time)
+	}
+	s.inFlight[name]++
+	s.lastRun[name] = at
+}
+
+// markFinished records that an execution of the named request has ended
+func (s *Scheduler) markFinished(name string) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.inFlight[name]--
+}
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestScheduler_PauseResume(t *testing.T) {
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "relative",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("http://localhost")},
+		},
+	}
+	scheduler := NewScheduler(requests, SchedulerConfig{})
+
+	if err := scheduler.Pause("missing"); err == nil {
+		t.Error("Expected error pausing unknown request")
+	}
+
+	if err := scheduler.Pause("relative"); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if !scheduler.IsPaused("relative") {
+		t.Error("Expected request to be paused")
+	}
+	if scheduler.shouldRunRequest(&requests[0]) {
+		t.Error("Paused request should not run")
+	}
+
+	if err := scheduler.Resume("relative"); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if !scheduler.shouldRunRequest(&requests[0]) {
+		t.Error("Resumed request should run")
+	}
+}
+
+func TestScheduler_Statuses(t *testing.T) {
+	epoch := time.Now().Add(time.Hour).Unix()
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "epoch",
+			Schedule: spec.ScheduleSpec{Epoch: &epoch},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("http://localhost")},
+		},
+		{
+			Name:     "relative",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("5m")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("http://localhost")},
+		},
+	}
+	scheduler := NewScheduler(requests, SchedulerConfig{})
+	scheduler.Pause("relative")
+
+	statuses := scheduler.Statuses()
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 statuses, got %d", len(statuses))
+	}
+	if statuses[0].Name != "epoch" || statuses[0].NextRun.Unix() != epoch {
+		t.Errorf("Unexpected epoch status: %+v", statuses[0])
+	}
+	if !statuses[1].Paused {
+		t.Error("Expected relative request to be reported as paused")
+	}
+	if until := time.Until(statuses[1].NextRun); until < 4*time.Minute || until > 5*time.Minute {
+		t.Errorf("Expected relative next run ~5m away, got %v", until)
+	}
+}
+
+func TestScheduler_Trigger(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer server.Close()
+
+	epoch := time.Now().Add(time.Hour).Unix()
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "future",
+			Schedule: spec.ScheduleSpec{Epoch: &epoch},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+	}
+	scheduler := NewScheduler(requests, SchedulerConfig{})
+
+	if err := scheduler.Trigger("future"); err == nil {
+		t.Error("Expected error triggering before start")
+	}
+
+	go scheduler.Start()
+	defer scheduler.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		err := scheduler.Trigger("future")
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Trigger never succeeded: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := scheduler.Trigger("missing"); err == nil {
+		t.Error("Expected error triggering unknown request")
+	}
+
+	for atomic.LoadInt32(&hits) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Triggered request was never sent")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
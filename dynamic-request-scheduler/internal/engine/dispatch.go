@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"sync"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// dispatch is an execution waiting for a free runner
+type dispatch struct {
+	req *spec.ScheduledRequest
+
+	// claimed is set for scheduled executions, which were counted by
+	// claimDispatch, or by claimCopies for the extra copies a firing fans
+	// out to, and must be released when they start and finish
+	claimed bool
+
+	// fanned is set for all but the first copy of a fanned out firing,
+	// which only wake their timeline's worker when they end rather than
+	// putting the request back on it
+	fanned bool
+
+	// execution numbers executions in the order they were queued, from 1,
+	// and selects the sequence value they are evaluated with
+	execution int64
+
+	// firing numbers the executions of this request in the order they were
+	// queued, from 1, and with the request name selects the seed its random
+	// template values are drawn from
+	firing int64
+
+	// timeline is the worker timeline a scheduled execution was taken from,
+	// which its request is put back on when the execution ends; nil for
+	// manual triggers
+	timeline *timeline
+}
+
+// dispatchQueue hands executions to a fixed pool of runners in the order
+// they were queued. Pushing never blocks, so a saturated pool cannot stall
+// the scheduling loop, and waiting executions start first come, first served.
+type dispatchQueue struct {
+	mu     sync.Mutex
+	ready  *sync.Cond
+	items  []dispatch
+	closed bool
+	pushed int64
+
+	// firings counts the executions queued for each request
+	firings map[string]int64
+}
+
+func newDispatchQueue() *dispatchQueue {
+	q := &dispatchQueue{}
+	q.ready = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues an execution, returning false once the queue is closed
+func (q *dispatchQueue) push(d dispatch) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return false
+	}
+	q.pushed++
+	d.execution = q.pushed
+	if q.firings == nil {
+		q.firings = make(map[string]int64)
+	}
+	q.firings[d.req.Name]++
+	d.firing = q.firings[d.req.Name]
+	q.items = append(q.items, d)
+	q.ready.Signal()
+	return true
+}
+
+// nextFiring returns the firing number the next execution of the named
+// request queued will get
+func (q *dispatchQueue) nextFiring(name string) int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.firings[name] + 1
+}
+
+// pop waits for the oldest queued execution, returning false once the
+// queue is closed and empty
+func (q *dispatchQueue) pop() (dispatch, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 && !q.closed {
+		q.ready.Wait()
+	}
+	if len(q.items) == 0 {
+		return dispatch{}, false
+	}
+	d := q.items[0]
+	q.items[0] = dispatch{}
+	q.items = q.items[1:]
+	return d, true
+}
+
+// close stops further pushes; runners finish what is queued and then exit
+func (q *dispatchQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.ready.Broadcast()
+}
+
+// len returns how many executions are waiting
+func (q *dispatchQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// startRunners starts one runner per concurrency slot, each executing
+// queued requests until the queue is closed and drained
+func (s *Scheduler) startRunners(queue *dispatchQueue, evaluator *spec.Evaluator, wg *sync.WaitGroup) {
+	for i := 0; i < s.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				d, ok := queue.pop()
+				if !ok {
+					return
+				}
+				s.run(d, evaluator)
+			}
+		}()
+	}
+}
+
+// run executes a dispatched request, releasing its claim once it ends.
+// Executions still queued when the scheduler is stopped are skipped, and a
+// panic ends only the execution, leaving the runner to carry on.
+func (s *Scheduler) run(d dispatch, evaluator *spec.Evaluator) {
+	defer isolate(d.req.Name)
+	if d.timeline != nil {
+		// Deferred first so it runs after finishDispatch, when the worker can
+		// see the execution has ended
+		if d.fanned {
+			defer d.timeline.signal()
+		} else {
+			defer s.requeue(d.timeline, d.req)
+		}
+	}
+	if d.claimed {
+		s.unqueue(d.req.Name)
+		defer s.finishDispatch()
+	}
+	if s.ctx.Err() != nil {
+		return
+	}
+	s.pressure.begin()
+	defer s.pressure.end()
+	s.executeRequest(d.req, evaluator.ForRequest(d.execution, d.req.Name, d.firing))
+}
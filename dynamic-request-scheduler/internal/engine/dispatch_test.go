@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestDispatchQueue_Order(t *testing.T) {
+	queue := newDispatchQueue()
+	for _, name := range []string{"a", "b", "c"} {
+		if !queue.push(dispatch{req: &spec.ScheduledRequest{Name: name}}) {
+			t.Fatalf("Expected push of %s to succeed", name)
+		}
+	}
+	queue.close()
+
+	if queue.push(dispatch{req: &spec.ScheduledRequest{Name: "late"}}) {
+		t.Error("Expected push after close to fail")
+	}
+
+	var got []string
+	for {
+		d, ok := queue.pop()
+		if !ok {
+			break
+		}
+		got = append(got, d.req.Name)
+	}
+	if len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("Expected queued executions drained in order, got %v", got)
+	}
+}
+
+func TestDispatchQueue_PopWaits(t *testing.T) {
+	queue := newDispatchQueue()
+	popped := make(chan string)
+	go func() {
+		d, _ := queue.pop()
+		popped <- d.req.Name
+	}()
+
+	select {
+	case <-popped:
+		t.Fatal("Expected pop to wait for a push")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	queue.push(dispatch{req: &spec.ScheduledRequest{Name: "a"}})
+	if name := <-popped; name != "a" {
+		t.Errorf("Expected a, got %s", name)
+	}
+}
+
+func TestScheduler_ClaimDispatchCoalescesQueued(t *testing.T) {
+	req := &spec.ScheduledRequest{Name: "relative", Schedule: spec.ScheduleSpec{Relative: stringPtr("1s"), Repeat: true}}
+	scheduler := NewScheduler([]spec.ScheduledRequest{*req}, SchedulerConfig{})
+
+	if !scheduler.claimDispatch(req) {
+		t.Fatal("Expected the first claim to succeed")
+	}
+	if scheduler.claimDispatch(req) {
+		t.Error("Expected a request still waiting in the queue not to be queued again")
+	}
+
+	scheduler.unqueue(req.Name)
+	if !scheduler.claimDispatch(req) {
+		t.Error("Expected the request to be claimable once it left the queue")
+	}
+}
+
+// minDispatchThroughput is the documented floor, in executions per second,
+// for once-mode dispatch against an in-process server. Typical hardware runs
+// well over ten times faster, and several times faster even under the race
+// detector; the floor is kept low so only real regressions fail.
+const minDispatchThroughput = 500
+
+// dispatchRun builds a quiet once-mode scheduler executing a templated POST
+// count times against server
+func dispatchRun(server *httptest.Server, count int, recorder ResultRecorder) *Scheduler {
+	requests := []spec.ScheduledRequest{{
+		Name:     "dispatch",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP: spec.HttpRequestSpec{
+			Method:  "POST",
+			URL:     spec.NewLiteralString(server.URL + "/orders"),
+			Headers: spec.LiteralHeaders(map[string]string{"X-Request-ID": "{{ uuid }}"}),
+			Body:    spec.NewLiteralAny(map[string]interface{}{"id": "{{ uuid }}", "quantity": "{{ randInt 1 10 }}"}),
+		},
+	}}
+	return NewScheduler(requests, SchedulerConfig{
+		Once:        true,
+		Count:       count,
+		Concurrency: 50,
+		Quiet:       true,
+		Recorders:   []ResultRecorder{recorder},
+	})
+}
+
+func BenchmarkScheduler_Dispatch(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	scheduler := dispatchRun(server, b.N, &recordingRecorder{})
+	b.ReportAllocs()
+	b.ResetTimer()
+	if err := scheduler.Start(); err != nil {
+		b.Fatalf("Start failed: %v", err)
+	}
+}
+
+func TestScheduler_DispatchThroughput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping throughput test in short mode")
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	const executions = 2000
+	recorder := &recordingRecorder{}
+	scheduler := dispatchRun(server, executions, recorder)
+
+	start := time.Now()
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if len(recorder.results) != executions {
+		t.Fatalf("Expected %d executions, got %d", executions, len(recorder.results))
+	}
+	for _, result := range recorder.results {
+		if result.Error != "" || result.StatusCode != http.StatusOK {
+			t.Fatalf("Expected every execution to succeed, got %+v", result)
+		}
+	}
+	if rate := float64(executions) / elapsed.Seconds(); rate < minDispatchThroughput {
+		t.Errorf("Dispatch throughput %.0f executions/s is below the %d/s target", rate, minDispatchThroughput)
+	}
+	t.Logf("Dispatched %d executions in %v (%.0f/s)", executions, elapsed, float64(executions)/elapsed.Seconds())
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// everyState tracks the firings of an every schedule
+type everyState struct {
+	// fired counts the executions dispatched, and tick is the interval tick
+	// the last one was dispatched for
+	fired int
+	tick  time.Time
+}
+
+// everyDueTime returns when an every schedule next fires: as the run starts,
+// then on the tick after the one last dispatched, jitter included. ok is
+// false once it has fired Count times.
+func (s *Scheduler) everyDueTime(req *spec.ScheduledRequest) (due time.Time, ok bool, err error) {
+	interval, err := time.ParseDuration(*req.Schedule.Every)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid every interval '%s': %w", *req.Schedule.Every, err)
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	state, fired := s.every[req.Name]
+	switch {
+	case !fired:
+		return s.clockStart.Add(s.nextJitter(req)), true, nil
+	case req.Schedule.Count > 0 && state.fired >= req.Schedule.Count:
+		return time.Time{}, false, nil
+	default:
+		return state.tick.Add(interval + s.nextJitter(req)), true, nil
+	}
+}
+
+// countEvery records a dispatch of an every schedule against the latest tick
+// at or before now, so ticks missed while a run overran are skipped rather
+// than fired in a burst; callers hold stateMu
+func (s *Scheduler) countEvery(req *spec.ScheduledRequest) {
+	interval, err := time.ParseDuration(*req.Schedule.Every)
+	if err != nil || interval <= 0 {
+		return
+	}
+	if s.every == nil {
+		s.every = make(map[string]*everyState)
+	}
+	state, ok := s.every[req.Name]
+	if !ok {
+		state = &everyState{}
+		s.every[req.Name] = state
+	}
+
+	state.fired++
+	state.tick = s.clockStart
+	if elapsed := s.now().Sub(s.clockStart); elapsed > 0 {
+		state.tick = s.clockStart.Add(elapsed / interval * interval)
+	}
+}
+
+// completed reports whether a request has fired every execution its
+// schedule allows; callers hold stateMu
+func (s *Scheduler) completed(req *spec.ScheduledRequest) bool {
+	if req.Schedule.Every != nil && req.Schedule.Count > 0 {
+		state, ok := s.every[req.Name]
+		return ok && state.fired >= req.Schedule.Count
+	}
+	return isOneShot(req.Schedule) && s.fired[req.Name]
+}
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/amqp"
+	"local-dev-tools/dynamic-request-scheduler/internal/aws"
+	"local-dev-tools/dynamic-request-scheduler/internal/grpc"
+	"local-dev-tools/dynamic-request-scheduler/internal/kafka"
+	"local-dev-tools/dynamic-request-scheduler/internal/nats"
+	"local-dev-tools/dynamic-request-scheduler/internal/redis"
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// Executor sends resolved requests of one type. Every request type, HTTP
+// included, is an executor keyed by the type's name, so a new target is
+// added by registering one rather than by changing the scheduler.
+//
+// Executors report what they received as an HTTPResponse. Those for
+// message brokers and other non-HTTP targets describe a successful delivery
+// with a 200 status, as deliveredResponse does, so every type counts alike
+// in summaries, history and notifications. An error may be returned
+// alongside a response, such as a SOAP fault, to fail the execution while
+// keeping what was received.
+type Executor interface {
+	Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error)
+}
+
+// ExecutorFunc adapts a function to Executor
+type ExecutorFunc func(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error)
+
+// Execute calls f
+func (f ExecutorFunc) Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	return f(ctx, resolved)
+}
+
+// defaultExecutors returns the built-in executor for every request type.
+// Types sharing a transport share its client and connection pool.
+func defaultExecutors(timeout time.Duration, maxResponse int64) map[string]Executor {
+	httpClient := NewHTTPClient(timeout)
+	httpClient.LimitResponse(maxResponse)
+	awsClient := aws.NewClient(timeout)
+	return map[string]Executor{
+		spec.TypeHTTP:    httpClient,
+		spec.TypeSSE:     NewSSEClient(timeout),
+		spec.TypeKafka:   kafkaExecutor{client: kafka.NewClient(timeout, clientID)},
+		spec.TypeAMQP:    amqpExecutor{client: amqp.NewClient(timeout, clientID)},
+		spec.TypeRedis:   redisExecutor{client: redis.NewClient(timeout)},
+		spec.TypeNATS:    natsExecutor{client: nats.NewClient(timeout, clientID)},
+		spec.TypeSQS:     sqsExecutor{client: awsClient},
+		spec.TypeSNS:     snsExecutor{client: awsClient},
+		spec.TypeSOAP:    soapExecutor{client: httpClient},
+		spec.TypeJSONRPC: jsonrpcExecutor{client: httpClient},
+		spec.TypeGRPC:    grpcExecutor{client: grpc.NewClient(timeout)},
+	}
+}
+
+// RegisterExecutor sets the executor for a request type, replacing any
+// existing one. It must be called before Start.
+func (s *Scheduler) RegisterExecutor(requestType string, executor Executor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.executors[requestType] = executor
+}
+
+// send executes a resolved request with the executor for its type
+func (s *Scheduler) send(ctx context.Context, resolved *spec.ResolvedRequest) (resp *HTTPResponse, err error) {
+	executor, ok := s.executors[resolved.Type()]
+	if !ok {
+		return nil, fmt.Errorf("no executor registered for request type %q", resolved.Type())
+	}
+	defer recoverPanic(&err, resolved.Type()+" executor")
+	return executor.Execute(ctx, resolved)
+}
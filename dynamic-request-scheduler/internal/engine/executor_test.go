@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestDefaultExecutors(t *testing.T) {
+	executors := defaultExecutors(0, 0)
+	types := []string{spec.TypeHTTP, spec.TypeSSE, spec.TypeKafka, spec.TypeAMQP, spec.TypeRedis,
+		spec.TypeNATS, spec.TypeSQS, spec.TypeSNS, spec.TypeSOAP, spec.TypeJSONRPC, spec.TypeGRPC}
+	for _, requestType := range types {
+		if executors[requestType] == nil {
+			t.Errorf("Expected a built-in executor for %s requests", requestType)
+		}
+	}
+	if len(executors) != len(types) {
+		t.Errorf("Expected %d built-in executors, got %d", len(types), len(executors))
+	}
+}
+
+func TestScheduler_RegisterExecutor(t *testing.T) {
+	var sent []string
+	fake := ExecutorFunc(func(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+		sent = append(sent, resolved.Method+" "+resolved.URL)
+		return &HTTPResponse{StatusCode: http.StatusNoContent, Status: "204 No Content"}, nil
+	})
+
+	requests := []spec.ScheduledRequest{{
+		Name:     "ping",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "POST", URL: spec.NewLiteralString("http://example.invalid/ping")},
+	}}
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{Once: true, Recorders: []ResultRecorder{recorder}})
+	scheduler.RegisterExecutor(spec.TypeHTTP, fake)
+
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if len(sent) != 1 || sent[0] != "POST http://example.invalid/ping" {
+		t.Errorf("Expected the registered executor to send the request, got %v", sent)
+	}
+	if len(recorder.results) != 1 || recorder.results[0].StatusCode != http.StatusNoContent {
+		t.Errorf("Expected the executor's response to be recorded, got %+v", recorder.results)
+	}
+}
+
+func TestScheduler_ConfigExecutors(t *testing.T) {
+	failure := errors.New("broker unavailable")
+	scheduler := NewScheduler(nil, SchedulerConfig{
+		Executors: map[string]Executor{
+			spec.TypeKafka: ExecutorFunc(func(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+				return nil, failure
+			}),
+		},
+	})
+
+	_, err := scheduler.send(context.Background(), &spec.ResolvedRequest{Kafka: &spec.KafkaTarget{Topic: "orders"}})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected the configured Kafka executor to run, got %v", err)
+	}
+	if _, ok := scheduler.executors[spec.TypeHTTP].(*HTTPClient); !ok {
+		t.Errorf("Expected other types to keep their built-in executors")
+	}
+}
+
+func TestScheduler_SendUnknownType(t *testing.T) {
+	scheduler := NewScheduler(nil, SchedulerConfig{})
+	delete(scheduler.executors, spec.TypeHTTP)
+
+	if _, err := scheduler.send(context.Background(), &spec.ResolvedRequest{Method: "GET", URL: "http://localhost"}); err == nil {
+		t.Errorf("Expected an error when no executor handles the request type")
+	}
+}
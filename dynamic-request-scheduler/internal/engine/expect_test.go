@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// expectRequests returns one request per path of the server, each expecting
+// the given response
+func expectRequests(url string, expect spec.ExpectSpec, paths ...string) []spec.ScheduledRequest {
+	var requests []spec.ScheduledRequest
+	for _, path := range paths {
+		requests = append(requests, spec.ScheduledRequest{
+			Name:     path,
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(url + "/" + path)},
+			Expect:   spec.Expectations{expect},
+		})
+	}
+	return requests
+}
+
+func TestScheduler_ExpectContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok": true}`))
+		case "/html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<h1>Maintenance</h1>`))
+		}
+	}))
+	defer server.Close()
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(expectRequests(server.URL, spec.ExpectSpec{ContentType: "json"}, "json", "html"), SchedulerConfig{
+		Once:        true,
+		Concurrency: 1,
+		Recorders:   []ResultRecorder{recorder},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if len(recorder.results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(recorder.results))
+	}
+	for _, result := range recorder.results {
+		switch result.RequestName {
+		case "json":
+			if !result.Success() || len(result.Unmet) != 0 {
+				t.Errorf("Expected the JSON response to meet expectations, got %v", result.Unmet)
+			}
+		case "html":
+			if result.Success() || len(result.Unmet) != 1 || result.StatusClass() != Class2xx {
+				t.Errorf("Expected the HTML response to fail its content type expectation with a 2xx status, got %v (%s)", result.Unmet, result.StatusClass())
+			}
+		}
+	}
+}
+
+func TestScheduler_ExpectXPath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		switch r.URL.Path {
+		case "/open":
+			w.Write([]byte(`<order id="7"><status>open</status></order>`))
+		case "/closed":
+			w.Write([]byte(`<order id="7"><status>closed</status></order>`))
+		}
+	}))
+	defer server.Close()
+
+	recorder := &recordingRecorder{}
+	expect := spec.ExpectSpec{XPath: map[string]string{"/order/status": "open"}}
+	scheduler := NewScheduler(expectRequests(server.URL, expect, "open", "closed"), SchedulerConfig{
+		Once:        true,
+		Concurrency: 1,
+		Recorders:   []ResultRecorder{recorder},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if len(recorder.results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(recorder.results))
+	}
+	for _, result := range recorder.results {
+		switch result.RequestName {
+		case "open":
+			if !result.Success() {
+				t.Errorf("Expected the open order to meet expectations, got %v", result.Unmet)
+			}
+		case "closed":
+			if result.Success() || len(result.Unmet) != 1 || result.Unmet[0] != `xpath /order/status = "closed", expected "open"` {
+				t.Errorf("Expected the closed order to fail its XPath expectation, got %v", result.Unmet)
+			}
+		}
+	}
+}
+
+func TestScheduler_ExpectWarn(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("degraded"))
+	}))
+	defer server.Close()
+
+	recorder := &recordingRecorder{}
+	expect := spec.ExpectSpec{Severity: spec.SeverityWarn, BodyRegex: "^ok$"}
+	scheduler := NewScheduler(expectRequests(server.URL, expect, "status"), SchedulerConfig{
+		Once:        true,
+		Concurrency: 1,
+		Recorders:   []ResultRecorder{recorder},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if len(recorder.results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(recorder.results))
+	}
+	result := recorder.results[0]
+	if !result.Success() || len(result.Unmet) != 0 {
+		t.Errorf("Expected a missed warn expectation to leave the execution successful, got %v", result.Unmet)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("Expected 1 warning, got %v", result.Warnings)
+	}
+}
+
+func TestScheduler_ExpectStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	recorder := &recordingRecorder{}
+	expect := spec.ExpectSpec{Status: spec.StatusCodes{404}}
+	scheduler := NewScheduler(expectRequests(server.URL, expect, "gone", "error"), SchedulerConfig{
+		Once:        true,
+		Concurrency: 1,
+		Recorders:   []ResultRecorder{recorder},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if len(recorder.results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(recorder.results))
+	}
+	for _, result := range recorder.results {
+		switch result.RequestName {
+		case "gone":
+			if !result.Success() || !result.ExpectedStatus || result.FailureReason() != "" {
+				t.Errorf("Expected the expected 404 to succeed, got %v (%s)", result.Unmet, result.FailureReason())
+			}
+		case "error":
+			if result.Success() || result.ExpectedStatus || len(result.Unmet) != 1 || result.Unmet[0] != "status 500, expected 404" {
+				t.Errorf("Expected the 500 to fail its status expectation, got %v", result.Unmet)
+			}
+		}
+	}
+}
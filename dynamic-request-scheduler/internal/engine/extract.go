@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// extractReads maps each request to the variables it reads that requests
+// extract during the run, so it is not evaluated before they are set
+func extractReads(setup, requests []spec.ScheduledRequest) map[string][]string {
+	extracted := spec.Extracted(requests)
+	for name := range spec.Extracted(setup) {
+		extracted[name] = true
+	}
+	if len(extracted) == 0 {
+		return nil
+	}
+
+	reads := make(map[string][]string)
+	for i := range requests {
+		for _, name := range requests[i].Variables() {
+			if extracted[name] {
+				reads[requests[i].Name] = append(reads[requests[i].Name], name)
+			}
+		}
+	}
+	return reads
+}
+
+// currentVariables returns the run's variables. Once dispatch begins the map
+// is replaced rather than changed, so it can be read without a lock.
+func (s *Scheduler) currentVariables() map[string]interface{} {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.variables
+}
+
+// awaitExtracts returns an error naming a variable the request reads that is
+// extracted by another request which has not succeeded yet
+func (s *Scheduler) awaitExtracts(req *spec.ScheduledRequest, variables map[string]interface{}) error {
+	for _, name := range s.extractReads[req.Name] {
+		if _, ok := variables[name]; !ok {
+			return fmt.Errorf("variable %q has not been extracted yet", name)
+		}
+	}
+	return nil
+}
+
+// extractVariables sets the run variables a request extracts from a
+// successful response. A value missing from the response leaves the previous
+// one in place.
+func (s *Scheduler) extractVariables(req *spec.ScheduledRequest, result *ExecutionResult) {
+	names := make([]string, 0, len(req.HTTP.Extract))
+	for name := range req.HTTP.Extract {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		value, err := spec.CaptureValue(req.HTTP.Extract[name], result.StatusCode, result.ResponseHeaders, result.ResponseBody)
+		if err != nil {
+			s.logExecution("WARN: Request '%s' [%s] kept its previous variable %s: %v", req.Name, result.ExecutionID, name, err)
+			continue
+		}
+		values[name] = value
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	variables := make(map[string]interface{}, len(s.variables)+len(values))
+	for name, value := range s.variables {
+		variables[name] = value
+	}
+	for name, value := range values {
+		variables[name] = value
+	}
+	s.variables = variables
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// extractRequests logs in and then reads the extracted token and user, or
+// in the reverse order
+func extractRequests(url string, loginFirst bool) []spec.ScheduledRequest {
+	login := spec.ScheduledRequest{
+		Name:     "login",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP: spec.HttpRequestSpec{
+			Method:  "POST",
+			URL:     spec.NewLiteralString(url + "/login"),
+			Extract: map[string]string{"token": "$.access_token", "user": "$.user.id", "missing": "$.refresh_token"},
+		},
+	}
+	profile := spec.ScheduledRequest{
+		Name:     "profile",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP: spec.HttpRequestSpec{
+			Method:  "GET",
+			URL:     spec.NewLiteralString(url + "/users/{{ .Variables.user }}"),
+			Headers: spec.LiteralHeaders(map[string]string{"Authorization": `Bearer {{ var "token" }}`}),
+		},
+	}
+	if loginFirst {
+		return []spec.ScheduledRequest{login, profile}
+	}
+	return []spec.ScheduledRequest{profile, login}
+}
+
+func TestScheduler_ExtractChainsRequests(t *testing.T) {
+	server := &setupServer{status: 200}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	scheduler := NewScheduler(extractRequests(ts.URL, true), SchedulerConfig{Once: true, Concurrency: 1})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if seen := server.seen(); fmt.Sprint(seen) != "[/users/42 Bearer abc]" {
+		t.Errorf("Expected the profile request to read the extracted variables, got %v", seen)
+	}
+	if _, ok := scheduler.currentVariables()["missing"]; ok {
+		t.Error("Expected a value missing from the response not to be set")
+	}
+}
+
+func TestScheduler_ExtractNotYetSet(t *testing.T) {
+	server := &setupServer{status: 200}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(extractRequests(ts.URL, false), SchedulerConfig{
+		Once:        true,
+		Concurrency: 1,
+		Recorders:   []ResultRecorder{recorder},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if seen := server.seen(); len(seen) != 0 {
+		t.Errorf("Expected the profile request not to be sent, got %v", seen)
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	for _, result := range recorder.results {
+		if result.RequestName == "profile" && result.Error != `variable "user" has not been extracted yet` {
+			t.Errorf("Expected an evaluation error for the profile request, got %q", result.Error)
+		}
+	}
+}
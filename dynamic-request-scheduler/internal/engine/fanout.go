@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"log"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// fanOut returns how many copies of a request its next firing dispatches.
+// A templated fan_out draws its random values from the seed of the firing's
+// first copy, under a name of its own so they don't repeat the copy's. When
+// it cannot be evaluated, the firing dispatches a single copy.
+func (s *Scheduler) fanOut(req *spec.ScheduledRequest, evaluator *spec.Evaluator, queue *dispatchQueue) int {
+	if req.FanOut == "" || evaluator == nil {
+		return 1
+	}
+	firing := queue.nextFiring(req.Name)
+	copies, err := evaluator.ForRequest(0, req.Name+" fan_out", firing).FanOut(req)
+	if err != nil {
+		log.Printf("Error evaluating fan_out of request '%s', dispatching one copy: %v", req.Name, err)
+		return 1
+	}
+	if copies > 1 && s.logsProgress(req) {
+		s.logExecution("Request '%s' fans out to %d copies", req.Name, copies)
+	}
+	return copies
+}
+
+// claimCopies counts the extra copies a claimed firing fans out to as
+// dispatched, so the run does not end before they do
+func (s *Scheduler) claimCopies(n int) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.active += n
+}
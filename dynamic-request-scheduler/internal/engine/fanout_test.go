@@ -0,0 +1,178 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestScheduler_FanOutOnce(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "burst",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+			FanOut:   "{{ randInt 3 3 }}",
+		},
+		{
+			Name:     "single",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+	}
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{Once: true, Count: 2, Recorders: []ResultRecorder{recorder}})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, result := range recorder.results {
+		counts[result.RequestName]++
+	}
+	if counts["burst"] != 6 || counts["single"] != 2 {
+		t.Errorf("Expected 6 burst and 2 single executions, got %v", counts)
+	}
+	if hits.Load() != 8 {
+		t.Errorf("Expected 8 calls, got %d", hits.Load())
+	}
+}
+
+// growingRecorder records results and the growth it is told of
+type growingRecorder struct {
+	recordingRecorder
+	grown int
+}
+
+func (r *growingRecorder) Grow(n int) {
+	r.grown += n
+}
+
+func TestScheduler_FanOutGrowsRecorders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "burst",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+			FanOut:   "3",
+		},
+		{
+			Name:     "single",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+	}
+
+	recorder := &growingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{Once: true, Count: 2, Recorders: []ResultRecorder{recorder}})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	// 4 firings are planned; the two bursts add 2 copies each
+	if recorder.grown != 4 {
+		t.Errorf("Expected the recorder to grow by 4, got %d", recorder.grown)
+	}
+	if planned := 2*len(requests) + recorder.grown; len(recorder.results) != planned {
+		t.Errorf("Expected %d results, got %d", planned, len(recorder.results))
+	}
+}
+
+func TestScheduler_FanOutWaitsForCopies(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Later copies finish after the first, which puts the request back
+		if hits.Add(1) > 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	past := time.Now().Add(-time.Minute).Unix()
+	requests := []spec.ScheduledRequest{{
+		Name:     "burst",
+		Schedule: spec.ScheduleSpec{Epoch: &past},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		FanOut:   "4",
+	}}
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{Concurrency: 4, ExitWhenDone: true, Recorders: []ResultRecorder{recorder}})
+
+	done := make(chan error, 1)
+	go func() { done <- scheduler.Start() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		scheduler.Stop()
+		t.Fatal("Expected the run to exit once every copy finished")
+	}
+
+	if hits.Load() != 4 || len(recorder.results) != 4 {
+		t.Errorf("Expected the one-shot firing to run 4 copies to completion, got %d calls and %d results", hits.Load(), len(recorder.results))
+	}
+}
+
+func TestScheduler_FanOutInvalidTemplate(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{{
+		Name:     "burst",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		FanOut:   "{{ randInt 0 0 }}",
+	}}
+
+	scheduler := NewScheduler(requests, SchedulerConfig{Once: true})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("Expected a fan_out that cannot be evaluated to dispatch one copy, got %d calls", hits.Load())
+	}
+}
+
+func TestScheduler_FanOutAboveCap(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{{
+		Name:     "burst",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		FanOut:   "{{ randInt 1000000 1000000 }}",
+	}}
+
+	scheduler := NewScheduler(requests, SchedulerConfig{Once: true})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("Expected a fan_out above the cap to dispatch one copy, got %d calls", hits.Load())
+	}
+}
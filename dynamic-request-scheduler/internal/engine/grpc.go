@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/grpc"
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// grpcExecutor calls the unary method of each resolved gRPC request,
+// looking up its schema through server reflection. The reply, in its JSON
+// form, is the response body and the response metadata its headers.
+type grpcExecutor struct {
+	client *grpc.Client
+}
+
+func (e grpcExecutor) Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	start := time.Now()
+	target := resolved.GRPC
+
+	reply, err := e.client.Invoke(ctx, grpc.Target{Address: target.Address, TLS: target.TLS}, target.Method, resolved.Headers, resolved.Body)
+	if err != nil {
+		return nil, fmt.Errorf("gRPC call failed: %w", err)
+	}
+	body, err := json.Marshal(reply.Message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode gRPC reply: %w", err)
+	}
+
+	response := deliveredResponse("OK", start, body)
+	response.Headers = reply.Metadata
+	// The body is the reply converted to JSON, not the gRPC frame
+	response.Headers.Set("Content-Type", "application/json")
+	return response, nil
+}
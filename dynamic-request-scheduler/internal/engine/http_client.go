@@ -1,10 +1,8 @@
 package engine
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 
@@ -13,8 +11,14 @@ import (
 
 // HTTPClient handles HTTP request execution
 type HTTPClient struct {
-	client  *http.Client
+	client *http.Client
+
+	// timeout bounds requests whose context has no deadline of its own
 	timeout time.Duration
+
+	// maxResponse is how many bytes of each response body are kept; the rest
+	// is read and discarded
+	maxResponse int64
 }
 
 // NewHTTPClient creates a new HTTP client
@@ -23,30 +27,35 @@ func NewHTTPClient(timeout time.Duration) *HTTPClient {
 		timeout = 30 * time.Second
 	}
 
+	// Keep more than the default two idle connections per host, so that
+	// concurrent executions against one service reuse connections
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 100
+
 	return &HTTPClient{
 		client: &http.Client{
-			Timeout: timeout,
+			Transport: wireTracer{next: transport},
 		},
-		timeout: timeout,
+		timeout:     timeout,
+		maxResponse: DefaultMaxResponseBytes,
 	}
 }
 
-// SendRequest sends an HTTP request and returns the response details
-func (c *HTTPClient) SendRequest(resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
-	start := time.Now()
+// LimitResponse keeps at most n bytes of each response body, discarding the
+// rest so large responses don't accumulate in memory. A limit below 1 keeps
+// whole responses.
+func (c *HTTPClient) LimitResponse(n int64) {
+	c.maxResponse = n
+}
 
-	// Prepare request body
-	var body io.Reader
-	if resolved.Body != nil && resolved.Method != "GET" && resolved.Method != "HEAD" {
-		jsonData, err := json.Marshal(resolved.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request body: %w", err)
-		}
-		body = bytes.NewReader(jsonData)
-	}
+// SendRequest sends an HTTP request and returns the response details.
+// Cancelling ctx aborts the request; a deadline on ctx replaces the client
+// timeout.
+func (c *HTTPClient) SendRequest(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	start := time.Now()
 
 	// Create HTTP request
-	req, err := http.NewRequest(resolved.Method, resolved.URL, body)
+	req, err := http.NewRequestWithContext(ctx, resolved.Method, resolved.URL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
@@ -56,25 +65,64 @@ func (c *HTTPClient) SendRequest(resolved *spec.ResolvedRequest) (*HTTPResponse,
 		req.Header.Set(key, value)
 	}
 
-	// Set default Content-Type for requests with body
-	if body != nil && req.Header.Get("Content-Type") == "" {
-		req.Header.Set("Content-Type", "application/json")
+	// Attach the body, marshalled into a pooled buffer or streamed from its
+	// file, with a default Content-Type
+	contentType := ""
+	if resolved.Method != "GET" && resolved.Method != "HEAD" {
+		switch {
+		case resolved.BodyFile != "":
+			if contentType, err = attachFile(req, resolved.BodyFile); err != nil {
+				return nil, err
+			}
+		case resolved.Body != nil:
+			body, err := marshalBody(resolved.Body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal request body: %w", err)
+			}
+			defer body.release()
+			body.attach(req)
+			contentType = "application/json"
+		}
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return c.do(req, start)
+}
+
+// Execute sends a resolved HTTP request, so the client serves as the
+// executor for plain HTTP requests
+func (c *HTTPClient) Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	return c.SendRequest(ctx, resolved)
+}
+
+// do sends a prepared request and reads the whole response, bounding it by
+// the client timeout unless its context already has a deadline
+func (c *HTTPClient) do(req *http.Request, start time.Time) (*HTTPResponse, error) {
+	if _, ok := req.Context().Deadline(); !ok {
+		ctx, cancel := context.WithTimeout(req.Context(), c.timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
 	}
 
-	// Send request
+	trace := &timingTrace{}
+	req = req.WithContext(withTimings(req.Context(), trace))
+
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Read response body
-	responseBody, err := io.ReadAll(resp.Body)
+	// Read response body, keeping at most maxResponse bytes of it
+	responseBody, size, err := readLimited(resp.Body, c.maxResponse)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	duration := time.Since(start)
+	timings := trace.finish()
 
 	return &HTTPResponse{
 		StatusCode:    resp.StatusCode,
@@ -82,7 +130,9 @@ func (c *HTTPClient) SendRequest(resolved *spec.ResolvedRequest) (*HTTPResponse,
 		Headers:       resp.Header,
 		Body:          responseBody,
 		Duration:      duration,
-		ContentLength: len(responseBody),
+		ContentLength: int(size),
+		Truncated:     size > int64(len(responseBody)),
+		Timings:       timings,
 	}, nil
 }
 
@@ -94,6 +144,13 @@ type HTTPResponse struct {
 	Body          []byte
 	Duration      time.Duration
 	ContentLength int
+
+	// Truncated is set when Body holds only the start of a response whose
+	// full size is ContentLength
+	Truncated bool
+
+	// Timings breaks down the exchange; only HTTP requests have one
+	Timings *Timings
 }
 
 // IsSuccess returns true if the response indicates success
@@ -103,6 +160,10 @@ func (r *HTTPResponse) IsSuccess() bool {
 
 // String returns a string representation of the response
 func (r *HTTPResponse) String() string {
-	return fmt.Sprintf("HTTP %d %s (%v, %d bytes)",
-		r.StatusCode, r.Status, r.Duration, r.ContentLength)
+	truncated := ""
+	if r.Truncated {
+		truncated = ", truncated"
+	}
+	return fmt.Sprintf("HTTP %d %s (%v, %d bytes%s)",
+		r.StatusCode, r.Status, r.Duration, r.ContentLength, truncated)
 }
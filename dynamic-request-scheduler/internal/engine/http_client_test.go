@@ -1,7 +1,9 @@
 package engine
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -18,9 +20,6 @@ func TestNewHTTPClient(t *testing.T) {
 	if client.timeout != 30*time.Second {
 		t.Errorf("Expected timeout 30s, got %v", client.timeout)
 	}
-	if client.client.Timeout != 30*time.Second {
-		t.Errorf("Expected http.Client timeout 30s, got %v", client.client.Timeout)
-	}
 }
 
 func TestHTTPClient_SendRequest_GET(t *testing.T) {
@@ -46,7 +45,7 @@ func TestHTTPClient_SendRequest_GET(t *testing.T) {
 		Body:    nil,
 	}
 
-	resp, err := client.SendRequest(resolved)
+	resp, err := client.SendRequest(context.Background(), resolved)
 	if err != nil {
 		t.Fatalf("SendRequest failed: %v", err)
 	}
@@ -99,7 +98,7 @@ func TestHTTPClient_SendRequest_POST(t *testing.T) {
 		},
 	}
 
-	resp, err := client.SendRequest(resolved)
+	resp, err := client.SendRequest(context.Background(), resolved)
 	if err != nil {
 		t.Fatalf("SendRequest failed: %v", err)
 	}
@@ -129,7 +128,7 @@ func TestHTTPClient_SendRequest_WithCustomContentType(t *testing.T) {
 		Body: "<test>value</test>",
 	}
 
-	resp, err := client.SendRequest(resolved)
+	resp, err := client.SendRequest(context.Background(), resolved)
 	if err != nil {
 		t.Fatalf("SendRequest failed: %v", err)
 	}
@@ -155,12 +154,36 @@ func TestHTTPClient_SendRequest_Timeout(t *testing.T) {
 		Body:    nil,
 	}
 
-	_, err := client.SendRequest(resolved)
+	_, err := client.SendRequest(context.Background(), resolved)
 	if err == nil {
 		t.Fatal("Expected timeout error, got nil")
 	}
 }
 
+func TestHTTPClient_SendRequest_ContextDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	// A deadline on the context replaces the shorter client timeout
+	client := NewHTTPClient(50 * time.Millisecond)
+	resolved := &spec.ResolvedRequest{Method: "GET", URL: server.URL, Headers: map[string]string{}}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := client.SendRequest(ctx, resolved); err != nil {
+		t.Errorf("Expected the context deadline to replace the client timeout, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := NewHTTPClient(time.Minute).SendRequest(ctx, resolved)
+	if !errors.Is(err, context.Canceled) || time.Since(start) > 150*time.Millisecond {
+		t.Errorf("Expected cancelling the context to abort the request, got %v after %v", err, time.Since(start))
+	}
+}
+
 func TestHTTPClient_SendRequest_InvalidURL(t *testing.T) {
 	client := NewHTTPClient(30 * time.Second)
 	resolved := &spec.ResolvedRequest{
@@ -170,7 +193,7 @@ func TestHTTPClient_SendRequest_InvalidURL(t *testing.T) {
 		Body:    nil,
 	}
 
-	_, err := client.SendRequest(resolved)
+	_, err := client.SendRequest(context.Background(), resolved)
 	if err == nil {
 		t.Fatal("Expected error for invalid URL, got nil")
 	}
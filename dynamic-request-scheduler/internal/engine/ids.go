@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Default correlation header names
+const (
+	DefaultRunIDHeader       = "X-Run-ID"
+	DefaultExecutionIDHeader = "X-Request-ID"
+)
+
+// idFallback disambiguates IDs generated when crypto/rand is unavailable
+var idFallback uint64
+
+// NewID returns a random UUID v4 suitable for run and execution IDs
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// Fallback to a timestamp-based ID if crypto/rand fails
+		return fmt.Sprintf("%d-%d", time.Now().UnixNano(), atomic.AddUint64(&idFallback, 1))
+	}
+
+	// Set version (4) and variant bits
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// NewSeed returns a random positive seed for template random values, small
+// enough to survive being written to JSON and typed back on a command line
+func NewSeed() int64 {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		b = binary.LittleEndian.AppendUint64(b[:0], uint64(time.Now().UnixNano()))
+	}
+	seed := int64(binary.LittleEndian.Uint64(b) & (1<<53 - 1))
+	if seed == 0 {
+		return 1
+	}
+	return seed
+}
+
+// headerValue looks up a header case-insensitively
+func headerValue(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
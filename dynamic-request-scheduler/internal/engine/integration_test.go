@@ -111,10 +111,10 @@ func TestIntegration_BasicRequestFlow(t *testing.T) {
 			},
 			HTTP: spec.HttpRequestSpec{
 				Method: "GET",
-				URL:    mockServer.URL() + "/test",
-				Headers: map[string]string{
+				URL:    spec.NewLiteralString(mockServer.URL() + "/test"),
+				Headers: spec.LiteralHeaders(map[string]string{
 					"X-Test": "{{ uuid }}",
-				},
+				}),
 			},
 		},
 		{
@@ -124,14 +124,14 @@ func TestIntegration_BasicRequestFlow(t *testing.T) {
 			},
 			HTTP: spec.HttpRequestSpec{
 				Method: "POST",
-				URL:    mockServer.URL() + "/test",
-				Headers: map[string]string{
+				URL:    spec.NewLiteralString(mockServer.URL() + "/test"),
+				Headers: spec.LiteralHeaders(map[string]string{
 					"Content-Type": "application/json",
-				},
-				Body: map[string]interface{}{
+				}),
+				Body: spec.NewLiteralAny(map[string]interface{}{
 					"message": "{{ uuid }}",
 					"time":    "{{ now | rfc3339 }}",
-				},
+				}),
 			},
 		},
 	}
@@ -215,7 +215,7 @@ func TestIntegration_ConcurrencyControl(t *testing.T) {
 			},
 			HTTP: spec.HttpRequestSpec{
 				Method: "GET",
-				URL:    mockServer.URL() + "/delay/1",
+				URL:    spec.NewLiteralString(mockServer.URL() + "/delay/1"),
 			},
 		},
 		{
@@ -225,7 +225,7 @@ func TestIntegration_ConcurrencyControl(t *testing.T) {
 			},
 			HTTP: spec.HttpRequestSpec{
 				Method: "GET",
-				URL:    mockServer.URL() + "/delay/1",
+				URL:    spec.NewLiteralString(mockServer.URL() + "/delay/1"),
 			},
 		},
 		{
@@ -235,7 +235,7 @@ func TestIntegration_ConcurrencyControl(t *testing.T) {
 			},
 			HTTP: spec.HttpRequestSpec{
 				Method: "GET",
-				URL:    mockServer.URL() + "/delay/1",
+				URL:    spec.NewLiteralString(mockServer.URL() + "/delay/1"),
 			},
 		},
 	}
@@ -287,13 +287,13 @@ func TestIntegration_DryRunMode(t *testing.T) {
 			},
 			HTTP: spec.HttpRequestSpec{
 				Method: "POST",
-				URL:    mockServer.URL() + "/test",
-				Headers: map[string]string{
+				URL:    spec.NewLiteralString(mockServer.URL() + "/test"),
+				Headers: spec.LiteralHeaders(map[string]string{
 					"X-Test": "{{ uuid }}",
-				},
-				Body: map[string]interface{}{
+				}),
+				Body: spec.NewLiteralAny(map[string]interface{}{
 					"message": "{{ uuid }}",
-				},
+				}),
 			},
 		},
 	}
@@ -339,7 +339,7 @@ func TestIntegration_ErrorHandling(t *testing.T) {
 			},
 			HTTP: spec.HttpRequestSpec{
 				Method: "GET",
-				URL:    mockServer.URL() + "/error",
+				URL:    spec.NewLiteralString(mockServer.URL() + "/error"),
 			},
 		},
 	}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// JSONRPCError is an error object returned in a JSON-RPC response
+type JSONRPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *JSONRPCError) Error() string {
+	if len(e.Data) > 0 {
+		return fmt.Sprintf("JSON-RPC error %d: %s (%s)", e.Code, e.Message, e.Data)
+	}
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// jsonrpcExecutor POSTs each resolved JSON-RPC call. An error object in the
+// response, or a response to a different id, fails the execution with the
+// response kept alongside the error.
+type jsonrpcExecutor struct {
+	client *HTTPClient
+}
+
+func (e jsonrpcExecutor) Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	resp, err := e.client.SendRequest(ctx, resolved)
+	if err != nil || resolved.JSONRPC.Notification || !resp.IsSuccess() {
+		return resp, err
+	}
+	return resp, checkJSONRPCResponse(resp.Body, resolved.JSONRPC.ID)
+}
+
+// checkJSONRPCResponse returns the error object of a JSON-RPC response, or
+// an error if the body is not a response to the call with id
+func checkJSONRPCResponse(body []byte, id interface{}) error {
+	var response struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Result  json.RawMessage `json:"result"`
+		Error   *JSONRPCError   `json:"error"`
+		ID      json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("invalid JSON-RPC response: %w", err)
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if response.Result == nil {
+		return fmt.Errorf("invalid JSON-RPC response: neither result nor error is set")
+	}
+
+	want, _ := json.Marshal(id)
+	var got bytes.Buffer
+	if json.Compact(&got, response.ID) != nil || !bytes.Equal(got.Bytes(), want) {
+		return fmt.Errorf("JSON-RPC response id %s does not match request id %s", response.ID, want)
+	}
+	return nil
+}
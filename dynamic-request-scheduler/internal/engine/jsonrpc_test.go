@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestCheckJSONRPCResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		id   interface{}
+		want string
+	}{
+		{name: "result", body: `{"jsonrpc":"2.0","result":"0x10","id":1}`, id: int64(1)},
+		{name: "null result", body: `{"jsonrpc":"2.0","result":null,"id":"a"}`, id: "a"},
+		{name: "error object", body: `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":1}`, id: int64(1), want: "JSON-RPC error -32601: Method not found"},
+		{name: "error data", body: `{"jsonrpc":"2.0","error":{"code":3,"message":"execution reverted","data":"0x08c3"},"id":1}`, id: 1, want: `JSON-RPC error 3: execution reverted ("0x08c3")`},
+		{name: "other id", body: `{"jsonrpc":"2.0","result":1,"id":2}`, id: int64(1), want: "response id 2 does not match request id 1"},
+		{name: "no result", body: `{"jsonrpc":"2.0","id":1}`, id: int64(1), want: "neither result nor error"},
+		{name: "not JSON", body: `<html>`, id: int64(1), want: "invalid JSON-RPC response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkJSONRPCResponse([]byte(tt.body), tt.id)
+			if tt.want == "" && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestScheduler_SendJSONRPC(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&call)
+		id, _ := json.Marshal(call["id"])
+
+		switch call["method"] {
+		case "eth_blockNumber":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","result":"0x10","id":%s}`, id)
+		case "notify":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":%s}`, id)
+		}
+	}))
+	defer server.Close()
+
+	scheduler := NewScheduler(nil, SchedulerConfig{})
+	call := func(method string, options *spec.JSONRPCOptions) (*HTTPResponse, error) {
+		body := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+		if !options.Notification {
+			body["id"] = options.ID
+		}
+		return scheduler.send(context.Background(), &spec.ResolvedRequest{Method: "POST", URL: server.URL, Body: body, JSONRPC: options})
+	}
+
+	if resp, err := call("eth_blockNumber", &spec.JSONRPCOptions{ID: int64(7)}); err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected successful call, got %+v %v", resp, err)
+	}
+	if _, err := call("notify", &spec.JSONRPCOptions{Notification: true}); err != nil {
+		t.Errorf("Expected notification without response checks, got %v", err)
+	}
+
+	resp, err := call("eth_missing", &spec.JSONRPCOptions{ID: "req-1"})
+	var rpcErr *JSONRPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Errorf("Expected JSON-RPC error object, got %v", err)
+	}
+	if resp == nil || !strings.Contains(string(resp.Body), "Method not found") {
+		t.Errorf("Expected the response alongside the error, got %+v", resp)
+	}
+}
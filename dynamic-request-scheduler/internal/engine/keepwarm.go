@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// States of an endpoint kept warm, as Warmth reports them
+const (
+	StateWarm = "warm"
+	StateCold = "cold"
+	StateDown = "down"
+)
+
+// Warmth is the state of a keep-warm request's endpoint
+type Warmth struct {
+	Request string
+
+	// State is StateWarm, StateCold or StateDown, as of the last execution
+	State string
+
+	// Since is when the endpoint entered State
+	Since time.Time
+
+	// ColdStarts counts executions that found the endpoint cold
+	ColdStarts uint64
+}
+
+// Up reports whether the endpoint answered its last execution
+func (w Warmth) Up() bool {
+	return w.State != StateDown
+}
+
+// stateOf classifies an execution of a keep-warm request
+func stateOf(result *ExecutionResult) string {
+	switch {
+	case !result.Success():
+		return StateDown
+	case result.Slow:
+		return StateCold
+	default:
+		return StateWarm
+	}
+}
+
+// trackWarmth records the state an execution of a keep-warm request found
+// its endpoint in, and returns a line describing the change, or "" when the
+// state is unchanged
+func (s *Scheduler) trackWarmth(req *spec.ScheduledRequest, result *ExecutionResult) string {
+	state := stateOf(result)
+
+	s.stateMu.Lock()
+	if s.warmth == nil {
+		s.warmth = make(map[string]*Warmth)
+	}
+	warmth, ok := s.warmth[req.Name]
+	if !ok {
+		warmth = &Warmth{Request: req.Name}
+		s.warmth[req.Name] = warmth
+	}
+	if state == StateCold {
+		warmth.ColdStarts++
+	}
+	previous := warmth.State
+	if previous != state {
+		warmth.State = state
+		warmth.Since = result.StartedAt
+	}
+	s.stateMu.Unlock()
+
+	if previous == state {
+		return ""
+	}
+
+	detail := fmt.Sprintf("%s in %v", result.Status, result.Duration.Round(time.Millisecond))
+	color := ansiGreen
+	switch state {
+	case StateDown:
+		detail = result.FailureReason()
+		color = ansiRed
+	case StateCold:
+		color = ansiYellow
+	}
+	if previous == "" {
+		return fmt.Sprintf("Keep-warm '%s' is %s: %s", req.Name, s.paint(color, state), detail)
+	}
+	return fmt.Sprintf("Keep-warm '%s' went %s → %s: %s", req.Name, previous, s.paint(color, state), detail)
+}
+
+// Warmth returns the state of every keep-warm request that has run, sorted
+// by name
+func (s *Scheduler) Warmth() []Warmth {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	states := make([]Warmth, 0, len(s.warmth))
+	for _, warmth := range s.warmth {
+		states = append(states, *warmth)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Request < states[j].Request })
+	return states
+}
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestScheduler_KeepWarm(t *testing.T) {
+	// The endpoint starts cold, warms up, then goes down
+	responses := []string{"cold", "warm", "warm", "down", "down", "warm"}
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch responses[calls.Add(1)-1] {
+		case "cold":
+			time.Sleep(30 * time.Millisecond)
+		case "down":
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	requests := (&spec.KeepWarmSpec{URLs: []string{server.URL}, ColdThreshold: "20ms"}).Requests()
+	scheduler := NewScheduler(requests, SchedulerConfig{})
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	for range responses {
+		scheduler.executeRequest(&requests[0], scheduler.newEvaluator())
+	}
+
+	name := requests[0].Name
+	want := []string{
+		"Keep-warm '" + name + "' is cold: 200 OK in",
+		"Keep-warm '" + name + "' went cold → warm: 200 OK in",
+		"Keep-warm '" + name + "' went warm → down: HTTP 502 Bad Gateway",
+		"Keep-warm '" + name + "' went down → warm: 200 OK in",
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("Expected only the %d state changes to be logged, got:\n%s", len(want), buf.String())
+	}
+	for i := range want {
+		if !strings.Contains(lines[i], want[i]) {
+			t.Errorf("Expected line %d to contain %q, got %q", i, want[i], lines[i])
+		}
+	}
+
+	states := scheduler.Warmth()
+	if len(states) != 1 || states[0].State != StateWarm || !states[0].Up() || states[0].ColdStarts != 1 {
+		t.Errorf("Expected one warm endpoint with a cold start, got %+v", states)
+	}
+}
@@ -0,0 +1,279 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stage ramps the arrival rate linearly from the previous stage's target to
+// Target requests per second over Duration
+type Stage struct {
+	Duration time.Duration
+	Target   float64
+}
+
+// ParseStages reads comma-separated duration:rate stages, e.g.
+// "30s:10,1m:50,30s:0" ramps to 10 req/s, then to 50, then back down to 0
+func ParseStages(value string) ([]Stage, error) {
+	var stages []Stage
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		durationText, rateText, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid stage %q: expected duration:rate (e.g. 30s:10)", part)
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(durationText))
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid stage %q: duration must be positive (e.g. 30s)", part)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateText), 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("invalid stage %q: rate must be a non-negative number", part)
+		}
+		stages = append(stages, Stage{Duration: duration, Target: rate})
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("at least one stage is required")
+	}
+	return stages, nil
+}
+
+// LoadPlan describes an open-model load test: requests arrive at the planned
+// rate whether or not earlier ones have completed, as real users would
+type LoadPlan struct {
+	// StartRate is the arrival rate the first stage ramps from
+	StartRate float64
+
+	// Stages run in order; a single stage with equal start and target rates
+	// sustains a constant rate
+	Stages []Stage
+
+	// MaxInFlight bounds concurrent executions. Arrivals while the limit is
+	// reached are dropped and counted rather than queued, so a slow target
+	// cannot silently lower the offered load.
+	MaxInFlight int
+
+	// Progress, when set, is called every ProgressInterval during the run
+	Progress         func(LoadStatus)
+	ProgressInterval time.Duration
+}
+
+// Duration returns the total length of the plan
+func (p LoadPlan) Duration() time.Duration {
+	var total time.Duration
+	for _, stage := range p.Stages {
+		total += stage.Duration
+	}
+	return total
+}
+
+// RateAt returns the planned arrival rate at elapsed time into the run
+func (p LoadPlan) RateAt(elapsed time.Duration) float64 {
+	from := p.StartRate
+	for _, stage := range p.Stages {
+		if elapsed < stage.Duration {
+			progress := float64(elapsed) / float64(stage.Duration)
+			return from + (stage.Target-from)*progress
+		}
+		elapsed -= stage.Duration
+		from = stage.Target
+	}
+	return from
+}
+
+// ArrivalTime returns when, into the run, the nth arrival is due: the time at
+// which the rate integrated from the start reaches n. It reports false when
+// the plan ends first.
+func (p LoadPlan) ArrivalTime(n float64) (time.Duration, bool) {
+	var offset time.Duration
+	from := p.StartRate
+	for _, stage := range p.Stages {
+		seconds := stage.Duration.Seconds()
+		area := (from + stage.Target) / 2 * seconds
+		if n > area {
+			n -= area
+			offset += stage.Duration
+			from = stage.Target
+			continue
+		}
+
+		// Solve from*x + slope/2*x^2 = n for the time x into the stage
+		var x float64
+		if slope := (stage.Target - from) / seconds; slope == 0 {
+			x = n / from
+		} else {
+			x = (-from + math.Sqrt(max(from*from+2*slope*n, 0))) / slope
+		}
+		return offset + time.Duration(x*float64(time.Second)), true
+	}
+	return 0, false
+}
+
+// MaxRate returns the highest rate the plan reaches
+func (p LoadPlan) MaxRate() float64 {
+	rate := p.StartRate
+	for _, stage := range p.Stages {
+		rate = max(rate, stage.Target)
+	}
+	return rate
+}
+
+// LoadStatus is a point-in-time view of a running load test
+type LoadStatus struct {
+	Elapsed     time.Duration
+	Rate        float64
+	Started     uint64
+	Completed   uint64
+	Dropped     uint64
+	InFlight    int64
+	MaxInFlight int64
+}
+
+// LoadResult summarizes the arrivals of a finished load test; latency and
+// status statistics come from the scheduler's recorders
+type LoadResult struct {
+	Duration    time.Duration
+	Started     uint64
+	Dropped     uint64
+	MaxInFlight int64
+}
+
+// loadCounters are updated by the arrival loop and executions
+type loadCounters struct {
+	started     atomic.Uint64
+	completed   atomic.Uint64
+	dropped     atomic.Uint64
+	inFlight    atomic.Int64
+	maxInFlight atomic.Int64
+}
+
+func (c *loadCounters) status(elapsed time.Duration, rate float64) LoadStatus {
+	return LoadStatus{
+		Elapsed:     elapsed,
+		Rate:        rate,
+		Started:     c.started.Load(),
+		Completed:   c.completed.Load(),
+		Dropped:     c.dropped.Load(),
+		InFlight:    c.inFlight.Load(),
+		MaxInFlight: c.maxInFlight.Load(),
+	}
+}
+
+// RunLoad sends the scheduler's requests, in rotation, at the rates planned,
+// ignoring their schedules. It returns once the plan is complete, or the
+// scheduler is stopped, and every execution has finished.
+func (s *Scheduler) RunLoad(plan LoadPlan) (LoadResult, error) {
+	if len(plan.Stages) == 0 {
+		return LoadResult{}, fmt.Errorf("a load plan needs at least one stage")
+	}
+	if plan.MaxInFlight <= 0 {
+		plan.MaxInFlight = 100
+	}
+
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return LoadResult{}, fmt.Errorf("scheduler is already running")
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	evaluator := s.newEvaluator()
+
+	var counters loadCounters
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	stopProgress := make(chan struct{})
+	if plan.Progress != nil {
+		if plan.ProgressInterval <= 0 {
+			plan.ProgressInterval = 5 * time.Second
+		}
+		go func() {
+			ticker := time.NewTicker(plan.ProgressInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					elapsed := time.Since(start)
+					plan.Progress(counters.status(elapsed, plan.RateAt(elapsed)))
+				case <-stopProgress:
+					return
+				}
+			}
+		}()
+	}
+
+	log.Printf("Starting load test run %s with %d requests for %v, up to %d in flight",
+		s.runID, len(s.requests), plan.Duration(), plan.MaxInFlight)
+
+	for arrivals := 0; ; arrivals++ {
+		offset, ok := plan.ArrivalTime(float64(arrivals + 1))
+		if !ok || !s.sleepUntil(start.Add(offset)) {
+			break
+		}
+		request := s.requests[arrivals%len(s.requests)]
+		execution := evaluator.ForRequest(int64(arrivals+1), request.Name, int64(arrivals/len(s.requests)+1))
+
+		if counters.inFlight.Load() >= int64(plan.MaxInFlight) {
+			counters.dropped.Add(1)
+			continue
+		}
+		inFlight := counters.inFlight.Add(1)
+		for {
+			peak := counters.maxInFlight.Load()
+			if inFlight <= peak || counters.maxInFlight.CompareAndSwap(peak, inFlight) {
+				break
+			}
+		}
+		counters.started.Add(1)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer counters.inFlight.Add(-1)
+			defer counters.completed.Add(1)
+			defer isolate(request.Name)
+			s.executeRequest(&request, execution)
+		}()
+	}
+
+	wg.Wait()
+	close(stopProgress)
+
+	s.mu.Lock()
+	s.running = false
+	s.mu.Unlock()
+
+	return LoadResult{
+		Duration:    time.Since(start),
+		Started:     counters.started.Load(),
+		Dropped:     counters.dropped.Load(),
+		MaxInFlight: counters.maxInFlight.Load(),
+	}, nil
+}
+
+// sleepUntil waits for t, returning false if the scheduler is stopped first
+func (s *Scheduler) sleepUntil(t time.Time) bool {
+	delay := time.Until(t)
+	if delay <= 0 {
+		return s.ctx.Err() == nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.ctx.Done():
+		return false
+	}
+}
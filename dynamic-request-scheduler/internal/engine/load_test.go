@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestParseStages(t *testing.T) {
+	stages, err := ParseStages("30s:10, 1m:50.5,30s:0")
+	if err != nil {
+		t.Fatalf("ParseStages failed: %v", err)
+	}
+	want := []Stage{{30 * time.Second, 10}, {time.Minute, 50.5}, {30 * time.Second, 0}}
+	if len(stages) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, stages)
+	}
+	for i := range want {
+		if stages[i] != want[i] {
+			t.Errorf("Stage %d: expected %v, got %v", i, want[i], stages[i])
+		}
+	}
+
+	for _, value := range []string{"", "30s", "0s:10", "30s:-1", "soon:10", "30s:fast"} {
+		if _, err := ParseStages(value); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestLoadPlan_Rates(t *testing.T) {
+	plan := LoadPlan{Stages: []Stage{{10 * time.Second, 10}, {10 * time.Second, 10}, {10 * time.Second, 0}}}
+
+	if plan.Duration() != 30*time.Second || plan.MaxRate() != 10 {
+		t.Errorf("Unexpected duration %v or max rate %v", plan.Duration(), plan.MaxRate())
+	}
+	rates := map[time.Duration]float64{0: 0, 5 * time.Second: 5, 15 * time.Second: 10, 25 * time.Second: 5, time.Minute: 0}
+	for elapsed, want := range rates {
+		if got := plan.RateAt(elapsed); math.Abs(got-want) > 1e-9 {
+			t.Errorf("RateAt(%v) = %v, want %v", elapsed, got, want)
+		}
+	}
+
+	// Ramping up to 10/s over 10s delivers 50 arrivals, the plateau 100 more
+	// and the ramp down the last 50
+	arrivals := map[float64]time.Duration{
+		50:  10 * time.Second,
+		100: 15 * time.Second,
+		150: 20 * time.Second,
+		200: 30 * time.Second,
+	}
+	for n, want := range arrivals {
+		got, ok := plan.ArrivalTime(n)
+		if !ok || (got-want).Abs() > time.Millisecond {
+			t.Errorf("ArrivalTime(%v) = %v %v, want %v", n, got, ok, want)
+		}
+	}
+	if _, ok := plan.ArrivalTime(201); ok {
+		t.Error("Expected no arrivals after the plan ends")
+	}
+}
+
+func TestScheduler_RunLoad(t *testing.T) {
+	var received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{Name: "a", Schedule: spec.ScheduleSpec{Relative: stringPtr("1m")}, HTTP: spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + "/a")}},
+		{Name: "b", Schedule: spec.ScheduleSpec{Relative: stringPtr("1m")}, HTTP: spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + "/b")}},
+	}
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{Recorders: []ResultRecorder{recorder}, Quiet: true})
+
+	var progress atomic.Int64
+	result, err := scheduler.RunLoad(LoadPlan{
+		StartRate:        40,
+		Stages:           []Stage{{Duration: time.Second, Target: 40}},
+		Progress:         func(LoadStatus) { progress.Add(1) },
+		ProgressInterval: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("RunLoad failed: %v", err)
+	}
+
+	if result.Started != 40 || result.Dropped != 0 || received.Load() != 40 {
+		t.Errorf("Expected 40 executions, got %+v with %d received", result, received.Load())
+	}
+	byName := make(map[string]int)
+	for _, r := range recorder.results {
+		byName[r.RequestName]++
+	}
+	if byName["a"] != 20 || byName["b"] != 20 {
+		t.Errorf("Expected requests to alternate, got %v", byName)
+	}
+	if result.Duration < 950*time.Millisecond || progress.Load() == 0 {
+		t.Errorf("Expected a 1s run with progress updates, got %v and %d updates", result.Duration, progress.Load())
+	}
+}
+
+func TestScheduler_RunLoadDropsOverLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{{Name: "slow", Schedule: spec.ScheduleSpec{Relative: stringPtr("1m")}, HTTP: spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)}}}
+	scheduler := NewScheduler(requests, SchedulerConfig{Quiet: true})
+
+	result, err := scheduler.RunLoad(LoadPlan{
+		StartRate:   50,
+		Stages:      []Stage{{Duration: 200 * time.Millisecond, Target: 50}},
+		MaxInFlight: 2,
+	})
+	if err != nil {
+		t.Fatalf("RunLoad failed: %v", err)
+	}
+	if result.Started != 2 || result.Dropped != 8 || result.MaxInFlight != 2 {
+		t.Errorf("Expected 2 started and 8 dropped, got %+v", result)
+	}
+}
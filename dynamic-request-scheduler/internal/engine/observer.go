@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// Observer follows each execution through the scheduler. Unlike a
+// ResultRecorder, which only sees the finished ExecutionResult, an observer
+// also sees the request as resolved and the raw response, so metrics,
+// reports and notifications can be added without changing the engine.
+//
+// Methods are called from the goroutine running the execution, often
+// concurrently for different executions, and must not block for long.
+type Observer interface {
+	// OnScheduled is called when a request is due and dispatched, before it
+	// waits for a rate limit slot or is evaluated
+	OnScheduled(req *spec.ScheduledRequest)
+
+	// OnStart is called once the request is resolved, just before it is sent
+	OnStart(resolved *spec.ResolvedRequest, executionID string)
+
+	// OnComplete is called after the result has been recorded. resolved is
+	// nil when the request failed to evaluate and resp is nil when no
+	// response was received.
+	OnComplete(resolved *spec.ResolvedRequest, resp *HTTPResponse, result ExecutionResult)
+}
+
+// ObserverFuncs adapts a set of functions to Observer; nil functions are
+// skipped, so an embedder only sets the events it cares about
+type ObserverFuncs struct {
+	Scheduled func(req *spec.ScheduledRequest)
+	Start     func(resolved *spec.ResolvedRequest, executionID string)
+	Complete  func(resolved *spec.ResolvedRequest, resp *HTTPResponse, result ExecutionResult)
+}
+
+// OnScheduled calls f.Scheduled if set
+func (f ObserverFuncs) OnScheduled(req *spec.ScheduledRequest) {
+	if f.Scheduled != nil {
+		f.Scheduled(req)
+	}
+}
+
+// OnStart calls f.Start if set
+func (f ObserverFuncs) OnStart(resolved *spec.ResolvedRequest, executionID string) {
+	if f.Start != nil {
+		f.Start(resolved, executionID)
+	}
+}
+
+// OnComplete calls f.Complete if set
+func (f ObserverFuncs) OnComplete(resolved *spec.ResolvedRequest, resp *HTTPResponse, result ExecutionResult) {
+	if f.Complete != nil {
+		f.Complete(resolved, resp, result)
+	}
+}
+
+// AddObserver registers an observer alongside those in SchedulerConfig. It
+// must be called before Start.
+func (s *Scheduler) AddObserver(observer Observer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = append(s.observers, observer)
+}
+
+func (s *Scheduler) notifyScheduled(req *spec.ScheduledRequest) {
+	for _, observer := range s.observers {
+		observer.OnScheduled(req)
+	}
+}
+
+func (s *Scheduler) notifyStart(resolved *spec.ResolvedRequest, executionID string) {
+	for _, observer := range s.observers {
+		observer.OnStart(resolved, executionID)
+	}
+}
+
+func (s *Scheduler) notifyComplete(resolved *spec.ResolvedRequest, resp *HTTPResponse, result ExecutionResult) {
+	for _, observer := range s.observers {
+		observer.OnComplete(resolved, resp, result)
+	}
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// eventLog is an Observer that records the events it sees
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+	bodies map[string]string
+}
+
+func (l *eventLog) OnScheduled(req *spec.ScheduledRequest) {
+	l.add("scheduled " + req.Name)
+}
+
+func (l *eventLog) OnStart(resolved *spec.ResolvedRequest, executionID string) {
+	l.add("start " + resolved.Name + " " + resolved.URL)
+}
+
+func (l *eventLog) OnComplete(resolved *spec.ResolvedRequest, resp *HTTPResponse, result ExecutionResult) {
+	switch {
+	case resolved == nil:
+		l.add("failed " + result.RequestName)
+	case resp != nil:
+		l.mu.Lock()
+		l.bodies[resolved.Name] = string(resp.Body)
+		l.mu.Unlock()
+		l.add("complete " + resolved.Name + " " + result.Status)
+	}
+}
+
+func (l *eventLog) add(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func TestScheduler_Observers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "ping",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + "/ping")},
+		},
+		{
+			Name:     "broken",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("{{ invalid }}")},
+		},
+	}
+
+	log := &eventLog{bodies: make(map[string]string)}
+	var completed []string
+	var mu sync.Mutex
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:      true,
+		Observers: []Observer{log},
+	})
+	scheduler.AddObserver(ObserverFuncs{
+		Complete: func(resolved *spec.ResolvedRequest, resp *HTTPResponse, result ExecutionResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			completed = append(completed, result.RequestName)
+		},
+	})
+
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	sort.Strings(log.events)
+	want := []string{
+		"complete ping 200 OK",
+		"failed broken",
+		"scheduled broken",
+		"scheduled ping",
+		"start ping " + server.URL + "/ping",
+	}
+	if strings.Join(log.events, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Got events:\n%s\nwant:\n%s", strings.Join(log.events, "\n"), strings.Join(want, "\n"))
+	}
+	if log.bodies["ping"] != "pong" {
+		t.Errorf("Expected the response body to reach the observer, got %q", log.bodies["ping"])
+	}
+
+	sort.Strings(completed)
+	if strings.Join(completed, ",") != "broken,ping" {
+		t.Errorf("Expected ObserverFuncs to see both completions, got %v", completed)
+	}
+}
+
+func TestObserverFuncs_NilFuncs(t *testing.T) {
+	// Unset functions are skipped rather than panicking
+	var observer Observer = ObserverFuncs{}
+	observer.OnScheduled(&spec.ScheduledRequest{Name: "x"})
+	observer.OnStart(&spec.ResolvedRequest{Name: "x"}, "id")
+	observer.OnComplete(nil, nil, ExecutionResult{})
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/plugin"
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// Transformer rewrites resolved requests just before they are sent, after
+// correlation headers are added; an error fails the execution
+type Transformer interface {
+	Transform(ctx context.Context, resolved *spec.ResolvedRequest) error
+}
+
+// PluginExecutor adapts an external plugin to Executor, for requests of
+// the custom type it is registered for
+func PluginExecutor(p *plugin.Plugin) Executor {
+	return ExecutorFunc(func(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+		start := time.Now()
+		resp, err := p.Execute(ctx, resolved)
+		if resp == nil {
+			return nil, err
+		}
+
+		body := resp.BodyBytes()
+		status := resp.Status
+		if status == "" {
+			status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		var headers http.Header
+		if len(resp.Headers) > 0 {
+			headers = make(http.Header, len(resp.Headers))
+			for key, value := range resp.Headers {
+				headers.Set(key, value)
+			}
+		}
+		return &HTTPResponse{
+			StatusCode:    resp.StatusCode,
+			Status:        status,
+			Headers:       headers,
+			Body:          body,
+			Duration:      time.Since(start),
+			ContentLength: len(body),
+		}, err
+	})
+}
+
+// transform passes a resolved request through every transformer in turn
+func (s *Scheduler) transform(ctx context.Context, resolved *spec.ResolvedRequest) (err error) {
+	defer recoverPanic(&err, "transformer")
+	for _, transformer := range s.transformers {
+		if err := transformer.Transform(ctx, resolved); err != nil {
+			return err
+		}
+	}
+	return nil
+}
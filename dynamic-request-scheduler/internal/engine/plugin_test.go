@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/plugin"
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// signer is a Transformer that adds a header, or fails when err is set
+type signer struct {
+	err error
+}
+
+func (s signer) Transform(ctx context.Context, resolved *spec.ResolvedRequest) error {
+	if s.err != nil {
+		return s.err
+	}
+	resolved.Headers["X-Signature"] = "signed:" + resolved.Headers["X-Execution-ID"]
+	return nil
+}
+
+func TestScheduler_Transformers(t *testing.T) {
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Signature")
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{{
+		Name:     "orders",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+	}}
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:              true,
+		ExecutionIDHeader: "X-Execution-ID",
+		Recorders:         []ResultRecorder{recorder},
+		Transformers:      []Transformer{signer{}},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	result := recorder.results[0]
+	if signature == "" || signature != "signed:"+result.ExecutionID {
+		t.Errorf("Expected the transformer to see correlation headers and change what is sent, got %q", signature)
+	}
+	if result.Headers["X-Signature"] != signature {
+		t.Errorf("Expected the transformed headers recorded, got %v", result.Headers)
+	}
+}
+
+func TestScheduler_TransformerError(t *testing.T) {
+	sent := false
+	requests := []spec.ScheduledRequest{{
+		Name:     "orders",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("http://example.invalid")},
+	}}
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:         true,
+		Recorders:    []ResultRecorder{recorder},
+		Transformers: []Transformer{signer{err: errors.New("no signing key")}},
+		Executors: map[string]Executor{spec.TypeHTTP: ExecutorFunc(func(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+			sent = true
+			return nil, nil
+		})},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if sent {
+		t.Errorf("Expected a failed transform to stop the request being sent")
+	}
+	if len(recorder.results) != 1 || recorder.results[0].Error != "no signing key" {
+		t.Errorf("Expected the transform error recorded, got %+v", recorder.results)
+	}
+}
+
+func TestPluginExecutor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grpc.sh")
+	script := "#!/bin/sh\ncat > /dev/null\necho '{\"status_code\": 201, \"headers\": {\"grpc-status\": \"0\"}, \"body\": {\"message\": \"Hello Ada\"}}'\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	p, err := plugin.New(spec.PluginSpec{Name: "grpc", Command: []string{path}, Type: "grpc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := []spec.ScheduledRequest{{
+		Name:     "say-hello",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		Plugin:   &spec.PluginRequestSpec{Type: "grpc", Target: "localhost:50051"},
+	}}
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:      true,
+		Recorders: []ResultRecorder{recorder},
+		Executors: map[string]Executor{"grpc": PluginExecutor(p)},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	result := recorder.results[0]
+	if !result.Success() || result.Status != "201 Created" || result.Method != "GRPC" || result.URL != "localhost:50051" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if result.ResponseHeaders.Get("Grpc-Status") != "0" || string(result.ResponseBody) != `{"message": "Hello Ada"}` {
+		t.Errorf("Expected the plugin's headers and body, got %v %s", result.ResponseHeaders, result.ResponseBody)
+	}
+}
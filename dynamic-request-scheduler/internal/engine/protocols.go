@@ -0,0 +1,267 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/amqp"
+	"local-dev-tools/dynamic-request-scheduler/internal/aws"
+	"local-dev-tools/dynamic-request-scheduler/internal/kafka"
+	"local-dev-tools/dynamic-request-scheduler/internal/nats"
+	"local-dev-tools/dynamic-request-scheduler/internal/redis"
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// maxStatusLength bounds protocol replies shown as an execution's status
+const maxStatusLength = 200
+
+// clientID identifies the scheduler to brokers that record client names
+const clientID = "dynamic-request-scheduler"
+
+// deliveredResponse reports a successful non-HTTP delivery. It carries a 200
+// status code so that deliveries count as successes alongside HTTP 2xx
+// responses in summaries, history and notifications.
+func deliveredResponse(status string, start time.Time, body []byte) *HTTPResponse {
+	return &HTTPResponse{
+		StatusCode:    http.StatusOK,
+		Status:        status,
+		Body:          body,
+		Duration:      time.Since(start),
+		ContentLength: len(body),
+	}
+}
+
+// messageBytes encodes a resolved body as a message payload: strings are sent
+// as-is, nil as an empty payload, and anything else as JSON
+func messageBytes(body interface{}) ([]byte, error) {
+	switch value := body.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(value), nil
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal message body: %w", err)
+		}
+		return data, nil
+	}
+}
+
+// kafkaExecutor produces each resolved Kafka request as a single record
+type kafkaExecutor struct {
+	client *kafka.Client
+}
+
+func (e kafkaExecutor) Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	start := time.Now()
+	target := resolved.Kafka
+
+	value, err := messageBytes(resolved.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	message := kafka.Message{
+		Topic:     target.Topic,
+		Partition: target.Partition,
+		Value:     value,
+		Headers:   resolved.Headers,
+	}
+	if target.Key != nil {
+		message.Key = []byte(*target.Key)
+	}
+
+	result, err := e.client.Produce(ctx, target.Brokers, message)
+	if err != nil {
+		return nil, fmt.Errorf("Kafka produce failed: %w", err)
+	}
+
+	status := fmt.Sprintf("produced to %s[%d]@%d", target.Topic, result.Partition, result.Offset)
+	return deliveredResponse(status, start, nil), nil
+}
+
+// amqpExecutor publishes each resolved AMQP request and waits for the
+// broker to confirm it
+type amqpExecutor struct {
+	client *amqp.Client
+}
+
+func (e amqpExecutor) Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	start := time.Now()
+	target := resolved.AMQP
+
+	body, err := messageBytes(resolved.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	props := target.Properties
+	properties := amqp.Properties{
+		ContentType:     props.ContentType,
+		ContentEncoding: props.ContentEncoding,
+		Headers:         resolved.Headers,
+		CorrelationID:   props.CorrelationID,
+		ReplyTo:         props.ReplyTo,
+		Expiration:      props.Expiration,
+		MessageID:       props.MessageID,
+		Timestamp:       start,
+		Type:            props.Type,
+		AppID:           props.AppID,
+	}
+	if _, isString := resolved.Body.(string); properties.ContentType == "" && resolved.Body != nil && !isString {
+		properties.ContentType = "application/json"
+	}
+	if props.Persistent {
+		properties.DeliveryMode = 2
+	}
+	if props.Priority != nil {
+		priority := uint8(*props.Priority)
+		properties.Priority = &priority
+	}
+
+	err = e.client.Publish(ctx, target.URL, amqp.Message{
+		Exchange:   target.Exchange,
+		RoutingKey: target.RoutingKey,
+		Mandatory:  target.Mandatory,
+		Properties: properties,
+		Body:       body,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("AMQP publish failed: %w", err)
+	}
+
+	exchange := target.Exchange
+	if exchange == "" {
+		exchange = "(default)"
+	}
+	status := fmt.Sprintf("published to exchange %s with routing key %q", exchange, target.RoutingKey)
+	return deliveredResponse(status, start, nil), nil
+}
+
+// redisExecutor runs each resolved Redis command. The reply is shown as the
+// status and kept as JSON in the response body; error replies fail the
+// execution.
+type redisExecutor struct {
+	client *redis.Client
+}
+
+func (e redisExecutor) Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	start := time.Now()
+
+	reply, err := e.client.Do(ctx, resolved.Redis.URL, resolved.Redis.Args)
+	if err != nil {
+		return nil, fmt.Errorf("Redis command failed: %w", err)
+	}
+
+	body, err := json.Marshal(reply)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Redis reply: %w", err)
+	}
+
+	status := redis.Format(reply)
+	if len(status) > maxStatusLength {
+		status = status[:maxStatusLength] + "..."
+	}
+	return deliveredResponse(status, start, body), nil
+}
+
+// natsExecutor publishes each resolved NATS request, or in request-reply
+// mode waits for the first reply and returns it as the response body and
+// headers
+type natsExecutor struct {
+	client *nats.Client
+}
+
+func (e natsExecutor) Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	start := time.Now()
+	target := resolved.NATS
+
+	data, err := messageBytes(resolved.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := nats.Msg{Subject: target.Subject, Data: data}
+	if len(resolved.Headers) > 0 {
+		msg.Header = http.Header{}
+		for key, value := range resolved.Headers {
+			// Set directly: NATS header names are case-sensitive
+			msg.Header[key] = []string{value}
+		}
+	}
+
+	if !target.Request {
+		if err := e.client.Publish(ctx, target.URL, msg); err != nil {
+			return nil, fmt.Errorf("NATS publish failed: %w", err)
+		}
+		return deliveredResponse("published to "+target.Subject, start, nil), nil
+	}
+
+	reply, err := e.client.Request(ctx, target.URL, msg, target.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("NATS request failed: %w", err)
+	}
+	response := deliveredResponse(fmt.Sprintf("reply received (%d bytes)", len(reply.Data)), start, reply.Data)
+	response.Headers = reply.Header
+	return response, nil
+}
+
+// sqsExecutor sends each resolved SQS request as a single message
+type sqsExecutor struct {
+	client *aws.Client
+}
+
+func (e sqsExecutor) Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	start := time.Now()
+	target := resolved.SQS
+
+	body, err := messageBytes(resolved.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	messageID, err := e.client.SendMessage(ctx, target.Region, aws.SQSMessage{
+		QueueURL:        target.QueueURL,
+		Body:            string(body),
+		Attributes:      resolved.Headers,
+		DelaySeconds:    target.DelaySeconds,
+		GroupID:         target.GroupID,
+		DeduplicationID: target.DeduplicationID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SQS send failed: %w", err)
+	}
+	return deliveredResponse("sent message "+messageID, start, nil), nil
+}
+
+// snsExecutor publishes each resolved SNS request to its topic
+type snsExecutor struct {
+	client *aws.Client
+}
+
+func (e snsExecutor) Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	start := time.Now()
+	target := resolved.SNS
+
+	message, err := messageBytes(resolved.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	messageID, err := e.client.Publish(ctx, target.Endpoint, target.Region, aws.SNSMessage{
+		TopicARN:        target.TopicARN,
+		Subject:         target.Subject,
+		Message:         string(message),
+		Attributes:      resolved.Headers,
+		GroupID:         target.GroupID,
+		DeduplicationID: target.DeduplicationID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SNS publish failed: %w", err)
+	}
+	return deliveredResponse("published message "+messageID, start, nil), nil
+}
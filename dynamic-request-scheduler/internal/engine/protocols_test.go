@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMessageBytes(t *testing.T) {
+	cases := []struct {
+		body interface{}
+		want string
+	}{
+		{nil, ""},
+		{"plain text", "plain text"},
+		{map[string]interface{}{"id": 1}, `{"id":1}`},
+		{[]interface{}{"a", 2}, `["a",2]`},
+	}
+	for _, c := range cases {
+		got, err := messageBytes(c.body)
+		if err != nil || string(got) != c.want {
+			t.Errorf("messageBytes(%v) = %q, %v; want %q", c.body, got, err, c.want)
+		}
+	}
+	if got, _ := messageBytes(nil); got != nil {
+		t.Errorf("Expected nil body to stay nil, got %q", got)
+	}
+}
+
+func TestDeliveredResponse(t *testing.T) {
+	resp := deliveredResponse("published", time.Now(), nil)
+	result := ExecutionResult{StatusCode: resp.StatusCode, Status: resp.Status}
+	if !result.Success() || result.StatusClass() != Class2xx {
+		t.Errorf("Expected delivery to count as a success, got %+v", result)
+	}
+}
@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces executions evenly so that no more than a fixed number
+// start per second across all requests
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter returns a limiter for rps executions per second, or nil
+// (no limit) when rps is not positive
+func newRateLimiter(rps float64) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / rps)}
+}
+
+// wait blocks until the caller's slot comes up, returning false if ctx is
+// cancelled first. A nil limiter never blocks.
+func (l *rateLimiter) wait(ctx context.Context) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultRecentResults is how many executions of each request Recent keeps
+// when SchedulerConfig does not set a number
+const DefaultRecentResults = 20
+
+// RecentExecution summarizes one execution kept for Recent
+type RecentExecution struct {
+	ExecutionID string
+	StartedAt   time.Time
+	Duration    time.Duration
+	StatusCode  int
+	StatusClass string
+	Success     bool
+
+	// Reason is why the execution failed, as ExecutionResult.FailureReason
+	// describes it; empty for successes
+	Reason string
+}
+
+// resultRing keeps the most recent executions of a request, overwriting the
+// oldest once it is full
+type resultRing struct {
+	items []RecentExecution
+	next  int
+	full  bool
+}
+
+// add keeps an execution, dropping the oldest when the ring is full
+func (r *resultRing) add(execution RecentExecution) {
+	r.items[r.next] = execution
+	r.next = (r.next + 1) % len(r.items)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// newest returns the kept executions, most recent first
+func (r *resultRing) newest() []RecentExecution {
+	count := r.next
+	if r.full {
+		count = len(r.items)
+	}
+	executions := make([]RecentExecution, 0, count)
+	for i := 1; i <= count; i++ {
+		executions = append(executions, r.items[(r.next-i+len(r.items))%len(r.items)])
+	}
+	return executions
+}
+
+// Recent returns the most recent executions of the named request in this
+// run, most recent first, up to SchedulerConfig.RecentResults of them
+func (s *Scheduler) Recent(name string) ([]RecentExecution, error) {
+	if s.findRequest(name) == nil {
+		return nil, fmt.Errorf("unknown request: %s", name)
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	ring, ok := s.recent[name]
+	if !ok {
+		return nil, nil
+	}
+	return ring.newest(), nil
+}
+
+// keepRecent adds an execution to its request's recent executions
+func (s *Scheduler) keepRecent(result *ExecutionResult) {
+	if s.recentSize <= 0 {
+		return
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.recent == nil {
+		s.recent = make(map[string]*resultRing)
+	}
+	ring, ok := s.recent[result.RequestName]
+	if !ok {
+		ring = &resultRing{items: make([]RecentExecution, s.recentSize)}
+		s.recent[result.RequestName] = ring
+	}
+	ring.add(RecentExecution{
+		ExecutionID: result.ExecutionID,
+		StartedAt:   result.StartedAt,
+		Duration:    result.Duration,
+		StatusCode:  result.StatusCode,
+		StatusClass: result.StatusClass(),
+		Success:     result.Success(),
+		Reason:      result.FailureReason(),
+	})
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestResultRing(t *testing.T) {
+	ring := &resultRing{items: make([]RecentExecution, 3)}
+	if got := ring.newest(); len(got) != 0 {
+		t.Errorf("Expected an empty ring, got %v", got)
+	}
+
+	for i := 1; i <= 5; i++ {
+		ring.add(RecentExecution{StatusCode: i})
+		want := i
+		if want > 3 {
+			want = 3
+		}
+		got := ring.newest()
+		if len(got) != want {
+			t.Fatalf("After %d adds: expected %d executions, got %d", i, want, len(got))
+		}
+		for j, execution := range got {
+			if execution.StatusCode != i-j {
+				t.Errorf("After %d adds: expected execution %d to be %d, got %d", i, j, i-j, execution.StatusCode)
+			}
+		}
+	}
+}
+
+func TestScheduler_Recent(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls%2 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	scheduler := NewScheduler([]spec.ScheduledRequest{{
+		Name:     "flaky",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+	}}, SchedulerConfig{Once: true, Count: 5, Concurrency: 1, RecentResults: 3, Quiet: true})
+
+	if recent, err := scheduler.Recent("flaky"); err != nil || len(recent) != 0 {
+		t.Errorf("Expected no executions before the run, got %v (%v)", recent, err)
+	}
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	recent, err := scheduler.Recent("flaky")
+	if err != nil {
+		t.Fatalf("Recent failed: %v", err)
+	}
+	if len(recent) != 3 {
+		t.Fatalf("Expected the last 3 executions, got %d", len(recent))
+	}
+	// The 5th, 4th and 3rd executions, most recent first
+	wantStatus := []int{200, 503, 200}
+	for i, execution := range recent {
+		if execution.StatusCode != wantStatus[i] || execution.Success != (wantStatus[i] == 200) {
+			t.Errorf("Execution %d: expected status %d, got %+v", i, wantStatus[i], execution)
+		}
+		if i > 0 && execution.StartedAt.After(recent[i-1].StartedAt) {
+			t.Errorf("Expected executions most recent first, got %v after %v", execution.StartedAt, recent[i-1].StartedAt)
+		}
+	}
+	if recent[1].Reason != "HTTP 503 Service Unavailable" || recent[1].StatusClass != Class5xx {
+		t.Errorf("Expected the failure to be described, got %+v", recent[1])
+	}
+
+	if _, err := scheduler.Recent("missing"); err == nil {
+		t.Error("Expected an unknown request to be rejected")
+	}
+}
+
+func TestScheduler_RecentDisabled(t *testing.T) {
+	scheduler := NewScheduler([]spec.ScheduledRequest{{Name: "api"}}, SchedulerConfig{RecentResults: -1})
+	scheduler.record(ExecutionResult{RequestName: "api", StartedAt: time.Now(), StatusCode: 200})
+	if recent, err := scheduler.Recent("api"); err != nil || len(recent) != 0 {
+		t.Errorf("Expected no executions to be kept, got %v (%v)", recent, err)
+	}
+}
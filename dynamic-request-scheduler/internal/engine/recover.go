@@ -0,0 +1,25 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// recoverPanic turns a panic in the calling function into an error stored
+// in *err, so a bug in a template function, transformer or executor fails
+// only the execution it happened in. It must be deferred directly.
+func recoverPanic(err *error, what string) {
+	if r := recover(); r != nil {
+		log.Printf("Recovered from panic in %s: %v\n%s", what, r, debug.Stack())
+		*err = fmt.Errorf("panic in %s: %v", what, r)
+	}
+}
+
+// isolate stops a panic that escaped an execution of the named request from
+// taking down the goroutine running it. It must be deferred directly.
+func isolate(name string) {
+	if r := recover(); r != nil {
+		log.Printf("Recovered from panic executing request '%s': %v\n%s", name, r, debug.Stack())
+	}
+}
@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	err := func() (err error) {
+		defer recoverPanic(&err, "test executor")
+		panic("boom")
+	}()
+	if err == nil || err.Error() != "panic in test executor: boom" {
+		t.Errorf("Expected the panic returned as an error, got %v", err)
+	}
+
+	err = func() (err error) {
+		defer recoverPanic(&err, "test executor")
+		return errors.New("plain failure")
+	}()
+	if err == nil || err.Error() != "plain failure" {
+		t.Errorf("Expected errors without a panic left alone, got %v", err)
+	}
+}
+
+func TestScheduler_ExecutorPanic(t *testing.T) {
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "panics",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("http://localhost/panics")},
+		},
+		{
+			Name:     "works",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("http://localhost/works")},
+		},
+	}
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:        true,
+		Concurrency: 1,
+		Recorders:   []ResultRecorder{recorder},
+		Executors: map[string]Executor{spec.TypeHTTP: ExecutorFunc(func(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+			if strings.HasSuffix(resolved.URL, "/panics") {
+				panic("executor bug")
+			}
+			return &HTTPResponse{StatusCode: 200, Status: "200 OK"}, nil
+		})},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if len(recorder.results) != 2 {
+		t.Fatalf("Expected both executions recorded, got %+v", recorder.results)
+	}
+	for _, result := range recorder.results {
+		switch result.RequestName {
+		case "panics":
+			if !strings.Contains(result.Error, "panic in http executor") {
+				t.Errorf("Expected the panic recorded as a failure, got %q", result.Error)
+			}
+		case "works":
+			if !result.Success() {
+				t.Errorf("Expected the other request to succeed, got %+v", result)
+			}
+		}
+	}
+}
+
+// panickingTransformer panics on every request
+type panickingTransformer struct{}
+
+func (panickingTransformer) Transform(ctx context.Context, resolved *spec.ResolvedRequest) error {
+	panic("transformer bug")
+}
+
+// panickingRecorder panics on every result
+type panickingRecorder struct{}
+
+func (panickingRecorder) Record(result ExecutionResult) error {
+	panic("recorder bug")
+}
+
+func TestScheduler_PanicIsolation(t *testing.T) {
+	sent := 0
+	requests := []spec.ScheduledRequest{{
+		Name:     "orders",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("http://localhost/orders")},
+	}}
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:         true,
+		Count:        2,
+		Concurrency:  1,
+		Recorders:    []ResultRecorder{recorder, panickingRecorder{}},
+		Transformers: []Transformer{panickingTransformer{}},
+		Executors: map[string]Executor{spec.TypeHTTP: ExecutorFunc(func(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+			sent++
+			return nil, nil
+		})},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	// Both executions ran on the single runner despite the recorder panicking
+	if sent != 0 || len(recorder.results) != 2 {
+		t.Fatalf("Expected two failed executions and nothing sent, got %d sent and %+v", sent, recorder.results)
+	}
+	if !strings.Contains(recorder.results[0].Error, "panic in transformer: transformer bug") {
+		t.Errorf("Expected the transformer panic recorded, got %q", recorder.results[0].Error)
+	}
+}
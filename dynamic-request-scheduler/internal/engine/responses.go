@@ -0,0 +1,28 @@
+package engine
+
+import "local-dev-tools/dynamic-request-scheduler/internal/spec"
+
+// LastResponse implements spec.ResponseSource, returning the most recent
+// successful response of a request in this run
+func (s *Scheduler) LastResponse(request string) (spec.Response, bool) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	response, ok := s.lastResponses[request]
+	return response, ok
+}
+
+// keepResponse keeps a successful response for templates of other requests
+// to read with lastResponse
+func (s *Scheduler) keepResponse(result *ExecutionResult) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.lastResponses == nil {
+		s.lastResponses = make(map[string]spec.Response)
+	}
+	s.lastResponses[result.RequestName] = spec.Response{
+		StatusCode: result.StatusCode,
+		Headers:    result.ResponseHeaders,
+		Body:       result.ResponseBody,
+		Duration:   result.Duration,
+	}
+}
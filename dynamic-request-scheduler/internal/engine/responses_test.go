@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestScheduler_LastResponse(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/orders":
+			w.Write([]byte(`{"id": 7}`))
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "create-order",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "POST", URL: spec.NewLiteralString(server.URL + "/orders")},
+		},
+		{
+			Name:     "broken",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + "/broken")},
+		},
+		{
+			Name:     "get-order",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + `/orders/{{ lastResponse "create-order" "$.id" }}`)},
+		},
+	}
+	scheduler := NewScheduler(requests, SchedulerConfig{Once: true, Concurrency: 1})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(paths) != 3 || paths[2] != "/orders/7" {
+		t.Errorf("Expected get-order to use the created order's id, got %v", paths)
+	}
+	if response, ok := scheduler.LastResponse("create-order"); !ok || response.StatusCode != http.StatusOK {
+		t.Errorf("Expected create-order's response kept, got %+v (%v)", response, ok)
+	}
+	if _, ok := scheduler.LastResponse("broken"); ok {
+		t.Error("Expected a failed response not to be kept")
+	}
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ExecutionResult captures the outcome of a single request execution
+type ExecutionResult struct {
+	RunID        string
+	ExecutionID  string
+	RequestName  string
+	RequestID    string
+	Method       string
+	URL          string
+	Headers      map[string]string
+	Body         interface{}
+	ScheduledFor time.Time
+	StartedAt    time.Time
+	Duration     time.Duration
+	StatusCode   int
+	Status       string
+	Error        string
+	TimedOut     bool
+
+	// Slow is set when a completed execution exceeded its slow threshold
+	Slow bool
+
+	// Unmet describes each expectation of the request's expect section the
+	// response did not meet
+	Unmet []string
+
+	// Warnings describes each expectation of a warn block the response did
+	// not meet; unlike Unmet, they do not fail the execution
+	Warnings []string
+
+	// Attempts is how many times the request was sent, more than one when
+	// its retry policy retried it; the other fields describe the last attempt
+	Attempts int
+
+	// ExpectedStatus is set when the status is outside 2xx but the
+	// request's expect section accepts it, so it does not fail the execution
+	ExpectedStatus bool
+
+	// ResponseHeaders and ResponseBody are set when a response was received
+	ResponseHeaders http.Header
+	ResponseBody    []byte
+
+	// Timings breaks down the duration of an HTTP exchange by phase; nil
+	// for other request types and when no response was received
+	Timings *Timings
+}
+
+// Success returns true if the execution completed with a 2xx or expected
+// response that met the request's expectations
+func (r *ExecutionResult) Success() bool {
+	return r.Error == "" && r.statusOK() && len(r.Unmet) == 0
+}
+
+// statusOK reports whether the status alone lets the execution succeed
+func (r *ExecutionResult) statusOK() bool {
+	return (r.StatusCode >= 200 && r.StatusCode < 300) || r.ExpectedStatus
+}
+
+// FailureReason describes why an execution did not succeed: its error, its
+// status, or the expectations its response did not meet
+func (r *ExecutionResult) FailureReason() string {
+	switch {
+	case r.Error != "":
+		return r.Error
+	case !r.statusOK():
+		return "HTTP " + r.Status
+	case len(r.Unmet) > 0:
+		return "unmet expectation: " + strings.Join(r.Unmet, "; ")
+	default:
+		return ""
+	}
+}
+
+// Status classes reported by StatusClass
+const (
+	Class2xx     = "2xx"
+	Class3xx     = "3xx"
+	Class4xx     = "4xx"
+	Class5xx     = "5xx"
+	ClassTimeout = "timeout"
+	ClassError   = "error"
+)
+
+// StatusClasses lists every status class in display order
+var StatusClasses = []string{Class2xx, Class3xx, Class4xx, Class5xx, ClassTimeout, ClassError}
+
+// StatusClass buckets the result by response status, timeout or other error
+func (r *ExecutionResult) StatusClass() string {
+	switch {
+	case r.TimedOut:
+		return ClassTimeout
+	case r.Error != "":
+		return ClassError
+	case r.StatusCode >= 200 && r.StatusCode < 300:
+		return Class2xx
+	case r.StatusCode >= 300 && r.StatusCode < 400:
+		return Class3xx
+	case r.StatusCode >= 400 && r.StatusCode < 500:
+		return Class4xx
+	case r.StatusCode >= 500 && r.StatusCode < 600:
+		return Class5xx
+	default:
+		return ClassError
+	}
+}
+
+// ResultRecorder receives execution results as they complete
+type ResultRecorder interface {
+	Record(result ExecutionResult) error
+}
+
+// Grower is implemented by recorders that count towards a known number of
+// executions, such as a progress bar. A run with Once tells them how many
+// extra executions fan_out added once every execution is queued.
+type Grower interface {
+	Grow(n int)
+}
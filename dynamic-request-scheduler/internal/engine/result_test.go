@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestExecutionResult_StatusClass(t *testing.T) {
+	tests := []struct {
+		result ExecutionResult
+		want   string
+	}{
+		{ExecutionResult{StatusCode: 200}, Class2xx},
+		{ExecutionResult{StatusCode: 204}, Class2xx},
+		{ExecutionResult{StatusCode: 301}, Class3xx},
+		{ExecutionResult{StatusCode: 404}, Class4xx},
+		{ExecutionResult{StatusCode: 503}, Class5xx},
+		{ExecutionResult{Error: "refused"}, ClassError},
+		{ExecutionResult{Error: "deadline", TimedOut: true}, ClassTimeout},
+		{ExecutionResult{StatusCode: 101}, ClassError},
+	}
+
+	for _, tt := range tests {
+		if got := tt.result.StatusClass(); got != tt.want {
+			t.Errorf("StatusClass(%+v) = %s, want %s", tt.result, got, tt.want)
+		}
+	}
+}
+
+func TestExecutionResult_FailureReason(t *testing.T) {
+	tests := []struct {
+		result ExecutionResult
+		want   string
+	}{
+		{ExecutionResult{StatusCode: 200, Status: "200 OK"}, ""},
+		{ExecutionResult{Error: "refused"}, "refused"},
+		{ExecutionResult{StatusCode: 503, Status: "503 Service Unavailable"}, "HTTP 503 Service Unavailable"},
+		{ExecutionResult{StatusCode: 200, Status: "200 OK", Unmet: []string{"a", "b"}}, "unmet expectation: a; b"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.result.FailureReason(); got != tt.want {
+			t.Errorf("FailureReason(%+v) = %q, want %q", tt.result, got, tt.want)
+		}
+		if success := tt.result.Success(); success != (tt.want == "") {
+			t.Errorf("Success(%+v) = %v, want %v", tt.result, success, tt.want == "")
+		}
+	}
+}
+
+func TestScheduler_RecordsTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "slow",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+	}
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:      true,
+		Timeout:   50 * time.Millisecond,
+		Recorders: []ResultRecorder{recorder},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if len(recorder.results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(recorder.results))
+	}
+	if got := recorder.results[0].StatusClass(); got != ClassTimeout {
+		t.Errorf("Expected timeout class, got %s (%s)", got, recorder.results[0].Error)
+	}
+}
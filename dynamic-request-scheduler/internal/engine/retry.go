@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// sendAttempts sends a resolved request, retrying it under the request's
+// retry policy, and returns the last attempt's outcome and how many
+// attempts were made. Each failed attempt that is retried is logged with
+// the delay before the next one, whose jitter is drawn from rng.
+func (s *Scheduler) sendAttempts(ctx context.Context, req *spec.ScheduledRequest, resolved *spec.ResolvedRequest, executionID string, rng *rand.Rand) (*HTTPResponse, int, error) {
+	resp, err := s.send(ctx, resolved)
+	policy := req.Retry
+	if policy == nil {
+		return resp, 1, err
+	}
+
+	attempt := 1
+	for ; attempt < policy.MaxAttempts() && s.retryable(req, resp, err); attempt++ {
+		// A cancelled or expired execution has no time left to retry in
+		if ctx.Err() != nil {
+			break
+		}
+
+		delay := policy.Delay(attempt, rng)
+		if s.logsProgress(req) {
+			s.logExecution("Request '%s' [%s] attempt %d/%d %s, retrying in %v", resolved.Name, executionID,
+				attempt, policy.MaxAttempts(), s.colorize(ClassError, "failed: "+attemptFailure(resp, err)), delay.Round(time.Millisecond))
+		}
+
+		timer := time.NewTimer(s.realDuration(delay))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, attempt, err
+		case <-timer.C:
+		}
+
+		resp, err = s.send(ctx, resolved)
+	}
+	return resp, attempt, err
+}
+
+// retryable reports whether an attempt failed in a way its request's retry
+// policy retries: with an error, or with a retried status that the request
+// does not expect
+func (s *Scheduler) retryable(req *spec.ScheduledRequest, resp *HTTPResponse, err error) bool {
+	if err != nil {
+		return true
+	}
+	return req.Retry.RetriesStatus(resp.StatusCode) && !req.Expect.AcceptsStatus(resp.StatusCode)
+}
+
+// attemptsNote is appended to an execution's outcome line when its request
+// retries, saying which attempt the outcome is from
+func attemptsNote(req *spec.ScheduledRequest, attempts int) string {
+	if req.Retry == nil {
+		return ""
+	}
+	return fmt.Sprintf(", attempt %d/%d", attempts, req.Retry.MaxAttempts())
+}
+
+// attemptFailure describes why an attempt failed, for the retry log line
+func attemptFailure(resp *HTTPResponse, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Status
+}
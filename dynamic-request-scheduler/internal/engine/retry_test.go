@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestScheduler_Retry(t *testing.T) {
+	var flakyCalls, downCalls, missingCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if flakyCalls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/down":
+			downCalls.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		case "/missing":
+			missingCalls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	retry := &spec.RetrySpec{Attempts: 3, Backoff: "1ms", MaxBackoff: "5ms"}
+	var requests []spec.ScheduledRequest
+	for _, path := range []string{"flaky", "down", "missing"} {
+		requests = append(requests, spec.ScheduledRequest{
+			Name:     path,
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + "/" + path)},
+			Retry:    retry,
+		})
+	}
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{Once: true, Recorders: []ResultRecorder{recorder}})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if len(recorder.results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(recorder.results))
+	}
+	for _, result := range recorder.results {
+		switch result.RequestName {
+		case "flaky":
+			if !result.Success() || result.Attempts != 3 {
+				t.Errorf("Expected the flaky request to succeed on its third attempt, got %d (%d attempts)", result.StatusCode, result.Attempts)
+			}
+		case "down":
+			if result.Success() || result.Attempts != 3 || result.StatusCode != http.StatusBadGateway {
+				t.Errorf("Expected the down request to fail after 3 attempts, got %d (%d attempts)", result.StatusCode, result.Attempts)
+			}
+		case "missing":
+			if result.Attempts != 1 {
+				t.Errorf("Expected a 404 not to be retried, got %d attempts", result.Attempts)
+			}
+		}
+	}
+	if flakyCalls.Load() != 3 || downCalls.Load() != 3 || missingCalls.Load() != 1 {
+		t.Errorf("Expected 3, 3 and 1 calls, got %d, %d and %d", flakyCalls.Load(), downCalls.Load(), missingCalls.Load())
+	}
+}
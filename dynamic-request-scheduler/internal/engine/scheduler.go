@@ -2,8 +2,11 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,26 +15,203 @@ import (
 
 // Scheduler manages request execution
 type Scheduler struct {
-	requests    []spec.ScheduledRequest
-	workers     int
-	concurrency int
-	once        bool
-	dryRun      bool
-	httpClient  *HTTPClient
-	ctx         context.Context
-	cancel      context.CancelFunc
-	wg          sync.WaitGroup
-	mu          sync.Mutex
-	running     bool
+	requests     []spec.ScheduledRequest
+	workers      int
+	shards       []string
+	concurrency  int
+	once         bool
+	count        int
+	duration     time.Duration
+	exitDone     bool
+	dryRun       bool
+	executors    map[string]Executor
+	transformers []Transformer
+	recorders    []ResultRecorder
+	observers    []Observer
+	setup        []spec.ScheduledRequest
+	teardown     []spec.ScheduledRequest
+	runID        string
+	seed         int64
+	runIDHeader  string
+	execHeader   string
+	color        bool
+	slow         time.Duration
+	quiet        bool
+	failureLog   *failureLog
+	clock        spec.Clock
+	limiter      *rateLimiter
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	mu           sync.Mutex
+	running      bool
+	evaluator    *spec.Evaluator
+	queue        *dispatchQueue
+	pressure     backpressure
+	startedAt    time.Time
+
+	// stateStore saves the state requests keep between firings; nil keeps it
+	// in memory for the run
+	stateStore StateStore
+
+	// abandonTeardown cancels the teardown requests while they run
+	abandonTeardown context.CancelFunc
+
+	// variables are shared by every evaluator of the run; setup captures
+	// fill them before dispatch begins, and extracts replace the map with an
+	// updated copy afterwards, under stateMu
+	variables map[string]interface{}
+
+	// extractReads lists, per request, the variables it reads that are set
+	// by extracts
+	extractReads map[string][]string
+
+	// Per-request runtime state, guarded by stateMu
+	stateMu  sync.Mutex
+	paused   map[string]bool
+	inFlight map[string]int
+	lastRun  map[string]time.Time
+	state    map[string]map[string]interface{}
+
+	// lastResponses keeps each request's most recent successful response
+	lastResponses map[string]spec.Response
+
+	// recent keeps each request's most recent executions for Recent, up to
+	// recentSize of them
+	recent     map[string]*resultRing
+	recentSize int
+
+	// traceHTTP selects the requests whose HTTP traffic is dumped
+	traceHTTP *spec.RequestFilter
+
+	// warmth tracks the endpoint state of each keep-warm request
+	warmth map[string]*Warmth
+
+	// One-shot (epoch and template) schedules fire once; fired records those
+	// already dispatched and templateDue caches each template's resolved time
+	fired       map[string]bool
+	templateDue map[string]time.Time
+	// cronDue caches each cron request's next fire time until it is claimed
+	cronDue map[string]time.Time
+	// dispatched records when each relative request was last dispatched, on
+	// the scheduler clock; its next run is an interval later, and the first
+	// one an interval after clockStart
+	dispatched map[string]time.Time
+	clockStart time.Time
+	// jitter holds the delay drawn for each relative and every request's
+	// next firing, until it is claimed
+	jitter map[string]time.Duration
+	// every tracks the firings of every schedules
+	every map[string]*everyState
+	// queued records requests waiting in the dispatch queue, so a request
+	// that is still waiting for a runner is not queued again
+	queued map[string]bool
+	// active counts dispatched executions that have not finished yet
+	active int
 }
 
 // SchedulerConfig holds configuration for the scheduler
 type SchedulerConfig struct {
-	Workers     int
+	Workers int
+	// ShardBy spreads requests without a shard over the workers by request
+	// (the default) or by host; see spec.AssignShards
+	ShardBy     string
 	Concurrency int
 	Once        bool
 	DryRun      bool
 	Timeout     time.Duration
+	Recorders   []ResultRecorder
+
+	// Executors send requests of the types they are keyed by, replacing the
+	// built-in executor for a type or adding a new one
+	Executors map[string]Executor
+
+	// Transformers rewrite each resolved request before it is sent, such as
+	// transform plugins that sign requests
+	Transformers []Transformer
+
+	// Observers are notified as each execution is scheduled, starts and
+	// completes; more can be added with AddObserver
+	Observers []Observer
+
+	// Count is the number of times each request is executed in once mode;
+	// values below 1 mean a single execution
+	Count int
+
+	// Duration stops a continuous run after it has elapsed on Clock; zero
+	// runs until stopped
+	Duration time.Duration
+
+	// ExitWhenDone stops a continuous run once every request has fired its
+	// last scheduled execution and none are in flight. Runs that include
+	// endless schedules (see spec.ScheduleSpec.Endless) never finish on
+	// their own.
+	ExitWhenDone bool
+
+	// RPS caps how many executions start per second across all requests;
+	// zero means unlimited
+	RPS float64
+
+	// Clock supplies the current time to templates and schedules; defaults to
+	// the real clock
+	Clock spec.Clock
+
+	// StateStore saves the state requests keep between firings, so it
+	// survives restarts; nil keeps it in memory for the run
+	StateStore StateStore
+
+	// Setup requests run once, in order, before dispatch begins; their
+	// captures set variables every later request reads, and the run fails
+	// without dispatching anything if one of them fails
+	Setup []spec.ScheduledRequest
+
+	// Teardown requests run once, in order, after dispatch stops at the end
+	// of a run, including after Stop; their schedules are ignored
+	Teardown []spec.ScheduledRequest
+
+	// RunID identifies this scheduler run; one is generated when empty
+	RunID string
+
+	// Seed makes template random values (randInt, fake data, uuid, ...)
+	// reproducible: each execution draws from a seed derived from it and the
+	// execution's number in the run. One is generated when zero, so every run
+	// can be replayed with the seed it logs.
+	Seed int64
+
+	// RunIDHeader and ExecutionIDHeader name the correlation headers injected
+	// into every request; an empty name disables that header
+	RunIDHeader       string
+	ExecutionIDHeader string
+
+	// Color enables ANSI colors for execution status in log output
+	Color bool
+
+	// SlowThreshold marks completed executions taking longer as slow; requests
+	// may override it with slow_threshold. Zero disables the check.
+	SlowThreshold time.Duration
+
+	// Quiet suppresses the log lines written for every execution, for
+	// high-volume runs such as load tests; failures still reach recorders
+	Quiet bool
+
+	// RepeatLogInterval collapses the log lines of a request that keeps
+	// failing with the same reason into one line per interval, defaulting to
+	// DefaultRepeatLogInterval; a negative value logs every failure
+	RepeatLogInterval time.Duration
+
+	// RecentResults is how many executions of each request Recent keeps,
+	// defaulting to DefaultRecentResults; a negative value keeps none
+	RecentResults int
+
+	// MaxResponseBytes caps how much of each HTTP response body is kept for
+	// recorders and checks, defaulting to DefaultMaxResponseBytes; a negative
+	// value keeps whole responses
+	MaxResponseBytes int64
+
+	// TraceHTTP logs the exact bytes sent and received by the HTTP, SOAP and
+	// JSON-RPC requests it matches, with sensitive headers redacted; nil
+	// traces nothing
+	TraceHTTP *spec.RequestFilter
 }
 
 // NewScheduler creates a new scheduler with the given configuration
@@ -45,17 +225,64 @@ func NewScheduler(requests []spec.ScheduledRequest, config SchedulerConfig) *Sch
 	if config.Timeout <= 0 {
 		config.Timeout = 30 * time.Second
 	}
+	if config.Count <= 0 {
+		config.Count = 1
+	}
+	if config.RecentResults == 0 {
+		config.RecentResults = DefaultRecentResults
+	}
+	if config.MaxResponseBytes == 0 {
+		config.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+	if config.RunID == "" {
+		config.RunID = NewID()
+	}
+	if config.Clock == nil {
+		config.Clock = &spec.RealClock{}
+	}
+	if config.Seed == 0 {
+		config.Seed = NewSeed()
+	}
+
+	executors := defaultExecutors(config.Timeout, config.MaxResponseBytes)
+	for requestType, executor := range config.Executors {
+		executors[requestType] = executor
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Scheduler{
-		requests:    requests,
-		workers:     config.Workers,
-		concurrency: config.Concurrency,
-		once:        config.Once,
-		dryRun:      config.DryRun,
-		httpClient:  NewHTTPClient(config.Timeout),
-		ctx:         ctx,
-		cancel:      cancel,
+		requests:     requests,
+		workers:      config.Workers,
+		shards:       spec.AssignShards(requests, config.Workers, config.ShardBy),
+		concurrency:  config.Concurrency,
+		once:         config.Once,
+		count:        config.Count,
+		duration:     config.Duration,
+		exitDone:     config.ExitWhenDone,
+		dryRun:       config.DryRun,
+		executors:    executors,
+		transformers: config.Transformers,
+		recorders:    config.Recorders,
+		observers:    append([]Observer(nil), config.Observers...),
+		setup:        config.Setup,
+		teardown:     config.Teardown,
+		runID:        config.RunID,
+		seed:         config.Seed,
+		runIDHeader:  config.RunIDHeader,
+		execHeader:   config.ExecutionIDHeader,
+		color:        config.Color,
+		slow:         config.SlowThreshold,
+		quiet:        config.Quiet,
+		failureLog:   newFailureLog(config.RepeatLogInterval),
+		clock:        config.Clock,
+		limiter:      newRateLimiter(config.RPS),
+		ctx:          ctx,
+		cancel:       cancel,
+		variables:    make(map[string]interface{}),
+		extractReads: extractReads(config.Setup, requests),
+		stateStore:   config.StateStore,
+		recentSize:   config.RecentResults,
+		traceHTTP:    config.TraceHTTP,
 	}
 }
 
@@ -69,21 +296,44 @@ func (s *Scheduler) Start() error {
 	s.running = true
 	s.mu.Unlock()
 
-	log.Printf("Starting scheduler with %d requests, %d workers, concurrency: %d",
-		len(s.requests), s.workers, s.concurrency)
+	s.stateMu.Lock()
+	s.startedAt = time.Now()
+	s.clockStart = s.now()
+	s.stateMu.Unlock()
+
+	log.Printf("Starting scheduler run %s with %d requests, %d workers, concurrency: %d, seed: %d",
+		s.runID, len(s.requests), s.workers, s.concurrency, s.seed)
 
 	if s.dryRun {
 		return s.runDryRun()
 	}
 
-	if s.once {
-		return s.runOnce()
+	// Teardown also follows a failed setup, which may have created
+	// resources before it failed
+	err := s.runSetup()
+	if err == nil && s.ctx.Err() == nil {
+		if s.once {
+			err = s.runOnce()
+		} else {
+			err = s.runContinuous()
+		}
 	}
+	s.runTeardown()
+	return err
+}
+
+// RunID returns the identifier of this scheduler run
+func (s *Scheduler) RunID() string {
+	return s.runID
+}
 
-	return s.runContinuous()
+// Seed returns the seed template random values are drawn from in this run
+func (s *Scheduler) Seed() int64 {
+	return s.seed
 }
 
-// Stop stops the scheduler
+// Stop stops the scheduler. Teardown requests still run once dispatch has
+// stopped; calling Stop again while they run abandons the rest of them.
 func (s *Scheduler) Stop() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -92,66 +342,131 @@ func (s *Scheduler) Stop() {
 		log.Println("Stopping scheduler...")
 		s.cancel()
 		s.running = false
+	} else if s.abandonTeardown != nil {
+		log.Println("Abandoning teardown...")
+		s.abandonTeardown()
 	}
 }
 
-// runDryRun shows what would be executed without actually running
+// runDryRun shows what would be executed without actually running. Next run
+// times come from the same schedule evaluation the workers use, and requests
+// that fail to evaluate fail the dry run.
 func (s *Scheduler) runDryRun() error {
 	log.Println("DRY RUN MODE - No requests will be sent")
+	log.Printf("Evaluating as of %s", s.clock.Now().Format(time.RFC3339))
 
-	evaluator := spec.NewEvaluator(spec.NewTemplateEngine(&spec.EvaluationContext{
-		Variables: make(map[string]interface{}),
-		Clock:     &spec.RealClock{},
-	}))
+	for _, req := range s.setup {
+		method, url := req.Target()
+		log.Printf("Setup request: %s (%s %s)", req.Name, method, url)
+	}
+
+	evaluator := s.newEvaluator()
+	scheduleEngine := spec.NewScheduleEngine()
 
-	for _, req := range s.requests {
-		resolved, err := evaluator.EvaluateRequest(&req)
+	// Number executions as the first pass of a once run would, so a seeded
+	// preview shows the values that run sends
+	var invalid []string
+	for i := range s.requests {
+		req := &s.requests[i]
+		due, ok, err := s.dueTime(req)
+		if err != nil {
+			log.Printf("Error evaluating schedule of request '%s': %v", req.Name, err)
+			invalid = append(invalid, req.Name)
+			continue
+		}
+		resolved, err := evaluate(evaluator.ForRequest(int64(i+1), req.Name, 1), req)
 		if err != nil {
 			log.Printf("Error evaluating request '%s': %v", req.Name, err)
+			invalid = append(invalid, req.Name)
 			continue
 		}
 
 		log.Printf("Request: %s", resolved.Name)
 		log.Printf("  Method: %s", resolved.Method)
 		log.Printf("  URL: %s", resolved.URL)
-		log.Printf("  Scheduled for: %s", resolved.ScheduledFor.Format(time.RFC3339))
+		switch {
+		case !ok:
+			log.Printf("  Next run: none")
+		case req.Schedule.Relative != nil && req.Schedule.Repeats():
+			log.Printf("  Next run: %s, then every %s", due.Format(time.RFC3339), *req.Schedule.Relative)
+		case req.Schedule.Every != nil && req.Schedule.Count > 1:
+			log.Printf("  Next run: %s, then every %s, %d runs in all", due.Format(time.RFC3339), *req.Schedule.Every, req.Schedule.Count)
+		case req.Schedule.Every != nil && req.Schedule.Repeats():
+			log.Printf("  Next run: %s, then every %s", due.Format(time.RFC3339), *req.Schedule.Every)
+		default:
+			log.Printf("  Next run: %s", due.Format(time.RFC3339))
+		}
+		// Every schedule but epoch applies jitter when dispatched
+		if jitter := scheduleEngine.MaxJitter(req.Schedule); jitter > 0 && req.Schedule.Epoch == nil {
+			log.Printf("  Jitter: up to %v, included in the next run", jitter)
+		}
+		log.Printf("  Shard: %s", s.shards[i])
 		log.Printf("  Headers: %v", resolved.Headers)
 		if resolved.Body != nil {
 			log.Printf("  Body: %v", resolved.Body)
 		}
+		if resolved.BodyFile != "" {
+			log.Printf("  Body file: %s", resolved.BodyFile)
+		}
 		log.Println()
 	}
 
+	for _, req := range s.teardown {
+		method, url := req.Target()
+		log.Printf("Teardown request: %s (%s %s)", req.Name, method, url)
+	}
+
+	if len(invalid) > 0 {
+		return &DryRunError{Invalid: invalid}
+	}
 	return nil
 }
 
-// runOnce executes all requests once and exits
-func (s *Scheduler) runOnce() error {
-	log.Println("Running all requests once...")
-
-	evaluator := spec.NewEvaluator(spec.NewTemplateEngine(&spec.EvaluationContext{
-		Variables: make(map[string]interface{}),
-		Clock:     &spec.RealClock{},
-	}))
+// DryRunError reports the requests a dry run could not evaluate, which would
+// fail every time the scheduler ran them
+type DryRunError struct {
+	Invalid []string
+}
 
-	// Create a worker pool for concurrent execution
-	semaphore := make(chan struct{}, s.concurrency)
-	var wg sync.WaitGroup
+func (e *DryRunError) Error() string {
+	return fmt.Sprintf("%d request(s) failed to evaluate: %s", len(e.Invalid), strings.Join(e.Invalid, ", "))
+}
 
-	for _, req := range s.requests {
-		wg.Add(1)
-		go func(request spec.ScheduledRequest) {
-			defer wg.Done()
+// runOnce executes every request count times and exits
+func (s *Scheduler) runOnce() error {
+	if s.count > 1 {
+		log.Printf("Running all requests %d times...", s.count)
+	} else {
+		log.Println("Running all requests once...")
+	}
 
-			// Acquire semaphore
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
+	evaluator := s.newEvaluator()
 
-			// Evaluate and execute request
-			s.executeRequest(&request, evaluator)
-		}(req)
+	// Queue every execution up front and let the runners work through them
+	// in order, at most s.concurrency at a time
+	queue := newDispatchQueue()
+	extra := 0
+	for i := 0; i < s.count; i++ {
+		for j := range s.requests {
+			req := &s.requests[j]
+			copies := s.fanOut(req, evaluator, queue)
+			extra += copies - 1
+			for ; copies > 0; copies-- {
+				queue.push(dispatch{req: req})
+			}
+		}
+	}
+	queue.close()
+	if extra > 0 {
+		for _, recorder := range s.recorders {
+			if grower, ok := recorder.(Grower); ok {
+				grower.Grow(extra)
+			}
+		}
 	}
 
+	var wg sync.WaitGroup
+	s.startRunners(queue, evaluator, &wg)
 	wg.Wait()
 	log.Println("All requests completed")
 	return nil
@@ -161,120 +476,692 @@ func (s *Scheduler) runOnce() error {
 func (s *Scheduler) runContinuous() error {
 	log.Println("Starting continuous scheduling...")
 
-	// Create evaluator with context
-	evaluator := spec.NewEvaluator(spec.NewTemplateEngine(&spec.EvaluationContext{
-		Variables: make(map[string]interface{}),
-		Clock:     &spec.RealClock{},
-	}))
+	evaluator := s.newEvaluator()
 
-	// Create a worker pool for concurrent execution
-	semaphore := make(chan struct{}, s.concurrency)
+	// Workers queue due executions and a pool of runners executes them, so
+	// waiting for a free slot never holds up evaluating other schedules
+	queue := newDispatchQueue()
+	var runners sync.WaitGroup
+	s.startRunners(queue, evaluator, &runners)
 
-	// Start worker goroutines
-	for i := 0; i < s.workers; i++ {
+	// Expose the evaluator and queue so manual triggers share them
+	s.mu.Lock()
+	s.evaluator = evaluator
+	s.queue = queue
+	s.mu.Unlock()
+
+	if scaled, ok := s.clock.(*spec.ScaledClock); ok {
+		log.Printf("Virtual clock running at %gx from %s", scaled.Scale, scaled.Origin.UTC().Format(time.RFC3339))
+	}
+
+	if s.exitDone {
+		for _, req := range s.requests {
+			if req.Schedule.Endless() {
+				log.Printf("Request '%s' repeats indefinitely; the run will not exit on its own", req.Name)
+			}
+		}
+	}
+
+	if s.duration > 0 {
+		timer := time.AfterFunc(s.realDuration(s.duration), func() {
+			log.Printf("Run duration of %v elapsed", s.duration)
+			s.Stop()
+		})
+		defer timer.Stop()
+	}
+
+	// Start one worker per shard, each evaluating only its own requests
+	for _, group := range s.workerGroups() {
 		s.wg.Add(1)
-		go s.worker(i, evaluator, semaphore)
+		go s.worker(group.shard, group.requests, queue)
 	}
 
 	// Wait for context cancellation
 	<-s.ctx.Done()
 
-	// Wait for all workers to finish
+	// Wait for all workers to finish, then for the runners to skip what is
+	// still queued and finish what is in flight
 	s.wg.Wait()
+	queue.close()
+	runners.Wait()
 
 	log.Println("Scheduler stopped")
 	return nil
 }
 
-// worker runs in a loop, processing scheduled requests
-func (s *Scheduler) worker(id int, evaluator *spec.Evaluator, semaphore chan struct{}) {
+// worker dispatches a group's requests as they fall due, sleeping until the
+// earliest of them and putting recurring requests back on its timeline once
+// their executions end
+func (s *Scheduler) worker(shard string, requests []*spec.ScheduledRequest, queue *dispatchQueue) {
 	defer s.wg.Done()
 
-	log.Printf("Worker %d started", id)
+	log.Printf("Worker %s started with %d requests", shard, len(requests))
+
+	// Each request waits on the timeline until it is due rather than being
+	// checked on every pass
+	line := newTimeline()
+	for _, req := range requests {
+		s.plan(line, req)
+	}
 
+	var lastPass time.Time
 	for {
-		select {
-		case <-s.ctx.Done():
-			log.Printf("Worker %d stopping", id)
+		if s.ctx.Err() != nil {
+			log.Printf("Worker %s stopping", shard)
 			return
-		default:
-			// Process all requests
-			for _, req := range s.requests {
-				select {
-				case <-s.ctx.Done():
-					return
-				default:
-					// Check if it's time to run this request
-					if s.shouldRunRequest(&req, evaluator) {
-						// Acquire semaphore for concurrency control
-						semaphore <- struct{}{}
-
-						// Execute request in a goroutine to allow concurrent execution
-						go func(request spec.ScheduledRequest) {
-							defer func() { <-semaphore }()
-							s.executeRequest(&request, evaluator)
-						}(req)
-					}
-				}
+		}
+
+		if s.exitDone && s.allDone() {
+			log.Println("All requests have completed their schedules")
+			s.Stop()
+			continue
+		}
+
+		// While the runners are saturated, dispatch at most once per
+		// backpressure interval and leave due requests waiting
+		pressure := s.pressure.adjust(s.concurrency, queue.len())
+		var wait time.Duration
+		if since := time.Since(lastPass); pressure.Saturated && since < pressure.Interval {
+			wait = pressure.Interval - since
+		} else {
+			lastPass = time.Now()
+			for _, req := range line.popDue(s.now()) {
+				s.dispatchDue(line, req, queue)
+			}
+			wait = s.timelineWait(line)
+			if pressure.Saturated {
+				wait = max(wait, pressure.Interval)
 			}
+		}
 
-			// Sleep before next iteration
-			time.Sleep(1 * time.Second)
+		// Sleep until the earliest request is due, waking early when a
+		// request is added back or an execution ends
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.ctx.Done():
+		case <-line.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// workerGroup is the requests evaluated by one worker
+type workerGroup struct {
+	shard    string
+	requests []*spec.ScheduledRequest
+}
+
+// workerGroups groups the requests by shard: one group for each shared
+// worker, even if it has no requests, then one for each other shard in
+// config order
+func (s *Scheduler) workerGroups() []workerGroup {
+	groups := make([]workerGroup, 0, s.workers)
+	index := make(map[string]int)
+	for i := 0; i < s.workers; i++ {
+		index[spec.WorkerShard(i)] = i
+		groups = append(groups, workerGroup{shard: spec.WorkerShard(i)})
+	}
+	for i, shard := range s.shards {
+		n, ok := index[shard]
+		if !ok {
+			n = len(groups)
+			index[shard] = n
+			groups = append(groups, workerGroup{shard: shard})
 		}
+		groups[n].requests = append(groups[n].requests, &s.requests[i])
 	}
+	return groups
 }
 
 // shouldRunRequest determines if a request should be executed now
-func (s *Scheduler) shouldRunRequest(req *spec.ScheduledRequest, evaluator *spec.Evaluator) bool {
-	// For now, we'll use a simple approach: run relative schedules immediately
-	// In a full implementation, this would track last run times and compute next runs
+func (s *Scheduler) shouldRunRequest(req *spec.ScheduledRequest) bool {
+	if s.IsPaused(req.Name) {
+		return false
+	}
+
+	due, ok, err := s.dueTime(req)
+	if err != nil {
+		log.Printf("Error evaluating schedule of request '%s': %v", req.Name, err)
+		return false
+	}
+	return ok && !due.After(s.now())
+}
+
+// dueTime returns when the workers next dispatch a request, which is also
+// what a dry run reports. ok is false when it is not dispatched again.
+func (s *Scheduler) dueTime(req *spec.ScheduledRequest) (due time.Time, ok bool, err error) {
+	if isOneShot(req.Schedule) && s.hasFired(req.Name) {
+		return time.Time{}, false, nil
+	}
 
+	switch {
+	case req.Schedule.Relative != nil:
+		due, err = s.relativeDueTime(req)
+	case req.Schedule.Epoch != nil:
+		return time.Unix(*req.Schedule.Epoch, 0), true, nil
+	case req.Schedule.Template != nil:
+		due, err = s.templateDueTime(req)
+	case req.Schedule.Cron != nil:
+		due, err = s.cronDueTime(req)
+	case req.Schedule.Every != nil:
+		return s.everyDueTime(req)
+	default:
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return due, true, nil
+}
+
+// isOneShot reports whether a schedule fires a single time
+func isOneShot(schedule spec.ScheduleSpec) bool {
+	return !schedule.Repeats()
+}
+
+// relativeDueTime returns when a relative schedule next fires: its interval
+// after it was last dispatched, or after the run started for its first run,
+// jitter included
+func (s *Scheduler) relativeDueTime(req *spec.ScheduledRequest) (time.Time, error) {
+	interval, err := time.ParseDuration(*req.Schedule.Relative)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid relative duration '%s': %w", *req.Schedule.Relative, err)
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	base, ok := s.dispatched[req.Name]
+	if !ok {
+		base = s.clockStart
+	}
+	return base.Add(interval + s.nextJitter(req)), nil
+}
+
+// nextJitter returns the jitter delaying the next firing of a relative or
+// every schedule. It is drawn once per firing, so the due time stays put
+// between checks; callers hold stateMu
+func (s *Scheduler) nextJitter(req *spec.ScheduledRequest) time.Duration {
+	if offset, ok := s.jitter[req.Name]; ok {
+		return offset
+	}
+	offset := spec.NewScheduleEngine().JitterOffset(req.Schedule)
+	if s.jitter == nil {
+		s.jitter = make(map[string]time.Duration)
+	}
+	s.jitter[req.Name] = offset
+	return offset
+}
+
+// templateDueTime resolves a template schedule once, on first use, so that
+// templates relative to now (e.g. "in 5 minutes") don't keep moving
+func (s *Scheduler) templateDueTime(req *spec.ScheduledRequest) (time.Time, error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	if due, ok := s.templateDue[req.Name]; ok {
+		return due, nil
+	}
+
+	templateEngine := spec.NewTemplateEngine(&spec.EvaluationContext{
+		Variables: s.variables,
+		Clock:     s.clock,
+	})
+	due, err := spec.NewScheduleEngine().ComputeNextRunWithTemplate(s.now(), req.Schedule, templateEngine)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if s.templateDue == nil {
+		s.templateDue = make(map[string]time.Time)
+	}
+	s.templateDue[req.Name] = due
+	return due, nil
+}
+
+// cronDueTime returns the next fire time of a cron schedule, jitter
+// included, computed when the request is first checked and again after
+// each dispatch
+func (s *Scheduler) cronDueTime(req *spec.ScheduledRequest) (time.Time, error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	if due, ok := s.cronDue[req.Name]; ok {
+		return due, nil
+	}
+
+	due, err := spec.NewScheduleEngine().ComputeNextRun(s.now(), req.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if s.cronDue == nil {
+		s.cronDue = make(map[string]time.Time)
+	}
+	s.cronDue[req.Name] = due
+	return due, nil
+}
+
+// hasFired reports whether a one-shot request has already been dispatched
+func (s *Scheduler) hasFired(name string) bool {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.fired[name]
+}
+
+// claimDispatch records a dispatch, returning false if the request is
+// still waiting in the queue or another worker already dispatched the same
+// one-shot request
+func (s *Scheduler) claimDispatch(req *spec.ScheduledRequest) bool {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	if s.queued[req.Name] {
+		return false
+	}
+
+	if isOneShot(req.Schedule) {
+		if s.fired[req.Name] {
+			return false
+		}
+		if s.fired == nil {
+			s.fired = make(map[string]bool)
+		}
+		s.fired[req.Name] = true
+	}
+	// The next cron fire time is computed from when this one was claimed,
+	// and the next relative or every firing draws its own jitter
+	delete(s.cronDue, req.Name)
+	delete(s.jitter, req.Name)
+	if req.Schedule.Every != nil {
+		s.countEvery(req)
+	}
 	if req.Schedule.Relative != nil {
-		// For relative schedules, we'll run them immediately for now
-		// TODO: Implement proper scheduling logic with last run tracking
-		return true
+		if s.dispatched == nil {
+			s.dispatched = make(map[string]time.Time)
+		}
+		s.dispatched[req.Name] = s.now()
+	}
+	if s.queued == nil {
+		s.queued = make(map[string]bool)
 	}
+	s.queued[req.Name] = true
+	s.active++
+	return true
+}
 
-	if req.Schedule.Epoch != nil {
-		// For epoch schedules, check if it's time
-		now := time.Now().Unix()
-		return *req.Schedule.Epoch <= now
+// unqueue records that a claimed execution has left the queue
+func (s *Scheduler) unqueue(name string) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	delete(s.queued, name)
+}
+
+// finishDispatch records that a dispatched execution has ended
+func (s *Scheduler) finishDispatch() {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.active--
+}
+
+// allDone reports whether every request is a one-shot that has fired and
+// no dispatched execution is still running
+func (s *Scheduler) allDone() bool {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	if s.active > 0 {
+		return false
 	}
+	for i := range s.requests {
+		if !s.completed(&s.requests[i]) {
+			return false
+		}
+	}
+	return true
+}
 
-	// For template and cron schedules, we need more sophisticated logic
-	// TODO: Implement proper scheduling for these types
-	return false
+// newEvaluator returns an evaluator over the run's variables, seed, clock
+// and responses
+func (s *Scheduler) newEvaluator() *spec.Evaluator {
+	return spec.NewEvaluator(spec.NewTemplateEngine(&spec.EvaluationContext{
+		Variables: s.variables,
+		Seed:      s.seed,
+		Clock:     s.clock,
+		Responses: s,
+	}))
 }
 
 // executeRequest evaluates and executes a single request
 func (s *Scheduler) executeRequest(req *spec.ScheduledRequest, evaluator *spec.Evaluator) {
+	s.execute(s.ctx, req, evaluator)
+}
+
+// execute evaluates and executes a single request, cancelling it when parent
+// is done. It returns the recorded result, or nil when the execution was
+// dropped before it started.
+func (s *Scheduler) execute(parent context.Context, req *spec.ScheduledRequest, evaluator *spec.Evaluator) *ExecutionResult {
+	s.notifyScheduled(req)
+
+	// Executions waiting for a rate limit slot are dropped when parent is done
+	if !s.limiter.wait(parent) {
+		return nil
+	}
+
+	// Cancelling parent cancels the execution, and the request's own timeout
+	// bounds all of it
+	ctx, cancel := executionContext(parent, req)
+	defer cancel()
+
 	start := time.Now()
+	s.markStarted(req.Name, start)
+	defer s.markFinished(req.Name)
+
+	executionID := NewID()
 
 	// Evaluate the request
-	resolved, err := evaluator.EvaluateRequest(req)
+	if len(req.State) > 0 {
+		evaluator = evaluator.WithState(s.requestState(req.RequestID()))
+	}
+	// Lines describing the outcome are written once it is known, unless
+	// the request keeps failing the same way
+	var lines []string
+	logf := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	// Requests read the variables extracted so far
+	variables := s.currentVariables()
+	evaluator = evaluator.WithVariables(variables)
+	var resolved *spec.ResolvedRequest
+	err := s.awaitExtracts(req, variables)
+	if err == nil {
+		resolved, err = evaluate(evaluator, req)
+	}
 	if err != nil {
-		log.Printf("Error evaluating request '%s': %v", req.Name, err)
-		return
+		logf("Error evaluating request '%s' [%s]: %s", req.Name, executionID, s.colorize(ClassError, err.Error()))
+		method, url := req.Target()
+		result := ExecutionResult{
+			RunID:       s.runID,
+			ExecutionID: executionID,
+			RequestName: req.Name,
+			RequestID:   req.RequestID(),
+			Method:      method,
+			URL:         url,
+			StartedAt:   start,
+			Duration:    time.Since(start),
+			Error:       err.Error(),
+		}
+		s.logOutcome(req, &result, lines)
+		s.record(result)
+		s.notifyComplete(nil, nil, result)
+		return &result
+	}
+
+	executionID = s.injectCorrelationHeaders(resolved, executionID)
+
+	// Transformers see the correlation headers, and what they change is what
+	// is sent and recorded
+	if err := s.transform(ctx, resolved); err != nil {
+		logf("Error transforming request '%s' [%s]: %s", resolved.Name, executionID, s.colorize(ClassError, err.Error()))
+		result := ExecutionResult{
+			RunID:        s.runID,
+			ExecutionID:  executionID,
+			RequestName:  resolved.Name,
+			RequestID:    req.RequestID(),
+			Method:       resolved.Method,
+			URL:          resolved.URL,
+			Headers:      resolved.Headers,
+			Body:         resolved.Body,
+			ScheduledFor: resolved.ScheduledFor,
+			StartedAt:    start,
+			Duration:     time.Since(start),
+			Error:        err.Error(),
+			TimedOut:     isTimeout(err),
+		}
+		s.logOutcome(req, &result, lines)
+		s.record(result)
+		s.notifyComplete(resolved, nil, result)
+		return &result
+	}
+
+	if s.tracing(req) {
+		ctx = withWireTrace(ctx, resolved.Name, executionID)
+	}
+
+	if s.logsProgress(req) {
+		s.logExecution("Executing request '%s' [%s] at %s", resolved.Name, executionID, start.Format(time.RFC3339))
+	}
+
+	result := ExecutionResult{
+		RunID:        s.runID,
+		ExecutionID:  executionID,
+		RequestName:  resolved.Name,
+		RequestID:    req.RequestID(),
+		Method:       resolved.Method,
+		URL:          resolved.URL,
+		Headers:      resolved.Headers,
+		Body:         resolved.Body,
+		ScheduledFor: resolved.ScheduledFor,
+		StartedAt:    start,
 	}
 
-	log.Printf("Executing request '%s' at %s", resolved.Name, start.Format(time.RFC3339))
+	s.notifyStart(resolved, executionID)
 
-	// Execute the HTTP request
-	status, duration, err := s.sendHTTPRequest(resolved)
+	// Execute the request, retrying it when its retry policy says so
+	resp, attempts, err := s.sendAttempts(ctx, req, resolved, executionID, evaluator.Rand("retry"))
+	result.Attempts = attempts
+
+	// Some request types report a response alongside an error, such as an SSE
+	// subscription that received too few events
+	if resp != nil {
+		result.Duration = resp.Duration
+		result.StatusCode = resp.StatusCode
+		result.Status = resp.Status
+		result.ResponseHeaders = resp.Headers
+		result.ResponseBody = resp.Body
+		result.Timings = resp.Timings
+	}
 
 	if err != nil {
-		log.Printf("Request '%s' failed: %v (duration: %v)", resolved.Name, err, duration)
+		if resp == nil {
+			result.Duration = time.Since(start)
+		}
+		result.Error = err.Error()
+		result.TimedOut = isTimeout(err)
+		if result.TimedOut {
+			s.pressure.observe(result.Duration)
+		}
+		logf("Request '%s' [%s] %s (duration: %v%s)", resolved.Name, executionID,
+			s.colorize(result.StatusClass(), "failed: "+err.Error()), result.Duration, attemptsNote(req, attempts))
 	} else {
-		log.Printf("Request '%s' completed: %s (duration: %v)", resolved.Name, status, duration)
+		s.pressure.observe(resp.Duration)
+
+		if len(req.Expect) > 0 {
+			result.Unmet, result.Warnings = req.Expect.Check(spec.Response{StatusCode: resp.StatusCode, Headers: resp.Headers, Body: resp.Body, Duration: resp.Duration})
+			result.ExpectedStatus = !resp.IsSuccess() && req.Expect.AcceptsStatus(resp.StatusCode)
+		}
+
+		// Executions checked against expectations say whether they passed,
+		// so an expected error status reads differently from an unexpected one
+		outcome := resp.Status
+		switch {
+		case len(req.Expect) == 0:
+		case len(result.Unmet) > 0:
+			outcome += ", expectations failed"
+		default:
+			outcome += ", expectations passed"
+		}
+		class := result.StatusClass()
+		switch {
+		case len(result.Unmet) > 0:
+			class = ClassError
+		case result.ExpectedStatus:
+			class = Class2xx
+		}
+		logf("Request '%s' [%s] completed: %s (duration: %v%s)", resolved.Name, executionID,
+			s.colorize(class, outcome), resp.Duration, attemptsNote(req, attempts))
+
+		if threshold := s.slowThreshold(req); threshold > 0 && resp.Duration > threshold {
+			result.Slow = true
+			logf("WARN: Request '%s' [%s] was %s (duration %v exceeds threshold %v)", resolved.Name, executionID,
+				s.paint(ansiYellow, "slow"), resp.Duration, threshold)
+		}
+
+		if len(req.Expect) > 0 {
+			for _, unmet := range result.Unmet {
+				logf("Request '%s' [%s] %s", resolved.Name, executionID,
+					s.colorize(ClassError, "did not meet expectation: "+unmet))
+			}
+			for _, warning := range result.Warnings {
+				logf("WARN: Request '%s' [%s] %s", resolved.Name, executionID,
+					s.paint(ansiYellow, "did not meet expectation: "+warning))
+			}
+		}
+	}
+
+	s.logOutcome(req, &result, lines)
+
+	if result.Success() {
+		s.keepResponse(&result)
+		if len(req.State) > 0 {
+			s.updateState(req, &result)
+		}
+		if len(req.HTTP.Extract) > 0 {
+			s.extractVariables(req, &result)
+		}
 	}
+
+	s.record(result)
+	s.notifyComplete(resolved, resp, result)
+	return &result
 }
 
-// sendHTTPRequest sends an HTTP request and returns status, duration, and error
-func (s *Scheduler) sendHTTPRequest(resolved *spec.ResolvedRequest) (string, time.Duration, error) {
-	resp, err := s.httpClient.SendRequest(resolved)
-	if err != nil {
-		return "", 0, err
+// logExecution logs a line about a single execution unless running quietly
+func (s *Scheduler) logExecution(format string, args ...interface{}) {
+	if !s.quiet {
+		log.Printf(format, args...)
+	}
+}
+
+// logOutcome writes the log lines describing how an execution ended, unless
+// its request keeps failing the same way, in which case they are collapsed
+// into a periodic count. Keep-warm requests write a line only when the state
+// of their endpoint changes.
+func (s *Scheduler) logOutcome(req *spec.ScheduledRequest, result *ExecutionResult, lines []string) {
+	// Keep-warm requests only log changes of their endpoint's state, which
+	// are tracked even when quiet
+	if req.KeepWarm {
+		if change := s.trackWarmth(req, result); change != "" && !s.quiet {
+			log.Print(change)
+		}
+		return
+	}
+	if s.quiet {
+		return
+	}
+	write, summary := s.failureLog.outcome(req.Name, result.FailureReason(), time.Now())
+	if summary != "" {
+		log.Print(s.paint(ansiYellow, summary))
+	}
+	if write {
+		for _, line := range lines {
+			log.Print(line)
+		}
 	}
+}
+
+// logsProgress reports whether the lines an execution of req writes as it
+// goes, before its outcome, are logged: not for keep-warm requests, nor for
+// requests whose repeated failures are being collapsed
+func (s *Scheduler) logsProgress(req *spec.ScheduledRequest) bool {
+	return !req.KeepWarm && !s.failureLog.collapsing(req.Name)
+}
+
+// now reads the scheduler clock, falling back to real time when none is set
+func (s *Scheduler) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
+// realDuration converts a duration measured on the scheduler clock into real
+// time, which differs when running on an accelerated virtual clock
+func (s *Scheduler) realDuration(d time.Duration) time.Duration {
+	if scaled, ok := s.clock.(*spec.ScaledClock); ok {
+		return scaled.RealDuration(d)
+	}
+	return d
+}
+
+// executionContext returns the context for one execution of a request,
+// cancelled with parent and carrying the request's timeout as a deadline
+// when it sets one
+func executionContext(parent context.Context, req *spec.ScheduledRequest) (context.Context, context.CancelFunc) {
+	if timeout, err := req.TimeoutDuration(); err == nil && timeout > 0 {
+		return context.WithTimeout(parent, timeout)
+	}
+	return context.WithCancel(parent)
+}
+
+// slowThreshold returns the slow threshold for a request, preferring its own setting
+func (s *Scheduler) slowThreshold(req *spec.ScheduledRequest) time.Duration {
+	if threshold, err := req.SlowThresholdDuration(); err == nil && threshold > 0 {
+		return threshold
+	}
+	return s.slow
+}
+
+// injectCorrelationHeaders adds the run and execution ID headers to a resolved
+// request. Headers already set by the request spec are left untouched; if the
+// spec sets the execution ID header itself, its value becomes the execution ID.
+func (s *Scheduler) injectCorrelationHeaders(resolved *spec.ResolvedRequest, executionID string) string {
+	headers := make(map[string]string, len(resolved.Headers)+2)
+	for key, value := range resolved.Headers {
+		headers[key] = value
+	}
+
+	if s.runIDHeader != "" {
+		if _, ok := headerValue(headers, s.runIDHeader); !ok {
+			headers[s.runIDHeader] = s.runID
+		}
+	}
+	if s.execHeader != "" {
+		if value, ok := headerValue(headers, s.execHeader); ok {
+			executionID = value
+		} else {
+			headers[s.execHeader] = executionID
+		}
+	}
+
+	resolved.Headers = headers
+	return executionID
+}
 
-	return resp.Status, resp.Duration, nil
+// evaluate resolves a request, failing it rather than the worker when a
+// template panics
+func evaluate(evaluator *spec.Evaluator, req *spec.ScheduledRequest) (resolved *spec.ResolvedRequest, err error) {
+	defer recoverPanic(&err, "template evaluation")
+	return evaluator.EvaluateRequest(req)
+}
+
+// isTimeout reports whether err was caused by a timeout
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// record keeps an execution result for Recent and passes it to every
+// configured recorder
+func (s *Scheduler) record(result ExecutionResult) {
+	s.keepRecent(&result)
+	for _, recorder := range s.recorders {
+		if err := recorder.Record(result); err != nil {
+			log.Printf("Error recording result for '%s': %v", result.RequestName, err)
+		}
+	}
 }
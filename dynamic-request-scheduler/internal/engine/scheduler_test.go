@@ -1,6 +1,16 @@
 package engine
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -16,7 +26,7 @@ func TestNewScheduler(t *testing.T) {
 			},
 			HTTP: spec.HttpRequestSpec{
 				Method: "GET",
-				URL:    "https://example.com",
+				URL:    spec.NewLiteralString("https://example.com"),
 			},
 		},
 	}
@@ -60,10 +70,10 @@ func TestScheduler_DryRun(t *testing.T) {
 			},
 			HTTP: spec.HttpRequestSpec{
 				Method: "GET",
-				URL:    "https://example.com",
-				Headers: map[string]string{
+				URL:    spec.NewLiteralString("https://example.com"),
+				Headers: spec.LiteralHeaders(map[string]string{
 					"X-Test": "{{ uuid }}",
-				},
+				}),
 			},
 		},
 	}
@@ -91,6 +101,127 @@ func TestScheduler_DryRun(t *testing.T) {
 	scheduler.Stop()
 }
 
+func TestScheduler_DryRunClock(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	at := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	scheduler := NewScheduler([]spec.ScheduledRequest{{
+		Name:     "preview",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("5m"), Repeat: true},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("https://example.com/?t={{ unix now }}")},
+	}}, SchedulerConfig{DryRun: true, Clock: &spec.FixedClock{Time: at}})
+
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"Evaluating as of 2025-03-01T09:00:00Z",
+		"URL: https://example.com/?t=1740819600",
+		"Next run: 2025-03-01T09:05:00Z, then every 5m",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected dry run output to contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestScheduler_DryRunSchedules(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	at := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	epoch := at.Add(-time.Hour).Unix()
+	scheduler := NewScheduler([]spec.ScheduledRequest{
+		{
+			Name:     "report",
+			Schedule: spec.ScheduleSpec{Cron: stringPtr("*/15 * * * *"), Jitter: stringPtr("±5m")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("https://example.com/report")},
+		},
+		{
+			Name:     "overdue",
+			Schedule: spec.ScheduleSpec{Epoch: &epoch},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("https://example.com/overdue")},
+		},
+		{
+			Name:     "broken",
+			Schedule: spec.ScheduleSpec{Template: stringPtr("{{ nope }}")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("https://example.com/broken")},
+		},
+	}, SchedulerConfig{DryRun: true, Clock: &spec.FixedClock{Time: at}})
+
+	err := scheduler.Start()
+	var dryRunErr *DryRunError
+	if !errors.As(err, &dryRunErr) || len(dryRunErr.Invalid) != 1 || dryRunErr.Invalid[0] != "broken" {
+		t.Fatalf("Expected the broken template to fail the dry run, got %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"Next run: 2025-03-01T09:1",
+		"Jitter: up to 5m0s, included in the next run",
+		"Next run: 2025-03-01T08:00:00Z",
+		"Error evaluating schedule of request 'broken'",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected dry run output to contain %q:\n%s", want, out)
+		}
+	}
+}
+
+// stepClock is a clock tests move forward by hand
+type stepClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *stepClock) set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+func TestScheduler_CronDispatch(t *testing.T) {
+	clock := &stepClock{now: time.Date(2025, 3, 1, 9, 0, 30, 0, time.UTC)}
+	scheduler := NewScheduler([]spec.ScheduledRequest{{
+		Name:     "tick",
+		Schedule: spec.ScheduleSpec{Cron: stringPtr("* * * * *")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("https://example.com/")},
+	}}, SchedulerConfig{Clock: clock})
+	req := &scheduler.requests[0]
+
+	if scheduler.shouldRunRequest(req) {
+		t.Error("Expected the cron request to wait for the next minute")
+	}
+	if due, ok, err := scheduler.dueTime(req); err != nil || !ok || !due.Equal(time.Date(2025, 3, 1, 9, 1, 0, 0, time.UTC)) {
+		t.Errorf("Expected it due at 09:01, got %v (%v, %v)", due, ok, err)
+	}
+
+	clock.set(time.Date(2025, 3, 1, 9, 1, 0, 0, time.UTC))
+	if !scheduler.shouldRunRequest(req) || !scheduler.claimDispatch(req) {
+		t.Fatal("Expected the cron request to be dispatched at 09:01")
+	}
+	scheduler.unqueue(req.Name)
+	if scheduler.shouldRunRequest(req) {
+		t.Error("Expected the cron request to fire once per minute")
+	}
+
+	clock.set(time.Date(2025, 3, 1, 9, 2, 5, 0, time.UTC))
+	if !scheduler.shouldRunRequest(req) {
+		t.Error("Expected the cron request to be due again at 09:02")
+	}
+}
+
 func TestScheduler_Once(t *testing.T) {
 	requests := []spec.ScheduledRequest{
 		{
@@ -100,7 +231,7 @@ func TestScheduler_Once(t *testing.T) {
 			},
 			HTTP: spec.HttpRequestSpec{
 				Method: "GET",
-				URL:    "https://httpbin.org/get",
+				URL:    spec.NewLiteralString("https://httpbin.org/get"),
 			},
 		},
 	}
@@ -131,7 +262,7 @@ func TestScheduler_Stop(t *testing.T) {
 			},
 			HTTP: spec.HttpRequestSpec{
 				Method: "GET",
-				URL:    "https://example.com",
+				URL:    spec.NewLiteralString("https://example.com"),
 			},
 		},
 	}
@@ -159,38 +290,23 @@ func TestScheduler_Stop(t *testing.T) {
 }
 
 func TestScheduler_ConcurrencyControl(t *testing.T) {
-	// Create multiple requests that will run immediately
-	requests := []spec.ScheduledRequest{
-		{
-			Name: "request-1",
-			Schedule: spec.ScheduleSpec{
-				Relative: stringPtr("1s"),
-			},
-			HTTP: spec.HttpRequestSpec{
-				Method: "GET",
-				URL:    "https://httpbin.org/delay/1",
-			},
-		},
-		{
-			Name: "request-2",
-			Schedule: spec.ScheduleSpec{
-				Relative: stringPtr("1s"),
-			},
-			HTTP: spec.HttpRequestSpec{
-				Method: "GET",
-				URL:    "https://httpbin.org/delay/1",
-			},
-		},
-		{
-			Name: "request-3",
-			Schedule: spec.ScheduleSpec{
-				Relative: stringPtr("1s"),
-			},
-			HTTP: spec.HttpRequestSpec{
-				Method: "GET",
-				URL:    "https://httpbin.org/delay/1",
-			},
-		},
+	var current, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := current.Add(1)
+		defer current.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	var requests []spec.ScheduledRequest
+	for _, name := range []string{"request-1", "request-2", "request-3"} {
+		requests = append(requests, spec.ScheduledRequest{
+			Name:     name,
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		})
 	}
 
 	config := SchedulerConfig{
@@ -202,38 +318,139 @@ func TestScheduler_ConcurrencyControl(t *testing.T) {
 	}
 
 	scheduler := NewScheduler(requests, config)
-	
+
 	start := time.Now()
 	err := scheduler.Start()
 	duration := time.Since(start)
-	
+
 	if err != nil {
 		t.Fatalf("Start failed: %v", err)
 	}
 
-	// With concurrency=2 and 3 requests that each take 1 second,
-	// the total time should be at least 2 seconds (2 batches)
-	if duration < 2*time.Second {
-		t.Errorf("Expected duration >= 2s due to concurrency limit, got %v", duration)
+	// With concurrency=2 and 3 requests that each take 200ms,
+	// the total time should be at least 400ms (2 batches)
+	if duration < 400*time.Millisecond {
+		t.Errorf("Expected duration >= 400ms due to concurrency limit, got %v", duration)
+	}
+	if got := peak.Load(); got != 2 {
+		t.Errorf("Expected at most 2 requests in flight, got %d", got)
+	}
+}
+
+func TestScheduler_SaturatedPoolDoesNotBlockScheduling(t *testing.T) {
+	release := make(chan struct{})
+	var order []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		order = append(order, r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+	}))
+	defer server.Close()
+
+	past := time.Now().Add(-time.Minute).Unix()
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "slow",
+			Schedule: spec.ScheduleSpec{Epoch: int64Ptr(past)},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + "/slow")},
+		},
+		{
+			Name:     "first",
+			Schedule: spec.ScheduleSpec{Epoch: int64Ptr(past)},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + "/first")},
+		},
+		{
+			Name:     "second",
+			Schedule: spec.ScheduleSpec{Epoch: int64Ptr(past)},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + "/second")},
+		},
+	}
+
+	scheduler := NewScheduler(requests, SchedulerConfig{Workers: 1, Concurrency: 1})
+	done := make(chan error, 1)
+	go func() { done <- scheduler.Start() }()
+	defer func() {
+		scheduler.Stop()
+		<-done
+	}()
+	defer close(release)
+
+	// With the only slot held by the slow request, the loop must still
+	// evaluate and queue every other due request
+	deadline := time.Now().Add(2 * time.Second)
+	for !(scheduler.hasFired("first") && scheduler.hasFired("second")) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected due requests to be dispatched while the pool is saturated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	release <- struct{}{}
+	deadline = time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		got := strings.Join(order, ",")
+		mu.Unlock()
+		if got == "/slow,/first,/second" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected queued requests to run in the order they became due, got %s", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestScheduler_WorkerGroups(t *testing.T) {
+	requests := []spec.ScheduledRequest{
+		{Name: "a"},
+		{Name: "critical", Shard: "critical"},
+		{Name: "b"},
+		{Name: "c"},
+		{Name: "also-critical", Shard: "critical"},
+	}
+	scheduler := NewScheduler(requests, SchedulerConfig{Workers: 3})
+
+	var got []string
+	for _, group := range scheduler.workerGroups() {
+		names := make([]string, len(group.requests))
+		for i, req := range group.requests {
+			names[i] = req.Name
+		}
+		got = append(got, group.shard+"="+strings.Join(names, ","))
+	}
+	want := "worker-0=a|worker-1=b|worker-2=c|critical=critical,also-critical"
+	if strings.Join(got, "|") != want {
+		t.Errorf("Expected groups %s, got %s", want, strings.Join(got, "|"))
 	}
 }
 
 func TestScheduler_ShouldRunRequest(t *testing.T) {
-	scheduler := &Scheduler{}
-	ctx := &spec.EvaluationContext{}
-	templateEngine := spec.NewTemplateEngine(ctx)
-	evaluator := spec.NewEvaluator(templateEngine)
+	scheduler := &Scheduler{clockStart: time.Now().Add(-2 * time.Second)}
 	
-	// Test relative schedule (should run immediately if in the past)
+	// Test relative schedule (should run once its interval has passed since the start)
 	relativeRequest := spec.ScheduledRequest{
 		Schedule: spec.ScheduleSpec{
 			Relative: stringPtr("1s"),
 		},
 	}
 	
-	// This should run immediately since it's a relative schedule
-	if !scheduler.shouldRunRequest(&relativeRequest, evaluator) {
-		t.Error("Relative request should run immediately")
+	if !scheduler.shouldRunRequest(&relativeRequest) {
+		t.Error("Relative request should run once its interval has passed")
+	}
+
+	laterRequest := spec.ScheduledRequest{
+		Schedule: spec.ScheduleSpec{
+			Relative: stringPtr("1m"),
+		},
+	}
+
+	if scheduler.shouldRunRequest(&laterRequest) {
+		t.Error("Relative request should not run before its interval has passed")
 	}
 
 	// Test epoch schedule in the past
@@ -243,7 +460,7 @@ func TestScheduler_ShouldRunRequest(t *testing.T) {
 		},
 	}
 	
-	if !scheduler.shouldRunRequest(&pastRequest, evaluator) {
+	if !scheduler.shouldRunRequest(&pastRequest) {
 		t.Error("Past epoch request should run")
 	}
 
@@ -254,7 +471,7 @@ func TestScheduler_ShouldRunRequest(t *testing.T) {
 		},
 	}
 	
-	if scheduler.shouldRunRequest(&futureRequest, evaluator) {
+	if scheduler.shouldRunRequest(&futureRequest) {
 		t.Error("Future epoch request should not run yet")
 	}
 }
@@ -268,10 +485,10 @@ func TestScheduler_ExecuteRequest(t *testing.T) {
 			},
 			HTTP: spec.HttpRequestSpec{
 				Method: "GET",
-				URL:    "https://httpbin.org/get",
-				Headers: map[string]string{
+				URL:    spec.NewLiteralString("https://httpbin.org/get"),
+				Headers: spec.LiteralHeaders(map[string]string{
 					"X-Test": "{{ uuid }}",
-				},
+				}),
 			},
 		},
 	}
@@ -296,6 +513,593 @@ func TestScheduler_ExecuteRequest(t *testing.T) {
 	scheduler.executeRequest(&requests[0], evaluator)
 }
 
+// recordingRecorder collects results passed to it
+type recordingRecorder struct {
+	mu      sync.Mutex
+	results []ExecutionResult
+}
+
+func (r *recordingRecorder) Record(result ExecutionResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, result)
+	return nil
+}
+
+func TestScheduler_Recorders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name: "ok-request",
+			Schedule: spec.ScheduleSpec{
+				Relative: stringPtr("1s"),
+			},
+			HTTP: spec.HttpRequestSpec{
+				Method: "GET",
+				URL:    spec.NewLiteralString(server.URL),
+			},
+		},
+		{
+			Name: "bad-request",
+			Schedule: spec.ScheduleSpec{
+				Relative: stringPtr("1s"),
+			},
+			HTTP: spec.HttpRequestSpec{
+				Method: "GET",
+				URL:    spec.NewLiteralString("{{ invalid }}"),
+			},
+		},
+	}
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:      true,
+		Recorders: []ResultRecorder{recorder},
+	})
+
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if len(recorder.results) != 2 {
+		t.Fatalf("Expected 2 recorded results, got %d", len(recorder.results))
+	}
+
+	byName := make(map[string]ExecutionResult)
+	for _, result := range recorder.results {
+		byName[result.RequestName] = result
+	}
+
+	if ok := byName["ok-request"]; ok.StatusCode != http.StatusAccepted || !ok.Success() {
+		t.Errorf("Expected successful 202 result, got %+v", ok)
+	}
+	if bad := byName["bad-request"]; bad.Error == "" || bad.Success() {
+		t.Errorf("Expected evaluation failure to be recorded, got %+v", bad)
+	}
+}
+
+func TestScheduler_CorrelationHeaders(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]http.Header)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.Path] = r.Header.Clone()
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "generated",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + "/generated")},
+		},
+		{
+			Name:     "explicit",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP: spec.HttpRequestSpec{
+				Method:  "GET",
+				URL:     spec.NewLiteralString(server.URL + "/explicit"),
+				Headers: spec.LiteralHeaders(map[string]string{"x-request-id": "custom-id"}),
+			},
+		},
+	}
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:              true,
+		Recorders:         []ResultRecorder{recorder},
+		RunID:             "run-123",
+		RunIDHeader:       DefaultRunIDHeader,
+		ExecutionIDHeader: DefaultExecutionIDHeader,
+	})
+	if scheduler.RunID() != "run-123" {
+		t.Errorf("Expected configured run ID, got %q", scheduler.RunID())
+	}
+
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	for path, headers := range seen {
+		if got := headers.Get("X-Run-ID"); got != "run-123" {
+			t.Errorf("%s: expected run ID header, got %q", path, got)
+		}
+	}
+	generated := seen["/generated"].Get("X-Request-ID")
+	if len(generated) != 36 {
+		t.Errorf("Expected generated UUID execution ID, got %q", generated)
+	}
+	if got := seen["/explicit"].Get("X-Request-ID"); got != "custom-id" {
+		t.Errorf("Expected spec header to be preserved, got %q", got)
+	}
+
+	byName := make(map[string]ExecutionResult)
+	for _, result := range recorder.results {
+		byName[result.RequestName] = result
+		if result.RunID != "run-123" {
+			t.Errorf("Expected run ID on result %s, got %q", result.RequestName, result.RunID)
+		}
+	}
+	if got := byName["generated"].ExecutionID; got != generated {
+		t.Errorf("Expected result execution ID %q to match header, got %q", generated, got)
+	}
+	if got := byName["explicit"].ExecutionID; got != "custom-id" {
+		t.Errorf("Expected spec-provided execution ID, got %q", got)
+	}
+	if spec := requests[0].HTTP.Headers; spec != nil {
+		t.Errorf("Expected request spec headers to be left untouched, got %v", spec)
+	}
+}
+
+func TestScheduler_CorrelationHeadersDisabled(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler([]spec.ScheduledRequest{{
+		Name:     "plain",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+	}}, SchedulerConfig{Once: true, Recorders: []ResultRecorder{recorder}})
+
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if headers.Get("X-Run-ID") != "" || headers.Get("X-Request-ID") != "" {
+		t.Errorf("Expected no correlation headers, got %v", headers)
+	}
+	if scheduler.RunID() == "" || recorder.results[0].ExecutionID == "" {
+		t.Error("Expected IDs to be generated even without headers")
+	}
+}
+
+func TestScheduler_SlowThreshold(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "global-threshold",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+		{
+			Name:          "own-threshold",
+			SlowThreshold: "5s",
+			Schedule:      spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:          spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+	}
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:          true,
+		SlowThreshold: 10 * time.Millisecond,
+		Recorders:     []ResultRecorder{recorder},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	for _, result := range recorder.results {
+		want := result.RequestName == "global-threshold"
+		if result.Slow != want {
+			t.Errorf("%s: expected slow=%v, got %v (duration %v)", result.RequestName, want, result.Slow, result.Duration)
+		}
+	}
+}
+
+func TestScheduler_RequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "tight",
+			Timeout:  "50ms",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+		{
+			Name:     "loose",
+			Timeout:  "5s",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+	}
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:      true,
+		Timeout:   100 * time.Millisecond,
+		Recorders: []ResultRecorder{recorder},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	for _, result := range recorder.results {
+		want := result.RequestName == "tight"
+		if result.TimedOut != want {
+			t.Errorf("%s: expected timed out=%v, got %v (%s)", result.RequestName, want, result.TimedOut, result.Error)
+		}
+	}
+}
+
+func TestScheduler_StopCancelsInFlight(t *testing.T) {
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	past := time.Now().Add(-time.Minute).Unix()
+	requests := []spec.ScheduledRequest{{
+		Name:     "hanging",
+		Schedule: spec.ScheduleSpec{Epoch: int64Ptr(past)},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+	}}
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{Timeout: time.Minute, Recorders: []ResultRecorder{recorder}})
+	done := make(chan error, 1)
+	go func() { done <- scheduler.Start() }()
+
+	<-started
+	scheduler.Stop()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expected Stop to cancel the in-flight request")
+	}
+
+	if len(recorder.results) != 1 || !strings.Contains(recorder.results[0].Error, "context canceled") {
+		t.Errorf("Expected the cancelled execution recorded, got %+v", recorder.results)
+	}
+}
+
+func TestScheduler_OnceCount(t *testing.T) {
+	var hits, inFlight, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "ping",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+	}
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:        true,
+		Count:       20,
+		Concurrency: 3,
+		Recorders:   []ResultRecorder{recorder},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if hits != 20 || len(recorder.results) != 20 {
+		t.Errorf("Expected 20 executions, got %d hits and %d results", hits, len(recorder.results))
+	}
+	if peak > 3 {
+		t.Errorf("Expected at most 3 concurrent executions, got %d", peak)
+	}
+}
+
+func TestScheduler_Duration(t *testing.T) {
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "later",
+			Schedule: spec.ScheduleSpec{Cron: stringPtr("0 0 1 1 *")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString("http://127.0.0.1:1/")},
+		},
+	}
+
+	scheduler := NewScheduler(requests, SchedulerConfig{Duration: 100 * time.Millisecond})
+
+	done := make(chan error, 1)
+	go func() { done <- scheduler.Start() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		scheduler.Stop()
+		t.Fatal("Expected the run to stop after its duration")
+	}
+}
+
+func TestScheduler_ScaledClock(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer server.Close()
+
+	// An hour of virtual time passes in half a second, and the 3h run lasts 1.5s
+	origin := time.Now()
+	due := origin.Add(time.Hour).Unix()
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "in-an-hour",
+			Schedule: spec.ScheduleSpec{Epoch: &due},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+	}
+
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Duration: 3 * time.Hour,
+		Clock:    spec.NewScaledClock(origin, 7200),
+	})
+
+	start := time.Now()
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected virtual duration to elapse in about 1.5s, took %v", elapsed)
+	}
+	if atomic.LoadInt32(&hits) == 0 {
+		t.Error("Expected epoch schedule to fire on the virtual clock")
+	}
+}
+
+func TestScheduler_RelativeInterval(t *testing.T) {
+	var hourly, delayed int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hourly" {
+			atomic.AddInt32(&hourly, 1)
+		} else {
+			atomic.AddInt32(&delayed, 1)
+		}
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "hourly",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1h"), Repeat: true},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + "/hourly")},
+		},
+		{
+			Name:     "delayed",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1h")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + "/delayed")},
+		},
+	}
+
+	// Three and a half virtual hours pass in 1.75s: the hourly request fires
+	// at each hour after the start, the delayed one only at the first
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Duration: 3*time.Hour + 30*time.Minute,
+		Clock:    spec.NewScaledClock(time.Now(), 7200),
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if hits := atomic.LoadInt32(&hourly); hits != 3 {
+		t.Errorf("Expected the hourly request to fire 3 times, got %d", hits)
+	}
+	if hits := atomic.LoadInt32(&delayed); hits != 1 {
+		t.Errorf("Expected the delayed request to fire once, got %d", hits)
+	}
+}
+
+func TestScheduler_EveryCount(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "bounded",
+			Schedule: spec.ScheduleSpec{Every: stringPtr("1h"), Count: 3},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+	}
+
+	// The request fires at the start and after one and two virtual hours,
+	// then the run exits on its own well before its duration
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Duration:     24 * time.Hour,
+		ExitWhenDone: true,
+		Clock:        spec.NewScaledClock(time.Now(), 7200),
+	})
+	start := time.Now()
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if hits := atomic.LoadInt32(&hits); hits != 3 {
+		t.Errorf("Expected the request to fire 3 times, got %d", hits)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the run to exit after the last firing, took %v", elapsed)
+	}
+	if status := scheduler.Statuses()[0]; !status.NextRun.IsZero() {
+		t.Errorf("Expected no next run after the last firing, got %v", status.NextRun)
+	}
+}
+
+func TestScheduler_JitterDelaysIntervals(t *testing.T) {
+	start := time.Now()
+	scheduler := &Scheduler{clockStart: start}
+
+	tests := []struct {
+		name string
+		req  spec.ScheduledRequest
+		base time.Time
+	}{
+		{
+			name: "relative",
+			req:  spec.ScheduledRequest{Name: "relative", Schedule: spec.ScheduleSpec{Relative: stringPtr("1h"), Repeat: true, Jitter: stringPtr("±10m")}},
+			base: start.Add(time.Hour),
+		},
+		{
+			name: "every",
+			req:  spec.ScheduledRequest{Name: "every", Schedule: spec.ScheduleSpec{Every: stringPtr("1h"), Jitter: stringPtr("±10m")}},
+			base: start,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			due, ok, err := scheduler.dueTime(&tt.req)
+			if err != nil || !ok {
+				t.Fatalf("dueTime failed: %v, %v", ok, err)
+			}
+			if !due.After(tt.base) || !due.Before(tt.base.Add(10*time.Minute)) {
+				t.Errorf("Expected the jittered run within 10m after %v, got %v", tt.base, due)
+			}
+
+			// The jitter is drawn once per firing, so the run doesn't move
+			// between checks
+			for i := 0; i < 5; i++ {
+				if again, _, _ := scheduler.dueTime(&tt.req); !again.Equal(due) {
+					t.Fatalf("Expected the due time to stay at %v, got %v", due, again)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduler_RPS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "ping",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+	}
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:      true,
+		Count:     5,
+		RPS:       20,
+		Recorders: []ResultRecorder{recorder},
+	})
+
+	start := time.Now()
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	// Five executions at 20 per second start at 0, 50, 100, 150 and 200ms
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("Expected rate limit to spread executions over 200ms, took %v", elapsed)
+	}
+	if len(recorder.results) != 5 {
+		t.Errorf("Expected 5 executions, got %d", len(recorder.results))
+	}
+}
+
+func TestScheduler_ExitWhenDone(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer server.Close()
+
+	past := time.Now().Add(-time.Minute).Unix()
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "past-epoch",
+			Schedule: spec.ScheduleSpec{Epoch: &past},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+		{
+			Name:     "template",
+			Schedule: spec.ScheduleSpec{Template: stringPtr("{{ unix now }}")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+	}
+
+	// Several workers must not dispatch the same one-shot request twice
+	scheduler := NewScheduler(requests, SchedulerConfig{Workers: 3, ExitWhenDone: true})
+
+	done := make(chan error, 1)
+	go func() { done <- scheduler.Start() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		scheduler.Stop()
+		t.Fatal("Expected the run to exit once both one-shot requests fired")
+	}
+
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Errorf("Expected each one-shot request to fire exactly once, got %d executions", got)
+	}
+	for _, status := range scheduler.Statuses() {
+		if !status.NextRun.IsZero() {
+			t.Errorf("%s: expected no next run after firing, got %v", status.Name, status.NextRun)
+		}
+	}
+}
+
 // Helper functions
 func stringPtr(s string) *string {
 	return &s
@@ -304,3 +1108,99 @@ func stringPtr(s string) *string {
 func int64Ptr(i int64) *int64 {
 	return &i
 }
+
+func TestScheduler_SeedReproducesExecutions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{{
+		Name:     "order",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP: spec.HttpRequestSpec{
+			Method: "POST",
+			URL:    spec.NewLiteralString(server.URL),
+			Body:   spec.NewLiteralAny(map[string]interface{}{"seq": "{{ seq }}", "id": "{{ uuid }}", "n": "{{ randInt 1 1000000 }}"}),
+		},
+	}}
+
+	// Bodies sent by a run, keyed by their sequence number
+	bodies := func(seed int64) map[string]string {
+		recorder := &recordingRecorder{}
+		scheduler := NewScheduler(requests, SchedulerConfig{
+			Once: true, Count: 20, Concurrency: 5, Quiet: true, Seed: seed,
+			Recorders: []ResultRecorder{recorder},
+		})
+		if err := scheduler.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		got := make(map[string]string)
+		for _, result := range recorder.results {
+			body := result.Body.(map[string]interface{})
+			got[body["seq"].(string)] = fmt.Sprint(body["id"], body["n"])
+		}
+		return got
+	}
+
+	first, replay := bodies(7), bodies(7)
+	if len(first) != 20 {
+		t.Fatalf("Expected 20 distinct sequence numbers, got %d", len(first))
+	}
+	for seq, values := range first {
+		if replay[seq] != values {
+			t.Errorf("Expected execution %s to send %s again, got %s", seq, values, replay[seq])
+		}
+	}
+	if other := bodies(8); other["1"] == first["1"] {
+		t.Errorf("Expected another seed to send different values, both sent %s", first["1"])
+	}
+
+	if seed := NewScheduler(requests, SchedulerConfig{}).Seed(); seed == 0 {
+		t.Error("Expected a seed to be generated")
+	}
+}
+
+func TestScheduler_SeedPerRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	request := func(name string) spec.ScheduledRequest {
+		return spec.ScheduledRequest{
+			Name:     name,
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP: spec.HttpRequestSpec{
+				Method: "POST",
+				URL:    spec.NewLiteralString(server.URL),
+				Body:   spec.NewLiteralAny(map[string]interface{}{"id": "{{ uuid }}"}),
+			},
+		}
+	}
+
+	// IDs sent by each request of a run, in the order it fired
+	ids := func(requests ...spec.ScheduledRequest) map[string][]string {
+		recorder := &recordingRecorder{}
+		scheduler := NewScheduler(requests, SchedulerConfig{
+			Once: true, Count: 3, Concurrency: 1, Quiet: true, Seed: 7,
+			Recorders: []ResultRecorder{recorder},
+		})
+		if err := scheduler.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		got := make(map[string][]string)
+		for _, result := range recorder.results {
+			got[result.RequestName] = append(got[result.RequestName], result.Body.(map[string]interface{})["id"].(string))
+		}
+		return got
+	}
+
+	alone := ids(request("orders"))
+	shared := ids(request("payments"), request("orders"))
+	if fmt.Sprint(alone["orders"]) != fmt.Sprint(shared["orders"]) {
+		t.Errorf("Expected orders to send %v whatever else runs, got %v", alone["orders"], shared["orders"])
+	}
+	if shared["orders"][0] == shared["payments"][0] {
+		t.Errorf("Expected orders and payments to send different IDs, both sent %s", shared["orders"][0])
+	}
+	if alone["orders"][0] == alone["orders"][1] {
+		t.Errorf("Expected each firing to send a different ID, got %v", alone["orders"])
+	}
+}
@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// runSetup executes the setup requests once, in order, before dispatch
+// begins, storing their captures in the run's variables. It fails at the
+// first request that fails or whose capture cannot be read, so nothing is
+// scheduled against missing fixtures. Stop interrupts it without an error.
+func (s *Scheduler) runSetup() error {
+	if len(s.setup) == 0 {
+		return nil
+	}
+
+	log.Printf("Running %d setup request(s)...", len(s.setup))
+	evaluator := s.newEvaluator()
+
+	// Setup requests have no schedule of their own; each is due as it runs
+	immediately := "0s"
+	for i := range s.setup {
+		req := s.setup[i]
+		req.Schedule = spec.ScheduleSpec{Relative: &immediately}
+
+		var result *ExecutionResult
+		func() {
+			defer isolate(req.Name)
+			result = s.execute(s.ctx, &req, evaluator.ForRequest(int64(i+1), req.Name, 1))
+		}()
+
+		if s.ctx.Err() != nil {
+			log.Println("Setup interrupted")
+			return nil
+		}
+		if result == nil {
+			return fmt.Errorf("setup request '%s' did not run", req.Name)
+		}
+		if !result.Success() {
+			return fmt.Errorf("setup request '%s' failed: %s", req.Name, result.FailureReason())
+		}
+
+		for name, source := range req.Capture {
+			value, err := spec.CaptureValue(source, result.StatusCode, result.ResponseHeaders, result.ResponseBody)
+			if err != nil {
+				return fmt.Errorf("setup request '%s': capture %s: %w", req.Name, name, err)
+			}
+			s.variables[name] = value
+		}
+	}
+
+	log.Println("Setup completed")
+	return nil
+}
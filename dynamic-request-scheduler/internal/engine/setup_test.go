@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// setupServer logs in on /login and records the Authorization header of
+// every other request
+type setupServer struct {
+	mu     sync.Mutex
+	status int
+	auth   []string
+}
+
+func (s *setupServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path == "/login" {
+		w.WriteHeader(s.status)
+		w.Write([]byte(`{"access_token": "abc", "user": {"id": 42}}`))
+		return
+	}
+	s.auth = append(s.auth, r.URL.Path+" "+r.Header.Get("Authorization"))
+}
+
+func (s *setupServer) seen() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.auth...)
+}
+
+func setupScheduler(url string, capture map[string]string) *Scheduler {
+	requests := []spec.ScheduledRequest{{
+		Name:     "profile",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP: spec.HttpRequestSpec{
+			Method:  "GET",
+			URL:     spec.NewLiteralString(url + `/users/{{ var "user" }}`),
+			Headers: spec.LiteralHeaders(map[string]string{"Authorization": `Bearer {{ var "token" }}`}),
+		},
+	}}
+	return NewScheduler(requests, SchedulerConfig{
+		Once: true,
+		Setup: []spec.ScheduledRequest{{
+			Name:    "login",
+			HTTP:    spec.HttpRequestSpec{Method: "POST", URL: spec.NewLiteralString(url + "/login")},
+			Capture: capture,
+		}},
+		Teardown: teardownRequests(url, "logout"),
+	})
+}
+
+func TestScheduler_SetupCapturesVariables(t *testing.T) {
+	server := &setupServer{status: http.StatusOK}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	scheduler := setupScheduler(ts.URL, map[string]string{"token": "$.access_token", "user": "$.user.id"})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	seen := server.seen()
+	if len(seen) != 2 || seen[0] != "/users/42 Bearer abc" || seen[1] != "/logout " {
+		t.Errorf("Expected the captured token and user in the scheduled request, got %v", seen)
+	}
+}
+
+func TestScheduler_SetupFailure(t *testing.T) {
+	server := &setupServer{status: http.StatusUnauthorized}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	err := setupScheduler(ts.URL, map[string]string{"token": "$.access_token"}).Start()
+	if err == nil || !strings.Contains(err.Error(), "setup request 'login' failed: HTTP 401") {
+		t.Errorf("Expected the failed login to fail the run, got %v", err)
+	}
+	if seen := server.seen(); len(seen) != 1 || seen[0] != "/logout " {
+		t.Errorf("Expected only teardown after a failed setup, got %v", seen)
+	}
+}
+
+func TestScheduler_SetupMissingCapture(t *testing.T) {
+	server := &setupServer{status: http.StatusOK}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	err := setupScheduler(ts.URL, map[string]string{"token": "$.token"}).Start()
+	if err == nil || !strings.Contains(err.Error(), "capture token") {
+		t.Errorf("Expected a missing capture to fail the run, got %v", err)
+	}
+}
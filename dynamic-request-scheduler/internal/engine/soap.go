@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// SOAPFault is a fault returned in a SOAP response envelope
+type SOAPFault struct {
+	Code   string
+	Reason string
+}
+
+func (f *SOAPFault) Error() string {
+	return fmt.Sprintf("SOAP fault %s: %s", f.Code, f.Reason)
+}
+
+// soapExecutor POSTs each resolved SOAP envelope as-is. A fault in the
+// response fails the execution, with the response kept alongside the error.
+type soapExecutor struct {
+	client *HTTPClient
+}
+
+func (e soapExecutor) Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	start := time.Now()
+
+	envelope, _ := resolved.Body.(string)
+	req, err := http.NewRequestWithContext(ctx, resolved.Method, resolved.URL, strings.NewReader(envelope))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SOAP request: %w", err)
+	}
+	for key, value := range resolved.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.do(req, start)
+	if err != nil {
+		return nil, err
+	}
+	if fault := parseSOAPFault(resp.Body); fault != nil {
+		return resp, fault
+	}
+	return resp, nil
+}
+
+// parseSOAPFault returns the fault in a SOAP 1.1 or 1.2 response envelope,
+// or nil when the body is not an envelope containing a fault
+func parseSOAPFault(body []byte) *SOAPFault {
+	var envelope struct {
+		XMLName xml.Name `xml:"Envelope"`
+		Body    struct {
+			Fault *struct {
+				// SOAP 1.1
+				FaultCode   string `xml:"faultcode"`
+				FaultString string `xml:"faultstring"`
+
+				// SOAP 1.2
+				Code   string `xml:"Code>Value"`
+				Reason string `xml:"Reason>Text"`
+			} `xml:"Fault"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal(body, &envelope); err != nil || envelope.Body.Fault == nil {
+		return nil
+	}
+
+	fault := envelope.Body.Fault
+	if fault.Code != "" || fault.Reason != "" {
+		return &SOAPFault{Code: strings.TrimSpace(fault.Code), Reason: strings.TrimSpace(fault.Reason)}
+	}
+	return &SOAPFault{Code: strings.TrimSpace(fault.FaultCode), Reason: strings.TrimSpace(fault.FaultString)}
+}
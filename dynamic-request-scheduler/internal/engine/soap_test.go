@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestParseSOAPFault(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want *SOAPFault
+	}{
+		{
+			name: "SOAP 1.1 fault",
+			body: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>
+				<faultcode>soap:Client</faultcode><faultstring>Invalid account</faultstring></soap:Fault></soap:Body></soap:Envelope>`,
+			want: &SOAPFault{Code: "soap:Client", Reason: "Invalid account"},
+		},
+		{
+			name: "SOAP 1.2 fault",
+			body: `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body><env:Fault>
+				<env:Code><env:Value>env:Receiver</env:Value></env:Code>
+				<env:Reason><env:Text xml:lang="en">Database unavailable</env:Text></env:Reason>
+				</env:Fault></env:Body></env:Envelope>`,
+			want: &SOAPFault{Code: "env:Receiver", Reason: "Database unavailable"},
+		},
+		{
+			name: "successful response",
+			body: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><GetBalanceResponse><Balance>10</Balance></GetBalanceResponse></soap:Body></soap:Envelope>`,
+		},
+		{name: "not XML", body: `{"error": "nope"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseSOAPFault([]byte(tt.body))
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("parseSOAPFault() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduler_SendSOAP(t *testing.T) {
+	var action, contentType, envelope string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		action, contentType, envelope = r.Header.Get("SOAPAction"), r.Header.Get("Content-Type"), string(body)
+
+		if strings.Contains(envelope, "missing") {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `<Envelope><Body><Fault><faultcode>Client</faultcode><faultstring>No such account</faultstring></Fault></Body></Envelope>`)
+			return
+		}
+		fmt.Fprint(w, `<Envelope><Body><GetBalanceResponse/></Body></Envelope>`)
+	}))
+	defer server.Close()
+
+	scheduler := NewScheduler(nil, SchedulerConfig{})
+	resolved := &spec.ResolvedRequest{
+		Method:  "POST",
+		URL:     server.URL,
+		Headers: map[string]string{"Content-Type": "text/xml; charset=utf-8", "SOAPAction": `"GetBalance"`},
+		Body:    `<Envelope><Body><GetBalance>42</GetBalance></Body></Envelope>`,
+		SOAP:    &spec.SOAPOptions{Version: spec.SOAP11},
+	}
+
+	resp, err := scheduler.send(context.Background(), resolved)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected successful response, got %+v %v", resp, err)
+	}
+	if envelope != resolved.Body || action != `"GetBalance"` || contentType != "text/xml; charset=utf-8" {
+		t.Errorf("Expected envelope sent as-is, got %q (SOAPAction %s, Content-Type %s)", envelope, action, contentType)
+	}
+
+	resolved.Body = `<Envelope><Body><GetBalance>missing</GetBalance></Body></Envelope>`
+	resp, err = scheduler.send(context.Background(), resolved)
+	if err == nil || err.Error() != "SOAP fault Client: No such account" {
+		t.Errorf("Expected SOAP fault error, got %v", err)
+	}
+	if resp == nil || resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected the fault response alongside the error, got %+v", resp)
+	}
+}
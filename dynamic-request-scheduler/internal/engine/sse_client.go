@@ -0,0 +1,165 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// SSEEvent is a single event received from a Server-Sent Events stream
+type SSEEvent struct {
+	Event string `json:"event"`
+	ID    string `json:"id,omitempty"`
+	Data  string `json:"data"`
+}
+
+// SSEClient subscribes to Server-Sent Events endpoints
+type SSEClient struct {
+	client *http.Client
+}
+
+// NewSSEClient creates an SSE client. The timeout bounds how long to wait for
+// the response headers; the subscription itself runs for its own duration.
+func NewSSEClient(timeout time.Duration) *SSEClient {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+	return &SSEClient{client: &http.Client{Transport: transport}}
+}
+
+// Execute subscribes to a resolved SSE request's stream, so the client
+// serves as the executor for SSE requests
+func (c *SSEClient) Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	return c.Subscribe(ctx, resolved)
+}
+
+// Subscribe reads events from the stream until the subscription duration
+// elapses, max_events matching events arrive, the server closes the stream or
+// ctx is cancelled. The matching events are returned as a JSON array in the
+// response body. Receiving fewer than min_events is reported as an error
+// alongside the response.
+func (c *SSEClient) Subscribe(ctx context.Context, resolved *spec.ResolvedRequest) (*HTTPResponse, error) {
+	start := time.Now()
+	options := resolved.SSE
+
+	ctx, cancel := context.WithTimeout(ctx, options.Duration)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, resolved.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSE request: %w", err)
+	}
+	for key, value := range resolved.Headers {
+		req.Header.Set(key, value)
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("SSE request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Error responses are reported like any other HTTP response
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &HTTPResponse{
+			StatusCode:    resp.StatusCode,
+			Status:        resp.Status,
+			Headers:       resp.Header,
+			Body:          body,
+			Duration:      time.Since(start),
+			ContentLength: len(body),
+		}, nil
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		return nil, fmt.Errorf("unexpected SSE content type %q", resp.Header.Get("Content-Type"))
+	}
+
+	events, err := readSSEEvents(resp.Body, options.Event, options.MaxEvents)
+	// The stream ending because the subscription window closed is expected
+	if err != nil && ctx.Err() == nil {
+		return nil, fmt.Errorf("failed to read SSE stream: %w", err)
+	}
+
+	body, err := json.Marshal(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SSE events: %w", err)
+	}
+
+	response := &HTTPResponse{
+		StatusCode:    resp.StatusCode,
+		Status:        fmt.Sprintf("%s (%d events)", resp.Status, len(events)),
+		Headers:       resp.Header,
+		Body:          body,
+		Duration:      time.Since(start),
+		ContentLength: len(body),
+	}
+	if len(events) < options.MinEvents {
+		return response, fmt.Errorf("received %d events, expected at least %d", len(events), options.MinEvents)
+	}
+	return response, nil
+}
+
+// readSSEEvents parses an event stream, keeping events of the given type (or
+// every event when it is empty) until max events are kept, if max is positive
+func readSSEEvents(r io.Reader, eventType string, max int) ([]SSEEvent, error) {
+	events := []SSEEvent{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var current SSEEvent
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// A blank line dispatches the event built up so far
+		if line == "" {
+			if data != nil {
+				current.Data = strings.Join(data, "\n")
+				if current.Event == "" {
+					current.Event = "message"
+				}
+				if eventType == "" || current.Event == eventType {
+					events = append(events, current)
+					if max > 0 && len(events) >= max {
+						return events, nil
+					}
+				}
+			}
+			current, data = SSEEvent{}, nil
+			continue
+		}
+
+		// Lines starting with a colon are comments, often used as keep-alives
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			current.Event = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			current.ID = value
+		}
+	}
+	return events, scanner.Err()
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func sseServer(t *testing.T, stream string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("Expected event-stream Accept header, got %q", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, stream)
+		w.(http.Flusher).Flush()
+		// Hold the stream open like a real server until the client leaves
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSSEClient_Subscribe(t *testing.T) {
+	server := sseServer(t, ": keep-alive\n\nid: 1\ndata: first\n\nevent: order.created\ndata: {\"id\":\ndata: 7}\n\ndata: third\n\n")
+
+	resolved := &spec.ResolvedRequest{
+		Method: "GET",
+		URL:    server.URL,
+		SSE:    &spec.SSEOptions{Duration: 200 * time.Millisecond},
+	}
+	resp, err := NewSSEClient(time.Second).Subscribe(context.Background(), resolved)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Status, "(3 events)") {
+		t.Errorf("Unexpected status: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	var events []SSEEvent
+	if err := json.Unmarshal(resp.Body, &events); err != nil {
+		t.Fatalf("Expected JSON events, got %s", resp.Body)
+	}
+	want := []SSEEvent{
+		{Event: "message", ID: "1", Data: "first"},
+		{Event: "order.created", Data: "{\"id\":\n7}"},
+		{Event: "message", Data: "third"},
+	}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, events)
+	}
+}
+
+func TestSSEClient_SubscribeLimits(t *testing.T) {
+	server := sseServer(t, "event: tick\ndata: 1\n\nevent: tock\ndata: 2\n\nevent: tick\ndata: 3\n\n")
+	client := NewSSEClient(time.Second)
+
+	// Reaching max_events ends the subscription without waiting for the duration
+	start := time.Now()
+	resp, err := client.Subscribe(context.Background(), &spec.ResolvedRequest{
+		URL: server.URL,
+		SSE: &spec.SSEOptions{Duration: 10 * time.Second, Event: "tick", MaxEvents: 2},
+	})
+	if err != nil || !strings.Contains(resp.Status, "(2 events)") {
+		t.Fatalf("Expected 2 tick events, got %v %v", resp, err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Expected max_events to end the subscription early")
+	}
+
+	// Too few events still reports what was received
+	resp, err = client.Subscribe(context.Background(), &spec.ResolvedRequest{
+		URL: server.URL,
+		SSE: &spec.SSEOptions{Duration: 100 * time.Millisecond, Event: "tock", MinEvents: 2},
+	})
+	if err == nil || !strings.Contains(err.Error(), "received 1 events, expected at least 2") {
+		t.Errorf("Expected min_events error, got %v", err)
+	}
+	if resp == nil || !strings.Contains(string(resp.Body), `"data":"2"`) {
+		t.Errorf("Expected received events alongside the error, got %v", resp)
+	}
+}
+
+func TestSSEClient_NotAStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	client := NewSSEClient(time.Second)
+	options := &spec.SSEOptions{Duration: time.Second}
+
+	resp, err := client.Subscribe(context.Background(), &spec.ResolvedRequest{URL: server.URL + "/missing", SSE: options})
+	if err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 response, got %v %v", resp, err)
+	}
+
+	if _, err := client.Subscribe(context.Background(), &spec.ResolvedRequest{URL: server.URL, SSE: options}); err == nil || !strings.Contains(err.Error(), "content type") {
+		t.Errorf("Expected content type error, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"log"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// StateStore persists the state requests keep between firings, so it
+// survives restarts
+type StateStore interface {
+	// LoadState returns the saved state of the request with the given ID,
+	// or nil if it has none
+	LoadState(requestID string) (map[string]interface{}, error)
+
+	// SaveState replaces the saved state of the request with the given ID
+	SaveState(requestID string, state map[string]interface{}) error
+}
+
+// requestState returns a copy of the state of the request with the given
+// ID, loading it from the state store the first time it is needed
+func (s *Scheduler) requestState(id string) map[string]interface{} {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	if s.state == nil {
+		s.state = make(map[string]map[string]interface{})
+	}
+	state, ok := s.state[id]
+	if !ok {
+		if s.stateStore != nil {
+			var err error
+			if state, err = s.stateStore.LoadState(id); err != nil {
+				log.Printf("Error loading state of request %s: %v", id, err)
+			}
+		}
+		if state == nil {
+			state = make(map[string]interface{})
+		}
+		s.state[id] = state
+	}
+
+	copied := make(map[string]interface{}, len(state))
+	for key, value := range state {
+		copied[key] = value
+	}
+	return copied
+}
+
+// updateState stores the values a request keeps from a successful response
+// in its state and saves it. A value missing from the response leaves the
+// previous one in place.
+func (s *Scheduler) updateState(req *spec.ScheduledRequest, result *ExecutionResult) {
+	id := req.RequestID()
+	state := s.requestState(id)
+	for key, source := range req.State {
+		value, err := spec.CaptureValue(source, result.StatusCode, result.ResponseHeaders, result.ResponseBody)
+		if err != nil {
+			s.logExecution("WARN: Request '%s' [%s] kept its previous state %s: %v", req.Name, result.ExecutionID, key, err)
+			continue
+		}
+		state[key] = value
+	}
+
+	s.stateMu.Lock()
+	s.state[id] = state
+	s.stateMu.Unlock()
+
+	if s.stateStore != nil {
+		if err := s.stateStore.SaveState(id, state); err != nil {
+			log.Printf("Error saving state of request '%s': %v", req.Name, err)
+		}
+	}
+}
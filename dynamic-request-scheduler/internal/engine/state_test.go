@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// memoryStateStore is a StateStore kept in memory
+type memoryStateStore struct {
+	mu     sync.Mutex
+	states map[string]map[string]interface{}
+	saves  int
+}
+
+func (m *memoryStateStore) LoadState(request string) (map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.states[request], nil
+}
+
+func (m *memoryStateStore) SaveState(request string, state map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[request] = state
+	m.saves++
+	return nil
+}
+
+func TestScheduler_RequestState(t *testing.T) {
+	var mu sync.Mutex
+	var cursors []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		cursors = append(cursors, r.URL.Query().Get("cursor"))
+		if len(cursors) == 2 {
+			// A page without a cursor keeps the previous one
+			w.Write([]byte(`{}`))
+			return
+		}
+		fmt.Fprintf(w, `{"next": "c%d"}`, len(cursors))
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{{
+		Name:     "page",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + `/items?cursor={{ state "cursor" }}`)},
+		State:    map[string]string{"cursor": "$.next"},
+	}}
+	store := &memoryStateStore{states: map[string]map[string]interface{}{
+		"page": {"cursor": "c0"},
+	}}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:        true,
+		Count:       3,
+		Concurrency: 1,
+		StateStore:  store,
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+
+	want := []string{"c0", "c1", "c1"}
+	if fmt.Sprint(cursors) != fmt.Sprint(want) {
+		t.Errorf("Expected cursors %v, got %v", want, cursors)
+	}
+	if store.saves != 3 || store.states["page"]["cursor"] != "c3" {
+		t.Errorf("Expected the last cursor saved after each firing, got %v after %d saves", store.states["page"], store.saves)
+	}
+}
+
+func TestScheduler_RequestStateFollowsID(t *testing.T) {
+	var mu sync.Mutex
+	var cursor string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		cursor = r.URL.Query().Get("cursor")
+		w.Write([]byte(`{"next": "c2"}`))
+	}))
+	defer server.Close()
+
+	// A renamed request keeps the state saved under its ID
+	requests := []spec.ScheduledRequest{{
+		Name:     "Page through items",
+		ID:       "page",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + `/items?cursor={{ state "cursor" }}`)},
+		State:    map[string]string{"cursor": "$.next"},
+	}}
+	store := &memoryStateStore{states: map[string]map[string]interface{}{
+		"page": {"cursor": "c1"},
+	}}
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:       true,
+		StateStore: store,
+		Recorders:  []ResultRecorder{recorder},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+
+	if cursor != "c1" {
+		t.Errorf("Expected the cursor saved under the request's ID, got %q", cursor)
+	}
+	if len(store.states) != 1 || store.states["page"]["cursor"] != "c2" {
+		t.Errorf("Expected the state saved under the request's ID, got %v", store.states)
+	}
+	if len(recorder.results) != 1 || recorder.results[0].RequestID != "page" {
+		t.Errorf("Expected the result to carry the request's ID, got %+v", recorder.results)
+	}
+}
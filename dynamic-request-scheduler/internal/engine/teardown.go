@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"context"
+	"log"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// runTeardown executes the teardown requests once, in order, after dispatch
+// has stopped. They run on their own context, since the scheduler's is
+// already cancelled by then, which Stop cancels if called again.
+func (s *Scheduler) runTeardown() {
+	if len(s.teardown) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.mu.Lock()
+	s.abandonTeardown = cancel
+	s.mu.Unlock()
+
+	log.Printf("Running %d teardown request(s)...", len(s.teardown))
+	evaluator := s.newEvaluator()
+	// Teardown requests have no schedule of their own; each is due as it runs
+	immediately := "0s"
+	for i := range s.teardown {
+		if ctx.Err() != nil {
+			log.Printf("Teardown abandoned, skipped %d request(s)", len(s.teardown)-i)
+			return
+		}
+		req := s.teardown[i]
+		req.Schedule = spec.ScheduleSpec{Relative: &immediately}
+		func() {
+			defer isolate(req.Name)
+			s.execute(ctx, &req, evaluator.ForRequest(int64(i+1), req.Name, 1))
+		}()
+	}
+	log.Println("Teardown completed")
+}
@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// pathLog records the paths a test server was asked for, in order
+type pathLog struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (l *pathLog) handler(delay time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.mu.Lock()
+		l.paths = append(l.paths, r.URL.Path)
+		l.mu.Unlock()
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+	})
+}
+
+func (l *pathLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.paths...)
+}
+
+func teardownRequests(url string, names ...string) []spec.ScheduledRequest {
+	var requests []spec.ScheduledRequest
+	for _, name := range names {
+		requests = append(requests, spec.ScheduledRequest{
+			Name: name,
+			HTTP: spec.HttpRequestSpec{Method: "DELETE", URL: spec.NewLiteralString(url + "/" + name)},
+		})
+	}
+	return requests
+}
+
+func TestScheduler_TeardownAfterOnce(t *testing.T) {
+	var log pathLog
+	server := httptest.NewServer(log.handler(0))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{{
+		Name:     "create",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "POST", URL: spec.NewLiteralString(server.URL + "/create")},
+	}}
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Once:      true,
+		Teardown:  teardownRequests(server.URL, "tenant", "user"),
+		Recorders: []ResultRecorder{recorder},
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	paths := log.get()
+	if len(paths) != 3 || paths[0] != "/create" || paths[1] != "/tenant" || paths[2] != "/user" {
+		t.Errorf("Expected teardown requests in order after the run, got %v", paths)
+	}
+	if len(recorder.results) != 3 || recorder.results[2].RequestName != "user" {
+		t.Errorf("Expected teardown executions recorded, got %d results", len(recorder.results))
+	}
+}
+
+func TestScheduler_TeardownOnStop(t *testing.T) {
+	var log pathLog
+	server := httptest.NewServer(log.handler(0))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{{
+		Name:     "tick",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1h")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL + "/tick")},
+	}}
+	scheduler := NewScheduler(requests, SchedulerConfig{Teardown: teardownRequests(server.URL, "tenant")})
+	done := make(chan error)
+	go func() { done <- scheduler.Start() }()
+
+	time.Sleep(100 * time.Millisecond)
+	scheduler.Stop()
+	if err := <-done; err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if paths := log.get(); len(paths) == 0 || paths[len(paths)-1] != "/tenant" {
+		t.Errorf("Expected the teardown request to run after stopping, got %v", paths)
+	}
+}
+
+func TestScheduler_TeardownAbandoned(t *testing.T) {
+	var log pathLog
+	server := httptest.NewServer(log.handler(5 * time.Second))
+	defer server.Close()
+
+	scheduler := NewScheduler(nil, SchedulerConfig{
+		Once:     true,
+		Teardown: teardownRequests(server.URL, "slow", "skipped"),
+	})
+	done := make(chan error)
+	go func() { done <- scheduler.Start() }()
+
+	// The first Stop ends dispatch; the second abandons the teardown
+	time.Sleep(100 * time.Millisecond)
+	scheduler.Stop()
+	scheduler.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a second Stop to abandon the teardown")
+	}
+	if paths := log.get(); len(paths) != 1 || paths[0] != "/slow" {
+		t.Errorf("Expected only the first teardown request to start, got %v", paths)
+	}
+}
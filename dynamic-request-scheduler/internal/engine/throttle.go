@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultRepeatLogInterval is how often a request failing repeatedly with
+// the same reason is logged, when SchedulerConfig does not set one
+const DefaultRepeatLogInterval = 5 * time.Minute
+
+// failureStreak is a request failing repeatedly with the same reason
+type failureStreak struct {
+	reason string
+
+	// reported is when the streak was last logged, and suppressed counts the
+	// failures since then whose log lines were dropped
+	reported   time.Time
+	suppressed int
+}
+
+// failureLog collapses the log lines of requests that keep failing with the
+// same reason into one line per interval, so a service that is down does not
+// drown the console. Results and metrics still see every failure.
+type failureLog struct {
+	// interval is how often a streak is logged; negative logs every failure
+	interval time.Duration
+
+	mu      sync.Mutex
+	streaks map[string]*failureStreak
+}
+
+// newFailureLog creates a failure log collapsing streaks into one line per
+// interval; zero uses DefaultRepeatLogInterval and a negative interval logs
+// every failure
+func newFailureLog(interval time.Duration) *failureLog {
+	if interval == 0 {
+		interval = DefaultRepeatLogInterval
+	}
+	return &failureLog{interval: interval, streaks: make(map[string]*failureStreak)}
+}
+
+// collapsing reports whether a request is in a failure streak whose
+// executions are not being logged
+func (f *failureLog) collapsing(request string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.streaks[request] != nil
+}
+
+// outcome records how an execution of a request ended, with reason empty for
+// a success, and reports whether its log lines should be written. summary is
+// a line to write first when failures were dropped since the streak was last
+// logged: every interval, and when the streak ends.
+func (f *failureLog) outcome(request, reason string, now time.Time) (write bool, summary string) {
+	if f.interval < 0 {
+		return true, ""
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	streak := f.streaks[request]
+	if streak != nil && reason != "" && streak.reason == reason {
+		streak.suppressed++
+		if now.Sub(streak.reported) < f.interval {
+			return false, ""
+		}
+		summary = streak.summary(request, now)
+		streak.reported = now
+		streak.suppressed = 0
+		return false, summary
+	}
+
+	// A success or a different failure ends the streak
+	if streak != nil && streak.suppressed > 0 {
+		summary = streak.summary(request, now)
+	}
+	delete(f.streaks, request)
+	if reason != "" {
+		f.streaks[request] = &failureStreak{reason: reason, reported: now}
+	}
+	return true, summary
+}
+
+// summary describes the failures dropped since the streak was last logged
+func (s *failureStreak) summary(request string, now time.Time) string {
+	times := "times"
+	if s.suppressed == 1 {
+		times = "time"
+	}
+	return fmt.Sprintf("Request '%s' failed %d more %s in the last %v: %s",
+		request, s.suppressed, times, now.Sub(s.reported).Round(time.Second), s.reason)
+}
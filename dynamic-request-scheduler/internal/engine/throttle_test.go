@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestFailureLog_Outcome(t *testing.T) {
+	failures := newFailureLog(5 * time.Minute)
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	// The first failure is logged, the repeats are dropped
+	if write, summary := failures.outcome("api", "HTTP 503", start); !write || summary != "" {
+		t.Errorf("Expected the first failure to be logged, got %v %q", write, summary)
+	}
+	if !failures.collapsing("api") || failures.collapsing("other") {
+		t.Error("Expected only api to be collapsing")
+	}
+	for i := 1; i <= 3; i++ {
+		if write, summary := failures.outcome("api", "HTTP 503", start.Add(time.Duration(i)*time.Minute)); write || summary != "" {
+			t.Errorf("Expected repeat %d to be dropped, got %v %q", i, write, summary)
+		}
+	}
+
+	// Once the interval has passed, the dropped failures are counted
+	write, summary := failures.outcome("api", "HTTP 503", start.Add(5*time.Minute))
+	if write || summary != "Request 'api' failed 4 more times in the last 5m0s: HTTP 503" {
+		t.Errorf("Expected a summary after the interval, got %v %q", write, summary)
+	}
+
+	// A different failure ends the streak and is logged
+	failures.outcome("api", "HTTP 503", start.Add(6*time.Minute))
+	write, summary = failures.outcome("api", "connection refused", start.Add(7*time.Minute))
+	if !write || summary != "Request 'api' failed 1 more time in the last 2m0s: HTTP 503" {
+		t.Errorf("Expected a new failure to be logged after a summary, got %v %q", write, summary)
+	}
+
+	// So is a success
+	write, summary = failures.outcome("api", "", start.Add(8*time.Minute))
+	if !write || summary != "" || failures.collapsing("api") {
+		t.Errorf("Expected a success to end the streak, got %v %q", write, summary)
+	}
+}
+
+func TestFailureLog_Disabled(t *testing.T) {
+	failures := newFailureLog(-1)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if write, summary := failures.outcome("api", "HTTP 503", now); !write || summary != "" {
+			t.Errorf("Expected every failure to be logged, got %v %q", write, summary)
+		}
+	}
+}
+
+func TestScheduler_CollapsesRepeatedFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	recorder := &recordingRecorder{}
+	scheduler := NewScheduler([]spec.ScheduledRequest{{
+		Name:     "down",
+		Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+	}}, SchedulerConfig{Once: true, Count: 5, Concurrency: 1, Recorders: []ResultRecorder{recorder}})
+
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if len(recorder.results) != 5 {
+		t.Errorf("Expected every failure to be recorded, got %d", len(recorder.results))
+	}
+	out := buf.String()
+	if got := strings.Count(out, "completed: 503"); got != 1 {
+		t.Errorf("Expected the repeated failure to be logged once, got %d:\n%s", got, out)
+	}
+	if got := strings.Count(out, "Executing request 'down'"); got != 1 {
+		t.Errorf("Expected the executions of a collapsed streak not to be logged, got %d:\n%s", got, out)
+	}
+}
@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"container/heap"
+	"log"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// maxTimelineWait bounds how long a worker sleeps when nothing is due sooner,
+// so it still notices clock changes that a timer does not
+const maxTimelineWait = time.Minute
+
+// timelineEntry is a request waiting for its next run
+type timelineEntry struct {
+	due time.Time
+	req *spec.ScheduledRequest
+
+	// seq breaks ties between requests due at the same time, keeping the
+	// order they were added in
+	seq uint64
+}
+
+// timelineHeap orders entries by due time, earliest first
+type timelineHeap []timelineEntry
+
+func (h timelineHeap) Len() int { return len(h) }
+func (h timelineHeap) Less(i, j int) bool {
+	if h[i].due.Equal(h[j].due) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].due.Before(h[j].due)
+}
+func (h timelineHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *timelineHeap) Push(x interface{}) { *h = append(*h, x.(timelineEntry)) }
+func (h *timelineHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// timeline is a min-heap of the next run times of one worker's requests.
+// A request is on it at most once: it is taken off when due, and recurring
+// requests are put back once their execution ends.
+type timeline struct {
+	mu      sync.Mutex
+	entries timelineHeap
+	seq     uint64
+
+	// wake is signalled when an entry is added or an execution ends, so the
+	// worker re-evaluates how long to sleep
+	wake chan struct{}
+}
+
+func newTimeline() *timeline {
+	return &timeline{wake: make(chan struct{}, 1)}
+}
+
+// add puts a request on the timeline at due and wakes the worker
+func (t *timeline) add(req *spec.ScheduledRequest, due time.Time) {
+	t.mu.Lock()
+	t.seq++
+	heap.Push(&t.entries, timelineEntry{due: due, req: req, seq: t.seq})
+	t.mu.Unlock()
+	t.signal()
+}
+
+// signal wakes the worker without blocking
+func (t *timeline) signal() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// popDue takes every request due at or before now off the timeline, earliest
+// first
+func (t *timeline) popDue(now time.Time) []*spec.ScheduledRequest {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var due []*spec.ScheduledRequest
+	for len(t.entries) > 0 && !t.entries[0].due.After(now) {
+		due = append(due, heap.Pop(&t.entries).(timelineEntry).req)
+	}
+	return due
+}
+
+// next returns the earliest due time, and false when the timeline is empty
+func (t *timeline) next() (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.entries) == 0 {
+		return time.Time{}, false
+	}
+	return t.entries[0].due, true
+}
+
+// plan puts a request on a timeline at its next due time, leaving off
+// requests that do not run again
+func (s *Scheduler) plan(line *timeline, req *spec.ScheduledRequest) {
+	due, ok, err := s.dueTime(req)
+	if err != nil {
+		log.Printf("Error evaluating schedule of request '%s': %v", req.Name, err)
+		return
+	}
+	if ok {
+		line.add(req, due)
+	}
+}
+
+// requeue plans the next run of a request once a dispatched execution of it
+// has ended. One-shot schedules are done, but the worker is still woken to
+// notice when every request has finished.
+func (s *Scheduler) requeue(line *timeline, req *spec.ScheduledRequest) {
+	s.plan(line, req)
+	line.signal()
+}
+
+// dispatchDue queues a request taken off the timeline for the runners.
+// Paused requests are checked again a scheduler pass later.
+func (s *Scheduler) dispatchDue(line *timeline, req *spec.ScheduledRequest, queue *dispatchQueue) {
+	if !s.shouldRunRequest(req) {
+		if s.IsPaused(req.Name) {
+			line.add(req, s.now().Add(dispatchInterval))
+		} else {
+			s.plan(line, req)
+		}
+		return
+	}
+	if !s.claimDispatch(req) {
+		return
+	}
+	// The next cron fire time counts from this one rather than from when the
+	// execution ends
+	if req.Schedule.Cron != nil {
+		if _, err := s.cronDueTime(req); err != nil {
+			log.Printf("Error evaluating schedule of request '%s': %v", req.Name, err)
+		}
+	}
+	s.mu.Lock()
+	evaluator := s.evaluator
+	s.mu.Unlock()
+
+	// Only the first copy of a fanned out firing puts the request back on
+	// the timeline
+	copies := s.fanOut(req, evaluator, queue)
+	s.claimCopies(copies - 1)
+	queue.push(dispatch{req: req, claimed: true, timeline: line})
+	for i := 1; i < copies; i++ {
+		queue.push(dispatch{req: req, claimed: true, fanned: true, timeline: line})
+	}
+}
+
+// timelineWait returns how long a worker sleeps before its next pass: until
+// the earliest request is due, in real time, and at most maxTimelineWait
+func (s *Scheduler) timelineWait(line *timeline) time.Duration {
+	due, ok := line.next()
+	if !ok {
+		return maxTimelineWait
+	}
+	wait := s.realDuration(due.Sub(s.now()))
+	if wait < 0 {
+		return 0
+	}
+	return min(wait, maxTimelineWait)
+}
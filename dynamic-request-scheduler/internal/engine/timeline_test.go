@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestTimeline_Order(t *testing.T) {
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	line := newTimeline()
+	late := &spec.ScheduledRequest{Name: "late"}
+	first := &spec.ScheduledRequest{Name: "first"}
+	second := &spec.ScheduledRequest{Name: "second"}
+	early := &spec.ScheduledRequest{Name: "early"}
+	line.add(late, start.Add(time.Hour))
+	line.add(first, start.Add(time.Minute))
+	line.add(second, start.Add(time.Minute))
+	line.add(early, start)
+
+	if due, ok := line.next(); !ok || !due.Equal(start) {
+		t.Errorf("Expected the earliest entry to be due at %v, got %v %v", start, due, ok)
+	}
+
+	// Entries due together come off in the order they were added
+	due := line.popDue(start.Add(time.Minute))
+	if len(due) != 3 || due[0] != early || due[1] != first || due[2] != second {
+		names := make([]string, len(due))
+		for i, req := range due {
+			names[i] = req.Name
+		}
+		t.Errorf("Expected early, first, second, got %v", names)
+	}
+	if due := line.popDue(start.Add(time.Minute)); len(due) != 0 {
+		t.Errorf("Expected nothing else due, got %d", len(due))
+	}
+
+	if due, ok := line.next(); !ok || !due.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected late to be next, got %v %v", due, ok)
+	}
+	line.popDue(start.Add(time.Hour))
+	if _, ok := line.next(); ok {
+		t.Error("Expected the timeline to be empty")
+	}
+}
+
+func TestScheduler_RelativeDoesNotOverlap(t *testing.T) {
+	var inFlight, maxInFlight, hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			peak := atomic.LoadInt32(&maxInFlight)
+			if n <= peak || atomic.CompareAndSwapInt32(&maxInFlight, peak, n) {
+				break
+			}
+		}
+		atomic.AddInt32(&hits, 1)
+		time.Sleep(1500 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	requests := []spec.ScheduledRequest{
+		{
+			Name:     "slow",
+			Schedule: spec.ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(server.URL)},
+		},
+	}
+
+	// Runners are free, but the request is not due again until its
+	// execution has ended
+	scheduler := NewScheduler(requests, SchedulerConfig{
+		Concurrency: 5,
+		Duration:    2 * time.Second,
+	})
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if peak := atomic.LoadInt32(&maxInFlight); peak != 1 {
+		t.Errorf("Expected one execution in flight at a time, got %d", peak)
+	}
+	if hits := atomic.LoadInt32(&hits); hits != 1 {
+		t.Errorf("Expected a single execution in 2s, got %d", hits)
+	}
+}
+
+func TestScheduler_TimelineWait(t *testing.T) {
+	origin := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	scheduler := NewScheduler(nil, SchedulerConfig{Clock: spec.NewScaledClock(origin, 60)})
+	line := newTimeline()
+
+	if wait := scheduler.timelineWait(line); wait != maxTimelineWait {
+		t.Errorf("Expected an empty timeline to wait %v, got %v", maxTimelineWait, wait)
+	}
+
+	// An hour of virtual time is a minute of real time, the cap
+	line.add(&spec.ScheduledRequest{Name: "later"}, origin.Add(2*time.Hour))
+	if wait := scheduler.timelineWait(line); wait != maxTimelineWait {
+		t.Errorf("Expected the wait to be capped at %v, got %v", maxTimelineWait, wait)
+	}
+
+	line.add(&spec.ScheduledRequest{Name: "soon"}, origin.Add(time.Minute))
+	if wait := scheduler.timelineWait(line); wait <= 0 || wait > time.Second {
+		t.Errorf("Expected to wait about a second for a virtual minute, got %v", wait)
+	}
+
+	line.add(&spec.ScheduledRequest{Name: "overdue"}, origin.Add(-time.Minute))
+	if wait := scheduler.timelineWait(line); wait != 0 {
+		t.Errorf("Expected no wait for an overdue request, got %v", wait)
+	}
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings breaks down how long an HTTP exchange spent in each phase, so a
+// slow execution shows whether name resolution, connecting, the server or
+// the transfer was slow. Phases that did not happen, such as DNS and
+// Connect on a reused connection, are zero. Across redirects, each phase
+// but Transfer adds up the time spent in it on every hop; Transfer covers
+// the final response.
+type Timings struct {
+	// DNS is how long resolving the host took
+	DNS time.Duration
+
+	// Connect is how long opening the TCP connection took
+	Connect time.Duration
+
+	// TLS is how long the TLS handshake took
+	TLS time.Duration
+
+	// TTFB is the time to first byte: from the request being written to the
+	// first byte of the response, which is how long the server took
+	TTFB time.Duration
+
+	// Transfer is how long reading the response, from its first byte to the
+	// end of the body, took
+	Transfer time.Duration
+
+	// Reused is set when the exchange went over a kept-alive connection
+	Reused bool
+}
+
+// timingTrace records the phases of an exchange as httptrace reports them.
+// Hooks may run on other goroutines, such as parallel dials, so it locks.
+type timingTrace struct {
+	mu      sync.Mutex
+	timings Timings
+
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+}
+
+// withTimings returns a context that records the phases of HTTP exchanges
+// sent with it into trace
+func withTimings(ctx context.Context, trace *timingTrace) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			trace.mark(&trace.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			trace.add(&trace.dnsStart, &trace.timings.DNS)
+		},
+		ConnectStart: func(string, string) {
+			// Parallel dials to several addresses count from the first
+			trace.mu.Lock()
+			if trace.connectStart.IsZero() {
+				trace.connectStart = time.Now()
+			}
+			trace.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				trace.add(&trace.connectStart, &trace.timings.Connect)
+			}
+		},
+		TLSHandshakeStart: func() {
+			trace.mark(&trace.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			trace.add(&trace.tlsStart, &trace.timings.TLS)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			trace.mu.Lock()
+			trace.timings.Reused = info.Reused
+			trace.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			trace.mark(&trace.wroteRequest)
+		},
+		GotFirstResponseByte: func() {
+			trace.mark(&trace.firstByte)
+			trace.add(&trace.wroteRequest, &trace.timings.TTFB)
+		},
+	})
+}
+
+// mark records the current time as the start of a phase
+func (t *timingTrace) mark(start *time.Time) {
+	t.mu.Lock()
+	*start = time.Now()
+	t.mu.Unlock()
+}
+
+// add adds the time since start to a phase and clears start, ignoring
+// phases that never started
+func (t *timingTrace) add(start *time.Time, phase *time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if start.IsZero() {
+		return
+	}
+	*phase += time.Since(*start)
+	*start = time.Time{}
+}
+
+// finish ends the transfer of the final response once its body has been
+// read, and returns the recorded phases
+func (t *timingTrace) finish() *Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.firstByte.IsZero() {
+		t.timings.Transfer = time.Since(t.firstByte)
+	}
+	timings := t.timings
+	return &timings
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestHTTPClient_Timings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(5 * time.Second)
+	client.client.Transport = wireTracer{next: server.Client().Transport}
+	resolved := &spec.ResolvedRequest{Method: "GET", URL: server.URL}
+
+	resp, err := client.SendRequest(context.Background(), resolved)
+	if err != nil {
+		t.Fatalf("SendRequest failed: %v", err)
+	}
+	timings := resp.Timings
+	if timings == nil {
+		t.Fatal("Expected a timing breakdown")
+	}
+	if timings.Reused || timings.Connect <= 0 || timings.TLS <= 0 {
+		t.Errorf("Expected a new connection with a TLS handshake, got %+v", timings)
+	}
+	if timings.TTFB < 20*time.Millisecond {
+		t.Errorf("Expected TTFB to include the server's 20ms, got %v", timings.TTFB)
+	}
+	if total := timings.DNS + timings.Connect + timings.TLS + timings.TTFB + timings.Transfer; total > resp.Duration {
+		t.Errorf("Expected the phases (%v) to fit in the duration (%v)", total, resp.Duration)
+	}
+
+	resp, err = client.SendRequest(context.Background(), resolved)
+	if err != nil {
+		t.Fatalf("SendRequest failed: %v", err)
+	}
+	if !resp.Timings.Reused || resp.Timings.Connect != 0 || resp.Timings.TLS != 0 {
+		t.Errorf("Expected the second request to reuse the connection, got %+v", resp.Timings)
+	}
+}
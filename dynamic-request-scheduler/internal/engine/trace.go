@@ -0,0 +1,209 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// maxTraceBytes bounds how much of each dumped request or response is
+// logged, so tracing a large download doesn't flood the log
+const maxTraceBytes = 64 * 1024
+
+// redactedValue replaces the values of sensitive headers in traces
+const redactedValue = "[REDACTED]"
+
+// sensitiveHeaders are always redacted in traces, in canonical form
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"X-Auth-Token":        true,
+}
+
+// sensitiveWords mark further headers as sensitive when their name contains
+// one of them, such as X-Session-Token or X-Client-Secret
+var sensitiveWords = []string{"token", "secret", "password", "api-key", "apikey"}
+
+// sensitiveParams are query parameters always redacted in traces, besides
+// those whose name contains one of sensitiveWords, such as api_key or token
+var sensitiveParams = map[string]bool{
+	"key":       true,
+	"auth":      true,
+	"sig":       true,
+	"signature": true,
+}
+
+// urlHeaders carry URLs, whose credentials are redacted as in the request
+// line
+var urlHeaders = map[string]bool{
+	"Location":         true,
+	"Content-Location": true,
+	"Referer":          true,
+}
+
+// wireTrace identifies the execution whose traffic is dumped
+type wireTrace struct {
+	name        string
+	executionID string
+}
+
+type wireTraceKey struct{}
+
+// withWireTrace makes HTTP requests sent with the returned context dump the
+// bytes they send and receive
+func withWireTrace(ctx context.Context, name, executionID string) context.Context {
+	return context.WithValue(ctx, wireTraceKey{}, wireTrace{name: name, executionID: executionID})
+}
+
+// tracing reports whether a request is selected for wire tracing
+func (s *Scheduler) tracing(req *spec.ScheduledRequest) bool {
+	return s.traceHTTP != nil && s.traceHTTP.Matches(req)
+}
+
+// wireTracer wraps a transport, logging the exact requests and responses of
+// traced executions. Each redirect is dumped as a request of its own.
+type wireTracer struct {
+	next http.RoundTripper
+}
+
+// RoundTrip sends a request, dumping it and its response when its context
+// carries a wireTrace
+func (t wireTracer) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace, ok := req.Context().Value(wireTraceKey{}).(wireTrace)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	// Dumping reads the body and puts back an unread copy of it
+	if dump, err := httputil.DumpRequestOut(req, true); err != nil {
+		log.Printf("TRACE '%s' [%s] could not dump request: %v", trace.name, trace.executionID, err)
+	} else {
+		log.Printf("TRACE '%s' [%s] >>> request\n%s", trace.name, trace.executionID, formatTrace(dump))
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		log.Printf("TRACE '%s' [%s] <<< no response: %v", trace.name, trace.executionID, err)
+		return nil, err
+	}
+
+	if dump, err := httputil.DumpResponse(resp, true); err != nil {
+		log.Printf("TRACE '%s' [%s] could not dump response: %v", trace.name, trace.executionID, err)
+	} else {
+		log.Printf("TRACE '%s' [%s] <<< response\n%s", trace.name, trace.executionID, formatTrace(dump))
+	}
+	return resp, nil
+}
+
+// formatTrace redacts a dumped request or response and cuts it to
+// maxTraceBytes
+func formatTrace(dump []byte) string {
+	dump = redactDump(dump)
+	if len(dump) > maxTraceBytes {
+		return fmt.Sprintf("%s\n... (%d more bytes)", dump[:maxTraceBytes], len(dump)-maxTraceBytes)
+	}
+	return string(dump)
+}
+
+// redactDump replaces the values of sensitive headers in a dumped request or
+// response, and the credentials in the URLs of its request line and of
+// headers such as Location. Only the header section, up to the first blank
+// line, is rewritten; the body is left as sent.
+func redactDump(dump []byte) []byte {
+	head, body, found := bytes.Cut(dump, []byte("\r\n\r\n"))
+	lines := strings.Split(string(head), "\r\n")
+	// The first line is the request line, METHOD target PROTO, or the
+	// status line of a response
+	if method, rest, ok := strings.Cut(lines[0], " "); ok && !strings.HasPrefix(method, "HTTP/") {
+		target, proto, _ := strings.Cut(rest, " ")
+		lines[0] = method + " " + redactURL(target) + " " + proto
+	}
+	for i := 1; i < len(lines); i++ {
+		name, value, ok := strings.Cut(lines[i], ":")
+		switch {
+		case !ok:
+		case isSensitiveHeader(name):
+			lines[i] = name + ": " + redactedValue
+		case urlHeaders[http.CanonicalHeaderKey(strings.TrimSpace(name))]:
+			lines[i] = name + ": " + redactURL(strings.TrimSpace(value))
+		}
+	}
+
+	redacted := []byte(strings.Join(lines, "\r\n"))
+	if found {
+		redacted = append(redacted, "\r\n\r\n"...)
+		redacted = append(redacted, body...)
+	}
+	return redacted
+}
+
+// redactURL replaces the userinfo of a URL and the values of its sensitive
+// query parameters. It rewrites the text as it is rather than parsing and
+// re-encoding it, so the rest of the URL is logged exactly as sent.
+func redactURL(raw string) string {
+	if scheme, rest, ok := strings.Cut(raw, "://"); ok {
+		authority := rest
+		if end := strings.IndexAny(rest, "/?#"); end >= 0 {
+			authority = rest[:end]
+		}
+		if at := strings.LastIndex(authority, "@"); at >= 0 {
+			raw = scheme + "://" + redactedValue + rest[at:]
+		}
+	}
+
+	base, query, ok := strings.Cut(raw, "?")
+	if !ok {
+		return raw
+	}
+	query, fragment, hasFragment := strings.Cut(query, "#")
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		name, _, ok := strings.Cut(param, "=")
+		if ok && isSensitiveParam(name) {
+			params[i] = name + "=" + redactedValue
+		}
+	}
+	redacted := base + "?" + strings.Join(params, "&")
+	if hasFragment {
+		redacted += "#" + fragment
+	}
+	return redacted
+}
+
+// isSensitiveParam reports whether a query parameter's value is redacted in
+// traces
+func isSensitiveParam(name string) bool {
+	if unescaped, err := url.QueryUnescape(name); err == nil {
+		name = unescaped
+	}
+	lower := strings.ToLower(name)
+	if sensitiveParams[lower] {
+		return true
+	}
+	// Parameters spell words with underscores where headers use dashes
+	return isSensitiveHeader(strings.ReplaceAll(lower, "_", "-"))
+}
+
+// isSensitiveHeader reports whether a header's value is redacted in traces
+func isSensitiveHeader(name string) bool {
+	if sensitiveHeaders[http.CanonicalHeaderKey(strings.TrimSpace(name))] {
+		return true
+	}
+	lower := strings.ToLower(name)
+	for _, word := range sensitiveWords {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
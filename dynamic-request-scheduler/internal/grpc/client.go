@@ -0,0 +1,273 @@
+// Package grpc is a minimal gRPC client for calling unary methods over
+// HTTP/2. It looks up the request and response schemas of a method through
+// server reflection and converts messages to and from their JSON form, so no
+// generated code or exported descriptor sets are needed.
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/healthcheck"
+)
+
+// maxMessageSize bounds the messages accepted from the server
+const maxMessageSize = 16 << 20
+
+// Target is the server a call is sent to
+type Target struct {
+	// Address is the server's host:port
+	Address string
+
+	// TLS connects over TLS instead of cleartext HTTP/2 (h2c)
+	TLS bool
+}
+
+// Response is the reply to a unary call
+type Response struct {
+	// Message is the reply in its JSON form
+	Message map[string]interface{}
+
+	// Metadata holds the response headers and trailers
+	Metadata http.Header
+}
+
+// Client calls methods, caching the schema of each method it has called
+type Client struct {
+	timeout time.Duration
+	h2c     *http.Client
+	h2      *http.Client
+
+	mu      sync.Mutex
+	methods map[string]*Method
+}
+
+// NewClient creates a client whose calls each complete within timeout
+func NewClient(timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Client{
+		timeout: timeout,
+		h2c:     &http.Client{Transport: newTransport(false)},
+		h2:      &http.Client{Transport: newTransport(true)},
+		methods: make(map[string]*Method),
+	}
+}
+
+// Invoke calls a unary method, named like orders.v1.Orders/GetOrder, with
+// message in its JSON form and metadata sent as request headers
+func (c *Client) Invoke(ctx context.Context, target Target, method string, metadata map[string]string, message interface{}) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	m, err := c.method(ctx, target, method)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.invoke(ctx, target, m, metadata, message)
+	if err != nil {
+		// Look the schema up again next time, in case the server was
+		// restarted with a different one
+		c.mu.Lock()
+		delete(c.methods, cacheKey(target, method))
+		c.mu.Unlock()
+	}
+	return response, err
+}
+
+func (c *Client) invoke(ctx context.Context, target Target, m *Method, metadata map[string]string, message interface{}) (*Response, error) {
+	if !m.Unary() {
+		return nil, fmt.Errorf("%s is a streaming method; only unary methods can be called", m.FullName())
+	}
+	data, err := m.Input.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s message: %w", m.Input.Name, err)
+	}
+
+	reply, header, err := c.call(ctx, target, "/"+m.FullName(), metadata, data)
+	if err != nil {
+		return nil, err
+	}
+	object, err := m.Output.Unmarshal(reply)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s response: %w", m.Output.Name, err)
+	}
+	return &Response{Message: object, Metadata: header}, nil
+}
+
+// method returns the schema of a method, looking it up through reflection
+// the first time it is called
+func (c *Client) method(ctx context.Context, target Target, name string) (*Method, error) {
+	key := cacheKey(target, name)
+	c.mu.Lock()
+	m, ok := c.methods[key]
+	c.mu.Unlock()
+	if ok {
+		return m, nil
+	}
+
+	serviceName, methodName, ok := SplitMethod(name)
+	if !ok {
+		return nil, fmt.Errorf("invalid method %q (expected e.g. orders.v1.Orders/GetOrder)", name)
+	}
+	p := newPool()
+	if err := c.load(ctx, target, p, serviceName); err != nil {
+		return nil, err
+	}
+	service := p.services[serviceName]
+	if service == nil {
+		return nil, fmt.Errorf("the server has no service %s", serviceName)
+	}
+	for _, m := range service.Methods {
+		if m.Name == methodName {
+			c.mu.Lock()
+			c.methods[key] = m
+			c.mu.Unlock()
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("service %s has no method %s", serviceName, methodName)
+}
+
+func cacheKey(target Target, method string) string {
+	return strconv.FormatBool(target.TLS) + " " + target.Address + " " + method
+}
+
+// SplitMethod splits a method name given as service/method or
+// service.method, optionally with a leading slash
+func SplitMethod(name string) (service, method string, ok bool) {
+	name = strings.TrimPrefix(name, "/")
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		i = strings.LastIndex(name, ".")
+	}
+	if i <= 0 || i == len(name)-1 {
+		return "", "", false
+	}
+	return name[:i], name[i+1:], true
+}
+
+// call sends one length-prefixed message to path and returns the single
+// message of the response with its headers and trailers. A status other
+// than OK is returned as a *StatusError.
+func (c *Client) call(ctx context.Context, target Target, path string, metadata map[string]string, message []byte) ([]byte, http.Header, error) {
+	scheme, client := "http", c.h2c
+	if target.TLS {
+		scheme, client = "https", c.h2
+	}
+
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	frame = append(frame, message...)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, scheme+"://"+target.Address+path, bytes.NewReader(frame))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, value := range metadata {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Header.Set("Grpc-Timeout", fmt.Sprintf("%dm", max(time.Until(deadline).Milliseconds(), 1)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, healthcheck.Simplify(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("status %s (is this a gRPC server?)", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageSize+6))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Errors without a message are sent as headers only
+	header := resp.Header.Clone()
+	for key, values := range resp.Trailer {
+		header[key] = values
+	}
+	code := header.Get("Grpc-Status")
+	if code == "" {
+		return nil, nil, fmt.Errorf("response has no grpc-status")
+	}
+	if code != "0" {
+		status := &StatusError{Message: header.Get("Grpc-Message")}
+		status.Code, _ = strconv.Atoi(code)
+		if decoded, err := url.PathUnescape(status.Message); err == nil {
+			status.Message = decoded
+		}
+		return nil, header, status
+	}
+
+	reply, err := readMessage(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reply, header, nil
+}
+
+// readMessage returns the first length-prefixed message of a response body
+func readMessage(body []byte) ([]byte, error) {
+	if len(body) < 5 {
+		return nil, fmt.Errorf("malformed gRPC response")
+	}
+	if body[0] != 0 {
+		return nil, fmt.Errorf("compressed gRPC responses are not supported")
+	}
+	size := binary.BigEndian.Uint32(body[1:5])
+	if size > maxMessageSize {
+		return nil, fmt.Errorf("gRPC response exceeds %d bytes", maxMessageSize)
+	}
+	if uint32(len(body)-5) < size {
+		return nil, fmt.Errorf("truncated gRPC response")
+	}
+	return body[5 : 5+size], nil
+}
+
+// Status codes the client acts on
+const (
+	codeNotFound      = 5
+	codeUnimplemented = 12
+)
+
+// codeNames are the names of the status codes, by number
+var codeNames = []string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED",
+	"NOT_FOUND", "ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION", "ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED",
+	"INTERNAL", "UNAVAILABLE", "DATA_LOSS", "UNAUTHENTICATED",
+}
+
+// StatusError is a call that completed with a status other than OK
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	text := "gRPC status " + strconv.Itoa(e.Code)
+	if e.Code >= 0 && e.Code < len(codeNames) {
+		text += " " + codeNames[e.Code]
+	}
+	if e.Message != "" {
+		text += ": " + e.Message
+	}
+	return text
+}
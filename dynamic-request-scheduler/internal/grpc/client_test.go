@@ -0,0 +1,247 @@
+//go:build go1.24
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newOrdersServer serves the orders.v1.Orders service of testFiles over
+// cleartext HTTP/2, with server reflection at path. It sends orders.proto
+// without the file it imports, so clients must ask for that by name.
+func newOrdersServer(t *testing.T, path string) *httptest.Server {
+	t.Helper()
+	orders, common := testFiles()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || r.Header.Get("Content-Type") != "application/grpc" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if len(body) < 5 {
+			writeStatus(w, "13", "no message")
+			return
+		}
+		message := body[5:]
+
+		switch r.URL.Path {
+		case path:
+			fields, _ := readFields(message)
+			var response []byte
+			switch field := fields[0]; field.number {
+			case requestListServices:
+				var list []byte
+				for _, name := range []string{"orders.v1.Orders", "grpc.reflection.v1.ServerReflection"} {
+					list = appendBytes(list, 1, appendString(nil, 1, name))
+				}
+				response = appendBytes(nil, responseServices, list)
+			case requestFileContainingSymbol:
+				switch string(field.bytes) {
+				case "orders.v1.Orders", "orders.v1.Order", "orders.v1.Order.Status":
+					response = appendBytes(nil, responseFileDescriptors, appendBytes(nil, 1, orders))
+				default:
+					response = appendBytes(nil, responseError, appendString(appendVarintField(nil, 1, codeNotFound), 2, "symbol not found"))
+				}
+			case requestFileByFilename:
+				response = appendBytes(nil, responseFileDescriptors, appendBytes(nil, 1, common))
+			}
+			writeMessage(w, response)
+		case "/orders.v1.Orders/GetOrder":
+			fields, _ := readFields(message)
+			if len(fields) == 0 || string(fields[0].bytes) == "missing" {
+				writeStatus(w, "5", "order%20missing")
+				return
+			}
+			if r.Header.Get("Authorization") != "Bearer dev" || r.Header.Get("Grpc-Timeout") == "" {
+				writeStatus(w, "16", "")
+				return
+			}
+			// Order { id: "A1", status: SHIPPED, total { currency: "EUR", units: 1250 }, delta: -3 }
+			reply := appendBytes(nil, 1, fields[0].bytes)
+			reply = appendVarintField(reply, 2, 2)
+			reply = appendBytes(reply, 3, appendVarintField(appendString(nil, 1, "EUR"), 2, 1250))
+			reply = appendVarintField(reply, 7, 5)
+			w.Header().Set("Request-Id", "r-1")
+			writeMessage(w, reply)
+		default:
+			writeStatus(w, "12", "unknown service")
+		}
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// writeMessage sends a successful response with one message
+func writeMessage(w http.ResponseWriter, message []byte) {
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status")
+	frame := make([]byte, 5)
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	w.Write(append(frame, message...))
+	w.Header().Set("Grpc-Status", "0")
+}
+
+// writeStatus sends an error as a trailers-only response
+func writeStatus(w http.ResponseWriter, code, message string) {
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Grpc-Status", code)
+	if message != "" {
+		w.Header().Set("Grpc-Message", message)
+	}
+}
+
+func serverTarget(server *httptest.Server) Target {
+	return Target{Address: strings.TrimPrefix(server.URL, "http://")}
+}
+
+func TestClient_Invoke(t *testing.T) {
+	target := serverTarget(newOrdersServer(t, reflectionPath))
+	client := NewClient(time.Second)
+	metadata := map[string]string{"Authorization": "Bearer dev"}
+
+	response, err := client.Invoke(context.Background(), target, "orders.v1.Orders/GetOrder", metadata, map[string]interface{}{"orderId": "A1"})
+	if err != nil {
+		t.Fatalf("Invoke failed: %v", err)
+	}
+	got := response.Message
+	total, _ := got["total"].(map[string]interface{})
+	if got["id"] != "A1" || got["status"] != "SHIPPED" || got["delta"] != int64(-3) || total["currency"] != "EUR" || total["units"] != "1250" {
+		t.Errorf("Unexpected reply: %v", got)
+	}
+	if response.Metadata.Get("Request-Id") != "r-1" || response.Metadata.Get("Grpc-Status") != "0" {
+		t.Errorf("Expected response headers and trailers, got %v", response.Metadata)
+	}
+
+	// A status other than OK
+	_, err = client.Invoke(context.Background(), target, "orders.v1.Orders.GetOrder", metadata, map[string]interface{}{"order_id": "missing"})
+	var status *StatusError
+	if !errors.As(err, &status) || status.Code != 5 || err.Error() != "gRPC status 5 NOT_FOUND: order missing" {
+		t.Errorf("Expected NOT_FOUND, got %v", err)
+	}
+
+	invalid := map[string]string{
+		"orders.v1.Orders/WatchOrders": "orders.v1.Orders/WatchOrders is a streaming method",
+		"orders.v1.Orders/Nope":        "service orders.v1.Orders has no method Nope",
+		"orders.v1.Users/GetUser":      "the server does not know orders.v1.Users",
+		"GetOrder":                     `invalid method "GetOrder"`,
+	}
+	for method, want := range invalid {
+		if _, err := client.Invoke(context.Background(), target, method, metadata, nil); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", method, want, err)
+		}
+	}
+
+	if _, err := client.Invoke(context.Background(), target, "orders.v1.Orders/GetOrder", metadata, map[string]interface{}{"id": "A1"}); err == nil || !strings.Contains(err.Error(), `invalid orders.v1.GetOrderRequest message: orders.v1.GetOrderRequest has no field "id"`) {
+		t.Errorf("Expected an invalid message error, got %v", err)
+	}
+}
+
+func TestClient_Services(t *testing.T) {
+	for _, path := range []string{reflectionPath, reflectionAlphaPath} {
+		target := serverTarget(newOrdersServer(t, path))
+		services, err := NewClient(time.Second).Services(context.Background(), target)
+		if err != nil {
+			t.Fatalf("Services failed with reflection at %s: %v", path, err)
+		}
+		if len(services) != 1 {
+			t.Fatalf("Expected the reflection service left out, got %d services", len(services))
+		}
+
+		var out bytes.Buffer
+		services[0].Report(&out)
+		want := `service orders.v1.Orders {
+  rpc GetOrder(orders.v1.GetOrderRequest) returns (orders.v1.Order);
+  rpc WatchOrders(orders.v1.GetOrderRequest) returns (stream orders.v1.Order);  // streaming, cannot be scheduled
+}
+`
+		if out.String() != want {
+			t.Errorf("Expected:\n%s\ngot:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestClient_Describe(t *testing.T) {
+	target := serverTarget(newOrdersServer(t, reflectionPath))
+	client := NewClient(time.Second)
+
+	// The server only finds services and types, so the method is looked up
+	// through its service
+	symbol, err := client.Describe(context.Background(), target, "orders.v1.Orders/GetOrder")
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+	var out bytes.Buffer
+	symbol.Report(&out)
+	want := `rpc orders.v1.Orders/GetOrder(orders.v1.GetOrderRequest) returns (orders.v1.Order);
+
+message orders.v1.GetOrderRequest {
+  string order_id = 1;
+  repeated int32 quantities = 2;
+  map<string, string> labels = 3;
+  bool rush = 4;
+}
+
+message orders.v1.Order {
+  string id = 1;
+  orders.v1.Order.Status status = 2;
+  orders.v1.Money total = 3;
+  repeated string tags = 4;
+  bytes signature = 5;
+  double weight = 6;
+  sint32 delta = 7;
+}
+
+enum orders.v1.Order.Status {
+  STATUS_UNSPECIFIED = 0;
+  PENDING = 1;
+  SHIPPED = 2;
+}
+
+message orders.v1.Money {
+  string currency = 1;
+  int64 units = 2;
+}
+`
+	if out.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, out.String())
+	}
+
+	symbol, err = client.Describe(context.Background(), target, "orders.v1.Order.Status")
+	if _, ok := symbol.(*Enum); err != nil || !ok {
+		t.Errorf("Expected the enum, got %v, %v", symbol, err)
+	}
+	if _, err := client.Describe(context.Background(), target, "orders.v1.Invoice"); err == nil || err.Error() != "the server does not know orders.v1.Invoice" {
+		t.Errorf("Expected an unknown symbol error, got %v", err)
+	}
+}
+
+func TestClient_NoReflection(t *testing.T) {
+	target := serverTarget(newOrdersServer(t, "/none"))
+	_, err := NewClient(time.Second).Services(context.Background(), target)
+	if err == nil || !strings.Contains(err.Error(), "does not offer reflection") {
+		t.Errorf("Expected a missing reflection error, got %v", err)
+	}
+}
+
+func TestClient_NotGRPC(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := NewClient(time.Second).Services(context.Background(), serverTarget(server))
+	if err == nil {
+		t.Error("Expected a plain HTTP/1 server to fail")
+	}
+}
@@ -0,0 +1,501 @@
+package grpc
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Marshal encodes value, a message in its JSON form, as m. Fields are
+// matched by their proto or JSON name; numbers may be given as JSON numbers
+// or strings, enums by name or number, and bytes as base64.
+func (m *Message) Marshal(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		// A templated message resolves to its JSON text
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(v), &decoded); err != nil {
+			return nil, fmt.Errorf("message is not a JSON object: %w", err)
+		}
+		return m.Marshal(decoded)
+	case map[string]interface{}:
+		return m.marshalObject(nil, v)
+	default:
+		return nil, fmt.Errorf("message must be an object, got %T", value)
+	}
+}
+
+// marshalObject appends the fields of object, encoded as m, to b
+func (m *Message) marshalObject(b []byte, object map[string]interface{}) ([]byte, error) {
+	// Sorted by field number so the same message always encodes the same way
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		if m.fieldNamed(key) == nil {
+			return nil, fmt.Errorf("%s has no field %q", m.Name, key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return m.fieldNamed(keys[i]).Number < m.fieldNamed(keys[j]).Number
+	})
+
+	var err error
+	for _, key := range keys {
+		field := m.fieldNamed(key)
+		if b, err = field.marshal(b, object[key]); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return b, nil
+}
+
+// marshal appends the encoding of field set to value
+func (f *Field) marshal(b []byte, value interface{}) ([]byte, error) {
+	if value == nil {
+		// null leaves a field unset
+		return b, nil
+	}
+
+	if f.IsMap() {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object, got %T", value)
+		}
+		keyField, valueField := f.Message.field(1), f.Message.field(2)
+		if keyField == nil || valueField == nil {
+			return nil, fmt.Errorf("invalid map entry type %s", f.Message.Name)
+		}
+
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			entry, err := keyField.marshal(nil, key)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", key, err)
+			}
+			if entry, err = valueField.marshal(entry, object[key]); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			b = appendBytes(b, f.Number, entry)
+		}
+		return b, nil
+	}
+
+	if f.Repeated {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an array, got %T", value)
+		}
+		if wireType := f.wireType(); wireType != wireBytes {
+			// Repeated scalars are packed into one length-delimited field
+			var packed []byte
+			for i, item := range items {
+				var err error
+				if packed, err = f.appendScalar(packed, item); err != nil {
+					return nil, fmt.Errorf("[%d]: %w", i, err)
+				}
+			}
+			return appendBytes(b, f.Number, packed), nil
+		}
+		for i, item := range items {
+			var err error
+			if b, err = f.marshalOne(b, item); err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return b, nil
+	}
+
+	return f.marshalOne(b, value)
+}
+
+// marshalOne appends a single value of field, with its tag
+func (f *Field) marshalOne(b []byte, value interface{}) ([]byte, error) {
+	switch f.Type {
+	case typeMessage:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object, got %T", value)
+		}
+		data, err := f.Message.marshalObject(nil, object)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(b, f.Number, data), nil
+	case typeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %T", value)
+		}
+		return appendString(b, f.Number, s), nil
+	case typeBytes:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a base64 string, got %T", value)
+		}
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("expected a base64 string: %w", err)
+		}
+		return appendBytes(b, f.Number, data), nil
+	case typeGroup:
+		return nil, fmt.Errorf("groups are not supported")
+	default:
+		return f.appendScalar(appendTag(b, f.Number, f.wireType()), value)
+	}
+}
+
+// wireType returns how values of the field's type are encoded
+func (f *Field) wireType() int {
+	switch f.Type {
+	case typeDouble, typeFixed64, typeSfixed64:
+		return wireFixed64
+	case typeFloat, typeFixed32, typeSfixed32:
+		return wireFixed32
+	case typeString, typeBytes, typeMessage, typeGroup:
+		return wireBytes
+	default:
+		return wireVarint
+	}
+}
+
+// appendScalar appends a numeric, bool or enum value without its tag
+func (f *Field) appendScalar(b []byte, value interface{}) ([]byte, error) {
+	switch f.Type {
+	case typeBool:
+		v, ok := value.(bool)
+		if s, isString := value.(string); isString {
+			// Map keys are always strings
+			v, ok = s == "true", s == "true" || s == "false"
+		}
+		if !ok {
+			return nil, fmt.Errorf("expected true or false, got %v", value)
+		}
+		if v {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case typeEnum:
+		number, err := f.enumNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		return binary.AppendUvarint(b, uint64(number)), nil
+	case typeDouble, typeFloat:
+		v, err := toFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		if f.Type == typeFloat {
+			return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(v))), nil
+		}
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(v)), nil
+	}
+
+	switch f.Type {
+	case typeUint32, typeFixed32:
+		v, err := toInt(value, 32, true)
+		if err != nil {
+			return nil, err
+		}
+		if f.Type == typeFixed32 {
+			return binary.LittleEndian.AppendUint32(b, uint32(v)), nil
+		}
+		return binary.AppendUvarint(b, v), nil
+	case typeUint64, typeFixed64:
+		v, err := toInt(value, 64, true)
+		if err != nil {
+			return nil, err
+		}
+		if f.Type == typeFixed64 {
+			return binary.LittleEndian.AppendUint64(b, v), nil
+		}
+		return binary.AppendUvarint(b, v), nil
+	case typeInt32, typeSint32, typeSfixed32:
+		v, err := toInt(value, 32, false)
+		if err != nil {
+			return nil, err
+		}
+		switch f.Type {
+		case typeSint32:
+			return binary.AppendVarint(b, int64(v)), nil
+		case typeSfixed32:
+			return binary.LittleEndian.AppendUint32(b, uint32(v)), nil
+		}
+		// Negative int32s are sign-extended to ten bytes
+		return binary.AppendUvarint(b, uint64(int64(v))), nil
+	case typeInt64, typeSint64, typeSfixed64:
+		v, err := toInt(value, 64, false)
+		if err != nil {
+			return nil, err
+		}
+		switch f.Type {
+		case typeSint64:
+			return binary.AppendVarint(b, int64(v)), nil
+		case typeSfixed64:
+			return binary.LittleEndian.AppendUint64(b, v), nil
+		}
+		return binary.AppendUvarint(b, v), nil
+	}
+	return nil, fmt.Errorf("unsupported field type %d", f.Type)
+}
+
+// enumNumber returns the number of an enum value given by name or number
+func (f *Field) enumNumber(value interface{}) (int32, error) {
+	if name, ok := value.(string); ok {
+		for _, v := range f.Enum.Values {
+			if v.Name == name {
+				return v.Number, nil
+			}
+		}
+		if _, err := strconv.ParseInt(name, 10, 32); err != nil {
+			return 0, fmt.Errorf("%s has no value %q", f.Enum.Name, name)
+		}
+	}
+	v, err := toInt(value, 32, false)
+	return int32(v), err
+}
+
+// toFloat converts a JSON number, or a string holding one, to a float
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		switch v {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected a number, got %q", v)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("expected a number, got %T", value)
+	}
+}
+
+// toInt converts a whole JSON number, or a string holding one, to an integer
+// of the given size, returned as its two's complement bits
+func toInt(value interface{}, bits int, unsigned bool) (uint64, error) {
+	var text string
+	switch v := value.(type) {
+	case int:
+		text = strconv.Itoa(v)
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case uint64:
+		text = strconv.FormatUint(v, 10)
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("expected a whole number, got %v", v)
+		}
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		text = v.String()
+	case string:
+		text = v
+	default:
+		return 0, fmt.Errorf("expected a whole number, got %T", value)
+	}
+
+	if unsigned {
+		n, err := strconv.ParseUint(text, 10, bits)
+		if err != nil {
+			return 0, fmt.Errorf("expected an unsigned %d-bit integer, got %s", bits, text)
+		}
+		return n, nil
+	}
+	n, err := strconv.ParseInt(text, 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("expected a %d-bit integer, got %s", bits, text)
+	}
+	return uint64(n), nil
+}
+
+// Unmarshal decodes an encoded m into its JSON form, as in the proto3 JSON
+// mapping: fields are keyed by JSON name, 64-bit integers are strings,
+// bytes are base64 and enums are names. Fields the schema does not know are
+// left out.
+func (m *Message) Unmarshal(data []byte) (map[string]interface{}, error) {
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	object := make(map[string]interface{})
+	for _, wire := range fields {
+		field := m.field(wire.number)
+		if field == nil {
+			continue
+		}
+
+		switch {
+		case field.IsMap():
+			entries, _ := object[field.JSONName].(map[string]interface{})
+			if entries == nil {
+				entries = make(map[string]interface{})
+				object[field.JSONName] = entries
+			}
+			if wire.wireType != wireBytes {
+				return nil, fmt.Errorf("%s: %w", field.Name, errMalformed)
+			}
+			entry, err := field.Message.Unmarshal(wire.bytes)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field.Name, err)
+			}
+			key := fmt.Sprint(entry[field.Message.field(1).JSONName])
+			if entry[field.Message.field(1).JSONName] == nil {
+				key = field.Message.field(1).zeroKey()
+			}
+			entries[key] = entry[field.Message.field(2).JSONName]
+		case field.Repeated:
+			values, err := field.unmarshal(wire)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field.Name, err)
+			}
+			items, _ := object[field.JSONName].([]interface{})
+			object[field.JSONName] = append(items, values...)
+		default:
+			values, err := field.unmarshal(wire)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field.Name, err)
+			}
+			// The last value of a singular field wins
+			object[field.JSONName] = values[len(values)-1]
+		}
+	}
+	return object, nil
+}
+
+// zeroKey is the JSON form of a map key left at its default value
+func (f *Field) zeroKey() string {
+	switch f.Type {
+	case typeString:
+		return ""
+	case typeBool:
+		return "false"
+	default:
+		return "0"
+	}
+}
+
+// unmarshal decodes the values of one field read from the wire; packed
+// repeated scalars hold several
+func (f *Field) unmarshal(wire wireField) ([]interface{}, error) {
+	expected := f.wireType()
+	if wire.wireType == wireBytes && expected != wireBytes {
+		// Packed repeated scalars
+		var values []interface{}
+		data := wire.bytes
+		for len(data) > 0 {
+			var value uint64
+			switch expected {
+			case wireVarint:
+				var n int
+				if value, n = binary.Uvarint(data); n <= 0 {
+					return nil, errMalformed
+				}
+				data = data[n:]
+			case wireFixed64:
+				if len(data) < 8 {
+					return nil, errMalformed
+				}
+				value, data = binary.LittleEndian.Uint64(data), data[8:]
+			case wireFixed32:
+				if len(data) < 4 {
+					return nil, errMalformed
+				}
+				value, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+			}
+			values = append(values, f.scalarValue(value))
+		}
+		return values, nil
+	}
+	if wire.wireType != expected {
+		return nil, errMalformed
+	}
+
+	switch f.Type {
+	case typeMessage:
+		object, err := f.Message.Unmarshal(wire.bytes)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{object}, nil
+	case typeString:
+		return []interface{}{string(wire.bytes)}, nil
+	case typeBytes:
+		return []interface{}{base64.StdEncoding.EncodeToString(wire.bytes)}, nil
+	case typeGroup:
+		return nil, fmt.Errorf("groups are not supported")
+	default:
+		return []interface{}{f.scalarValue(wire.value)}, nil
+	}
+}
+
+// scalarValue converts the raw bits of a numeric, bool or enum value to its
+// JSON form
+func (f *Field) scalarValue(value uint64) interface{} {
+	switch f.Type {
+	case typeBool:
+		return value != 0
+	case typeEnum:
+		for _, v := range f.Enum.Values {
+			if v.Number == int32(value) {
+				return v.Name
+			}
+		}
+		return int64(int32(value))
+	case typeDouble:
+		return jsonFloat(math.Float64frombits(value))
+	case typeFloat:
+		return jsonFloat(float64(math.Float32frombits(uint32(value))))
+	case typeInt32, typeSfixed32:
+		return int64(int32(value))
+	case typeSint32:
+		return int64(int32(uint32(value>>1) ^ -uint32(value&1)))
+	case typeUint32, typeFixed32:
+		return int64(uint32(value))
+	case typeInt64, typeSfixed64:
+		return strconv.FormatInt(int64(value), 10)
+	case typeSint64:
+		return strconv.FormatInt(int64(value>>1)^-int64(value&1), 10)
+	default:
+		// uint64 and fixed64
+		return strconv.FormatUint(value, 10)
+	}
+}
+
+// jsonFloat returns f, or the string JSON uses for it when it is not finite
+func jsonFloat(f float64) interface{} {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return f
+}
@@ -0,0 +1,278 @@
+package grpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Field labels of FieldDescriptorProto
+const (
+	labelOptional = 1
+)
+
+func appendVarintField(b []byte, number int, value uint64) []byte {
+	b = appendTag(b, number, wireVarint)
+	return binary.AppendUvarint(b, value)
+}
+
+// fieldProto encodes a FieldDescriptorProto
+func fieldProto(name string, number, label, fieldType int, typeName string) []byte {
+	b := appendString(nil, 1, name)
+	b = appendVarintField(b, 3, uint64(number))
+	b = appendVarintField(b, 4, uint64(label))
+	b = appendVarintField(b, 5, uint64(fieldType))
+	if typeName != "" {
+		b = appendString(b, 6, typeName)
+	}
+	return b
+}
+
+// messageProto encodes a DescriptorProto with fields, and nested types or
+// enums given as already encoded DescriptorProto fields
+func messageProto(name string, fields [][]byte, nested ...[]byte) []byte {
+	b := appendString(nil, 1, name)
+	for _, field := range fields {
+		b = appendBytes(b, 2, field)
+	}
+	for _, n := range nested {
+		b = append(b, n...)
+	}
+	return b
+}
+
+// testFiles returns the encoded descriptors of orders/v1/orders.proto and
+// the common.proto it imports:
+//
+//	message Money { string currency = 1; int64 units = 2; }
+//
+//	message GetOrderRequest {
+//	  string order_id = 1;
+//	  repeated int32 quantities = 2;
+//	  map<string, string> labels = 3;
+//	  bool rush = 4;
+//	}
+//	message Order {
+//	  enum Status { STATUS_UNSPECIFIED = 0; PENDING = 1; SHIPPED = 2; }
+//	  string id = 1;
+//	  Status status = 2;
+//	  Money total = 3;
+//	  repeated string tags = 4;
+//	  bytes signature = 5;
+//	  double weight = 6;
+//	  sint32 delta = 7;
+//	}
+//	service Orders {
+//	  rpc GetOrder(GetOrderRequest) returns (Order);
+//	  rpc WatchOrders(GetOrderRequest) returns (stream Order);
+//	}
+func testFiles() (orders, common []byte) {
+	common = appendString(nil, 1, "orders/v1/common.proto")
+	common = appendString(common, 2, "orders.v1")
+	common = appendBytes(common, 4, messageProto("Money", [][]byte{
+		fieldProto("currency", 1, labelOptional, typeString, ""),
+		fieldProto("units", 2, labelOptional, typeInt64, ""),
+	}))
+
+	labelsEntry := messageProto("LabelsEntry", [][]byte{
+		fieldProto("key", 1, labelOptional, typeString, ""),
+		fieldProto("value", 2, labelOptional, typeString, ""),
+	}, appendBytes(nil, 7, appendVarintField(nil, 7, 1)))
+	request := messageProto("GetOrderRequest", [][]byte{
+		append(fieldProto("order_id", 1, labelOptional, typeString, ""), appendString(nil, 10, "orderId")...),
+		fieldProto("quantities", 2, labelRepeated, typeInt32, ""),
+		fieldProto("labels", 3, labelRepeated, typeMessage, ".orders.v1.GetOrderRequest.LabelsEntry"),
+		fieldProto("rush", 4, labelOptional, typeBool, ""),
+	}, appendBytes(nil, 3, labelsEntry))
+
+	var status []byte
+	status = appendString(status, 1, "Status")
+	for i, name := range []string{"STATUS_UNSPECIFIED", "PENDING", "SHIPPED"} {
+		status = appendBytes(status, 2, appendVarintField(appendString(nil, 1, name), 2, uint64(i)))
+	}
+	order := messageProto("Order", [][]byte{
+		fieldProto("id", 1, labelOptional, typeString, ""),
+		fieldProto("status", 2, labelOptional, typeEnum, ".orders.v1.Order.Status"),
+		fieldProto("total", 3, labelOptional, typeMessage, ".orders.v1.Money"),
+		fieldProto("tags", 4, labelRepeated, typeString, ""),
+		fieldProto("signature", 5, labelOptional, typeBytes, ""),
+		fieldProto("weight", 6, labelOptional, typeDouble, ""),
+		fieldProto("delta", 7, labelOptional, typeSint32, ""),
+	}, appendBytes(nil, 4, status))
+
+	getOrder := appendString(nil, 1, "GetOrder")
+	getOrder = appendString(getOrder, 2, ".orders.v1.GetOrderRequest")
+	getOrder = appendString(getOrder, 3, ".orders.v1.Order")
+	watchOrders := appendString(nil, 1, "WatchOrders")
+	watchOrders = appendString(watchOrders, 2, ".orders.v1.GetOrderRequest")
+	watchOrders = appendString(watchOrders, 3, ".orders.v1.Order")
+	watchOrders = appendVarintField(watchOrders, 6, 1)
+	service := appendString(nil, 1, "Orders")
+	service = appendBytes(service, 2, getOrder)
+	service = appendBytes(service, 2, watchOrders)
+
+	orders = appendString(nil, 1, "orders/v1/orders.proto")
+	orders = appendString(orders, 2, "orders.v1")
+	orders = appendString(orders, 3, "orders/v1/common.proto")
+	orders = appendBytes(orders, 4, request)
+	orders = appendBytes(orders, 4, order)
+	orders = appendBytes(orders, 6, service)
+	return orders, common
+}
+
+// testPool returns a pool holding both test files
+func testPool(t *testing.T) *pool {
+	t.Helper()
+	orders, common := testFiles()
+	p := newPool()
+	for _, file := range [][]byte{orders, common} {
+		if _, err := p.addFile(file); err != nil {
+			t.Fatalf("addFile failed: %v", err)
+		}
+	}
+	if err := p.link(); err != nil {
+		t.Fatalf("link failed: %v", err)
+	}
+	return p
+}
+
+func TestMessage_Marshal(t *testing.T) {
+	p := testPool(t)
+	request := p.messages["orders.v1.GetOrderRequest"]
+
+	data, err := request.Marshal(map[string]interface{}{
+		"order_id":   "A1",
+		"quantities": []interface{}{1, "2"},
+		"labels":     map[string]interface{}{"env": "dev"},
+		"rush":       true,
+	})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := []byte{
+		0x0a, 0x02, 'A', '1',
+		0x12, 0x02, 0x01, 0x02,
+		0x1a, 0x0a, 0x0a, 0x03, 'e', 'n', 'v', 0x12, 0x03, 'd', 'e', 'v',
+		0x20, 0x01,
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("Expected % x, got % x", want, data)
+	}
+
+	// The JSON name, and a message resolved from a template as JSON text
+	data, err = request.Marshal(`{"orderId": "A1", "quantities": [-1]}`)
+	want = append([]byte{0x0a, 0x02, 'A', '1', 0x12, 0x0a}, bytes.Repeat([]byte{0xff}, 9)...)
+	if err != nil || !bytes.Equal(data, append(want, 0x01)) {
+		t.Errorf("Expected a sign-extended int32, got % x, %v", data, err)
+	}
+}
+
+func TestMessage_MarshalErrors(t *testing.T) {
+	p := testPool(t)
+	request := p.messages["orders.v1.GetOrderRequest"]
+	order := p.messages["orders.v1.Order"]
+
+	tests := []struct {
+		message *Message
+		value   interface{}
+		want    string
+	}{
+		{request, map[string]interface{}{"nope": 1}, `orders.v1.GetOrderRequest has no field "nope"`},
+		{request, map[string]interface{}{"order_id": 1}, "order_id: expected a string, got int"},
+		{request, map[string]interface{}{"quantities": []interface{}{1 << 40}}, "quantities: [0]: expected a 32-bit integer, got 1099511627776"},
+		{request, map[string]interface{}{"quantities": []interface{}{1.5}}, "expected a whole number, got 1.5"},
+		{request, map[string]interface{}{"labels": []interface{}{"a"}}, "labels: expected an object"},
+		{request, map[string]interface{}{"rush": "yes"}, "rush: expected true or false, got yes"},
+		{request, []interface{}{}, "message must be an object"},
+		{order, map[string]interface{}{"status": "LOST"}, `orders.v1.Order.Status has no value "LOST"`},
+		{order, map[string]interface{}{"signature": "not base64!"}, "expected a base64 string"},
+		{order, map[string]interface{}{"total": map[string]interface{}{"units": "ten"}}, "total: units: expected a 64-bit integer, got ten"},
+	}
+
+	for _, tt := range tests {
+		if _, err := tt.message.Marshal(tt.value); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Marshal(%v): expected error containing %q, got %v", tt.value, tt.want, err)
+		}
+	}
+}
+
+func TestMessage_RoundTrip(t *testing.T) {
+	order := testPool(t).messages["orders.v1.Order"]
+
+	data, err := order.Marshal(map[string]interface{}{
+		"id":        "A1",
+		"status":    2,
+		"total":     map[string]interface{}{"currency": "EUR", "units": 1250},
+		"tags":      []interface{}{"a", "b"},
+		"signature": "AQI=",
+		"weight":    1.5,
+		"delta":     -3,
+	})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	got, err := order.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"id":        "A1",
+		"status":    "SHIPPED",
+		"total":     map[string]interface{}{"currency": "EUR", "units": "1250"},
+		"tags":      []interface{}{"a", "b"},
+		"signature": "AQI=",
+		"weight":    1.5,
+		"delta":     int64(-3),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestMessage_UnmarshalMapsAndUnknownFields(t *testing.T) {
+	request := testPool(t).messages["orders.v1.GetOrderRequest"]
+
+	// Unpacked repeated values, two map entries and an unknown field 9
+	data := []byte{
+		0x10, 0x05, 0x10, 0x06,
+		0x1a, 0x05, 0x0a, 0x01, 'a', 0x12, 0x00,
+		0x1a, 0x05, 0x0a, 0x01, 'b', 0x12, 0x00,
+		0x48, 0x01,
+	}
+	got, err := request.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"quantities": []interface{}{int64(5), int64(6)},
+		"labels":     map[string]interface{}{"a": "", "b": ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if _, err := request.Unmarshal([]byte{0x0a, 0x05, 'A'}); err == nil {
+		t.Error("Expected a truncated message to fail")
+	}
+}
+
+func TestSplitMethod(t *testing.T) {
+	tests := []struct {
+		name, service, method string
+	}{
+		{"orders.v1.Orders/GetOrder", "orders.v1.Orders", "GetOrder"},
+		{"/orders.v1.Orders/GetOrder", "orders.v1.Orders", "GetOrder"},
+		{"orders.v1.Orders.GetOrder", "orders.v1.Orders", "GetOrder"},
+		{"GetOrder", "", ""},
+		{"orders.v1.Orders/", "", ""},
+	}
+	for _, tt := range tests {
+		service, method, ok := SplitMethod(tt.name)
+		if service != tt.service || method != tt.method || ok != (tt.service != "") {
+			t.Errorf("SplitMethod(%q) = %q, %q, %v", tt.name, service, method, ok)
+		}
+	}
+}
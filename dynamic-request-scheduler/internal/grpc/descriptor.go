@@ -0,0 +1,408 @@
+package grpc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Field types of google.protobuf.FieldDescriptorProto
+const (
+	typeDouble   = 1
+	typeFloat    = 2
+	typeInt64    = 3
+	typeUint64   = 4
+	typeInt32    = 5
+	typeFixed64  = 6
+	typeFixed32  = 7
+	typeBool     = 8
+	typeString   = 9
+	typeGroup    = 10
+	typeMessage  = 11
+	typeBytes    = 12
+	typeUint32   = 13
+	typeEnum     = 14
+	typeSfixed32 = 15
+	typeSfixed64 = 16
+	typeSint32   = 17
+	typeSint64   = 18
+)
+
+// typeNames are the .proto names of the scalar field types
+var typeNames = map[int]string{
+	typeDouble: "double", typeFloat: "float", typeInt64: "int64", typeUint64: "uint64",
+	typeInt32: "int32", typeFixed64: "fixed64", typeFixed32: "fixed32", typeBool: "bool",
+	typeString: "string", typeGroup: "group", typeBytes: "bytes", typeUint32: "uint32",
+	typeSfixed32: "sfixed32", typeSfixed64: "sfixed64", typeSint32: "sint32", typeSint64: "sint64",
+}
+
+// labelRepeated is the FieldDescriptorProto label of repeated fields
+const labelRepeated = 3
+
+// Service is a gRPC service described by server reflection
+type Service struct {
+	// Name is the full name, e.g. orders.v1.Orders
+	Name    string
+	Methods []*Method
+}
+
+// Method is a method of a service
+type Method struct {
+	Name    string
+	Service string
+	Input   *Message
+	Output  *Message
+
+	ClientStreaming bool
+	ServerStreaming bool
+
+	inputType, outputType string
+}
+
+// FullName returns the method's name as called, e.g. orders.v1.Orders/GetOrder
+func (m *Method) FullName() string {
+	return m.Service + "/" + m.Name
+}
+
+// Unary reports whether the method takes and returns a single message, the
+// only kind a scheduled request can call
+func (m *Method) Unary() bool {
+	return !m.ClientStreaming && !m.ServerStreaming
+}
+
+// Message is a message type
+type Message struct {
+	// Name is the full name, e.g. orders.v1.Order
+	Name   string
+	Fields []*Field
+
+	// mapEntry is set for the entry types generated for map fields
+	mapEntry bool
+}
+
+// Field is a field of a message
+type Field struct {
+	Name     string
+	JSONName string
+	Number   int
+	Type     int
+	Repeated bool
+
+	// Optional is set for proto3 optional fields
+	Optional bool
+
+	// Message or Enum is the field's type for message and enum fields
+	Message *Message
+	Enum    *Enum
+
+	typeRef string
+}
+
+// IsMap reports whether the field is a map, a repeated field of an entry
+// type with key and value fields
+func (f *Field) IsMap() bool {
+	return f.Repeated && f.Message != nil && f.Message.mapEntry
+}
+
+// field returns the field of m numbered number, or nil
+func (m *Message) field(number int) *Field {
+	for _, field := range m.Fields {
+		if field.Number == number {
+			return field
+		}
+	}
+	return nil
+}
+
+// fieldNamed returns the field of m with the given proto or JSON name, or nil
+func (m *Message) fieldNamed(name string) *Field {
+	for _, field := range m.Fields {
+		if field.Name == name || field.JSONName == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// Enum is an enum type
+type Enum struct {
+	Name   string
+	Values []EnumValue
+}
+
+// EnumValue is a named value of an enum
+type EnumValue struct {
+	Name   string
+	Number int32
+}
+
+// pool holds the types of the file descriptors loaded for one lookup, keyed
+// by full name
+type pool struct {
+	files    map[string]bool
+	services map[string]*Service
+	messages map[string]*Message
+	enums    map[string]*Enum
+}
+
+func newPool() *pool {
+	return &pool{
+		files:    make(map[string]bool),
+		services: make(map[string]*Service),
+		messages: make(map[string]*Message),
+		enums:    make(map[string]*Enum),
+	}
+}
+
+// addFile adds the types of an encoded FileDescriptorProto and returns the
+// names of the files it imports
+func (p *pool) addFile(data []byte) ([]string, error) {
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file descriptor: %w", err)
+	}
+
+	var name, pkg string
+	var dependencies []string
+	for _, field := range fields {
+		switch field.number {
+		case 1:
+			name = string(field.bytes)
+		case 2:
+			pkg = string(field.bytes)
+		case 3:
+			dependencies = append(dependencies, string(field.bytes))
+		}
+	}
+	if p.files[name] {
+		return nil, nil
+	}
+	p.files[name] = true
+
+	for _, field := range fields {
+		switch field.number {
+		case 4:
+			err = p.addMessage(pkg, field.bytes)
+		case 5:
+			err = p.addEnum(pkg, field.bytes)
+		case 6:
+			err = p.addService(pkg, field.bytes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid file descriptor %s: %w", name, err)
+		}
+	}
+	return dependencies, nil
+}
+
+// addMessage adds an encoded DescriptorProto and the types nested in it
+func (p *pool) addMessage(scope string, data []byte) error {
+	fields, err := readFields(data)
+	if err != nil {
+		return err
+	}
+
+	message := &Message{}
+	for _, field := range fields {
+		if field.number == 1 {
+			message.Name = qualify(scope, string(field.bytes))
+		}
+	}
+	p.messages[message.Name] = message
+
+	for _, field := range fields {
+		switch field.number {
+		case 2:
+			f, err := parseField(field.bytes)
+			if err != nil {
+				return err
+			}
+			message.Fields = append(message.Fields, f)
+		case 3:
+			err = p.addMessage(message.Name, field.bytes)
+		case 4:
+			err = p.addEnum(message.Name, field.bytes)
+		case 7:
+			// MessageOptions { bool map_entry = 7; }
+			options, err := readFields(field.bytes)
+			if err != nil {
+				return err
+			}
+			for _, option := range options {
+				if option.number == 7 && option.wireType == wireVarint {
+					message.mapEntry = option.value != 0
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseField decodes an encoded FieldDescriptorProto
+func parseField(data []byte) (*Field, error) {
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Field{}
+	for _, field := range fields {
+		switch field.number {
+		case 1:
+			f.Name = string(field.bytes)
+		case 3:
+			f.Number = int(field.value)
+		case 4:
+			f.Repeated = field.value == labelRepeated
+		case 5:
+			f.Type = int(field.value)
+		case 6:
+			f.typeRef = strings.TrimPrefix(string(field.bytes), ".")
+		case 10:
+			f.JSONName = string(field.bytes)
+		case 17:
+			f.Optional = field.value != 0
+		}
+	}
+	if f.JSONName == "" {
+		f.JSONName = jsonName(f.Name)
+	}
+	return f, nil
+}
+
+// addEnum adds an encoded EnumDescriptorProto
+func (p *pool) addEnum(scope string, data []byte) error {
+	fields, err := readFields(data)
+	if err != nil {
+		return err
+	}
+
+	enum := &Enum{}
+	for _, field := range fields {
+		switch field.number {
+		case 1:
+			enum.Name = qualify(scope, string(field.bytes))
+		case 2:
+			// EnumValueDescriptorProto { string name = 1; int32 number = 2; }
+			valueFields, err := readFields(field.bytes)
+			if err != nil {
+				return err
+			}
+			var value EnumValue
+			for _, valueField := range valueFields {
+				switch valueField.number {
+				case 1:
+					value.Name = string(valueField.bytes)
+				case 2:
+					value.Number = int32(valueField.value)
+				}
+			}
+			enum.Values = append(enum.Values, value)
+		}
+	}
+	p.enums[enum.Name] = enum
+	return nil
+}
+
+// addService adds an encoded ServiceDescriptorProto
+func (p *pool) addService(pkg string, data []byte) error {
+	fields, err := readFields(data)
+	if err != nil {
+		return err
+	}
+
+	service := &Service{}
+	for _, field := range fields {
+		if field.number == 1 {
+			service.Name = qualify(pkg, string(field.bytes))
+		}
+	}
+	for _, field := range fields {
+		if field.number != 2 {
+			continue
+		}
+		methodFields, err := readFields(field.bytes)
+		if err != nil {
+			return err
+		}
+		method := &Method{Service: service.Name}
+		for _, methodField := range methodFields {
+			switch methodField.number {
+			case 1:
+				method.Name = string(methodField.bytes)
+			case 2:
+				method.inputType = strings.TrimPrefix(string(methodField.bytes), ".")
+			case 3:
+				method.outputType = strings.TrimPrefix(string(methodField.bytes), ".")
+			case 5:
+				method.ClientStreaming = methodField.value != 0
+			case 6:
+				method.ServerStreaming = methodField.value != 0
+			}
+		}
+		service.Methods = append(service.Methods, method)
+	}
+	p.services[service.Name] = service
+	return nil
+}
+
+// link resolves the type names of fields and methods once every file they
+// refer to is loaded
+func (p *pool) link() error {
+	for _, message := range p.messages {
+		for _, field := range message.Fields {
+			if field.typeRef == "" || field.Message != nil || field.Enum != nil {
+				continue
+			}
+			switch field.Type {
+			case typeMessage, typeGroup:
+				field.Message = p.messages[field.typeRef]
+			case typeEnum:
+				field.Enum = p.enums[field.typeRef]
+			}
+			if field.Message == nil && field.Enum == nil {
+				return fmt.Errorf("%s.%s has unknown type %s", message.Name, field.Name, field.typeRef)
+			}
+		}
+	}
+	for _, service := range p.services {
+		for _, method := range service.Methods {
+			method.Input = p.messages[method.inputType]
+			method.Output = p.messages[method.outputType]
+			if method.Input == nil || method.Output == nil {
+				return fmt.Errorf("%s refers to a message type the server did not describe", method.FullName())
+			}
+		}
+	}
+	return nil
+}
+
+// qualify joins a scope and a name into a full name
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// jsonName converts a field name to lowerCamelCase, as protoc does when a
+// descriptor leaves out json_name
+func jsonName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+		case upper && 'a' <= r && r <= 'z':
+			b.WriteRune(r - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteRune(r)
+			upper = false
+		}
+	}
+	return b.String()
+}
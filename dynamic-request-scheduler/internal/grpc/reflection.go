@@ -0,0 +1,213 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Paths of the server reflection service; older servers only offer v1alpha
+const (
+	reflectionPath      = "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"
+	reflectionAlphaPath = "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"
+)
+
+// Fields of ServerReflectionRequest and ServerReflectionResponse
+const (
+	requestFileByFilename       = 3
+	requestFileContainingSymbol = 4
+	requestListServices         = 7
+
+	responseFileDescriptors = 4
+	responseServices        = 6
+	responseError           = 7
+)
+
+// reflect sends a ServerReflectionRequest with one string field set and
+// returns the matching field of the response
+func (c *Client) reflect(ctx context.Context, target Target, number int, value string, want int) ([]byte, error) {
+	request := appendString(nil, number, value)
+	data, _, err := c.call(ctx, target, reflectionPath, nil, request)
+	var status *StatusError
+	if errors.As(err, &status) && status.Code == codeUnimplemented {
+		data, _, err = c.call(ctx, target, reflectionAlphaPath, nil, request)
+		if errors.As(err, &status) && status.Code == codeUnimplemented {
+			return nil, fmt.Errorf("the server does not offer reflection (register the grpc.reflection service to describe it)")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reflection response: %w", err)
+	}
+	for _, field := range fields {
+		switch field.number {
+		case want:
+			return field.bytes, nil
+		case responseError:
+			// ErrorResponse { int32 error_code = 1; string error_message = 2; }
+			errorFields, err := readFields(field.bytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid reflection response: %w", err)
+			}
+			status := &StatusError{}
+			for _, errorField := range errorFields {
+				switch errorField.number {
+				case 1:
+					status.Code = int(int32(errorField.value))
+				case 2:
+					status.Message = string(errorField.bytes)
+				}
+			}
+			return nil, status
+		}
+	}
+	return nil, fmt.Errorf("invalid reflection response: no result")
+}
+
+// load adds the file defining symbol, and every file it imports, to p
+func (c *Client) load(ctx context.Context, target Target, p *pool, symbol string) error {
+	data, err := c.reflect(ctx, target, requestFileContainingSymbol, symbol, responseFileDescriptors)
+	var status *StatusError
+	if errors.As(err, &status) && status.Code == codeNotFound {
+		return fmt.Errorf("the server does not know %s", symbol)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Servers usually send the imports along with the file; any that are
+	// missing are asked for by name
+	var pending []string
+	for {
+		deps, err := p.addFiles(data)
+		if err != nil {
+			return err
+		}
+		pending = append(pending, deps...)
+		for len(pending) > 0 && p.files[pending[0]] {
+			pending = pending[1:]
+		}
+		if len(pending) == 0 {
+			return p.link()
+		}
+		if data, err = c.reflect(ctx, target, requestFileByFilename, pending[0], responseFileDescriptors); err != nil {
+			return fmt.Errorf("failed to load %s: %w", pending[0], err)
+		}
+	}
+}
+
+// addFiles adds each file of an encoded FileDescriptorResponse to p and
+// returns the files they import
+func (p *pool) addFiles(data []byte) ([]string, error) {
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reflection response: %w", err)
+	}
+	var dependencies []string
+	for _, field := range fields {
+		if field.number != 1 {
+			continue
+		}
+		deps, err := p.addFile(field.bytes)
+		if err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, deps...)
+	}
+	return dependencies, nil
+}
+
+// Services lists the services of the server, with their methods, leaving
+// out the reflection service itself
+func (c *Client) Services(ctx context.Context, target Target) ([]*Service, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	data, err := c.reflect(ctx, target, requestListServices, "*", responseServices)
+	if err != nil {
+		return nil, err
+	}
+	// ListServiceResponse { repeated ServiceResponse service = 1; } with
+	// ServiceResponse { string name = 1; }
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reflection response: %w", err)
+	}
+	var names []string
+	for _, field := range fields {
+		serviceFields, err := readFields(field.bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reflection response: %w", err)
+		}
+		for _, serviceField := range serviceFields {
+			if serviceField.number == 1 && !strings.HasPrefix(string(serviceField.bytes), "grpc.reflection.") {
+				names = append(names, string(serviceField.bytes))
+			}
+		}
+	}
+	sort.Strings(names)
+
+	p := newPool()
+	services := make([]*Service, 0, len(names))
+	for _, name := range names {
+		if p.services[name] == nil {
+			if err := c.load(ctx, target, p, name); err != nil {
+				return nil, err
+			}
+		}
+		if service := p.services[name]; service != nil {
+			services = append(services, service)
+		}
+	}
+	return services, nil
+}
+
+// Symbol is a service, method, message or enum described by the server
+type Symbol interface {
+	// Report writes the symbol in .proto syntax; methods and messages are
+	// followed by the message and enum types they use
+	Report(w io.Writer)
+}
+
+// Describe looks up a service, method, message or enum by its full name.
+// Methods may be named as service/method or service.method.
+func (c *Client) Describe(ctx context.Context, target Target, name string) (Symbol, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	symbol := strings.Replace(strings.TrimPrefix(name, "/"), "/", ".", 1)
+	p := newPool()
+	err := c.load(ctx, target, p, symbol)
+	if err != nil {
+		// Not every server finds methods by name; look for their service
+		service, _, ok := SplitMethod(symbol)
+		if !ok || c.load(ctx, target, p, service) != nil {
+			return nil, err
+		}
+	}
+
+	if service := p.services[symbol]; service != nil {
+		return service, nil
+	}
+	if message := p.messages[symbol]; message != nil {
+		return message, nil
+	}
+	if enum := p.enums[symbol]; enum != nil {
+		return enum, nil
+	}
+	if serviceName, methodName, ok := SplitMethod(symbol); ok && p.services[serviceName] != nil {
+		for _, method := range p.services[serviceName].Methods {
+			if method.Name == methodName {
+				return method, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("the server does not know %s", name)
+}
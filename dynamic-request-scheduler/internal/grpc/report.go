@@ -0,0 +1,126 @@
+package grpc
+
+import (
+	"fmt"
+	"io"
+)
+
+// Report writes the service and its methods, marking those a scheduled
+// request cannot call
+func (s *Service) Report(w io.Writer) {
+	fmt.Fprintf(w, "service %s {\n", s.Name)
+	for _, method := range s.Methods {
+		fmt.Fprintf(w, "  %s", method.signature(method.Name))
+		if !method.Unary() {
+			fmt.Fprint(w, "  // streaming, cannot be scheduled")
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// Report writes the method followed by its request and response messages
+func (m *Method) Report(w io.Writer) {
+	fmt.Fprintln(w, m.signature(m.FullName()))
+	fmt.Fprintln(w)
+	writeTypes(w, m.Input, m.Output)
+}
+
+// signature returns the method's rpc line, naming it name
+func (m *Method) signature(name string) string {
+	input, output := m.Input.Name, m.Output.Name
+	if m.ClientStreaming {
+		input = "stream " + input
+	}
+	if m.ServerStreaming {
+		output = "stream " + output
+	}
+	return fmt.Sprintf("rpc %s(%s) returns (%s);", name, input, output)
+}
+
+// Report writes the message followed by the types of its fields
+func (m *Message) Report(w io.Writer) {
+	writeTypes(w, m)
+}
+
+// Report writes the enum and its values
+func (e *Enum) Report(w io.Writer) {
+	writeTypes(w, e)
+}
+
+// writeTypes writes each message or enum once, in the order they are first
+// used, starting from roots, with a blank line between them
+func writeTypes(w io.Writer, roots ...interface{}) {
+	seen := make(map[string]bool)
+	queue := roots
+	for i := 0; i < len(queue); i++ {
+		switch t := queue[i].(type) {
+		case *Message:
+			if seen[t.Name] {
+				continue
+			}
+			if len(seen) > 0 {
+				fmt.Fprintln(w)
+			}
+			seen[t.Name] = true
+			fmt.Fprintf(w, "message %s {\n", t.Name)
+			for _, field := range t.Fields {
+				fmt.Fprintf(w, "  %s%s %s = %d;\n", field.label(), field.typeName(), field.Name, field.Number)
+				switch {
+				case field.IsMap():
+					for _, entryField := range field.Message.Fields {
+						if entryField.Message != nil {
+							queue = append(queue, entryField.Message)
+						} else if entryField.Enum != nil {
+							queue = append(queue, entryField.Enum)
+						}
+					}
+				case field.Message != nil:
+					queue = append(queue, field.Message)
+				case field.Enum != nil:
+					queue = append(queue, field.Enum)
+				}
+			}
+			fmt.Fprintln(w, "}")
+		case *Enum:
+			if seen[t.Name] {
+				continue
+			}
+			if len(seen) > 0 {
+				fmt.Fprintln(w)
+			}
+			seen[t.Name] = true
+			fmt.Fprintf(w, "enum %s {\n", t.Name)
+			for _, value := range t.Values {
+				fmt.Fprintf(w, "  %s = %d;\n", value.Name, value.Number)
+			}
+			fmt.Fprintln(w, "}")
+		}
+	}
+}
+
+// label returns the field's label with a trailing space, if it has one
+func (f *Field) label() string {
+	switch {
+	case f.IsMap():
+		return ""
+	case f.Repeated:
+		return "repeated "
+	case f.Optional:
+		return "optional "
+	}
+	return ""
+}
+
+// typeName returns the field's type as written in a .proto file
+func (f *Field) typeName() string {
+	switch {
+	case f.IsMap():
+		return fmt.Sprintf("map<%s, %s>", f.Message.field(1).typeName(), f.Message.field(2).typeName())
+	case f.Message != nil:
+		return f.Message.Name
+	case f.Enum != nil:
+		return f.Enum.Name
+	}
+	return typeNames[f.Type]
+}
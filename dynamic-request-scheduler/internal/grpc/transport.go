@@ -0,0 +1,23 @@
+//go:build go1.24
+
+package grpc
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// newTransport returns an HTTP/2-only transport, over TLS or cleartext
+// (h2c). Go 1.24 added the http.Protocols that make this possible without
+// golang.org/x/net.
+func newTransport(useTLS bool) http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Protocols = new(http.Protocols)
+	if useTLS {
+		transport.TLSClientConfig = &tls.Config{NextProtos: []string{"h2"}}
+		transport.Protocols.SetHTTP2(true)
+	} else {
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	}
+	return transport
+}
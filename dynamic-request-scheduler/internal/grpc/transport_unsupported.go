@@ -0,0 +1,23 @@
+//go:build !go1.24
+
+package grpc
+
+import (
+	"errors"
+	"net/http"
+)
+
+// errUnsupported is returned for every call by builds without HTTP/2 support
+var errUnsupported = errors.New("gRPC requests need a build with Go 1.24 or later")
+
+// newTransport returns a transport that fails every request, as HTTP/2
+// without TLS needs Go 1.24
+func newTransport(bool) http.RoundTripper {
+	return unsupportedTransport{}
+}
+
+type unsupportedTransport struct{}
+
+func (unsupportedTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errUnsupported
+}
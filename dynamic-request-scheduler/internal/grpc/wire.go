@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Wire types of the protobuf encoding
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// errMalformed is returned for messages that are not valid protobuf
+var errMalformed = errors.New("malformed protobuf message")
+
+// wireField is one field read from an encoded message
+type wireField struct {
+	number   int
+	wireType int
+
+	// value holds varint, fixed64 and fixed32 values
+	value uint64
+
+	// bytes holds length-delimited values
+	bytes []byte
+}
+
+// readFields decodes the fields of an encoded message in the order they
+// appear
+func readFields(data []byte) ([]wireField, error) {
+	var fields []wireField
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 || key>>3 == 0 {
+			return nil, errMalformed
+		}
+		data = data[n:]
+
+		field := wireField{number: int(key >> 3), wireType: int(key & 7)}
+		switch field.wireType {
+		case wireVarint:
+			if field.value, n = binary.Uvarint(data); n <= 0 {
+				return nil, errMalformed
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return nil, errMalformed
+			}
+			field.value = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return nil, errMalformed
+			}
+			field.bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case wireFixed32:
+			if len(data) < 4 {
+				return nil, errMalformed
+			}
+			field.value = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d in field %d (groups are not supported)", field.wireType, field.number)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// appendTag appends the key of a field
+func appendTag(b []byte, number, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(number)<<3|uint64(wireType))
+}
+
+// appendBytes appends a length-delimited field
+func appendBytes(b []byte, number int, value []byte) []byte {
+	b = appendTag(b, number, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// appendString appends a string field
+func appendString(b []byte, number int, value string) []byte {
+	return appendBytes(b, number, []byte(value))
+}
@@ -0,0 +1,408 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS executions (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id        TEXT    NOT NULL DEFAULT '',
+	execution_id  TEXT    NOT NULL DEFAULT '',
+	request_name  TEXT    NOT NULL,
+	method        TEXT    NOT NULL,
+	url           TEXT    NOT NULL,
+	headers       TEXT,
+	body          TEXT,
+	scheduled_for INTEGER,
+	started_at    INTEGER NOT NULL,
+	duration_ms   REAL    NOT NULL,
+	status_code   INTEGER NOT NULL,
+	status        TEXT,
+	error         TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_executions_name_started ON executions (request_name, started_at);
+CREATE TABLE IF NOT EXISTS runs (
+	run_id      TEXT    PRIMARY KEY,
+	seed        INTEGER NOT NULL,
+	started_at  INTEGER NOT NULL,
+	clock_start INTEGER NOT NULL,
+	time_scale  REAL    NOT NULL,
+	args        TEXT
+);
+CREATE TABLE IF NOT EXISTS request_state (
+	request_id TEXT    PRIMARY KEY,
+	state      TEXT    NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+`
+
+// addedColumns lists columns introduced after the initial schema, which are
+// added to databases created by older versions when they are opened
+var addedColumns = []struct{ name, definition string }{
+	{"run_id", "TEXT NOT NULL DEFAULT ''"},
+	{"execution_id", "TEXT NOT NULL DEFAULT ''"},
+	{"request_id", "TEXT NOT NULL DEFAULT ''"},
+	{"unmet", "TEXT NOT NULL DEFAULT ''"},
+	{"expected_status", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// Store persists execution results to an embedded SQLite database
+type Store struct {
+	db *sql.DB
+}
+
+// Entry is a single execution read back from the history database
+type Entry struct {
+	ID           int64
+	RunID        string
+	ExecutionID  string
+	RequestName  string
+	RequestID    string
+	Method       string
+	URL          string
+	Headers      map[string]string
+	Body         interface{}
+	ScheduledFor time.Time
+	StartedAt    time.Time
+	Duration     time.Duration
+	StatusCode   int
+	Status       string
+	Error        string
+
+	// Unmet describes each expectation the response did not meet
+	Unmet []string
+
+	// ExpectedStatus is set when a status outside 2xx was accepted by the
+	// request's expect section
+	ExpectedStatus bool
+}
+
+// Run describes how a scheduler run was started, so it can be replayed
+type Run struct {
+	ID   string
+	Seed int64
+
+	// StartedAt is when the run started and ClockStart the time its clock
+	// read then, which differs on a virtual clock running TimeScale times
+	// faster than real time
+	StartedAt  time.Time
+	ClockStart time.Time
+	TimeScale  float64
+
+	// Args are the command line options the run was started with
+	Args []string
+}
+
+// Filter narrows the entries returned by Query
+type Filter struct {
+	Name       string
+	RunID      string
+	Since      time.Time
+	FailedOnly bool
+	Limit      int
+}
+
+// Open opens (or creates) the history database at path
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %w", err)
+	}
+
+	// SQLite only supports a single writer; serialize access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize history schema: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate history schema: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// migrate adds any columns missing from an existing executions table
+func migrate(db *sql.DB) error {
+	rows, err := db.Query("PRAGMA table_info(executions)")
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range addedColumns {
+		if existing[column.name] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE executions ADD COLUMN %s %s", column.name, column.definition)); err != nil {
+			return err
+		}
+	}
+
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_executions_run ON executions (run_id)")
+	return err
+}
+
+// Close closes the underlying database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record implements engine.ResultRecorder
+func (s *Store) Record(result engine.ExecutionResult) error {
+	headers, err := json.Marshal(result.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode headers: %w", err)
+	}
+
+	var body []byte
+	if result.Body != nil {
+		body, err = json.Marshal(result.Body)
+		if err != nil {
+			return fmt.Errorf("failed to encode body: %w", err)
+		}
+	}
+
+	var unmet []byte
+	if len(result.Unmet) > 0 {
+		unmet, err = json.Marshal(result.Unmet)
+		if err != nil {
+			return fmt.Errorf("failed to encode unmet expectations: %w", err)
+		}
+	}
+
+	var scheduledFor int64
+	if !result.ScheduledFor.IsZero() {
+		scheduledFor = result.ScheduledFor.UnixNano()
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO executions
+			(run_id, execution_id, request_name, request_id, method, url, headers, body, scheduled_for, started_at, duration_ms, status_code, status, error, unmet, expected_status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		result.RunID,
+		result.ExecutionID,
+		result.RequestName,
+		result.RequestID,
+		result.Method,
+		result.URL,
+		string(headers),
+		string(body),
+		scheduledFor,
+		result.StartedAt.UnixNano(),
+		float64(result.Duration)/float64(time.Millisecond),
+		result.StatusCode,
+		result.Status,
+		result.Error,
+		string(unmet),
+		result.ExpectedStatus,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert execution: %w", err)
+	}
+
+	return nil
+}
+
+// RecordRun stores how a run was started, replacing any earlier record of it
+func (s *Store) RecordRun(run Run) error {
+	args, err := json.Marshal(run.Args)
+	if err != nil {
+		return fmt.Errorf("failed to encode run arguments: %w", err)
+	}
+
+	_, err = s.db.Exec(
+		`INSERT OR REPLACE INTO runs (run_id, seed, started_at, clock_start, time_scale, args) VALUES (?, ?, ?, ?, ?, ?)`,
+		run.ID, run.Seed, run.StartedAt.UnixNano(), run.ClockStart.UnixNano(), run.TimeScale, string(args),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert run: %w", err)
+	}
+	return nil
+}
+
+// Run returns the recorded run with the given ID
+func (s *Store) Run(id string) (Run, error) {
+	var (
+		run        Run
+		startedAt  int64
+		clockStart int64
+		args       sql.NullString
+	)
+	err := s.db.QueryRow(`SELECT run_id, seed, started_at, clock_start, time_scale, args FROM runs WHERE run_id = ?`, id).
+		Scan(&run.ID, &run.Seed, &startedAt, &clockStart, &run.TimeScale, &args)
+	if err == sql.ErrNoRows {
+		return Run{}, fmt.Errorf("run %s not found in history", id)
+	}
+	if err != nil {
+		return Run{}, fmt.Errorf("failed to read run: %w", err)
+	}
+
+	run.StartedAt = time.Unix(0, startedAt).UTC()
+	run.ClockStart = time.Unix(0, clockStart).UTC()
+	if args.String != "" {
+		if err := json.Unmarshal([]byte(args.String), &run.Args); err != nil {
+			return Run{}, fmt.Errorf("failed to decode arguments of run %s: %w", id, err)
+		}
+	}
+	return run, nil
+}
+
+// LoadState implements engine.StateStore
+func (s *Store) LoadState(requestID string) (map[string]interface{}, error) {
+	var encoded string
+	err := s.db.QueryRow(`SELECT state FROM request_state WHERE request_id = ?`, requestID).Scan(&encoded)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	// Keep numbers exact, as they were captured
+	decoder := json.NewDecoder(strings.NewReader(encoded))
+	decoder.UseNumber()
+	var state map[string]interface{}
+	if err := decoder.Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode state of %s: %w", requestID, err)
+	}
+	return state, nil
+}
+
+// SaveState implements engine.StateStore
+func (s *Store) SaveState(requestID string, state map[string]interface{}) error {
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	_, err = s.db.Exec(
+		`INSERT OR REPLACE INTO request_state (request_id, state, updated_at) VALUES (?, ?, ?)`,
+		requestID, string(encoded), time.Now().UnixNano(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// Query returns recorded executions matching the filter, most recent first
+func (s *Store) Query(filter Filter) ([]Entry, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Name != "" {
+		conditions = append(conditions, "request_name = ?")
+		args = append(args, filter.Name)
+	}
+	if filter.RunID != "" {
+		conditions = append(conditions, "run_id = ?")
+		args = append(args, filter.RunID)
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "started_at >= ?")
+		args = append(args, filter.Since.UnixNano())
+	}
+	if filter.FailedOnly {
+		conditions = append(conditions, "(error != '' OR ((status_code < 200 OR status_code >= 300) AND expected_status = 0) OR unmet != '')")
+	}
+
+	query := `SELECT id, run_id, execution_id, request_name, request_id, method, url, headers, body, scheduled_for, started_at,
+		duration_ms, status_code, status, error, unmet, expected_status FROM executions`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY started_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var (
+			entry        Entry
+			headers      string
+			body         string
+			scheduledFor int64
+			startedAt    int64
+			durationMs   float64
+			unmet        string
+		)
+
+		err := rows.Scan(&entry.ID, &entry.RunID, &entry.ExecutionID, &entry.RequestName, &entry.RequestID, &entry.Method, &entry.URL, &headers, &body,
+			&scheduledFor, &startedAt, &durationMs, &entry.StatusCode, &entry.Status, &entry.Error, &unmet, &entry.ExpectedStatus)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history row: %w", err)
+		}
+
+		if headers != "" {
+			if err := json.Unmarshal([]byte(headers), &entry.Headers); err != nil {
+				return nil, fmt.Errorf("failed to decode headers for execution %d: %w", entry.ID, err)
+			}
+		}
+		if body != "" {
+			if err := json.Unmarshal([]byte(body), &entry.Body); err != nil {
+				return nil, fmt.Errorf("failed to decode body for execution %d: %w", entry.ID, err)
+			}
+		}
+		if unmet != "" {
+			if err := json.Unmarshal([]byte(unmet), &entry.Unmet); err != nil {
+				return nil, fmt.Errorf("failed to decode unmet expectations for execution %d: %w", entry.ID, err)
+			}
+		}
+		if scheduledFor != 0 {
+			entry.ScheduledFor = time.Unix(0, scheduledFor).UTC()
+		}
+		entry.StartedAt = time.Unix(0, startedAt).UTC()
+		entry.Duration = time.Duration(durationMs * float64(time.Millisecond))
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// Success returns true if the recorded execution completed with a 2xx or
+// expected response that met its expectations
+func (e *Entry) Success() bool {
+	statusOK := (e.StatusCode >= 200 && e.StatusCode < 300) || e.ExpectedStatus
+	return e.Error == "" && statusOK && len(e.Unmet) == 0
+}
@@ -0,0 +1,303 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	store, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestStore_RecordAndQuery(t *testing.T) {
+	store := openTestStore(t)
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	results := []engine.ExecutionResult{
+		{
+			RunID:       "run-a",
+			ExecutionID: "exec-1",
+			RequestName: "health",
+			RequestID:   "health-check",
+			Method:      "GET",
+			URL:         "http://localhost/health",
+			Headers:     map[string]string{"X-Test": "1"},
+			StartedAt:   base,
+			Duration:    150 * time.Millisecond,
+			StatusCode:  200,
+			Status:      "200 OK",
+		},
+		{
+			RequestName: "sync",
+			Method:      "POST",
+			URL:         "http://localhost/sync",
+			Body:        map[string]interface{}{"id": "abc"},
+			StartedAt:   base.Add(time.Minute),
+			Duration:    2 * time.Second,
+			StatusCode:  500,
+			Status:      "500 Internal Server Error",
+		},
+		{
+			RequestName: "health",
+			Method:      "GET",
+			URL:         "http://localhost/health",
+			StartedAt:   base.Add(2 * time.Minute),
+			Duration:    10 * time.Millisecond,
+			Error:       "connection refused",
+		},
+	}
+
+	for _, result := range results {
+		if err := store.Record(result); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	all, err := store.Query(Filter{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(all))
+	}
+	if !all[0].StartedAt.Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("Expected most recent entry first, got %v", all[0].StartedAt)
+	}
+
+	last := all[2]
+	if last.Headers["X-Test"] != "1" {
+		t.Errorf("Expected header X-Test=1, got %v", last.Headers)
+	}
+	if last.Duration != 150*time.Millisecond {
+		t.Errorf("Expected duration 150ms, got %v", last.Duration)
+	}
+	if !last.Success() {
+		t.Error("Expected first execution to be successful")
+	}
+	if last.RunID != "run-a" || last.ExecutionID != "exec-1" {
+		t.Errorf("Expected correlation IDs to round-trip, got %q/%q", last.RunID, last.ExecutionID)
+	}
+	if last.RequestID != "health-check" {
+		t.Errorf("Expected request ID to round-trip, got %q", last.RequestID)
+	}
+
+	body, ok := all[1].Body.(map[string]interface{})
+	if !ok || body["id"] != "abc" {
+		t.Errorf("Expected body to round-trip, got %v", all[1].Body)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{name: "by name", filter: Filter{Name: "health"}, want: 2},
+		{name: "failed only", filter: Filter{FailedOnly: true}, want: 2},
+		{name: "since", filter: Filter{Since: base.Add(30 * time.Second)}, want: 2},
+		{name: "limit", filter: Filter{Limit: 1}, want: 1},
+		{name: "by run", filter: Filter{RunID: "run-a"}, want: 1},
+		{name: "combined", filter: Filter{Name: "health", FailedOnly: true}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := store.Query(tt.filter)
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			if len(entries) != tt.want {
+				t.Errorf("Expected %d entries, got %d", tt.want, len(entries))
+			}
+		})
+	}
+}
+
+func TestStore_SurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	err = store.Record(engine.ExecutionResult{
+		RequestName: "persisted",
+		Method:      "GET",
+		URL:         "http://localhost",
+		StartedAt:   time.Now(),
+		StatusCode:  204,
+	})
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	store.Close()
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer reopened.Close()
+
+	entries, err := reopened.Query(Filter{Name: "persisted"})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry after reopen, got %d", len(entries))
+	}
+}
+
+func TestStore_MigratesOldSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+
+	// Create a database with the schema used before correlation IDs existed
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE executions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, request_name TEXT NOT NULL, method TEXT NOT NULL,
+		url TEXT NOT NULL, headers TEXT, body TEXT, scheduled_for INTEGER, started_at INTEGER NOT NULL,
+		duration_ms REAL NOT NULL, status_code INTEGER NOT NULL, status TEXT, error TEXT);
+		INSERT INTO executions (request_name, method, url, headers, body, scheduled_for, started_at, duration_ms, status_code, status, error)
+		VALUES ('legacy', 'GET', 'http://localhost', 'null', '', 0, 1, 5, 200, '200 OK', '');`)
+	if err != nil {
+		t.Fatalf("Failed to create old schema: %v", err)
+	}
+	db.Close()
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed on old schema: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Record(engine.ExecutionResult{RunID: "run-b", RequestName: "new", StartedAt: time.Now()}); err != nil {
+		t.Fatalf("Record failed after migration: %v", err)
+	}
+
+	entries, err := store.Query(Filter{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[1].RequestName != "legacy" || entries[1].RunID != "" {
+		t.Errorf("Expected legacy row with empty run ID, got %+v", entries[1])
+	}
+	if entries[0].RunID != "run-b" {
+		t.Errorf("Expected new row to carry run ID, got %+v", entries[0])
+	}
+}
+
+func TestStore_State(t *testing.T) {
+	store := openTestStore(t)
+	if state, err := store.LoadState("page"); err != nil || state != nil {
+		t.Errorf("Expected no state before saving, got %v (%v)", state, err)
+	}
+
+	for _, cursor := range []string{"c1", "c2"} {
+		if err := store.SaveState("page", map[string]interface{}{"cursor": cursor, "total": json.Number("12345678901234567890")}); err != nil {
+			t.Fatalf("SaveState failed: %v", err)
+		}
+	}
+	state, err := store.LoadState("page")
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	if state["cursor"] != "c2" || state["total"] != json.Number("12345678901234567890") {
+		t.Errorf("Expected the last saved state with exact numbers, got %v", state)
+	}
+}
+
+func TestStore_RecordRun(t *testing.T) {
+	store := openTestStore(t)
+	run := Run{
+		ID:         "run-a",
+		Seed:       42,
+		StartedAt:  time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC),
+		ClockStart: time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC),
+		TimeScale:  60,
+		Args:       []string{"--config", "config.yaml", "--once"},
+	}
+	if err := store.RecordRun(run); err != nil {
+		t.Fatalf("RecordRun failed: %v", err)
+	}
+
+	got, err := store.Run("run-a")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got.Seed != 42 || !got.StartedAt.Equal(run.StartedAt) || !got.ClockStart.Equal(run.ClockStart) ||
+		got.TimeScale != 60 || len(got.Args) != 3 || got.Args[2] != "--once" {
+		t.Errorf("Expected %+v, got %+v", run, got)
+	}
+
+	if _, err := store.Run("run-b"); err == nil {
+		t.Error("Expected an error for an unknown run")
+	}
+}
+
+func TestStore_UnmetExpectations(t *testing.T) {
+	store := openTestStore(t)
+	now := time.Now()
+
+	if err := store.Record(engine.ExecutionResult{RequestName: "met", StartedAt: now, StatusCode: 200, Status: "200 OK"}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	unmet := []string{"content type text/html, expected json"}
+	if err := store.Record(engine.ExecutionResult{RequestName: "unmet", StartedAt: now, StatusCode: 200, Status: "200 OK", Unmet: unmet}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	failed, err := store.Query(Filter{FailedOnly: true})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(failed) != 1 || failed[0].RequestName != "unmet" {
+		t.Fatalf("Expected only the unmet execution to count as failed, got %+v", failed)
+	}
+	if failed[0].Success() || len(failed[0].Unmet) != 1 || failed[0].Unmet[0] != unmet[0] {
+		t.Errorf("Expected unmet expectations to round-trip, got %v", failed[0].Unmet)
+	}
+}
+
+func TestStore_ExpectedStatus(t *testing.T) {
+	store := openTestStore(t)
+	now := time.Now()
+
+	if err := store.Record(engine.ExecutionResult{RequestName: "gone", StartedAt: now, StatusCode: 404, Status: "404 Not Found", ExpectedStatus: true}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := store.Record(engine.ExecutionResult{RequestName: "broken", StartedAt: now, StatusCode: 404, Status: "404 Not Found"}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	failed, err := store.Query(Filter{FailedOnly: true})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(failed) != 1 || failed[0].RequestName != "broken" {
+		t.Fatalf("Expected only the unexpected 404 to count as failed, got %+v", failed)
+	}
+
+	entries, err := store.Query(Filter{Name: "gone"})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(entries) != 1 || !entries[0].ExpectedStatus || !entries[0].Success() {
+		t.Errorf("Expected the expected 404 to round-trip as a success, got %+v", entries)
+	}
+}
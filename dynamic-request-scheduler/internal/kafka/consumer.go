@@ -0,0 +1,474 @@
+package kafka
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+	"time"
+)
+
+// API keys and versions used by the consumer
+const (
+	apiFetch             = 1
+	apiListOffsets       = 2
+	apiDeleteRecords     = 21
+	fetchVersion         = 4
+	listOffsetsVersion   = 1
+	deleteRecordsVersion = 0
+)
+
+// Start positions for NewConsumer, which are also the special timestamps of
+// the ListOffsets API
+const (
+	OffsetLatest   int64 = -1
+	OffsetEarliest int64 = -2
+)
+
+// fetchMaxBytes bounds the records a fetch returns for each partition. Brokers
+// still return a larger batch when it is the first one, so it is never stuck.
+const fetchMaxBytes = 1 << 20
+
+// errOffsetOutOfRange is the error for a fetch offset the partition no longer
+// or does not yet hold
+const errOffsetOutOfRange brokerError = 1
+
+// Record is a record read from a topic
+type Record struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Timestamp time.Time
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+}
+
+// Consumer reads every partition of a topic without joining a consumer group,
+// so it never commits offsets or takes records from other consumers
+type Consumer struct {
+	client *Client
+	topic  string
+
+	// conns holds a connection to each partition leader, by node ID
+	conns   map[int32]*brokerConn
+	leaders map[int32]int32
+	offsets map[int32]int64
+}
+
+// NewConsumer connects to the leaders of the topic's partitions and starts
+// every partition at start: OffsetEarliest, OffsetLatest or an offset
+func (c *Client) NewConsumer(ctx context.Context, brokers []string, topic string, start int64) (*Consumer, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	conns, meta, err := c.connectLeaders(ctx, brokers, topic)
+	if err != nil {
+		return nil, err
+	}
+	consumer := &Consumer{client: c, topic: topic, conns: conns, leaders: meta.partitions, offsets: make(map[int32]int64)}
+
+	for node, partitions := range consumer.byLeader() {
+		if start >= 0 {
+			for _, p := range partitions {
+				consumer.offsets[p] = start
+			}
+			continue
+		}
+		offsets, err := listOffsets(conns[node], topic, partitions, start)
+		if err != nil {
+			consumer.Close()
+			return nil, err
+		}
+		for p, offset := range offsets {
+			consumer.offsets[p] = offset
+		}
+	}
+	return consumer, nil
+}
+
+// Partitions returns the topic's partitions in order
+func (r *Consumer) Partitions() []int32 {
+	partitions := make([]int32, 0, len(r.leaders))
+	for p := range r.leaders {
+		partitions = append(partitions, p)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+	return partitions
+}
+
+// Poll fetches the records after the last ones returned, waiting up to wait
+// for new records when every partition is caught up. An empty result means
+// none arrived in time.
+func (r *Consumer) Poll(ctx context.Context, wait time.Duration) ([]Record, error) {
+	var records []Record
+	for node, partitions := range r.byLeader() {
+		if err := ctx.Err(); err != nil {
+			return records, err
+		}
+		fetched, err := r.fetch(r.conns[node], partitions, wait)
+		if err != nil {
+			return records, err
+		}
+		records = append(records, fetched...)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Partition != records[j].Partition {
+			return records[i].Partition < records[j].Partition
+		}
+		return records[i].Offset < records[j].Offset
+	})
+	return records, nil
+}
+
+// Close closes the connections to the leaders
+func (r *Consumer) Close() error {
+	closeConns(r.conns)
+	return nil
+}
+
+// byLeader groups the partitions by the node leading them
+func (r *Consumer) byLeader() map[int32][]int32 {
+	groups := make(map[int32][]int32)
+	for _, p := range r.Partitions() {
+		groups[r.leaders[p]] = append(groups[r.leaders[p]], p)
+	}
+	return groups
+}
+
+// fetch reads from the partitions one leader holds, advancing their offsets
+func (r *Consumer) fetch(conn *brokerConn, partitions []int32, wait time.Duration) ([]Record, error) {
+	var req encoder
+	req.int32(-1) // replica ID
+	req.int32(int32(wait / time.Millisecond))
+	req.int32(1) // min bytes, so an empty partition waits
+	req.int32(int32(min(fetchMaxBytes*len(partitions), maxResponseSize/2)))
+	req.int8(0) // read uncommitted
+	req.int32(1)
+	req.string(r.topic)
+	req.int32(int32(len(partitions)))
+	for _, p := range partitions {
+		req.int32(p)
+		req.int64(r.offsets[p])
+		req.int32(fetchMaxBytes)
+	}
+
+	conn.SetDeadline(time.Now().Add(r.client.timeout + wait))
+	d, err := conn.roundTrip(apiFetch, fetchVersion, req.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	var outOfRange []int32
+	d.int32() // throttle time
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		topic := d.string()
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			partition := d.int32()
+			code := brokerError(d.int16())
+			d.int64() // high watermark
+			d.int64() // last stable offset
+			for k, a := 0, d.arrayLen(); k < a; k++ {
+				d.int64() // aborted producer ID
+				d.int64() // first offset
+			}
+			size := d.int32()
+			var data []byte
+			if size > 0 {
+				data = d.take(int(size))
+			}
+			if d.err != nil || topic != r.topic {
+				continue
+			}
+
+			switch code {
+			case 0:
+			case errOffsetOutOfRange:
+				outOfRange = append(outOfRange, partition)
+				continue
+			default:
+				return nil, fmt.Errorf("failed to fetch from %s[%d]: %w", r.topic, partition, code)
+			}
+
+			batch, next, err := decodeRecords(r.topic, partition, data, r.offsets[partition])
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s[%d]: %w", r.topic, partition, err)
+			}
+			records = append(records, batch...)
+			r.offsets[partition] = next
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	if len(outOfRange) > 0 {
+		if err := r.reset(conn, outOfRange); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// reset moves partitions whose offset is out of range to the earliest record
+// when they were behind it, such as after the topic was truncated, and to the
+// end when they were past it
+func (r *Consumer) reset(conn *brokerConn, partitions []int32) error {
+	earliest, err := listOffsets(conn, r.topic, partitions, OffsetEarliest)
+	if err != nil {
+		return err
+	}
+	latest, err := listOffsets(conn, r.topic, partitions, OffsetLatest)
+	if err != nil {
+		return err
+	}
+	for _, p := range partitions {
+		if r.offsets[p] < earliest[p] {
+			r.offsets[p] = earliest[p]
+		} else {
+			r.offsets[p] = latest[p]
+		}
+	}
+	return nil
+}
+
+// DeleteRecords deletes every record currently in the topic, as the
+// kafka-delete-records tool does, and returns how many there were. Offsets
+// keep counting from where they were.
+func (c *Client) DeleteRecords(ctx context.Context, brokers []string, topic string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	conns, meta, err := c.connectLeaders(ctx, brokers, topic)
+	if err != nil {
+		return 0, err
+	}
+	consumer := &Consumer{client: c, topic: topic, conns: conns, leaders: meta.partitions}
+	defer consumer.Close()
+
+	var deleted int64
+	for node, partitions := range consumer.byLeader() {
+		conn := conns[node]
+		earliest, err := listOffsets(conn, topic, partitions, OffsetEarliest)
+		if err != nil {
+			return deleted, err
+		}
+		latest, err := listOffsets(conn, topic, partitions, OffsetLatest)
+		if err != nil {
+			return deleted, err
+		}
+
+		var req encoder
+		req.int32(1)
+		req.string(topic)
+		req.int32(int32(len(partitions)))
+		for _, p := range partitions {
+			req.int32(p)
+			req.int64(latest[p])
+		}
+		req.int32(int32(c.timeout / time.Millisecond))
+
+		d, err := conn.roundTrip(apiDeleteRecords, deleteRecordsVersion, req.Bytes())
+		if err != nil {
+			return deleted, err
+		}
+		d.int32() // throttle time
+		for i, n := 0, d.arrayLen(); i < n; i++ {
+			d.string() // topic
+			for j, m := 0, d.arrayLen(); j < m; j++ {
+				partition := d.int32()
+				d.int64() // low watermark
+				if code := brokerError(d.int16()); code != 0 && d.err == nil {
+					return deleted, fmt.Errorf("failed to delete records from %s[%d]: %w", topic, partition, code)
+				}
+				deleted += latest[partition] - earliest[partition]
+			}
+		}
+		if d.err != nil {
+			return deleted, d.err
+		}
+	}
+	return deleted, nil
+}
+
+// connectLeaders looks up the topic and connects to each of its partition
+// leaders, reusing the bootstrap connection for single-node clusters as
+// Produce does
+func (c *Client) connectLeaders(ctx context.Context, brokers []string, topic string) (map[int32]*brokerConn, *metadata, error) {
+	bootstrap, addr, err := c.dialAny(ctx, brokers)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta, err := c.topicMetadata(ctx, bootstrap, topic)
+	if err != nil {
+		bootstrap.Close()
+		return nil, nil, err
+	}
+
+	conns := make(map[int32]*brokerConn)
+	bootstrapUsed := false
+	for _, leader := range meta.partitions {
+		if _, ok := conns[leader]; ok {
+			continue
+		}
+		leaderAddr, ok := meta.brokers[leader]
+		if !ok || leaderAddr == addr || len(meta.brokers) == 1 {
+			conns[leader] = bootstrap
+			bootstrapUsed = true
+			continue
+		}
+		conn, err := c.dial(ctx, leaderAddr)
+		if err != nil {
+			closeConns(conns)
+			bootstrap.Close()
+			return nil, nil, err
+		}
+		conns[leader] = conn
+	}
+	if !bootstrapUsed {
+		bootstrap.Close()
+	}
+	return conns, meta, nil
+}
+
+// closeConns closes each connection once, since leaders may share one
+func closeConns(conns map[int32]*brokerConn) {
+	closed := make(map[*brokerConn]bool)
+	for _, conn := range conns {
+		if !closed[conn] {
+			closed[conn] = true
+			conn.Close()
+		}
+	}
+}
+
+// listOffsets looks up the earliest or latest offset of partitions on their
+// leader
+func listOffsets(conn *brokerConn, topic string, partitions []int32, timestamp int64) (map[int32]int64, error) {
+	var req encoder
+	req.int32(-1) // replica ID
+	req.int32(1)
+	req.string(topic)
+	req.int32(int32(len(partitions)))
+	for _, p := range partitions {
+		req.int32(p)
+		req.int64(timestamp)
+	}
+
+	d, err := conn.roundTrip(apiListOffsets, listOffsetsVersion, req.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	offsets := make(map[int32]int64)
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		d.string() // topic
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			partition := d.int32()
+			code := brokerError(d.int16())
+			d.int64() // timestamp
+			offset := d.int64()
+			if code != 0 && d.err == nil {
+				return nil, fmt.Errorf("failed to look up offsets of %s[%d]: %w", topic, partition, code)
+			}
+			offsets[partition] = offset
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return offsets, nil
+}
+
+// Compression codecs named in errors; only gzip is supported besides none
+var codecNames = map[int16]string{2: "snappy", 3: "lz4", 4: "zstd"}
+
+// decodeRecords reads the v2 record batches of a fetch response, skipping
+// records before from, and returns the offset to fetch next. A batch cut short
+// by the fetch size ends the records.
+func decodeRecords(topic string, partition int32, data []byte, from int64) ([]Record, int64, error) {
+	var records []Record
+	next := from
+	d := &decoder{buf: data}
+	for len(d.buf) >= 12 {
+		baseOffset := d.int64()
+		length := d.int32()
+		if int(length) > len(d.buf) {
+			break
+		}
+		batch := &decoder{buf: d.take(int(length))}
+		batch.int32() // partition leader epoch
+		if magic := batch.int8(); magic != 2 {
+			return nil, next, fmt.Errorf("message format v%d is not supported", magic)
+		}
+		crc := uint32(batch.int32())
+		if batch.err == nil && crc32.Checksum(batch.buf, castagnoli) != crc {
+			return nil, next, fmt.Errorf("record batch at offset %d failed its CRC check", baseOffset)
+		}
+		attributes := batch.int16()
+		lastOffsetDelta := batch.int32()
+		firstTimestamp := batch.int64()
+		maxTimestamp := batch.int64()
+		batch.take(8 + 2 + 4) // producer ID, epoch and base sequence
+		count := batch.int32()
+		if batch.err != nil {
+			return nil, next, batch.err
+		}
+		if end := baseOffset + int64(lastOffsetDelta) + 1; end > next {
+			next = end
+		}
+
+		// Control batches mark transaction boundaries and hold no data
+		if attributes&0x20 != 0 {
+			continue
+		}
+
+		body := batch
+		switch codec := attributes & 0x07; codec {
+		case 0:
+		case 1:
+			zr, err := gzip.NewReader(bytes.NewReader(batch.buf))
+			if err != nil {
+				return nil, next, fmt.Errorf("invalid gzip batch: %w", err)
+			}
+			raw, err := io.ReadAll(io.LimitReader(zr, maxResponseSize))
+			if err != nil {
+				return nil, next, fmt.Errorf("invalid gzip batch: %w", err)
+			}
+			body = &decoder{buf: raw}
+		default:
+			return nil, next, fmt.Errorf("records compressed with %s are not supported", codecNames[codec])
+		}
+
+		for i := int32(0); i < count; i++ {
+			body.varint() // record length
+			body.int8()   // attributes
+			timestamp := firstTimestamp + body.varint()
+			offset := baseOffset + body.varint()
+			record := Record{Topic: topic, Partition: partition, Offset: offset, Key: body.varBytes(), Value: body.varBytes()}
+			if headers := body.varint(); headers > 0 {
+				record.Headers = make(map[string]string)
+				for j := int64(0); j < headers && body.err == nil; j++ {
+					key := string(body.varBytes())
+					record.Headers[key] = string(body.varBytes())
+				}
+			}
+			if body.err != nil {
+				return nil, next, body.err
+			}
+
+			// Log append time replaces the producer's timestamps
+			if attributes&0x08 != 0 {
+				timestamp = maxTimestamp
+			}
+			record.Timestamp = time.UnixMilli(timestamp)
+			if offset >= from {
+				records = append(records, record)
+			}
+		}
+	}
+	return records, next, nil
+}
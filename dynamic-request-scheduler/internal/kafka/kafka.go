@@ -0,0 +1,408 @@
+// Package kafka is a minimal Kafka producer speaking the broker wire protocol
+// directly. It covers what scheduled local runs need: looking up the leader
+// for a topic partition and producing a single record, against Kafka or
+// Kafka-compatible brokers such as Redpanda.
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// API keys and versions used by the producer
+const (
+	apiProduce      = 0
+	apiMetadata     = 3
+	produceVersion  = 3
+	metadataVersion = 1
+)
+
+// maxResponseSize bounds the response frames accepted from a broker
+const maxResponseSize = 64 << 20
+
+// metadataRetries is how many times a lookup is retried while a topic is
+// being auto-created or its leader elected
+const metadataRetries = 5
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Message is a single record to produce
+type Message struct {
+	Topic string
+
+	// Partition selects the partition; nil hashes the key like the Java
+	// client's default partitioner, or spreads keyless records round-robin
+	Partition *int32
+
+	// Key and Value are sent as-is; nil is written as a null field
+	Key   []byte
+	Value []byte
+
+	Headers map[string]string
+}
+
+// Result reports where a produced record was written
+type Result struct {
+	Partition int32
+	Offset    int64
+}
+
+// Client produces records, opening a connection per call
+type Client struct {
+	timeout  time.Duration
+	clientID string
+	next     atomic.Uint32
+}
+
+// NewClient creates a producer whose calls each complete within timeout
+func NewClient(timeout time.Duration, clientID string) *Client {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Client{timeout: timeout, clientID: clientID}
+}
+
+// Produce writes msg to the leader of its partition, bootstrapping from the
+// first reachable broker, and waits for the leader's acknowledgement
+func (c *Client) Produce(ctx context.Context, brokers []string, msg Message) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	bootstrap, addr, err := c.dialAny(ctx, brokers)
+	if err != nil {
+		return Result{}, err
+	}
+	defer bootstrap.Close()
+
+	meta, err := c.topicMetadata(ctx, bootstrap, msg.Topic)
+	if err != nil {
+		return Result{}, err
+	}
+
+	partition, err := c.partitionFor(msg, meta.partitions)
+	if err != nil {
+		return Result{}, err
+	}
+	leader := meta.partitions[partition]
+
+	// Single-node local brokers often advertise a hostname that only resolves
+	// inside their container network, so reuse the bootstrap connection when
+	// it must be the leader
+	conn := bootstrap
+	if leaderAddr, ok := meta.brokers[leader]; ok && leaderAddr != addr && len(meta.brokers) > 1 {
+		if conn, err = c.dial(ctx, leaderAddr); err != nil {
+			return Result{}, err
+		}
+		defer conn.Close()
+	}
+
+	offset, err := c.produce(conn, msg, partition)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Partition: partition, Offset: offset}, nil
+}
+
+// metadata is the broker and partition layout of one topic
+type metadata struct {
+	brokers map[int32]string
+	// partitions maps each partition to the node ID of its leader
+	partitions map[int32]int32
+}
+
+// topicMetadata looks up the topic's partitions, retrying while brokers
+// report it as unavailable
+func (c *Client) topicMetadata(ctx context.Context, conn *brokerConn, topic string) (*metadata, error) {
+	for attempt := 1; ; attempt++ {
+		meta, err := c.fetchMetadata(conn, topic)
+		if err == nil {
+			return meta, nil
+		}
+		if kerr, ok := err.(brokerError); !ok || !kerr.retriable() || attempt == metadataRetries {
+			return nil, fmt.Errorf("failed to look up topic %q: %w", topic, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to look up topic %q: %w", topic, err)
+		case <-time.After(time.Duration(attempt) * 100 * time.Millisecond):
+		}
+	}
+}
+
+func (c *Client) fetchMetadata(conn *brokerConn, topic string) (*metadata, error) {
+	var req encoder
+	req.int32(1)
+	req.string(topic)
+
+	d, err := conn.roundTrip(apiMetadata, metadataVersion, req.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &metadata{brokers: make(map[int32]string), partitions: make(map[int32]int32)}
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		node := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.string() // rack
+		meta.brokers[node] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	d.int32() // controller ID
+
+	var topicErr brokerError
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		code := brokerError(d.int16())
+		name := d.string()
+		d.int8() // is internal
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			partitionErr := brokerError(d.int16())
+			partition := d.int32()
+			leader := d.int32()
+			for k, r := 0, d.arrayLen(); k < r; k++ {
+				d.int32() // replica
+			}
+			for k, r := 0, d.arrayLen(); k < r; k++ {
+				d.int32() // in-sync replica
+			}
+			if name == topic && partitionErr == 0 && leader >= 0 {
+				meta.partitions[partition] = leader
+			}
+		}
+		if name == topic {
+			topicErr = code
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	if topicErr != 0 {
+		return nil, topicErr
+	}
+	if len(meta.partitions) == 0 {
+		return nil, brokerError(5)
+	}
+	return meta, nil
+}
+
+// partitionFor picks the partition to write msg to
+func (c *Client) partitionFor(msg Message, partitions map[int32]int32) (int32, error) {
+	if msg.Partition != nil {
+		if _, ok := partitions[*msg.Partition]; !ok {
+			return 0, fmt.Errorf("topic %q has no available partition %d", msg.Topic, *msg.Partition)
+		}
+		return *msg.Partition, nil
+	}
+
+	ids := make([]int32, 0, len(partitions))
+	for id := range partitions {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	if msg.Key != nil {
+		return ids[int(murmur2(msg.Key)&0x7fffffff)%len(ids)], nil
+	}
+	return ids[int(c.next.Add(1)-1)%len(ids)], nil
+}
+
+// produce sends a single-record batch and returns the offset it was written at
+func (c *Client) produce(conn *brokerConn, msg Message, partition int32) (int64, error) {
+	var req encoder
+	req.nullString() // transactional ID
+	req.int16(1)     // acks from the leader only
+	req.int32(int32(c.timeout / time.Millisecond))
+	req.int32(1)
+	req.string(msg.Topic)
+	req.int32(1)
+	req.int32(partition)
+	req.bytes(recordBatch(msg, time.Now()))
+
+	d, err := conn.roundTrip(apiProduce, produceVersion, req.Bytes())
+	if err != nil {
+		return 0, err
+	}
+
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		d.string() // topic
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			index := d.int32()
+			code := brokerError(d.int16())
+			offset := d.int64()
+			d.int64() // log append time
+			if d.err == nil && index == partition {
+				if code != 0 {
+					return 0, fmt.Errorf("failed to produce to %s[%d]: %w", msg.Topic, partition, code)
+				}
+				return offset, nil
+			}
+		}
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return 0, fmt.Errorf("produce response did not include %s[%d]", msg.Topic, partition)
+}
+
+// recordBatch encodes msg as a v2 record batch holding a single record
+func recordBatch(msg Message, now time.Time) []byte {
+	var record encoder
+	record.int8(0)   // attributes
+	record.varint(0) // timestamp delta
+	record.varint(0) // offset delta
+	record.varBytes(msg.Key)
+	record.varBytes(msg.Value)
+
+	keys := make([]string, 0, len(msg.Headers))
+	for key := range msg.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	record.varint(int64(len(keys)))
+	for _, key := range keys {
+		record.varBytes([]byte(key))
+		record.varBytes([]byte(msg.Headers[key]))
+	}
+
+	// Everything from the attributes onwards is covered by the CRC
+	var body encoder
+	body.int16(0) // attributes: no compression
+	body.int32(0) // last offset delta
+	timestamp := now.UnixMilli()
+	body.int64(timestamp)
+	body.int64(timestamp)
+	body.int64(-1) // producer ID
+	body.int16(-1) // producer epoch
+	body.int32(-1) // base sequence
+	body.int32(1)  // record count
+	body.varint(int64(record.Len()))
+	body.Write(record.Bytes())
+
+	var batch encoder
+	batch.int64(0)                     // base offset, assigned by the broker
+	batch.int32(int32(9 + body.Len())) // leader epoch, magic and CRC precede the body
+	batch.int32(-1)                    // partition leader epoch
+	batch.int8(2)                      // magic
+	batch.int32(int32(crc32.Checksum(body.Bytes(), castagnoli)))
+	batch.Write(body.Bytes())
+	return batch.Bytes()
+}
+
+// murmur2 is the hash used by the Java client's default partitioner, so keyed
+// records land on the same partition as they would from application code
+func murmur2(data []byte) int32 {
+	const (
+		seed uint32 = 0x9747b28c
+		m    uint32 = 0x5bd1e995
+		r           = 24
+	)
+
+	length := len(data)
+	h := seed ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	tail := length &^ 3
+	switch length & 3 {
+	case 3:
+		h ^= uint32(data[tail+2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[tail+1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[tail])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
+
+// brokerConn is a connection to one broker
+type brokerConn struct {
+	net.Conn
+	clientID    string
+	correlation int32
+}
+
+// dialAny connects to the first reachable broker, returning its address
+func (c *Client) dialAny(ctx context.Context, brokers []string) (*brokerConn, string, error) {
+	var lastErr error
+	for _, addr := range brokers {
+		conn, err := c.dial(ctx, addr)
+		if err == nil {
+			return conn, addr, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no brokers configured")
+	}
+	return nil, "", lastErr
+}
+
+func (c *Client) dial(ctx context.Context, addr string) (*brokerConn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to broker %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	return &brokerConn{Conn: conn, clientID: c.clientID}, nil
+}
+
+// roundTrip sends one request and returns a decoder over the response body
+func (c *brokerConn) roundTrip(apiKey, version int16, body []byte) (*decoder, error) {
+	c.correlation++
+
+	var req encoder
+	req.int16(apiKey)
+	req.int16(version)
+	req.int32(c.correlation)
+	req.string(c.clientID)
+	req.Write(body)
+
+	frame := binary.BigEndian.AppendUint32(nil, uint32(req.Len()))
+	if _, err := c.Write(append(frame, req.Bytes()...)); err != nil {
+		return nil, fmt.Errorf("failed to send to broker: %w", err)
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(c, size[:]); err != nil {
+		return nil, fmt.Errorf("failed to read from broker: %w", err)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < 4 || n > maxResponseSize {
+		return nil, fmt.Errorf("invalid response size %d from broker", n)
+	}
+	resp := make([]byte, n)
+	if _, err := io.ReadFull(c, resp); err != nil {
+		return nil, fmt.Errorf("failed to read from broker: %w", err)
+	}
+
+	d := &decoder{buf: resp}
+	if id := d.int32(); id != c.correlation {
+		return nil, fmt.Errorf("unexpected correlation ID %d from broker (want %d)", id, c.correlation)
+	}
+	return d, nil
+}
@@ -0,0 +1,488 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMurmur2(t *testing.T) {
+	// Vectors from the Java client's partitioner tests
+	cases := map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	}
+	for input, want := range cases {
+		if got := murmur2([]byte(input)); got != want {
+			t.Errorf("murmur2(%q) = %d, want %d", input, got, want)
+		}
+	}
+}
+
+// producedRecord is a record decoded by the fake broker
+type producedRecord struct {
+	topic     string
+	partition int32
+	key       []byte
+	value     []byte
+	headers   map[string]string
+}
+
+// fakeBroker answers metadata and produce requests for a single-broker
+// cluster advertising an unresolvable hostname, as containerised brokers do
+type fakeBroker struct {
+	t          *testing.T
+	listener   net.Listener
+	partitions int32
+	unready    int
+
+	mu       sync.Mutex
+	produced []producedRecord
+	// deleted is each partition's low watermark
+	deleted map[int32]int64
+}
+
+func newFakeBroker(t *testing.T, partitions int32, unready int) *fakeBroker {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	b := &fakeBroker{t: t, listener: listener, partitions: partitions, unready: unready}
+	t.Cleanup(func() { listener.Close() })
+	go b.serve()
+	return b
+}
+
+func (b *fakeBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *fakeBroker) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(conn, frame); err != nil {
+			return
+		}
+
+		d := &decoder{buf: frame}
+		apiKey, version, correlation := d.int16(), d.int16(), d.int32()
+		if clientID := d.string(); clientID != "drs-test" {
+			b.t.Errorf("Unexpected client ID %q", clientID)
+		}
+
+		var resp encoder
+		resp.int32(correlation)
+		switch {
+		case apiKey == apiMetadata && version == metadataVersion:
+			b.metadata(d, &resp)
+		case apiKey == apiProduce && version == produceVersion:
+			b.produce(d, &resp)
+		case apiKey == apiListOffsets && version == listOffsetsVersion:
+			b.listOffsets(d, &resp)
+		case apiKey == apiFetch && version == fetchVersion:
+			b.fetch(d, &resp)
+		case apiKey == apiDeleteRecords && version == deleteRecordsVersion:
+			b.deleteRecords(d, &resp)
+		default:
+			b.t.Errorf("Unexpected request %d v%d", apiKey, version)
+			return
+		}
+
+		out := binary.BigEndian.AppendUint32(nil, uint32(resp.Len()))
+		conn.Write(append(out, resp.Bytes()...))
+	}
+}
+
+func (b *fakeBroker) metadata(d *decoder, resp *encoder) {
+	d.int32()
+	topic := d.string()
+
+	b.mu.Lock()
+	code := int16(0)
+	if b.unready > 0 {
+		b.unready--
+		code = 5
+	}
+	b.mu.Unlock()
+
+	resp.int32(1)
+	resp.int32(7)
+	resp.string("kafka.internal")
+	resp.int32(9092)
+	resp.nullString()
+	resp.int32(7) // controller
+	resp.int32(1)
+	resp.int16(code)
+	resp.string(topic)
+	resp.int8(0)
+	if code != 0 {
+		resp.int32(0)
+		return
+	}
+	resp.int32(b.partitions)
+	for p := int32(0); p < b.partitions; p++ {
+		resp.int16(0)
+		resp.int32(p)
+		resp.int32(7)
+		resp.int32(1)
+		resp.int32(7)
+		resp.int32(1)
+		resp.int32(7)
+	}
+}
+
+func (b *fakeBroker) produce(d *decoder, resp *encoder) {
+	d.string() // transactional ID
+	if acks := d.int16(); acks != 1 {
+		b.t.Errorf("Expected acks=1, got %d", acks)
+	}
+	d.int32() // timeout
+	d.int32()
+	topic := d.string()
+	d.int32()
+	partition := d.int32()
+	batch := d.take(int(d.int32()))
+
+	record := b.decodeBatch(batch)
+	record.topic, record.partition = topic, partition
+
+	b.mu.Lock()
+	offset := int64(len(b.produced))
+	b.produced = append(b.produced, record)
+	b.mu.Unlock()
+
+	resp.int32(1)
+	resp.string(topic)
+	resp.int32(1)
+	resp.int32(partition)
+	resp.int16(0)
+	resp.int64(offset + 100)
+	resp.int64(-1)
+	resp.int32(0) // throttle time
+}
+
+func (b *fakeBroker) decodeBatch(batch []byte) producedRecord {
+	d := &decoder{buf: batch}
+	d.int64() // base offset
+	if length := d.int32(); int(length) != len(d.buf) {
+		b.t.Errorf("Batch length %d does not match remaining %d bytes", length, len(d.buf))
+	}
+	d.int32() // leader epoch
+	if magic := d.int8(); magic != 2 {
+		b.t.Errorf("Expected magic 2, got %d", magic)
+	}
+	crc := uint32(d.int32())
+	if got := crc32.Checksum(d.buf, castagnoli); got != crc {
+		b.t.Errorf("CRC mismatch: batch says %x, computed %x", crc, got)
+	}
+	d.take(2 + 4 + 8 + 8 + 8 + 2 + 4) // attributes through base sequence
+	if count := d.int32(); count != 1 {
+		b.t.Errorf("Expected 1 record, got %d", count)
+	}
+
+	varint := func() int64 {
+		v, n := binary.Varint(d.buf)
+		d.take(n)
+		return v
+	}
+	varBytes := func() []byte {
+		n := varint()
+		if n < 0 {
+			return nil
+		}
+		return d.take(int(n))
+	}
+
+	varint() // record length
+	d.int8() // attributes
+	varint() // timestamp delta
+	varint() // offset delta
+	record := producedRecord{key: varBytes(), value: varBytes(), headers: map[string]string{}}
+	for i := varint(); i > 0; i-- {
+		key := string(varBytes())
+		record.headers[key] = string(varBytes())
+	}
+	if d.err != nil || len(d.buf) != 0 {
+		b.t.Errorf("Malformed record batch: %v, %d trailing bytes", d.err, len(d.buf))
+	}
+	return record
+}
+
+// partitionLog returns the records produced to a partition; a record's offset
+// is its index
+func (b *fakeBroker) partitionLog(partition int32) []producedRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var log []producedRecord
+	for _, r := range b.produced {
+		if r.partition == partition {
+			log = append(log, r)
+		}
+	}
+	return log
+}
+
+func (b *fakeBroker) lowWatermark(partition int32) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.deleted[partition]
+}
+
+func (b *fakeBroker) listOffsets(d *decoder, resp *encoder) {
+	d.int32() // replica ID
+	d.int32()
+	topic := d.string()
+	n := d.int32()
+	resp.int32(1)
+	resp.string(topic)
+	resp.int32(n)
+	for i := int32(0); i < n; i++ {
+		partition, timestamp := d.int32(), d.int64()
+		offset := int64(len(b.partitionLog(partition)))
+		if timestamp == OffsetEarliest {
+			offset = b.lowWatermark(partition)
+		}
+		resp.int32(partition)
+		resp.int16(0)
+		resp.int64(-1)
+		resp.int64(offset)
+	}
+}
+
+// fetch returns every record from the fetch offset on, one batch each
+func (b *fakeBroker) fetch(d *decoder, resp *encoder) {
+	d.int32() // replica ID
+	d.int32() // max wait
+	d.int32() // min bytes
+	d.int32() // max bytes
+	d.int8()  // isolation level
+	d.int32()
+	topic := d.string()
+	n := d.int32()
+
+	resp.int32(0) // throttle time
+	resp.int32(1)
+	resp.string(topic)
+	resp.int32(n)
+	for i := int32(0); i < n; i++ {
+		partition, offset := d.int32(), d.int64()
+		d.int32() // partition max bytes
+
+		log := b.partitionLog(partition)
+		code := int16(0)
+		if offset < b.lowWatermark(partition) || offset > int64(len(log)) {
+			code = 1
+		}
+		var records encoder
+		for o := offset; code == 0 && o < int64(len(log)); o++ {
+			r := log[o]
+			batch := recordBatch(Message{Key: r.key, Value: r.value, Headers: r.headers}, time.UnixMilli(1700000000000+o))
+			binary.BigEndian.PutUint64(batch, uint64(o))
+			records.Write(batch)
+		}
+		// A batch cut short by the fetch size is ignored
+		records.Write([]byte{0, 0, 0, 0, 0, 0, 0, 9, 0, 0, 1, 0, 1})
+
+		resp.int32(partition)
+		resp.int16(code)
+		resp.int64(int64(len(log)))
+		resp.int64(int64(len(log)))
+		resp.int32(0) // aborted transactions
+		resp.bytes(records.Bytes())
+	}
+}
+
+func (b *fakeBroker) deleteRecords(d *decoder, resp *encoder) {
+	d.int32()
+	topic := d.string()
+	n := d.int32()
+	resp.int32(0) // throttle time
+	resp.int32(1)
+	resp.string(topic)
+	resp.int32(n)
+	for i := int32(0); i < n; i++ {
+		partition, offset := d.int32(), d.int64()
+		b.mu.Lock()
+		if b.deleted == nil {
+			b.deleted = make(map[int32]int64)
+		}
+		b.deleted[partition] = offset
+		b.mu.Unlock()
+		resp.int32(partition)
+		resp.int64(offset)
+		resp.int16(0)
+	}
+}
+
+func TestClient_Produce(t *testing.T) {
+	broker := newFakeBroker(t, 3, 2)
+	client := NewClient(5*time.Second, "drs-test")
+	brokers := []string{"127.0.0.1:1", broker.listener.Addr().String()}
+
+	result, err := client.Produce(context.Background(), brokers, Message{
+		Topic:   "orders",
+		Key:     []byte("foobar"),
+		Value:   []byte(`{"id":1}`),
+		Headers: map[string]string{"source": "drs", "X-Request-ID": "abc"},
+	})
+	if err != nil {
+		t.Fatalf("Produce failed: %v", err)
+	}
+	// (-790332482 & 0x7fffffff) % 3 == 0
+	if result.Partition != 0 || result.Offset != 100 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	record := broker.produced[0]
+	if record.topic != "orders" || string(record.key) != "foobar" || string(record.value) != `{"id":1}` {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+	if record.headers["source"] != "drs" || record.headers["X-Request-ID"] != "abc" {
+		t.Errorf("Unexpected headers: %v", record.headers)
+	}
+
+	// Keyless records are spread across partitions; a null key stays null
+	seen := map[int32]bool{}
+	for i := 0; i < 3; i++ {
+		result, err := client.Produce(context.Background(), brokers, Message{Topic: "orders", Value: []byte("x")})
+		if err != nil {
+			t.Fatalf("Produce failed: %v", err)
+		}
+		seen[result.Partition] = true
+	}
+	if len(seen) != 3 || broker.produced[1].key != nil {
+		t.Errorf("Expected round-robin across 3 partitions with null keys, got %v", seen)
+	}
+
+	missing := int32(9)
+	if _, err := client.Produce(context.Background(), brokers, Message{Topic: "orders", Partition: &missing}); err == nil || !strings.Contains(err.Error(), "no available partition 9") {
+		t.Errorf("Expected missing partition error, got %v", err)
+	}
+}
+
+func TestClient_ProduceUnavailable(t *testing.T) {
+	broker := newFakeBroker(t, 1, metadataRetries)
+	client := NewClient(5*time.Second, "drs-test")
+
+	_, err := client.Produce(context.Background(), []string{broker.listener.Addr().String()}, Message{Topic: "orders"})
+	if err == nil || !strings.Contains(err.Error(), "LEADER_NOT_AVAILABLE") {
+		t.Errorf("Expected leader error after retries, got %v", err)
+	}
+
+	if _, err := client.Produce(context.Background(), []string{"127.0.0.1:1"}, Message{Topic: "orders"}); err == nil || !strings.Contains(err.Error(), "failed to connect") {
+		t.Errorf("Expected connection error, got %v", err)
+	}
+}
+
+func TestConsumer_Poll(t *testing.T) {
+	broker := newFakeBroker(t, 2, 0)
+	client := NewClient(5*time.Second, "drs-test")
+	brokers := []string{broker.listener.Addr().String()}
+	ctx := context.Background()
+
+	produce := func(partition int32, value string) {
+		t.Helper()
+		if _, err := client.Produce(ctx, brokers, Message{Topic: "orders", Partition: &partition, Value: []byte(value), Headers: map[string]string{"n": value}}); err != nil {
+			t.Fatalf("Produce failed: %v", err)
+		}
+	}
+	produce(1, "a")
+	produce(0, "b")
+	produce(1, "c")
+
+	consumer, err := client.NewConsumer(ctx, brokers, "orders", OffsetEarliest)
+	if err != nil {
+		t.Fatalf("NewConsumer failed: %v", err)
+	}
+	defer consumer.Close()
+
+	records, err := consumer.Poll(ctx, 0)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	var got []string
+	for _, r := range records {
+		got = append(got, fmt.Sprintf("%d@%d=%s(%s)", r.Partition, r.Offset, r.Value, r.Headers["n"]))
+	}
+	if want := "0@0=b(b) 1@0=a(a) 1@1=c(c)"; strings.Join(got, " ") != want {
+		t.Errorf("Got records %v, want %s", got, want)
+	}
+	if ts := records[2].Timestamp.UnixMilli(); ts != 1700000000001 {
+		t.Errorf("Unexpected timestamp %d", ts)
+	}
+
+	if records, _ := consumer.Poll(ctx, 0); len(records) != 0 {
+		t.Errorf("Expected no records once caught up, got %d", len(records))
+	}
+	produce(0, "d")
+	if records, _ := consumer.Poll(ctx, 0); len(records) != 1 || string(records[0].Value) != "d" || records[0].Offset != 1 {
+		t.Errorf("Expected the new record, got %+v", records)
+	}
+
+	latest, err := client.NewConsumer(ctx, brokers, "orders", OffsetLatest)
+	if err != nil {
+		t.Fatalf("NewConsumer failed: %v", err)
+	}
+	defer latest.Close()
+	if records, _ := latest.Poll(ctx, 0); len(records) != 0 {
+		t.Errorf("Expected no records from the latest offsets, got %d", len(records))
+	}
+	if partitions := latest.Partitions(); len(partitions) != 2 || partitions[0] != 0 {
+		t.Errorf("Unexpected partitions %v", partitions)
+	}
+}
+
+func TestClient_DeleteRecords(t *testing.T) {
+	broker := newFakeBroker(t, 2, 0)
+	client := NewClient(5*time.Second, "drs-test")
+	brokers := []string{broker.listener.Addr().String()}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		client.Produce(ctx, brokers, Message{Topic: "orders", Value: []byte("x")})
+	}
+
+	// A consumer positioned before the deleted records moves to the new start
+	consumer, err := client.NewConsumer(ctx, brokers, "orders", 0)
+	if err != nil {
+		t.Fatalf("NewConsumer failed: %v", err)
+	}
+	defer consumer.Close()
+
+	deleted, err := client.DeleteRecords(ctx, brokers, "orders")
+	if err != nil || deleted != 3 {
+		t.Fatalf("Expected 3 deleted records, got %d (%v)", deleted, err)
+	}
+
+	if records, err := consumer.Poll(ctx, 0); err != nil || len(records) != 0 {
+		t.Fatalf("Expected the out of range offsets to reset, got %d records (%v)", len(records), err)
+	}
+	partition := int32(0)
+	client.Produce(ctx, brokers, Message{Topic: "orders", Partition: &partition, Value: []byte("new")})
+	if records, _ := consumer.Poll(ctx, 0); len(records) != 1 || string(records[0].Value) != "new" {
+		t.Errorf("Expected only the new record, got %+v", records)
+	}
+}
@@ -0,0 +1,188 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// errShortRead is returned when a response ends before a field is complete
+var errShortRead = errors.New("kafka: truncated response")
+
+// encoder builds a Kafka protocol message using big-endian fixed-width
+// integers, int16-length strings and zigzag varints inside record batches
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) int8(v int8) {
+	e.WriteByte(byte(v))
+}
+
+func (e *encoder) int16(v int16) {
+	e.Write(binary.BigEndian.AppendUint16(nil, uint16(v)))
+}
+
+func (e *encoder) int32(v int32) {
+	e.Write(binary.BigEndian.AppendUint32(nil, uint32(v)))
+}
+
+func (e *encoder) int64(v int64) {
+	e.Write(binary.BigEndian.AppendUint64(nil, uint64(v)))
+}
+
+func (e *encoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.WriteString(s)
+}
+
+// nullString writes the null string, encoded as length -1
+func (e *encoder) nullString() {
+	e.int16(-1)
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.int32(int32(len(b)))
+	e.Write(b)
+}
+
+func (e *encoder) varint(v int64) {
+	e.Write(binary.AppendVarint(nil, v))
+}
+
+// varBytes writes a varint length followed by b, with nil encoded as -1
+func (e *encoder) varBytes(b []byte) {
+	if b == nil {
+		e.varint(-1)
+		return
+	}
+	e.varint(int64(len(b)))
+	e.Write(b)
+}
+
+// decoder reads a Kafka protocol response. The first error is kept and
+// every later read returns a zero value, so callers check err once.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.buf) < n {
+		d.err = errShortRead
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) int8() int8 {
+	if b := d.take(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *decoder) int16() int16 {
+	if b := d.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *decoder) int32() int32 {
+	if b := d.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *decoder) int64() int64 {
+	if b := d.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+// string reads a nullable string, returning "" for null
+func (d *decoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+// varint reads a zigzag varint, as used inside record batches
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = errShortRead
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+// varBytes reads a varint length followed by that many bytes, returning nil
+// for a null field
+func (d *decoder) varBytes() []byte {
+	n := d.varint()
+	if n < 0 {
+		return nil
+	}
+	return d.take(int(n))
+}
+
+// arrayLen reads an array length, treating a null array as empty
+func (d *decoder) arrayLen() int {
+	n := d.int32()
+	if n < 0 {
+		return 0
+	}
+	if int(n) > len(d.buf) {
+		// Every element takes at least one byte
+		d.err = errShortRead
+		return 0
+	}
+	return int(n)
+}
+
+// brokerError describes a non-zero Kafka error code
+type brokerError int16
+
+// Error codes worth naming in messages; others are reported by number
+var errorNames = map[brokerError]string{
+	1:  "OFFSET_OUT_OF_RANGE",
+	2:  "CORRUPT_MESSAGE",
+	3:  "UNKNOWN_TOPIC_OR_PARTITION",
+	5:  "LEADER_NOT_AVAILABLE",
+	6:  "NOT_LEADER_OR_FOLLOWER",
+	7:  "REQUEST_TIMED_OUT",
+	10: "MESSAGE_TOO_LARGE",
+	17: "INVALID_TOPIC_EXCEPTION",
+	19: "NOT_ENOUGH_REPLICAS",
+	29: "TOPIC_AUTHORIZATION_FAILED",
+	87: "INVALID_RECORD",
+}
+
+func (e brokerError) Error() string {
+	if name, ok := errorNames[e]; ok {
+		return fmt.Sprintf("kafka error %d (%s)", int16(e), name)
+	}
+	return fmt.Sprintf("kafka error %d", int16(e))
+}
+
+// retriable reports whether the error is expected to clear on its own, such as
+// a topic that is still being auto-created
+func (e brokerError) retriable() bool {
+	return e == 3 || e == 5 || e == 6
+}
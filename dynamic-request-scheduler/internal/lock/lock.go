@@ -0,0 +1,101 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// pollInterval is how often a waiting instance retries a held lock
+const pollInterval = 500 * time.Millisecond
+
+// errLocked is returned by tryLock when another process holds the lock
+var errLocked = errors.New("locked")
+
+// HeldError is returned when another scheduler holds the lock
+type HeldError struct {
+	Path string
+	// Holder describes the process holding the lock, when known
+	Holder string
+}
+
+func (e *HeldError) Error() string {
+	if e.Holder == "" {
+		return fmt.Sprintf("lock %s is held by another scheduler", e.Path)
+	}
+	return fmt.Sprintf("lock %s is held by another scheduler (%s)", e.Path, e.Holder)
+}
+
+// Lock is an exclusive lock on a file, held until Release or until the
+// process exits, so a crashed scheduler never leaves a stale lock behind
+type Lock struct {
+	file *os.File
+}
+
+// TryAcquire takes the lock on path, creating the file if needed, or returns
+// a *HeldError at once if another process holds it
+func TryAcquire(path string) (*Lock, error) {
+	file, err := tryLock(path)
+	if errors.Is(err, errLocked) {
+		return nil, &HeldError{Path: path, Holder: readHolder(path)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	// Describe this process in the file, for the message a second instance
+	// shows; the lock itself does not depend on it
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(holder()+"\n"), 0)
+	}
+	return &Lock{file: file}, nil
+}
+
+// Acquire takes the lock on path, waiting up to wait for another process to
+// release it. It returns a *HeldError if the lock is still held after wait,
+// or ctx's error if ctx is done first.
+func Acquire(ctx context.Context, path string, wait time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		lock, err := TryAcquire(path)
+		var held *HeldError
+		if !errors.As(err, &held) || !time.Now().Before(deadline) {
+			return lock, err
+		}
+
+		timer := time.NewTimer(min(pollInterval, time.Until(deadline)))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Release gives up the lock
+func (l *Lock) Release() error {
+	l.file.Truncate(0)
+	return l.file.Close()
+}
+
+// holder describes this process
+func holder() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown host"
+	}
+	return fmt.Sprintf("pid %d on %s since %s", os.Getpid(), host, time.Now().Format(time.RFC3339))
+}
+
+// readHolder returns the description the lock holder wrote, if readable
+func readHolder(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
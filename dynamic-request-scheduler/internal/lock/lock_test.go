@@ -0,0 +1,68 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTryAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drs.lock")
+
+	first, err := TryAcquire(path)
+	if err != nil {
+		t.Fatalf("TryAcquire failed: %v", err)
+	}
+
+	_, err = TryAcquire(path)
+	var held *HeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("Expected a HeldError while the lock is held, got %v", err)
+	}
+	if runtime.GOOS != "windows" && !strings.Contains(held.Holder, "pid ") {
+		t.Errorf("Expected the holder to be described, got %q", held.Holder)
+	}
+
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	second, err := TryAcquire(path)
+	if err != nil {
+		t.Fatalf("Expected the lock to be free after Release, got %v", err)
+	}
+	second.Release()
+}
+
+func TestAcquire_Waits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drs.lock")
+	first, err := TryAcquire(path)
+	if err != nil {
+		t.Fatalf("TryAcquire failed: %v", err)
+	}
+
+	// Without waiting long enough, the second instance gives up
+	start := time.Now()
+	_, err = Acquire(context.Background(), path, 100*time.Millisecond)
+	var held *HeldError
+	if !errors.As(err, &held) || time.Since(start) < 100*time.Millisecond {
+		t.Fatalf("Expected a HeldError after waiting, got %v after %v", err, time.Since(start))
+	}
+
+	// A waiting instance takes over once the holder releases the lock
+	time.AfterFunc(100*time.Millisecond, func() { first.Release() })
+	second, err := Acquire(context.Background(), path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Expected the lock once released, got %v", err)
+	}
+	defer second.Release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := Acquire(ctx, path, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancelling to stop waiting, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package lock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// errorSharingViolation is returned when opening a file another process has
+// open without sharing
+const errorSharingViolation syscall.Errno = 32
+
+// tryLock opens path without sharing it, so no other process can open it
+// until the handle is closed or the process exits. The holder description
+// can't be read while locked, so waiting instances don't show it.
+func tryLock(path string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil,
+		syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if errors.Is(err, errorSharingViolation) {
+			return nil, errLocked
+		}
+		return nil, err
+	}
+	return os.NewFile(uintptr(handle), path), nil
+}
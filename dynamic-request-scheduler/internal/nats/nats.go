@@ -0,0 +1,393 @@
+// Package nats is a minimal NATS client for publishing a message, or sending a
+// request and waiting for its reply, over the NATS text protocol.
+package nats
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPayloadSize bounds the messages accepted from the server
+const maxPayloadSize = 64 << 20
+
+// headerVersion starts every header block
+const headerVersion = "NATS/1.0"
+
+// ErrNoResponders is returned when nothing is subscribed to a request subject
+var ErrNoResponders = errors.New("no responders are listening on the subject")
+
+// Msg is a message published to or received from a subject
+type Msg struct {
+	Subject string
+	Header  http.Header
+	Data    []byte
+}
+
+// Client publishes messages, opening a connection per call
+type Client struct {
+	timeout time.Duration
+	name    string
+}
+
+// NewClient creates a client whose calls each complete within timeout; name
+// identifies the connection in server monitoring
+func NewClient(timeout time.Duration, name string) *Client {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Client{timeout: timeout, name: name}
+}
+
+// Publish sends msg to the server at rawURL and waits until the server has
+// processed it, so permission errors are reported
+func (c *Client) Publish(ctx context.Context, rawURL string, msg Msg) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	conn, err := c.connect(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.publish(msg, ""); err != nil {
+		return err
+	}
+	return conn.flush()
+}
+
+// Request sends msg with a unique reply subject and waits up to timeout for
+// the first reply
+func (c *Client) Request(ctx context.Context, rawURL string, msg Msg, timeout time.Duration) (*Msg, error) {
+	if timeout <= 0 || timeout > c.timeout {
+		timeout = c.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := c.connect(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	inbox := newInbox()
+	if err := conn.send("SUB %s 1\r\n", inbox); err != nil {
+		return nil, err
+	}
+	if err := conn.publish(msg, inbox); err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.nextMsg()
+	if err != nil {
+		if isTimeout(err) {
+			return nil, fmt.Errorf("no reply within %v: %w", timeout, err)
+		}
+		return nil, err
+	}
+	if status := reply.Header.Get("Status"); status == "503" {
+		return nil, ErrNoResponders
+	}
+	return reply, nil
+}
+
+// connection is an open, authenticated connection to a server
+type connection struct {
+	net.Conn
+	r       *bufio.Reader
+	headers bool
+}
+
+// serverInfo is the part of the server's INFO message the client uses
+type serverInfo struct {
+	Headers      bool `json:"headers"`
+	AuthRequired bool `json:"auth_required"`
+	TLSRequired  bool `json:"tls_required"`
+}
+
+// connectOptions is the CONNECT message sent after INFO
+type connectOptions struct {
+	Verbose      bool   `json:"verbose"`
+	Pedantic     bool   `json:"pedantic"`
+	Name         string `json:"name,omitempty"`
+	Lang         string `json:"lang"`
+	Version      string `json:"version"`
+	Protocol     int    `json:"protocol"`
+	Headers      bool   `json:"headers"`
+	NoResponders bool   `json:"no_responders"`
+	User         string `json:"user,omitempty"`
+	Pass         string `json:"pass,omitempty"`
+	AuthToken    string `json:"auth_token,omitempty"`
+}
+
+// connect dials rawURL (nats://[user:password@|token@]host:port), reads the
+// server's INFO, and sends CONNECT followed by a PING to confirm acceptance
+func (c *Client) connect(ctx context.Context, rawURL string) (*connection, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "nats" {
+		return nil, fmt.Errorf("invalid NATS URL %q: scheme must be nats", rawURL)
+	}
+	host, port := u.Hostname(), u.Port()
+	if host == "" {
+		host = "localhost"
+	}
+	if port == "" {
+		port = "4222"
+	}
+	addr := net.JoinHostPort(host, port)
+
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS at %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		netConn.SetDeadline(deadline)
+	}
+	conn := &connection{Conn: netConn, r: bufio.NewReader(netConn)}
+
+	line, err := conn.readLine()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	op, args, _ := strings.Cut(line, " ")
+	if !strings.EqualFold(op, "INFO") {
+		conn.Close()
+		return nil, fmt.Errorf("expected INFO from NATS server, got %q", line)
+	}
+	var info serverInfo
+	if err := json.Unmarshal([]byte(args), &info); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("invalid INFO from NATS server: %w", err)
+	}
+	if info.TLSRequired {
+		conn.Close()
+		return nil, errors.New("the NATS server requires TLS, which is not supported")
+	}
+	conn.headers = info.Headers
+
+	options := connectOptions{
+		Name:         c.name,
+		Lang:         "go",
+		Version:      "1.0.0",
+		Protocol:     1,
+		Headers:      info.Headers,
+		NoResponders: info.Headers,
+	}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			options.User, options.Pass = u.User.Username(), password
+		} else {
+			options.AuthToken = u.User.Username()
+		}
+	}
+	data, _ := json.Marshal(options)
+	if err := conn.send("CONNECT %s\r\n", data); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// publish sends msg, with headers when it has any
+func (c *connection) publish(msg Msg, reply string) error {
+	subject := msg.Subject
+	if reply != "" {
+		subject += " " + reply
+	}
+
+	if len(msg.Header) == 0 {
+		return c.send("PUB %s %d\r\n%s\r\n", subject, len(msg.Data), msg.Data)
+	}
+	if !c.headers {
+		return errors.New("the NATS server does not support message headers")
+	}
+	header := encodeHeader(msg.Header)
+	return c.send("HPUB %s %d %d\r\n%s%s\r\n", subject, len(header), len(header)+len(msg.Data), header, msg.Data)
+}
+
+// flush sends a PING and waits for its PONG, surfacing any error the server
+// reported for earlier commands
+func (c *connection) flush() error {
+	if err := c.send("PING\r\n"); err != nil {
+		return err
+	}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		switch op, _, _ := strings.Cut(line, " "); strings.ToUpper(op) {
+		case "PONG":
+			return nil
+		case "PING":
+			if err := c.send("PONG\r\n"); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// nextMsg waits for the next message delivered to the connection
+func (c *connection) nextMsg() (*Msg, error) {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		op, rest, _ := strings.Cut(line, " ")
+		args := strings.Fields(rest)
+		switch strings.ToUpper(op) {
+		case "PING":
+			if err := c.send("PONG\r\n"); err != nil {
+				return nil, err
+			}
+		case "MSG":
+			// MSG <subject> <sid> [reply-to] <size>
+			if len(args) < 3 {
+				return nil, fmt.Errorf("malformed NATS message %q", line)
+			}
+			size, err := strconv.Atoi(args[len(args)-1])
+			if err != nil {
+				return nil, fmt.Errorf("malformed NATS message %q", line)
+			}
+			data, err := c.readPayload(size)
+			if err != nil {
+				return nil, err
+			}
+			return &Msg{Subject: args[0], Header: http.Header{}, Data: data}, nil
+		case "HMSG":
+			// HMSG <subject> <sid> [reply-to] <header size> <total size>
+			if len(args) < 4 {
+				return nil, fmt.Errorf("malformed NATS message %q", line)
+			}
+			headerSize, err1 := strconv.Atoi(args[len(args)-2])
+			size, err2 := strconv.Atoi(args[len(args)-1])
+			if err1 != nil || err2 != nil || headerSize > size {
+				return nil, fmt.Errorf("malformed NATS message %q", line)
+			}
+			data, err := c.readPayload(size)
+			if err != nil {
+				return nil, err
+			}
+			header, err := decodeHeader(data[:headerSize])
+			if err != nil {
+				return nil, err
+			}
+			return &Msg{Subject: args[0], Header: header, Data: data[headerSize:]}, nil
+		}
+	}
+}
+
+// readLine reads one protocol line, turning -ERR into an error
+func (c *connection) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return "", errors.New("NATS server closed the connection")
+		}
+		return "", fmt.Errorf("failed to read from NATS server: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "-ERR") {
+		return "", fmt.Errorf("NATS server error: %s", strings.Trim(strings.TrimSpace(line[4:]), "'"))
+	}
+	if line == "+OK" {
+		return c.readLine()
+	}
+	return line, nil
+}
+
+// readPayload reads a message payload and its trailing CRLF
+func (c *connection) readPayload(size int) ([]byte, error) {
+	if size < 0 || size > maxPayloadSize {
+		return nil, fmt.Errorf("invalid NATS payload size %d", size)
+	}
+	buf := make([]byte, size+2)
+	if _, err := io.ReadFull(c.r, buf); err != nil {
+		return nil, fmt.Errorf("failed to read from NATS server: %w", err)
+	}
+	return buf[:size], nil
+}
+
+func (c *connection) send(format string, args ...interface{}) error {
+	if _, err := fmt.Fprintf(c.Conn, format, args...); err != nil {
+		return fmt.Errorf("failed to send to NATS server: %w", err)
+	}
+	return nil
+}
+
+// encodeHeader renders a header block in key order
+func encodeHeader(header http.Header) string {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(headerVersion + "\r\n")
+	for _, key := range keys {
+		for _, value := range header[key] {
+			b.WriteString(key + ": " + value + "\r\n")
+		}
+	}
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+// decodeHeader parses a header block. An inline status such as the 503 sent
+// when nobody is listening is returned as the Status header.
+func decodeHeader(block []byte) (http.Header, error) {
+	r := textproto.NewReader(bufio.NewReader(strings.NewReader(string(block))))
+	first, err := r.ReadLine()
+	if err != nil || !strings.HasPrefix(first, headerVersion) {
+		return nil, fmt.Errorf("malformed NATS header block")
+	}
+
+	mime, err := r.ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("malformed NATS header block: %w", err)
+	}
+	header := http.Header(mime)
+	if header == nil {
+		header = http.Header{}
+	}
+	if status := strings.Fields(strings.TrimPrefix(first, headerVersion)); len(status) > 0 {
+		header.Set("Status", status[0])
+	}
+	return header, nil
+}
+
+// newInbox returns a unique subject for receiving a reply
+func newInbox() string {
+	var b [12]byte
+	rand.Read(b[:])
+	return "_INBOX." + hex.EncodeToString(b[:])
+}
+
+// isTimeout reports whether err was caused by the connection deadline
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
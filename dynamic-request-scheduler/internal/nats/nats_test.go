@@ -0,0 +1,194 @@
+package nats
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer speaks enough of the NATS protocol to accept publishes and answer
+// requests: "echo" replies with the payload and headers it received,
+// "forbidden" is rejected, "slow" never replies and any other request subject
+// has no responders.
+type fakeServer struct {
+	listener net.Listener
+
+	mu        sync.Mutex
+	connects  []connectOptions
+	published []Msg
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &fakeServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) url(userinfo string) string {
+	return "nats://" + userinfo + s.listener.Addr().String()
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+	fmt.Fprint(conn, "INFO {\"server_id\":\"fake\",\"headers\":true}\r\n")
+
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		op, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+		args := strings.Fields(rest)
+
+		switch op {
+		case "CONNECT":
+			var options connectOptions
+			json.Unmarshal([]byte(rest), &options)
+			s.mu.Lock()
+			s.connects = append(s.connects, options)
+			s.mu.Unlock()
+		case "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		case "SUB":
+		case "PUB", "HPUB":
+			size, _ := strconv.Atoi(args[len(args)-1])
+			headerSize := 0
+			if op == "HPUB" {
+				headerSize, _ = strconv.Atoi(args[len(args)-2])
+			}
+			payload := make([]byte, size+2)
+			io.ReadFull(r, payload)
+
+			msg := Msg{Subject: args[0], Header: http.Header{}, Data: payload[headerSize:size]}
+			if headerSize > 0 {
+				msg.Header, _ = decodeHeader(payload[:headerSize])
+			}
+			s.mu.Lock()
+			s.published = append(s.published, msg)
+			s.mu.Unlock()
+
+			reply := ""
+			if len(args) == 3+min(headerSize, 1) {
+				reply = args[1]
+			}
+			s.respond(conn, msg, reply, payload[:size], headerSize)
+		}
+	}
+}
+
+func (s *fakeServer) respond(conn net.Conn, msg Msg, reply string, payload []byte, headerSize int) {
+	switch {
+	case msg.Subject == "forbidden":
+		fmt.Fprint(conn, "-ERR 'Permissions Violation for Publish to \"forbidden\"'\r\n")
+	case reply == "" || msg.Subject == "slow":
+	case msg.Subject == "echo" && headerSize > 0:
+		fmt.Fprintf(conn, "HMSG %s 1 %d %d\r\n%s\r\n", reply, headerSize, len(payload), payload)
+	case msg.Subject == "echo":
+		fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", reply, len(payload), payload)
+	default:
+		fmt.Fprintf(conn, "HMSG %s 1 16 16\r\nNATS/1.0 503\r\n\r\n\r\n", reply)
+	}
+}
+
+func TestClient_Publish(t *testing.T) {
+	server := newFakeServer(t)
+	client := NewClient(5*time.Second, "drs-test")
+
+	err := client.Publish(context.Background(), server.url("app:secret@"), Msg{Subject: "orders.created", Data: []byte(`{"id":1}`)})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	err = client.Publish(context.Background(), server.url("s3cr3t@"), Msg{
+		Subject: "orders.created",
+		Header:  http.Header{"X-Request-ID": {"abc"}},
+		Data:    []byte("hello"),
+	})
+	if err != nil {
+		t.Fatalf("Publish with headers failed: %v", err)
+	}
+
+	first, second := server.published[0], server.published[1]
+	if first.Subject != "orders.created" || string(first.Data) != `{"id":1}` || len(first.Header) != 0 {
+		t.Errorf("Unexpected message: %+v", first)
+	}
+	if string(second.Data) != "hello" || second.Header.Get("X-Request-ID") != "abc" {
+		t.Errorf("Unexpected message with headers: %+v", second)
+	}
+
+	connect := server.connects[0]
+	if connect.User != "app" || connect.Pass != "secret" || connect.Name != "drs-test" || !connect.Headers || !connect.NoResponders {
+		t.Errorf("Unexpected CONNECT: %+v", connect)
+	}
+	if server.connects[1].AuthToken != "s3cr3t" {
+		t.Errorf("Expected token authentication, got %+v", server.connects[1])
+	}
+
+	err = client.Publish(context.Background(), server.url(""), Msg{Subject: "forbidden"})
+	if err == nil || !strings.Contains(err.Error(), "Permissions Violation") {
+		t.Errorf("Expected permissions error, got %v", err)
+	}
+}
+
+func TestClient_Request(t *testing.T) {
+	server := newFakeServer(t)
+	client := NewClient(5*time.Second, "drs-test")
+
+	reply, err := client.Request(context.Background(), server.url(""), Msg{Subject: "echo", Data: []byte("ping")}, time.Second)
+	if err != nil || string(reply.Data) != "ping" {
+		t.Fatalf("Expected echoed reply, got %+v %v", reply, err)
+	}
+
+	reply, err = client.Request(context.Background(), server.url(""), Msg{
+		Subject: "echo",
+		Header:  http.Header{"Trace": {"1"}},
+		Data:    []byte("with headers"),
+	}, time.Second)
+	if err != nil || string(reply.Data) != "with headers" || reply.Header.Get("Trace") != "1" {
+		t.Fatalf("Expected echoed reply with headers, got %+v %v", reply, err)
+	}
+
+	if _, err := client.Request(context.Background(), server.url(""), Msg{Subject: "nobody"}, time.Second); !errors.Is(err, ErrNoResponders) {
+		t.Errorf("Expected no responders, got %v", err)
+	}
+
+	start := time.Now()
+	_, err = client.Request(context.Background(), server.url(""), Msg{Subject: "slow"}, 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "no reply within 100ms") || time.Since(start) > 2*time.Second {
+		t.Errorf("Expected reply timeout, got %v", err)
+	}
+}
+
+func TestClient_ConnectErrors(t *testing.T) {
+	client := NewClient(time.Second, "drs-test")
+	if err := client.Publish(context.Background(), "tls://localhost:4222", Msg{Subject: "a"}); err == nil || !strings.Contains(err.Error(), "scheme must be nats") {
+		t.Errorf("Expected scheme error, got %v", err)
+	}
+	if err := client.Publish(context.Background(), "nats://127.0.0.1:1", Msg{Subject: "a"}); err == nil || !strings.Contains(err.Error(), "failed to connect") {
+		t.Errorf("Expected connection error, got %v", err)
+	}
+}
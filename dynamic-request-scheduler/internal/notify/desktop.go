@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+// notificationTitle is shown as the title of every desktop notification
+const notificationTitle = "Dynamic Request Scheduler"
+
+// DesktopNotifier raises an OS-level notification when a request starts failing
+type DesktopNotifier struct {
+	after   int
+	streaks *streakTracker
+	pending sync.WaitGroup
+
+	// command builds the platform notification command; replaced in tests
+	command func(title, message string) (*exec.Cmd, error)
+}
+
+// NewDesktopNotifier creates a notifier that fires once a request has failed
+// `after` times in a row (minimum 1)
+func NewDesktopNotifier(after int) *DesktopNotifier {
+	if after <= 0 {
+		after = 1
+	}
+	return &DesktopNotifier{
+		after:   after,
+		streaks: newStreakTracker(),
+		command: desktopCommand,
+	}
+}
+
+// Record implements engine.ResultRecorder
+func (d *DesktopNotifier) Record(result engine.ExecutionResult) error {
+	if d.streaks.observe(result) != d.after {
+		return nil
+	}
+
+	message := fmt.Sprintf("Request '%s' failed: %s", result.RequestName, result.FailureReason())
+	if d.after > 1 {
+		message = fmt.Sprintf("Request '%s' failed %d times in a row: %s", result.RequestName, d.after, result.FailureReason())
+	}
+
+	cmd, err := d.command(notificationTitle, message)
+	if err != nil {
+		return err
+	}
+
+	// Run the notifier in the background so slow notification daemons never delay execution
+	d.pending.Add(1)
+	go func() {
+		defer d.pending.Done()
+		if output, err := cmd.CombinedOutput(); err != nil {
+			log.Printf("Error showing desktop notification: %v (%s)", err, strings.TrimSpace(string(output)))
+		}
+	}()
+	return nil
+}
+
+// Wait blocks until all in-flight notifications have been shown
+func (d *DesktopNotifier) Wait() {
+	d.pending.Wait()
+}
+
+// desktopCommand returns the command that shows a notification on the current OS
+func desktopCommand(title, message string) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		return exec.Command("osascript", "-e", script), nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("notify-send", "--app-name", title, title, message), nil
+	case "windows":
+		script := fmt.Sprintf(`Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Warning
+$n.Visible = $true
+$n.ShowBalloonTip(10000, %s, %s, [System.Windows.Forms.ToolTipIcon]::Warning)
+Start-Sleep -Seconds 10
+$n.Dispose()`, powerShellString(title), powerShellString(message))
+		return exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", script), nil
+	default:
+		return nil, fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// powerShellString quotes s as a single-quoted PowerShell string literal
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
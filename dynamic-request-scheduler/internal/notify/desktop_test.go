@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// captureCommands replaces the notifier's command builder with one that records messages
+func captureCommands(d *DesktopNotifier) *[]string {
+	var mu sync.Mutex
+	messages := &[]string{}
+	d.command = func(title, message string) (*exec.Cmd, error) {
+		mu.Lock()
+		*messages = append(*messages, message)
+		mu.Unlock()
+		return exec.Command("true"), nil
+	}
+	return messages
+}
+
+func TestDesktopNotifier_FirstFailure(t *testing.T) {
+	if _, err := exec.LookPath("true"); err != nil {
+		t.Skip("true command not available")
+	}
+
+	notifier := NewDesktopNotifier(0)
+	messages := captureCommands(notifier)
+
+	notifier.Record(success("api"))
+	notifier.Record(failure("api"))
+	notifier.Record(failure("api"))
+	notifier.Record(success("api"))
+	notifier.Record(failure("api"))
+	notifier.Wait()
+
+	if len(*messages) != 2 {
+		t.Fatalf("Expected 2 notifications (one per failure streak), got %d: %v", len(*messages), *messages)
+	}
+	if !strings.Contains((*messages)[0], "Request 'api' failed: HTTP 503") {
+		t.Errorf("Unexpected message: %q", (*messages)[0])
+	}
+}
+
+func TestDesktopNotifier_Threshold(t *testing.T) {
+	if _, err := exec.LookPath("true"); err != nil {
+		t.Skip("true command not available")
+	}
+
+	notifier := NewDesktopNotifier(3)
+	messages := captureCommands(notifier)
+
+	for i := 0; i < 5; i++ {
+		notifier.Record(failure("api"))
+	}
+	notifier.Wait()
+
+	if len(*messages) != 1 {
+		t.Fatalf("Expected 1 notification, got %d", len(*messages))
+	}
+	if !strings.Contains((*messages)[0], "failed 3 times in a row") {
+		t.Errorf("Unexpected message: %q", (*messages)[0])
+	}
+}
+
+func TestQuoting(t *testing.T) {
+	if got := appleScriptString(`say "hi" \ bye`); got != `"say \"hi\" \\ bye"` {
+		t.Errorf("Unexpected AppleScript quoting: %s", got)
+	}
+	if got := powerShellString("it's"); got != "'it''s'" {
+		t.Errorf("Unexpected PowerShell quoting: %s", got)
+	}
+}
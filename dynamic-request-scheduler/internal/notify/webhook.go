@@ -0,0 +1,250 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// defaultPayload is a Slack-compatible message used when a notification has no payload
+var defaultPayload = map[string]interface{}{
+	"text": "Request '{{ .Request }}' failed {{ .ConsecutiveFailures }} time(s) in a row: {{ .Reason }}",
+}
+
+// Event is the data available to notification payload templates
+type Event struct {
+	Notification        string
+	RunID               string
+	ExecutionID         string
+	Request             string
+	Method              string
+	URL                 string
+	StatusCode          int
+	Status              string
+	Error               string
+	Reason              string
+	ConsecutiveFailures int
+	Time                time.Time
+}
+
+// WebhookNotifier sends webhook notifications when requests fail repeatedly
+type WebhookNotifier struct {
+	notifications []spec.NotificationSpec
+	client        *http.Client
+	templates     *spec.TemplateEngine
+
+	streaks *streakTracker
+
+	mu      sync.Mutex
+	pending sync.WaitGroup
+}
+
+// NewWebhookNotifier creates a notifier for the given notification specs
+func NewWebhookNotifier(notifications []spec.NotificationSpec) *WebhookNotifier {
+	return &WebhookNotifier{
+		notifications: notifications,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		templates:     spec.NewTemplateEngine(nil),
+		streaks:       newStreakTracker(),
+	}
+}
+
+// Record implements engine.ResultRecorder
+func (n *WebhookNotifier) Record(result engine.ExecutionResult) error {
+	streak := n.streaks.observe(result)
+	if streak == 0 {
+		return nil
+	}
+
+	event := Event{
+		RunID:               result.RunID,
+		ExecutionID:         result.ExecutionID,
+		Request:             result.RequestName,
+		Method:              result.Method,
+		URL:                 result.URL,
+		StatusCode:          result.StatusCode,
+		Status:              result.Status,
+		Error:               result.Error,
+		Reason:              result.FailureReason(),
+		ConsecutiveFailures: streak,
+		Time:                result.StartedAt,
+	}
+
+	for _, notification := range n.notifications {
+		if !appliesTo(notification, result.RequestName) {
+			continue
+		}
+
+		// Notify once per failure streak, when the threshold is first reached
+		threshold := notification.After
+		if threshold <= 0 {
+			threshold = 1
+		}
+		if streak != threshold {
+			continue
+		}
+
+		event.Notification = notification.Name
+		n.pending.Add(1)
+		go func(notification spec.NotificationSpec, event Event) {
+			defer n.pending.Done()
+			if err := n.send(notification, event); err != nil {
+				log.Printf("Error sending notification '%s': %v", notification.Name, err)
+			}
+		}(notification, event)
+	}
+
+	return nil
+}
+
+// Wait blocks until all in-flight notifications have been sent
+func (n *WebhookNotifier) Wait() {
+	n.pending.Wait()
+}
+
+// send renders the payload and delivers a single notification
+func (n *WebhookNotifier) send(notification spec.NotificationSpec, event Event) error {
+	payload := notification.Payload
+	if payload == nil {
+		payload = defaultPayload
+	}
+
+	// The template engine is shared, so rendering is serialized
+	n.mu.Lock()
+	rendered, err := n.render(payload, event)
+	if err != nil {
+		n.mu.Unlock()
+		return fmt.Errorf("failed to render payload: %w", err)
+	}
+	headers, err := n.renderHeaders(notification.Headers, event)
+	n.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to render headers: %w", err)
+	}
+
+	body, err := json.Marshal(rendered)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	method := notification.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	req, err := http.NewRequest(method, notification.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+
+	log.Printf("Sent notification '%s' for request '%s'", notification.Name, event.Request)
+	return nil
+}
+
+// render recursively evaluates template strings in a payload against the event
+func (n *WebhookNotifier) render(v interface{}, event Event) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		if spec.IsTemplateString(val) {
+			return n.templates.EvaluateTemplateWithData(val, event)
+		}
+		return val, nil
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(val))
+		for key, item := range val {
+			r, err := n.render(item, event)
+			if err != nil {
+				return nil, err
+			}
+			rendered[key] = r
+		}
+		return rendered, nil
+	case []interface{}:
+		rendered := make([]interface{}, len(val))
+		for i, item := range val {
+			r, err := n.render(item, event)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = r
+		}
+		return rendered, nil
+	default:
+		return v, nil
+	}
+}
+
+// renderHeaders evaluates template strings in header values against the event
+func (n *WebhookNotifier) renderHeaders(headers map[string]string, event Event) (map[string]string, error) {
+	rendered := make(map[string]string, len(headers))
+	for key, value := range headers {
+		if !spec.IsTemplateString(value) {
+			rendered[key] = value
+			continue
+		}
+		resolved, err := n.templates.EvaluateTemplateWithData(value, event)
+		if err != nil {
+			return nil, err
+		}
+		rendered[key] = resolved
+	}
+	return rendered, nil
+}
+
+// appliesTo reports whether a notification covers the named request
+func appliesTo(notification spec.NotificationSpec, name string) bool {
+	if len(notification.Requests) == 0 {
+		return true
+	}
+	for _, candidate := range notification.Requests {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
+
+// streakTracker counts consecutive failures per request
+type streakTracker struct {
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+func newStreakTracker() *streakTracker {
+	return &streakTracker{failures: make(map[string]int)}
+}
+
+// observe updates the streak for the result's request and returns the
+// current number of consecutive failures (0 after a success)
+func (t *streakTracker) observe(result engine.ExecutionResult) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if result.Success() {
+		t.failures[result.RequestName] = 0
+		return 0
+	}
+	t.failures[result.RequestName]++
+	return t.failures[result.RequestName]
+}
@@ -0,0 +1,138 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// webhookServer captures JSON payloads posted to it
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	payloads []map[string]interface{}
+	headers  []http.Header
+}
+
+func newWebhookServer() *webhookServer {
+	ws := &webhookServer{}
+	ws.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+
+		ws.mu.Lock()
+		ws.payloads = append(ws.payloads, payload)
+		ws.headers = append(ws.headers, r.Header.Clone())
+		ws.mu.Unlock()
+	}))
+	return ws
+}
+
+func (ws *webhookServer) received() []map[string]interface{} {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return append([]map[string]interface{}(nil), ws.payloads...)
+}
+
+func failure(name string) engine.ExecutionResult {
+	return engine.ExecutionResult{
+		RequestName: name,
+		Method:      "GET",
+		URL:         "http://localhost/" + name,
+		StartedAt:   time.Now(),
+		StatusCode:  503,
+		Status:      "503 Service Unavailable",
+	}
+}
+
+func success(name string) engine.ExecutionResult {
+	return engine.ExecutionResult{RequestName: name, StatusCode: 200, Status: "200 OK", StartedAt: time.Now()}
+}
+
+func TestWebhookNotifier_ConsecutiveFailures(t *testing.T) {
+	server := newWebhookServer()
+	defer server.Close()
+
+	notifier := NewWebhookNotifier([]spec.NotificationSpec{
+		{Name: "slack", URL: server.URL, After: 3},
+	})
+
+	// Two failures then a success resets the streak
+	notifier.Record(failure("api"))
+	notifier.Record(failure("api"))
+	notifier.Record(success("api"))
+	notifier.Wait()
+	if got := len(server.received()); got != 0 {
+		t.Fatalf("Expected no notifications before threshold, got %d", got)
+	}
+
+	// Five consecutive failures notify exactly once
+	for i := 0; i < 5; i++ {
+		notifier.Record(failure("api"))
+	}
+	notifier.Wait()
+
+	payloads := server.received()
+	if len(payloads) != 1 {
+		t.Fatalf("Expected 1 notification, got %d", len(payloads))
+	}
+	want := "Request 'api' failed 3 time(s) in a row: HTTP 503 Service Unavailable"
+	if payloads[0]["text"] != want {
+		t.Errorf("Expected default text %q, got %q", want, payloads[0]["text"])
+	}
+
+	// A new streak after recovery notifies again
+	notifier.Record(success("api"))
+	for i := 0; i < 3; i++ {
+		notifier.Record(failure("api"))
+	}
+	notifier.Wait()
+	if got := len(server.received()); got != 2 {
+		t.Errorf("Expected 2 notifications after second streak, got %d", got)
+	}
+}
+
+func TestWebhookNotifier_CustomPayloadAndFilter(t *testing.T) {
+	server := newWebhookServer()
+	defer server.Close()
+
+	notifier := NewWebhookNotifier([]spec.NotificationSpec{
+		{
+			Name:     "pager",
+			URL:      server.URL,
+			Headers:  map[string]string{"Authorization": "Bearer {{ .Notification }}-token"},
+			Requests: []string{"critical"},
+			Payload: map[string]interface{}{
+				"summary": "{{ .Request }} via {{ .Notification }}",
+				"details": []interface{}{"{{ .Reason }}", 42},
+			},
+		},
+	})
+
+	notifier.Record(failure("ignored"))
+	errored := failure("critical")
+	errored.Error = "connection refused"
+	notifier.Record(errored)
+	notifier.Wait()
+
+	payloads := server.received()
+	if len(payloads) != 1 {
+		t.Fatalf("Expected 1 notification, got %d", len(payloads))
+	}
+	if payloads[0]["summary"] != "critical via pager" {
+		t.Errorf("Unexpected summary: %v", payloads[0]["summary"])
+	}
+	details := payloads[0]["details"].([]interface{})
+	if details[0] != "connection refused" || details[1] != float64(42) {
+		t.Errorf("Unexpected details: %v", details)
+	}
+	if server.headers[0].Get("Authorization") != "Bearer pager-token" {
+		t.Errorf("Expected custom header, got %v", server.headers[0])
+	}
+}
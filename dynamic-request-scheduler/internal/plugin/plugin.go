@@ -0,0 +1,232 @@
+// Package plugin runs external executables that extend the scheduler. Each
+// call starts the plugin's command, writes one JSON message to its stdin
+// and reads one JSON reply from its stdout, so a plugin can be written in
+// any language without linking against the scheduler.
+//
+// The message is {"action": "transform" | "execute", "request": {...}}
+// with the resolved request. A transform reply is {"request": {...}} with
+// the fields to change; an execute reply is {"status_code": 200, "status":
+// "...", "headers": {...}, "body": ...}. Either may instead set "error" to
+// fail the execution. Anything the plugin writes to stderr is kept for the
+// error when it exits unsuccessfully.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// DefaultTimeout bounds each call when the plugin does not set a timeout
+const DefaultTimeout = 30 * time.Second
+
+// maxStderr bounds the stderr shown in errors
+const maxStderr = 500
+
+// Actions sent to plugins
+const (
+	ActionTransform = "transform"
+	ActionExecute   = "execute"
+)
+
+// Plugin is a registered external executable
+type Plugin struct {
+	Name    string
+	Command []string
+	Timeout time.Duration
+
+	// transform and requests control which requests Transform passes to the
+	// plugin
+	transform bool
+	requests  map[string]bool
+}
+
+// New creates a plugin from a validated registration
+func New(s spec.PluginSpec) (*Plugin, error) {
+	timeout, err := s.TimeoutDuration()
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %w", err)
+	}
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	p := &Plugin{Name: s.Name, Command: s.Command, Timeout: timeout, transform: s.Transform}
+	if len(s.Requests) > 0 {
+		p.requests = make(map[string]bool, len(s.Requests))
+		for _, name := range s.Requests {
+			p.requests[name] = true
+		}
+	}
+	return p, nil
+}
+
+// Request is a resolved request as sent to a plugin
+type Request struct {
+	Name         string                 `json:"name"`
+	Type         string                 `json:"type"`
+	Method       string                 `json:"method"`
+	URL          string                 `json:"url"`
+	Headers      map[string]string      `json:"headers"`
+	Body         interface{}            `json:"body,omitempty"`
+	Params       map[string]interface{} `json:"params,omitempty"`
+	ScheduledFor time.Time              `json:"scheduled_for"`
+}
+
+// message is what a plugin reads from stdin
+type message struct {
+	Action  string  `json:"action"`
+	Request Request `json:"request"`
+}
+
+// transformReply is what a transform plugin writes to stdout. Fields left
+// out of the request are kept as they were.
+type transformReply struct {
+	Request *struct {
+		Method  *string           `json:"method"`
+		URL     *string           `json:"url"`
+		Headers map[string]string `json:"headers"`
+		Body    json.RawMessage   `json:"body"`
+	} `json:"request"`
+	Error string `json:"error"`
+}
+
+// Response is what an execute plugin writes to stdout
+type Response struct {
+	// StatusCode defaults to 200 when the reply has no error
+	StatusCode int               `json:"status_code"`
+	Status     string            `json:"status"`
+	Headers    map[string]string `json:"headers"`
+
+	// Body is kept as the response body: a JSON string as its text and
+	// anything else as JSON
+	Body  json.RawMessage `json:"body"`
+	Error string          `json:"error"`
+}
+
+// BodyBytes returns the response body
+func (r *Response) BodyBytes() []byte {
+	var text string
+	if err := json.Unmarshal(r.Body, &text); err == nil {
+		return []byte(text)
+	}
+	if len(r.Body) == 0 || string(r.Body) == "null" {
+		return nil
+	}
+	return r.Body
+}
+
+// Transform passes a resolved request through the plugin and applies the
+// changes it replies with. Requests the plugin does not transform are left
+// alone.
+func (p *Plugin) Transform(ctx context.Context, resolved *spec.ResolvedRequest) error {
+	if !p.transform || (p.requests != nil && !p.requests[resolved.Name]) {
+		return nil
+	}
+
+	var reply transformReply
+	if err := p.call(ctx, ActionTransform, resolved, &reply); err != nil {
+		return err
+	}
+	if reply.Error != "" {
+		return fmt.Errorf("plugin %s: %s", p.Name, reply.Error)
+	}
+	if reply.Request == nil {
+		return nil
+	}
+
+	changed := reply.Request
+	if changed.Method != nil {
+		resolved.Method = *changed.Method
+	}
+	if changed.URL != nil {
+		resolved.URL = *changed.URL
+	}
+	if changed.Headers != nil {
+		resolved.Headers = changed.Headers
+	}
+	if changed.Body != nil {
+		var body interface{}
+		if err := json.Unmarshal(changed.Body, &body); err != nil {
+			return fmt.Errorf("plugin %s returned an invalid body: %w", p.Name, err)
+		}
+		resolved.Body = body
+	}
+	return nil
+}
+
+// Execute has the plugin send a resolved request. An error in the reply is
+// returned alongside the response so what the plugin received is kept.
+func (p *Plugin) Execute(ctx context.Context, resolved *spec.ResolvedRequest) (*Response, error) {
+	var resp Response
+	if err := p.call(ctx, ActionExecute, resolved, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return &resp, fmt.Errorf("plugin %s: %s", p.Name, resp.Error)
+	}
+	if resp.StatusCode == 0 {
+		resp.StatusCode = 200
+	}
+	return &resp, nil
+}
+
+// call runs the plugin once, writing the message and decoding its reply
+func (p *Plugin) call(ctx context.Context, action string, resolved *spec.ResolvedRequest, reply interface{}) error {
+	input, err := json.Marshal(message{Action: action, Request: newRequest(resolved)})
+	if err != nil {
+		return fmt.Errorf("failed to encode request for plugin %s: %w", p.Name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.Command[0], p.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait on children of a killed plugin that still hold its output
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("plugin %s timed out after %v: %w", p.Name, p.Timeout, context.DeadlineExceeded)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			if len(msg) > maxStderr {
+				msg = msg[:maxStderr] + "..."
+			}
+			return fmt.Errorf("plugin %s failed: %w: %s", p.Name, err, msg)
+		}
+		return fmt.Errorf("plugin %s failed: %w", p.Name, err)
+	}
+
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.Bytes()), reply); err != nil {
+		return fmt.Errorf("plugin %s replied with invalid JSON: %w", p.Name, err)
+	}
+	return nil
+}
+
+func newRequest(resolved *spec.ResolvedRequest) Request {
+	req := Request{
+		Name:         resolved.Name,
+		Type:         resolved.Type(),
+		Method:       resolved.Method,
+		URL:          resolved.URL,
+		Headers:      resolved.Headers,
+		Body:         resolved.Body,
+		ScheduledFor: resolved.ScheduledFor,
+	}
+	if resolved.Plugin != nil {
+		req.Params = resolved.Plugin.Params
+	}
+	return req
+}
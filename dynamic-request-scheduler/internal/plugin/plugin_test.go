@@ -0,0 +1,162 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// writePlugin writes a shell script plugin that saves its input next to
+// itself and runs body
+func writePlugin(t *testing.T, body string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plugin.sh")
+	input := filepath.Join(dir, "input.json")
+	script := "#!/bin/sh\ncat > " + input + "\n" + body + "\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path, input
+}
+
+func newPlugin(t *testing.T, s spec.PluginSpec) *Plugin {
+	t.Helper()
+	p, err := New(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestExecute(t *testing.T) {
+	path, input := writePlugin(t, `echo '{"status": "called Greeter/SayHello", "headers": {"grpc-status": "0"}, "body": {"message": "hi"}}'`)
+	p := newPlugin(t, spec.PluginSpec{Name: "grpc", Command: []string{path}, Type: "grpc"})
+
+	resp, err := p.Execute(context.Background(), &spec.ResolvedRequest{
+		Name:   "hello",
+		Method: "GRPC",
+		URL:    "localhost:50051",
+		Body:   map[string]interface{}{"name": "Ada"},
+		Plugin: &spec.PluginTarget{Type: "grpc", Params: map[string]interface{}{"method": "Greeter/SayHello"}},
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if resp.StatusCode != 200 || resp.Status != "called Greeter/SayHello" || resp.Headers["grpc-status"] != "0" {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if got := string(resp.BodyBytes()); got != `{"message": "hi"}` {
+		t.Errorf("Expected the body kept as JSON, got %s", got)
+	}
+
+	var sent message
+	data, _ := os.ReadFile(input)
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatalf("Plugin received invalid JSON %q: %v", data, err)
+	}
+	if sent.Action != ActionExecute || sent.Request.Type != "grpc" || sent.Request.URL != "localhost:50051" || sent.Request.Params["method"] != "Greeter/SayHello" {
+		t.Errorf("Unexpected message sent to plugin: %+v", sent)
+	}
+}
+
+func TestExecute_ReplyError(t *testing.T) {
+	path, _ := writePlugin(t, `echo '{"status_code": 503, "error": "unavailable", "body": "try later"}'`)
+	p := newPlugin(t, spec.PluginSpec{Name: "grpc", Command: []string{path}, Type: "grpc"})
+
+	resp, err := p.Execute(context.Background(), &spec.ResolvedRequest{Name: "hello"})
+	if err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("Expected the reply's error, got %v", err)
+	}
+	if resp == nil || resp.StatusCode != 503 || string(resp.BodyBytes()) != "try later" {
+		t.Errorf("Expected the response alongside the error, got %+v", resp)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	path, input := writePlugin(t, `echo '{"request": {"headers": {"X-Signature": "abc123"}, "body": {"signed": true}}}'`)
+	p := newPlugin(t, spec.PluginSpec{Name: "sign", Command: []string{path}, Transform: true})
+
+	resolved := &spec.ResolvedRequest{Name: "orders", Method: "POST", URL: "http://localhost/orders", Headers: map[string]string{"A": "b"}}
+	if err := p.Transform(context.Background(), resolved); err != nil {
+		t.Fatalf("Transform failed: %v", err)
+	}
+	if resolved.Headers["X-Signature"] != "abc123" || resolved.Method != "POST" || resolved.URL != "http://localhost/orders" {
+		t.Errorf("Expected headers replaced and other fields kept, got %+v", resolved)
+	}
+	if body, ok := resolved.Body.(map[string]interface{}); !ok || body["signed"] != true {
+		t.Errorf("Expected the body replaced, got %#v", resolved.Body)
+	}
+
+	data, _ := os.ReadFile(input)
+	if !strings.Contains(string(data), `"action":"transform"`) {
+		t.Errorf("Expected a transform message, got %s", data)
+	}
+}
+
+func TestTransform_Requests(t *testing.T) {
+	path, input := writePlugin(t, `echo '{}'`)
+	p := newPlugin(t, spec.PluginSpec{Name: "sign", Command: []string{path}, Transform: true, Requests: []string{"orders"}})
+
+	if err := p.Transform(context.Background(), &spec.ResolvedRequest{Name: "health"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(input); !os.IsNotExist(err) {
+		t.Errorf("Expected the plugin not to run for other requests")
+	}
+
+	// Type-only plugins never transform
+	typed := newPlugin(t, spec.PluginSpec{Name: "grpc", Command: []string{path}, Type: "grpc"})
+	if err := typed.Transform(context.Background(), &spec.ResolvedRequest{Name: "orders"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(input); !os.IsNotExist(err) {
+		t.Errorf("Expected a type plugin not to run as a transform")
+	}
+}
+
+func TestCall_Failures(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		timeout string
+		want    string
+	}{
+		{"exit status", "echo 'no such service' >&2; exit 3", "", "exit status 3: no such service"},
+		{"invalid json", "echo 'hello'", "", "invalid JSON"},
+		{"timeout", "sleep 5", "100ms", "timed out after 100ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, _ := writePlugin(t, tt.body)
+			p := newPlugin(t, spec.PluginSpec{Name: "broken", Command: []string{path}, Type: "x", Timeout: tt.timeout})
+
+			_, err := p.Execute(context.Background(), &spec.ResolvedRequest{Name: "r"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+			if tt.name == "timeout" && !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected timeouts to wrap context.DeadlineExceeded")
+			}
+		})
+	}
+}
+
+func TestNew_DefaultTimeout(t *testing.T) {
+	p := newPlugin(t, spec.PluginSpec{Name: "p", Command: []string{"true"}, Type: "x"})
+	if p.Timeout != DefaultTimeout {
+		t.Errorf("Expected the default timeout, got %v", p.Timeout)
+	}
+	p = newPlugin(t, spec.PluginSpec{Name: "p", Command: []string{"true"}, Type: "x", Timeout: "5s"})
+	if p.Timeout != 5*time.Second {
+		t.Errorf("Expected a 5s timeout, got %v", p.Timeout)
+	}
+}
@@ -0,0 +1,274 @@
+// Package redis is a minimal Redis client that runs single commands over
+// RESP2, which Redis and compatible servers (Valkey, KeyDB, Dragonfly) speak.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBulkSize bounds the bulk strings accepted in a reply
+const maxBulkSize = 64 << 20
+
+// maxDepth bounds the nesting of array replies
+const maxDepth = 16
+
+// Error is an error reply sent by the server, such as a command against a
+// key holding the wrong type
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Status is a simple string reply such as OK
+type Status string
+
+// Reply is a decoded reply: Status for simple strings, string for bulk
+// strings, int64 for integers, []interface{} for arrays and nil for nulls
+type Reply interface{}
+
+// Client runs commands, opening a connection per call
+type Client struct {
+	timeout time.Duration
+}
+
+// NewClient creates a client whose calls each complete within timeout
+func NewClient(timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Client{timeout: timeout}
+}
+
+// Do connects to the server at rawURL (redis://[user:password@]host:port/db),
+// authenticates and selects the database if the URL asks for it, then runs
+// one command and returns its reply. Error replies are returned as Error.
+func (c *Client) Do(ctx context.Context, rawURL string, args []string) (Reply, error) {
+	if len(args) == 0 {
+		return nil, errors.New("redis: empty command")
+	}
+	target, err := parseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	conn, err := c.dial(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	if target.password != "" {
+		auth := []string{"AUTH", target.password}
+		if target.user != "" {
+			auth = []string{"AUTH", target.user, target.password}
+		}
+		if _, err := roundTrip(rw, auth); err != nil {
+			return nil, fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+	if target.db != 0 {
+		if _, err := roundTrip(rw, []string{"SELECT", strconv.Itoa(target.db)}); err != nil {
+			return nil, fmt.Errorf("failed to select database %d: %w", target.db, err)
+		}
+	}
+
+	return roundTrip(rw, args)
+}
+
+// target is a parsed server URL
+type target struct {
+	addr     string
+	host     string
+	tls      bool
+	user     string
+	password string
+	db       int
+}
+
+// parseURL reads a redis:// or rediss:// URL
+func parseURL(rawURL string) (*target, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+
+	t := &target{host: u.Hostname()}
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		t.tls = true
+	default:
+		return nil, fmt.Errorf("invalid Redis URL %q: scheme must be redis or rediss", rawURL)
+	}
+	if t.host == "" {
+		t.host = "localhost"
+	}
+	port := u.Port()
+	if port == "" {
+		port = "6379"
+	}
+	t.addr = net.JoinHostPort(t.host, port)
+
+	if u.User != nil {
+		t.user = u.User.Username()
+		t.password, _ = u.User.Password()
+		// redis://password@host is a common shorthand for the default user
+		if _, hasPassword := u.User.Password(); !hasPassword {
+			t.user, t.password = "", t.user
+		}
+	}
+	if path := strings.TrimPrefix(u.Path, "/"); path != "" {
+		if t.db, err = strconv.Atoi(path); err != nil || t.db < 0 {
+			return nil, fmt.Errorf("invalid Redis URL %q: database must be a number", rawURL)
+		}
+	}
+	return t, nil
+}
+
+func (c *Client) dial(ctx context.Context, t *target) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %w", t.addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if t.tls {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: t.host})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS handshake with %s failed: %w", t.addr, err)
+		}
+		return tlsConn, nil
+	}
+	return conn, nil
+}
+
+// roundTrip writes a command as an array of bulk strings and reads its reply
+func roundTrip(rw *bufio.ReadWriter, args []string) (Reply, error) {
+	fmt.Fprintf(rw, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(rw, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := rw.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to send command: %w", err)
+	}
+
+	reply, err := readReply(rw.Reader, 0)
+	if err != nil {
+		return nil, err
+	}
+	if replyErr, ok := reply.(Error); ok {
+		return nil, replyErr
+	}
+	return reply, nil
+}
+
+// readReply decodes one RESP2 reply. Error replies nested in arrays are kept
+// as Error values rather than failing the whole reply.
+func readReply(r *bufio.Reader, depth int) (Reply, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("redis: connection closed by server")
+		}
+		return nil, fmt.Errorf("failed to read reply: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: malformed reply")
+	}
+
+	kind, value := line[0], line[1:]
+	switch kind {
+	case '+':
+		return Status(value), nil
+	case '-':
+		return Error(value), nil
+	case ':':
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed integer reply %q", value)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(value)
+		if err != nil || n > maxBulkSize {
+			return nil, fmt.Errorf("redis: malformed bulk reply length %q", value)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("failed to read reply: %w", err)
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array reply length %q", value)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		if depth >= maxDepth {
+			return nil, errors.New("redis: reply nested too deeply")
+		}
+		items := make([]interface{}, 0, min(n, 1024))
+		for i := 0; i < n; i++ {
+			item, err := readReply(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
+
+// Format renders a reply the way redis-cli prints it, on a single line
+func Format(reply Reply) string {
+	switch value := reply.(type) {
+	case nil:
+		return "(nil)"
+	case Status:
+		return string(value)
+	case int64:
+		return fmt.Sprintf("(integer) %d", value)
+	case string:
+		return strconv.Quote(value)
+	case Error:
+		return "(error) " + string(value)
+	case []interface{}:
+		if len(value) == 0 {
+			return "(empty array)"
+		}
+		parts := make([]string, len(value))
+		for i, item := range value {
+			parts[i] = Format(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	default:
+		return fmt.Sprint(value)
+	}
+}
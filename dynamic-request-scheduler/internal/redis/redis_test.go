@@ -0,0 +1,151 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer answers commands with canned replies and records what it received
+type fakeServer struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	commands [][]string
+}
+
+func newFakeServer(t *testing.T, replies map[string]string) *fakeServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &fakeServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn, replies)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) handle(conn net.Conn, replies map[string]string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		var n int
+		if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+			return
+		}
+		args := make([]string, n)
+		for i := range args {
+			var size int
+			fmt.Fscanf(r, "$%d\r\n", &size)
+			buf := make([]byte, size+2)
+			if _, err := r.Read(buf); err != nil {
+				return
+			}
+			args[i] = string(buf[:size])
+		}
+
+		s.mu.Lock()
+		s.commands = append(s.commands, args)
+		s.mu.Unlock()
+
+		reply, ok := replies[strings.ToUpper(args[0])]
+		if !ok {
+			reply = "-ERR unknown command '" + args[0] + "'\r\n"
+		}
+		conn.Write([]byte(reply))
+	}
+}
+
+func (s *fakeServer) url(userinfo, db string) string {
+	return "redis://" + userinfo + s.listener.Addr().String() + db
+}
+
+func TestClient_Do(t *testing.T) {
+	server := newFakeServer(t, map[string]string{
+		"AUTH":   "+OK\r\n",
+		"SELECT": "+OK\r\n",
+		"LPUSH":  ":3\r\n",
+		"GET":    "$-1\r\n",
+		"LRANGE": "*3\r\n$5\r\nfirst\r\n:2\r\n*1\r\n$0\r\n\r\n",
+	})
+	client := NewClient(5 * time.Second)
+
+	reply, err := client.Do(context.Background(), server.url("app:secret@", "/2"), []string{"LPUSH", "jobs", `{"id": 1}`})
+	if err != nil || reply != int64(3) {
+		t.Fatalf("Expected integer reply 3, got %v %v", reply, err)
+	}
+	want := [][]string{{"AUTH", "app", "secret"}, {"SELECT", "2"}, {"LPUSH", "jobs", `{"id": 1}`}}
+	if !reflect.DeepEqual(server.commands, want) {
+		t.Errorf("Expected %q, got %q", want, server.commands)
+	}
+
+	// A bare password authenticates the default user; database 0 is not selected
+	if _, err := client.Do(context.Background(), server.url("secret@", ""), []string{"GET", "missing"}); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if got := server.commands[3]; !reflect.DeepEqual(got, []string{"AUTH", "secret"}) || len(server.commands) != 5 {
+		t.Errorf("Expected AUTH with password only, got %q", server.commands[3:])
+	}
+
+	reply, err = client.Do(context.Background(), server.url("", ""), []string{"LRANGE", "jobs", "0", "-1"})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if got := Format(reply); got != `["first", (integer) 2, [""]]` {
+		t.Errorf("Unexpected formatted reply: %s", got)
+	}
+}
+
+func TestClient_DoErrors(t *testing.T) {
+	server := newFakeServer(t, map[string]string{"AUTH": "-WRONGPASS invalid username-password pair\r\n"})
+	client := NewClient(5 * time.Second)
+
+	_, err := client.Do(context.Background(), server.url("", ""), []string{"NOPE"})
+	var replyErr Error
+	if !errors.As(err, &replyErr) || !strings.Contains(err.Error(), "unknown command 'NOPE'") {
+		t.Errorf("Expected error reply, got %v", err)
+	}
+
+	if _, err := client.Do(context.Background(), server.url(":bad@", ""), []string{"PING"}); err == nil || !strings.Contains(err.Error(), "authentication failed: redis: WRONGPASS") {
+		t.Errorf("Expected authentication error, got %v", err)
+	}
+
+	for _, url := range []string{"http://localhost", "redis://localhost/db"} {
+		if _, err := client.Do(context.Background(), url, []string{"PING"}); err == nil || !strings.Contains(err.Error(), "invalid Redis URL") {
+			t.Errorf("Expected URL error for %s, got %v", url, err)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	cases := map[string]Reply{
+		"OK":              Status("OK"),
+		`"hello"`:         "hello",
+		"(integer) -1":    int64(-1),
+		"(nil)":           nil,
+		"(empty array)":   []interface{}{},
+		"[(error) WRONG]": []interface{}{Error("WRONG")},
+	}
+	for want, reply := range cases {
+		if got := Format(reply); got != want {
+			t.Errorf("Format(%#v) = %s, want %s", reply, got, want)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+)
+
+// Config describes the scheduler process to register with the OS service manager
+type Config struct {
+	// Name identifies the service (systemd unit, launchd label suffix or task name)
+	Name        string
+	Description string
+	Executable  string
+	Args        []string
+	// WorkingDir is where the process starts, so relative paths in Args keep working
+	WorkingDir string
+	// System installs a system-wide systemd unit instead of a per-user one
+	System bool
+}
+
+// runCommand executes a service manager command; replaced in tests
+var runCommand = defaultRunCommand
+
+func defaultRunCommand(name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w (%s)", name, strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Install registers the scheduler to start at boot (or login) and starts it
+// now. It returns a description of where the service was installed.
+func Install(cfg Config) (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return installSystemd(cfg)
+	case "darwin":
+		return installLaunchd(cfg)
+	case "windows":
+		return installTask(cfg)
+	default:
+		return "", fmt.Errorf("service install is not supported on %s", runtime.GOOS)
+	}
+}
+
+// Uninstall stops the service and removes its registration
+func Uninstall(cfg Config) error {
+	switch runtime.GOOS {
+	case "linux":
+		return uninstallSystemd(cfg)
+	case "darwin":
+		return uninstallLaunchd(cfg)
+	case "windows":
+		return runCommand("schtasks.exe", "/Delete", "/F", "/TN", cfg.Name)
+	default:
+		return fmt.Errorf("service uninstall is not supported on %s", runtime.GOOS)
+	}
+}
+
+// systemdPath returns the unit file location and the systemctl scope flags
+func systemdPath(cfg Config) (string, []string, error) {
+	if cfg.System {
+		return filepath.Join("/etc/systemd/system", cfg.Name+".service"), nil, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", nil, err
+	}
+	return filepath.Join(dir, "systemd", "user", cfg.Name+".service"), []string{"--user"}, nil
+}
+
+func installSystemd(cfg Config) (string, error) {
+	path, scope, err := systemdPath(cfg)
+	if err != nil {
+		return "", err
+	}
+	if err := writeFile(path, systemdUnit(cfg)); err != nil {
+		return "", err
+	}
+	if err := runCommand("systemctl", append(scope, "daemon-reload")...); err != nil {
+		return "", err
+	}
+	if err := runCommand("systemctl", append(scope, "enable", "--now", cfg.Name)...); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func uninstallSystemd(cfg Config) error {
+	path, scope, err := systemdPath(cfg)
+	if err != nil {
+		return err
+	}
+	if err := runCommand("systemctl", append(scope, "disable", "--now", cfg.Name)...); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove unit file: %w", err)
+	}
+	return runCommand("systemctl", append(scope, "daemon-reload")...)
+}
+
+// systemdUnit renders a unit that restarts the scheduler if it exits with an error
+func systemdUnit(cfg Config) string {
+	target := "default.target"
+	if cfg.System {
+		target = "multi-user.target"
+	}
+
+	args := []string{systemdQuote(cfg.Executable)}
+	for _, arg := range cfg.Args {
+		args = append(args, systemdQuote(arg))
+	}
+
+	return fmt.Sprintf(`[Unit]
+Description=%s
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+WorkingDirectory=%s
+ExecStart=%s
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=%s
+`, cfg.Description, systemdQuote(cfg.WorkingDir), strings.Join(args, " "), target)
+}
+
+// systemdQuote escapes specifiers and quotes arguments containing whitespace
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// launchdLabel is the reverse-DNS label used for the launch agent
+func launchdLabel(cfg Config) string {
+	return "local.dev-tools." + cfg.Name
+}
+
+func launchdPath(cfg Config) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", launchdLabel(cfg)+".plist"), nil
+}
+
+func installLaunchd(cfg Config) (string, error) {
+	path, err := launchdPath(cfg)
+	if err != nil {
+		return "", err
+	}
+	if err := writeFile(path, launchdPlist(cfg)); err != nil {
+		return "", err
+	}
+	if err := runCommand("launchctl", "load", "-w", path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func uninstallLaunchd(cfg Config) error {
+	path, err := launchdPath(cfg)
+	if err != nil {
+		return err
+	}
+	if err := runCommand("launchctl", "unload", "-w", path); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove launch agent: %w", err)
+	}
+	return nil
+}
+
+// launchdPlist renders a launch agent that starts at login and is restarted
+// if it exits with an error. Output goes to <name>.log in the working directory.
+func launchdPlist(cfg Config) string {
+	var args strings.Builder
+	for _, arg := range append([]string{cfg.Executable}, cfg.Args...) {
+		fmt.Fprintf(&args, "\n\t\t<string>%s</string>", xmlEscape(arg))
+	}
+	logPath := xmlEscape(filepath.Join(cfg.WorkingDir, cfg.Name+".log"))
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>%s
+	</array>
+	<key>WorkingDirectory</key>
+	<string>%s</string>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, xmlEscape(launchdLabel(cfg)), args.String(), xmlEscape(cfg.WorkingDir), logPath, logPath)
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;").Replace(s)
+}
+
+// installTask registers a scheduled task that starts the scheduler when the
+// current user logs on. Unlike a Windows service it needs no service control
+// handler, so the scheduler binary runs unchanged.
+func installTask(cfg Config) (string, error) {
+	userID := ""
+	if current, err := user.Current(); err == nil {
+		userID = current.Username
+	}
+
+	// schtasks only takes the settings a task needs to keep running, such
+	// as no time limit, from a definition file
+	file, err := os.CreateTemp("", cfg.Name+"-*.xml")
+	if err != nil {
+		return "", fmt.Errorf("failed to create task definition: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(utf16File(taskXML(cfg, userID)))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write task definition: %w", err)
+	}
+
+	if err := runCommand("schtasks.exe", "/Create", "/F", "/TN", cfg.Name, "/XML", file.Name()); err != nil {
+		return "", err
+	}
+	if err := runCommand("schtasks.exe", "/Run", "/TN", cfg.Name); err != nil {
+		return "", err
+	}
+	return `scheduled task \` + cfg.Name, nil
+}
+
+// taskXML renders a task that starts at logon of userID, or of any user when
+// it is empty, and runs for as long as the user stays logged on. Task
+// Scheduler retries it every minute if it fails to start.
+func taskXML(cfg Config, userID string) string {
+	trigger, principal := "", ""
+	if userID != "" {
+		trigger = "\n      <UserId>" + xmlEscape(userID) + "</UserId>"
+		principal = "\n      <UserId>" + xmlEscape(userID) + "</UserId>"
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-16"?>
+<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">
+  <RegistrationInfo>
+    <Description>%s</Description>
+  </RegistrationInfo>
+  <Triggers>
+    <LogonTrigger>
+      <Enabled>true</Enabled>%s
+    </LogonTrigger>
+  </Triggers>
+  <Principals>
+    <Principal id="Author">%s
+      <LogonType>InteractiveToken</LogonType>
+      <RunLevel>LeastPrivilege</RunLevel>
+    </Principal>
+  </Principals>
+  <Settings>
+    <MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>
+    <DisallowStartIfOnBatteries>false</DisallowStartIfOnBatteries>
+    <StopIfGoingOnBatteries>false</StopIfGoingOnBatteries>
+    <ExecutionTimeLimit>PT0S</ExecutionTimeLimit>
+    <RestartOnFailure>
+      <Interval>PT1M</Interval>
+      <Count>999</Count>
+    </RestartOnFailure>
+  </Settings>
+  <Actions Context="Author">
+    <Exec>
+      <Command>cmd.exe</Command>
+      <Arguments>%s</Arguments>
+      <WorkingDirectory>%s</WorkingDirectory>
+    </Exec>
+  </Actions>
+</Task>
+`, xmlEscape(cfg.Description), trigger, principal, xmlEscape(taskArguments(cfg)), xmlEscape(cfg.WorkingDir))
+}
+
+// taskArguments builds the cmd.exe arguments of the task action, which run
+// the scheduler and append its output to <name>.log in the working directory
+func taskArguments(cfg Config) string {
+	args := []string{cmdQuote(cfg.Executable)}
+	for _, arg := range cfg.Args {
+		args = append(args, cmdQuote(arg))
+	}
+	return fmt.Sprintf(`/s /c "%s >> "%s.log" 2>&1"`, strings.Join(args, " "), cfg.Name)
+}
+
+// cmdEscaper escapes the characters cmd.exe treats specially, so it passes
+// them on unchanged. An escaped quote does not start a quoted section, and
+// ^% breaks up variable names so nothing is expanded.
+var cmdEscaper = strings.NewReplacer(
+	"^", "^^", `"`, `^"`, "%", "^%", "!", "^!",
+	"&", "^&", "|", "^|", "<", "^<", ">", "^>", "(", "^(", ")", "^)",
+)
+
+// cmdQuote quotes an argument for a program started through cmd.exe: first
+// the way Windows programs split their command line, then escaped for cmd.exe
+func cmdQuote(s string) string {
+	return cmdEscaper.Replace(windowsQuote(s))
+}
+
+// windowsQuote quotes an argument the way Windows programs split their
+// command line when needed. Backslashes are only special before a quote.
+func windowsQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\"") {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			slashes++
+		case '"':
+			b.WriteString(strings.Repeat(`\`, slashes+1))
+			slashes = 0
+		default:
+			slashes = 0
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteString(strings.Repeat(`\`, slashes))
+	b.WriteByte('"')
+	return b.String()
+}
+
+// utf16File encodes a task definition as UTF-16 with a byte order mark, the
+// encoding schtasks reads
+func utf16File(s string) []byte {
+	encoded := utf16.Encode([]rune(s))
+	b := make([]byte, 2, 2+2*len(encoded))
+	b[0], b[1] = 0xff, 0xfe
+	for _, c := range encoded {
+		b = append(b, byte(c), byte(c>>8))
+	}
+	return b
+}
+
+// writeFile creates the parent directory and writes a service definition
+func writeFile(path, contents string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func testConfig() Config {
+	return Config{
+		Name:        "drs",
+		Description: "Dynamic Request Scheduler",
+		Executable:  "/usr/local/bin/drs",
+		Args:        []string{"--config", "my config.yaml", "--slow", "100%"},
+		WorkingDir:  "/home/dev/project",
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	unit := systemdUnit(testConfig())
+	for _, want := range []string{
+		"Description=Dynamic Request Scheduler",
+		"WorkingDirectory=/home/dev/project",
+		`ExecStart=/usr/local/bin/drs --config "my config.yaml" --slow 100%%`,
+		"Restart=on-failure",
+		"WantedBy=default.target",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("Expected unit to contain %q:\n%s", want, unit)
+		}
+	}
+
+	cfg := testConfig()
+	cfg.System = true
+	if !strings.Contains(systemdUnit(cfg), "WantedBy=multi-user.target") {
+		t.Error("Expected system unit to be wanted by multi-user.target")
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	plist := launchdPlist(testConfig())
+	for _, want := range []string{
+		"<string>local.dev-tools.drs</string>",
+		"<string>/usr/local/bin/drs</string>",
+		"<string>my config.yaml</string>",
+		"<string>/home/dev/project/drs.log</string>",
+		"<key>RunAtLoad</key>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("Expected plist to contain %q:\n%s", want, plist)
+		}
+	}
+
+	cfg := testConfig()
+	cfg.Args = []string{"--match", "a&b"}
+	if !strings.Contains(launchdPlist(cfg), "<string>a&amp;b</string>") {
+		t.Error("Expected arguments to be XML escaped")
+	}
+}
+
+func TestCmdQuote(t *testing.T) {
+	tests := []struct {
+		arg, want string
+	}{
+		{`C:\drs\drs.exe`, `C:\drs\drs.exe`},
+		{`C:\Program Files\drs.exe`, `^"C:\Program Files\drs.exe^"`},
+		{"my config.yaml", `^"my config.yaml^"`},
+		{"", `^"^"`},
+		{`C:\my dev\`, `^"C:\my dev\\^"`},
+		{`say "hi"`, `^"say \^"hi\^"^"`},
+		{`a\"b`, `^"a\\\^"b^"`},
+		{"100%", "100^%"},
+		{"%PATH%", "^%PATH^%"},
+		{"a&b|c>d", "a^&b^|c^>d"},
+		{"^(x)!", "^^^(x^)^!"},
+	}
+	for _, tt := range tests {
+		if got := cmdQuote(tt.arg); got != tt.want {
+			t.Errorf("cmdQuote(%q) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestTaskArguments(t *testing.T) {
+	cfg := testConfig()
+	cfg.Executable = `C:\Program Files\drs.exe`
+	want := `/s /c "^"C:\Program Files\drs.exe^" --config ^"my config.yaml^" --slow 100^% >> "drs.log" 2>&1"`
+	if got := taskArguments(cfg); got != want {
+		t.Errorf("Unexpected task arguments:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTaskXML(t *testing.T) {
+	cfg := testConfig()
+	cfg.WorkingDir = `C:\my dev`
+	task := taskXML(cfg, `DEV\ada`)
+	for _, want := range []string{
+		"<UserId>DEV\\ada</UserId>\n    </LogonTrigger>",
+		"<ExecutionTimeLimit>PT0S</ExecutionTimeLimit>",
+		"<RestartOnFailure>",
+		"<Arguments>/s /c &quot;/usr/local/bin/drs --config ^&quot;my config.yaml^&quot; --slow 100^% &gt;&gt; &quot;drs.log&quot; 2&gt;&amp;1&quot;</Arguments>",
+		`<WorkingDirectory>C:\my dev</WorkingDirectory>`,
+	} {
+		if !strings.Contains(task, want) {
+			t.Errorf("Expected task to contain %q:\n%s", want, task)
+		}
+	}
+	if strings.Contains(taskXML(cfg, ""), "<UserId>") {
+		t.Error("Expected a task without a user to start at any logon")
+	}
+
+	encoded := utf16File("<T/>")
+	if !bytes.Equal(encoded, []byte{0xff, 0xfe, '<', 0, 'T', 0, '/', 0, '>', 0}) {
+		t.Errorf("Unexpected UTF-16 encoding % x", encoded)
+	}
+}
+
+func TestInstallSystemd(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("systemd install is only used on Linux")
+	}
+
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+
+	var commands []string
+	runCommand = func(name string, args ...string) error {
+		commands = append(commands, name+" "+strings.Join(args, " "))
+		return nil
+	}
+	defer func() { runCommand = defaultRunCommand }()
+
+	path, err := Install(testConfig())
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if want := filepath.Join(configDir, "systemd", "user", "drs.service"); path != want {
+		t.Errorf("Expected unit at %s, got %s", want, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected unit file to exist: %v", err)
+	}
+
+	if err := Uninstall(testConfig()); err != nil {
+		t.Fatalf("Uninstall failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected unit file to be removed: %v", err)
+	}
+
+	want := []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable --now drs",
+		"systemctl --user disable --now drs",
+		"systemctl --user daemon-reload",
+	}
+	if strings.Join(commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected commands:\n%s", strings.Join(commands, "\n"))
+	}
+}
@@ -0,0 +1,318 @@
+package sink
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+// harCreator identifies the tool in exported HAR files
+const harCreator = "dynamic-request-scheduler"
+
+// HARWriter collects executed requests and writes them as a HAR 1.2 archive on Close
+type HARWriter struct {
+	mu      sync.Mutex
+	path    string
+	entries []HAREntry
+}
+
+// HAR is the top-level HAR document
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog holds the archived entries
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator describes the application that produced the archive
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a single request/response exchange
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+
+	// Custom fields carry scheduler context; HAR reserves the underscore prefix for them
+	RequestName string `json:"_requestName,omitempty"`
+	RunID       string `json:"_runId,omitempty"`
+	ExecutionID string `json:"_executionId,omitempty"`
+	Error       string `json:"_error,omitempty"`
+}
+
+// HARRequest describes the sent request
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse describes the received response; Status is 0 when none arrived
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue is a header, cookie or query parameter
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the request body
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is the response body
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARTimings breaks down the exchange duration. Phases that did not happen,
+// such as connecting over a reused connection, are -1; without a breakdown
+// the whole duration counts as waiting.
+type HARTimings struct {
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// NewHARWriter creates a writer that will save the archive to path. The file
+// is created immediately so an unwritable path fails fast.
+func NewHARWriter(path string) (*HARWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HAR file: %w", err)
+	}
+	file.Close()
+	return &HARWriter{path: path}, nil
+}
+
+// Record implements engine.ResultRecorder
+func (w *HARWriter) Record(result engine.ExecutionResult) error {
+	// Requests that failed evaluation were never sent and have no usable URL,
+	// and non-HTTP requests such as Kafka records have no HAR representation
+	u, err := url.Parse(result.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil
+	}
+
+	entry := NewHAREntry(result)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.entries = append(w.entries, entry)
+	return nil
+}
+
+// Close writes all recorded entries, ordered by start time, to the HAR file
+func (w *HARWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entries := append([]HAREntry{}, w.entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+
+	har := HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: harCreator, Version: "1.0"},
+		Entries: entries,
+	}}
+
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode HAR: %w", err)
+	}
+	if err := os.WriteFile(w.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write HAR file: %w", err)
+	}
+	return nil
+}
+
+// NewHAREntry converts an execution result to a HAR entry
+func NewHAREntry(result engine.ExecutionResult) HAREntry {
+	durationMs := float64(result.Duration) / float64(time.Millisecond)
+
+	return HAREntry{
+		StartedDateTime: result.StartedAt.UTC(),
+		Time:            durationMs,
+		Request:         newHARRequest(result),
+		Response:        newHARResponse(result),
+		Timings:         newHARTimings(result, durationMs),
+		RequestName:     result.RequestName,
+		RunID:           result.RunID,
+		ExecutionID:     result.ExecutionID,
+		Error:           result.Error,
+	}
+}
+
+// newHARTimings converts an execution's timing breakdown; HAR counts the
+// TLS handshake as part of connecting
+func newHARTimings(result engine.ExecutionResult, durationMs float64) HARTimings {
+	t := result.Timings
+	if t == nil {
+		return HARTimings{DNS: -1, Connect: -1, SSL: -1, Wait: durationMs}
+	}
+	return HARTimings{
+		DNS:     harPhase(t.DNS),
+		Connect: harPhase(t.Connect + t.TLS),
+		SSL:     harPhase(t.TLS),
+		Wait:    milliseconds(t.TTFB),
+		Receive: milliseconds(t.Transfer),
+	}
+}
+
+// harPhase renders an optional phase, which is -1 when it did not happen
+func harPhase(d time.Duration) float64 {
+	if d == 0 {
+		return -1
+	}
+	return milliseconds(d)
+}
+
+func newHARRequest(result engine.ExecutionResult) HARRequest {
+	request := HARRequest{
+		Method:      result.Method,
+		URL:         result.URL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []HARNameValue{},
+		Headers:     []HARNameValue{},
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+	}
+
+	headerNames := make([]string, 0, len(result.Headers))
+	for name := range result.Headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	contentType := ""
+	for _, name := range headerNames {
+		request.Headers = append(request.Headers, HARNameValue{Name: name, Value: result.Headers[name]})
+		if strings.EqualFold(name, "Content-Type") {
+			contentType = result.Headers[name]
+		}
+	}
+
+	if u, err := url.Parse(result.URL); err == nil {
+		query := u.Query()
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			for _, value := range query[key] {
+				request.QueryString = append(request.QueryString, HARNameValue{Name: key, Value: value})
+			}
+		}
+	}
+
+	// Mirror the HTTP client, which only sends JSON bodies for methods other than GET and HEAD
+	if result.Body != nil && result.Method != "GET" && result.Method != "HEAD" {
+		if body, err := json.Marshal(result.Body); err == nil {
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			request.PostData = &HARPostData{MimeType: contentType, Text: string(body)}
+			request.BodySize = len(body)
+		}
+	}
+
+	return request
+}
+
+func newHARResponse(result engine.ExecutionResult) HARResponse {
+	response := HARResponse{
+		Status:      result.StatusCode,
+		StatusText:  statusText(result.Status, result.StatusCode),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []HARNameValue{},
+		Headers:     []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(result.ResponseBody),
+	}
+	if result.StatusCode == 0 {
+		response.HTTPVersion = ""
+		response.BodySize = -1
+	}
+
+	names := make([]string, 0, len(result.ResponseHeaders))
+	for name := range result.ResponseHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range result.ResponseHeaders[name] {
+			response.Headers = append(response.Headers, HARNameValue{Name: name, Value: value})
+		}
+	}
+	response.RedirectURL = result.ResponseHeaders.Get("Location")
+
+	response.Content = HARContent{
+		Size:     len(result.ResponseBody),
+		MimeType: result.ResponseHeaders.Get("Content-Type"),
+	}
+	if len(result.ResponseBody) > 0 {
+		if utf8.Valid(result.ResponseBody) {
+			response.Content.Text = string(result.ResponseBody)
+		} else {
+			response.Content.Text = base64.StdEncoding.EncodeToString(result.ResponseBody)
+			response.Content.Encoding = "base64"
+		}
+	}
+
+	return response
+}
+
+// statusText extracts the reason phrase from a Go status line such as "200 OK"
+func statusText(status string, code int) string {
+	if text := strings.TrimPrefix(status, strconv.Itoa(code)+" "); text != status {
+		return text
+	}
+	return http.StatusText(code)
+}
@@ -0,0 +1,108 @@
+package sink
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+func TestHARWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.har")
+	writer, err := NewHARWriter(path)
+	if err != nil {
+		t.Fatalf("NewHARWriter failed: %v", err)
+	}
+
+	started := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	writer.Record(engine.ExecutionResult{
+		RunID:        "run-1",
+		ExecutionID:  "exec-2",
+		RequestName:  "create",
+		Method:       "POST",
+		URL:          "http://localhost/items?b=2&a=1",
+		Headers:      map[string]string{"X-Run-ID": "run-1"},
+		Body:         map[string]interface{}{"id": 1},
+		StartedAt:    started.Add(time.Second),
+		Duration:     250 * time.Millisecond,
+		StatusCode:   201,
+		Status:       "201 Created",
+		ResponseBody: []byte(`{"ok":true}`),
+		ResponseHeaders: http.Header{
+			"Content-Type": []string{"application/json"},
+		},
+		Timings: &engine.Timings{Connect: 10 * time.Millisecond, TLS: 20 * time.Millisecond, TTFB: 200 * time.Millisecond, Transfer: 5 * time.Millisecond},
+	})
+	writer.Record(engine.ExecutionResult{
+		RequestName:  "binary",
+		Method:       "GET",
+		URL:          "http://localhost/image",
+		StartedAt:    started,
+		StatusCode:   200,
+		Status:       "200 OK",
+		ResponseBody: []byte{0xff, 0xfe},
+	})
+	writer.Record(engine.ExecutionResult{RequestName: "down", Method: "GET", URL: "http://localhost:1", StartedAt: started.Add(2 * time.Second), Error: "connection refused"})
+	writer.Record(engine.ExecutionResult{RequestName: "unresolved", Method: "GET", URL: "{{ invalid }}", Error: "template error"})
+	writer.Record(engine.ExecutionResult{RequestName: "event", Method: "KAFKA", URL: "kafka://localhost:9092/orders", StatusCode: 200})
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read HAR: %v", err)
+	}
+	var har HAR
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("Invalid HAR JSON: %v", err)
+	}
+
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 3 {
+		t.Fatalf("Expected HAR 1.2 with 3 entries, got %s with %d", har.Log.Version, len(har.Log.Entries))
+	}
+
+	binary, create, down := har.Log.Entries[0], har.Log.Entries[1], har.Log.Entries[2]
+	if binary.RequestName != "binary" || create.RequestName != "create" {
+		t.Errorf("Expected entries ordered by start time, got %s, %s", binary.RequestName, create.RequestName)
+	}
+	if binary.Response.Content.Encoding != "base64" || binary.Response.Content.Text != "//4=" {
+		t.Errorf("Expected base64 binary content, got %+v", binary.Response.Content)
+	}
+
+	if create.Time != 250 || create.ExecutionID != "exec-2" {
+		t.Errorf("Unexpected entry metadata: %+v", create)
+	}
+	if create.Request.PostData == nil || create.Request.PostData.Text != `{"id":1}` || create.Request.PostData.MimeType != "application/json" {
+		t.Errorf("Unexpected post data: %+v", create.Request.PostData)
+	}
+	if q := create.Request.QueryString; len(q) != 2 || q[0].Name != "a" {
+		t.Errorf("Unexpected query string: %+v", q)
+	}
+	if create.Response.StatusText != "Created" || create.Response.Content.MimeType != "application/json" || create.Response.Content.Text != `{"ok":true}` {
+		t.Errorf("Unexpected response: %+v", create.Response)
+	}
+
+	want := HARTimings{DNS: -1, Connect: 30, SSL: 20, Wait: 200, Receive: 5}
+	if create.Timings != want {
+		t.Errorf("Expected timings %+v, got %+v", want, create.Timings)
+	}
+	if binary.Timings.Connect != -1 || binary.Timings.Wait != 0 {
+		t.Errorf("Expected no breakdown without timings, got %+v", binary.Timings)
+	}
+
+	if down.Response.Status != 0 || down.Error != "connection refused" {
+		t.Errorf("Expected failed exchange with status 0, got %+v", down)
+	}
+}
+
+func TestNewHARWriter_InvalidPath(t *testing.T) {
+	if _, err := NewHARWriter(filepath.Join(t.TempDir(), "missing", "run.har")); err == nil {
+		t.Error("Expected error for unwritable path")
+	}
+}
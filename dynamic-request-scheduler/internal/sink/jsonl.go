@@ -0,0 +1,129 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+// JSONLRecord is the on-disk representation of a single execution
+type JSONLRecord struct {
+	RunID        string            `json:"run_id,omitempty"`
+	ExecutionID  string            `json:"execution_id,omitempty"`
+	Request      string            `json:"request"`
+	RequestID    string            `json:"request_id,omitempty"`
+	Method       string            `json:"method"`
+	URL          string            `json:"url"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	Body         interface{}       `json:"body,omitempty"`
+	ScheduledFor *time.Time        `json:"scheduled_for,omitempty"`
+	StartedAt    time.Time         `json:"started_at"`
+	DurationMs   float64           `json:"duration_ms"`
+	StatusCode   int               `json:"status_code,omitempty"`
+	Status       string            `json:"status,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	Attempts     int               `json:"attempts,omitempty"`
+	Unmet        []string          `json:"unmet,omitempty"`
+	Warnings     []string          `json:"warnings,omitempty"`
+	Timings      *JSONLTimings     `json:"timings,omitempty"`
+	Success      bool              `json:"success"`
+}
+
+// JSONLTimings is the on-disk representation of an HTTP execution's timing
+// breakdown, in milliseconds
+type JSONLTimings struct {
+	DNSMs      float64 `json:"dns_ms"`
+	ConnectMs  float64 `json:"connect_ms"`
+	TLSMs      float64 `json:"tls_ms"`
+	TTFBMs     float64 `json:"ttfb_ms"`
+	TransferMs float64 `json:"transfer_ms"`
+	Reused     bool    `json:"reused_connection"`
+}
+
+// JSONLWriter appends one JSON record per execution to a file
+type JSONLWriter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewJSONLWriter opens path for appending, creating it if needed
+func NewJSONLWriter(path string) (*JSONLWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results file: %w", err)
+	}
+	return &JSONLWriter{file: file}, nil
+}
+
+// Record implements engine.ResultRecorder
+func (w *JSONLWriter) Record(result engine.ExecutionResult) error {
+	record := NewJSONLRecord(result)
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	line = append(line, '\n')
+
+	// Write each record in a single call so tailing readers never see partial lines
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file
+func (w *JSONLWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// NewJSONLRecord converts an execution result to its JSONL representation
+func NewJSONLRecord(result engine.ExecutionResult) JSONLRecord {
+	record := JSONLRecord{
+		RunID:       result.RunID,
+		ExecutionID: result.ExecutionID,
+		Request:     result.RequestName,
+		RequestID:   result.RequestID,
+		Method:      result.Method,
+		URL:         result.URL,
+		Headers:     result.Headers,
+		Body:        result.Body,
+		StartedAt:   result.StartedAt.UTC(),
+		DurationMs:  milliseconds(result.Duration),
+		StatusCode:  result.StatusCode,
+		Status:      result.Status,
+		Error:       result.Error,
+		Attempts:    result.Attempts,
+		Unmet:       result.Unmet,
+		Warnings:    result.Warnings,
+		Success:     result.Success(),
+	}
+	if !result.ScheduledFor.IsZero() {
+		scheduledFor := result.ScheduledFor.UTC()
+		record.ScheduledFor = &scheduledFor
+	}
+	if t := result.Timings; t != nil {
+		record.Timings = &JSONLTimings{
+			DNSMs:      milliseconds(t.DNS),
+			ConnectMs:  milliseconds(t.Connect),
+			TLSMs:      milliseconds(t.TLS),
+			TTFBMs:     milliseconds(t.TTFB),
+			TransferMs: milliseconds(t.Transfer),
+			Reused:     t.Reused,
+		}
+	}
+	return record
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
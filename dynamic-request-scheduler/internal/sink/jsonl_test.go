@@ -0,0 +1,107 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+func TestJSONLWriter_Record(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+
+	writer, err := NewJSONLWriter(path)
+	if err != nil {
+		t.Fatalf("NewJSONLWriter failed: %v", err)
+	}
+
+	started := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := writer.Record(engine.ExecutionResult{
+				RunID:       "run-1",
+				ExecutionID: fmt.Sprintf("exec-%d", i),
+				RequestName: "req",
+				Method:      "POST",
+				URL:         "http://localhost/items",
+				Body:        map[string]interface{}{"n": i},
+				StartedAt:   started,
+				Duration:    1500 * time.Microsecond,
+				StatusCode:  201,
+				Status:      "201 Created",
+			})
+			if err != nil {
+				t.Errorf("Record failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := writer.Record(engine.ExecutionResult{RequestName: "broken", Error: "boom", StartedAt: started}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	writer.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open results: %v", err)
+	}
+	defer file.Close()
+
+	var records []JSONLRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record JSONLRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 21 {
+		t.Fatalf("Expected 21 records, got %d", len(records))
+	}
+	if !records[0].Success || records[0].DurationMs != 1.5 || records[0].StatusCode != 201 || records[0].RunID != "run-1" {
+		t.Errorf("Unexpected record: %+v", records[0])
+	}
+	if last := records[20]; last.Success || last.Error != "boom" || last.ScheduledFor != nil {
+		t.Errorf("Unexpected failure record: %+v", last)
+	}
+}
+
+func TestJSONLWriter_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	if err := os.WriteFile(path, []byte("{\"request\":\"previous\"}\n"), 0o644); err != nil {
+		t.Fatalf("Failed to seed file: %v", err)
+	}
+
+	writer, err := NewJSONLWriter(path)
+	if err != nil {
+		t.Fatalf("NewJSONLWriter failed: %v", err)
+	}
+	writer.Record(engine.ExecutionResult{RequestName: "next", StartedAt: time.Now()})
+	writer.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read results: %v", err)
+	}
+	lines := 0
+	for _, b := range data {
+		if b == '\n' {
+			lines++
+		}
+	}
+	if lines != 2 {
+		t.Errorf("Expected 2 lines after append, got %d", lines)
+	}
+}
@@ -0,0 +1,95 @@
+package sink
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+// StatsDEmitter sends execution counters and timings to a StatsD or DogStatsD agent over UDP
+type StatsDEmitter struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	prefix string
+	tags   bool
+}
+
+// NewStatsDEmitter connects to the agent at addr. Metric names start with
+// prefix; with tags set, the request name and status class are sent as
+// DogStatsD tags, otherwise they are embedded in the metric name.
+func NewStatsDEmitter(addr, prefix string, tags bool) (*StatsDEmitter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to StatsD agent: %w", err)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	return &StatsDEmitter{conn: conn, prefix: prefix, tags: tags}, nil
+}
+
+// Record implements engine.ResultRecorder
+func (e *StatsDEmitter) Record(result engine.ExecutionResult) error {
+	class := result.StatusClass()
+	lines := []string{e.metric("executions", "1", "c", result.RequestName, class)}
+
+	// Only completed HTTP exchanges have a meaningful latency
+	if result.Error == "" {
+		ms := strconv.FormatFloat(float64(result.Duration)/float64(time.Millisecond), 'f', 3, 64)
+		lines = append(lines, e.metric("duration", ms, "ms", result.RequestName, ""))
+	}
+
+	// Send all lines for an execution in a single datagram
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err := e.conn.Write([]byte(strings.Join(lines, "\n"))); err != nil {
+		return fmt.Errorf("failed to send StatsD metrics: %w", err)
+	}
+	return nil
+}
+
+// Close closes the UDP socket
+func (e *StatsDEmitter) Close() error {
+	return e.conn.Close()
+}
+
+// metric formats a single StatsD line
+func (e *StatsDEmitter) metric(name, value, kind, request, class string) string {
+	if e.tags {
+		tags := "request:" + sanitizeTag(request)
+		if class != "" {
+			tags += ",class:" + class
+		}
+		return fmt.Sprintf("%s%s:%s|%s|#%s", e.prefix, name, value, kind, tags)
+	}
+
+	name += "." + sanitizeMetricSegment(request)
+	if class != "" {
+		name += "." + class
+	}
+	return fmt.Sprintf("%s%s:%s|%s", e.prefix, name, value, kind)
+}
+
+// sanitizeTag strips characters DogStatsD uses as tag delimiters
+func sanitizeTag(s string) string {
+	return strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_").Replace(s)
+}
+
+// sanitizeMetricSegment makes a request name safe to embed in a metric name
+func sanitizeMetricSegment(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package sink
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+// listenUDP starts a UDP listener and returns its address and a packet reader
+func listenUDP(t *testing.T) (string, func() string) {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	read := func() string {
+		buf := make([]byte, 1024)
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom failed: %v", err)
+		}
+		return string(buf[:n])
+	}
+	return conn.LocalAddr().String(), read
+}
+
+func TestStatsDEmitter_Tags(t *testing.T) {
+	addr, read := listenUDP(t)
+
+	emitter, err := NewStatsDEmitter(addr, "drs", true)
+	if err != nil {
+		t.Fatalf("NewStatsDEmitter failed: %v", err)
+	}
+	defer emitter.Close()
+
+	emitter.Record(engine.ExecutionResult{RequestName: "Get, Users", StatusCode: 200, Duration: 1500 * time.Microsecond})
+	lines := strings.Split(read(), "\n")
+	want := []string{
+		"drs.executions:1|c|#request:Get_ Users,class:2xx",
+		"drs.duration:1.500|ms|#request:Get_ Users",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected packet:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Errors are counted but have no timing
+	emitter.Record(engine.ExecutionResult{RequestName: "down", Error: "refused", Duration: time.Second})
+	if got := read(); got != "drs.executions:1|c|#request:down,class:error" {
+		t.Errorf("Unexpected error packet: %q", got)
+	}
+}
+
+func TestStatsDEmitter_Plain(t *testing.T) {
+	addr, read := listenUDP(t)
+
+	emitter, err := NewStatsDEmitter(addr, "", false)
+	if err != nil {
+		t.Fatalf("NewStatsDEmitter failed: %v", err)
+	}
+	defer emitter.Close()
+
+	emitter.Record(engine.ExecutionResult{RequestName: "Health Check", StatusCode: 503, Duration: 2 * time.Millisecond})
+	want := "executions.health_check.5xx:1|c\nduration.health_check:2.000|ms"
+	if got := read(); got != want {
+		t.Errorf("Unexpected packet:\n%s\nwant:\n%s", got, want)
+	}
+}
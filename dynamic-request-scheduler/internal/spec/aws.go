@@ -0,0 +1,193 @@
+package spec
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// defaultAWSEndpoint is LocalStack's default edge endpoint
+const defaultAWSEndpoint = "http://localhost:4566"
+
+// SQSSpec sends a message to an SQS queue, such as one emulated by
+// LocalStack. The queue URL, group and deduplication IDs accept templates;
+// attributes and body are resolved like HTTP headers and bodies.
+type SQSSpec struct {
+	// QueueURL is the queue's URL, e.g. http://localhost:4566/000000000000/orders
+	QueueURL   string            `json:"queue_url" yaml:"queue_url"`
+	Attributes map[string]string `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+	Body       interface{}       `json:"body,omitempty" yaml:"body,omitempty"`
+
+	// DelaySeconds postpones delivery by up to 900 seconds
+	DelaySeconds *int `json:"delay_seconds,omitempty" yaml:"delay_seconds,omitempty"`
+
+	// GroupID and DeduplicationID apply to FIFO queues
+	GroupID         string `json:"message_group_id,omitempty" yaml:"message_group_id,omitempty"`
+	DeduplicationID string `json:"deduplication_id,omitempty" yaml:"deduplication_id,omitempty"`
+
+	// Region defaults to AWS_REGION, then us-east-1
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+}
+
+// SNSSpec publishes a message to an SNS topic. The endpoint, topic ARN,
+// subject, group and deduplication IDs accept templates; attributes and
+// message are resolved like HTTP headers and bodies.
+type SNSSpec struct {
+	// Endpoint defaults to http://localhost:4566
+	Endpoint   string            `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	TopicARN   string            `json:"topic_arn" yaml:"topic_arn"`
+	Subject    string            `json:"subject,omitempty" yaml:"subject,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+	Message    interface{}       `json:"message,omitempty" yaml:"message,omitempty"`
+
+	// GroupID and DeduplicationID apply to FIFO topics
+	GroupID         string `json:"message_group_id,omitempty" yaml:"message_group_id,omitempty"`
+	DeduplicationID string `json:"deduplication_id,omitempty" yaml:"deduplication_id,omitempty"`
+
+	// Region defaults to the topic ARN's region
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+}
+
+// SQSTarget is where a resolved SQS request is sent; the message body and
+// attributes are the resolved request's Body and Headers
+type SQSTarget struct {
+	QueueURL        string
+	Region          string
+	DelaySeconds    *int
+	GroupID         string
+	DeduplicationID string
+}
+
+// SNSTarget is where a resolved SNS request is published; the message and
+// attributes are the resolved request's Body and Headers
+type SNSTarget struct {
+	Endpoint        string
+	TopicARN        string
+	Region          string
+	Subject         string
+	GroupID         string
+	DeduplicationID string
+}
+
+// Validate validates an SQS message specification
+func (s *SQSSpec) Validate() error {
+	if s.QueueURL == "" {
+		return &ValidationError{
+			Field:   "sqs.queue_url",
+			Message: "queue URL is required",
+		}
+	}
+	if err := validateAWSURL("sqs.queue_url", s.QueueURL); err != nil {
+		return err
+	}
+
+	if d := s.DelaySeconds; d != nil && (*d < 0 || *d > 900) {
+		return &ValidationError{
+			Field:   "sqs.delay_seconds",
+			Message: "delay must be between 0 and 900 seconds",
+		}
+	}
+
+	return nil
+}
+
+// Validate validates an SNS publish specification
+func (s *SNSSpec) Validate() error {
+	if s.TopicARN == "" {
+		return &ValidationError{
+			Field:   "sns.topic_arn",
+			Message: "topic ARN is required",
+		}
+	}
+	if !IsTemplateString(s.TopicARN) && !strings.HasPrefix(s.TopicARN, "arn:") {
+		return &ValidationError{
+			Field:   "sns.topic_arn",
+			Message: fmt.Sprintf("invalid topic ARN: %s", s.TopicARN),
+		}
+	}
+
+	return validateAWSURL("sns.endpoint", s.Endpoint)
+}
+
+// validateAWSURL checks that a literal endpoint is an HTTP URL
+func validateAWSURL(field, rawURL string) error {
+	if rawURL == "" || IsTemplateString(rawURL) {
+		return nil
+	}
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &ValidationError{
+			Field:   field,
+			Message: fmt.Sprintf("invalid URL: %s", rawURL),
+		}
+	}
+	return nil
+}
+
+// resolveSQS resolves the templated fields of an SQS request
+func (e *Evaluator) resolveSQS(s *SQSSpec) (*SQSTarget, error) {
+	target := &SQSTarget{
+		QueueURL:        s.QueueURL,
+		Region:          s.Region,
+		DelaySeconds:    s.DelaySeconds,
+		GroupID:         s.GroupID,
+		DeduplicationID: s.DeduplicationID,
+	}
+
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"queue URL", &target.QueueURL},
+		{"region", &target.Region},
+		{"message_group_id", &target.GroupID},
+		{"deduplication_id", &target.DeduplicationID},
+	}
+	for _, field := range fields {
+		value, err := e.resolveString(*field.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve SQS %s template: %w", field.name, err)
+		}
+		*field.value = value
+	}
+	return target, nil
+}
+
+// resolveSNS resolves the templated fields of an SNS request
+func (e *Evaluator) resolveSNS(s *SNSSpec) (*SNSTarget, error) {
+	target := &SNSTarget{
+		Endpoint:        s.Endpoint,
+		TopicARN:        s.TopicARN,
+		Region:          s.Region,
+		Subject:         s.Subject,
+		GroupID:         s.GroupID,
+		DeduplicationID: s.DeduplicationID,
+	}
+	if target.Endpoint == "" {
+		target.Endpoint = defaultAWSEndpoint
+	}
+
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"endpoint", &target.Endpoint},
+		{"topic ARN", &target.TopicARN},
+		{"region", &target.Region},
+		{"subject", &target.Subject},
+		{"message_group_id", &target.GroupID},
+		{"deduplication_id", &target.DeduplicationID},
+	}
+	for _, field := range fields {
+		value, err := e.resolveString(*field.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve SNS %s template: %w", field.name, err)
+		}
+		*field.value = value
+	}
+
+	// arn:aws:sns:<region>:<account>:<topic>
+	if parts := strings.Split(target.TopicARN, ":"); target.Region == "" && len(parts) == 6 {
+		target.Region = parts[3]
+	}
+	return target, nil
+}
@@ -0,0 +1,137 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+)
+
+// Body kinds returned by BodyKind
+const (
+	BodyJSON = "json"
+	BodyXML  = "xml"
+	BodyText = "text"
+)
+
+// BodyKind classifies a Content-Type as BodyJSON, BodyXML or BodyText,
+// including structured suffixes such as application/problem+json, and
+// returns "" for other or missing types
+func BodyKind(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		return BodyJSON
+	case mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+		return BodyXML
+	case strings.HasPrefix(mediaType, "text/"):
+		return BodyText
+	default:
+		return ""
+	}
+}
+
+// ParseBody decodes a response body according to its Content-Type: XML
+// into the tree described by ParseXML and anything else as JSON, since many
+// local services send JSON without labelling it. Text that is not JSON is
+// returned as a string. JSON numbers are kept exact as json.Number.
+func ParseBody(contentType string, body []byte) (interface{}, error) {
+	kind := BodyKind(contentType)
+	if kind == BodyXML {
+		return ParseXML(body)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// Keep numbers exact, so large IDs are not rendered as floats
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		if kind == BodyText {
+			return string(body), nil
+		}
+		return nil, fmt.Errorf("response body is not JSON: %w", err)
+	}
+	return value, nil
+}
+
+// ParseXML decodes an XML document into nested maps, so JSON paths can read
+// it. The document becomes a map holding its root element. An element with
+// attributes or children becomes a map of its attributes (prefixed with "@",
+// leaving out namespace declarations), its children by local name (a list
+// when repeated) and its text under "#text"; any other element becomes its
+// text. For example <order id="7"><item>a</item><item>b</item></order>
+// reads as {"order": {"@id": "7", "item": ["a", "b"]}}.
+func ParseXML(body []byte) (interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("response body is not XML: no root element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("response body is not XML: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			root, err := parseXMLElement(decoder, start)
+			if err != nil {
+				return nil, fmt.Errorf("response body is not XML: %w", err)
+			}
+			return map[string]interface{}{start.Name.Local: root}, nil
+		}
+	}
+}
+
+// parseXMLElement reads the content of the element start opens, up to its
+// end element
+func parseXMLElement(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	element := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		// Namespace declarations are not data
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		element["@"+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := parseXMLElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			name := t.Name.Local
+			switch existing := element[name].(type) {
+			case nil:
+				element[name] = child
+			case []interface{}:
+				element[name] = append(existing, child)
+			default:
+				element[name] = []interface{}{existing, child}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(element) == 0 {
+				return content, nil
+			}
+			if content != "" {
+				element["#text"] = content
+			}
+			return element, nil
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package spec
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestBodyKind(t *testing.T) {
+	tests := map[string]string{
+		"application/json":                BodyJSON,
+		"application/json; charset=utf-8": BodyJSON,
+		"application/problem+json":        BodyJSON,
+		"application/xml":                 BodyXML,
+		"text/xml; charset=utf-8":         BodyXML,
+		"application/soap+xml":            BodyXML,
+		"text/plain":                      BodyText,
+		"text/html; charset=utf-8":        BodyText,
+		"application/octet-stream":        "",
+		"":                                "",
+	}
+	for contentType, want := range tests {
+		if got := BodyKind(contentType); got != want {
+			t.Errorf("BodyKind(%q) = %q, want %q", contentType, got, want)
+		}
+	}
+}
+
+func TestParseBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        interface{}
+		wantErr     bool
+	}{
+		{name: "json", contentType: "application/json", body: `{"id": 7}`, want: map[string]interface{}{"id": json.Number("7")}},
+		{name: "unlabelled json", body: `[1]`, want: []interface{}{json.Number("1")}},
+		{name: "json sent as text", contentType: "text/plain", body: `{"ok": true}`, want: map[string]interface{}{"ok": true}},
+		{name: "text", contentType: "text/plain", body: "pong", want: "pong"},
+		{name: "xml", contentType: "text/xml", body: `<ok>yes</ok>`, want: map[string]interface{}{"ok": "yes"}},
+		{name: "unlabelled non-json", body: "pong", wantErr: true},
+		{name: "broken xml", contentType: "application/xml", body: "<ok>", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBody(tt.contentType, []byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBody() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseXML(t *testing.T) {
+	body := `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <order id="7" status="open">
+      <item>a</item>
+      <item>b</item>
+      <item>c</item>
+      <note lang="en">rush</note>
+      <empty/>
+    </order>
+  </soap:Body>
+</soap:Envelope>`
+
+	got, err := ParseXML([]byte(body))
+	if err != nil {
+		t.Fatalf("ParseXML() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"Envelope": map[string]interface{}{
+			"Body": map[string]interface{}{
+				"order": map[string]interface{}{
+					"@id":     "7",
+					"@status": "open",
+					"item":    []interface{}{"a", "b", "c"},
+					"note":    map[string]interface{}{"@lang": "en", "#text": "rush"},
+					"empty":   "",
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseXML() = %#v, want %#v", got, want)
+	}
+
+	if _, err := ParseXML([]byte("   ")); err == nil {
+		t.Error("Expected an error for a document without a root element")
+	}
+}
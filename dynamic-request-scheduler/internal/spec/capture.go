@@ -0,0 +1,153 @@
+package spec
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Capture sources other than JSON paths into the response body
+const (
+	CaptureStatus = "status"
+	CaptureBody   = "body"
+
+	// captureHeaderPrefix precedes the name of a captured response header
+	captureHeaderPrefix = "header:"
+)
+
+// validateSources checks that every entry of a capture or state section
+// names a variable and a source CaptureValue understands
+func validateSources(field string, sources map[string]string) error {
+	for _, name := range sortedKeys(sources) {
+		source := sources[name]
+		if name == "" {
+			return &ValidationError{Field: field, Message: "variable name is required"}
+		}
+		switch {
+		case source == CaptureStatus, source == CaptureBody:
+		case strings.HasPrefix(source, captureHeaderPrefix):
+			if strings.TrimPrefix(source, captureHeaderPrefix) == "" {
+				return &ValidationError{Field: field + "." + name, Message: "header name is required"}
+			}
+		case IsXPath(source):
+			if _, err := ParseXPath(source); err != nil {
+				return &ValidationError{Field: field + "." + name, Message: err.Error()}
+			}
+		default:
+			if _, err := parseJSONPath(source); err != nil {
+				return &ValidationError{Field: field + "." + name, Message: err.Error()}
+			}
+		}
+	}
+	return nil
+}
+
+// CaptureValue reads the value a capture source names from a response:
+// "status" for the status code, "body" for the whole body, "header:<Name>"
+// for a response header, a JSON path such as "$.data.items[0].id" for a
+// value in the body, parsed according to its Content-Type by ParseBody, and
+// an XPath such as "/envelope/body/order/@id" for a value in an XML body
+func CaptureValue(source string, statusCode int, headers http.Header, body []byte) (interface{}, error) {
+	switch {
+	case source == CaptureStatus:
+		return statusCode, nil
+	case source == CaptureBody:
+		return string(body), nil
+	case strings.HasPrefix(source, captureHeaderPrefix):
+		name := strings.TrimPrefix(source, captureHeaderPrefix)
+		if values := headers.Values(name); len(values) > 0 {
+			return values[0], nil
+		}
+		return nil, fmt.Errorf("response has no %s header", name)
+	case IsXPath(source):
+		path, err := ParseXPath(source)
+		if err != nil {
+			return nil, err
+		}
+		return path.Evaluate(body)
+	}
+
+	value, err := ParseBody(headers.Get("Content-Type"), body)
+	if err != nil {
+		return nil, err
+	}
+	return LookupJSONPath(value, source)
+}
+
+// jsonPathStep is one key or array index of a JSON path
+type jsonPathStep struct {
+	key   string
+	index int
+	isKey bool
+}
+
+// parseJSONPath splits a path such as "$.items[0].id" into its steps
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid JSON path %q: must start with $", path)
+	}
+
+	var steps []jsonPathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("invalid JSON path %q: empty key", path)
+			}
+			steps = append(steps, jsonPathStep{key: key, isKey: true})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: unclosed [", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: bad array index %q", path, rest[1:end])
+			}
+			steps = append(steps, jsonPathStep{index: index})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid JSON path %q: expected . or [ after %q", path, path[:len(path)-len(rest)])
+		}
+	}
+	return steps, nil
+}
+
+// LookupJSONPath returns the value at a JSON path such as "$.items[0].id" in
+// a decoded JSON value
+func LookupJSONPath(value interface{}, path string) (interface{}, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, step := range steps {
+		if step.isKey {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: %q is not in an object", path, step.key)
+			}
+			if value, ok = object[step.key]; !ok {
+				return nil, fmt.Errorf("%s: no %q key", path, step.key)
+			}
+			continue
+		}
+		array, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: [%d] is not in an array", path, step.index)
+		}
+		if step.index >= len(array) {
+			return nil, fmt.Errorf("%s: index %d out of range (length %d)", path, step.index, len(array))
+		}
+		value = array[step.index]
+	}
+	return value, nil
+}
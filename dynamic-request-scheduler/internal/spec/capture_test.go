@@ -0,0 +1,118 @@
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLookupJSONPath(t *testing.T) {
+	var value interface{}
+	json.Unmarshal([]byte(`{"data": {"items": [{"id": "a"}, {"id": "b", "tags": ["x", "y"]}]}, "token": "abc"}`), &value)
+
+	tests := []struct {
+		path    string
+		want    interface{}
+		wantErr string
+	}{
+		{path: "$.token", want: "abc"},
+		{path: "$.data.items[1].id", want: "b"},
+		{path: "$.data.items[1].tags[0]", want: "x"},
+		{path: "$.missing", wantErr: `no "missing" key`},
+		{path: "$.data.items[5]", wantErr: "out of range"},
+		{path: "$.token.id", wantErr: "not in an object"},
+		{path: "$.data[0]", wantErr: "not in an array"},
+		{path: "token", wantErr: "must start with $"},
+		{path: "$.data.items[x]", wantErr: "bad array index"},
+		{path: "$.data..id", wantErr: "empty key"},
+		{path: "$.data.items[0", wantErr: "unclosed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := LookupJSONPath(value, tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v (%v)", tt.wantErr, err, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Expected %v, got %v (%v)", tt.want, got, err)
+			}
+		})
+	}
+
+	if got, err := LookupJSONPath(value, "$"); err != nil || got == nil {
+		t.Errorf("Expected $ to return the whole document, got %v (%v)", got, err)
+	}
+}
+
+func TestCaptureValue(t *testing.T) {
+	headers := http.Header{"Etag": []string{`"v1"`}}
+	body := []byte(`{"id": 12345678901234567890, "session": {"token": "abc"}}`)
+
+	tests := []struct {
+		source string
+		want   interface{}
+	}{
+		{source: "status", want: 201},
+		{source: "body", want: string(body)},
+		{source: "header:ETag", want: `"v1"`},
+		{source: "$.session.token", want: "abc"},
+		{source: "$.id", want: json.Number("12345678901234567890")},
+	}
+	for _, tt := range tests {
+		got, err := CaptureValue(tt.source, 201, headers, body)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: expected %v, got %v (%v)", tt.source, tt.want, got, err)
+		}
+	}
+
+	if _, err := CaptureValue("header:Location", 201, headers, body); err == nil {
+		t.Error("Expected an error for a missing header")
+	}
+	if _, err := CaptureValue("$.id", 200, headers, []byte("not json")); err == nil {
+		t.Error("Expected an error for a JSON path into a non-JSON body")
+	}
+
+	xmlHeaders := http.Header{"Content-Type": []string{"application/xml; charset=utf-8"}}
+	xmlBody := []byte(`<session expires="60"><token>abc</token></session>`)
+	if got, err := CaptureValue("$.session.token", 200, xmlHeaders, xmlBody); err != nil || got != "abc" {
+		t.Errorf("Expected a JSON path into an XML body, got %v (%v)", got, err)
+	}
+	if got, err := CaptureValue("$.session.@expires", 200, xmlHeaders, xmlBody); err != nil || got != "60" {
+		t.Errorf("Expected a JSON path to an XML attribute, got %v (%v)", got, err)
+	}
+	if got, err := CaptureValue("/session/@expires", 200, http.Header{}, xmlBody); err != nil || got != "60" {
+		t.Errorf("Expected an XPath into an XML body, got %v (%v)", got, err)
+	}
+	if _, err := CaptureValue("/session/missing", 200, xmlHeaders, xmlBody); err == nil {
+		t.Error("Expected an error for an XPath that selects nothing")
+	}
+}
+
+func TestScheduledRequest_ValidateCaptures(t *testing.T) {
+	req := ScheduledRequest{
+		Name:    "login",
+		HTTP:    HttpRequestSpec{Method: "POST", URL: NewLiteralString("http://localhost/login")},
+		Capture: map[string]string{"token": "$.token", "etag": "header:ETag", "code": "status", "id": "//order/@id"},
+	}
+	if err := req.ValidateUnscheduled(); err != nil {
+		t.Errorf("Expected valid captures, got %v", err)
+	}
+
+	for _, source := range []string{"token", "header:", "$.items[-1]", "/order/@id/x"} {
+		req.Capture = map[string]string{"value": source}
+		if err := req.ValidateUnscheduled(); err == nil || !strings.Contains(err.Error(), "capture.value") {
+			t.Errorf("Expected %q to be rejected, got %v", source, err)
+		}
+	}
+
+	relative := "1m"
+	req.Schedule = ScheduleSpec{Relative: &relative}
+	req.Capture = map[string]string{"token": "$.token"}
+	if err := req.Validate(); err == nil || !strings.Contains(err.Error(), "only setup requests") {
+		t.Errorf("Expected a scheduled request to be unable to capture, got %v", err)
+	}
+}
@@ -4,18 +4,44 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the top-level configuration file
 type Config struct {
-	Requests []ScheduledRequest `json:"requests" yaml:"requests"`
+	Requests      []ScheduledRequest     `json:"requests" yaml:"requests"`
+	Notifications []NotificationSpec     `json:"notifications,omitempty" yaml:"notifications,omitempty"`
+	Profiles      map[string]ProfileSpec `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+	Plugins       []PluginSpec           `json:"plugins,omitempty" yaml:"plugins,omitempty"`
+
+	// KeepWarm adds a keep-warm request for each of its URLs to Requests
+	KeepWarm *KeepWarmSpec `json:"keep_warm,omitempty" yaml:"keep_warm,omitempty"`
+
+	// Setup requests run once, in order, before scheduling begins, such as
+	// to log in; their captures set variables every later request can read
+	Setup []ScheduledRequest `json:"setup,omitempty" yaml:"setup,omitempty"`
+
+	// Teardown requests run once, in order, when the scheduler shuts down
+	// gracefully, such as to delete test tenants; they take no schedule
+	Teardown []ScheduledRequest `json:"teardown,omitempty" yaml:"teardown,omitempty"`
 }
 
 // LoadConfig loads configuration from a file (supports both YAML and JSON)
 func LoadConfig(path string) ([]ScheduledRequest, error) {
+	config, err := LoadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return config.Requests, nil
+}
+
+// LoadConfigFile loads and validates the full configuration file, including
+// top-level sections other than requests
+func LoadConfigFile(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
@@ -40,14 +66,119 @@ func LoadConfig(path string) ([]ScheduledRequest, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	// Keep-warm URLs are pinged by requests of their own
+	if err := config.KeepWarm.Validate(); err != nil {
+		return nil, err
+	}
+	config.Requests = append(config.Requests, config.KeepWarm.Requests()...)
+
 	// Validate all requests
 	for i, req := range config.Requests {
 		if err := req.Validate(); err != nil {
 			return nil, fmt.Errorf("request %d (%s): %w", i, req.Name, err)
 		}
 	}
+	for i, req := range config.Setup {
+		if err := req.ValidateUnscheduled(); err != nil {
+			return nil, fmt.Errorf("setup %d (%s): %w", i, req.Name, err)
+		}
+	}
+	for i, req := range config.Teardown {
+		if err := req.ValidateUnscheduled(); err != nil {
+			return nil, fmt.Errorf("teardown %d (%s): %w", i, req.Name, err)
+		}
+		if len(req.Capture) > 0 {
+			return nil, fmt.Errorf("teardown %d (%s): %w", i, req.Name, &ValidationError{
+				Field:   "capture",
+				Message: "only setup requests can capture values",
+			})
+		}
+	}
 
-	return config.Requests, nil
+	// Load files referenced by requests, relative to the config file
+	if err := loadRequestFiles(config.Requests, "request", filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if err := loadRequestFiles(config.Setup, "setup", filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if err := loadRequestFiles(config.Teardown, "teardown", filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+
+	// Names and IDs identify requests across every section
+	if err := assignIDs(&config); err != nil {
+		return nil, err
+	}
+
+	// Templates may only read variables that setup captures set
+	if err := validateVariables(&config); err != nil {
+		return nil, err
+	}
+
+	// Validate notifications
+	for i, notification := range config.Notifications {
+		if err := notification.Validate(); err != nil {
+			return nil, fmt.Errorf("notification %d (%s): %w", i, notification.Name, err)
+		}
+	}
+
+	// Validate plugins, and that every plugin request has a plugin to run it
+	pluginTypes := make(map[string]string)
+	for i := range config.Plugins {
+		plugin := &config.Plugins[i]
+		if err := plugin.Validate(); err != nil {
+			return nil, fmt.Errorf("plugin %d (%s): %w", i, plugin.Name, err)
+		}
+		if plugin.Type != "" {
+			if other, ok := pluginTypes[plugin.Type]; ok {
+				return nil, fmt.Errorf("plugin %d (%s): type %s is already handled by plugin %s", i, plugin.Name, plugin.Type, other)
+			}
+			pluginTypes[plugin.Type] = plugin.Name
+		}
+		plugin.resolveCommand(filepath.Dir(path))
+	}
+	for i, req := range config.Requests {
+		if req.Plugin != nil && pluginTypes[req.Plugin.Type] == "" {
+			return nil, fmt.Errorf("request %d (%s): plugin.type: no plugin in the plugins section handles type %s", i, req.Name, req.Plugin.Type)
+		}
+	}
+	for i, req := range config.Setup {
+		if req.Plugin != nil && pluginTypes[req.Plugin.Type] == "" {
+			return nil, fmt.Errorf("setup %d (%s): plugin.type: no plugin in the plugins section handles type %s", i, req.Name, req.Plugin.Type)
+		}
+	}
+	for i, req := range config.Teardown {
+		if req.Plugin != nil && pluginTypes[req.Plugin.Type] == "" {
+			return nil, fmt.Errorf("teardown %d (%s): plugin.type: no plugin in the plugins section handles type %s", i, req.Name, req.Plugin.Type)
+		}
+	}
+
+	// Validate profiles
+	for name, profile := range config.Profiles {
+		if err := profile.Validate(); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", name, err)
+		}
+	}
+
+	return &config, nil
+}
+
+// Profile returns the named profile, listing the available ones if it is missing
+func (c *Config) Profile(name string) (ProfileSpec, error) {
+	if profile, ok := c.Profiles[name]; ok {
+		return profile, nil
+	}
+
+	names := make([]string, 0, len(c.Profiles))
+	for n := range c.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return ProfileSpec{}, fmt.Errorf("profile %q not found: the config defines no profiles", name)
+	}
+	return ProfileSpec{}, fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(names, ", "))
 }
 
 // Validate validates the entire configuration
@@ -68,6 +199,22 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// loadRequestFiles loads the files referenced by requests, such as SOAP
+// envelopes, resolving relative paths against dir. kind names the section in
+// errors.
+func loadRequestFiles(requests []ScheduledRequest, kind, dir string) error {
+	for i := range requests {
+		req := &requests[i]
+		req.HTTP.resolveBodyFile(dir)
+		if req.SOAP != nil {
+			if err := req.SOAP.loadEnvelope(dir); err != nil {
+				return fmt.Errorf("%s %d (%s): %w", kind, i, req.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
 // Validate validates a single scheduled request
 func (r *ScheduledRequest) Validate() error {
 	if r.Name == "" {
@@ -81,13 +228,222 @@ func (r *ScheduledRequest) Validate() error {
 		return err
 	}
 
-	if err := r.HTTP.Validate(); err != nil {
+	if len(r.Capture) > 0 {
+		return &ValidationError{
+			Field:   "capture",
+			Message: "only setup requests can capture values",
+		}
+	}
+
+	if err := r.validateFanOut(); err != nil {
 		return err
 	}
 
+	return r.validateTarget()
+}
+
+// ValidateUnscheduled validates a request that runs outside the schedule,
+// such as a setup or teardown request, which must not set a schedule
+func (r *ScheduledRequest) ValidateUnscheduled() error {
+	if r.Name == "" {
+		return &ValidationError{
+			Field:   "name",
+			Message: "request name is required",
+		}
+	}
+
+	if r.Schedule != (ScheduleSpec{}) {
+		return &ValidationError{
+			Field:   "schedule",
+			Message: "this request runs once outside the schedule and cannot set one",
+		}
+	}
+
+	if r.FanOut != "" {
+		return &ValidationError{
+			Field:   "fan_out",
+			Message: "this request runs once outside the schedule and cannot fan out",
+		}
+	}
+
+	if err := validateSources("capture", r.Capture); err != nil {
+		return err
+	}
+
+	return r.validateTarget()
+}
+
+// validateTarget validates what a request sends, its thresholds and the
+// state it keeps
+func (r *ScheduledRequest) validateTarget() error {
+	if r.ID != "" && !validRequestID(r.ID) {
+		return &ValidationError{
+			Field:   "id",
+			Message: fmt.Sprintf("invalid id %q: use letters, digits, '.', '_' and '-'", r.ID),
+		}
+	}
+
+	if err := r.validateType(); err != nil {
+		return err
+	}
+
+	if err := validateSources("state", r.State); err != nil {
+		return err
+	}
+
+	if err := validateSources("http.extract", r.HTTP.Extract); err != nil {
+		return err
+	}
+
+	if err := r.Expect.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.SLO.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.Retry.Validate(); err != nil {
+		return err
+	}
+
+	if threshold, err := r.SlowThresholdDuration(); err != nil || threshold < 0 {
+		return &ValidationError{
+			Field:   "slow_threshold",
+			Message: fmt.Sprintf("invalid duration: %s", r.SlowThreshold),
+		}
+	}
+
+	if timeout, err := r.TimeoutDuration(); err != nil || timeout < 0 {
+		return &ValidationError{
+			Field:   "timeout",
+			Message: fmt.Sprintf("invalid duration: %s", r.Timeout),
+		}
+	}
+
 	return nil
 }
 
+// validateType checks that exactly one request type is configured and that
+// its section is valid
+func (r *ScheduledRequest) validateType() error {
+	var types []string
+	if r.HTTP.Method != "" || !r.HTTP.URL.IsZero() || len(r.HTTP.Headers) > 0 || !r.HTTP.Body.IsZero() {
+		types = append(types, TypeHTTP)
+	}
+	if r.SSE != nil {
+		types = append(types, TypeSSE)
+	}
+	if r.Kafka != nil {
+		types = append(types, TypeKafka)
+	}
+	if r.AMQP != nil {
+		types = append(types, TypeAMQP)
+	}
+	if r.Redis != nil {
+		types = append(types, TypeRedis)
+	}
+	if r.NATS != nil {
+		types = append(types, TypeNATS)
+	}
+	if r.SQS != nil {
+		types = append(types, TypeSQS)
+	}
+	if r.SNS != nil {
+		types = append(types, TypeSNS)
+	}
+	if r.SOAP != nil {
+		types = append(types, TypeSOAP)
+	}
+	if r.JSONRPC != nil {
+		types = append(types, TypeJSONRPC)
+	}
+	if r.GRPC != nil {
+		types = append(types, TypeGRPC)
+	}
+	if r.Plugin != nil {
+		types = append(types, "plugin")
+	}
+	if len(types) > 1 {
+		return &ValidationError{
+			Field:   "request",
+			Message: fmt.Sprintf("only one request type may be specified, found %s", strings.Join(types, " and ")),
+		}
+	}
+
+	if r.Plugin != nil {
+		return r.Plugin.Validate()
+	}
+	switch r.Type() {
+	case TypeSSE:
+		return r.SSE.Validate()
+	case TypeKafka:
+		return r.Kafka.Validate()
+	case TypeAMQP:
+		return r.AMQP.Validate()
+	case TypeRedis:
+		return r.Redis.Validate()
+	case TypeNATS:
+		return r.NATS.Validate()
+	case TypeSQS:
+		return r.SQS.Validate()
+	case TypeSNS:
+		return r.SNS.Validate()
+	case TypeSOAP:
+		return r.SOAP.Validate()
+	case TypeJSONRPC:
+		return r.JSONRPC.Validate()
+	case TypeGRPC:
+		return r.GRPC.Validate()
+	default:
+		return r.HTTP.Validate()
+	}
+}
+
+// standardMethods are the HTTP methods accepted without allow_custom_methods
+var standardMethods = map[string]bool{
+	"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true,
+	"HEAD": true, "OPTIONS": true, "TRACE": true,
+}
+
+// ValidateMethod checks that method is a standard HTTP method or, when the
+// request allows custom methods, any valid method token such as PROPFIND
+func (h *HttpRequestSpec) ValidateMethod(method string) error {
+	if standardMethods[strings.ToUpper(method)] {
+		return nil
+	}
+	if !h.AllowCustomMethods {
+		return &ValidationError{
+			Field:   "http.method",
+			Message: fmt.Sprintf("invalid HTTP method: %s (set allow_custom_methods to send nonstandard methods)", method),
+		}
+	}
+	if !isToken(method) {
+		return &ValidationError{
+			Field:   "http.method",
+			Message: fmt.Sprintf("invalid HTTP method: %q is not a valid method token", method),
+		}
+	}
+	return nil
+}
+
+// isToken reports whether s is an HTTP token (RFC 9110, section 5.6.2), the
+// syntax of method names
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // Validate validates HTTP request specification
 func (h *HttpRequestSpec) Validate() error {
 	if h.Method == "" {
@@ -97,23 +453,83 @@ func (h *HttpRequestSpec) Validate() error {
 		}
 	}
 
-	if h.URL == "" {
+	if h.URL.IsZero() {
 		return &ValidationError{
 			Field:   "http.url",
 			Message: "HTTP URL is required",
 		}
 	}
 
-	// Validate HTTP method
-	validMethods := map[string]bool{
-		"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true,
-		"HEAD": true, "OPTIONS": true, "TRACE": true,
+	// A templated method is checked once it has been evaluated
+	if !IsTemplateString(h.Method) {
+		if err := h.ValidateMethod(h.Method); err != nil {
+			return err
+		}
 	}
 
-	if !validMethods[strings.ToUpper(h.Method)] {
+	if !h.Body.IsZero() && h.BodyFile != "" {
 		return &ValidationError{
-			Field:   "http.method",
-			Message: fmt.Sprintf("invalid HTTP method: %s", h.Method),
+			Field:   "http.body_file",
+			Message: "body and body_file cannot both be set",
+		}
+	}
+
+	return nil
+}
+
+// Validate validates a notification specification
+func (n *NotificationSpec) Validate() error {
+	if n.Name == "" {
+		return &ValidationError{
+			Field:   "notifications.name",
+			Message: "notification name is required",
+		}
+	}
+
+	if n.URL == "" {
+		return &ValidationError{
+			Field:   "notifications.url",
+			Message: "notification URL is required",
+		}
+	}
+
+	if n.After < 0 {
+		return &ValidationError{
+			Field:   "notifications.after",
+			Message: "consecutive failure threshold must be non-negative",
+		}
+	}
+
+	return nil
+}
+
+// Validate validates a runner profile
+func (p *ProfileSpec) Validate() error {
+	for field, value := range map[string]*int{"workers": p.Workers, "concurrency": p.Concurrency, "count": p.Count} {
+		if value != nil && *value < 1 {
+			return &ValidationError{
+				Field:   "profiles." + field,
+				Message: "must be at least 1",
+			}
+		}
+	}
+
+	if p.RPS != nil && *p.RPS < 0 {
+		return &ValidationError{
+			Field:   "profiles.rps",
+			Message: "requests per second must be non-negative",
+		}
+	}
+
+	for field, value := range map[string]string{"timeout": p.Timeout, "duration": p.Duration} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return &ValidationError{
+				Field:   "profiles." + field,
+				Message: fmt.Sprintf("invalid duration: %s", value),
+			}
 		}
 	}
 
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 // DynamicString represents a string that can be either literal or a template
@@ -27,6 +29,54 @@ type DynamicAny struct {
 	isTemplate bool
 }
 
+// NewLiteralString returns a DynamicString holding a literal value
+func NewLiteralString(value string) DynamicString {
+	return DynamicString{value: value}
+}
+
+// NewTemplateString returns a DynamicString evaluated from a template
+func NewTemplateString(template string) DynamicString {
+	return DynamicString{template: template, isTemplate: true}
+}
+
+// NewLiteralInt64 returns a DynamicInt64 holding a literal value
+func NewLiteralInt64(value int64) DynamicInt64 {
+	return DynamicInt64{value: value}
+}
+
+// NewTemplateInt64 returns a DynamicInt64 evaluated from a template
+func NewTemplateInt64(template string) DynamicInt64 {
+	return DynamicInt64{template: template, isTemplate: true}
+}
+
+// NewLiteralAny returns a DynamicAny holding a literal value
+func NewLiteralAny(value interface{}) DynamicAny {
+	return DynamicAny{value: value}
+}
+
+// NewTemplateAny returns a DynamicAny evaluated from a template
+func NewTemplateAny(template string) DynamicAny {
+	return DynamicAny{template: template, isTemplate: true}
+}
+
+// LiteralHeaders returns headers holding the literal values of headers, whose
+// inline {{ }} templates are still evaluated with the request
+func LiteralHeaders(headers map[string]string) map[string]DynamicString {
+	if headers == nil {
+		return nil
+	}
+	literal := make(map[string]DynamicString, len(headers))
+	for key, value := range headers {
+		literal[key] = NewLiteralString(value)
+	}
+	return literal
+}
+
+// templateObject is the form a template takes in a config file
+type templateObject struct {
+	Template string `json:"template" yaml:"template"`
+}
+
 // UnmarshalJSON implements json.Unmarshaler for DynamicString
 func (d *DynamicString) UnmarshalJSON(data []byte) error {
 	// Try to unmarshal as a string first (literal value)
@@ -38,9 +88,7 @@ func (d *DynamicString) UnmarshalJSON(data []byte) error {
 	}
 
 	// Try to unmarshal as a template object
-	var templateObj struct {
-		Template string `json:"template"`
-	}
+	var templateObj templateObject
 	if err := json.Unmarshal(data, &templateObj); err == nil && templateObj.Template != "" {
 		d.template = templateObj.Template
 		d.isTemplate = true
@@ -61,9 +109,7 @@ func (d *DynamicInt64) UnmarshalJSON(data []byte) error {
 	}
 
 	// Try to unmarshal as a template object
-	var templateObj struct {
-		Template string `json:"template"`
-	}
+	var templateObj templateObject
 	if err := json.Unmarshal(data, &templateObj); err == nil && templateObj.Template != "" {
 		d.template = templateObj.Template
 		d.isTemplate = true
@@ -76,9 +122,7 @@ func (d *DynamicInt64) UnmarshalJSON(data []byte) error {
 // UnmarshalJSON implements json.Unmarshaler for DynamicAny
 func (d *DynamicAny) UnmarshalJSON(data []byte) error {
 	// Try to unmarshal as a template object first
-	var templateObj struct {
-		Template string `json:"template"`
-	}
+	var templateObj templateObject
 	if err := json.Unmarshal(data, &templateObj); err == nil && templateObj.Template != "" {
 		d.template = templateObj.Template
 		d.isTemplate = true
@@ -91,20 +135,187 @@ func (d *DynamicAny) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// unmarshalTemplateYAML reports the template of a {template: "..."} mapping
+// node, or false for any other node
+func unmarshalTemplateYAML(node *yaml.Node) (string, bool) {
+	if node.Kind != yaml.MappingNode {
+		return "", false
+	}
+	var templateObj templateObject
+	if err := node.Decode(&templateObj); err != nil || templateObj.Template == "" {
+		return "", false
+	}
+	return templateObj.Template, true
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for DynamicString, accepting
+// the same forms as UnmarshalJSON
+func (d *DynamicString) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var s string
+		if err := node.Decode(&s); err == nil {
+			d.value = s
+			d.isTemplate = false
+			return nil
+		}
+	}
+	if template, ok := unmarshalTemplateYAML(node); ok {
+		d.template = template
+		d.isTemplate = true
+		return nil
+	}
+	return fmt.Errorf("DynamicString must be a string or {template: \"...\"}")
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for DynamicInt64
+func (d *DynamicInt64) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var n int64
+		if err := node.Decode(&n); err == nil {
+			d.value = n
+			d.isTemplate = false
+			return nil
+		}
+	}
+	if template, ok := unmarshalTemplateYAML(node); ok {
+		d.template = template
+		d.isTemplate = true
+		return nil
+	}
+	return fmt.Errorf("DynamicInt64 must be a number or {template: \"...\"}")
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for DynamicAny. Literal values
+// are kept as YAML decodes them, so integers in a request body stay
+// integers rather than becoming JSON numbers.
+func (d *DynamicAny) UnmarshalYAML(node *yaml.Node) error {
+	if template, ok := unmarshalTemplateYAML(node); ok {
+		d.template = template
+		d.isTemplate = true
+		return nil
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	d.value = value
+	d.isTemplate = false
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler for DynamicString, writing the
+// form UnmarshalJSON reads
+func (d DynamicString) MarshalJSON() ([]byte, error) {
+	if d.isTemplate {
+		return json.Marshal(templateObject{Template: d.template})
+	}
+	return json.Marshal(d.value)
+}
+
+// MarshalJSON implements json.Marshaler for DynamicInt64
+func (d DynamicInt64) MarshalJSON() ([]byte, error) {
+	if d.isTemplate {
+		return json.Marshal(templateObject{Template: d.template})
+	}
+	return json.Marshal(d.value)
+}
+
+// MarshalJSON implements json.Marshaler for DynamicAny
+func (d DynamicAny) MarshalJSON() ([]byte, error) {
+	if d.isTemplate {
+		return json.Marshal(templateObject{Template: d.template})
+	}
+	if raw, ok := d.value.(json.RawMessage); ok {
+		return raw, nil
+	}
+	return json.Marshal(d.value)
+}
+
+// MarshalYAML implements yaml.Marshaler for DynamicString
+func (d DynamicString) MarshalYAML() (interface{}, error) {
+	if d.isTemplate {
+		return templateObject{Template: d.template}, nil
+	}
+	return d.value, nil
+}
+
+// MarshalYAML implements yaml.Marshaler for DynamicInt64
+func (d DynamicInt64) MarshalYAML() (interface{}, error) {
+	if d.isTemplate {
+		return templateObject{Template: d.template}, nil
+	}
+	return d.value, nil
+}
+
+// MarshalYAML implements yaml.Marshaler for DynamicAny
+func (d DynamicAny) MarshalYAML() (interface{}, error) {
+	if d.isTemplate {
+		return templateObject{Template: d.template}, nil
+	}
+	if _, ok := d.value.(json.RawMessage); !ok {
+		return d.value, nil
+	}
+	return d.Literal()
+}
+
 // IsTemplate returns true if this dynamic value contains a template
-func (d *DynamicString) IsTemplate() bool { return d.isTemplate }
-func (d *DynamicInt64) IsTemplate() bool  { return d.isTemplate }
-func (d *DynamicAny) IsTemplate() bool    { return d.isTemplate }
+func (d DynamicString) IsTemplate() bool { return d.isTemplate }
+func (d DynamicInt64) IsTemplate() bool  { return d.isTemplate }
+func (d DynamicAny) IsTemplate() bool    { return d.isTemplate }
 
 // GetTemplate returns the template string if this is a template, empty string otherwise
-func (d *DynamicString) GetTemplate() string { return d.template }
-func (d *DynamicInt64) GetTemplate() string  { return d.template }
-func (d *DynamicAny) GetTemplate() string    { return d.template }
+func (d DynamicString) GetTemplate() string { return d.template }
+func (d DynamicInt64) GetTemplate() string  { return d.template }
+func (d DynamicAny) GetTemplate() string    { return d.template }
+
+// IsZero reports whether the value is unset, so omitempty leaves it out
+func (d DynamicString) IsZero() bool { return !d.isTemplate && d.value == "" }
+func (d DynamicInt64) IsZero() bool  { return !d.isTemplate && d.value == 0 }
+func (d DynamicAny) IsZero() bool    { return !d.isTemplate && d.value == nil }
+
+// Raw returns the value as written: the template of a template, the literal
+// value otherwise
+func (d DynamicString) Raw() string {
+	if d.isTemplate {
+		return d.template
+	}
+	return d.value
+}
 
 // GetValue returns the literal value if this is not a template
-func (d *DynamicString) GetValue() string   { return d.value }
-func (d *DynamicInt64) GetValue() int64     { return d.value }
-func (d *DynamicAny) GetValue() interface{} { return d.value }
+func (d DynamicString) GetValue() string   { return d.value }
+func (d DynamicInt64) GetValue() int64     { return d.value }
+func (d DynamicAny) GetValue() interface{} { return d.value }
+
+// Literal returns the literal value of a DynamicAny decoded into plain Go
+// values (maps, slices, strings, float64s, bools), or nil for a template
+func (d *DynamicAny) Literal() (interface{}, error) {
+	if d.isTemplate {
+		return nil, nil
+	}
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Decode stores the literal value of a DynamicAny in the value pointed to by
+// target, as json.Unmarshal would
+func (d *DynamicAny) Decode(target interface{}) error {
+	if d.isTemplate {
+		return fmt.Errorf("DynamicAny is a template, not a literal value")
+	}
+	raw, ok := d.value.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(d.value); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(raw, target)
+}
 
 // String returns a string representation for debugging
 func (d *DynamicString) String() string {
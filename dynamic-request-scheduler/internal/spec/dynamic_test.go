@@ -1,8 +1,11 @@
 package spec
 
 import (
+	"bytes"
 	"encoding/json"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestDynamicString_UnmarshalJSON(t *testing.T) {
@@ -337,3 +340,185 @@ func TestDynamicInt64_Methods(t *testing.T) {
 		t.Errorf("String() = %v, want %v", di.String(), expectedStr)
 	}
 }
+
+func TestDynamic_MarshalJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		value interface{}
+	}{
+		{name: "literal string", input: `"hello"`, value: &DynamicString{}},
+		{name: "template string", input: `{"template":"{{ uuid }}"}`, value: &DynamicString{}},
+		{name: "literal int64", input: `42`, value: &DynamicInt64{}},
+		{name: "template int64", input: `{"template":"{{ now | unix }}"}`, value: &DynamicInt64{}},
+		{name: "literal object", input: `{"a":[1,"b",true]}`, value: &DynamicAny{}},
+		{name: "template any", input: `{"template":"{{ randInt 1 10 }}"}`, value: &DynamicAny{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := json.Unmarshal([]byte(tt.input), tt.value); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			got, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.input {
+				t.Errorf("Marshal() = %s, want %s", got, tt.input)
+			}
+		})
+	}
+}
+
+func TestDynamic_MarshalYAML(t *testing.T) {
+	values := struct {
+		Name     DynamicString `yaml:"name"`
+		Count    DynamicInt64  `yaml:"count"`
+		Payload  DynamicAny    `yaml:"payload"`
+		Computed DynamicAny    `yaml:"computed"`
+	}{
+		Name:     NewLiteralString("hello"),
+		Count:    NewTemplateInt64("{{ now | unix }}"),
+		Payload:  NewLiteralAny(json.RawMessage(`{"ids":[1,2]}`)),
+		Computed: NewTemplateAny("{{ uuid }}"),
+	}
+
+	got, err := yaml.Marshal(values)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `name: hello
+count:
+    template: '{{ now | unix }}'
+payload:
+    ids:
+        - 1
+        - 2
+computed:
+    template: '{{ uuid }}'
+`
+	if string(got) != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDynamicAny_Decode(t *testing.T) {
+	var d DynamicAny
+	if err := json.Unmarshal([]byte(`{"id": 7, "tags": ["a"]}`), &d); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	var target struct {
+		ID   int      `json:"id"`
+		Tags []string `json:"tags"`
+	}
+	if err := d.Decode(&target); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if target.ID != 7 || len(target.Tags) != 1 || target.Tags[0] != "a" {
+		t.Errorf("Decode() = %+v", target)
+	}
+
+	literal, err := d.Literal()
+	if err != nil {
+		t.Fatalf("Literal() error = %v", err)
+	}
+	if object, ok := literal.(map[string]interface{}); !ok || object["id"] != float64(7) {
+		t.Errorf("Literal() = %#v", literal)
+	}
+
+	built := NewLiteralAny(map[string]interface{}{"id": 7})
+	if err := built.Decode(&target); err != nil || target.ID != 7 {
+		t.Errorf("Decode() of constructed value = %+v, %v", target, err)
+	}
+
+	template := NewTemplateAny("{{ uuid }}")
+	if err := template.Decode(&target); err == nil {
+		t.Error("Decode() of a template should fail")
+	}
+}
+
+func TestDynamic_UnmarshalYAML(t *testing.T) {
+	var values struct {
+		Name     DynamicString `yaml:"name"`
+		Computed DynamicString `yaml:"computed"`
+		Count    DynamicInt64  `yaml:"count"`
+		Stamp    DynamicInt64  `yaml:"stamp"`
+		Payload  DynamicAny    `yaml:"payload"`
+		Random   DynamicAny    `yaml:"random"`
+	}
+	input := `
+name: hello
+computed:
+  template: "{{ uuid }}"
+count: 42
+stamp: {template: "{{ now | unix }}"}
+payload:
+  ids: [1, 2]
+  template: ""
+random:
+  template: "{{ randInt 1 10 }}"
+`
+	if err := yaml.Unmarshal([]byte(input), &values); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if values.Name.IsTemplate() || values.Name.GetValue() != "hello" {
+		t.Errorf("name = %v", values.Name.String())
+	}
+	if !values.Computed.IsTemplate() || values.Computed.GetTemplate() != "{{ uuid }}" {
+		t.Errorf("computed = %v", values.Computed.String())
+	}
+	if values.Count.IsTemplate() || values.Count.GetValue() != 42 {
+		t.Errorf("count = %v", values.Count.String())
+	}
+	if !values.Stamp.IsTemplate() || values.Stamp.GetTemplate() != "{{ now | unix }}" {
+		t.Errorf("stamp = %v", values.Stamp.String())
+	}
+	if !values.Random.IsTemplate() || values.Random.GetTemplate() != "{{ randInt 1 10 }}" {
+		t.Errorf("random = %v", values.Random.String())
+	}
+
+	// A literal read from YAML matches the same literal read from JSON
+	var fromJSON DynamicAny
+	if err := json.Unmarshal([]byte(`{"ids": [1, 2], "template": ""}`), &fromJSON); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	got, _ := json.Marshal(values.Payload)
+	want, _ := json.Marshal(fromJSON)
+	if values.Payload.IsTemplate() || string(got) != string(compactJSON(t, want)) {
+		t.Errorf("payload = %s, want %s", got, want)
+	}
+}
+
+func TestDynamic_UnmarshalYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		target interface{}
+	}{
+		{name: "string from list", input: `[a, b]`, target: &DynamicString{}},
+		{name: "string from empty template", input: `{template: ""}`, target: &DynamicString{}},
+		{name: "int64 from word", input: `forty-two`, target: &DynamicInt64{}},
+		{name: "int64 from other object", input: `{other: 1}`, target: &DynamicInt64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := yaml.Unmarshal([]byte(tt.input), tt.target); err == nil {
+				t.Error("Unmarshal() should fail")
+			}
+		})
+	}
+}
+
+// compactJSON removes insignificant whitespace from data
+func compactJSON(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		t.Fatalf("json.Compact() error = %v", err)
+	}
+	return buf.Bytes()
+}
@@ -1,14 +1,22 @@
 package spec
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/rand"
 	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
 // Evaluator resolves all dynamic fields in a request specification
 type Evaluator struct {
 	engine *TemplateEngine
+
+	// nextJSONRPCID numbers JSON-RPC calls that do not set an id
+	nextJSONRPCID atomic.Int64
 }
 
 // NewEvaluator creates a new evaluator with the given template engine
@@ -22,26 +30,71 @@ func (e *Evaluator) EvaluateRequest(req *ScheduledRequest) (*ResolvedRequest, er
 		return nil, fmt.Errorf("request cannot be nil")
 	}
 
+	httpSpec := req.HTTP
+	switch req.Type() {
+	case TypeSSE:
+		// A subscription is a GET that stays open, sharing URL and header resolution
+		httpSpec = HttpRequestSpec{Method: "GET", URL: NewLiteralString(req.SSE.URL), Headers: LiteralHeaders(req.SSE.Headers)}
+	case TypeKafka:
+		// The record value and headers resolve like an HTTP body and headers
+		httpSpec = HttpRequestSpec{Method: "KAFKA", Headers: LiteralHeaders(req.Kafka.Headers), Body: NewLiteralAny(req.Kafka.Value)}
+	case TypeAMQP:
+		httpSpec = HttpRequestSpec{Method: "AMQP", Headers: LiteralHeaders(req.AMQP.Headers), Body: NewLiteralAny(req.AMQP.Body)}
+	case TypeRedis:
+		httpSpec = HttpRequestSpec{Method: "REDIS"}
+	case TypeNATS:
+		httpSpec = HttpRequestSpec{Method: "NATS", Headers: LiteralHeaders(req.NATS.Headers), Body: NewLiteralAny(req.NATS.Payload)}
+	case TypeSQS:
+		httpSpec = HttpRequestSpec{Method: "SQS", Headers: LiteralHeaders(req.SQS.Attributes), Body: NewLiteralAny(req.SQS.Body)}
+	case TypeSNS:
+		httpSpec = HttpRequestSpec{Method: "SNS", Headers: LiteralHeaders(req.SNS.Attributes), Body: NewLiteralAny(req.SNS.Message)}
+	case TypeSOAP:
+		httpSpec = HttpRequestSpec{Method: "POST", URL: NewLiteralString(req.SOAP.URL), Headers: LiteralHeaders(req.SOAP.Headers)}
+	case TypeJSONRPC:
+		httpSpec = HttpRequestSpec{Method: "POST", URL: NewLiteralString(req.JSONRPC.URL), Headers: LiteralHeaders(req.JSONRPC.Headers)}
+	}
+	if req.GRPC != nil {
+		// Checked by section rather than type, which plugins registered for
+		// grpc share
+		httpSpec = HttpRequestSpec{Method: "GRPC", Headers: LiteralHeaders(req.GRPC.Metadata), Body: NewLiteralAny(req.GRPC.Message)}
+	}
+	if req.Plugin != nil {
+		httpSpec = HttpRequestSpec{Method: strings.ToUpper(req.Plugin.Type), URL: NewLiteralString(req.Plugin.Target), Headers: LiteralHeaders(req.Plugin.Headers), Body: NewLiteralAny(req.Plugin.Body)}
+	}
+
 	resolved := &ResolvedRequest{
 		Name:   req.Name,
-		Method: req.HTTP.Method,
-		URL:    req.HTTP.URL,
+		Method: httpSpec.Method,
+	}
+	if req.SSE != nil {
+		resolved.SSE = req.SSE.options()
 	}
 
-	// Resolve URL if it contains templates
-	if IsTemplateString(resolved.URL) {
-		resolvedURL, err := e.engine.EvaluateTemplate(resolved.URL)
+	// Resolve the method if it is a template, such as one picking a method
+	// at random, and check what it picked
+	if IsTemplateString(resolved.Method) {
+		resolvedMethod, err := e.engine.EvaluateTemplate(resolved.Method)
 		if err != nil {
-			return nil, fmt.Errorf("failed to resolve URL template: %w", err)
+			return nil, fmt.Errorf("failed to resolve method template: %w", err)
+		}
+		resolvedMethod = strings.TrimSpace(resolvedMethod)
+		if err := httpSpec.ValidateMethod(resolvedMethod); err != nil {
+			return nil, fmt.Errorf("resolved method: %w", err)
 		}
-		resolved.URL = resolvedURL
+		resolved.Method = resolvedMethod
+	}
+
+	// Resolve the URL, a DynamicString, to a string
+	resolvedURL, err := e.resolveValue(httpSpec.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve URL template: %w", err)
 	}
+	resolved.URL = resolvedURL.(string)
 
 	// Resolve headers
 	resolved.Headers = make(map[string]string)
-	for key, value := range req.HTTP.Headers {
+	for _, key := range sortedKeys(httpSpec.Headers) {
 		resolvedKey := key
-		resolvedValue := value
 
 		// Resolve header key if it contains templates
 		if IsTemplateString(key) {
@@ -52,26 +105,113 @@ func (e *Evaluator) EvaluateRequest(req *ScheduledRequest) (*ResolvedRequest, er
 			}
 		}
 
-		// Resolve header value if it contains templates
-		if IsTemplateString(value) {
-			var err error
-			resolvedValue, err = e.engine.EvaluateTemplate(value)
-			if err != nil {
-				return nil, fmt.Errorf("failed to resolve header value template: %w", err)
-			}
+		resolvedValue, err := e.resolveValue(httpSpec.Headers[key])
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve header value template: %w", err)
 		}
-
-		resolved.Headers[resolvedKey] = resolvedValue
+		resolved.Headers[resolvedKey] = resolvedValue.(string)
 	}
 
 	// Resolve body recursively
-	if req.HTTP.Body != nil {
-		resolvedBody, err := e.resolveValue(req.HTTP.Body)
+	if !httpSpec.Body.IsZero() {
+		resolvedBody, err := e.resolveValue(httpSpec.Body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve body: %w", err)
 		}
 		resolved.Body = resolvedBody
 	}
+	resolved.BodyFile = httpSpec.BodyFile
+
+	if req.Kafka != nil {
+		target, err := e.resolveKafka(req.Kafka)
+		if err != nil {
+			return nil, err
+		}
+		resolved.Kafka = target
+		resolved.URL = target.URL()
+	}
+
+	if req.AMQP != nil {
+		target, err := e.resolveAMQP(req.AMQP)
+		if err != nil {
+			return nil, err
+		}
+		resolved.AMQP = target
+		resolved.URL = target.DisplayURL()
+	}
+
+	if req.Redis != nil {
+		target, err := e.resolveRedis(req.Redis)
+		if err != nil {
+			return nil, err
+		}
+		resolved.Redis = target
+		resolved.URL = target.DisplayURL()
+		// Show the command where other request types show their body
+		resolved.Body = target.Args
+	}
+
+	if req.NATS != nil {
+		target, err := e.resolveNATS(req.NATS)
+		if err != nil {
+			return nil, err
+		}
+		resolved.NATS = target
+		resolved.URL = target.DisplayURL()
+	}
+
+	if req.SQS != nil {
+		target, err := e.resolveSQS(req.SQS)
+		if err != nil {
+			return nil, err
+		}
+		resolved.SQS = target
+		resolved.URL = target.QueueURL
+	}
+
+	if req.SNS != nil {
+		target, err := e.resolveSNS(req.SNS)
+		if err != nil {
+			return nil, err
+		}
+		resolved.SNS = target
+		resolved.URL = target.TopicARN
+	}
+
+	if req.SOAP != nil {
+		envelope, err := e.resolveSOAP(req.SOAP, resolved.Headers)
+		if err != nil {
+			return nil, err
+		}
+		resolved.SOAP = &SOAPOptions{Version: req.SOAP.version()}
+		resolved.Body = envelope
+	}
+
+	if req.JSONRPC != nil {
+		call, options, err := e.resolveJSONRPC(req.JSONRPC)
+		if err != nil {
+			return nil, err
+		}
+		resolved.JSONRPC = options
+		resolved.Body = call
+	}
+
+	if req.GRPC != nil {
+		target, err := e.resolveGRPC(req.GRPC)
+		if err != nil {
+			return nil, err
+		}
+		resolved.GRPC = target
+		resolved.URL = target.URL()
+	}
+
+	if req.Plugin != nil {
+		target, err := e.resolvePlugin(req.Plugin)
+		if err != nil {
+			return nil, err
+		}
+		resolved.Plugin = target
+	}
 
 	// Compute scheduled time from schedule specification
 	scheduledTime, err := e.computeScheduledTime(req.Schedule)
@@ -83,6 +223,26 @@ func (e *Evaluator) EvaluateRequest(req *ScheduledRequest) (*ResolvedRequest, er
 	return resolved, nil
 }
 
+// resolveString evaluates s if it contains templates
+func (e *Evaluator) resolveString(s string) (string, error) {
+	if IsTemplateString(s) {
+		return e.engine.EvaluateTemplate(s)
+	}
+	return s, nil
+}
+
+// sortedKeys returns the keys of m in order. Maps are resolved in this order
+// so a seeded engine hands out the same random values to the same fields on
+// every run.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // resolveValue recursively resolves templates in any value
 func (e *Evaluator) resolveValue(v interface{}) (interface{}, error) {
 	if v == nil {
@@ -98,7 +258,8 @@ func (e *Evaluator) resolveValue(v interface{}) (interface{}, error) {
 
 	case map[string]interface{}:
 		resolved := make(map[string]interface{})
-		for key, value := range val {
+		for _, key := range sortedKeys(val) {
+			value := val[key]
 			resolvedKey := key
 			resolvedValue := value
 
@@ -136,7 +297,8 @@ func (e *Evaluator) resolveValue(v interface{}) (interface{}, error) {
 		if val.IsTemplate() {
 			return e.engine.EvaluateTemplate(val.GetTemplate())
 		}
-		return val.GetValue(), nil
+		// Literals may still hold inline templates
+		return e.resolveValue(val.GetValue())
 
 	case DynamicInt64:
 		if val.IsTemplate() {
@@ -148,7 +310,15 @@ func (e *Evaluator) resolveValue(v interface{}) (interface{}, error) {
 		if val.IsTemplate() {
 			return e.engine.EvaluateTemplate(val.GetTemplate())
 		}
-		return val.GetValue(), nil
+		// Literals read from JSON are kept raw until they are resolved
+		if _, ok := val.GetValue().(json.RawMessage); ok {
+			literal, err := val.Literal()
+			if err != nil {
+				return nil, err
+			}
+			return e.resolveValue(literal)
+		}
+		return e.resolveValue(val.GetValue())
 
 	default:
 		// For other types, try to use reflection to handle nested structs
@@ -235,3 +405,38 @@ func (e *Evaluator) SetVariable(key string, value interface{}) {
 func (e *Evaluator) SetSeed(seed int64) {
 	e.engine.SetSeed(seed)
 }
+
+// ForExecution returns an evaluator for the n-th execution of a run; see
+// TemplateEngine.ForExecution
+func (e *Evaluator) ForExecution(n int64) *Evaluator {
+	return NewEvaluator(e.engine.ForExecution(n))
+}
+
+// ForRequest returns an evaluator for the n-th execution of a run, which is
+// the given firing of the named request; see TemplateEngine.ForRequest
+func (e *Evaluator) ForRequest(n int64, request string, firing int64) *Evaluator {
+	return NewEvaluator(e.engine.ForRequest(n, request, firing))
+}
+
+// Rand returns a random source for values drawn outside templates, such as
+// retry jitter. A seeded evaluator's source is derived from its seed and the
+// stream name, so the values repeat with --seed and --replay without
+// changing what the template functions draw; an unseeded one returns nil.
+func (e *Evaluator) Rand(stream string) *rand.Rand {
+	if e.engine.ctx.Seed == 0 {
+		return nil
+	}
+	return rand.New(rand.NewSource(requestSeed(e.engine.ctx.Seed, stream, 1)))
+}
+
+// WithState returns an evaluator whose templates read the given request state
+// with the state function
+func (e *Evaluator) WithState(state map[string]interface{}) *Evaluator {
+	return NewEvaluator(e.engine.WithState(state))
+}
+
+// WithVariables returns an evaluator whose templates read the given run
+// variables
+func (e *Evaluator) WithVariables(variables map[string]interface{}) *Evaluator {
+	return NewEvaluator(e.engine.WithVariables(variables))
+}
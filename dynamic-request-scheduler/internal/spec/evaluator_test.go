@@ -1,9 +1,12 @@
 package spec
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestNewEvaluator(t *testing.T) {
@@ -46,11 +49,11 @@ func TestEvaluator_EvaluateRequest(t *testing.T) {
 				},
 				HTTP: HttpRequestSpec{
 					Method: "GET",
-					URL:    "https://api.example.com/health",
-					Headers: map[string]string{
+					URL:    NewLiteralString("https://api.example.com/health"),
+					Headers: LiteralHeaders(map[string]string{
 						"User-Agent": "TestClient",
-					},
-					Body: nil,
+					}),
+					Body: NewLiteralAny(nil),
 				},
 			},
 			want: &ResolvedRequest{
@@ -74,13 +77,13 @@ func TestEvaluator_EvaluateRequest(t *testing.T) {
 				},
 				HTTP: HttpRequestSpec{
 					Method: "POST",
-					URL:    "https://api.example.com/users/{{ uuid }}",
-					Headers: map[string]string{
+					URL:    NewLiteralString("https://api.example.com/users/{{ uuid }}"),
+					Headers: LiteralHeaders(map[string]string{
 						"Content-Type": "application/json",
-					},
-					Body: map[string]interface{}{
+					}),
+					Body: NewLiteralAny(map[string]interface{}{
 						"id": "{{ uuid }}",
-					},
+					}),
 				},
 			},
 			wantErr: false,
@@ -94,13 +97,13 @@ func TestEvaluator_EvaluateRequest(t *testing.T) {
 				},
 				HTTP: HttpRequestSpec{
 					Method: "GET",
-					URL:    "https://api.example.com/data",
-					Headers: map[string]string{
+					URL:    NewLiteralString("https://api.example.com/data"),
+					Headers: LiteralHeaders(map[string]string{
 						"X-Trace-ID":    "{{ uuid }}",
 						"X-Timestamp":   "{{ now | unix }}",
 						"Authorization": "Bearer {{ .Variables.api_key }}",
-					},
-					Body: nil,
+					}),
+					Body: NewLiteralAny(nil),
 				},
 			},
 			wantErr: false,
@@ -114,11 +117,11 @@ func TestEvaluator_EvaluateRequest(t *testing.T) {
 				},
 				HTTP: HttpRequestSpec{
 					Method: "POST",
-					URL:    "https://api.example.com/events",
-					Headers: map[string]string{
+					URL:    NewLiteralString("https://api.example.com/events"),
+					Headers: LiteralHeaders(map[string]string{
 						"Content-Type": "application/json",
-					},
-					Body: map[string]interface{}{
+					}),
+					Body: NewLiteralAny(map[string]interface{}{
 						"event_id":  "{{ uuid }}",
 						"timestamp": "{{ now | rfc3339 }}",
 						"sequence":  "{{ seq }}",
@@ -126,7 +129,7 @@ func TestEvaluator_EvaluateRequest(t *testing.T) {
 							"source":  "test",
 							"version": "{{ seq }}",
 						},
-					},
+					}),
 				},
 			},
 			wantErr: false,
@@ -140,9 +143,9 @@ func TestEvaluator_EvaluateRequest(t *testing.T) {
 				},
 				HTTP: HttpRequestSpec{
 					Method:  "GET",
-					URL:     "https://api.example.com/health",
-					Headers: map[string]string{},
-					Body:    nil,
+					URL:     NewLiteralString("https://api.example.com/health"),
+					Headers: LiteralHeaders(map[string]string{}),
+					Body:    NewLiteralAny(nil),
 				},
 			},
 			want: &ResolvedRequest{
@@ -164,9 +167,9 @@ func TestEvaluator_EvaluateRequest(t *testing.T) {
 				},
 				HTTP: HttpRequestSpec{
 					Method:  "GET",
-					URL:     "https://api.example.com/health",
-					Headers: map[string]string{},
-					Body:    nil,
+					URL:     NewLiteralString("https://api.example.com/health"),
+					Headers: LiteralHeaders(map[string]string{}),
+					Body:    NewLiteralAny(nil),
 				},
 			},
 			wantErr: false,
@@ -473,6 +476,148 @@ func TestEvaluator_ComputeScheduledTime(t *testing.T) {
 	}
 }
 
+func TestEvaluator_ResolvedType(t *testing.T) {
+	evaluator := NewEvaluator(NewTemplateEngine(&EvaluationContext{Variables: make(map[string]interface{}), Clock: &RealClock{}}))
+	relative := "1m"
+	requests := []ScheduledRequest{
+		{Name: "http", HTTP: HttpRequestSpec{Method: "GET", URL: NewLiteralString("http://localhost/health")}},
+		{Name: "kafka", Kafka: &KafkaSpec{Brokers: []string{"localhost:9092"}, Topic: "orders"}},
+		{Name: "redis", Redis: &RedisSpec{Command: []interface{}{"PING"}}},
+	}
+
+	for _, req := range requests {
+		req.Schedule = ScheduleSpec{Relative: &relative}
+		resolved, err := evaluator.EvaluateRequest(&req)
+		if err != nil {
+			t.Fatalf("EvaluateRequest(%s): %v", req.Name, err)
+		}
+		if resolved.Type() != req.Type() {
+			t.Errorf("Resolved %s request has type %s, want %s", req.Name, resolved.Type(), req.Type())
+		}
+	}
+}
+
+func TestEvaluator_MethodTemplate(t *testing.T) {
+	evaluator := NewEvaluator(NewTemplateEngine(&EvaluationContext{
+		Variables: map[string]interface{}{"method": "propfind", "standard": "delete"},
+		Clock:     &RealClock{},
+	}))
+	relative := "1m"
+	tests := []struct {
+		name    string
+		http    HttpRequestSpec
+		want    string
+		wantErr bool
+	}{
+		{name: "standard method", http: HttpRequestSpec{Method: `{{ var "standard" | upper }}`}, want: "DELETE"},
+		{name: "picked method", http: HttpRequestSpec{Method: `{{ pick "GET" "GET" }}`}, want: "GET"},
+		{name: "custom method allowed", http: HttpRequestSpec{Method: `{{ var "method" | upper }}`, AllowCustomMethods: true}, want: "PROPFIND"},
+		{name: "custom method not allowed", http: HttpRequestSpec{Method: `{{ var "method" | upper }}`}, wantErr: true},
+		{name: "not a method token", http: HttpRequestSpec{Method: `{{ "GET /" }}`, AllowCustomMethods: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.http.URL = NewLiteralString("http://localhost/files")
+			req := ScheduledRequest{Name: "files", Schedule: ScheduleSpec{Relative: &relative}, HTTP: tt.http}
+			if err := req.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			resolved, err := evaluator.EvaluateRequest(&req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvaluateRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && resolved.Method != tt.want {
+				t.Errorf("Method = %q, want %q", resolved.Method, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_TemplateObjects(t *testing.T) {
+	evaluator := NewEvaluator(NewTemplateEngine(&EvaluationContext{
+		Variables: map[string]interface{}{"host": "localhost", "token": "abc"},
+		Clock:     &RealClock{},
+	}))
+
+	var req ScheduledRequest
+	input := `
+name: objects
+schedule: {relative: "1m"}
+http:
+  method: POST
+  url: {template: 'http://{{ var "host" }}/orders'}
+  headers:
+    Authorization: {template: 'Bearer {{ var "token" }}'}
+    X-Inline: '{{ var "host" }}'
+  body:
+    template: '{{ pick "ping" "ping" }}'
+`
+	if err := yaml.Unmarshal([]byte(input), &req); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	resolved, err := evaluator.EvaluateRequest(&req)
+	if err != nil {
+		t.Fatalf("EvaluateRequest() error = %v", err)
+	}
+	if resolved.URL != "http://localhost/orders" {
+		t.Errorf("URL = %q", resolved.URL)
+	}
+	if resolved.Headers["Authorization"] != "Bearer abc" || resolved.Headers["X-Inline"] != "localhost" {
+		t.Errorf("Headers = %v", resolved.Headers)
+	}
+	if resolved.Body != "ping" {
+		t.Errorf("Body = %v", resolved.Body)
+	}
+
+	// Literal bodies keep the types YAML gives them
+	input = `
+name: literal
+schedule: {relative: "1m"}
+http:
+  method: POST
+  url: http://localhost/orders
+  body: {quantity: 3, note: '{{ var "host" }}'}
+`
+	var literal ScheduledRequest
+	if err := yaml.Unmarshal([]byte(input), &literal); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	resolved, err = evaluator.EvaluateRequest(&literal)
+	if err != nil {
+		t.Fatalf("EvaluateRequest() error = %v", err)
+	}
+	want := map[string]interface{}{"quantity": 3, "note": "localhost"}
+	if !reflect.DeepEqual(resolved.Body, want) {
+		t.Errorf("Body = %#v, want %#v", resolved.Body, want)
+	}
+}
+
+func TestHttpRequestSpec_ValidateMethod(t *testing.T) {
+	tests := []struct {
+		method  string
+		custom  bool
+		wantErr bool
+	}{
+		{method: "GET"},
+		{method: "patch"},
+		{method: "PROPFIND", wantErr: true},
+		{method: "PROPFIND", custom: true},
+		{method: "MKCOL", custom: true},
+		{method: "BAD METHOD", custom: true, wantErr: true},
+		{method: "", custom: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		spec := HttpRequestSpec{AllowCustomMethods: tt.custom}
+		if err := spec.ValidateMethod(tt.method); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateMethod(%q) with custom=%v: error = %v, wantErr %v", tt.method, tt.custom, err, tt.wantErr)
+		}
+	}
+}
+
 func TestEvaluator_SetVariable(t *testing.T) {
 	ctx := &EvaluationContext{
 		Variables: make(map[string]interface{}),
@@ -512,3 +657,36 @@ func stringPtr(s string) *string {
 func int64Ptr(i int64) *int64 {
 	return &i
 }
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func BenchmarkEvaluator_EvaluateRequest(b *testing.B) {
+	evaluator := NewEvaluator(NewTemplateEngine(&EvaluationContext{
+		Variables: map[string]interface{}{"tenant": "acme"},
+		Clock:     &MockClock{now: time.Unix(1000, 0)},
+	}))
+	req := &ScheduledRequest{
+		Name:     "create-order",
+		Schedule: ScheduleSpec{Relative: stringPtr("30s")},
+		HTTP: HttpRequestSpec{
+			Method:  "POST",
+			URL:     NewLiteralString("http://localhost:8080/{{ .Variables.tenant }}/orders"),
+			Headers: LiteralHeaders(map[string]string{"X-Request-ID": "{{ uuid }}", "Content-Type": "application/json"}),
+			Body: NewLiteralAny(map[string]interface{}{
+				"id":       "{{ uuid }}",
+				"quantity": "{{ randInt 1 10 }}",
+				"due":      "{{ now | addHours 24 | rfc3339 }}",
+				"items":    []interface{}{map[string]interface{}{"sku": "ABC-{{ seq }}"}},
+			}),
+		},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := evaluator.EvaluateRequest(req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,510 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Expectation severities
+const (
+	// SeverityFail expectations fail the execution when missed
+	SeverityFail = "fail"
+
+	// SeverityWarn expectations are reported when missed but leave the
+	// execution's outcome alone
+	SeverityWarn = "warn"
+)
+
+// regexPrefix marks a header expectation as a regular expression rather
+// than an exact value
+const regexPrefix = "regex:"
+
+// ExpectSpec lists what a response must look like for an execution to
+// succeed, on top of a 2xx status unless Status lists others
+type ExpectSpec struct {
+	// Status lists the status codes the response must have. Codes outside
+	// 2xx listed by every fail block that sets Status count as a success,
+	// such as 404 for a check that a deleted resource is gone.
+	Status StatusCodes `json:"status,omitempty" yaml:"status,omitempty"`
+
+	// ContentType is the media type the response must declare, such as
+	// "application/json", or one of the body kinds "json", "xml" and
+	// "text", which accept any media type BodyKind puts in that kind.
+	// Parameters such as charset are ignored.
+	ContentType string `json:"content_type,omitempty" yaml:"content_type,omitempty"`
+
+	// Headers maps response header names to the value each must have, or,
+	// prefixed with "regex:", a regular expression one of its values must
+	// match somewhere, such as "regex:max-age=\d+"
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// HeadersPresent lists response headers that must be present, with
+	// any value
+	HeadersPresent []string `json:"headers_present,omitempty" yaml:"headers_present,omitempty"`
+
+	// BodyContains is text the response body must contain
+	BodyContains string `json:"body_contains,omitempty" yaml:"body_contains,omitempty"`
+
+	// BodyRegex is a regular expression the response body must match
+	// somewhere, for bodies that are not structured, such as plain text
+	// or HTML
+	BodyRegex string `json:"body_regex,omitempty" yaml:"body_regex,omitempty"`
+
+	// XPath maps XPath expressions to the value each must select in an XML
+	// response body, such as "count(//item)": "3"; see ParseXPath
+	XPath map[string]string `json:"xpath,omitempty" yaml:"xpath,omitempty"`
+
+	// JSONPath maps JSON paths to the value each must select in the
+	// response body, compared as text, such as "$.status": "ok"; objects
+	// and arrays compare as compact JSON
+	JSONPath map[string]string `json:"jsonpath,omitempty" yaml:"jsonpath,omitempty"`
+
+	// Expression is a template that must render "true" for the response,
+	// for checks the other expectations cannot express, such as
+	// {{ and (eq .Status 200) (gt (len .Body.items) 0) }}; see
+	// ExpressionData for what it reads
+	Expression string `json:"expression,omitempty" yaml:"expression,omitempty"`
+
+	// Severity is SeverityFail (the default) when missing an expectation
+	// fails the execution, or SeverityWarn when it is only reported
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"`
+}
+
+// StatusCodes are the status codes an expect block accepts, written in a
+// config as one code or a list of them
+type StatusCodes []int
+
+// UnmarshalJSON implements json.Unmarshaler, accepting a single code or a
+// list
+func (c *StatusCodes) UnmarshalJSON(data []byte) error {
+	var single int
+	if err := json.Unmarshal(data, &single); err == nil {
+		*c = StatusCodes{single}
+		return nil
+	}
+	var list []int
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*c = list
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, accepting the same forms as
+// UnmarshalJSON
+func (c *StatusCodes) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var single int
+		if err := node.Decode(&single); err != nil {
+			return err
+		}
+		*c = StatusCodes{single}
+		return nil
+	}
+	var list []int
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*c = list
+	return nil
+}
+
+// Contains reports whether code is one of the codes
+func (c StatusCodes) Contains(code int) bool {
+	for _, candidate := range c {
+		if candidate == code {
+			return true
+		}
+	}
+	return false
+}
+
+// String lists the codes as "200, 201 or 204"
+func (c StatusCodes) String() string {
+	codes := make([]string, len(c))
+	for i, code := range c {
+		codes[i] = strconv.Itoa(code)
+	}
+	if len(codes) < 2 {
+		return strings.Join(codes, "")
+	}
+	return strings.Join(codes[:len(codes)-1], ", ") + " or " + codes[len(codes)-1]
+}
+
+// Expectations are the expect blocks of a request, written in a config as
+// one block or a list of them, such as a fail block for the contract and a
+// warn block for latency
+type Expectations []ExpectSpec
+
+// UnmarshalJSON implements json.Unmarshaler, accepting a single block or a
+// list
+func (e *Expectations) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var single ExpectSpec
+		if err := json.Unmarshal(data, &single); err != nil {
+			return err
+		}
+		*e = Expectations{single}
+		return nil
+	}
+	var list []ExpectSpec
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*e = list
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, accepting the same forms as
+// UnmarshalJSON
+func (e *Expectations) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		var single ExpectSpec
+		if err := node.Decode(&single); err != nil {
+			return err
+		}
+		*e = Expectations{single}
+		return nil
+	}
+	var list []ExpectSpec
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*e = list
+	return nil
+}
+
+// Validate checks every block
+func (e Expectations) Validate() error {
+	for i := range e {
+		if err := e[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check returns a description of each expectation the response does not
+// meet, split into the fail expectations, which fail the execution, and
+// the warn expectations, which only report
+func (e Expectations) Check(response Response) (unmet, warnings []string) {
+	for i := range e {
+		missed := e[i].Check(response)
+		if e[i].Severity == SeverityWarn {
+			warnings = append(warnings, missed...)
+		} else {
+			unmet = append(unmet, missed...)
+		}
+	}
+	return unmet, warnings
+}
+
+// AcceptsStatus reports whether the fail blocks accept a status outside
+// 2xx: at least one of them sets Status, and every one that does lists
+// code. Warn blocks never change which statuses succeed.
+func (e Expectations) AcceptsStatus(code int) bool {
+	accepted := false
+	for i := range e {
+		if e[i].Severity == SeverityWarn || len(e[i].Status) == 0 {
+			continue
+		}
+		if !e[i].Status.Contains(code) {
+			return false
+		}
+		accepted = true
+	}
+	return accepted
+}
+
+// ExpressionData is what an expect.expression template reads
+type ExpressionData struct {
+	// Status is the response status code
+	Status int
+
+	// LatencyMs is how long the response took, in milliseconds
+	LatencyMs int64
+
+	// Headers are the response headers, read with .Headers.Get "Name"
+	Headers http.Header
+
+	// Body is the response body parsed by ParseBody, with JSON numbers as
+	// int64 or float64 so they compare with numbers, or nil when it does
+	// not parse
+	Body interface{}
+
+	// Text is the response body as a string
+	Text string
+}
+
+// Validate checks that every expectation is well-formed
+func (e *ExpectSpec) Validate() error {
+	switch e.Severity {
+	case "", SeverityFail, SeverityWarn:
+	default:
+		return &ValidationError{
+			Field:   "expect.severity",
+			Message: fmt.Sprintf("invalid severity %q: must be %s or %s", e.Severity, SeverityFail, SeverityWarn),
+		}
+	}
+	for _, code := range e.Status {
+		if code < 100 || code > 599 {
+			return &ValidationError{Field: "expect.status", Message: fmt.Sprintf("invalid status code %d: must be between 100 and 599", code)}
+		}
+	}
+	if e.ContentType != "" && !isBodyKind(e.ContentType) {
+		if _, _, err := mime.ParseMediaType(e.ContentType); err != nil {
+			return &ValidationError{
+				Field:   "expect.content_type",
+				Message: fmt.Sprintf("invalid media type %q: %v", e.ContentType, err),
+			}
+		}
+	}
+	for _, name := range sortedKeys(e.Headers) {
+		if name == "" {
+			return &ValidationError{Field: "expect.headers", Message: "header name is required"}
+		}
+		if pattern, ok := strings.CutPrefix(e.Headers[name], regexPrefix); ok {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return &ValidationError{Field: "expect.headers." + name, Message: fmt.Sprintf("invalid regex: %v", err)}
+			}
+		}
+	}
+	for _, name := range e.HeadersPresent {
+		if name == "" {
+			return &ValidationError{Field: "expect.headers_present", Message: "header name is required"}
+		}
+	}
+	if e.BodyRegex != "" {
+		if _, err := regexp.Compile(e.BodyRegex); err != nil {
+			return &ValidationError{Field: "expect.body_regex", Message: fmt.Sprintf("invalid regex: %v", err)}
+		}
+	}
+	for _, path := range sortedKeys(e.JSONPath) {
+		if _, err := parseJSONPath(path); err != nil {
+			return &ValidationError{Field: "expect.jsonpath", Message: err.Error()}
+		}
+	}
+	for _, expr := range sortedKeys(e.XPath) {
+		if _, err := ParseXPath(expr); err != nil {
+			return &ValidationError{Field: "expect.xpath", Message: err.Error()}
+		}
+	}
+	if e.Expression != "" {
+		if _, err := template.New("expression").Funcs(NewTemplateEngine(nil).funcMap).Parse(e.Expression); err != nil {
+			return &ValidationError{Field: "expect.expression", Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// Check returns a description of each expectation the response does not
+// meet, or nil when it meets them all
+func (e *ExpectSpec) Check(response Response) []string {
+	var unmet []string
+	if len(e.Status) > 0 && !e.Status.Contains(response.StatusCode) {
+		unmet = append(unmet, fmt.Sprintf("status %d, expected %s", response.StatusCode, e.Status))
+	}
+	if e.ContentType != "" && !matchesContentType(e.ContentType, response.Headers.Get("Content-Type")) {
+		actual := response.Headers.Get("Content-Type")
+		if actual == "" {
+			actual = "none"
+		}
+		unmet = append(unmet, fmt.Sprintf("content type %s, expected %s", actual, e.ContentType))
+	}
+	for _, name := range sortedKeys(e.Headers) {
+		if problem := checkHeader(name, e.Headers[name], response.Headers.Values(name)); problem != "" {
+			unmet = append(unmet, problem)
+		}
+	}
+	for _, name := range e.HeadersPresent {
+		if len(response.Headers.Values(name)) == 0 {
+			unmet = append(unmet, fmt.Sprintf("header %s missing", name))
+		}
+	}
+	if e.BodyContains != "" && !bytes.Contains(response.Body, []byte(e.BodyContains)) {
+		unmet = append(unmet, fmt.Sprintf("body does not contain %q", e.BodyContains))
+	}
+	if e.BodyRegex != "" {
+		if pattern, err := regexp.Compile(e.BodyRegex); err != nil {
+			unmet = append(unmet, fmt.Sprintf("invalid body regex: %v", err))
+		} else if !pattern.Match(response.Body) {
+			unmet = append(unmet, fmt.Sprintf("body does not match %q", e.BodyRegex))
+		}
+	}
+	for _, expr := range sortedKeys(e.XPath) {
+		if problem := checkXPath(expr, e.XPath[expr], response.Body); problem != "" {
+			unmet = append(unmet, problem)
+		}
+	}
+	for _, path := range sortedKeys(e.JSONPath) {
+		if problem := checkJSONPath(path, e.JSONPath[path], response); problem != "" {
+			unmet = append(unmet, problem)
+		}
+	}
+	if e.Expression != "" {
+		if problem := checkExpression(e.Expression, response); problem != "" {
+			unmet = append(unmet, problem)
+		}
+	}
+	return unmet
+}
+
+// checkHeader returns a description of how a response header's values fail
+// to meet expected, an exact value or a "regex:" pattern, or "" when one of
+// them meets it
+func checkHeader(name, expected string, values []string) string {
+	pattern, isRegex := strings.CutPrefix(expected, regexPrefix)
+	var re *regexp.Regexp
+	if isRegex {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return fmt.Sprintf("header %s: invalid regex: %v", name, err)
+		}
+	}
+	for _, value := range values {
+		if (isRegex && re.MatchString(value)) || (!isRegex && value == expected) {
+			return ""
+		}
+	}
+
+	want := fmt.Sprintf("expected %q", expected)
+	if isRegex {
+		want = fmt.Sprintf("expected to match %q", pattern)
+	}
+	if len(values) == 0 {
+		return fmt.Sprintf("header %s missing, %s", name, want)
+	}
+	return fmt.Sprintf("header %s = %q, %s", name, values[0], want)
+}
+
+// checkXPath returns a description of how an XML body fails to give
+// expected for an XPath expression, or "" when it does
+func checkXPath(expr, expected string, body []byte) string {
+	path, err := ParseXPath(expr)
+	if err != nil {
+		return err.Error()
+	}
+	value, err := path.Evaluate(body)
+	if err != nil {
+		return fmt.Sprintf("xpath %s", err)
+	}
+	if actual := fmt.Sprint(value); actual != expected {
+		return fmt.Sprintf("xpath %s = %q, expected %q", expr, actual, expected)
+	}
+	return ""
+}
+
+// checkJSONPath returns a description of how a response body fails to give
+// expected at a JSON path, or "" when it does
+func checkJSONPath(path, expected string, response Response) string {
+	body, err := ParseBody(response.Headers.Get("Content-Type"), response.Body)
+	if err != nil {
+		return fmt.Sprintf("jsonpath %s: %v", path, err)
+	}
+	value, err := LookupJSONPath(body, path)
+	if err != nil {
+		return fmt.Sprintf("jsonpath %s: %v", path, err)
+	}
+	if actual := jsonText(value); actual != expected {
+		return fmt.Sprintf("jsonpath %s = %q, expected %q", path, actual, expected)
+	}
+	return ""
+}
+
+// jsonText formats a value selected from a parsed body for comparison:
+// strings as they are, objects and arrays as compact JSON and anything else
+// as it is written in JSON
+func jsonText(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	text, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(text)
+}
+
+// checkExpression returns a description of how a response fails an
+// expression, or "" when the expression renders "true"
+func checkExpression(expression string, response Response) string {
+	data := ExpressionData{
+		Status:    response.StatusCode,
+		LatencyMs: response.Duration.Milliseconds(),
+		Headers:   response.Headers,
+		Text:      string(response.Body),
+	}
+	if data.Headers == nil {
+		data.Headers = http.Header{}
+	}
+	if body, err := ParseBody(response.Headers.Get("Content-Type"), response.Body); err == nil {
+		data.Body = comparableNumbers(body)
+	}
+
+	result, err := NewTemplateEngine(nil).EvaluateTemplateWithData(expression, data)
+	if err != nil {
+		return fmt.Sprintf("expression: %v", err)
+	}
+	switch strings.TrimSpace(result) {
+	case "true":
+		return ""
+	case "false":
+		return "expression is false"
+	default:
+		return fmt.Sprintf("expression rendered %q, expected true or false", strings.TrimSpace(result))
+	}
+}
+
+// comparableNumbers replaces the json.Number values in a parsed body with
+// int64, or float64 when they are not integers, so templates can compare
+// them with lt, gt and eq
+func comparableNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = comparableNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = comparableNumbers(item)
+		}
+	}
+	return value
+}
+
+// isBodyKind reports whether s names a body kind rather than a media type
+func isBodyKind(s string) bool {
+	switch strings.ToLower(s) {
+	case BodyJSON, BodyXML, BodyText:
+		return true
+	}
+	return false
+}
+
+// matchesContentType reports whether a response's Content-Type matches an
+// expected media type or body kind
+func matchesContentType(expected, actual string) bool {
+	if isBodyKind(expected) {
+		return BodyKind(actual) == strings.ToLower(expected)
+	}
+	want, _, _ := mime.ParseMediaType(expected)
+	got, _, err := mime.ParseMediaType(actual)
+	return err == nil && got == want
+}
@@ -0,0 +1,344 @@
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestExpectSpec_Validate(t *testing.T) {
+	valid := []string{"", "json", "XML", "text", "application/json", "application/vnd.api+json; charset=utf-8"}
+	for _, contentType := range valid {
+		expect := ExpectSpec{ContentType: contentType}
+		if err := expect.Validate(); err != nil {
+			t.Errorf("Validate(%q) error = %v", contentType, err)
+		}
+	}
+
+	expect := ExpectSpec{ContentType: "application/"}
+	if err := expect.Validate(); err == nil || !strings.Contains(err.Error(), "expect.content_type") {
+		t.Errorf("Expected an invalid media type error, got %v", err)
+	}
+
+	expect = ExpectSpec{Headers: map[string]string{"Cache-Control": "regex:max-age=("}}
+	if err := expect.Validate(); err == nil || !strings.Contains(err.Error(), "expect.headers.Cache-Control") {
+		t.Errorf("Expected an invalid header regex error, got %v", err)
+	}
+
+	expect = ExpectSpec{BodyRegex: "[a-"}
+	if err := expect.Validate(); err == nil || !strings.Contains(err.Error(), "expect.body_regex") {
+		t.Errorf("Expected an invalid body regex error, got %v", err)
+	}
+
+	expect = ExpectSpec{Expression: "{{ eq .Status 200 "}
+	if err := expect.Validate(); err == nil || !strings.Contains(err.Error(), "expect.expression") {
+		t.Errorf("Expected an invalid expression error, got %v", err)
+	}
+
+	expect = ExpectSpec{XPath: map[string]string{"/order[1": "7"}}
+	if err := expect.Validate(); err == nil || !strings.Contains(err.Error(), "expect.xpath") {
+		t.Errorf("Expected an invalid XPath error, got %v", err)
+	}
+
+	expect = ExpectSpec{Status: StatusCodes{200, 2000}}
+	if err := expect.Validate(); err == nil || !strings.Contains(err.Error(), "expect.status") {
+		t.Errorf("Expected an invalid status error, got %v", err)
+	}
+
+	expect = ExpectSpec{JSONPath: map[string]string{"$.items[": "7"}}
+	if err := expect.Validate(); err == nil || !strings.Contains(err.Error(), "expect.jsonpath") {
+		t.Errorf("Expected an invalid JSON path error, got %v", err)
+	}
+
+	expect = ExpectSpec{HeadersPresent: []string{""}}
+	if err := expect.Validate(); err == nil || !strings.Contains(err.Error(), "expect.headers_present") {
+		t.Errorf("Expected a missing header name error, got %v", err)
+	}
+}
+
+func TestExpectSpec_CheckStatus(t *testing.T) {
+	expect := ExpectSpec{Status: StatusCodes{200, 201}}
+	if unmet := expect.Check(Response{StatusCode: 201}); len(unmet) != 0 {
+		t.Errorf("Expected 201 to be accepted, got %v", unmet)
+	}
+	unmet := expect.Check(Response{StatusCode: 500})
+	if len(unmet) != 1 || unmet[0] != "status 500, expected 200 or 201" {
+		t.Errorf("Expected a status mismatch, got %v", unmet)
+	}
+}
+
+func TestExpectSpec_CheckBodyContains(t *testing.T) {
+	expect := ExpectSpec{BodyContains: "healthy", HeadersPresent: []string{"X-Request-Id"}}
+	response := Response{
+		Headers: http.Header{"X-Request-Id": []string{"abc"}},
+		Body:    []byte("service is healthy"),
+	}
+	if unmet := expect.Check(response); len(unmet) != 0 {
+		t.Errorf("Expected the response to meet expectations, got %v", unmet)
+	}
+
+	unmet := expect.Check(Response{Body: []byte("service is degraded")})
+	want := []string{"header X-Request-Id missing", `body does not contain "healthy"`}
+	if len(unmet) != len(want) || unmet[0] != want[0] || unmet[1] != want[1] {
+		t.Errorf("Expected %v, got %v", want, unmet)
+	}
+}
+
+func TestExpectSpec_CheckJSONPath(t *testing.T) {
+	body := []byte(`{"status": "ok", "count": 3, "ready": true, "items": [{"id": 7}], "tags": ["a", "b"]}`)
+	expect := ExpectSpec{JSONPath: map[string]string{
+		"$.status":      "ok",
+		"$.count":       "3",
+		"$.ready":       "true",
+		"$.items[0].id": "7",
+		"$.tags":        `["a","b"]`,
+	}}
+	if unmet := expect.Check(Response{Body: body}); len(unmet) != 0 {
+		t.Errorf("Expected every path to match, got %v", unmet)
+	}
+
+	expect = ExpectSpec{JSONPath: map[string]string{"$.count": "4", "$.missing": "x"}}
+	unmet := expect.Check(Response{Body: body})
+	if len(unmet) != 2 || unmet[0] != `jsonpath $.count = "3", expected "4"` || !strings.HasPrefix(unmet[1], "jsonpath $.missing:") {
+		t.Errorf("Expected a mismatch and a missing path, got %v", unmet)
+	}
+
+	unmet = expect.Check(Response{Headers: http.Header{"Content-Type": []string{"application/json"}}, Body: []byte("<html>")})
+	if len(unmet) != 2 || !strings.Contains(unmet[0], "not JSON") {
+		t.Errorf("Expected a body that is not JSON to miss, got %v", unmet)
+	}
+}
+
+func TestStatusCodes_Unmarshal(t *testing.T) {
+	var single ExpectSpec
+	if err := yaml.Unmarshal([]byte("status: 404"), &single); err != nil || len(single.Status) != 1 || single.Status[0] != 404 {
+		t.Errorf("Expected a single code, got %v (%v)", single.Status, err)
+	}
+	var list ExpectSpec
+	if err := json.Unmarshal([]byte(`{"status": [200, 204]}`), &list); err != nil || len(list.Status) != 2 || list.Status[1] != 204 {
+		t.Errorf("Expected a list of codes, got %v (%v)", list.Status, err)
+	}
+}
+
+func TestExpectations_AcceptsStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectations Expectations
+		code         int
+		want         bool
+	}{
+		{name: "no status", expectations: Expectations{{ContentType: "json"}}, code: 404, want: false},
+		{name: "listed", expectations: Expectations{{Status: StatusCodes{404}}}, code: 404, want: true},
+		{name: "not listed", expectations: Expectations{{Status: StatusCodes{200}}}, code: 404, want: false},
+		{name: "warn block", expectations: Expectations{{Status: StatusCodes{404}, Severity: SeverityWarn}}, code: 404, want: false},
+		{name: "every fail block", expectations: Expectations{{Status: StatusCodes{404}}, {Status: StatusCodes{410}}}, code: 404, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.expectations.AcceptsStatus(tt.code); got != tt.want {
+				t.Errorf("AcceptsStatus(%d) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpectSpec_CheckContentType(t *testing.T) {
+	tests := []struct {
+		expected string
+		actual   string
+		met      bool
+	}{
+		{expected: "json", actual: "application/json; charset=utf-8", met: true},
+		{expected: "json", actual: "application/problem+json", met: true},
+		{expected: "json", actual: "text/html", met: false},
+		{expected: "xml", actual: "text/xml", met: true},
+		{expected: "text", actual: "text/plain", met: true},
+		{expected: "application/json", actual: "Application/JSON; charset=utf-8", met: true},
+		{expected: "application/json", actual: "application/problem+json", met: false},
+		{expected: "json", actual: "", met: false},
+	}
+	for _, tt := range tests {
+		expect := ExpectSpec{ContentType: tt.expected}
+		response := Response{StatusCode: 200, Headers: http.Header{}}
+		if tt.actual != "" {
+			response.Headers.Set("Content-Type", tt.actual)
+		}
+		unmet := expect.Check(response)
+		if (len(unmet) == 0) != tt.met {
+			t.Errorf("Check(%q against %q) = %v, want met %v", tt.expected, tt.actual, unmet, tt.met)
+		}
+	}
+
+	expect := ExpectSpec{ContentType: "json"}
+	if unmet := expect.Check(Response{}); len(unmet) != 1 || unmet[0] != "content type none, expected json" {
+		t.Errorf("Expected a missing content type to be reported, got %v", unmet)
+	}
+}
+
+func TestExpectSpec_CheckXPath(t *testing.T) {
+	expect := ExpectSpec{XPath: map[string]string{
+		"/order/@id":    "7",
+		"count(//item)": "2",
+		"/order/status": "open",
+	}}
+
+	response := Response{Body: []byte(`<order id="7"><status>open</status><item/><item/></order>`)}
+	if unmet := expect.Check(response); len(unmet) != 0 {
+		t.Errorf("Expected the response to meet expectations, got %v", unmet)
+	}
+
+	response = Response{Body: []byte(`<order id="8"><item/></order>`)}
+	want := []string{
+		`xpath /order/@id = "8", expected "7"`,
+		"xpath /order/status: no match",
+		`xpath count(//item) = "1", expected "2"`,
+	}
+	unmet := expect.Check(response)
+	if len(unmet) != len(want) {
+		t.Fatalf("Expected %d unmet expectations, got %v", len(want), unmet)
+	}
+	for _, w := range want {
+		found := false
+		for _, u := range unmet {
+			found = found || u == w
+		}
+		if !found {
+			t.Errorf("Expected %q among %v", w, unmet)
+		}
+	}
+
+	if unmet := expect.Check(Response{Body: []byte("not xml")}); len(unmet) != 3 || !strings.Contains(unmet[0], "not XML") {
+		t.Errorf("Expected a non-XML body to miss every XPath expectation, got %v", unmet)
+	}
+}
+
+func TestExpectSpec_CheckHeaders(t *testing.T) {
+	expect := ExpectSpec{Headers: map[string]string{
+		"X-Cache":       "HIT",
+		"cache-control": `regex:max-age=\d+`,
+	}}
+
+	headers := http.Header{}
+	headers.Set("X-Cache", "HIT")
+	headers.Add("Cache-Control", "public")
+	headers.Add("Cache-Control", "max-age=60")
+	if unmet := expect.Check(Response{Headers: headers}); len(unmet) != 0 {
+		t.Errorf("Expected the headers to meet expectations, got %v", unmet)
+	}
+
+	headers = http.Header{}
+	headers.Set("Cache-Control", "no-store")
+	want := []string{
+		`header X-Cache missing, expected "HIT"`,
+		`header cache-control = "no-store", expected to match "max-age=\\d+"`,
+	}
+	unmet := expect.Check(Response{Headers: headers})
+	if len(unmet) != len(want) {
+		t.Fatalf("Expected %d unmet expectations, got %v", len(want), unmet)
+	}
+	for i := range want {
+		if unmet[i] != want[i] {
+			t.Errorf("Expected %q, got %q", want[i], unmet[i])
+		}
+	}
+}
+
+func TestExpectSpec_CheckBodyRegex(t *testing.T) {
+	expect := ExpectSpec{BodyRegex: `(?i)<title>\s*dashboard`}
+	if unmet := expect.Check(Response{Body: []byte("<html><TITLE> Dashboard</TITLE></html>")}); len(unmet) != 0 {
+		t.Errorf("Expected the body to match, got %v", unmet)
+	}
+	unmet := expect.Check(Response{Body: []byte("<html><title>Maintenance</title></html>")})
+	if len(unmet) != 1 || !strings.HasPrefix(unmet[0], "body does not match") {
+		t.Errorf("Expected the body not to match, got %v", unmet)
+	}
+}
+
+func TestExpectSpec_CheckExpression(t *testing.T) {
+	headers := http.Header{"Content-Type": []string{"application/json"}, "X-Cache": []string{"HIT"}}
+	response := Response{
+		StatusCode: 200,
+		Headers:    headers,
+		Body:       []byte(`{"items": [{"id": 1}, {"id": 2}], "total": 2, "ratio": 0.5}`),
+		Duration:   120 * time.Millisecond,
+	}
+
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{expression: `{{ and (eq .Status 200) (gt (len .Body.items) 0) }}`},
+		{expression: `{{ and (eq .Body.total 2) (lt .Body.ratio 1.0) }}`},
+		{expression: `{{ lt .LatencyMs 500 }}`},
+		{expression: `{{ eq (.Headers.Get "X-Cache") "HIT" }}`},
+		{expression: `{{ if eq .Status 200 }} true {{ end }}`},
+		{expression: `{{ gt .LatencyMs 500 }}`, want: "expression is false"},
+		{expression: `{{ .Status }}`, want: `expression rendered "200", expected true or false`},
+		{expression: `{{ gt (len .Body.missing) 0 }}`, want: "expression: "},
+	}
+	for _, tt := range tests {
+		expect := ExpectSpec{Expression: tt.expression}
+		unmet := expect.Check(response)
+		if tt.want == "" {
+			if len(unmet) != 0 {
+				t.Errorf("%s: expected the expression to hold, got %v", tt.expression, unmet)
+			}
+			continue
+		}
+		if len(unmet) != 1 || !strings.HasPrefix(unmet[0], tt.want) {
+			t.Errorf("%s: expected %q, got %v", tt.expression, tt.want, unmet)
+		}
+	}
+
+	expect := ExpectSpec{Expression: `{{ eq .Text "OK" }}`}
+	if unmet := expect.Check(Response{StatusCode: 200, Body: []byte("OK")}); len(unmet) != 0 {
+		t.Errorf("Expected a plain text body to be readable as .Text, got %v", unmet)
+	}
+}
+
+func TestExpectations_UnmarshalJSON(t *testing.T) {
+	var single Expectations
+	if err := json.Unmarshal([]byte(`{"content_type": "json"}`), &single); err != nil || len(single) != 1 || single[0].ContentType != "json" {
+		t.Errorf("Expected a single block, got %+v (%v)", single, err)
+	}
+
+	var list Expectations
+	err := json.Unmarshal([]byte(`[{"content_type": "json"}, {"severity": "warn", "body_regex": "ok"}]`), &list)
+	if err != nil || len(list) != 2 || list[1].Severity != SeverityWarn {
+		t.Errorf("Expected a list of blocks, got %+v (%v)", list, err)
+	}
+
+	if err := json.Unmarshal([]byte(`"json"`), &list); err == nil {
+		t.Error("Expected a string to be rejected")
+	}
+}
+
+func TestExpectations_Check(t *testing.T) {
+	expectations := Expectations{
+		{ContentType: "json"},
+		{Severity: SeverityWarn, Expression: "{{ lt .LatencyMs 500 }}"},
+	}
+
+	response := Response{
+		StatusCode: 200,
+		Headers:    http.Header{"Content-Type": []string{"text/html"}},
+		Duration:   time.Second,
+	}
+	unmet, warnings := expectations.Check(response)
+	if len(unmet) != 1 || !strings.HasPrefix(unmet[0], "content type") {
+		t.Errorf("Expected the fail block to be unmet, got %v", unmet)
+	}
+	if len(warnings) != 1 || warnings[0] != "expression is false" {
+		t.Errorf("Expected the warn block to warn, got %v", warnings)
+	}
+
+	response.Headers.Set("Content-Type", "application/json")
+	response.Duration = time.Millisecond
+	if unmet, warnings := expectations.Check(response); len(unmet) != 0 || len(warnings) != 0 {
+		t.Errorf("Expected every block to be met, got %v and %v", unmet, warnings)
+	}
+}
@@ -0,0 +1,173 @@
+package spec
+
+import (
+	"fmt"
+	"math"
+	mrand "math/rand"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Word lists for the fake data functions. Domains are reserved for
+// examples and phone numbers use the 555-01xx fictional range, so generated
+// data never reaches real people.
+var (
+	fakeFirstNames = []string{
+		"Ada", "Alan", "Amara", "Ana", "Arjun", "Ben", "Chen", "Chloe", "Daniel", "Diego",
+		"Elena", "Emma", "Farah", "Grace", "Hana", "Ines", "Isaac", "James", "Jin", "Kofi",
+		"Lars", "Layla", "Leo", "Lucia", "Maya", "Mei", "Mohammed", "Nadia", "Noah", "Olga",
+		"Omar", "Priya", "Rafael", "Rosa", "Sam", "Sara", "Sofia", "Tariq", "Tom", "Yuki",
+	}
+	fakeLastNames = []string{
+		"Adeyemi", "Andersson", "Bauer", "Brown", "Chen", "Costa", "Dubois", "Garcia", "Haddad", "Hopper",
+		"Ivanova", "Jensen", "Kim", "Kowalski", "Lovelace", "Martin", "Mensah", "Moreau", "Müller", "Nakamura",
+		"Nguyen", "Novak", "O'Brien", "Okafor", "Patel", "Rossi", "Santos", "Schmidt", "Silva", "Singh",
+		"Smith", "Sørensen", "Tanaka", "Turing", "Wang", "Williams", "Wilson", "Yilmaz", "Zhang", "Zimmermann",
+	}
+	fakeCompanyWords = []string{
+		"Acme", "Apex", "Blue", "Bright", "Cedar", "Cloud", "Delta", "Echo", "Granite", "Harbor",
+		"Iron", "Lumen", "Maple", "Nova", "Orbit", "Pine", "Quantum", "River", "Summit", "Vertex",
+	}
+	fakeCompanySuffixes = []string{"Labs", "Systems", "Works", "Group", "Logistics", "Foods", "Health", "Studio", "Partners", "Inc"}
+	fakeDomains         = []string{"example.com", "example.org", "example.net"}
+	fakeStreets         = []string{"Main St", "High St", "Oak Ave", "Station Rd", "Park Lane", "Mill Rd", "Church St", "Elm St", "Harbour Way", "King St"}
+	fakeCities          = []struct{ city, country string }{
+		{"Amsterdam", "Netherlands"}, {"Austin", "United States"}, {"Barcelona", "Spain"}, {"Berlin", "Germany"},
+		{"Bristol", "United Kingdom"}, {"Buenos Aires", "Argentina"}, {"Cape Town", "South Africa"}, {"Copenhagen", "Denmark"},
+		{"Dublin", "Ireland"}, {"Lagos", "Nigeria"}, {"Lisbon", "Portugal"}, {"Lyon", "France"},
+		{"Melbourne", "Australia"}, {"Montreal", "Canada"}, {"Mumbai", "India"}, {"Nairobi", "Kenya"},
+		{"Osaka", "Japan"}, {"Seoul", "South Korea"}, {"Toronto", "Canada"}, {"Warsaw", "Poland"},
+	}
+	fakeWords = []string{
+		"alpha", "amber", "anchor", "arrow", "basket", "beacon", "breeze", "candle", "canvas", "cobalt",
+		"coral", "crystal", "dawn", "ember", "falcon", "field", "forest", "garden", "glacier", "harbor",
+		"island", "jasper", "lantern", "meadow", "mirror", "nectar", "ocean", "orchid", "pebble", "prairie",
+		"quartz", "rain", "ridge", "saddle", "shadow", "signal", "silver", "spring", "stone", "thunder",
+		"timber", "valley", "velvet", "willow", "winter", "wonder", "yarrow", "zenith",
+	}
+)
+
+// random returns the seeded source when a seed is set, so fake data is
+// reproducible, and a time-seeded source otherwise
+func (e *TemplateEngine) random() *mrand.Rand {
+	if e.ctx.Seed != 0 {
+		if e.ctx.randSource == nil {
+			e.ctx.randSource = mrand.New(mrand.NewSource(e.ctx.Seed))
+		}
+		return e.ctx.randSource
+	}
+	if e.fallbackRand == nil {
+		e.fallbackRand = mrand.New(mrand.NewSource(time.Now().UnixNano()))
+	}
+	return e.fallbackRand
+}
+
+func (e *TemplateEngine) pickString(values []string) string {
+	return values[e.random().Intn(len(values))]
+}
+
+// pick returns one of its arguments, or one element of a single list
+// argument
+func (e *TemplateEngine) pick(values ...interface{}) (interface{}, error) {
+	if len(values) == 1 {
+		if values[0] == nil {
+			// A missing variable or map key
+			return nil, fmt.Errorf("pick: empty list")
+		}
+		if v := reflect.ValueOf(values[0]); v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			if v.Len() == 0 {
+				return nil, fmt.Errorf("pick: empty list")
+			}
+			return v.Index(e.random().Intn(v.Len())).Interface(), nil
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("pick: no values")
+	}
+	return values[e.random().Intn(len(values))], nil
+}
+
+func (e *TemplateEngine) firstName() string { return e.pickString(fakeFirstNames) }
+
+func (e *TemplateEngine) lastName() string { return e.pickString(fakeLastNames) }
+
+func (e *TemplateEngine) fullName() string { return e.firstName() + " " + e.lastName() }
+
+// username derives a handle such as ada.lovelace42
+func (e *TemplateEngine) username() string {
+	return fmt.Sprintf("%s.%s%d", slug(e.firstName()), slug(e.lastName()), e.random().Intn(100))
+}
+
+func (e *TemplateEngine) email() string {
+	return e.username() + "@" + e.pickString(fakeDomains)
+}
+
+func (e *TemplateEngine) company() string {
+	return e.pickString(fakeCompanyWords) + " " + e.pickString(fakeCompanySuffixes)
+}
+
+func (e *TemplateEngine) street() string {
+	return fmt.Sprintf("%d %s", 1+e.random().Intn(250), e.pickString(fakeStreets))
+}
+
+func (e *TemplateEngine) city() string {
+	return fakeCities[e.random().Intn(len(fakeCities))].city
+}
+
+func (e *TemplateEngine) country() string {
+	return fakeCities[e.random().Intn(len(fakeCities))].country
+}
+
+func (e *TemplateEngine) phone() string {
+	return fmt.Sprintf("+1-%03d-555-01%02d", 200+e.random().Intn(800), e.random().Intn(100))
+}
+
+func (e *TemplateEngine) word() string { return e.pickString(fakeWords) }
+
+// sentence returns n random words, capitalised and ending with a full stop
+func (e *TemplateEngine) sentence(n int) string {
+	if n < 1 {
+		n = 1
+	}
+	words := make([]string, n)
+	for i := range words {
+		words[i] = e.word()
+	}
+	words[0] = strings.ToUpper(words[0][:1]) + words[0][1:]
+	return strings.Join(words, " ") + "."
+}
+
+func (e *TemplateEngine) randBool() bool { return e.random().Intn(2) == 1 }
+
+// randTime returns a time between start and end, to the second
+func (e *TemplateEngine) randTime(start, end time.Time) time.Time {
+	span := end.Sub(start)
+	if span <= 0 {
+		return start
+	}
+	return start.Add(time.Duration(e.random().Int63n(int64(span)))).Truncate(time.Second)
+}
+
+// randDecimal returns a number between min and max with the given number of
+// decimal places, such as a price
+func (e *TemplateEngine) randDecimal(min, max float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round((min+e.random().Float64()*(max-min))*scale) / scale
+}
+
+// slug lowercases a name and drops characters unfit for an email address
+func slug(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z':
+			b.WriteRune(r)
+		case r == 'ø':
+			b.WriteByte('o')
+		case r == 'ü':
+			b.WriteByte('u')
+		}
+	}
+	return b.String()
+}
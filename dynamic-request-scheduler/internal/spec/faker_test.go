@@ -0,0 +1,95 @@
+package spec
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateEngine_FakeData(t *testing.T) {
+	engine := NewTemplateEngine(&EvaluationContext{Seed: 7})
+	patterns := map[string]string{
+		`{{ firstName }}`:                 `^\p{Lu}\p{L}+$`,
+		`{{ fullName }}`:                  `^\p{Lu}\p{L}+ \p{Lu}[\p{L}']+$`,
+		`{{ username }}`:                  `^[a-z]+\.[a-z]+\d{1,2}$`,
+		`{{ email }}`:                     `^[a-z]+\.[a-z]+\d{1,2}@example\.(com|org|net)$`,
+		`{{ company }}`:                   `^[A-Z][a-z]+ [A-Z][a-z]+$`,
+		`{{ street }}, {{ city }}`:        `^\d{1,3} [A-Za-z ]+, [A-Za-z ]+$`,
+		`{{ phone }}`:                     `^\+1-\d{3}-555-01\d\d$`,
+		`{{ sentence 4 }}`:                `^[A-Z][a-z]+( [a-z]+){3}\.$`,
+		`{{ randBool }}`:                  `^(true|false)$`,
+		`{{ randDecimal 5 10 2 }}`:        `^([5-9](\.\d{1,2})?|10)$`,
+		`{{ pick "red" "green" "blue" }}`: `^(red|green|blue)$`,
+	}
+	for tmpl, pattern := range patterns {
+		for i := 0; i < 20; i++ {
+			got, err := engine.EvaluateTemplate(tmpl)
+			if err != nil {
+				t.Fatalf("EvaluateTemplate(%q) failed: %v", tmpl, err)
+			}
+			if !regexp.MustCompile(pattern).MatchString(got) {
+				t.Errorf("%s = %q, want a match for %s", tmpl, got, pattern)
+				break
+			}
+		}
+	}
+}
+
+func TestTemplateEngine_FakeDataSeeded(t *testing.T) {
+	tmpl := `{{ fullName }} <{{ email }}> {{ randInt 1 1000 }}`
+	generate := func(seed int64) string {
+		engine := NewTemplateEngine(&EvaluationContext{Seed: seed})
+		var lines []string
+		for i := 0; i < 5; i++ {
+			line, _ := engine.EvaluateTemplate(tmpl)
+			lines = append(lines, line)
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	if generate(42) != generate(42) {
+		t.Errorf("Expected the same seed to give the same data")
+	}
+	if generate(42) == generate(43) {
+		t.Errorf("Expected different seeds to give different data")
+	}
+}
+
+func TestTemplateEngine_Pick(t *testing.T) {
+	engine := NewTemplateEngine(&EvaluationContext{
+		Seed:      1,
+		Variables: map[string]interface{}{"plans": []string{"free", "pro"}},
+	})
+	got, err := engine.EvaluateTemplate(`{{ pick (var "plans") }}`)
+	if err != nil || (got != "free" && got != "pro") {
+		t.Errorf("Expected a plan from the list, got %q, %v", got, err)
+	}
+
+	for _, tmpl := range []string{`{{ pick }}`, `{{ pick (var "none") }}`} {
+		engine.SetVariable("none", []string{})
+		if _, err := engine.EvaluateTemplate(tmpl); err == nil {
+			t.Errorf("Expected %s to fail", tmpl)
+		}
+	}
+}
+
+func TestTemplateEngine_RandTime(t *testing.T) {
+	engine := NewTemplateEngine(&EvaluationContext{Seed: 3})
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(30 * 24 * time.Hour)
+	for i := 0; i < 50; i++ {
+		got := engine.randTime(start, end)
+		if got.Before(start) || !got.Before(end) || got.Nanosecond() != 0 {
+			t.Fatalf("randTime = %v, want a whole second in [%v, %v)", got, start, end)
+		}
+	}
+	if got := engine.randTime(end, start); !got.Equal(end) {
+		t.Errorf("Expected an empty range to give its start, got %v", got)
+	}
+
+	rendered, err := engine.EvaluateTemplate(`{{ randTime (parseTime "2006-01-02" "2025-01-01") (parseTime "2006-01-02" "2025-02-01") | rfc3339 }}`)
+	if err != nil || !strings.HasPrefix(rendered, "2025-01-") {
+		t.Errorf("Expected a January 2025 time, got %q, %v", rendered, err)
+	}
+}
@@ -0,0 +1,51 @@
+package spec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxFanOut caps the copies a single firing can dispatch, so a typo or a
+// template with a wide range cannot flood the queue
+const MaxFanOut = 1000
+
+// validateFanOut checks that a literal fan_out is between 1 and MaxFanOut. A
+// templated one is only checked when each firing evaluates it.
+func (r *ScheduledRequest) validateFanOut() error {
+	if r.FanOut == "" || IsTemplateString(r.FanOut) {
+		return nil
+	}
+	if _, err := parseFanOut(r.FanOut); err != nil {
+		return &ValidationError{Field: "fan_out", Message: err.Error()}
+	}
+	return nil
+}
+
+// FanOut returns how many copies of the request a firing dispatches,
+// evaluating its fan_out template; 1 when fan_out is unset
+func (e *Evaluator) FanOut(req *ScheduledRequest) (int, error) {
+	if req.FanOut == "" {
+		return 1, nil
+	}
+	value, err := e.resolveString(req.FanOut)
+	if err != nil {
+		return 0, err
+	}
+	return parseFanOut(value)
+}
+
+// parseFanOut parses a fan_out count, which must be between 1 and MaxFanOut
+func parseFanOut(value string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("must be a whole number of copies, got %q", value)
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("must be at least 1, got %d", n)
+	}
+	if n > MaxFanOut {
+		return 0, fmt.Errorf("must be at most %d, got %d", MaxFanOut, n)
+	}
+	return n, nil
+}
@@ -0,0 +1,83 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestScheduledRequest_ValidateFanOut(t *testing.T) {
+	tests := []struct {
+		fanOut  string
+		wantErr string
+	}{
+		{fanOut: ""},
+		{fanOut: "3"},
+		{fanOut: "{{ randInt 1 5 }}"},
+		{fanOut: "0", wantErr: "fan_out: must be at least 1"},
+		{fanOut: "-2", wantErr: "fan_out: must be at least 1"},
+		{fanOut: "many", wantErr: "fan_out: must be a whole number"},
+		{fanOut: "1000"},
+		{fanOut: "1001", wantErr: "fan_out: must be at most 1000, got 1001"},
+	}
+	for _, tt := range tests {
+		req := ScheduledRequest{
+			Name:     "burst",
+			Schedule: ScheduleSpec{Relative: stringPtr("1s")},
+			HTTP:     HttpRequestSpec{Method: "GET", URL: NewLiteralString("http://localhost")},
+			FanOut:   tt.fanOut,
+		}
+		err := req.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("fan_out %q: unexpected error: %v", tt.fanOut, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("fan_out %q: expected error containing %q, got %v", tt.fanOut, tt.wantErr, err)
+		}
+	}
+
+	setup := ScheduledRequest{
+		Name:   "login",
+		HTTP:   HttpRequestSpec{Method: "POST", URL: NewLiteralString("http://localhost/login")},
+		FanOut: "2",
+	}
+	if err := setup.ValidateUnscheduled(); err == nil || !strings.Contains(err.Error(), "fan_out") {
+		t.Errorf("Expected a setup request to reject fan_out, got %v", err)
+	}
+}
+
+func TestEvaluator_FanOut(t *testing.T) {
+	evaluator := NewEvaluator(NewTemplateEngine(&EvaluationContext{Clock: &RealClock{}, Seed: 7}))
+
+	if n, err := evaluator.FanOut(&ScheduledRequest{}); err != nil || n != 1 {
+		t.Errorf("Expected an unset fan_out to dispatch 1 copy, got %d, %v", n, err)
+	}
+
+	req := &ScheduledRequest{FanOut: "{{ randInt 1 5 }}"}
+	for i := 0; i < 20; i++ {
+		n, err := evaluator.FanOut(req)
+		if err != nil || n < 1 || n > 5 {
+			t.Fatalf("Expected between 1 and 5 copies, got %d, %v", n, err)
+		}
+	}
+
+	if _, err := evaluator.FanOut(&ScheduledRequest{FanOut: "{{ randInt 0 0 }}"}); err == nil {
+		t.Error("Expected a fan_out evaluating to 0 to fail")
+	}
+	if _, err := evaluator.FanOut(&ScheduledRequest{FanOut: "{{ randInt 5000 5000 }}"}); err == nil || !strings.Contains(err.Error(), "at most 1000") {
+		t.Errorf("Expected a fan_out evaluating above the cap to fail, got %v", err)
+	}
+
+	// A literal count may be written as a YAML integer
+	var decoded ScheduledRequest
+	if err := yaml.Unmarshal([]byte("fan_out: 4"), &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if n, err := evaluator.FanOut(&decoded); err != nil || n != 4 {
+		t.Errorf("Expected 4 copies, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,132 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RequestFilter selects a subset of requests by name pattern and tags
+type RequestFilter struct {
+	patterns []*regexp.Regexp
+	tags     map[string]bool
+}
+
+// NewRequestFilter builds a filter from comma-separated name patterns and
+// tags. Patterns are globs ("orders-*", "health?") unless wrapped in slashes,
+// in which case they are regular expressions ("/^orders-(create|update)$/").
+// A request is selected when it matches any pattern and carries any of the
+// tags; an empty pattern or tag list selects everything.
+func NewRequestFilter(match string, tags []string) (*RequestFilter, error) {
+	filter := &RequestFilter{}
+
+	for _, pattern := range splitPatterns(match) {
+		var expr string
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			expr = pattern[1 : len(pattern)-1]
+		} else {
+			expr = globToRegexp(pattern)
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match pattern %q: %w", pattern, err)
+		}
+		filter.patterns = append(filter.patterns, re)
+	}
+
+	if len(tags) > 0 {
+		filter.tags = make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			filter.tags[tag] = true
+		}
+	}
+
+	return filter, nil
+}
+
+// Matches reports whether a request is selected by the filter
+func (f *RequestFilter) Matches(req *ScheduledRequest) bool {
+	if len(f.patterns) > 0 {
+		matched := false
+		for _, re := range f.patterns {
+			if re.MatchString(req.Name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if f.tags != nil {
+		for _, tag := range req.Tags {
+			if f.tags[tag] {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// Apply returns the requests selected by the filter, preserving order
+func (f *RequestFilter) Apply(requests []ScheduledRequest) []ScheduledRequest {
+	var selected []ScheduledRequest
+	for i := range requests {
+		if f.Matches(&requests[i]) {
+			selected = append(selected, requests[i])
+		}
+	}
+	return selected
+}
+
+// splitPatterns splits a comma-separated pattern list, dropping empty entries.
+// Commas inside /regex/ patterns are kept.
+func splitPatterns(match string) []string {
+	var patterns []string
+	var current strings.Builder
+	inRegex := false
+
+	flush := func() {
+		if pattern := strings.TrimSpace(current.String()); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+		current.Reset()
+	}
+
+	for _, r := range match {
+		switch {
+		case r == '/' && strings.TrimSpace(current.String()) == "":
+			inRegex = true
+		case r == '/' && inRegex:
+			inRegex = false
+		case r == ',' && !inRegex:
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+	return patterns
+}
+
+// globToRegexp converts a glob with * and ? wildcards to an anchored regular expression
+func globToRegexp(glob string) string {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return expr.String()
+}
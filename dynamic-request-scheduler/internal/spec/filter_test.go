@@ -0,0 +1,58 @@
+package spec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRequestFilter(t *testing.T) {
+	requests := []ScheduledRequest{
+		{Name: "orders-create", Tags: []string{"orders", "write"}},
+		{Name: "orders-list", Tags: []string{"orders"}},
+		{Name: "health", Tags: []string{"smoke"}},
+		{Name: "health2"},
+		{Name: "users.get"},
+	}
+
+	tests := []struct {
+		name  string
+		match string
+		tags  []string
+		want  []string
+	}{
+		{name: "no filter", want: []string{"orders-create", "orders-list", "health", "health2", "users.get"}},
+		{name: "glob", match: "orders-*", want: []string{"orders-create", "orders-list"}},
+		{name: "glob is anchored", match: "health", want: []string{"health"}},
+		{name: "single character", match: "health?", want: []string{"health2"}},
+		{name: "dots are literal", match: "users.*", want: []string{"users.get"}},
+		{name: "multiple patterns", match: "health, users.get", want: []string{"health", "users.get"}},
+		{name: "regex", match: "/^orders-(create|delete)$/", want: []string{"orders-create"}},
+		{name: "regex with comma", match: "/^health\\d{1,2}$/,users.get", want: []string{"health2", "users.get"}},
+		{name: "tags", tags: []string{"smoke", "write"}, want: []string{"orders-create", "health"}},
+		{name: "pattern and tags", match: "orders-*", tags: []string{"write"}, want: []string{"orders-create"}},
+		{name: "no matches", match: "missing", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewRequestFilter(tt.match, tt.tags)
+			if err != nil {
+				t.Fatalf("NewRequestFilter() error = %v", err)
+			}
+
+			var got []string
+			for _, req := range filter.Apply(requests) {
+				got = append(got, req.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRequestFilter_InvalidRegex(t *testing.T) {
+	if _, err := NewRequestFilter("/orders-(/", nil); err == nil {
+		t.Error("Expected error for invalid regular expression")
+	}
+}
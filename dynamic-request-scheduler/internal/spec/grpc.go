@@ -0,0 +1,109 @@
+package spec
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// GRPCSpec calls a unary gRPC method, whose request and response schemas are
+// looked up through server reflection. The address and method accept
+// templates; metadata and the message are resolved like HTTP headers and
+// bodies, with the message written in its JSON form.
+type GRPCSpec struct {
+	// Address is the server's host:port
+	Address string `json:"address" yaml:"address"`
+
+	// TLS connects over TLS instead of cleartext HTTP/2
+	TLS bool `json:"tls,omitempty" yaml:"tls,omitempty"`
+
+	// Method is the full method name, e.g. orders.v1.Orders/GetOrder
+	Method   string            `json:"method" yaml:"method"`
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Message  interface{}       `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// GRPCTarget is the server and method of a resolved gRPC call; the message
+// and metadata are the resolved request's Body and Headers
+type GRPCTarget struct {
+	Address string
+	TLS     bool
+	Method  string
+}
+
+// URL identifies the call in logs and results
+func (g *GRPCTarget) URL() string {
+	scheme := "grpc"
+	if g.TLS {
+		scheme = "grpcs"
+	}
+	return scheme + "://" + g.Address + "/" + g.Method
+}
+
+// Validate validates a gRPC call specification
+func (g *GRPCSpec) Validate() error {
+	if g.Address == "" {
+		return &ValidationError{
+			Field:   "grpc.address",
+			Message: "address is required",
+		}
+	}
+	if !IsTemplateString(g.Address) {
+		if _, _, err := net.SplitHostPort(g.Address); err != nil {
+			return &ValidationError{
+				Field:   "grpc.address",
+				Message: fmt.Sprintf("address must be host:port, got %s", g.Address),
+			}
+		}
+	}
+
+	if g.Method == "" {
+		return &ValidationError{
+			Field:   "grpc.method",
+			Message: "method is required",
+		}
+	}
+	if !IsTemplateString(g.Method) && !validGRPCMethod(g.Method) {
+		return &ValidationError{
+			Field:   "grpc.method",
+			Message: fmt.Sprintf("method must name a service and method, e.g. orders.v1.Orders/GetOrder, got %s", g.Method),
+		}
+	}
+
+	switch g.Message.(type) {
+	case nil, map[string]interface{}, string:
+	default:
+		return &ValidationError{
+			Field:   "grpc.message",
+			Message: "message must be an object",
+		}
+	}
+
+	return nil
+}
+
+// validGRPCMethod reports whether name has a service and a method, split by
+// a slash or the last dot
+func validGRPCMethod(name string) bool {
+	name = strings.TrimPrefix(name, "/")
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		i = strings.LastIndex(name, ".")
+	}
+	return i > 0 && i < len(name)-1
+}
+
+// resolveGRPC resolves the address and method of a gRPC call
+func (e *Evaluator) resolveGRPC(g *GRPCSpec) (*GRPCTarget, error) {
+	target := &GRPCTarget{TLS: g.TLS}
+
+	var err error
+	if target.Address, err = e.resolveString(g.Address); err != nil {
+		return nil, fmt.Errorf("failed to resolve gRPC address template: %w", err)
+	}
+	if target.Method, err = e.resolveString(g.Method); err != nil {
+		return nil, fmt.Errorf("failed to resolve gRPC method template: %w", err)
+	}
+	target.Method = strings.TrimPrefix(target.Method, "/")
+	return target, nil
+}
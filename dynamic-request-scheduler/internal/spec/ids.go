@@ -0,0 +1,95 @@
+package spec
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// RequestID returns the ID that identifies the request in saved state and
+// recorded results: its id when set, and otherwise DefaultRequestID of its
+// name
+func (r *ScheduledRequest) RequestID() string {
+	if r.ID != "" {
+		return r.ID
+	}
+	return DefaultRequestID(r.Name)
+}
+
+// DefaultRequestID derives a request ID from a name by lower-casing it and
+// joining its words with dashes, so "Nightly Cleanup" becomes
+// "nightly-cleanup". Names without letters or digits to keep get an ID
+// hashed from the name instead.
+func DefaultRequestID(name string) string {
+	var id strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && id.Len() > 0 {
+				id.WriteByte('-')
+			}
+			id.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	if id.Len() > 0 {
+		return id.String()
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+	return fmt.Sprintf("request-%08x", hash.Sum32())
+}
+
+// validRequestID reports whether id only uses the characters IDs may contain
+func validRequestID(id string) bool {
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return id != ""
+}
+
+// assignIDs rejects requests whose names or IDs are already used by another
+// request in any section, since captures, state, filtering and history all
+// find requests by them, and fills in the default ID of requests without one
+func assignIDs(config *Config) error {
+	type owner struct {
+		section string
+		index   int
+	}
+	names := make(map[string]owner)
+	ids := make(map[string]owner)
+
+	sections := []struct {
+		name     string
+		requests []ScheduledRequest
+	}{
+		{"setup", config.Setup},
+		{"request", config.Requests},
+		{"teardown", config.Teardown},
+	}
+	for _, section := range sections {
+		for i := range section.requests {
+			req := &section.requests[i]
+			if other, ok := names[req.Name]; ok {
+				return fmt.Errorf("%s %d (%s): name is already used by %s %d", section.name, i, req.Name, other.section, other.index)
+			}
+			names[req.Name] = owner{section.name, i}
+
+			if req.ID == "" {
+				req.ID = DefaultRequestID(req.Name)
+			}
+			if other, ok := ids[req.ID]; ok {
+				return fmt.Errorf("%s %d (%s): id %q is already used by %s %d; set a distinct id", section.name, i, req.Name, req.ID, other.section, other.index)
+			}
+			ids[req.ID] = owner{section.name, i}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultRequestID(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "health", want: "health"},
+		{name: "Nightly Cleanup", want: "nightly-cleanup"},
+		{name: "  POST /orders (v2) ", want: "post-orders-v2"},
+		{name: "sync_users", want: "sync-users"},
+	}
+	for _, tt := range tests {
+		if got := DefaultRequestID(tt.name); got != tt.want {
+			t.Errorf("DefaultRequestID(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if id := DefaultRequestID("健康"); !strings.HasPrefix(id, "request-") || id == DefaultRequestID("検査") {
+		t.Errorf("Expected names without ASCII letters to get distinct hashed IDs, got %q", id)
+	}
+
+	req := ScheduledRequest{Name: "Nightly Cleanup", ID: "cleanup"}
+	if got := req.RequestID(); got != "cleanup" {
+		t.Errorf("Expected a set ID to be used, got %q", got)
+	}
+	req.ID = ""
+	if got := req.RequestID(); got != "nightly-cleanup" {
+		t.Errorf("Expected an ID derived from the name, got %q", got)
+	}
+}
+
+func TestLoadConfigFile_RequestIDs(t *testing.T) {
+	path := writeConfig(t, "ids.yaml", `
+setup:
+  - name: "Login"
+    http:
+      method: "POST"
+      url: "http://localhost/login"
+requests:
+  - name: "Nightly Cleanup"
+    schedule:
+      relative: "1m"
+    http:
+      method: "GET"
+      url: "http://localhost/cleanup"
+  - name: "orders"
+    id: "orders.v2"
+    schedule:
+      relative: "1m"
+    http:
+      method: "GET"
+      url: "http://localhost/orders"
+`)
+	config, err := LoadConfigFile(path)
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	if got := config.Setup[0].ID; got != "login" {
+		t.Errorf("Expected setup ID login, got %q", got)
+	}
+	if got := config.Requests[0].ID; got != "nightly-cleanup" {
+		t.Errorf("Expected default ID nightly-cleanup, got %q", got)
+	}
+	if got := config.Requests[1].ID; got != "orders.v2" {
+		t.Errorf("Expected the configured ID to be kept, got %q", got)
+	}
+}
+
+func TestLoadConfigFile_DuplicateRequests(t *testing.T) {
+	request := func(name, id string) string {
+		entry := `
+  - name: "` + name + `"`
+		if id != "" {
+			entry += `
+    id: "` + id + `"`
+		}
+		return entry + `
+    http:
+      method: "GET"
+      url: "http://localhost/"`
+	}
+	scheduled := func(name, id string) string {
+		return request(name, id) + `
+    schedule:
+      relative: "1m"`
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "duplicate request names",
+			content: "requests:" + scheduled("orders", "") + scheduled("orders", "orders-2"),
+			wantErr: "request 1 (orders): name is already used by request 0",
+		},
+		{
+			name:    "setup and request share a name",
+			content: "setup:" + request("login", "") + "\nrequests:" + scheduled("login", "login-check"),
+			wantErr: "request 0 (login): name is already used by setup 0",
+		},
+		{
+			name:    "names with the same default ID",
+			content: "requests:" + scheduled("Sync Users", "") + "\nteardown:" + request("sync-users", ""),
+			wantErr: `teardown 0 (sync-users): id "sync-users" is already used by request 0`,
+		},
+		{
+			name:    "duplicate IDs",
+			content: "requests:" + scheduled("orders", "shared") + scheduled("payments", "shared"),
+			wantErr: `request 1 (payments): id "shared" is already used by request 0`,
+		},
+		{
+			name:    "invalid ID",
+			content: "requests:" + scheduled("orders", "orders v2"),
+			wantErr: "invalid id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfigFile(writeConfig(t, "config.yaml", tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package spec
+
+import (
+	"fmt"
+)
+
+// JSONRPCSpec calls a JSON-RPC 2.0 method over HTTP. The URL, method and
+// headers accept templates; params and id are resolved like an HTTP body.
+type JSONRPCSpec struct {
+	URL     string            `json:"url" yaml:"url"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Method  string            `json:"method" yaml:"method"`
+
+	// Params is an object of named parameters or an array of positional ones
+	Params interface{} `json:"params,omitempty" yaml:"params,omitempty"`
+
+	// ID defaults to a number that increases with every call
+	ID interface{} `json:"id,omitempty" yaml:"id,omitempty"`
+
+	// Notification sends the call without an id; no response is expected
+	Notification bool `json:"notification,omitempty" yaml:"notification,omitempty"`
+}
+
+// JSONRPCOptions are the settings of a resolved JSON-RPC call, whose request
+// object is the resolved request's Body
+type JSONRPCOptions struct {
+	// ID is the id sent with the call, nil for notifications
+	ID           interface{}
+	Notification bool
+}
+
+// Validate validates a JSON-RPC call specification
+func (j *JSONRPCSpec) Validate() error {
+	if j.URL == "" {
+		return &ValidationError{
+			Field:   "jsonrpc.url",
+			Message: "URL is required",
+		}
+	}
+
+	if j.Method == "" {
+		return &ValidationError{
+			Field:   "jsonrpc.method",
+			Message: "method is required",
+		}
+	}
+
+	switch j.Params.(type) {
+	case nil, map[string]interface{}, []interface{}:
+	default:
+		return &ValidationError{
+			Field:   "jsonrpc.params",
+			Message: "params must be an object or an array",
+		}
+	}
+
+	switch j.ID.(type) {
+	case nil, string, int:
+		if j.ID != nil && j.Notification {
+			return &ValidationError{
+				Field:   "jsonrpc.id",
+				Message: "notifications are sent without an id",
+			}
+		}
+	default:
+		return &ValidationError{
+			Field:   "jsonrpc.id",
+			Message: "id must be a string or an integer",
+		}
+	}
+
+	return nil
+}
+
+// resolveJSONRPC resolves the method, params and id of a JSON-RPC call and
+// returns the request object to send
+func (e *Evaluator) resolveJSONRPC(j *JSONRPCSpec) (map[string]interface{}, *JSONRPCOptions, error) {
+	method, err := e.resolveString(j.Method)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve JSON-RPC method template: %w", err)
+	}
+
+	call := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+	}
+	if j.Params != nil {
+		params, err := e.resolveValue(j.Params)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve JSON-RPC params: %w", err)
+		}
+		call["params"] = params
+	}
+
+	options := &JSONRPCOptions{Notification: j.Notification}
+	if !j.Notification {
+		if j.ID == nil {
+			options.ID = e.nextJSONRPCID.Add(1)
+		} else if options.ID, err = e.resolveValue(j.ID); err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve JSON-RPC id: %w", err)
+		}
+		call["id"] = options.ID
+	}
+	return call, options, nil
+}
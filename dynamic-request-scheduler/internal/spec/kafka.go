@@ -0,0 +1,90 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KafkaSpec produces a single record to a Kafka (or Kafka-compatible) topic.
+// Topic and key accept templates; headers and value are resolved like HTTP
+// headers and bodies.
+type KafkaSpec struct {
+	Brokers []string `json:"brokers" yaml:"brokers"`
+	Topic   string   `json:"topic" yaml:"topic"`
+	Key     string   `json:"key,omitempty" yaml:"key,omitempty"`
+
+	// Value is sent as-is when it is a string and encoded as JSON otherwise
+	Value   interface{}       `json:"value,omitempty" yaml:"value,omitempty"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// Partition pins the record to one partition; by default keyed records
+	// are hashed like the Java client and keyless records spread round-robin
+	Partition *int32 `json:"partition,omitempty" yaml:"partition,omitempty"`
+}
+
+// KafkaTarget is where a resolved Kafka request is produced; the value and
+// record headers are the resolved request's Body and Headers
+type KafkaTarget struct {
+	Brokers   []string
+	Topic     string
+	Key       *string
+	Partition *int32
+}
+
+// URL describes the target as a kafka:// URL for logs and results
+func (k *KafkaTarget) URL() string {
+	return "kafka://" + strings.Join(k.Brokers, ",") + "/" + k.Topic
+}
+
+// Validate validates a Kafka produce specification
+func (k *KafkaSpec) Validate() error {
+	if len(k.Brokers) == 0 {
+		return &ValidationError{
+			Field:   "kafka.brokers",
+			Message: "at least one broker address is required",
+		}
+	}
+
+	for _, broker := range k.Brokers {
+		if !strings.Contains(broker, ":") {
+			return &ValidationError{
+				Field:   "kafka.brokers",
+				Message: fmt.Sprintf("broker address must be host:port: %s", broker),
+			}
+		}
+	}
+
+	if k.Topic == "" {
+		return &ValidationError{
+			Field:   "kafka.topic",
+			Message: "Kafka topic is required",
+		}
+	}
+
+	if k.Partition != nil && *k.Partition < 0 {
+		return &ValidationError{
+			Field:   "kafka.partition",
+			Message: "partition must be non-negative",
+		}
+	}
+
+	return nil
+}
+
+// resolveKafka resolves the templated topic and key of a Kafka request
+func (e *Evaluator) resolveKafka(k *KafkaSpec) (*KafkaTarget, error) {
+	topic, err := e.resolveString(k.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve Kafka topic template: %w", err)
+	}
+
+	target := &KafkaTarget{Brokers: k.Brokers, Topic: topic, Partition: k.Partition}
+	if k.Key != "" {
+		key, err := e.resolveString(k.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve Kafka key template: %w", err)
+		}
+		target.Key = &key
+	}
+	return target, nil
+}
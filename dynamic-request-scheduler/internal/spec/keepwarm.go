@@ -0,0 +1,88 @@
+package spec
+
+import (
+	"fmt"
+	"time"
+)
+
+// Keep-warm defaults, used when the keep_warm section leaves a field unset
+const (
+	DefaultKeepWarmInterval = "5m"
+	DefaultColdThreshold    = "1s"
+)
+
+// KeepWarmTag is the tag of requests generated from the keep_warm section,
+// so --tag keep-warm selects them
+const KeepWarmTag = "keep-warm"
+
+// KeepWarmSpec pings endpoints that go cold when idle, such as serverless
+// functions run locally, often enough to keep them warm. Each URL becomes a
+// keep-warm request (see ScheduledRequest.KeepWarm).
+type KeepWarmSpec struct {
+	// URLs are the endpoints pinged with a GET
+	URLs []string `json:"urls" yaml:"urls"`
+
+	// Interval is how often each URL is pinged, starting when the scheduler
+	// starts; empty means DefaultKeepWarmInterval
+	Interval string `json:"interval,omitempty" yaml:"interval,omitempty"`
+
+	// ColdThreshold is the response time above which an endpoint counts as
+	// cold rather than warm; empty means DefaultColdThreshold
+	ColdThreshold string `json:"cold_threshold,omitempty" yaml:"cold_threshold,omitempty"`
+}
+
+// Validate checks the keep_warm section
+func (k *KeepWarmSpec) Validate() error {
+	if k == nil {
+		return nil
+	}
+	if len(k.URLs) == 0 {
+		return &ValidationError{Field: "keep_warm.urls", Message: "at least one URL is required"}
+	}
+	for _, url := range k.URLs {
+		if url == "" {
+			return &ValidationError{Field: "keep_warm.urls", Message: "URL must not be empty"}
+		}
+	}
+	for _, field := range []struct{ name, value string }{
+		{"keep_warm.interval", k.Interval},
+		{"keep_warm.cold_threshold", k.ColdThreshold},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(field.value); err != nil || d <= 0 {
+			return &ValidationError{Field: field.name, Message: fmt.Sprintf("must be a positive duration, got %s", field.value)}
+		}
+	}
+	return nil
+}
+
+// Requests returns a keep-warm request per URL, named "keep-warm <url>"
+func (k *KeepWarmSpec) Requests() []ScheduledRequest {
+	if k == nil {
+		return nil
+	}
+	interval := k.Interval
+	if interval == "" {
+		interval = DefaultKeepWarmInterval
+	}
+	threshold := k.ColdThreshold
+	if threshold == "" {
+		threshold = DefaultColdThreshold
+	}
+
+	requests := make([]ScheduledRequest, 0, len(k.URLs))
+	for _, url := range k.URLs {
+		every := interval
+		requests = append(requests, ScheduledRequest{
+			Name:          KeepWarmTag + " " + url,
+			Tags:          []string{KeepWarmTag},
+			Schedule:      ScheduleSpec{Every: &every},
+			HTTP:          HttpRequestSpec{Method: "GET", URL: NewLiteralString(url)},
+			SlowThreshold: threshold,
+			KeepWarm:      true,
+		})
+	}
+	return requests
+}
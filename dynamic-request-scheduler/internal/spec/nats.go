@@ -0,0 +1,99 @@
+package spec
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// defaultNATSURL is the default local NATS server address
+const defaultNATSURL = "nats://localhost:4222"
+
+// NATSSpec publishes a message to a NATS subject, or sends it as a request
+// and waits for a reply. The URL and subject accept templates; headers and
+// payload are resolved like HTTP headers and bodies.
+type NATSSpec struct {
+	// URL defaults to nats://localhost:4222
+	URL     string            `json:"url,omitempty" yaml:"url,omitempty"`
+	Subject string            `json:"subject" yaml:"subject"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Payload interface{}       `json:"payload,omitempty" yaml:"payload,omitempty"`
+
+	// Request waits for a reply, which becomes the execution's response body
+	Request bool `json:"request,omitempty" yaml:"request,omitempty"`
+
+	// Timeout bounds the wait for a reply (e.g. "2s"); defaults to the
+	// global request timeout
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// NATSTarget is where a resolved NATS request is sent; the payload and
+// headers are the resolved request's Body and Headers
+type NATSTarget struct {
+	URL     string
+	Subject string
+	Request bool
+	Timeout time.Duration
+}
+
+// DisplayURL is the server URL without its password or token, for logs and
+// results
+func (n *NATSTarget) DisplayURL() string {
+	return withoutCredentials(n.URL)
+}
+
+// Validate validates a NATS message specification
+func (n *NATSSpec) Validate() error {
+	if n.Subject == "" {
+		return &ValidationError{
+			Field:   "nats.subject",
+			Message: "subject is required",
+		}
+	}
+
+	if !IsTemplateString(n.URL) && n.URL != "" {
+		if u, err := url.Parse(n.URL); err != nil || u.Scheme != "nats" {
+			return &ValidationError{
+				Field:   "nats.url",
+				Message: fmt.Sprintf("invalid NATS URL: %s", n.URL),
+			}
+		}
+	}
+
+	if n.Timeout != "" {
+		if !n.Request {
+			return &ValidationError{
+				Field:   "nats.timeout",
+				Message: "timeout only applies when request is true",
+			}
+		}
+		if d, err := time.ParseDuration(n.Timeout); err != nil || d <= 0 {
+			return &ValidationError{
+				Field:   "nats.timeout",
+				Message: fmt.Sprintf("timeout must be a positive duration: %s", n.Timeout),
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveNATS resolves the URL and subject of a NATS request
+func (e *Evaluator) resolveNATS(n *NATSSpec) (*NATSTarget, error) {
+	target := &NATSTarget{URL: n.URL, Request: n.Request}
+	if target.URL == "" {
+		target.URL = defaultNATSURL
+	}
+	if n.Timeout != "" {
+		target.Timeout, _ = time.ParseDuration(n.Timeout)
+	}
+
+	var err error
+	if target.URL, err = e.resolveString(target.URL); err != nil {
+		return nil, fmt.Errorf("failed to resolve NATS URL template: %w", err)
+	}
+	if target.Subject, err = e.resolveString(n.Subject); err != nil {
+		return nil, fmt.Errorf("failed to resolve NATS subject template: %w", err)
+	}
+	return target, nil
+}
@@ -0,0 +1,133 @@
+package spec
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PluginSpec registers an external executable that extends the scheduler.
+// It is run once per call with a JSON message on stdin and answers with one
+// on stdout.
+type PluginSpec struct {
+	Name string `json:"name" yaml:"name"`
+
+	// Command is the executable and its arguments. An executable given as
+	// a relative path, such as ./plugins/grpc, is found relative to the
+	// config file; a bare name is looked up on PATH.
+	Command []string `json:"command" yaml:"command"`
+
+	// Type makes the plugin the executor for requests whose plugin section
+	// has this type
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// Transform passes every resolved request through the plugin just before
+	// it is sent, so it can sign or rewrite it
+	Transform bool `json:"transform,omitempty" yaml:"transform,omitempty"`
+
+	// Requests limits Transform to the named requests; empty means all
+	Requests []string `json:"requests,omitempty" yaml:"requests,omitempty"`
+
+	// Timeout bounds each call, e.g. "5s" (default 30s)
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// builtinTypes are the request types that plugins cannot take over. grpc is
+// left out so plugins registered for it before it was built in keep working;
+// such a plugin executes grpc sections as well as its own plugin sections.
+var builtinTypes = []string{TypeHTTP, TypeSSE, TypeKafka, TypeAMQP, TypeRedis, TypeNATS, TypeSQS, TypeSNS, TypeSOAP, TypeJSONRPC}
+
+// Validate validates a plugin registration
+func (p *PluginSpec) Validate() error {
+	if p.Name == "" {
+		return &ValidationError{Field: "name", Message: "plugin name is required"}
+	}
+	if len(p.Command) == 0 || p.Command[0] == "" {
+		return &ValidationError{Field: "command", Message: "command is required (e.g. [\"./plugins/sign\", \"--key\", \"dev\"])"}
+	}
+	if p.Type == "" && !p.Transform {
+		return &ValidationError{Field: "type", Message: "set type to handle a custom request type, or transform: true to rewrite requests"}
+	}
+	for _, builtin := range builtinTypes {
+		if p.Type == builtin {
+			return &ValidationError{Field: "type", Message: fmt.Sprintf("%s is a built-in request type", p.Type)}
+		}
+	}
+	if len(p.Requests) > 0 && !p.Transform {
+		return &ValidationError{Field: "requests", Message: "requests only applies to transform plugins"}
+	}
+	if timeout, err := p.TimeoutDuration(); err != nil || timeout < 0 {
+		return &ValidationError{Field: "timeout", Message: fmt.Sprintf("invalid duration: %s", p.Timeout)}
+	}
+	return nil
+}
+
+// TimeoutDuration parses Timeout, returning zero when it is not set
+func (p *PluginSpec) TimeoutDuration() (time.Duration, error) {
+	if p.Timeout == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(p.Timeout)
+}
+
+// resolveCommand makes a relative executable path relative to dir
+func (p *PluginSpec) resolveCommand(dir string) {
+	exe := p.Command[0]
+	if strings.ContainsRune(exe, filepath.Separator) && !filepath.IsAbs(exe) {
+		p.Command[0] = filepath.Join(dir, exe)
+	}
+}
+
+// PluginRequestSpec is a request of a custom type, executed by the plugin
+// registered for that type
+type PluginRequestSpec struct {
+	Type string `json:"type" yaml:"type"`
+
+	// Target names what the request is sent to, such as a gRPC address. It
+	// is shown as the request's URL in logs and results.
+	Target string `json:"target,omitempty" yaml:"target,omitempty"`
+
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty" yaml:"body,omitempty"`
+
+	// Params are any further options the plugin takes, passed with their
+	// templates resolved
+	Params map[string]interface{} `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// PluginTarget is a resolved plugin request; the target, headers and body
+// are the resolved request's URL, Headers and Body
+type PluginTarget struct {
+	Type   string
+	Params map[string]interface{}
+}
+
+// Validate validates a plugin request specification. Whether a plugin
+// handles the type is checked when the config is loaded.
+func (p *PluginRequestSpec) Validate() error {
+	if p.Type == "" {
+		return &ValidationError{Field: "plugin.type", Message: "type is required and must match a plugin in the plugins section"}
+	}
+	for _, builtin := range builtinTypes {
+		if p.Type == builtin {
+			return &ValidationError{Field: "plugin.type", Message: fmt.Sprintf("%s is a built-in request type; use its own section", p.Type)}
+		}
+	}
+	return nil
+}
+
+// resolvePlugin resolves the params of a plugin request
+func (e *Evaluator) resolvePlugin(p *PluginRequestSpec) (*PluginTarget, error) {
+	target := &PluginTarget{Type: p.Type}
+	if p.Params == nil {
+		return target, nil
+	}
+
+	params, err := e.resolveValue(p.Params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve plugin params: %w", err)
+	}
+	target.Params, _ = params.(map[string]interface{})
+	return target, nil
+}
@@ -0,0 +1,100 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// defaultRedisURL is the default local Redis address and database
+const defaultRedisURL = "redis://localhost:6379/0"
+
+// RedisSpec runs a single command against Redis, such as pushing a job onto
+// a list or publishing to a channel. The URL and every command argument
+// accept templates; structured arguments are encoded as JSON.
+type RedisSpec struct {
+	// URL defaults to redis://localhost:6379/0
+	URL     string        `json:"url,omitempty" yaml:"url,omitempty"`
+	Command []interface{} `json:"command" yaml:"command"`
+}
+
+// RedisTarget is a resolved Redis command
+type RedisTarget struct {
+	URL  string
+	Args []string
+}
+
+// DisplayURL is the server URL without its password, for logs and results
+func (r *RedisTarget) DisplayURL() string {
+	// redis://secret@host is shorthand for a password without a user name
+	return withoutCredentials(r.URL)
+}
+
+// Validate validates a Redis command specification
+func (r *RedisSpec) Validate() error {
+	if len(r.Command) == 0 {
+		return &ValidationError{
+			Field:   "redis.command",
+			Message: "command is required (e.g. [\"LPUSH\", \"jobs\", \"payload\"])",
+		}
+	}
+
+	if name, ok := r.Command[0].(string); !ok || name == "" {
+		return &ValidationError{
+			Field:   "redis.command",
+			Message: "the first element must be the command name",
+		}
+	}
+
+	if !IsTemplateString(r.URL) && r.URL != "" {
+		if u, err := url.Parse(r.URL); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") {
+			return &ValidationError{
+				Field:   "redis.url",
+				Message: fmt.Sprintf("invalid Redis URL: %s", r.URL),
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveRedis resolves the URL and command arguments of a Redis request
+func (e *Evaluator) resolveRedis(r *RedisSpec) (*RedisTarget, error) {
+	target := &RedisTarget{URL: r.URL}
+	if target.URL == "" {
+		target.URL = defaultRedisURL
+	}
+
+	var err error
+	if target.URL, err = e.resolveString(target.URL); err != nil {
+		return nil, fmt.Errorf("failed to resolve Redis URL template: %w", err)
+	}
+
+	for i, arg := range r.Command {
+		value, err := e.resolveValue(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve Redis argument %d: %w", i, err)
+		}
+		str, err := commandArg(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode Redis argument %d: %w", i, err)
+		}
+		target.Args = append(target.Args, str)
+	}
+	return target, nil
+}
+
+// commandArg converts a resolved argument to the string sent to the server
+func commandArg(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		return string(data), err
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
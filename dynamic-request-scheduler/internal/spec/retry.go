@@ -0,0 +1,119 @@
+package spec
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Retry policy defaults, used when a retry section leaves a field unset
+const (
+	DefaultRetryAttempts   = 3
+	DefaultRetryBackoff    = time.Second
+	DefaultRetryMaxBackoff = 30 * time.Second
+)
+
+// RetrySpec retries an execution that failed transiently, so a scheduled run
+// isn't lost to a service restarting or briefly overloaded
+type RetrySpec struct {
+	// Attempts is how many times an execution is sent at most, the first
+	// time included; zero means DefaultRetryAttempts
+	Attempts int `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+
+	// Backoff is the delay before the first retry (e.g. "500ms"), doubling
+	// for each retry after it; empty means DefaultRetryBackoff
+	Backoff string `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+
+	// MaxBackoff caps the delay between attempts; empty means
+	// DefaultRetryMaxBackoff
+	MaxBackoff string `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`
+
+	// OnStatus lists the status codes that are retried; empty means 429 and
+	// every 5xx status. Errors without a response, such as a refused
+	// connection or a timeout, are always retried.
+	OnStatus StatusCodes `json:"on_status,omitempty" yaml:"on_status,omitempty"`
+}
+
+// MaxAttempts returns how many times an execution is sent at most
+func (r *RetrySpec) MaxAttempts() int {
+	if r.Attempts <= 0 {
+		return DefaultRetryAttempts
+	}
+	return r.Attempts
+}
+
+// RetriesStatus reports whether a response with the status code is retried
+func (r *RetrySpec) RetriesStatus(code int) bool {
+	if len(r.OnStatus) > 0 {
+		return r.OnStatus.Contains(code)
+	}
+	return code == 429 || (code >= 500 && code < 600)
+}
+
+// Delay returns how long to wait before the given retry, counting from 1:
+// the backoff doubled for each earlier retry and capped at the maximum, of
+// which a random half is kept so that executions failing together spread
+// their retries out. The half is drawn from rng, or from the global source
+// when rng is nil.
+func (r *RetrySpec) Delay(retry int, rng *rand.Rand) time.Duration {
+	backoff, maxBackoff := r.durations()
+	delay := backoff
+	for i := 1; i < retry && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxBackoff)
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	if rng == nil {
+		return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+	}
+	return half + time.Duration(rng.Int63n(int64(delay-half)+1))
+}
+
+// durations parses the backoff and its cap, using the defaults for fields
+// that are unset or invalid
+func (r *RetrySpec) durations() (backoff, maxBackoff time.Duration) {
+	backoff, maxBackoff = DefaultRetryBackoff, DefaultRetryMaxBackoff
+	if d, err := time.ParseDuration(r.Backoff); err == nil {
+		backoff = d
+	}
+	if d, err := time.ParseDuration(r.MaxBackoff); err == nil {
+		maxBackoff = d
+	}
+	return backoff, maxBackoff
+}
+
+// Validate checks the fields of a retry policy
+func (r *RetrySpec) Validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.Attempts < 0 {
+		return &ValidationError{Field: "retry.attempts", Message: fmt.Sprintf("must not be negative, got %d", r.Attempts)}
+	}
+	for _, field := range []struct{ name, value string }{
+		{"retry.backoff", r.Backoff},
+		{"retry.max_backoff", r.MaxBackoff},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(field.value); err != nil || d < 0 {
+			return &ValidationError{Field: field.name, Message: fmt.Sprintf("invalid duration: %s", field.value)}
+		}
+	}
+	if backoff, maxBackoff := r.durations(); maxBackoff < backoff {
+		return &ValidationError{
+			Field:   "retry.max_backoff",
+			Message: fmt.Sprintf("must not be shorter than backoff (%v), got %v", backoff, maxBackoff),
+		}
+	}
+	for _, code := range r.OnStatus {
+		if code < 100 || code > 599 {
+			return &ValidationError{Field: "retry.on_status", Message: fmt.Sprintf("invalid status code %d: must be between 100 and 599", code)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package spec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetrySpec_Delay(t *testing.T) {
+	retry := RetrySpec{Backoff: "100ms", MaxBackoff: "300ms"}
+	tests := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{retry: 1, max: 100 * time.Millisecond},
+		{retry: 2, max: 200 * time.Millisecond},
+		{retry: 3, max: 300 * time.Millisecond},
+		{retry: 10, max: 300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if delay := retry.Delay(tt.retry, nil); delay < tt.max/2 || delay > tt.max {
+				t.Fatalf("Delay(%d) = %v, want between %v and %v", tt.retry, delay, tt.max/2, tt.max)
+			}
+		}
+	}
+
+	if delay := (&RetrySpec{}).Delay(1, nil); delay < DefaultRetryBackoff/2 || delay > DefaultRetryBackoff {
+		t.Errorf("Expected the default backoff, got %v", delay)
+	}
+}
+
+func TestRetrySpec_DelaySeeded(t *testing.T) {
+	retry := RetrySpec{Backoff: "1s", MaxBackoff: "1m"}
+	delays := func(seed int64) []time.Duration {
+		evaluator := NewEvaluator(NewTemplateEngine(&EvaluationContext{Clock: &RealClock{}, Seed: seed})).ForRequest(1, "sync", 1)
+		rng := evaluator.Rand("retry")
+		var out []time.Duration
+		for i := 1; i <= 5; i++ {
+			out = append(out, retry.Delay(i, rng))
+		}
+		return out
+	}
+
+	first, second := delays(42), delays(42)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected the same seed to give the same delays, got %v and %v", first, second)
+		}
+	}
+	if other := delays(43); reflect.DeepEqual(first, other) {
+		t.Errorf("Expected another seed to give other delays, got %v for both", first)
+	}
+
+	unseeded := NewEvaluator(NewTemplateEngine(&EvaluationContext{Clock: &RealClock{}}))
+	if rng := unseeded.Rand("retry"); rng != nil {
+		t.Error("Expected an unseeded evaluator to leave jitter to the global source")
+	}
+}
+
+func TestRetrySpec_RetriesStatus(t *testing.T) {
+	defaults := RetrySpec{}
+	for code, want := range map[int]bool{200: false, 404: false, 429: true, 500: true, 503: true} {
+		if got := defaults.RetriesStatus(code); got != want {
+			t.Errorf("RetriesStatus(%d) = %v, want %v", code, got, want)
+		}
+	}
+
+	listed := RetrySpec{OnStatus: StatusCodes{409}}
+	if !listed.RetriesStatus(409) || listed.RetriesStatus(500) {
+		t.Error("Expected on_status to replace the default statuses")
+	}
+}
+
+func TestRetrySpec_Validate(t *testing.T) {
+	valid := RetrySpec{Attempts: 5, Backoff: "200ms", MaxBackoff: "5s", OnStatus: StatusCodes{502, 503}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (&RetrySpec{}).Validate(); err != nil {
+		t.Errorf("Expected an empty policy to use the defaults, got %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		retry RetrySpec
+		field string
+	}{
+		{name: "negative attempts", retry: RetrySpec{Attempts: -1}, field: "retry.attempts"},
+		{name: "invalid backoff", retry: RetrySpec{Backoff: "soon"}, field: "retry.backoff"},
+		{name: "invalid max backoff", retry: RetrySpec{MaxBackoff: "-1s"}, field: "retry.max_backoff"},
+		{name: "max below backoff", retry: RetrySpec{Backoff: "1m"}, field: "retry.max_backoff"},
+		{name: "invalid status", retry: RetrySpec{OnStatus: StatusCodes{42}}, field: "retry.on_status"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.retry.Validate()
+			if err == nil || !strings.HasPrefix(err.Error(), tt.field+":") {
+				t.Errorf("Expected a %s error, got %v", tt.field, err)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package spec
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -56,6 +57,14 @@ func (s *ScheduleEngine) ComputeNextRun(now time.Time, schedule ScheduleSpec) (t
 		}
 		baseTime = cronSchedule.Next(now)
 
+	case schedule.Every != nil:
+		// Every scheduling - run on a fixed interval
+		interval, err := time.ParseDuration(*schedule.Every)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid every interval '%s': %w", *schedule.Every, err)
+		}
+		baseTime = now.Add(interval)
+
 	default:
 		return time.Time{}, fmt.Errorf("no valid schedule strategy found")
 	}
@@ -105,6 +114,14 @@ func (s *ScheduleEngine) ComputeNextRunWithTemplate(now time.Time, schedule Sche
 		}
 		baseTime = cronSchedule.Next(now)
 
+	case schedule.Every != nil:
+		// Every scheduling - run on a fixed interval
+		interval, err := time.ParseDuration(*schedule.Every)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid every interval '%s': %w", *schedule.Every, err)
+		}
+		baseTime = now.Add(interval)
+
 	default:
 		return time.Time{}, fmt.Errorf("no valid schedule strategy found")
 	}
@@ -117,38 +134,71 @@ func (s *ScheduleEngine) ComputeNextRunWithTemplate(now time.Time, schedule Sche
 	return baseTime, nil
 }
 
-// applyJitter adds random variation to the scheduled time
-func (s *ScheduleEngine) applyJitter(baseTime time.Time, jitterStr string) time.Time {
-	var duration time.Duration
-	var err error
-
-	// Parse jitter format: "±30s", "±2m", etc.
-	if len(jitterStr) > 1 && (jitterStr[0] == '±' || jitterStr[0] == '+' || jitterStr[0] == '-') {
-		// Handle ± symbol and +/- prefixes
-		if jitterStr[0] == '±' {
-			duration, err = time.ParseDuration(jitterStr[1:])
-		} else {
-			duration, err = time.ParseDuration(jitterStr)
+// NextRuns returns up to n upcoming fire times after now, ignoring jitter.
+// Schedules that do not repeat fire once, so they yield a single time.
+func (s *ScheduleEngine) NextRuns(now time.Time, schedule ScheduleSpec, n int, templateEngine *TemplateEngine) ([]time.Time, error) {
+	// Previews should be reproducible, so leave out the random jitter
+	schedule.Jitter = nil
+
+	var runs []time.Time
+	base := now
+	// Every schedules fire as they start, then on each interval, up to their
+	// count
+	if schedule.Every != nil {
+		runs = append(runs, now)
+		if schedule.Count > 0 {
+			n = min(n, schedule.Count)
 		}
-	} else {
-		duration, err = time.ParseDuration(jitterStr)
 	}
+	for len(runs) < n {
+		next, err := s.ComputeNextRunWithTemplate(base, schedule, templateEngine)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, next)
 
-	if err != nil {
-		// If jitter parsing fails, return base time unchanged
-		return baseTime
+		// One-shot schedules, and zero-length intervals, have no further runs
+		if !schedule.Repeats() || !next.After(base) {
+			break
+		}
+		base = next
 	}
+	return runs, nil
+}
 
-	// Add random jitter within the duration range
-	// For now, use a simple random approach - this could be enhanced with seeded random
-	jitterNanos := duration.Nanoseconds()
-	if jitterNanos > 0 {
-		// Use time-based random for now - could be enhanced with seeded random
-		randomJitter := time.Duration(time.Now().UnixNano() % jitterNanos)
-		baseTime = baseTime.Add(randomJitter)
+// applyJitter adds random variation to the scheduled time
+func (s *ScheduleEngine) applyJitter(baseTime time.Time, jitterStr string) time.Time {
+	return baseTime.Add(s.JitterOffset(ScheduleSpec{Jitter: &jitterStr}))
+}
+
+// JitterOffset draws the random delay jitter adds to one run of the
+// schedule, between zero and its jitter, or zero when it has no valid jitter
+func (s *ScheduleEngine) JitterOffset(schedule ScheduleSpec) time.Duration {
+	jitterNanos := s.MaxJitter(schedule).Nanoseconds()
+	if jitterNanos <= 0 {
+		return 0
 	}
+	// Use time-based random for now - could be enhanced with seeded random
+	return time.Duration(time.Now().UnixNano() % jitterNanos)
+}
+
+// parseJitter parses a jitter such as "±30s", "+2m" or "30s"
+func parseJitter(jitter string) (time.Duration, error) {
+	// time.ParseDuration accepts a leading + or -, but not ±
+	return time.ParseDuration(strings.TrimPrefix(jitter, "±"))
+}
 
-	return baseTime
+// MaxJitter returns the most that jitter delays a run of the schedule, or
+// zero when it has no valid jitter
+func (s *ScheduleEngine) MaxJitter(schedule ScheduleSpec) time.Duration {
+	if schedule.Jitter == nil {
+		return 0
+	}
+	duration, err := parseJitter(*schedule.Jitter)
+	if err != nil || duration < 0 {
+		return 0
+	}
+	return duration
 }
 
 // ValidateSchedule validates a schedule specification
@@ -167,9 +217,12 @@ func (s *ScheduleEngine) ValidateSchedule(schedule ScheduleSpec) error {
 	if schedule.Cron != nil {
 		count++
 	}
+	if schedule.Every != nil {
+		count++
+	}
 
 	if count != 1 {
-		return fmt.Errorf("exactly one schedule strategy must be specified (epoch, relative, template, or cron)")
+		return fmt.Errorf("exactly one schedule strategy must be specified (epoch, relative, template, cron, or every)")
 	}
 
 	// Validate specific strategies
@@ -185,6 +238,27 @@ func (s *ScheduleEngine) ValidateSchedule(schedule ScheduleSpec) error {
 		}
 	}
 
+	if schedule.Repeat && schedule.Relative == nil {
+		return fmt.Errorf("repeat only applies to relative schedules")
+	}
+
+	if schedule.Every != nil {
+		interval, err := time.ParseDuration(*schedule.Every)
+		if err != nil {
+			return fmt.Errorf("invalid every interval '%s': %w", *schedule.Every, err)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("every interval '%s' must be positive", *schedule.Every)
+		}
+	}
+
+	if schedule.Count < 0 {
+		return fmt.Errorf("count must not be negative")
+	}
+	if schedule.Count != 0 && schedule.Every == nil {
+		return fmt.Errorf("count only applies to every schedules")
+	}
+
 	if schedule.Cron != nil {
 		if _, err := s.cronParser.Parse(*schedule.Cron); err != nil {
 			return fmt.Errorf("invalid cron expression '%s': %w", *schedule.Cron, err)
@@ -193,22 +267,7 @@ func (s *ScheduleEngine) ValidateSchedule(schedule ScheduleSpec) error {
 
 	// Validate jitter if specified
 	if schedule.Jitter != nil {
-		jitterStr := *schedule.Jitter
-		var err error
-
-		// Parse jitter format: "±30s", "±2m", etc.
-		if len(jitterStr) > 1 && (jitterStr[0] == '±' || jitterStr[0] == '+' || jitterStr[0] == '-') {
-			// Handle ± symbol and +/- prefixes
-			if jitterStr[0] == '±' {
-				_, err = time.ParseDuration(jitterStr[1:])
-			} else {
-				_, err = time.ParseDuration(jitterStr)
-			}
-		} else {
-			_, err = time.ParseDuration(jitterStr)
-		}
-
-		if err != nil {
+		if _, err := parseJitter(*schedule.Jitter); err != nil {
 			return fmt.Errorf("invalid jitter duration '%s': %w", *schedule.Jitter, err)
 		}
 	}
@@ -214,6 +214,14 @@ func TestScheduleEngine_ValidateSchedule(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid plus-minus jitter",
+			schedule: ScheduleSpec{
+				Relative: stringPtr("5m"),
+				Jitter:   stringPtr("±30s"),
+			},
+			wantErr: false,
+		},
 		{
 			name: "invalid jitter",
 			schedule: ScheduleSpec{
@@ -222,6 +230,53 @@ func TestScheduleEngine_ValidateSchedule(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "repeating relative schedule",
+			schedule: ScheduleSpec{
+				Relative: stringPtr("5m"),
+				Repeat:   true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid every schedule with count",
+			schedule: ScheduleSpec{
+				Every: stringPtr("30s"),
+				Count: 10,
+			},
+			wantErr: false,
+		},
+		{
+			name: "zero every interval",
+			schedule: ScheduleSpec{
+				Every: stringPtr("0s"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative count",
+			schedule: ScheduleSpec{
+				Every: stringPtr("30s"),
+				Count: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "count without every",
+			schedule: ScheduleSpec{
+				Relative: stringPtr("30s"),
+				Count:    3,
+			},
+			wantErr: true,
+		},
+		{
+			name: "repeat without relative",
+			schedule: ScheduleSpec{
+				Cron:   stringPtr("*/5 * * * *"),
+				Repeat: true,
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -235,6 +290,34 @@ func TestScheduleEngine_ValidateSchedule(t *testing.T) {
 	}
 }
 
+func TestScheduleEngine_MaxJitter(t *testing.T) {
+	engine := NewScheduleEngine()
+	tests := map[string]time.Duration{
+		"±30s":    30 * time.Second,
+		"+2m":     2 * time.Minute,
+		"1h":      time.Hour,
+		"-5m":     0,
+		"invalid": 0,
+	}
+	for jitter, want := range tests {
+		if got := engine.MaxJitter(ScheduleSpec{Jitter: stringPtr(jitter)}); got != want {
+			t.Errorf("%s: expected %v, got %v", jitter, want, got)
+		}
+	}
+	if got := engine.MaxJitter(ScheduleSpec{}); got != 0 {
+		t.Errorf("Expected no jitter without one, got %v", got)
+	}
+
+	// ± jitter delays runs, which it silently did not before
+	base := time.Unix(1000, 0)
+	for i := 0; i < 20; i++ {
+		got := engine.applyJitter(base, "±30s")
+		if got.Before(base) || !got.Before(base.Add(30*time.Second)) {
+			t.Fatalf("Expected ±30s jitter within [base, base+30s), got %v", got.Sub(base))
+		}
+	}
+}
+
 func TestScheduleEngine_ApplyJitter(t *testing.T) {
 	engine := NewScheduleEngine()
 	baseTime := time.Unix(1000, 0)
@@ -280,3 +363,161 @@ func TestScheduleEngine_ApplyJitter(t *testing.T) {
 		})
 	}
 }
+
+func TestScheduleEngine_NextRuns(t *testing.T) {
+	engine := NewScheduleEngine()
+	now := time.Date(2025, 1, 1, 12, 2, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule ScheduleSpec
+		want     []time.Time
+	}{
+		{
+			name:     "relative repeats",
+			schedule: ScheduleSpec{Relative: stringPtr("10m"), Repeat: true, Jitter: stringPtr("30s")},
+			want:     []time.Time{now.Add(10 * time.Minute), now.Add(20 * time.Minute), now.Add(30 * time.Minute)},
+		},
+		{
+			name:     "cron repeats",
+			schedule: ScheduleSpec{Cron: stringPtr("*/5 * * * *")},
+			want: []time.Time{
+				time.Date(2025, 1, 1, 12, 5, 0, 0, time.UTC),
+				time.Date(2025, 1, 1, 12, 10, 0, 0, time.UTC),
+				time.Date(2025, 1, 1, 12, 15, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "relative fires once",
+			schedule: ScheduleSpec{Relative: stringPtr("10m")},
+			want:     []time.Time{now.Add(10 * time.Minute)},
+		},
+		{
+			name:     "every fires at once, then up to its count",
+			schedule: ScheduleSpec{Every: stringPtr("30s"), Count: 2},
+			want:     []time.Time{now, now.Add(30 * time.Second)},
+		},
+		{
+			name:     "every without count repeats",
+			schedule: ScheduleSpec{Every: stringPtr("30s")},
+			want:     []time.Time{now, now.Add(30 * time.Second), now.Add(time.Minute)},
+		},
+		{
+			name:     "zero interval fires once",
+			schedule: ScheduleSpec{Relative: stringPtr("0s")},
+			want:     []time.Time{now},
+		},
+		{
+			name:     "epoch fires once",
+			schedule: ScheduleSpec{Epoch: int64Ptr(2000000000)},
+			want:     []time.Time{time.Unix(2000000000, 0)},
+		},
+		{
+			name:     "template fires once",
+			schedule: ScheduleSpec{Template: stringPtr("1900000000")},
+			want:     []time.Time{time.Unix(1900000000, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := engine.NextRuns(now, tt.schedule, 3, NewTemplateEngine(nil))
+			if err != nil {
+				t.Fatalf("NextRuns() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("NextRuns() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("run %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := engine.NextRuns(now, ScheduleSpec{Cron: stringPtr("bad")}, 3, NewTemplateEngine(nil)); err == nil {
+		t.Error("Expected error for invalid cron expression")
+	}
+}
+
+func TestScheduleSpec_TypeAndExpression(t *testing.T) {
+	tests := []struct {
+		schedule ScheduleSpec
+		typ      string
+		expr     string
+	}{
+		{ScheduleSpec{Epoch: int64Ptr(42)}, "epoch", "42"},
+		{ScheduleSpec{Relative: stringPtr("5m")}, "relative", "5m"},
+		{ScheduleSpec{Template: stringPtr("{{ now | unix }}")}, "template", "{{ now | unix }}"},
+		{ScheduleSpec{Cron: stringPtr("@hourly")}, "cron", "@hourly"},
+		{ScheduleSpec{Every: stringPtr("30s"), Count: 10}, "every", "30s"},
+		{ScheduleSpec{}, "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.schedule.Type(); got != tt.typ {
+			t.Errorf("Type() = %q, want %q", got, tt.typ)
+		}
+		if got := tt.schedule.Expression(); got != tt.expr {
+			t.Errorf("Expression() = %q, want %q", got, tt.expr)
+		}
+	}
+}
+
+func TestScheduleSpec_Repeats(t *testing.T) {
+	tests := []struct {
+		schedule ScheduleSpec
+		want     bool
+	}{
+		{ScheduleSpec{Relative: stringPtr("5m")}, false},
+		{ScheduleSpec{Relative: stringPtr("5m"), Repeat: true}, true},
+		{ScheduleSpec{Relative: stringPtr("0s"), Repeat: true}, false},
+		{ScheduleSpec{Cron: stringPtr("@hourly")}, true},
+		{ScheduleSpec{Epoch: int64Ptr(42)}, false},
+		{ScheduleSpec{Template: stringPtr("{{ now | unix }}")}, false},
+		{ScheduleSpec{Every: stringPtr("30s")}, true},
+		{ScheduleSpec{Every: stringPtr("30s"), Count: 10}, true},
+		{ScheduleSpec{Every: stringPtr("30s"), Count: 1}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.schedule.Repeats(); got != tt.want {
+			t.Errorf("Repeats() of %s %s = %v, want %v", tt.schedule.Type(), tt.schedule.Expression(), got, tt.want)
+		}
+	}
+
+	bounded := ScheduleSpec{Every: stringPtr("30s"), Count: 10}
+	if bounded.Endless() {
+		t.Error("Expected an every schedule with a count to end")
+	}
+	if endless := (ScheduleSpec{Every: stringPtr("30s")}); !endless.Endless() {
+		t.Error("Expected an every schedule without a count to be endless")
+	}
+}
+
+func BenchmarkScheduleEngine_ComputeNextRun(b *testing.B) {
+	engine := NewScheduleEngine()
+	templateEngine := NewTemplateEngine(&EvaluationContext{
+		Variables: make(map[string]interface{}),
+		Clock:     &MockClock{now: time.Unix(1000, 0)},
+	})
+	now := time.Unix(1000, 0)
+	schedules := map[string]ScheduleSpec{
+		"epoch":    {Epoch: int64Ptr(2000)},
+		"relative": {Relative: stringPtr("5m")},
+		"cron":     {Cron: stringPtr("*/5 9-17 * * MON-FRI")},
+		"template": {Template: stringPtr("{{ now | addMinutes 5 | unix }}")},
+		"jitter":   {Relative: stringPtr("5m"), Jitter: stringPtr("30s")},
+	}
+	for name, schedule := range schedules {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := engine.ComputeNextRunWithTemplate(now, schedule, templateEngine); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
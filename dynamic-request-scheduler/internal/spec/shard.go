@@ -0,0 +1,67 @@
+package spec
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/url"
+)
+
+// Ways of spreading requests without a shard over the workers
+const (
+	// ShardByRequest deals requests out to the workers in turn
+	ShardByRequest = "request"
+	// ShardByHost keeps requests to the same host on the same worker
+	ShardByHost = "host"
+)
+
+// ValidateShardBy checks a --shard-by value
+func ValidateShardBy(by string) error {
+	switch by {
+	case ShardByRequest, ShardByHost:
+		return nil
+	default:
+		return fmt.Errorf("unknown shard-by %q (use %s or %s)", by, ShardByRequest, ShardByHost)
+	}
+}
+
+// WorkerShard names the worker group of the nth shared worker
+func WorkerShard(n int) string {
+	return fmt.Sprintf("worker-%d", n)
+}
+
+// AssignShards returns the worker group that evaluates each request's
+// schedule. A request's own shard wins; the rest are spread over the shared
+// workers, worker-0 to worker-(workers-1), by request or by host.
+func AssignShards(requests []ScheduledRequest, workers int, by string) []string {
+	if workers < 1 {
+		workers = 1
+	}
+
+	shards := make([]string, len(requests))
+	next := 0
+	for i := range requests {
+		req := &requests[i]
+		switch {
+		case req.Shard != "":
+			shards[i] = req.Shard
+		case by == ShardByHost:
+			h := fnv.New32a()
+			h.Write([]byte(req.host()))
+			shards[i] = WorkerShard(int(h.Sum32() % uint32(workers)))
+		default:
+			shards[i] = WorkerShard(next % workers)
+			next++
+		}
+	}
+	return shards
+}
+
+// host returns the host a request is sent to, or its whole target when that
+// is not a URL with a host
+func (r *ScheduledRequest) host() string {
+	_, target := r.Target()
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return target
+}
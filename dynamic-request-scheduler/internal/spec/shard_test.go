@@ -0,0 +1,45 @@
+package spec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAssignShards(t *testing.T) {
+	requests := []ScheduledRequest{
+		{Name: "a", HTTP: HttpRequestSpec{URL: NewLiteralString("http://orders:8080/a")}},
+		{Name: "b", HTTP: HttpRequestSpec{URL: NewLiteralString("http://orders:8080/b")}},
+		{Name: "critical", Shard: "critical", HTTP: HttpRequestSpec{URL: NewLiteralString("http://orders:8080/health")}},
+		{Name: "c", HTTP: HttpRequestSpec{URL: NewLiteralString("http://billing/c")}},
+		{Name: "pinned", Shard: "worker-1", HTTP: HttpRequestSpec{URL: NewLiteralString("http://billing/d")}},
+	}
+
+	got := AssignShards(requests, 2, ShardByRequest)
+	want := []string{"worker-0", "worker-1", "critical", "worker-0", "worker-1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("By request: expected %v, got %v", want, got)
+	}
+
+	got = AssignShards(requests, 4, ShardByHost)
+	if got[0] != got[1] {
+		t.Errorf("Expected requests to one host on the same worker, got %v", got)
+	}
+	if got[2] != "critical" || got[4] != "worker-1" {
+		t.Errorf("Expected a request's own shard to win, got %v", got)
+	}
+
+	if got := AssignShards(requests[:2], 0, ShardByRequest); got[0] != "worker-0" || got[1] != "worker-0" {
+		t.Errorf("Expected at least one worker, got %v", got)
+	}
+}
+
+func TestValidateShardBy(t *testing.T) {
+	for _, by := range []string{ShardByRequest, ShardByHost} {
+		if err := ValidateShardBy(by); err != nil {
+			t.Errorf("Expected %s to be valid: %v", by, err)
+		}
+	}
+	if err := ValidateShardBy("region"); err == nil {
+		t.Error("Expected an unknown shard-by to be rejected")
+	}
+}
@@ -0,0 +1,73 @@
+package spec
+
+import (
+	"fmt"
+	"time"
+)
+
+// SLOPercentiles are the latency percentiles an SLO may set a target for,
+// in the order they are reported
+var SLOPercentiles = []string{"p50", "p90", "p95", "p99"}
+
+// SLOSpec is a service level objective evaluated over a whole run, so a soak
+// run answers whether the request met its target
+type SLOSpec struct {
+	// Success is the minimum percentage of executions that must succeed
+	// (e.g. 99.9); an execution succeeds as it does for the exit code
+	Success *float64 `json:"success,omitempty" yaml:"success,omitempty"`
+
+	// Latency maps percentiles in SLOPercentiles to the duration they must
+	// stay under (e.g. p95: 200ms), over the executions that completed
+	Latency map[string]string `json:"latency,omitempty" yaml:"latency,omitempty"`
+}
+
+// LatencyTarget returns the duration a percentile must stay under, and false
+// when the SLO sets none or it is invalid
+func (s *SLOSpec) LatencyTarget(percentile string) (time.Duration, bool) {
+	value, ok := s.Latency[percentile]
+	if !ok {
+		return 0, false
+	}
+	target, err := time.ParseDuration(value)
+	return target, err == nil && target > 0
+}
+
+// Validate checks the objectives of an SLO
+func (s *SLOSpec) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if s.Success == nil && len(s.Latency) == 0 {
+		return &ValidationError{Field: "slo", Message: "must set success or latency"}
+	}
+	if s.Success != nil && (*s.Success <= 0 || *s.Success > 100) {
+		return &ValidationError{
+			Field:   "slo.success",
+			Message: fmt.Sprintf("must be a percentage above 0 and at most 100, got %v", *s.Success),
+		}
+	}
+	for _, percentile := range sortedKeys(s.Latency) {
+		if !isSLOPercentile(percentile) {
+			return &ValidationError{
+				Field:   "slo.latency",
+				Message: fmt.Sprintf("unknown percentile %q: use p50, p90, p95 or p99", percentile),
+			}
+		}
+		if _, ok := s.LatencyTarget(percentile); !ok {
+			return &ValidationError{
+				Field:   "slo.latency." + percentile,
+				Message: fmt.Sprintf("invalid duration: %s", s.Latency[percentile]),
+			}
+		}
+	}
+	return nil
+}
+
+func isSLOPercentile(percentile string) bool {
+	for _, p := range SLOPercentiles {
+		if p == percentile {
+			return true
+		}
+	}
+	return false
+}
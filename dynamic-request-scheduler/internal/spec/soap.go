@@ -0,0 +1,149 @@
+package spec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SOAP versions accepted by SOAPSpec.Version
+const (
+	SOAP11 = "1.1"
+	SOAP12 = "1.2"
+)
+
+// SOAPSpec POSTs an XML envelope to a SOAP endpoint, setting the content type
+// and action the way the SOAP version expects. The envelope is a template,
+// given inline or loaded from a file.
+type SOAPSpec struct {
+	URL     string            `json:"url" yaml:"url"`
+	Action  string            `json:"action,omitempty" yaml:"action,omitempty"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// Version is "1.1" (default) or "1.2"
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+
+	// Envelope is the XML envelope; EnvelopeFile loads it from a file
+	// relative to the config file instead
+	Envelope     string `json:"envelope,omitempty" yaml:"envelope,omitempty"`
+	EnvelopeFile string `json:"envelope_file,omitempty" yaml:"envelope_file,omitempty"`
+
+	// fileEnvelope holds the contents of EnvelopeFile once loaded
+	fileEnvelope string
+}
+
+// SOAPOptions are the settings of a resolved SOAP request, whose envelope is
+// the resolved request's Body
+type SOAPOptions struct {
+	Version string
+}
+
+// Validate validates a SOAP request specification
+func (s *SOAPSpec) Validate() error {
+	if s.URL == "" {
+		return &ValidationError{
+			Field:   "soap.url",
+			Message: "URL is required",
+		}
+	}
+
+	if (s.Envelope == "") == (s.EnvelopeFile == "") {
+		return &ValidationError{
+			Field:   "soap.envelope",
+			Message: "exactly one of envelope or envelope_file is required",
+		}
+	}
+
+	if s.Version != "" && s.Version != SOAP11 && s.Version != SOAP12 {
+		return &ValidationError{
+			Field:   "soap.version",
+			Message: fmt.Sprintf("unsupported SOAP version %q (use 1.1 or 1.2)", s.Version),
+		}
+	}
+
+	return nil
+}
+
+// loadEnvelope reads EnvelopeFile, resolving a relative path against dir
+func (s *SOAPSpec) loadEnvelope(dir string) error {
+	if s.EnvelopeFile == "" {
+		return nil
+	}
+	path := s.EnvelopeFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return &ValidationError{
+			Field:   "soap.envelope_file",
+			Message: fmt.Sprintf("failed to read envelope: %v", err),
+		}
+	}
+	s.fileEnvelope = string(data)
+	return nil
+}
+
+// version returns the SOAP version, defaulting to 1.1
+func (s *SOAPSpec) version() string {
+	if s.Version == "" {
+		return SOAP11
+	}
+	return s.Version
+}
+
+// resolveSOAP renders the envelope and returns it with the headers the SOAP
+// version requires added to headers
+func (e *Evaluator) resolveSOAP(s *SOAPSpec, headers map[string]string) (string, error) {
+	envelope := s.Envelope
+	if s.EnvelopeFile != "" {
+		if s.fileEnvelope == "" {
+			// Not loaded through LoadConfigFile, so relative to the working directory
+			if err := s.loadEnvelope(""); err != nil {
+				return "", err
+			}
+		}
+		envelope = s.fileEnvelope
+	}
+
+	envelope, err := e.resolveString(envelope)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve SOAP envelope template: %w", err)
+	}
+	action, err := e.resolveString(s.Action)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve SOAP action template: %w", err)
+	}
+
+	// Explicit headers win, like the default Content-Type of HTTP requests
+	hasContentType := hasHeader(headers, "Content-Type")
+	switch s.version() {
+	case SOAP12:
+		if !hasContentType {
+			contentType := "application/soap+xml; charset=utf-8"
+			if action != "" {
+				contentType += fmt.Sprintf("; action=%q", action)
+			}
+			headers["Content-Type"] = contentType
+		}
+	default:
+		if !hasContentType {
+			headers["Content-Type"] = "text/xml; charset=utf-8"
+		}
+		if !hasHeader(headers, "SOAPAction") {
+			headers["SOAPAction"] = fmt.Sprintf("%q", action)
+		}
+	}
+	return envelope, nil
+}
+
+// hasHeader reports whether headers contain name, compared case-insensitively
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
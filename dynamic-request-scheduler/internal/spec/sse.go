@@ -0,0 +1,78 @@
+package spec
+
+import (
+	"fmt"
+	"time"
+)
+
+// SSESpec subscribes to a Server-Sent Events endpoint for a bounded duration
+// and counts the events received
+type SSESpec struct {
+	URL     string            `json:"url" yaml:"url"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// Duration is how long to stay subscribed (e.g. "10s")
+	Duration string `json:"duration" yaml:"duration"`
+
+	// Event only counts events of this type; empty counts every event
+	Event string `json:"event,omitempty" yaml:"event,omitempty"`
+
+	// MinEvents fails the execution when fewer matching events arrive
+	MinEvents int `json:"min_events,omitempty" yaml:"min_events,omitempty"`
+
+	// MaxEvents ends the subscription early once this many matching events
+	// have arrived; zero waits for the full duration
+	MaxEvents int `json:"max_events,omitempty" yaml:"max_events,omitempty"`
+}
+
+// SSEOptions are the subscription settings of a resolved SSE request
+type SSEOptions struct {
+	Duration  time.Duration
+	Event     string
+	MinEvents int
+	MaxEvents int
+}
+
+// Validate validates an SSE subscription specification
+func (s *SSESpec) Validate() error {
+	if s.URL == "" {
+		return &ValidationError{
+			Field:   "sse.url",
+			Message: "SSE URL is required",
+		}
+	}
+
+	if d, err := time.ParseDuration(s.Duration); err != nil || d <= 0 {
+		return &ValidationError{
+			Field:   "sse.duration",
+			Message: fmt.Sprintf("invalid duration: %s", s.Duration),
+		}
+	}
+
+	if s.MinEvents < 0 || s.MaxEvents < 0 {
+		return &ValidationError{
+			Field:   "sse",
+			Message: "min_events and max_events must be non-negative",
+		}
+	}
+
+	if s.MaxEvents > 0 && s.MinEvents > s.MaxEvents {
+		return &ValidationError{
+			Field:   "sse.min_events",
+			Message: "min_events cannot exceed max_events",
+		}
+	}
+
+	return nil
+}
+
+// options converts the subscription settings, which Validate has checked
+func (s *SSESpec) options() *SSEOptions {
+	duration, _ := time.ParseDuration(s.Duration)
+	return &SSEOptions{
+		Duration:  duration,
+		Event:     s.Event,
+		MinEvents: s.MinEvents,
+		MaxEvents: s.MaxEvents,
+	}
+}
@@ -3,8 +3,10 @@ package spec
 import (
 	"crypto/rand"
 	"fmt"
+	"hash/fnv"
 	"math"
 	mrand "math/rand"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
@@ -16,6 +18,9 @@ import (
 type TemplateEngine struct {
 	funcMap template.FuncMap
 	ctx     *EvaluationContext
+
+	// fallbackRand serves the fake data functions when no seed is set
+	fallbackRand *mrand.Rand
 }
 
 // EvaluationContext holds variables and state for template evaluation
@@ -25,6 +30,30 @@ type EvaluationContext struct {
 	Seed      int64
 	Clock     Clock
 	randSource *mrand.Rand
+
+	// State is the persisted state of the request being evaluated, read by
+	// the state function
+	State map[string]interface{}
+
+	// Responses supplies other requests' responses to the lastResponse
+	// function; nil when there are none to read
+	Responses ResponseSource
+}
+
+// Response is a request's response as templates read it
+type Response struct {
+	StatusCode int
+	Headers    http.Header
+	Body       []byte
+
+	// Duration is how long the response took to arrive
+	Duration time.Duration
+}
+
+// ResponseSource supplies the most recent successful response of each
+// request
+type ResponseSource interface {
+	LastResponse(request string) (Response, bool)
 }
 
 // Clock interface for time operations (allows injection for testing)
@@ -37,6 +66,37 @@ type RealClock struct{}
 
 func (r *RealClock) Now() time.Time { return time.Now().UTC() }
 
+// FixedClock implements Clock by always returning the same instant, used to
+// preview schedules and templates as of a chosen time
+type FixedClock struct {
+	Time time.Time
+}
+
+func (f *FixedClock) Now() time.Time { return f.Time.UTC() }
+
+// ScaledClock implements Clock as a virtual clock that starts at an origin
+// and advances Scale times faster than real time
+type ScaledClock struct {
+	Origin  time.Time
+	Scale   float64
+	started time.Time
+}
+
+// NewScaledClock creates a virtual clock starting now at origin
+func NewScaledClock(origin time.Time, scale float64) *ScaledClock {
+	return &ScaledClock{Origin: origin, Scale: scale, started: time.Now()}
+}
+
+func (c *ScaledClock) Now() time.Time {
+	elapsed := time.Duration(float64(time.Since(c.started)) * c.Scale)
+	return c.Origin.Add(elapsed).UTC()
+}
+
+// RealDuration converts a virtual duration into the real time it takes to pass
+func (c *ScaledClock) RealDuration(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / c.Scale)
+}
+
 // NewTemplateEngine creates a new template engine with the standard function map
 func NewTemplateEngine(ctx *EvaluationContext) *TemplateEngine {
 	if ctx == nil {
@@ -65,9 +125,31 @@ func NewTemplateEngine(ctx *EvaluationContext) *TemplateEngine {
 		"randInt":   engine.randInt,
 		"randFloat": engine.randFloat,
 
+		// Fake data functions
+		"pick":        engine.pick,
+		"firstName":   engine.firstName,
+		"lastName":    engine.lastName,
+		"fullName":    engine.fullName,
+		"username":    engine.username,
+		"email":       engine.email,
+		"company":     engine.company,
+		"street":      engine.street,
+		"city":        engine.city,
+		"country":     engine.country,
+		"phone":       engine.phone,
+		"word":        engine.word,
+		"sentence":    engine.sentence,
+		"randBool":    engine.randBool,
+		"randTime":    engine.randTime,
+		"randDecimal": engine.randDecimal,
+
 		// Environment and variables
-		"env": engine.env,
-		"var": engine.getVar,
+		"env":   engine.env,
+		"var":   engine.getVar,
+		"state": engine.getState,
+
+		// Other requests
+		"lastResponse": engine.lastResponse,
 
 		// Sequence and iteration
 		"seq": engine.seq,
@@ -84,13 +166,19 @@ func NewTemplateEngine(ctx *EvaluationContext) *TemplateEngine {
 
 // EvaluateTemplate evaluates a template string and returns the result
 func (e *TemplateEngine) EvaluateTemplate(tmpl string) (string, error) {
+	return e.EvaluateTemplateWithData(tmpl, e.ctx)
+}
+
+// EvaluateTemplateWithData evaluates a template string against custom data
+// while keeping the standard function map available
+func (e *TemplateEngine) EvaluateTemplateWithData(tmpl string, data interface{}) (string, error) {
 	t, err := template.New("dynamic").Funcs(e.funcMap).Parse(tmpl)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
 
 	var result strings.Builder
-	err = t.Execute(&result, e.ctx)
+	err = t.Execute(&result, data)
 	if err != nil {
 		return "", fmt.Errorf("failed to execute template: %w", err)
 	}
@@ -145,9 +233,15 @@ func (e *TemplateEngine) parseTime(layout, value string) (time.Time, error) {
 
 // ID and random functions
 func (e *TemplateEngine) uuid() string {
-	// Generate a simple UUID v4
+	// Generate a simple UUID v4, from the seeded source when seeded so
+	// replayed runs send the same IDs
 	b := make([]byte, 16)
-	_, err := rand.Read(b)
+	var err error
+	if e.ctx.Seed != 0 {
+		_, err = e.random().Read(b)
+	} else {
+		_, err = rand.Read(b)
+	}
 	if err != nil {
 		// Fallback to timestamp-based ID if crypto/rand fails
 		return fmt.Sprintf("%d-%d", time.Now().UnixNano(), e.ctx.Sequence)
@@ -201,6 +295,27 @@ func (e *TemplateEngine) getVar(key string) interface{} {
 	return ""
 }
 
+func (e *TemplateEngine) getState(key string) interface{} {
+	if val, exists := e.ctx.State[key]; exists {
+		return val
+	}
+	return ""
+}
+
+// lastResponse reads a value from the most recent successful response of
+// another request; source is a capture source such as "$.id"
+func (e *TemplateEngine) lastResponse(request, source string) (interface{}, error) {
+	var response Response
+	ok := false
+	if e.ctx.Responses != nil {
+		response, ok = e.ctx.Responses.LastResponse(request)
+	}
+	if !ok {
+		return nil, fmt.Errorf("no response from request '%s' yet", request)
+	}
+	return CaptureValue(source, response.StatusCode, response.Headers, response.Body)
+}
+
 // Sequence and iteration
 func (e *TemplateEngine) seq() int64 {
 	e.ctx.Sequence++
@@ -233,6 +348,77 @@ func (e *TemplateEngine) SetSeed(seed int64) {
 	e.ctx.randSource = nil // Reset random source so new one is created with new seed
 }
 
+// ForExecution returns an engine for the n-th execution of a run. It shares
+// the variables, clock and responses, starts seq at n, and when seeded draws random
+// values from a seed derived from this engine's seed and n, so they don't
+// depend on the order concurrent executions are evaluated in.
+func (e *TemplateEngine) ForExecution(n int64) *TemplateEngine {
+	return NewTemplateEngine(&EvaluationContext{
+		Variables: e.ctx.Variables,
+		Sequence:  n - 1,
+		Seed:      executionSeed(e.ctx.Seed, n),
+		Clock:     e.ctx.Clock,
+		Responses: e.ctx.Responses,
+	})
+}
+
+// ForRequest returns an engine for the n-th execution of a run, which is the
+// given firing of the named request. It is like ForExecution, except that
+// random values are drawn from a seed derived from this engine's seed, the
+// request name and the firing, so each request has its own reproducible
+// stream of values however its executions interleave with other requests'.
+func (e *TemplateEngine) ForRequest(n int64, request string, firing int64) *TemplateEngine {
+	engine := e.ForExecution(n)
+	engine.ctx.Seed = requestSeed(e.ctx.Seed, request, firing)
+	return engine
+}
+
+// WithState returns an engine like this one whose state function reads the
+// given request state
+func (e *TemplateEngine) WithState(state map[string]interface{}) *TemplateEngine {
+	ctx := *e.ctx
+	ctx.State = state
+	ctx.randSource = nil
+	return NewTemplateEngine(&ctx)
+}
+
+// WithVariables returns an engine like this one whose templates read the
+// given run variables
+func (e *TemplateEngine) WithVariables(variables map[string]interface{}) *TemplateEngine {
+	ctx := *e.ctx
+	ctx.Variables = variables
+	ctx.randSource = nil
+	return NewTemplateEngine(&ctx)
+}
+
+// executionSeed derives the seed of the n-th execution from a run's seed,
+// returning 0 (unseeded) for an unseeded run
+func executionSeed(seed, n int64) int64 {
+	if seed == 0 {
+		return 0
+	}
+	// splitmix64 spreads neighbouring execution numbers over unrelated seeds
+	z := uint64(seed) + uint64(n)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	if z == 0 {
+		return 1
+	}
+	return int64(z)
+}
+
+// requestSeed derives the seed of a request's firing from a run's seed,
+// returning 0 (unseeded) for an unseeded run
+func requestSeed(seed int64, request string, firing int64) int64 {
+	if seed == 0 {
+		return 0
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(request))
+	return executionSeed(executionSeed(seed, int64(hash.Sum64())), firing)
+}
+
 // GetContext returns the evaluation context
 func (e *TemplateEngine) GetContext() *EvaluationContext {
 	return e.ctx
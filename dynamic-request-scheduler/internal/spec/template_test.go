@@ -1,6 +1,7 @@
 package spec
 
 import (
+	"net/http"
 	"strings"
 	"testing"
 	"time"
@@ -13,6 +14,22 @@ type MockClock struct {
 
 func (m *MockClock) Now() time.Time { return m.now }
 
+func TestScaledClock(t *testing.T) {
+	origin := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := NewScaledClock(origin, 3600)
+
+	time.Sleep(20 * time.Millisecond)
+	elapsed := clock.Now().Sub(origin)
+	// 20ms of real time is at least 72s of virtual time
+	if elapsed < 72*time.Second || elapsed > time.Hour {
+		t.Errorf("Expected virtual time to advance 3600x, got %v", elapsed)
+	}
+
+	if got := clock.RealDuration(2 * time.Hour); got != 2*time.Second {
+		t.Errorf("Expected 2h virtual to take 2s, got %v", got)
+	}
+}
+
 func TestNewTemplateEngine(t *testing.T) {
 	ctx := &EvaluationContext{
 		Variables: map[string]interface{}{"test": "value"},
@@ -400,3 +417,137 @@ func TestTemplateEngine_SetSeed(t *testing.T) {
 		t.Errorf("Deterministic behavior failed with same seed")
 	}
 }
+
+func TestTemplateEngine_WithState(t *testing.T) {
+	engine := NewTemplateEngine(nil)
+	engine.SetVariable("host", "localhost")
+
+	stateful := engine.WithState(map[string]interface{}{"cursor": "abc"})
+	got, err := stateful.EvaluateTemplate(`{{ var "host" }}?cursor={{ state "cursor" }}&missing={{ state "page" }}`)
+	if err != nil || got != "localhost?cursor=abc&missing=" {
+		t.Errorf("Expected state and variables, got %q (%v)", got, err)
+	}
+
+	if got, _ := engine.EvaluateTemplate(`{{ state "cursor" }}`); got != "" {
+		t.Errorf("Expected the original engine to have no state, got %q", got)
+	}
+}
+
+// staticResponses is a ResponseSource with fixed responses
+type staticResponses map[string]Response
+
+func (r staticResponses) LastResponse(request string) (Response, bool) {
+	response, ok := r[request]
+	return response, ok
+}
+
+func TestTemplateEngine_LastResponse(t *testing.T) {
+	engine := NewTemplateEngine(&EvaluationContext{
+		Clock: &RealClock{},
+		Responses: staticResponses{
+			"create-order": {StatusCode: 201, Headers: http.Header{"Location": []string{"/orders/7"}}, Body: []byte(`{"id": 7}`)},
+		},
+	})
+
+	got, err := engine.ForExecution(1).EvaluateTemplate(`{{ lastResponse "create-order" "$.id" }} {{ lastResponse "create-order" "header:Location" }} {{ lastResponse "create-order" "status" }}`)
+	if err != nil || got != "7 /orders/7 201" {
+		t.Errorf("Expected values from the last response, got %q (%v)", got, err)
+	}
+
+	if _, err := engine.EvaluateTemplate(`{{ lastResponse "cancel-order" "$.id" }}`); err == nil || !strings.Contains(err.Error(), "no response from request 'cancel-order' yet") {
+		t.Errorf("Expected an error before a request has responded, got %v", err)
+	}
+	if _, err := NewTemplateEngine(nil).EvaluateTemplate(`{{ lastResponse "create-order" "$.id" }}`); err == nil {
+		t.Error("Expected an error without a response source")
+	}
+}
+
+func TestTemplateEngine_ForExecution(t *testing.T) {
+	engine := NewTemplateEngine(&EvaluationContext{
+		Variables: map[string]interface{}{"tenant": "acme"},
+		Seed:      42,
+		Clock:     &MockClock{now: time.Unix(1000, 0)},
+	})
+	tmpl := "{{ seq }} {{ .Variables.tenant }} {{ uuid }} {{ randInt 1 1000000 }}"
+
+	first, err := engine.ForExecution(3).EvaluateTemplate(tmpl)
+	if err != nil {
+		t.Fatalf("EvaluateTemplate failed: %v", err)
+	}
+	if !strings.HasPrefix(first, "3 acme ") {
+		t.Errorf("Expected seq to start at the execution number, got %q", first)
+	}
+
+	// The same execution of a run with the same seed evaluates identically,
+	// whatever was evaluated before it
+	engine.ForExecution(4).EvaluateTemplate(tmpl)
+	again, _ := engine.ForExecution(3).EvaluateTemplate(tmpl)
+	if again != first {
+		t.Errorf("Expected execution 3 to repeat %q, got %q", first, again)
+	}
+
+	other, _ := engine.ForExecution(4).EvaluateTemplate(tmpl)
+	if other[2:] == first[2:] {
+		t.Errorf("Expected executions 3 and 4 to draw different values, both got %q", first[2:])
+	}
+
+	unseeded := NewTemplateEngine(nil).ForExecution(5)
+	if unseeded.GetContext().Seed != 0 {
+		t.Errorf("Expected executions of an unseeded engine to stay unseeded, got seed %d", unseeded.GetContext().Seed)
+	}
+}
+
+func TestTemplateEngine_ForRequest(t *testing.T) {
+	engine := NewTemplateEngine(&EvaluationContext{Seed: 42, Clock: &MockClock{now: time.Unix(1000, 0)}})
+	tmpl := "{{ uuid }} {{ randInt 1 1000000 }}"
+	evaluate := func(n int64, request string, firing int64) string {
+		got, err := engine.ForRequest(n, request, firing).EvaluateTemplate(tmpl)
+		if err != nil {
+			t.Fatalf("EvaluateTemplate failed: %v", err)
+		}
+		return got
+	}
+
+	// A request's firing draws the same values wherever it falls in the run
+	first := evaluate(3, "orders", 2)
+	if again := evaluate(7, "orders", 2); again != first {
+		t.Errorf("Expected firing 2 of orders to repeat %q, got %q", first, again)
+	}
+	if other := evaluate(3, "orders", 3); other == first {
+		t.Errorf("Expected firings 2 and 3 to draw different values, both got %q", first)
+	}
+	if other := evaluate(3, "payments", 2); other == first {
+		t.Errorf("Expected orders and payments to draw different values, both got %q", first)
+	}
+
+	if seq, _ := engine.ForRequest(5, "orders", 1).EvaluateTemplate("{{ seq }}"); seq != "5" {
+		t.Errorf("Expected seq to start at the execution number, got %q", seq)
+	}
+
+	unseeded := NewTemplateEngine(nil).ForRequest(5, "orders", 1)
+	if unseeded.GetContext().Seed != 0 {
+		t.Errorf("Expected firings of an unseeded engine to stay unseeded, got seed %d", unseeded.GetContext().Seed)
+	}
+}
+
+func BenchmarkTemplateEngine_EvaluateTemplate(b *testing.B) {
+	engine := NewTemplateEngine(&EvaluationContext{
+		Variables: map[string]interface{}{"tenant": "acme"},
+		Clock:     &MockClock{now: time.Unix(1000, 0)},
+	})
+	templates := map[string]string{
+		"plain":     "http://localhost:8080/health",
+		"variable":  "http://localhost:8080/{{ .Variables.tenant }}/orders",
+		"functions": "{{ now | addMinutes 5 | rfc3339 }}-{{ uuid }}-{{ randInt 1 100 }}",
+	}
+	for name, tmpl := range templates {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := engine.EvaluateTemplate(tmpl); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -1,22 +1,285 @@
 package spec
 
 import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
 // ScheduledRequest represents a request that will be scheduled and executed
 type ScheduledRequest struct {
-	Name     string          `json:"name" yaml:"name"`
+	Name string `json:"name" yaml:"name"`
+
+	// ID identifies the request in saved state and recorded results. It
+	// defaults to one derived from the name; setting it keeps them attached
+	// to the request when it is renamed. See RequestID.
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+
+	Tags     []string        `json:"tags,omitempty" yaml:"tags,omitempty"`
 	Schedule ScheduleSpec    `json:"schedule" yaml:"schedule"`
-	HTTP     HttpRequestSpec `json:"http" yaml:"http"`
+	HTTP     HttpRequestSpec `json:"http,omitempty" yaml:"http,omitempty"`
+
+	// SSE replaces the HTTP request with a Server-Sent Events subscription
+	SSE *SSESpec `json:"sse,omitempty" yaml:"sse,omitempty"`
+
+	// Kafka replaces the HTTP request with a record produced to a topic
+	Kafka *KafkaSpec `json:"kafka,omitempty" yaml:"kafka,omitempty"`
+
+	// AMQP replaces the HTTP request with a message published to a broker
+	AMQP *AMQPSpec `json:"amqp,omitempty" yaml:"amqp,omitempty"`
+
+	// Redis replaces the HTTP request with a single Redis command
+	Redis *RedisSpec `json:"redis,omitempty" yaml:"redis,omitempty"`
+
+	// NATS replaces the HTTP request with a message published to a subject
+	NATS *NATSSpec `json:"nats,omitempty" yaml:"nats,omitempty"`
+
+	// SQS replaces the HTTP request with a message sent to a queue
+	SQS *SQSSpec `json:"sqs,omitempty" yaml:"sqs,omitempty"`
+
+	// SNS replaces the HTTP request with a message published to a topic
+	SNS *SNSSpec `json:"sns,omitempty" yaml:"sns,omitempty"`
+
+	// SOAP replaces the HTTP request with an XML envelope POSTed to a SOAP
+	// endpoint
+	SOAP *SOAPSpec `json:"soap,omitempty" yaml:"soap,omitempty"`
+
+	// JSONRPC replaces the HTTP request with a JSON-RPC 2.0 call
+	JSONRPC *JSONRPCSpec `json:"jsonrpc,omitempty" yaml:"jsonrpc,omitempty"`
+
+	// GRPC replaces the HTTP request with a unary gRPC call
+	GRPC *GRPCSpec `json:"grpc,omitempty" yaml:"grpc,omitempty"`
+
+	// Plugin replaces the HTTP request with a custom type executed by a
+	// plugin from the config's plugins section
+	Plugin *PluginRequestSpec `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+
+	// Diff configures comparison against a stored baseline response
+	Diff *DiffSpec `json:"diff,omitempty" yaml:"diff,omitempty"`
+
+	// Expect lists what a response must look like, beyond a 2xx status, for
+	// an execution to succeed, or, in warn blocks, to pass without a warning
+	Expect Expectations `json:"expect,omitempty" yaml:"expect,omitempty"`
+
+	// SlowThreshold is the duration (e.g. "500ms") above which an execution
+	// is reported as slow, overriding the global --slow threshold
+	SlowThreshold string `json:"slow_threshold,omitempty" yaml:"slow_threshold,omitempty"`
+
+	// SLO is the success rate and latency the request must meet over the
+	// run, reported after the summary
+	SLO *SLOSpec `json:"slo,omitempty" yaml:"slo,omitempty"`
+
+	// Timeout bounds each execution, from evaluation until the response is
+	// read (e.g. "5s"), overriding the global --timeout
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+
+	// KeepWarm marks a request that keeps an endpoint warm. Rather than
+	// every execution, only changes of the endpoint's state are logged:
+	// down when an execution fails, cold when it succeeds slower than the
+	// slow threshold, and warm otherwise.
+	KeepWarm bool `json:"keep_warm,omitempty" yaml:"keep_warm,omitempty"`
+
+	// Retry sends an execution again, after a backoff, when it fails with an
+	// error or a retryable status; Timeout bounds all of its attempts
+	Retry *RetrySpec `json:"retry,omitempty" yaml:"retry,omitempty"`
+
+	// FanOut is how many copies of the request each firing dispatches at
+	// once, such as 3 or "{{ randInt 1 5 }}" for bursts whose size varies;
+	// templates are evaluated afresh on every firing. Empty means 1.
+	FanOut string `json:"fan_out,omitempty" yaml:"fan_out,omitempty"`
+
+	// Shard pins the request to a worker group that evaluates its schedule,
+	// so noisy requests elsewhere cannot delay it. worker-N pins it to one
+	// of the --workers workers; any other name gets a dedicated worker shared
+	// with the requests that name the same shard.
+	Shard string `json:"shard,omitempty" yaml:"shard,omitempty"`
+
+	// Capture stores values from a setup request's response in variables
+	// that later requests read with {{ var "name" }}. Each entry maps a
+	// variable name to a source; see CaptureValue.
+	Capture map[string]string `json:"capture,omitempty" yaml:"capture,omitempty"`
+
+	// State keeps values from each successful response, such as a
+	// pagination cursor, for the request's next firing to read with
+	// {{ state "key" }}. Each entry maps a key to a source, as Capture does;
+	// the state is saved to --history so it survives restarts.
+	State map[string]string `json:"state,omitempty" yaml:"state,omitempty"`
+}
+
+// Request types returned by ScheduledRequest.Type
+const (
+	TypeHTTP    = "http"
+	TypeSSE     = "sse"
+	TypeKafka   = "kafka"
+	TypeAMQP    = "amqp"
+	TypeRedis   = "redis"
+	TypeNATS    = "nats"
+	TypeSQS     = "sqs"
+	TypeSNS     = "sns"
+	TypeSOAP    = "soap"
+	TypeJSONRPC = "jsonrpc"
+	TypeGRPC    = "grpc"
+)
+
+// Type returns the kind of request to execute; plain HTTP unless another
+// request type section is set, and the plugin's type for plugin requests
+func (r *ScheduledRequest) Type() string {
+	switch {
+	case r.SSE != nil:
+		return TypeSSE
+	case r.Kafka != nil:
+		return TypeKafka
+	case r.AMQP != nil:
+		return TypeAMQP
+	case r.Redis != nil:
+		return TypeRedis
+	case r.NATS != nil:
+		return TypeNATS
+	case r.SQS != nil:
+		return TypeSQS
+	case r.SNS != nil:
+		return TypeSNS
+	case r.SOAP != nil:
+		return TypeSOAP
+	case r.JSONRPC != nil:
+		return TypeJSONRPC
+	case r.GRPC != nil:
+		return TypeGRPC
+	case r.Plugin != nil:
+		return r.Plugin.Type
+	default:
+		return TypeHTTP
+	}
+}
+
+// Target returns the unresolved method and URL shown when listing the request
+func (r *ScheduledRequest) Target() (method, url string) {
+	if r.Plugin != nil {
+		return strings.ToUpper(r.Plugin.Type), r.Plugin.Target
+	}
+	switch r.Type() {
+	case TypeSSE:
+		return "SSE", r.SSE.URL
+	case TypeKafka:
+		return "KAFKA", (&KafkaTarget{Brokers: r.Kafka.Brokers, Topic: r.Kafka.Topic}).URL()
+	case TypeAMQP:
+		target := AMQPTarget{URL: r.AMQP.URL}
+		if target.URL == "" {
+			target.URL = defaultAMQPURL
+		}
+		return "AMQP", target.DisplayURL()
+	case TypeRedis:
+		target := RedisTarget{URL: r.Redis.URL}
+		if target.URL == "" {
+			target.URL = defaultRedisURL
+		}
+		return "REDIS", target.DisplayURL()
+	case TypeNATS:
+		target := NATSTarget{URL: r.NATS.URL}
+		if target.URL == "" {
+			target.URL = defaultNATSURL
+		}
+		return "NATS", target.DisplayURL()
+	case TypeSQS:
+		return "SQS", r.SQS.QueueURL
+	case TypeSNS:
+		return "SNS", r.SNS.TopicARN
+	case TypeSOAP:
+		return "SOAP", r.SOAP.URL
+	case TypeJSONRPC:
+		return "JSONRPC", r.JSONRPC.URL
+	case TypeGRPC:
+		return "GRPC", (&GRPCTarget{Address: r.GRPC.Address, TLS: r.GRPC.TLS, Method: r.GRPC.Method}).URL()
+	default:
+		return r.HTTP.Method, r.HTTP.URL.Raw()
+	}
+}
+
+// withoutPassword removes the password from a broker URL so it can be shown
+// in logs and stored with results
+func withoutPassword(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	switch _, ok := u.User.Password(); {
+	case ok && u.User.Username() == "":
+		u.User = nil
+	case ok:
+		u.User = url.User(u.User.Username())
+	}
+	return u.String()
+}
+
+// withoutCredentials is withoutPassword for URLs where a user name without a
+// password is itself a secret, such as redis://password@host or
+// nats://token@host
+func withoutCredentials(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); !ok {
+			u.User = nil
+			return u.String()
+		}
+	}
+	return withoutPassword(rawURL)
+}
+
+// SlowThresholdDuration parses SlowThreshold, returning 0 when it is unset
+func (r *ScheduledRequest) SlowThresholdDuration() (time.Duration, error) {
+	if r.SlowThreshold == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(r.SlowThreshold)
+}
+
+// TimeoutDuration parses Timeout, returning 0 when it is unset
+func (r *ScheduledRequest) TimeoutDuration() (time.Duration, error) {
+	if r.Timeout == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(r.Timeout)
+}
+
+// DiffSpec configures how responses are compared against their baseline
+type DiffSpec struct {
+	// Ignore lists JSON paths excluded from comparison (e.g. "meta.timestamp", "items[*].id")
+	Ignore []string `json:"ignore,omitempty" yaml:"ignore,omitempty"`
 }
 
 // HttpRequestSpec defines the HTTP request to be made
 type HttpRequestSpec struct {
-	Method  string            `json:"method" yaml:"method"`
-	URL     string            `json:"url" yaml:"url"`
-	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
-	Body    interface{}       `json:"body,omitempty" yaml:"body,omitempty"`
+	Method string `json:"method" yaml:"method"`
+
+	// URL, Headers and Body are literals, which may contain inline {{ }}
+	// templates, or {template: "..."} objects evaluated with each execution
+	URL     DynamicString            `json:"url" yaml:"url"`
+	Headers map[string]DynamicString `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    DynamicAny               `json:"body,omitempty" yaml:"body,omitempty"`
+
+	// AllowCustomMethods accepts methods other than the standard ones, such
+	// as PROPFIND for WebDAV servers. Method may also be a template, whose
+	// result is checked when the request is evaluated.
+	AllowCustomMethods bool `json:"allow_custom_methods,omitempty" yaml:"allow_custom_methods,omitempty"`
+
+	// BodyFile streams a file, relative to the config file, as the body
+	// instead of Body. It is sent as-is rather than templated, so large
+	// payloads are never held in memory.
+	BodyFile string `json:"body_file,omitempty" yaml:"body_file,omitempty"`
+
+	// Extract sets run variables from each successful response, for later
+	// requests to read with {{ .Variables.name }} or {{ var "name" }}, such
+	// as a token from a login. Each entry maps a variable name to a source,
+	// as Capture does.
+	Extract map[string]string `json:"extract,omitempty" yaml:"extract,omitempty"`
+}
+
+// resolveBodyFile makes a relative BodyFile absolute against dir
+func (h *HttpRequestSpec) resolveBodyFile(dir string) {
+	if h.BodyFile != "" && !filepath.IsAbs(h.BodyFile) {
+		h.BodyFile = filepath.Join(dir, h.BodyFile)
+	}
 }
 
 // ScheduleSpec defines when the request should be executed
@@ -25,15 +288,28 @@ type ScheduleSpec struct {
 	// Epoch represents a specific Unix timestamp
 	Epoch *int64 `json:"epoch,omitempty" yaml:"epoch,omitempty"`
 
-	// Relative represents a duration from now (e.g., "5m", "1h")
+	// Relative represents a duration from when the scheduler starts (e.g.,
+	// "5m", "1h"): the request fires once, that long after the start
 	Relative *string `json:"relative,omitempty" yaml:"relative,omitempty"`
 
+	// Repeat fires a relative schedule again one interval after each
+	// dispatch, rather than once
+	Repeat bool `json:"repeat,omitempty" yaml:"repeat,omitempty"`
+
 	// Template represents a Go template that evaluates to a Unix timestamp
 	Template *string `json:"template,omitempty" yaml:"template,omitempty"`
 
 	// Cron represents a cron expression (e.g., "*/5 * * * *")
 	Cron *string `json:"cron,omitempty" yaml:"cron,omitempty"`
 
+	// Every fires the request when the scheduler starts and then on a fixed
+	// interval (e.g., "30s"), skipping ticks missed while a run overran
+	Every *string `json:"every,omitempty" yaml:"every,omitempty"`
+
+	// Count bounds how many times an every schedule fires; zero fires it
+	// until the scheduler stops
+	Count int `json:"count,omitempty" yaml:"count,omitempty"`
+
 	// Jitter adds random variation to the scheduled time (e.g., "±30s")
 	Jitter *string `json:"jitter,omitempty" yaml:"jitter,omitempty"`
 }
@@ -53,17 +329,120 @@ func (s *ScheduleSpec) Validate() error {
 	if s.Cron != nil {
 		count++
 	}
+	if s.Every != nil {
+		count++
+	}
 
 	if count != 1 {
 		return &ValidationError{
 			Field:   "schedule",
-			Message: "exactly one schedule strategy must be specified (epoch, relative, template, or cron)",
+			Message: "exactly one schedule strategy must be specified (epoch, relative, template, cron, or every)",
+		}
+	}
+
+	// Catch values the scheduler would otherwise only reject when it runs
+	if err := NewScheduleEngine().ValidateSchedule(*s); err != nil {
+		return &ValidationError{
+			Field:   "schedule",
+			Message: err.Error(),
 		}
 	}
 
 	return nil
 }
 
+// Repeats reports whether a schedule fires more than once: cron schedules,
+// relative schedules with a non-zero interval that set Repeat, and every
+// schedules unless Count is 1
+func (s *ScheduleSpec) Repeats() bool {
+	switch {
+	case s.Cron != nil:
+		return true
+	case s.Relative != nil:
+		interval, err := time.ParseDuration(*s.Relative)
+		return err == nil && interval > 0 && s.Repeat
+	case s.Every != nil:
+		return s.Count != 1
+	default:
+		return false
+	}
+}
+
+// Endless reports whether a schedule keeps firing for as long as the
+// scheduler runs, which every schedules with a Count do not
+func (s *ScheduleSpec) Endless() bool {
+	return s.Repeats() && !(s.Every != nil && s.Count > 0)
+}
+
+// Type returns the name of the schedule strategy in use
+func (s *ScheduleSpec) Type() string {
+	switch {
+	case s.Epoch != nil:
+		return "epoch"
+	case s.Relative != nil:
+		return "relative"
+	case s.Template != nil:
+		return "template"
+	case s.Cron != nil:
+		return "cron"
+	case s.Every != nil:
+		return "every"
+	default:
+		return ""
+	}
+}
+
+// Expression returns the schedule's configured value as written in the config
+func (s *ScheduleSpec) Expression() string {
+	switch {
+	case s.Epoch != nil:
+		return fmt.Sprintf("%d", *s.Epoch)
+	case s.Relative != nil:
+		return *s.Relative
+	case s.Template != nil:
+		return *s.Template
+	case s.Cron != nil:
+		return *s.Cron
+	case s.Every != nil:
+		return *s.Every
+	default:
+		return ""
+	}
+}
+
+// NotificationSpec defines a webhook called when a request keeps failing
+type NotificationSpec struct {
+	Name string `json:"name" yaml:"name"`
+	URL  string `json:"url" yaml:"url"`
+
+	// Method defaults to POST
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// Payload is sent as JSON; string values may contain templates. Defaults
+	// to a Slack-compatible {"text": "..."} message
+	Payload interface{} `json:"payload,omitempty" yaml:"payload,omitempty"`
+
+	// After is the number of consecutive failures that triggers the notification (default 1)
+	After int `json:"after,omitempty" yaml:"after,omitempty"`
+
+	// Requests limits the notification to the named requests; empty means all
+	Requests []string `json:"requests,omitempty" yaml:"requests,omitempty"`
+}
+
+// ProfileSpec is a named preset of runner options selected with --profile.
+// Unset fields leave the corresponding option at its default, and options
+// given on the command line always take precedence.
+type ProfileSpec struct {
+	Workers     *int     `json:"workers,omitempty" yaml:"workers,omitempty"`
+	Concurrency *int     `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+	RPS         *float64 `json:"rps,omitempty" yaml:"rps,omitempty"`
+	Timeout     string   `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Once        *bool    `json:"once,omitempty" yaml:"once,omitempty"`
+	Count       *int     `json:"count,omitempty" yaml:"count,omitempty"`
+	Duration    string   `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
 // ValidationError represents a validation error
 type ValidationError struct {
 	Field   string
@@ -82,4 +461,80 @@ type ResolvedRequest struct {
 	Headers      map[string]string
 	Body         interface{}
 	ScheduledFor time.Time
+
+	// BodyFile is the path of a file streamed as the HTTP body instead of Body
+	BodyFile string
+
+	// SSE is set for Server-Sent Events subscriptions, which GET URL with Headers
+	SSE *SSEOptions
+
+	// Kafka is set for Kafka requests, which produce Body with Headers as
+	// record headers
+	Kafka *KafkaTarget
+
+	// AMQP is set for AMQP requests, which publish Body with Headers as
+	// message headers
+	AMQP *AMQPTarget
+
+	// Redis is set for Redis requests
+	Redis *RedisTarget
+
+	// NATS is set for NATS requests, which send Body with Headers as message
+	// headers
+	NATS *NATSTarget
+
+	// SQS is set for SQS requests, which send Body with Headers as message
+	// attributes
+	SQS *SQSTarget
+
+	// SNS is set for SNS requests, which publish Body with Headers as message
+	// attributes
+	SNS *SNSTarget
+
+	// SOAP is set for SOAP requests, which POST the envelope in Body and
+	// are checked for faults
+	SOAP *SOAPOptions
+
+	// JSONRPC is set for JSON-RPC calls, which POST the request object in
+	// Body and are checked for error objects
+	JSONRPC *JSONRPCOptions
+
+	// GRPC is set for gRPC calls, which send Body as the request message
+	// with Headers as metadata
+	GRPC *GRPCTarget
+
+	// Plugin is set for plugin requests, which the plugin registered for
+	// its type executes
+	Plugin *PluginTarget
+}
+
+// Type returns the kind of request resolved, matching the Type of the
+// scheduled request it came from
+func (r *ResolvedRequest) Type() string {
+	switch {
+	case r.SSE != nil:
+		return TypeSSE
+	case r.Kafka != nil:
+		return TypeKafka
+	case r.AMQP != nil:
+		return TypeAMQP
+	case r.Redis != nil:
+		return TypeRedis
+	case r.NATS != nil:
+		return TypeNATS
+	case r.SQS != nil:
+		return TypeSQS
+	case r.SNS != nil:
+		return TypeSNS
+	case r.SOAP != nil:
+		return TypeSOAP
+	case r.JSONRPC != nil:
+		return TypeJSONRPC
+	case r.GRPC != nil:
+		return TypeGRPC
+	case r.Plugin != nil:
+		return r.Plugin.Type
+	default:
+		return TypeHTTP
+	}
 }
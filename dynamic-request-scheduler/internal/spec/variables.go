@@ -0,0 +1,210 @@
+package spec
+
+import (
+	"fmt"
+	"reflect"
+	"text/template"
+	"text/template/parse"
+)
+
+// validateVariables checks that every variable the templates of a config read
+// is set by a setup capture or an extract. Setup requests can only read what
+// earlier setup requests capture or extract; scheduled and teardown requests
+// can also read what any scheduled request extracts.
+func validateVariables(config *Config) error {
+	funcs := NewTemplateEngine(nil).funcMap
+	declared := make(map[string]bool)
+
+	for i := range config.Setup {
+		req := &config.Setup[i]
+		if name, ok := undeclaredVariable(req, funcs, declared); ok {
+			return fmt.Errorf("setup %d (%s): template reads variable %q, which no earlier setup request captures", i, req.Name, name)
+		}
+		for name := range req.Capture {
+			declared[name] = true
+		}
+		for name := range req.HTTP.Extract {
+			declared[name] = true
+		}
+	}
+	for name := range Extracted(config.Requests) {
+		declared[name] = true
+	}
+	for i := range config.Requests {
+		req := &config.Requests[i]
+		if name, ok := undeclaredVariable(req, funcs, declared); ok {
+			return fmt.Errorf("request %d (%s): template reads variable %q, which no setup request captures and no request extracts", i, req.Name, name)
+		}
+	}
+	for i := range config.Teardown {
+		req := &config.Teardown[i]
+		if name, ok := undeclaredVariable(req, funcs, declared); ok {
+			return fmt.Errorf("teardown %d (%s): template reads variable %q, which no setup request captures and no request extracts", i, req.Name, name)
+		}
+	}
+	return nil
+}
+
+// Extracted returns the names of the variables the extract sections of
+// requests set
+func Extracted(requests []ScheduledRequest) map[string]bool {
+	names := make(map[string]bool)
+	for i := range requests {
+		for name := range requests[i].HTTP.Extract {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// Variables returns the variables the templates of a request read with
+// var "name" or .Variables.name, each once
+func (r *ScheduledRequest) Variables() []string {
+	funcs := NewTemplateEngine(nil).funcMap
+	seen := make(map[string]bool)
+	var names []string
+	templateStrings(reflect.ValueOf(r), func(tmpl string) {
+		for _, name := range templateVariables(tmpl, funcs) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	})
+	return names
+}
+
+// undeclaredVariable returns the first variable read by a template of req
+// that is not declared
+func undeclaredVariable(req *ScheduledRequest, funcs template.FuncMap, declared map[string]bool) (string, bool) {
+	var found string
+	templateStrings(reflect.ValueOf(req), func(tmpl string) {
+		if found != "" {
+			return
+		}
+		for _, name := range templateVariables(tmpl, funcs) {
+			if !declared[name] {
+				found = name
+				return
+			}
+		}
+	})
+	return found, found != ""
+}
+
+// templateStrings calls visit with every template string in v, including map
+// keys, slice items and the fields of nested structs
+func templateStrings(v reflect.Value, visit func(string)) {
+	if v.IsValid() && v.CanInterface() {
+		switch d := v.Interface().(type) {
+		case DynamicString:
+			if d.IsTemplate() {
+				visit(d.GetTemplate())
+			} else {
+				templateStrings(reflect.ValueOf(d.GetValue()), visit)
+			}
+			return
+		case DynamicInt64:
+			if d.IsTemplate() {
+				visit(d.GetTemplate())
+			}
+			return
+		case DynamicAny:
+			if d.IsTemplate() {
+				visit(d.GetTemplate())
+			} else if literal, err := d.Literal(); err == nil {
+				templateStrings(reflect.ValueOf(literal), visit)
+			}
+			return
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			templateStrings(v.Elem(), visit)
+		}
+	case reflect.String:
+		if IsTemplateString(v.String()) {
+			visit(v.String())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				templateStrings(v.Field(i), visit)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			templateStrings(v.Index(i), visit)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			templateStrings(iter.Key(), visit)
+			templateStrings(iter.Value(), visit)
+		}
+	}
+}
+
+// templateVariables returns the variables a template reads with var "name"
+// or .Variables.name. Templates that fail to parse report no variables;
+// evaluating them reports the error.
+func templateVariables(tmpl string, funcs template.FuncMap) []string {
+	t, err := template.New("dynamic").Funcs(funcs).Parse(tmpl)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, child := range n.Nodes {
+					walk(child)
+				}
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, cmd := range n.Cmds {
+					walk(cmd)
+				}
+			}
+		case *parse.CommandNode:
+			if len(n.Args) > 1 {
+				if ident, ok := n.Args[0].(*parse.IdentifierNode); ok && ident.Ident == "var" {
+					if name, ok := n.Args[1].(*parse.StringNode); ok {
+						names = append(names, name.Text)
+					}
+				}
+			}
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			if len(n.Ident) > 1 && n.Ident[0] == "Variables" {
+				names = append(names, n.Ident[1])
+			}
+		}
+	}
+	walk(t.Tree.Root)
+	return names
+}
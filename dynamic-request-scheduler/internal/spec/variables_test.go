@@ -0,0 +1,172 @@
+package spec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplateVariables(t *testing.T) {
+	funcs := NewTemplateEngine(nil).funcMap
+	tests := []struct {
+		tmpl string
+		want []string
+	}{
+		{tmpl: `Bearer {{ var "token" }}`, want: []string{"token"}},
+		{tmpl: `{{ .Variables.user }}/{{ var "org" | upper }}`, want: []string{"user", "org"}},
+		{tmpl: `{{ if var "debug" }}{{ with .Variables.level }}{{ . }}{{ end }}{{ else }}{{ range .Variables.items }}{{ end }}{{ end }}`, want: []string{"debug", "level", "items"}},
+		{tmpl: `{{ var (env "NAME") }}`, want: nil},
+		{tmpl: `{{ uuid }} {{ state "cursor" }}`, want: nil},
+		{tmpl: `{{ var "broken" `, want: nil},
+	}
+	for _, tt := range tests {
+		if got := templateVariables(tt.tmpl, funcs); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.tmpl, tt.want, got)
+		}
+	}
+}
+
+func TestLoadConfigFile_Variables(t *testing.T) {
+	valid := writeConfig(t, "valid.yaml", `
+setup:
+  - name: "login"
+    http:
+      method: "POST"
+      url: "http://localhost/login"
+    capture:
+      token: "$.token"
+  - name: "tenant"
+    http:
+      method: "POST"
+      url: "http://localhost/tenants"
+      headers:
+        Authorization: 'Bearer {{ var "token" }}'
+    capture:
+      tenant: "$.id"
+requests:
+  - name: "orders"
+    schedule:
+      relative: "1m"
+    http:
+      method: "POST"
+      url: "http://localhost/tenants/{{ .Variables.tenant }}/orders"
+      body:
+        items:
+          - owner: '{{ var "token" }}'
+teardown:
+  - name: "delete-tenant"
+    http:
+      method: "DELETE"
+      url: 'http://localhost/tenants/{{ var "tenant" }}'
+`)
+	if _, err := LoadConfigFile(valid); err != nil {
+		t.Errorf("Expected captured variables to be readable, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{
+			name: "undeclared in body",
+			yaml: `
+requests:
+  - name: "orders"
+    schedule:
+      relative: "1m"
+    http:
+      method: "POST"
+      url: "http://localhost/orders"
+      body:
+        items:
+          - owner: '{{ var "owner" }}'
+`,
+			want: `request 0 (orders): template reads variable "owner", which no setup request captures`,
+		},
+		{
+			name: "captured by a later setup request",
+			yaml: `
+setup:
+  - name: "tenant"
+    http:
+      method: "POST"
+      url: 'http://localhost/tenants?token={{ var "token" }}'
+  - name: "login"
+    http:
+      method: "POST"
+      url: "http://localhost/login"
+    capture:
+      token: "$.token"
+`,
+			want: `setup 0 (tenant): template reads variable "token", which no earlier setup request captures`,
+		},
+		{
+			name: "undeclared in teardown",
+			yaml: `
+teardown:
+  - name: "cleanup"
+    http:
+      method: "DELETE"
+      url: "http://localhost/tenants/{{ .Variables.tenant }}"
+`,
+			want: `teardown 0 (cleanup): template reads variable "tenant"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, "config.yaml", tt.yaml)
+			if _, err := LoadConfigFile(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFile_Extract(t *testing.T) {
+	valid := writeConfig(t, "valid.yaml", `
+requests:
+  - name: "orders"
+    schedule:
+      relative: "1m"
+    http:
+      method: "GET"
+      url: "http://localhost/orders"
+      headers:
+        Authorization: "Bearer {{ .Variables.token }}"
+  - name: "login"
+    schedule:
+      relative: "10m"
+    http:
+      method: "POST"
+      url: "http://localhost/login"
+      extract:
+        token: "$.access_token"
+        session: "header:Set-Cookie"
+`)
+	config, err := LoadConfigFile(valid)
+	if err != nil {
+		t.Fatalf("Expected extracted variables to be readable, got %v", err)
+	}
+	if extracted := Extracted(config.Requests); !extracted["token"] || !extracted["session"] || len(extracted) != 2 {
+		t.Errorf("Expected token and session to be extracted, got %v", extracted)
+	}
+	if names := config.Requests[0].Variables(); !reflect.DeepEqual(names, []string{"token"}) {
+		t.Errorf("Expected orders to read token, got %v", names)
+	}
+
+	invalid := writeConfig(t, "invalid.yaml", `
+requests:
+  - name: "login"
+    schedule:
+      relative: "10m"
+    http:
+      method: "POST"
+      url: "http://localhost/login"
+      extract:
+        token: "$.["
+`)
+	if _, err := LoadConfigFile(invalid); err == nil || !strings.Contains(err.Error(), "http.extract.token") {
+		t.Errorf("Expected an invalid extract source error, got %v", err)
+	}
+}
@@ -0,0 +1,444 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XPath captures and expectations read XML responses with a subset of XPath
+// 1.0 that covers what smoke checks need:
+//
+//	/envelope/body/order/id      child steps from the document
+//	//item                       elements anywhere below
+//	*                            any element
+//	@id, text()                  attributes and text as the last step
+//	item[2], item[last()]        positions, counted from 1
+//	order[@id], order[@id='7']   attribute presence and values
+//	order[status='open']         child element values
+//	item[text()='a'], item[.='a'] the element's own value
+//	count(//item)                the number of nodes selected
+//
+// Names match local names, so namespace prefixes in a document or an
+// expression are ignored.
+
+// IsXPath reports whether a capture source or expectation is an XPath
+// rather than a JSON path: XPaths start with / or count(
+func IsXPath(source string) bool {
+	return strings.HasPrefix(source, "/") || strings.HasPrefix(source, "count(")
+}
+
+// xmlNode is an element of a parsed XML document
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     strings.Builder
+	all      strings.Builder
+}
+
+// value returns the text of the element and its descendants, which is how
+// XPath compares and returns elements
+func (n *xmlNode) value() string {
+	return strings.TrimSpace(n.all.String())
+}
+
+// attr returns the value of the named attribute
+func (n *xmlNode) attr(name string) (string, bool) {
+	for _, attr := range n.attrs {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// parseXMLDocument parses an XML body into a document node whose only
+// child is the root element
+func parseXMLDocument(body []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	document := &xmlNode{}
+	stack := []*xmlNode{document}
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("response body is not XML: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local, attrs: t.Attr}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			stack[len(stack)-1].text.Write(t)
+			for _, node := range stack {
+				node.all.Write(t)
+			}
+		}
+	}
+	if len(document.children) == 0 {
+		return nil, fmt.Errorf("response body is not XML: no root element")
+	}
+	return document, nil
+}
+
+// xpathStep is one step of an XPath location path
+type xpathStep struct {
+	// descendant is set for steps after //, which look at every element
+	// below the context rather than its children only
+	descendant bool
+
+	// name is the element or attribute name, or * for any element
+	name      string
+	attribute bool
+	text      bool
+
+	predicates []xpathPredicate
+}
+
+// xpathPredicate filters the elements a step selects
+type xpathPredicate struct {
+	// position selects the n-th element, counted from 1; last selects the
+	// last one
+	position int
+	last     bool
+
+	// otherwise the element must have the attribute (when attribute is
+	// set), child element or own value (when name is "."), with value when
+	// hasValue is set
+	name      string
+	attribute bool
+	value     string
+	hasValue  bool
+}
+
+// XPath is a parsed XPath expression
+type XPath struct {
+	expr  string
+	count bool
+	steps []xpathStep
+}
+
+// ParseXPath parses an expression of the XPath subset described above
+func ParseXPath(expr string) (*XPath, error) {
+	path := &XPath{expr: expr}
+	rest := strings.TrimSpace(expr)
+	if strings.HasPrefix(rest, "count(") && strings.HasSuffix(rest, ")") {
+		path.count = true
+		rest = strings.TrimSpace(rest[len("count(") : len(rest)-1])
+	}
+	if rest == "" {
+		return nil, fmt.Errorf("invalid XPath %q: empty path", expr)
+	}
+
+	descendant := false
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "//"):
+			descendant = true
+			rest = rest[2:]
+			continue
+		case strings.HasPrefix(rest, "/"):
+			rest = rest[1:]
+			continue
+		}
+
+		end := stepEnd(rest)
+		step, err := parseXPathStep(rest[:end])
+		if err != nil {
+			return nil, fmt.Errorf("invalid XPath %q: %w", expr, err)
+		}
+		step.descendant = descendant
+		descendant = false
+		if len(path.steps) > 0 {
+			if last := path.steps[len(path.steps)-1]; last.attribute || last.text {
+				return nil, fmt.Errorf("invalid XPath %q: %s must be the last step", expr, rest[:end])
+			}
+		}
+		path.steps = append(path.steps, step)
+		rest = rest[end:]
+	}
+	if descendant {
+		return nil, fmt.Errorf("invalid XPath %q: path ends with //", expr)
+	}
+	return path, nil
+}
+
+// stepEnd returns the length of the step at the start of s, up to the next
+// / outside a predicate
+func stepEnd(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '/' && depth == 0:
+			return i
+		}
+	}
+	return len(s)
+}
+
+// parseXPathStep parses a single step such as item[@id='7'][1]
+func parseXPathStep(s string) (xpathStep, error) {
+	var step xpathStep
+	name := s
+	if i := strings.IndexByte(s, '['); i >= 0 {
+		name = s[:i]
+		rest := s[i:]
+		for rest != "" {
+			if rest[0] != '[' {
+				return step, fmt.Errorf("unexpected %q in step %q", rest, s)
+			}
+			end := predicateEnd(rest)
+			if end < 0 {
+				return step, fmt.Errorf("unclosed [ in step %q", s)
+			}
+			predicate, err := parseXPathPredicate(strings.TrimSpace(rest[1:end]))
+			if err != nil {
+				return step, err
+			}
+			step.predicates = append(step.predicates, predicate)
+			rest = rest[end+1:]
+		}
+	}
+
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "text()":
+		step.text = true
+	case strings.HasPrefix(name, "@"):
+		step.attribute = true
+		step.name = localName(name[1:])
+	default:
+		step.name = localName(name)
+	}
+	if step.name == "" && !step.text {
+		return step, fmt.Errorf("empty step in %q", s)
+	}
+	if (step.attribute || step.text) && len(step.predicates) > 0 {
+		return step, fmt.Errorf("predicates only apply to elements, in %q", s)
+	}
+	return step, nil
+}
+
+// predicateEnd returns the index of the ] closing the predicate s starts
+// with, or -1
+func predicateEnd(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseXPathPredicate parses the inside of a predicate
+func parseXPathPredicate(s string) (xpathPredicate, error) {
+	var predicate xpathPredicate
+	if s == "last()" {
+		predicate.last = true
+		return predicate, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 {
+			return predicate, fmt.Errorf("position %d: positions start at 1", n)
+		}
+		predicate.position = n
+		return predicate, nil
+	}
+
+	name := s
+	if i := strings.IndexByte(s, '='); i >= 0 {
+		name = strings.TrimSpace(s[:i])
+		value := strings.TrimSpace(s[i+1:])
+		if len(value) < 2 || (value[0] != '\'' && value[0] != '"') || value[len(value)-1] != value[0] {
+			return predicate, fmt.Errorf("predicate [%s]: compare with a quoted string", s)
+		}
+		predicate.value = value[1 : len(value)-1]
+		predicate.hasValue = true
+	}
+	switch {
+	case name == "." || name == "text()":
+		predicate.name = "."
+	case strings.HasPrefix(name, "@"):
+		predicate.attribute = true
+		predicate.name = localName(name[1:])
+	default:
+		predicate.name = localName(name)
+	}
+	if predicate.name == "" {
+		return predicate, fmt.Errorf("predicate [%s]: missing name", s)
+	}
+	if predicate.name == "." && !predicate.hasValue {
+		return predicate, fmt.Errorf("predicate [%s]: compare with a quoted string", s)
+	}
+	return predicate, nil
+}
+
+// localName drops a namespace prefix from a name
+func localName(name string) string {
+	if i := strings.LastIndexByte(name, ':'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// Evaluate returns the value the expression selects in an XML body: the
+// number of nodes for count(), and otherwise the value of the first node
+// selected. It fails when nothing is selected.
+func (p *XPath) Evaluate(body []byte) (interface{}, error) {
+	document, err := parseXMLDocument(body)
+	if err != nil {
+		return nil, err
+	}
+	values := p.selectValues(document)
+	if p.count {
+		return len(values), nil
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s: no match", p.expr)
+	}
+	return values[0], nil
+}
+
+// selectValues returns the values of the nodes the path selects
+func (p *XPath) selectValues(document *xmlNode) []string {
+	context := []*xmlNode{document}
+	for _, step := range p.steps {
+		if step.descendant {
+			context = descendantsOrSelf(context)
+		}
+		switch {
+		case step.attribute:
+			var values []string
+			for _, node := range context {
+				for _, attr := range node.attrs {
+					if (step.name == "*" || attr.Name.Local == step.name) && attr.Name.Space != "xmlns" && attr.Name.Local != "xmlns" {
+						values = append(values, attr.Value)
+					}
+				}
+			}
+			return values
+		case step.text:
+			var values []string
+			for _, node := range context {
+				if text := strings.TrimSpace(node.text.String()); text != "" {
+					values = append(values, text)
+				}
+			}
+			return values
+		}
+
+		var next []*xmlNode
+		for _, node := range context {
+			var matched []*xmlNode
+			for _, child := range node.children {
+				if step.name == "*" || child.name == step.name {
+					matched = append(matched, child)
+				}
+			}
+			for _, predicate := range step.predicates {
+				matched = predicate.filter(matched)
+			}
+			next = append(next, matched...)
+		}
+		context = next
+	}
+
+	values := make([]string, len(context))
+	for i, node := range context {
+		values[i] = node.value()
+	}
+	return values
+}
+
+// descendantsOrSelf returns the nodes and every element below them, in
+// document order
+func descendantsOrSelf(nodes []*xmlNode) []*xmlNode {
+	var all []*xmlNode
+	var walk func(node *xmlNode)
+	walk = func(node *xmlNode) {
+		all = append(all, node)
+		for _, child := range node.children {
+			walk(child)
+		}
+	}
+	for _, node := range nodes {
+		walk(node)
+	}
+	return all
+}
+
+// filter returns the nodes the predicate keeps
+func (p xpathPredicate) filter(nodes []*xmlNode) []*xmlNode {
+	switch {
+	case p.last:
+		if len(nodes) == 0 {
+			return nil
+		}
+		return nodes[len(nodes)-1:]
+	case p.position > 0:
+		if p.position > len(nodes) {
+			return nil
+		}
+		return nodes[p.position-1 : p.position]
+	}
+
+	var kept []*xmlNode
+	for _, node := range nodes {
+		if p.matches(node) {
+			kept = append(kept, node)
+		}
+	}
+	return kept
+}
+
+// matches reports whether a node meets a condition predicate
+func (p xpathPredicate) matches(node *xmlNode) bool {
+	switch {
+	case p.name == ".":
+		return node.value() == p.value
+	case p.attribute:
+		value, ok := node.attr(p.name)
+		return ok && (!p.hasValue || value == p.value)
+	}
+	for _, child := range node.children {
+		if child.name == p.name && (!p.hasValue || child.value() == p.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the expression as written
+func (p *XPath) String() string {
+	return p.expr
+}
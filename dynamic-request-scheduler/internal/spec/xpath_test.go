@@ -0,0 +1,103 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestXPath_Evaluate(t *testing.T) {
+	body := []byte(`<?xml version="1.0"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+  <env:Body>
+    <order id="7" status="open">
+      <customer>alice</customer>
+      <item sku="a1">apple</item>
+      <item sku="b2">banana</item>
+      <item sku="c3">cherry</item>
+    </order>
+    <order id="8"><status>closed</status><item sku="d4">date</item></order>
+  </env:Body>
+</env:Envelope>`)
+
+	tests := []struct {
+		expr    string
+		want    interface{}
+		wantErr string
+	}{
+		{expr: "/Envelope/Body/order/@id", want: "7"},
+		{expr: "/env:Envelope/env:Body/order/customer", want: "alice"},
+		{expr: "//item", want: "apple"},
+		{expr: "//order/item[2]", want: "banana"},
+		{expr: "//order[1]/item[last()]", want: "cherry"},
+		{expr: "//item[@sku='d4']", want: "date"},
+		{expr: "//order[@status]/@id", want: "7"},
+		{expr: "//order[status='closed']/@id", want: "8"},
+		{expr: "//item[.='cherry']/@sku", want: "c3"},
+		{expr: "//customer/text()", want: "alice"},
+		{expr: "/Envelope/*/order/@id", want: "7"},
+		{expr: "count(//item)", want: 4},
+		{expr: "count(//missing)", want: 0},
+		{expr: "//missing", wantErr: "no match"},
+		{expr: "//item[@sku='zz']", wantErr: "no match"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			path, err := ParseXPath(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseXPath failed: %v", err)
+			}
+			got, err := path.Evaluate(body)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v (%v)", tt.wantErr, err, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Expected %v, got %v (%v)", tt.want, got, err)
+			}
+		})
+	}
+
+	path, _ := ParseXPath("/a")
+	if _, err := path.Evaluate([]byte(`{"a": 1}`)); err == nil || !strings.Contains(err.Error(), "not XML") {
+		t.Errorf("Expected a non-XML body to be rejected, got %v", err)
+	}
+}
+
+func TestParseXPath_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"":             "empty path",
+		"count()":      "empty path",
+		"/a//":         "ends with //",
+		"/a/@id/b":     "must be the last step",
+		"/a/text()/b":  "must be the last step",
+		"/a[1":         "unclosed [",
+		"/a[0]":        "positions start at 1",
+		"/a[@id=7]":    "quoted string",
+		"/a[.]":        "quoted string",
+		"/a/@id[1]":    "predicates only apply to elements",
+		"/a[1]x":       "unexpected",
+		"/a/[@id='7']": "empty step",
+	}
+	for expr, wantErr := range tests {
+		if _, err := ParseXPath(expr); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("ParseXPath(%q): expected error containing %q, got %v", expr, wantErr, err)
+		}
+	}
+}
+
+func TestIsXPath(t *testing.T) {
+	for source, want := range map[string]bool{
+		"/order/@id":    true,
+		"//item":        true,
+		"count(//item)": true,
+		"$.order.id":    false,
+		"header:ETag":   false,
+		"status":        false,
+	} {
+		if got := IsXPath(source); got != want {
+			t.Errorf("IsXPath(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
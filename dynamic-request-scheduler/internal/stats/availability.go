@@ -0,0 +1,186 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+// Target kinds tracked by Availability
+const (
+	TargetRequest = "request"
+	TargetHost    = "host"
+)
+
+// Availability tracks uptime per request and per target host over the session.
+// A target is down from its first failed execution until its next success.
+type Availability struct {
+	mu      sync.Mutex
+	targets map[targetKey]*targetState
+}
+
+type targetKey struct {
+	kind string
+	name string
+}
+
+// targetState is the up/down history of a single target
+type targetState struct {
+	first   time.Time
+	last    time.Time
+	up      bool
+	outages []Outage
+}
+
+// Outage is a period during which a target was failing; End is zero while ongoing
+type Outage struct {
+	Start time.Time
+	End   time.Time
+}
+
+// TargetAvailability is a point-in-time availability report for one target
+type TargetAvailability struct {
+	Kind         string
+	Name         string
+	Observed     time.Duration
+	Downtime     time.Duration
+	Availability float64
+	Up           bool
+	Outages      []Outage
+}
+
+// NewAvailability creates an empty availability tracker
+func NewAvailability() *Availability {
+	return &Availability{targets: make(map[targetKey]*targetState)}
+}
+
+// Record implements engine.ResultRecorder
+func (a *Availability) Record(result engine.ExecutionResult) error {
+	at := result.StartedAt.Add(result.Duration)
+	success := result.Success()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.observe(targetKey{TargetRequest, result.RequestName}, at, success)
+
+	// Requests that failed evaluation have no resolved host
+	if u, err := url.Parse(result.URL); err == nil && u.Host != "" {
+		a.observe(targetKey{TargetHost, u.Host}, at, success)
+	}
+	return nil
+}
+
+// observe applies one execution outcome to a target
+func (a *Availability) observe(key targetKey, at time.Time, success bool) {
+	state, ok := a.targets[key]
+	if !ok {
+		state = &targetState{first: at, up: true}
+		a.targets[key] = state
+	}
+
+	// Concurrent executions can complete out of order; keep the timeline monotonic
+	if at.Before(state.last) {
+		at = state.last
+	}
+	state.last = at
+
+	switch {
+	case state.up && !success:
+		state.outages = append(state.outages, Outage{Start: at})
+	case !state.up && success:
+		state.outages[len(state.outages)-1].End = at
+	}
+	state.up = success
+}
+
+// Snapshot reports availability for every target up to now, requests first
+// then hosts, each sorted by name
+func (a *Availability) Snapshot(now time.Time) []TargetAvailability {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	reports := make([]TargetAvailability, 0, len(a.targets))
+	for key, state := range a.targets {
+		end := now
+		if end.Before(state.last) {
+			end = state.last
+		}
+
+		report := TargetAvailability{
+			Kind:     key.kind,
+			Name:     key.name,
+			Observed: end.Sub(state.first),
+			Up:       state.up,
+			Outages:  append([]Outage(nil), state.outages...),
+		}
+		for _, outage := range state.outages {
+			outageEnd := outage.End
+			if outageEnd.IsZero() {
+				outageEnd = end
+			}
+			report.Downtime += outageEnd.Sub(outage.Start)
+		}
+
+		switch {
+		case report.Observed > 0:
+			report.Availability = 1 - float64(report.Downtime)/float64(report.Observed)
+		case state.up:
+			report.Availability = 1
+		}
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Kind != reports[j].Kind {
+			return reports[i].Kind == TargetRequest
+		}
+		return reports[i].Name < reports[j].Name
+	})
+	return reports
+}
+
+// WriteReport prints an availability table followed by each target's outages
+func (a *Availability) WriteReport(w io.Writer, now time.Time) {
+	reports := a.Snapshot(now)
+	if len(reports) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Availability")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TARGET\tSTATE\tAVAILABILITY\tOBSERVED\tDOWNTIME\tOUTAGES")
+	for _, r := range reports {
+		state := "up"
+		if !r.Up {
+			state = "down"
+		}
+		fmt.Fprintf(tw, "%s %s\t%s\t%.2f%%\t%s\t%s\t%d\n",
+			r.Kind, r.Name, state, r.Availability*100,
+			r.Observed.Round(time.Second), r.Downtime.Round(time.Millisecond), len(r.Outages))
+	}
+	tw.Flush()
+
+	for _, r := range reports {
+		if len(r.Outages) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s %s outages:\n", r.Kind, r.Name)
+		for _, outage := range r.Outages {
+			if outage.End.IsZero() {
+				fmt.Fprintf(w, "  %s - ongoing (%s)\n", outage.Start.Local().Format(time.TimeOnly),
+					now.Sub(outage.Start).Round(time.Millisecond))
+				continue
+			}
+			fmt.Fprintf(w, "  %s - %s (%s)\n", outage.Start.Local().Format(time.TimeOnly),
+				outage.End.Local().Format(time.TimeOnly), outage.End.Sub(outage.Start).Round(time.Millisecond))
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+func TestAvailability_Snapshot(t *testing.T) {
+	a := NewAvailability()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	record := func(name, url string, offset time.Duration, status int) {
+		a.Record(engine.ExecutionResult{RequestName: name, URL: url, StartedAt: base.Add(offset), StatusCode: status})
+	}
+
+	// api: up for 10s, down for 20s, up again for 70s
+	record("api", "http://localhost:8080/api", 0, 200)
+	record("api", "http://localhost:8080/api", 10*time.Second, 500)
+	record("api", "http://localhost:8080/api", 20*time.Second, 503)
+	record("api", "http://localhost:8080/api", 30*time.Second, 200)
+	// health shares the host and is down from 90s until the end
+	record("health", "http://localhost:8080/health", 0, 200)
+	record("health", "http://localhost:8080/health", 90*time.Second, 0)
+	// Unresolved templates only count toward the request
+	a.Record(engine.ExecutionResult{RequestName: "broken", URL: "{{ invalid }}", StartedAt: base, Error: "template error"})
+
+	reports := a.Snapshot(base.Add(100 * time.Second))
+	if len(reports) != 4 {
+		t.Fatalf("Expected 3 requests and 1 host, got %+v", reports)
+	}
+
+	api, broken, health, host := reports[0], reports[1], reports[2], reports[3]
+	if api.Name != "api" || broken.Name != "broken" || health.Name != "health" || host.Kind != TargetHost || host.Name != "localhost:8080" {
+		t.Fatalf("Unexpected report order: %+v", reports)
+	}
+
+	if api.Downtime != 20*time.Second || api.Availability != 0.8 || !api.Up || len(api.Outages) != 1 {
+		t.Errorf("Unexpected api availability: %+v", api)
+	}
+	if health.Downtime != 10*time.Second || health.Up || !health.Outages[0].End.IsZero() {
+		t.Errorf("Expected ongoing health outage, got %+v", health)
+	}
+	if broken.Availability != 0 || broken.Up {
+		t.Errorf("Expected broken request to be down, got %+v", broken)
+	}
+	// The host went down at 10s, up at 30s (api success), and down again at 90s
+	if host.Downtime != 30*time.Second || len(host.Outages) != 2 {
+		t.Errorf("Unexpected host availability: %+v", host)
+	}
+}
+
+func TestAvailability_OutOfOrder(t *testing.T) {
+	a := NewAvailability()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	a.Record(engine.ExecutionResult{RequestName: "api", StartedAt: base.Add(10 * time.Second), StatusCode: 500})
+	a.Record(engine.ExecutionResult{RequestName: "api", StartedAt: base, StatusCode: 200})
+
+	report := a.Snapshot(base.Add(10 * time.Second))[0]
+	if report.Downtime < 0 || report.Observed < 0 {
+		t.Errorf("Expected non-negative durations, got %+v", report)
+	}
+}
+
+func TestAvailability_WriteReport(t *testing.T) {
+	a := NewAvailability()
+
+	var empty bytes.Buffer
+	a.WriteReport(&empty, time.Now())
+	if empty.Len() != 0 {
+		t.Errorf("Expected no report without executions, got %q", empty.String())
+	}
+
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	a.Record(engine.ExecutionResult{RequestName: "api", URL: "http://localhost/api", StartedAt: base, StatusCode: 200})
+	a.Record(engine.ExecutionResult{RequestName: "api", URL: "http://localhost/api", StartedAt: base.Add(time.Minute), Error: "refused"})
+
+	var buf bytes.Buffer
+	a.WriteReport(&buf, base.Add(2*time.Minute))
+	out := buf.String()
+	for _, want := range []string{"Availability", "request api", "host localhost", "down", "50.00%", "2m0s", "ongoing (1m0s)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected report to contain %q:\n%s", want, out)
+		}
+	}
+}
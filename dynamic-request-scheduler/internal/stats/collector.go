@@ -0,0 +1,412 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+// summaryQuantiles are the percentiles reported in summaries and metrics
+var summaryQuantiles = []float64{0.5, 0.9, 0.95, 0.99}
+
+// Collector aggregates execution results per request for summaries and metrics
+type Collector struct {
+	mu       sync.Mutex
+	requests map[string]*requestStats
+
+	// pressure reports the scheduler's dispatch load for metrics, when set
+	pressure func() engine.Pressure
+
+	// warmth reports the state of keep-warm endpoints for metrics, when set
+	warmth func() []engine.Warmth
+}
+
+// requestStats holds the aggregated state for a single request
+type requestStats struct {
+	executions uint64
+	failures   uint64
+	slow       uint64
+	unmet      uint64
+	warned     uint64
+	latency    *Histogram
+	classes    map[string]uint64
+
+	// timed counts the executions with a timing breakdown, whose phases
+	// add up in phases
+	timed  uint64
+	phases engine.Timings
+}
+
+// RequestSummary is a point-in-time view of one request's statistics
+type RequestSummary struct {
+	Name       string
+	Executions uint64
+	Slow       uint64
+	Latency    LatencySummary
+
+	// Failures counts executions that errored, returned a non-2xx status or
+	// did not meet the request's expectations
+	Failures uint64
+
+	// Unmet counts executions whose response did not meet the request's
+	// expectations
+	Unmet uint64
+
+	// Warned counts executions whose response did not meet an expectation
+	// of severity warn
+	Warned uint64
+
+	// StatusClasses counts executions per engine status class (2xx, 5xx, timeout, ...)
+	StatusClasses map[string]uint64
+
+	// Phases is the mean time HTTP executions spent in each phase
+	Phases PhaseSummary
+}
+
+// PhaseSummary describes the mean timing breakdown of HTTP executions
+type PhaseSummary struct {
+	// Count is how many executions had a breakdown
+	Count    uint64
+	DNS      time.Duration
+	Connect  time.Duration
+	TLS      time.Duration
+	TTFB     time.Duration
+	Transfer time.Duration
+}
+
+// LatencySummary describes the latency distribution of completed executions
+type LatencySummary struct {
+	Count uint64
+	Sum   time.Duration
+	Min   time.Duration
+	Mean  time.Duration
+	Max   time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+}
+
+// NewCollector creates an empty collector
+func NewCollector() *Collector {
+	return &Collector{requests: make(map[string]*requestStats)}
+}
+
+// Record implements engine.ResultRecorder
+func (c *Collector) Record(result engine.ExecutionResult) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats, ok := c.requests[result.RequestName]
+	if !ok {
+		stats = &requestStats{latency: NewHistogram(), classes: make(map[string]uint64)}
+		c.requests[result.RequestName] = stats
+	}
+
+	stats.executions++
+	stats.classes[result.StatusClass()]++
+	if result.Slow {
+		stats.slow++
+	}
+	if len(result.Unmet) > 0 {
+		stats.unmet++
+	}
+	if len(result.Warnings) > 0 {
+		stats.warned++
+	}
+	if !result.Success() {
+		stats.failures++
+	}
+
+	// Only completed HTTP exchanges have a meaningful latency
+	if result.Error == "" {
+		stats.latency.Record(result.Duration)
+	}
+	if t := result.Timings; t != nil {
+		stats.timed++
+		stats.phases.DNS += t.DNS
+		stats.phases.Connect += t.Connect
+		stats.phases.TLS += t.TLS
+		stats.phases.TTFB += t.TTFB
+		stats.phases.Transfer += t.Transfer
+	}
+	return nil
+}
+
+// WatchPressure includes the dispatch load reported by source, such as
+// Scheduler.Pressure, in the metrics
+func (c *Collector) WatchPressure(source func() engine.Pressure) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pressure = source
+}
+
+// WatchWarmth makes the metrics include the state of keep-warm endpoints,
+// read from source whenever metrics are written
+func (c *Collector) WatchWarmth(source func() []engine.Warmth) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warmth = source
+}
+
+// Snapshot returns the current statistics for every request, sorted by name
+func (c *Collector) Snapshot() []RequestSummary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	summaries := make([]RequestSummary, 0, len(c.requests))
+	for name, stats := range c.requests {
+		classes := make(map[string]uint64, len(stats.classes))
+		for class, count := range stats.classes {
+			classes[class] = count
+		}
+		summaries = append(summaries, RequestSummary{
+			Name:          name,
+			Executions:    stats.executions,
+			Failures:      stats.failures,
+			Slow:          stats.slow,
+			Unmet:         stats.unmet,
+			Warned:        stats.warned,
+			Latency:       summarizeLatency(stats.latency),
+			StatusClasses: classes,
+			Phases:        summarizePhases(stats.timed, stats.phases),
+		})
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries
+}
+
+// Failures returns the number of executions that errored, returned a
+// non-2xx status or did not meet their request's expectations
+func (c *Collector) Failures() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var failures uint64
+	for _, stats := range c.requests {
+		failures += stats.failures
+	}
+	return failures
+}
+
+func summarizeLatency(h *Histogram) LatencySummary {
+	return LatencySummary{
+		Count: h.Count(),
+		Sum:   h.Sum(),
+		Min:   h.Min(),
+		Mean:  h.Mean(),
+		Max:   h.Max(),
+		P50:   h.Quantile(0.5),
+		P90:   h.Quantile(0.9),
+		P95:   h.Quantile(0.95),
+		P99:   h.Quantile(0.99),
+	}
+}
+
+func summarizePhases(count uint64, total engine.Timings) PhaseSummary {
+	if count == 0 {
+		return PhaseSummary{}
+	}
+	n := time.Duration(count)
+	return PhaseSummary{
+		Count:    count,
+		DNS:      total.DNS / n,
+		Connect:  total.Connect / n,
+		TLS:      total.TLS / n,
+		TTFB:     total.TTFB / n,
+		Transfer: total.Transfer / n,
+	}
+}
+
+// WriteSummary prints a human-readable summary table of the run
+func (c *Collector) WriteSummary(w io.Writer) {
+	summaries := c.Snapshot()
+	if len(summaries) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Summary")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "REQUEST\tRUNS")
+	for _, class := range engine.StatusClasses {
+		fmt.Fprintf(tw, "\t%s", strings.ToUpper(class))
+	}
+	fmt.Fprintln(tw, "\tSLOW\tUNMET\tWARN\tMIN\tMEAN\tP50\tP90\tP95\tP99\tMAX")
+	for _, s := range summaries {
+		l := s.Latency
+		fmt.Fprintf(tw, "%s\t%d", s.Name, s.Executions)
+		for _, class := range engine.StatusClasses {
+			fmt.Fprintf(tw, "\t%d", s.StatusClasses[class])
+		}
+		fmt.Fprintf(tw, "\t%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Slow, s.Unmet, s.Warned, formatLatency(l.Count, l.Min), formatLatency(l.Count, l.Mean),
+			formatLatency(l.Count, l.P50), formatLatency(l.Count, l.P90),
+			formatLatency(l.Count, l.P95), formatLatency(l.Count, l.P99),
+			formatLatency(l.Count, l.Max))
+	}
+	tw.Flush()
+}
+
+// WriteTimings prints the mean timing breakdown of each request's HTTP
+// executions, leaving out requests without one
+func (c *Collector) WriteTimings(w io.Writer) {
+	var timed []RequestSummary
+	for _, s := range c.Snapshot() {
+		if s.Phases.Count > 0 {
+			timed = append(timed, s)
+		}
+	}
+	if len(timed) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Timings (mean)")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REQUEST\tTIMED\tDNS\tCONNECT\tTLS\tTTFB\tTRANSFER")
+	for _, s := range timed {
+		p := s.Phases
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", s.Name, p.Count,
+			formatLatency(p.Count, p.DNS), formatLatency(p.Count, p.Connect), formatLatency(p.Count, p.TLS),
+			formatLatency(p.Count, p.TTFB), formatLatency(p.Count, p.Transfer))
+	}
+	tw.Flush()
+}
+
+// formatLatency renders a latency, or "-" when nothing completed
+func formatLatency(count uint64, d time.Duration) string {
+	if count == 0 {
+		return "-"
+	}
+	return d.Round(time.Microsecond * 100).String()
+}
+
+// Handler serves the collected statistics in Prometheus text exposition format
+func (c *Collector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.WriteMetrics(w)
+	})
+}
+
+// WriteMetrics writes the collected statistics in Prometheus text exposition format
+func (c *Collector) WriteMetrics(w io.Writer) {
+	summaries := c.Snapshot()
+
+	fmt.Fprintln(w, "# HELP drs_executions_total Total number of request executions.")
+	fmt.Fprintln(w, "# TYPE drs_executions_total counter")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "drs_executions_total{request=\"%s\"} %d\n", escapeLabel(s.Name), s.Executions)
+	}
+
+	fmt.Fprintln(w, "# HELP drs_responses_total Request executions by status class (2xx, 3xx, 4xx, 5xx, timeout, error).")
+	fmt.Fprintln(w, "# TYPE drs_responses_total counter")
+	for _, s := range summaries {
+		for _, class := range engine.StatusClasses {
+			fmt.Fprintf(w, "drs_responses_total{request=\"%s\",class=\"%s\"} %d\n", escapeLabel(s.Name), class, s.StatusClasses[class])
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP drs_slow_executions_total Completed executions that exceeded their slow threshold.")
+	fmt.Fprintln(w, "# TYPE drs_slow_executions_total counter")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "drs_slow_executions_total{request=\"%s\"} %d\n", escapeLabel(s.Name), s.Slow)
+	}
+
+	fmt.Fprintln(w, "# HELP drs_unmet_expectations_total Completed executions whose response did not meet the request's expectations.")
+	fmt.Fprintln(w, "# TYPE drs_unmet_expectations_total counter")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "drs_unmet_expectations_total{request=\"%s\"} %d\n", escapeLabel(s.Name), s.Unmet)
+	}
+
+	fmt.Fprintln(w, "# HELP drs_expectation_warnings_total Completed executions whose response did not meet a warn expectation.")
+	fmt.Fprintln(w, "# TYPE drs_expectation_warnings_total counter")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "drs_expectation_warnings_total{request=\"%s\"} %d\n", escapeLabel(s.Name), s.Warned)
+	}
+
+	fmt.Fprintln(w, "# HELP drs_request_duration_seconds Latency of completed request executions.")
+	fmt.Fprintln(w, "# TYPE drs_request_duration_seconds summary")
+	for _, s := range summaries {
+		name := escapeLabel(s.Name)
+		h := s.Latency
+		values := []time.Duration{h.P50, h.P90, h.P95, h.P99}
+		for i, q := range summaryQuantiles {
+			fmt.Fprintf(w, "drs_request_duration_seconds{request=\"%s\",quantile=\"%g\"} %g\n", name, q, values[i].Seconds())
+		}
+		fmt.Fprintf(w, "drs_request_duration_seconds_sum{request=\"%s\"} %g\n", name, h.Sum.Seconds())
+		fmt.Fprintf(w, "drs_request_duration_seconds_count{request=\"%s\"} %d\n", name, h.Count)
+	}
+
+	c.mu.Lock()
+	source, warmth := c.pressure, c.warmth
+	c.mu.Unlock()
+	if source != nil {
+		writePressure(w, source())
+	}
+	if warmth != nil {
+		writeWarmth(w, warmth())
+	}
+}
+
+// writeWarmth writes whether each keep-warm endpoint is up and how often it
+// was found cold
+func writeWarmth(w io.Writer, states []engine.Warmth) {
+	if len(states) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "# HELP drs_keep_warm_up 1 while a keep-warm endpoint answered its last ping, 0 while it is down.")
+	fmt.Fprintln(w, "# TYPE drs_keep_warm_up gauge")
+	for _, state := range states {
+		up := 0
+		if state.Up() {
+			up = 1
+		}
+		fmt.Fprintf(w, "drs_keep_warm_up{request=\"%s\"} %d\n", escapeLabel(state.Request), up)
+	}
+
+	fmt.Fprintln(w, "# HELP drs_keep_warm_cold_starts_total Keep-warm pings that found their endpoint cold.")
+	fmt.Fprintln(w, "# TYPE drs_keep_warm_cold_starts_total counter")
+	for _, state := range states {
+		fmt.Fprintf(w, "drs_keep_warm_cold_starts_total{request=\"%s\"} %d\n", escapeLabel(state.Request), state.ColdStarts)
+	}
+}
+
+// writePressure writes the dispatch load gauges
+func writePressure(w io.Writer, p engine.Pressure) {
+	saturated := 0
+	if p.Saturated {
+		saturated = 1
+	}
+	gauges := []struct {
+		name, help string
+		value      float64
+	}{
+		{"drs_dispatch_busy", "Runners executing a request.", float64(p.Busy)},
+		{"drs_dispatch_runners", "Runners available, the concurrency limit.", float64(p.Runners)},
+		{"drs_dispatch_queued", "Due executions waiting for a runner.", float64(p.Queued)},
+		{"drs_dispatch_saturated", "1 while dispatch is slowed because the runners are saturated.", float64(saturated)},
+		{"drs_dispatch_interval_seconds", "Pause between scheduling passes.", p.Interval.Seconds()},
+		{"drs_dispatch_latency_ratio", "Recent latency relative to its baseline, 0 until known.", p.LatencyRatio()},
+	}
+	for _, g := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n", g.name, g.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", g.name)
+		fmt.Fprintf(w, "%s %g\n", g.name, g.value)
+	}
+}
+
+// escapeLabel escapes a Prometheus label value
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,241 @@
+package stats
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+func recordSamples(c *Collector) {
+	for i := 1; i <= 100; i++ {
+		c.Record(engine.ExecutionResult{
+			RequestName: "api",
+			StatusCode:  200,
+			Duration:    time.Duration(i) * time.Millisecond,
+		})
+	}
+	c.Record(engine.ExecutionResult{RequestName: "api", Error: "connection refused", Duration: time.Hour})
+	c.Record(engine.ExecutionResult{RequestName: "api", StatusCode: 200, Duration: 2 * time.Second, Slow: true})
+	c.Record(engine.ExecutionResult{RequestName: `quoted "name"`, StatusCode: 500, Duration: time.Second})
+}
+
+func TestCollector_Snapshot(t *testing.T) {
+	c := NewCollector()
+	recordSamples(c)
+
+	summaries := c.Snapshot()
+	if len(summaries) != 2 {
+		t.Fatalf("Expected 2 summaries, got %d", len(summaries))
+	}
+
+	api := summaries[0]
+	if api.Name != "api" || api.Executions != 102 || api.Slow != 1 {
+		t.Errorf("Unexpected summary: %+v", api)
+	}
+	if api.Latency.Count != 101 {
+		t.Errorf("Expected errored executions to be excluded from latency, got %d samples", api.Latency.Count)
+	}
+	if api.StatusClasses[engine.Class2xx] != 101 || api.StatusClasses[engine.ClassError] != 1 {
+		t.Errorf("Unexpected status classes: %v", api.StatusClasses)
+	}
+	if summaries[1].StatusClasses[engine.Class5xx] != 1 {
+		t.Errorf("Expected one 5xx for quoted request, got %v", summaries[1].StatusClasses)
+	}
+	if api.Latency.Max != 2*time.Second {
+		t.Errorf("Expected max 2s, got %v", api.Latency.Max)
+	}
+	if p95 := api.Latency.P95; p95 < 93*time.Millisecond || p95 > 97*time.Millisecond {
+		t.Errorf("Expected p95 ~95ms, got %v", p95)
+	}
+}
+
+func TestCollector_Failures(t *testing.T) {
+	c := NewCollector()
+	if c.Failures() != 0 {
+		t.Errorf("Expected no failures for empty collector, got %d", c.Failures())
+	}
+
+	recordSamples(c)
+	c.Record(engine.ExecutionResult{RequestName: "slow", Error: "deadline exceeded", TimedOut: true})
+
+	// One error and one 5xx from recordSamples, plus the timeout
+	if got := c.Failures(); got != 3 {
+		t.Errorf("Expected 3 failures, got %d", got)
+	}
+
+	// A 2xx response that misses an expectation fails too
+	c.Record(engine.ExecutionResult{RequestName: "api", StatusCode: 200, Unmet: []string{"content type text/html, expected json"}})
+	if got := c.Failures(); got != 4 {
+		t.Errorf("Expected 4 failures, got %d", got)
+	}
+	for _, summary := range c.Snapshot() {
+		if summary.Name == "api" && summary.Unmet != 1 {
+			t.Errorf("Expected 1 unmet execution of api, got %d", summary.Unmet)
+		}
+	}
+
+	// One that misses a warn expectation does not
+	c.Record(engine.ExecutionResult{RequestName: "api", StatusCode: 200, Warnings: []string{"expression is false"}})
+	if got := c.Failures(); got != 4 {
+		t.Errorf("Expected a warning not to count as a failure, got %d failures", got)
+	}
+	for _, summary := range c.Snapshot() {
+		if summary.Name == "api" && summary.Warned != 1 {
+			t.Errorf("Expected 1 warned execution of api, got %d", summary.Warned)
+		}
+	}
+}
+
+func TestCollector_WriteSummary(t *testing.T) {
+	c := NewCollector()
+
+	var empty bytes.Buffer
+	c.WriteSummary(&empty)
+	if empty.Len() != 0 {
+		t.Errorf("Expected no summary without executions, got %q", empty.String())
+	}
+
+	recordSamples(c)
+	c.Record(engine.ExecutionResult{RequestName: "down", Error: "refused"})
+
+	var buf bytes.Buffer
+	c.WriteSummary(&buf)
+	out := buf.String()
+
+	for _, want := range []string{"REQUEST", "2XX", "5XX", "TIMEOUT", "P95", "api", "102", "SLOW", "2s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected summary to contain %q:\n%s", want, out)
+		}
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "down") && !strings.Contains(line, "-") {
+			t.Errorf("Expected '-' latency for request without completions: %q", line)
+		}
+	}
+}
+
+func TestCollector_Handler(t *testing.T) {
+	c := NewCollector()
+	recordSamples(c)
+
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE drs_request_duration_seconds summary",
+		`drs_executions_total{request="api"} 102`,
+		`drs_slow_executions_total{request="api"} 1`,
+		`drs_request_duration_seconds{request="api",quantile="0.5"}`,
+		`drs_request_duration_seconds_count{request="api"} 101`,
+		`drs_executions_total{request="quoted \"name\""} 1`,
+		`drs_responses_total{request="api",class="2xx"} 101`,
+		`drs_responses_total{request="api",class="error"} 1`,
+		`drs_responses_total{request="quoted \"name\"",class="5xx"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %q:\n%s", want, body)
+		}
+	}
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Unexpected content type: %s", ct)
+	}
+}
+
+func TestCollector_WatchPressure(t *testing.T) {
+	c := NewCollector()
+	var buf strings.Builder
+	c.WriteMetrics(&buf)
+	if strings.Contains(buf.String(), "drs_dispatch") {
+		t.Errorf("Expected no dispatch gauges without a pressure source:\n%s", buf.String())
+	}
+
+	c.WatchPressure(func() engine.Pressure {
+		return engine.Pressure{Busy: 4, Runners: 4, Queued: 2, Latency: 300 * time.Millisecond, Baseline: 100 * time.Millisecond, Saturated: true, Interval: 3 * time.Second}
+	})
+	buf.Reset()
+	c.WriteMetrics(&buf)
+	for _, want := range []string{
+		"# TYPE drs_dispatch_saturated gauge",
+		"drs_dispatch_busy 4",
+		"drs_dispatch_queued 2",
+		"drs_dispatch_saturated 1",
+		"drs_dispatch_interval_seconds 3",
+		"drs_dispatch_latency_ratio 3",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected metrics to contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestCollector_WriteTimings(t *testing.T) {
+	c := NewCollector()
+	c.Record(engine.ExecutionResult{RequestName: "kafka", Duration: time.Millisecond})
+
+	var empty bytes.Buffer
+	c.WriteTimings(&empty)
+	if empty.Len() != 0 {
+		t.Errorf("Expected no timings without a breakdown, got %q", empty.String())
+	}
+
+	c.Record(engine.ExecutionResult{RequestName: "api", StatusCode: 200, Timings: &engine.Timings{
+		DNS: 2 * time.Millisecond, Connect: 4 * time.Millisecond, TTFB: 10 * time.Millisecond, Transfer: time.Millisecond,
+	}})
+	c.Record(engine.ExecutionResult{RequestName: "api", StatusCode: 200, Timings: &engine.Timings{
+		TTFB: 20 * time.Millisecond, Transfer: 3 * time.Millisecond, Reused: true,
+	}})
+
+	summary := c.Snapshot()[0]
+	want := PhaseSummary{Count: 2, DNS: time.Millisecond, Connect: 2 * time.Millisecond, TTFB: 15 * time.Millisecond, Transfer: 2 * time.Millisecond}
+	if summary.Name != "api" || summary.Phases != want {
+		t.Errorf("Expected mean phases %+v, got %+v", want, summary.Phases)
+	}
+
+	var buf bytes.Buffer
+	c.WriteTimings(&buf)
+	out := buf.String()
+	for _, want := range []string{"TTFB", "TRANSFER", "api", "15ms"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected timings to contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "kafka") {
+		t.Errorf("Expected requests without a breakdown to be left out:\n%s", out)
+	}
+}
+
+func TestCollector_WatchWarmth(t *testing.T) {
+	c := NewCollector()
+	c.WatchWarmth(func() []engine.Warmth { return nil })
+	var buf strings.Builder
+	c.WriteMetrics(&buf)
+	if strings.Contains(buf.String(), "drs_keep_warm") {
+		t.Errorf("Expected no keep-warm metrics without keep-warm requests:\n%s", buf.String())
+	}
+
+	c.WatchWarmth(func() []engine.Warmth {
+		return []engine.Warmth{
+			{Request: "keep-warm http://localhost/a", State: engine.StateCold, ColdStarts: 2},
+			{Request: "keep-warm http://localhost/b", State: engine.StateDown},
+		}
+	})
+	buf.Reset()
+	c.WriteMetrics(&buf)
+	for _, want := range []string{
+		"# TYPE drs_keep_warm_up gauge",
+		`drs_keep_warm_up{request="keep-warm http://localhost/a"} 1`,
+		`drs_keep_warm_up{request="keep-warm http://localhost/b"} 0`,
+		`drs_keep_warm_cold_starts_total{request="keep-warm http://localhost/a"} 2`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected metrics to contain %q:\n%s", want, buf.String())
+		}
+	}
+}
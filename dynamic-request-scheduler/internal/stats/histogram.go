@@ -0,0 +1,130 @@
+package stats
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// subBucketBits controls histogram precision: values are tracked exactly up to
+// 2^subBucketBits microseconds and within 1/2^(subBucketBits-1) relative error above
+const subBucketBits = 7
+
+const (
+	subBucketCount = 1 << subBucketBits
+	subBucketHalf  = subBucketCount / 2
+)
+
+// Histogram is a streaming log-linear latency histogram with microsecond
+// resolution, in the spirit of HDR histograms. Memory use is bounded by the
+// magnitude of the largest value, not the number of samples.
+type Histogram struct {
+	counts []uint64
+	total  uint64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+// NewHistogram creates an empty histogram
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+// Record adds a duration sample
+func (h *Histogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+
+	idx := bucketIndex(uint64(d / time.Microsecond))
+	if idx >= len(h.counts) {
+		grown := make([]uint64, idx+1)
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	h.counts[idx]++
+
+	if h.total == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.total++
+	h.sum += d
+}
+
+// Count returns the number of recorded samples
+func (h *Histogram) Count() uint64 { return h.total }
+
+// Sum returns the total of all recorded samples
+func (h *Histogram) Sum() time.Duration { return h.sum }
+
+// Min returns the smallest recorded sample
+func (h *Histogram) Min() time.Duration { return h.min }
+
+// Max returns the largest recorded sample
+func (h *Histogram) Max() time.Duration { return h.max }
+
+// Mean returns the average of all recorded samples
+func (h *Histogram) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.total)
+}
+
+// Quantile returns the approximate value at quantile q (0..1)
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return h.min
+	}
+	if q >= 1 {
+		return h.max
+	}
+
+	rank := uint64(math.Ceil(q * float64(h.total)))
+	var seen uint64
+	for idx, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			value := bucketValue(idx)
+			// Clamp the bucket midpoint to the observed range
+			if value < h.min {
+				return h.min
+			}
+			if value > h.max {
+				return h.max
+			}
+			return value
+		}
+	}
+	return h.max
+}
+
+// bucketIndex maps a microsecond value to its bucket
+func bucketIndex(v uint64) int {
+	if v < subBucketCount {
+		return int(v)
+	}
+	shift := bits.Len64(v) - subBucketBits
+	top := v >> uint(shift)
+	return subBucketCount + (shift-1)*subBucketHalf + int(top-subBucketHalf)
+}
+
+// bucketValue returns the midpoint of a bucket as a duration
+func bucketValue(idx int) time.Duration {
+	if idx < subBucketCount {
+		return time.Duration(idx) * time.Microsecond
+	}
+	offset := idx - subBucketCount
+	shift := uint(offset/subBucketHalf + 1)
+	top := uint64(offset%subBucketHalf + subBucketHalf)
+	lower := top << shift
+	upper := (top + 1) << shift
+	return time.Duration((lower+upper)/2) * time.Microsecond
+}
@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestHistogram_Empty(t *testing.T) {
+	h := NewHistogram()
+	if h.Count() != 0 || h.Quantile(0.5) != 0 || h.Mean() != 0 {
+		t.Error("Expected empty histogram to report zeros")
+	}
+}
+
+func TestHistogram_ExactSmallValues(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 100; i++ {
+		h.Record(time.Duration(i) * time.Microsecond)
+	}
+
+	if h.Count() != 100 {
+		t.Errorf("Expected 100 samples, got %d", h.Count())
+	}
+	if h.Min() != time.Microsecond || h.Max() != 100*time.Microsecond {
+		t.Errorf("Unexpected min/max: %v/%v", h.Min(), h.Max())
+	}
+	if got := h.Quantile(0.5); got != 50*time.Microsecond {
+		t.Errorf("Expected p50 50µs, got %v", got)
+	}
+	if got := h.Quantile(0.99); got != 99*time.Microsecond {
+		t.Errorf("Expected p99 99µs, got %v", got)
+	}
+	if got := h.Mean(); got != 50500*time.Nanosecond {
+		t.Errorf("Expected mean 50.5µs, got %v", got)
+	}
+}
+
+func TestHistogram_RelativeError(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	h := NewHistogram()
+
+	var samples []time.Duration
+	for i := 0; i < 10000; i++ {
+		d := time.Duration(rng.ExpFloat64() * float64(50*time.Millisecond))
+		samples = append(samples, d)
+		h.Record(d)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	for _, q := range []float64{0.5, 0.9, 0.95, 0.99} {
+		exact := samples[int(q*float64(len(samples)))-1]
+		got := h.Quantile(q)
+		diff := float64(got-exact) / float64(exact)
+		if diff < -0.03 || diff > 0.03 {
+			t.Errorf("p%.0f: expected ~%v, got %v (%.2f%% off)", q*100, exact, got, diff*100)
+		}
+	}
+}
+
+func TestHistogram_BucketRoundTrip(t *testing.T) {
+	for _, v := range []uint64{0, 1, 127, 128, 129, 255, 256, 1000, 123456, 3600000000} {
+		idx := bucketIndex(v)
+		mid := uint64(bucketValue(idx) / time.Microsecond)
+		if bucketIndex(mid) != idx {
+			t.Errorf("Bucket midpoint for %d (idx %d) maps to a different bucket", v, idx)
+		}
+		if v >= subBucketCount {
+			diff := float64(int64(mid)-int64(v)) / float64(v)
+			if diff < -0.02 || diff > 0.02 {
+				t.Errorf("Value %d represented as %d (%.2f%% off)", v, mid, diff*100)
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+// LoadReport aggregates every execution of a load test into a single
+// k6-style summary; per-request breakdowns come from a Collector
+type LoadReport struct {
+	mu       sync.Mutex
+	latency  *Histogram
+	total    uint64
+	failed   uint64
+	received uint64
+	classes  map[string]uint64
+}
+
+// NewLoadReport creates an empty load test report
+func NewLoadReport() *LoadReport {
+	return &LoadReport{latency: NewHistogram(), classes: make(map[string]uint64)}
+}
+
+// Record implements engine.ResultRecorder
+func (r *LoadReport) Record(result engine.ExecutionResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.total++
+	r.classes[result.StatusClass()]++
+	if !result.Success() {
+		r.failed++
+	}
+	r.received += uint64(len(result.ResponseBody))
+
+	// Only completed exchanges have a meaningful latency, as in Collector
+	if result.Error == "" {
+		r.latency.Record(result.Duration)
+	}
+	return nil
+}
+
+// FailureRate returns the fraction of executions that errored or returned a
+// non-2xx status
+func (r *LoadReport) FailureRate() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.total == 0 {
+		return 0
+	}
+	return float64(r.failed) / float64(r.total)
+}
+
+// Write prints the report for a finished load test
+func (r *LoadReport) Write(w io.Writer, result engine.LoadResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seconds := result.Duration.Seconds()
+	perSecond := func(n uint64) float64 {
+		if seconds <= 0 {
+			return 0
+		}
+		return float64(n) / seconds
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Load Test")
+	metric := func(name, format string, args ...interface{}) {
+		fmt.Fprintf(w, "  %s%s: %s\n", name, strings.Repeat(".", max(20-len(name), 2)), fmt.Sprintf(format, args...))
+	}
+
+	h := r.latency
+	if h.Count() > 0 {
+		metric("req_duration", "avg=%s min=%s med=%s max=%s p(90)=%s p(95)=%s p(99)=%s",
+			formatLatency(1, h.Mean()), formatLatency(1, h.Min()), formatLatency(1, h.Quantile(0.5)),
+			formatLatency(1, h.Max()), formatLatency(1, h.Quantile(0.9)), formatLatency(1, h.Quantile(0.95)),
+			formatLatency(1, h.Quantile(0.99)))
+	} else {
+		metric("req_duration", "no completed requests")
+	}
+
+	failedPercent := 0.0
+	if r.total > 0 {
+		failedPercent = 100 * float64(r.failed) / float64(r.total)
+	}
+	metric("req_failed", "%.2f%% %d out of %d", failedPercent, r.failed, r.total)
+	metric("reqs", "%d %.1f/s", r.total, perSecond(r.total))
+	metric("dropped_iterations", "%d %.1f/s", result.Dropped, perSecond(result.Dropped))
+	metric("data_received", "%s %s/s", formatBytes(float64(r.received)), formatBytes(perSecond(r.received)))
+	metric("in_flight_max", "%d", result.MaxInFlight)
+
+	var classes []string
+	for _, class := range engine.StatusClasses {
+		if count := r.classes[class]; count > 0 {
+			classes = append(classes, fmt.Sprintf("%s=%d", class, count))
+		}
+	}
+	if len(classes) > 0 {
+		metric("status", "%s", strings.Join(classes, " "))
+	}
+	metric("duration", "%s", result.Duration.Round(time.Millisecond))
+}
+
+// formatBytes renders a byte count in decimal units, as k6 does
+func formatBytes(n float64) string {
+	units := []string{"B", "kB", "MB", "GB"}
+	unit := 0
+	for n >= 1000 && unit < len(units)-1 {
+		n /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%.0f %s", n, units[unit])
+	}
+	return fmt.Sprintf("%.1f %s", n, units[unit])
+}
@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+func TestLoadReport_Write(t *testing.T) {
+	report := NewLoadReport()
+	for i := 1; i <= 100; i++ {
+		report.Record(engine.ExecutionResult{StatusCode: 200, Duration: time.Duration(i) * time.Millisecond, ResponseBody: make([]byte, 100)})
+	}
+	report.Record(engine.ExecutionResult{StatusCode: 503, Duration: time.Second})
+	report.Record(engine.ExecutionResult{Error: "connection refused", Duration: time.Hour})
+
+	if rate := report.FailureRate(); rate < 0.0196 || rate > 0.0197 {
+		t.Errorf("Expected 2 of 102 to fail, got %v", rate)
+	}
+
+	var buf bytes.Buffer
+	report.Write(&buf, engine.LoadResult{Duration: 10 * time.Second, Started: 102, Dropped: 5, MaxInFlight: 7})
+	out := buf.String()
+
+	for _, want := range []string{
+		"req_duration........: avg=59.9ms min=1ms med=50.9ms max=1s p(90)=90.6ms p(95)=95.7ms p(99)=99.8ms",
+		"req_failed..........: 1.96% 2 out of 102",
+		"reqs................: 102 10.2/s",
+		"dropped_iterations..: 5 0.5/s",
+		"data_received.......: 10.0 kB 1.0 kB/s",
+		"in_flight_max.......: 7",
+		"status..............: 2xx=100 5xx=1 error=1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in report:\n%s", want, out)
+		}
+	}
+}
+
+func TestLoadReport_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	NewLoadReport().Write(&buf, engine.LoadResult{})
+	if !strings.Contains(buf.String(), "req_duration........: no completed requests") {
+		t.Errorf("Unexpected empty report:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,127 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// ObjectiveSuccess is the SLOResult objective for the success rate; the
+// others are latency percentiles
+const ObjectiveSuccess = "success"
+
+// SLOResult is whether a request met one objective of its SLO over the run
+type SLOResult struct {
+	Request string
+
+	// Objective is ObjectiveSuccess or a latency percentile such as p95
+	Objective string
+
+	// Target and Actual describe the objective and what the run measured,
+	// such as ">= 99%" and "98.50%"; Actual is "-" without executions to
+	// measure
+	Target string
+	Actual string
+	Met    bool
+}
+
+// EvaluateSLOs checks the SLO of each request that declares one against its
+// statistics over the run, in request order. A request without executions,
+// or without completed executions for a latency objective, misses it.
+func EvaluateSLOs(requests []spec.ScheduledRequest, summaries []RequestSummary) []SLOResult {
+	byName := make(map[string]RequestSummary, len(summaries))
+	for _, s := range summaries {
+		byName[s.Name] = s
+	}
+
+	var results []SLOResult
+	for _, req := range requests {
+		if req.SLO == nil {
+			continue
+		}
+		summary := byName[req.Name]
+
+		if req.SLO.Success != nil {
+			result := SLOResult{
+				Request:   req.Name,
+				Objective: ObjectiveSuccess,
+				Target:    fmt.Sprintf(">= %v%%", *req.SLO.Success),
+				Actual:    "-",
+			}
+			if summary.Executions > 0 {
+				rate := 100 * float64(summary.Executions-summary.Failures) / float64(summary.Executions)
+				result.Actual = fmt.Sprintf("%.2f%%", rate)
+				result.Met = rate >= *req.SLO.Success
+			}
+			results = append(results, result)
+		}
+
+		for _, percentile := range spec.SLOPercentiles {
+			target, ok := req.SLO.LatencyTarget(percentile)
+			if !ok {
+				continue
+			}
+			result := SLOResult{
+				Request:   req.Name,
+				Objective: percentile,
+				Target:    "< " + target.String(),
+				Actual:    "-",
+			}
+			if latency := summary.Latency; latency.Count > 0 {
+				actual := latency.percentile(percentile)
+				result.Actual = formatLatency(latency.Count, actual)
+				result.Met = actual < target
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// percentile returns the latency at one of spec.SLOPercentiles
+func (l LatencySummary) percentile(name string) time.Duration {
+	switch name {
+	case "p50":
+		return l.P50
+	case "p90":
+		return l.P90
+	case "p95":
+		return l.P95
+	default:
+		return l.P99
+	}
+}
+
+// SLOMissed counts the objectives that were not met
+func SLOMissed(results []SLOResult) int {
+	missed := 0
+	for _, result := range results {
+		if !result.Met {
+			missed++
+		}
+	}
+	return missed
+}
+
+// WriteSLOReport prints whether each SLO objective was met
+func WriteSLOReport(w io.Writer, results []SLOResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "SLO")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REQUEST\tOBJECTIVE\tTARGET\tACTUAL\tRESULT")
+	for _, r := range results {
+		verdict := "met"
+		if !r.Met {
+			verdict = "MISSED"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Request, r.Objective, r.Target, r.Actual, verdict)
+	}
+	tw.Flush()
+}
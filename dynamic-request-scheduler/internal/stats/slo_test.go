@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestEvaluateSLOs(t *testing.T) {
+	c := NewCollector()
+	recordSamples(c)
+
+	met, missed := 99.0, 99.5
+	requests := []spec.ScheduledRequest{
+		{Name: "api", SLO: &spec.SLOSpec{Success: &met, Latency: map[string]string{"p99": "50ms", "p50": "100ms"}}},
+		{Name: "unchecked"},
+		{Name: "api", SLO: &spec.SLOSpec{Success: &missed}},
+		{Name: "idle", SLO: &spec.SLOSpec{Latency: map[string]string{"p95": "1s"}}},
+	}
+	results := EvaluateSLOs(requests, c.Snapshot())
+
+	// api had 1 failure in 102 executions and a p50 of ~50ms
+	expected := []SLOResult{
+		{Request: "api", Objective: ObjectiveSuccess, Target: ">= 99%", Actual: "99.02%", Met: true},
+		{Request: "api", Objective: "p50", Target: "< 100ms", Met: true},
+		{Request: "api", Objective: "p99", Target: "< 50ms", Met: false},
+		{Request: "api", Objective: ObjectiveSuccess, Target: ">= 99.5%", Actual: "99.02%", Met: false},
+		{Request: "idle", Objective: "p95", Target: "< 1s", Actual: "-", Met: false},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %+v", len(expected), results)
+	}
+	for i, want := range expected {
+		got := results[i]
+		if want.Actual == "" {
+			want.Actual = got.Actual
+		}
+		if got != want {
+			t.Errorf("Result %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+	if missed := SLOMissed(results); missed != 3 {
+		t.Errorf("Expected 3 missed objectives, got %d", missed)
+	}
+}
+
+func TestWriteSLOReport(t *testing.T) {
+	var buf bytes.Buffer
+	WriteSLOReport(&buf, nil)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no report without SLOs, got %q", buf.String())
+	}
+
+	WriteSLOReport(&buf, []SLOResult{
+		{Request: "api", Objective: ObjectiveSuccess, Target: ">= 99%", Actual: "99.50%", Met: true},
+		{Request: "api", Objective: "p95", Target: "< 200ms", Actual: "231ms", Met: false},
+	})
+	out := buf.String()
+	for _, want := range []string{"SLO", "REQUEST", "OBJECTIVE", "99.50%", "met", "231ms", "MISSED"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected report to contain %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,448 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+	"local-dev-tools/dynamic-request-scheduler/internal/stats"
+)
+
+const (
+	// historySize is the number of recent executions used for rolling stats
+	historySize = 50
+	// logLines is the number of log lines shown beneath the table
+	logLines = 8
+	// recentLines is the number of recent executions of the selected
+	// request shown beneath the table
+	recentLines = 5
+	// refreshInterval controls how often the screen is redrawn
+	refreshInterval = 500 * time.Millisecond
+)
+
+// Controller is the subset of scheduler operations driven by the dashboard
+type Controller interface {
+	Statuses() []engine.RequestStatus
+	Pressure() engine.Pressure
+	Recent(name string) ([]engine.RecentExecution, error)
+	Pause(name string) error
+	Resume(name string) error
+	IsPaused(name string) bool
+	Trigger(name string) error
+	Stop()
+}
+
+// Dashboard renders a live table of request state and handles keybindings
+type Dashboard struct {
+	out  io.Writer
+	logs *logBuffer
+
+	mu       sync.Mutex
+	stats    map[string]*requestStats
+	selected int
+	message  string
+
+	closeOnce sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+// requestStats keeps a rolling window of recent executions for one request
+type requestStats struct {
+	lastStatus string
+	total      int
+	recent     []sample
+	latency    *stats.Histogram
+}
+
+type sample struct {
+	success bool
+	latency time.Duration
+}
+
+// New creates a dashboard writing to out
+func New(out io.Writer) *Dashboard {
+	return &Dashboard{
+		out:     out,
+		logs:    &logBuffer{max: logLines},
+		stats:   make(map[string]*requestStats),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// LogWriter returns a writer that captures log output for display in the dashboard
+func (d *Dashboard) LogWriter() io.Writer {
+	return d.logs
+}
+
+// Record implements engine.ResultRecorder
+func (d *Dashboard) Record(result engine.ExecutionResult) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	rs, ok := d.stats[result.RequestName]
+	if !ok {
+		rs = &requestStats{latency: stats.NewHistogram()}
+		d.stats[result.RequestName] = rs
+	}
+
+	rs.total++
+	if result.Error != "" {
+		rs.lastStatus = "ERR"
+	} else {
+		rs.lastStatus = fmt.Sprintf("%d", result.StatusCode)
+		rs.latency.Record(result.Duration)
+	}
+
+	rs.recent = append(rs.recent, sample{success: result.Success(), latency: result.Duration})
+	if len(rs.recent) > historySize {
+		rs.recent = rs.recent[len(rs.recent)-historySize:]
+	}
+	return nil
+}
+
+// Run puts the terminal into raw mode and drives the dashboard until Close is
+// called or the user quits. Quitting stops the controller.
+func (d *Dashboard) Run(ctrl Controller, in *os.File) error {
+	defer close(d.stopped)
+
+	fd := int(in.Fd())
+	if term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("failed to enter raw mode: %w", err)
+		}
+		defer term.Restore(fd, state)
+	}
+
+	// Hide the cursor while the dashboard owns the screen
+	fmt.Fprint(d.out, "\x1b[?25l")
+	defer fmt.Fprint(d.out, "\x1b[?25h\r\n")
+
+	keys := make(chan []byte)
+	go readKeys(in, keys)
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	d.render(ctrl)
+	for {
+		select {
+		case <-d.done:
+			return nil
+		case key := <-keys:
+			if quit := d.handleKey(ctrl, key); quit {
+				ctrl.Stop()
+				return nil
+			}
+			d.render(ctrl)
+		case <-ticker.C:
+			d.render(ctrl)
+		}
+	}
+}
+
+// Close stops the dashboard and waits for the terminal to be restored
+func (d *Dashboard) Close() {
+	d.closeOnce.Do(func() { close(d.done) })
+	<-d.stopped
+}
+
+// readKeys forwards raw key presses from in until it is closed
+func readKeys(in io.Reader, keys chan<- []byte) {
+	buf := make([]byte, 16)
+	for {
+		n, err := in.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, key := range splitKeys(buf[:n]) {
+			keys <- key
+		}
+	}
+}
+
+// splitKeys breaks a read into individual key presses, keeping arrow-key
+// escape sequences intact
+func splitKeys(data []byte) [][]byte {
+	var keys [][]byte
+	for len(data) > 0 {
+		size := 1
+		if data[0] == 0x1b && len(data) >= 3 && data[1] == '[' {
+			size = 3
+		}
+		key := make([]byte, size)
+		copy(key, data[:size])
+		keys = append(keys, key)
+		data = data[size:]
+	}
+	return keys
+}
+
+// handleKey applies a key press and reports whether the user asked to quit
+func (d *Dashboard) handleKey(ctrl Controller, key []byte) bool {
+	statuses := ctrl.Statuses()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(statuses) > 0 && d.selected >= len(statuses) {
+		d.selected = len(statuses) - 1
+	}
+
+	switch string(key) {
+	case "q", "\x03":
+		return true
+	case "k", "\x1b[A":
+		if d.selected > 0 {
+			d.selected--
+		}
+	case "j", "\x1b[B":
+		if d.selected < len(statuses)-1 {
+			d.selected++
+		}
+	case "p", " ":
+		if len(statuses) == 0 {
+			return false
+		}
+		name := statuses[d.selected].Name
+		if ctrl.IsPaused(name) {
+			d.message = resultMessage(ctrl.Resume(name), "Resumed "+name)
+		} else {
+			d.message = resultMessage(ctrl.Pause(name), "Paused "+name)
+		}
+	case "t", "\r":
+		if len(statuses) == 0 {
+			return false
+		}
+		name := statuses[d.selected].Name
+		d.message = resultMessage(ctrl.Trigger(name), "Triggered "+name)
+	}
+	return false
+}
+
+func resultMessage(err error, success string) string {
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return success
+}
+
+// render redraws the whole screen
+func (d *Dashboard) render(ctrl Controller) {
+	statuses := ctrl.Statuses()
+	d.mu.Lock()
+	selected := d.selected
+	d.mu.Unlock()
+
+	var recent []engine.RecentExecution
+	if selected < len(statuses) {
+		recent, _ = ctrl.Recent(statuses[selected].Name)
+	}
+	frame := d.frame(statuses, ctrl.Pressure(), recent, time.Now())
+	fmt.Fprint(d.out, "\x1b[H\x1b[2J"+frame)
+}
+
+// frame builds the dashboard contents for the given statuses, dispatch load
+// and recent executions of the selected request
+func (d *Dashboard) frame(statuses []engine.RequestStatus, pressure engine.Pressure, recent []engine.RecentExecution, now time.Time) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&b, format, args...)
+		b.WriteString("\x1b[K\r\n")
+	}
+
+	nameWidth := len("REQUEST")
+	for _, status := range statuses {
+		if len(status.Name) > nameWidth {
+			nameWidth = len(status.Name)
+		}
+	}
+
+	line("Dynamic Request Scheduler — %s", now.Format("15:04:05"))
+	line("%s", formatPressure(pressure))
+	line("")
+	line("  %-*s  %-12s  %-6s  %-8s  %-9s  %-9s  %-9s  %-4s  %s",
+		nameWidth, "REQUEST", "NEXT RUN", "LAST", "SUCCESS", "LATENCY", "P50", "P95", "RUNS", "TREND")
+
+	for i, status := range statuses {
+		cursor := " "
+		if i == d.selected {
+			cursor = ">"
+		}
+
+		last, success, latency, p50, p95, runs, trend := "-", "-", "-", "-", "-", 0, ""
+		if rs, ok := d.stats[status.Name]; ok && len(rs.recent) > 0 {
+			last = rs.lastStatus
+			success = fmt.Sprintf("%.1f%%", successRate(rs.recent))
+			latency = rs.recent[len(rs.recent)-1].latency.Round(time.Millisecond).String()
+			runs = rs.total
+			trend = sparkline(rs.recent)
+			if rs.latency.Count() > 0 {
+				p50 = rs.latency.Quantile(0.5).Round(time.Millisecond).String()
+				p95 = rs.latency.Quantile(0.95).Round(time.Millisecond).String()
+			}
+		}
+
+		line("%s %-*s  %-12s  %-6s  %-8s  %-9s  %-9s  %-9s  %-4d  %s",
+			cursor, nameWidth, status.Name, formatNextRun(status, now), last, success, latency, p50, p95, runs, trend)
+	}
+
+	if d.selected < len(statuses) {
+		line("")
+		line("Recent executions of %s:", statuses[d.selected].Name)
+		if len(recent) == 0 {
+			line("  none yet")
+		}
+		for i, execution := range recent {
+			if i == recentLines {
+				break
+			}
+			line("  %s", formatRecent(execution))
+		}
+	}
+
+	line("")
+	line("[↑/↓ j/k] select  [p] pause/resume  [t] trigger  [q] quit")
+	if d.message != "" {
+		line("%s", d.message)
+	} else {
+		line("")
+	}
+	line("")
+	for _, l := range d.logs.Lines() {
+		line("%s", l)
+	}
+
+	return b.String()
+}
+
+// formatPressure describes the dispatch load, calling out saturation
+func formatPressure(p engine.Pressure) string {
+	load := fmt.Sprintf("%d/%d runners busy, %d queued", p.Busy, p.Runners, p.Queued)
+	if !p.Saturated {
+		return "Dispatch: " + load
+	}
+	if ratio := p.LatencyRatio(); ratio > 0 {
+		load += fmt.Sprintf(", latency %.1fx baseline", ratio)
+	}
+	return fmt.Sprintf("Dispatch: SATURATED — %s; dispatching every %v", load, p.Interval.Round(100*time.Millisecond))
+}
+
+// formatNextRun describes when a request will next fire
+func formatNextRun(status engine.RequestStatus, now time.Time) string {
+	switch {
+	case status.Paused:
+		return "paused"
+	case status.InFlight > 0:
+		return "running"
+	case status.NextRun.IsZero():
+		return "-"
+	case !status.NextRun.After(now):
+		return "due"
+	default:
+		return "in " + status.NextRun.Sub(now).Round(time.Second).String()
+	}
+}
+
+// formatRecent describes one recent execution: when it started, its status,
+// latency and why it failed
+func formatRecent(execution engine.RecentExecution) string {
+	status := execution.StatusClass
+	if execution.StatusCode != 0 {
+		status = fmt.Sprintf("%d", execution.StatusCode)
+	}
+	text := fmt.Sprintf("%s  %-7s  %-9s", execution.StartedAt.Format("15:04:05"), status,
+		execution.Duration.Round(time.Millisecond))
+	if execution.Reason != "" {
+		text += "  " + execution.Reason
+	}
+	return text
+}
+
+// successRate returns the percentage of successful samples
+func successRate(samples []sample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	ok := 0
+	for _, s := range samples {
+		if s.success {
+			ok++
+		}
+	}
+	return float64(ok) * 100 / float64(len(samples))
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders sample latencies scaled between their min and max
+func sparkline(samples []sample) string {
+	if len(samples) == 0 {
+		return ""
+	}
+
+	min, max := samples[0].latency, samples[0].latency
+	for _, s := range samples {
+		if s.latency < min {
+			min = s.latency
+		}
+		if s.latency > max {
+			max = s.latency
+		}
+	}
+
+	var b strings.Builder
+	for _, s := range samples {
+		idx := 0
+		if max > min {
+			idx = int(float64(s.latency-min) / float64(max-min) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[idx])
+	}
+	return b.String()
+}
+
+// logBuffer keeps the most recent log lines for display
+type logBuffer struct {
+	mu      sync.Mutex
+	max     int
+	lines   []string
+	partial string
+}
+
+// Write implements io.Writer, splitting input into lines
+func (l *logBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	text := l.partial + string(p)
+	parts := strings.Split(text, "\n")
+	l.partial = parts[len(parts)-1]
+
+	l.lines = append(l.lines, parts[:len(parts)-1]...)
+	if len(l.lines) > l.max {
+		l.lines = l.lines[len(l.lines)-l.max:]
+	}
+	return len(p), nil
+}
+
+// Lines returns a copy of the buffered lines
+func (l *logBuffer) Lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lines := make([]string, len(l.lines))
+	copy(lines, l.lines)
+	return lines
+}
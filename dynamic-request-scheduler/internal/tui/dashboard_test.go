@@ -0,0 +1,217 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+// fakeController records control actions issued by the dashboard
+type fakeController struct {
+	statuses  []engine.RequestStatus
+	paused    map[string]bool
+	triggered []string
+	stopped   bool
+}
+
+func (f *fakeController) Statuses() []engine.RequestStatus { return f.statuses }
+func (f *fakeController) Pressure() engine.Pressure        { return engine.Pressure{} }
+func (f *fakeController) IsPaused(name string) bool        { return f.paused[name] }
+func (f *fakeController) Stop()                            { f.stopped = true }
+
+func (f *fakeController) Recent(name string) ([]engine.RecentExecution, error) {
+	return nil, nil
+}
+
+func (f *fakeController) Pause(name string) error {
+	f.paused[name] = true
+	return nil
+}
+
+func (f *fakeController) Resume(name string) error {
+	f.paused[name] = false
+	return nil
+}
+
+func (f *fakeController) Trigger(name string) error {
+	if name == "broken" {
+		return fmt.Errorf("cannot trigger")
+	}
+	f.triggered = append(f.triggered, name)
+	return nil
+}
+
+func newFakeController(names ...string) *fakeController {
+	ctrl := &fakeController{paused: make(map[string]bool)}
+	for _, name := range names {
+		ctrl.statuses = append(ctrl.statuses, engine.RequestStatus{Name: name})
+	}
+	return ctrl
+}
+
+func TestDashboard_HandleKey(t *testing.T) {
+	ctrl := newFakeController("first", "second", "broken")
+	d := New(&bytes.Buffer{})
+
+	d.handleKey(ctrl, []byte("j"))
+	d.handleKey(ctrl, []byte("p"))
+	if !ctrl.paused["second"] {
+		t.Error("Expected second request to be paused")
+	}
+
+	d.handleKey(ctrl, []byte("p"))
+	if ctrl.paused["second"] {
+		t.Error("Expected second request to be resumed")
+	}
+
+	d.handleKey(ctrl, []byte("\x1b[A"))
+	d.handleKey(ctrl, []byte("k"))
+	d.handleKey(ctrl, []byte("t"))
+	if len(ctrl.triggered) != 1 || ctrl.triggered[0] != "first" {
+		t.Errorf("Expected first request to be triggered, got %v", ctrl.triggered)
+	}
+
+	d.handleKey(ctrl, []byte("\x1b[B"))
+	d.handleKey(ctrl, []byte("\x1b[B"))
+	d.handleKey(ctrl, []byte("\x1b[B"))
+	d.handleKey(ctrl, []byte("t"))
+	if !strings.HasPrefix(d.message, "Error:") {
+		t.Errorf("Expected trigger error message, got %q", d.message)
+	}
+
+	if quit := d.handleKey(ctrl, []byte("x")); quit {
+		t.Error("Unknown key should not quit")
+	}
+	if quit := d.handleKey(ctrl, []byte("q")); !quit {
+		t.Error("Expected q to quit")
+	}
+	if quit := d.handleKey(ctrl, []byte("\x03")); !quit {
+		t.Error("Expected Ctrl-C to quit")
+	}
+}
+
+func TestDashboard_Frame(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	d := New(&bytes.Buffer{})
+
+	d.Record(engine.ExecutionResult{RequestName: "health", StatusCode: 200, Duration: 10 * time.Millisecond})
+	d.Record(engine.ExecutionResult{RequestName: "health", StatusCode: 500, Duration: 30 * time.Millisecond})
+	d.Record(engine.ExecutionResult{RequestName: "health", StatusCode: 200, Duration: 20 * time.Millisecond})
+	d.Record(engine.ExecutionResult{RequestName: "sync", Error: "refused"})
+	fmt.Fprintln(d.LogWriter(), "a log line")
+
+	statuses := []engine.RequestStatus{
+		{Name: "health", NextRun: now.Add(90 * time.Second)},
+		{Name: "sync", Paused: true},
+		{Name: "idle"},
+	}
+
+	recent := []engine.RecentExecution{
+		{StartedAt: now.Add(-time.Minute), StatusCode: 500, StatusClass: engine.Class5xx, Duration: 30 * time.Millisecond, Reason: "HTTP 500 Internal Server Error"},
+		{StartedAt: now.Add(-2 * time.Minute), StatusClass: engine.ClassError, Duration: time.Millisecond, Reason: "connection refused"},
+	}
+	frame := d.frame(statuses, engine.Pressure{Busy: 1, Runners: 10}, recent, now)
+
+	for _, want := range []string{"1/10 runners busy, 0 queued", "health", "in 1m30s", "200", "66.7%", "20ms", "30ms", "▁█▄", "paused", "ERR", "a log line",
+		"Recent executions of health:", "11:59:00  500      30ms       HTTP 500 Internal Server Error", "11:58:00  error    1ms        connection refused"} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("Expected frame to contain %q:\n%s", want, frame)
+		}
+	}
+}
+
+func TestFormatPressure(t *testing.T) {
+	if got := formatPressure(engine.Pressure{Busy: 2, Runners: 10}); got != "Dispatch: 2/10 runners busy, 0 queued" {
+		t.Errorf("Unexpected pressure line %q", got)
+	}
+
+	saturated := engine.Pressure{Busy: 10, Runners: 10, Queued: 3, Latency: 310 * time.Millisecond, Baseline: 100 * time.Millisecond, Saturated: true, Interval: 3100 * time.Millisecond}
+	want := "Dispatch: SATURATED — 10/10 runners busy, 3 queued, latency 3.1x baseline; dispatching every 3.1s"
+	if got := formatPressure(saturated); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestFormatNextRun(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		status engine.RequestStatus
+		want   string
+	}{
+		{engine.RequestStatus{Paused: true, NextRun: now.Add(time.Minute)}, "paused"},
+		{engine.RequestStatus{InFlight: 1}, "running"},
+		{engine.RequestStatus{}, "-"},
+		{engine.RequestStatus{NextRun: now.Add(-time.Second)}, "due"},
+		{engine.RequestStatus{NextRun: now.Add(5 * time.Second)}, "in 5s"},
+	}
+
+	for _, tt := range tests {
+		if got := formatNextRun(tt.status, now); got != tt.want {
+			t.Errorf("formatNextRun(%+v) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	samples := []sample{{latency: 1}, {latency: 5}, {latency: 9}}
+	if got := sparkline(samples); got != "▁▄█" {
+		t.Errorf("Expected ▁▄█, got %q", got)
+	}
+
+	flat := []sample{{latency: 3}, {latency: 3}}
+	if got := sparkline(flat); got != "▁▁" {
+		t.Errorf("Expected flat sparkline, got %q", got)
+	}
+
+	if got := sparkline(nil); got != "" {
+		t.Errorf("Expected empty sparkline, got %q", got)
+	}
+}
+
+func TestRecord_RollingWindow(t *testing.T) {
+	d := New(&bytes.Buffer{})
+	for i := 0; i < historySize+10; i++ {
+		d.Record(engine.ExecutionResult{RequestName: "r", StatusCode: 200})
+	}
+
+	rs := d.stats["r"]
+	if len(rs.recent) != historySize {
+		t.Errorf("Expected %d samples, got %d", historySize, len(rs.recent))
+	}
+	if rs.total != historySize+10 {
+		t.Errorf("Expected total %d, got %d", historySize+10, rs.total)
+	}
+	if rs.latency.Count() != historySize+10 {
+		t.Errorf("Expected histogram to keep all %d samples, got %d", historySize+10, rs.latency.Count())
+	}
+}
+
+func TestLogBuffer(t *testing.T) {
+	buf := &logBuffer{max: 2}
+	fmt.Fprint(buf, "one\ntwo\nthr")
+	fmt.Fprint(buf, "ee\n")
+
+	lines := buf.Lines()
+	if len(lines) != 2 || lines[0] != "two" || lines[1] != "three" {
+		t.Errorf("Unexpected lines: %v", lines)
+	}
+}
+
+func TestSplitKeys(t *testing.T) {
+	keys := splitKeys([]byte("jq\x1b[A\x1bp"))
+
+	want := []string{"j", "q", "\x1b[A", "\x1b", "p"}
+	if len(keys) != len(want) {
+		t.Fatalf("Expected %d keys, got %d: %q", len(want), len(keys), keys)
+	}
+	for i, key := range keys {
+		if string(key) != want[i] {
+			t.Errorf("Key %d: expected %q, got %q", i, want[i], key)
+		}
+	}
+}
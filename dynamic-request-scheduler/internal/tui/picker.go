@@ -0,0 +1,224 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// pickerRows is the maximum number of matching items shown at once
+const pickerRows = 15
+
+// PickItem is one entry offered by the picker
+type PickItem struct {
+	Name   string
+	Detail string
+}
+
+// Picker is an fzf-style selector: typing narrows the list by fuzzy match on
+// the item name, tab marks items, and enter returns the marked items (or the
+// highlighted one when nothing is marked).
+type Picker struct {
+	out      io.Writer
+	items    []PickItem
+	query    string
+	cursor   int
+	selected map[int]bool
+	matches  []int
+}
+
+// NewPicker creates a picker over items, keeping their order for ties
+func NewPicker(out io.Writer, items []PickItem) *Picker {
+	p := &Picker{out: out, items: items, selected: make(map[int]bool)}
+	p.filter()
+	return p
+}
+
+// Run puts the terminal into raw mode and lets the user choose items. It
+// returns the chosen names in item order, or nil if the user cancelled.
+func (p *Picker) Run(in *os.File) ([]string, error) {
+	fd := int(in.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("the picker requires an interactive terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+	defer term.Restore(fd, state)
+	defer fmt.Fprint(p.out, "\x1b[H\x1b[2J")
+
+	keys := make(chan []byte)
+	go readKeys(in, keys)
+
+	p.render()
+	for key := range keys {
+		done, cancelled := p.handleKey(key)
+		switch {
+		case cancelled:
+			return nil, nil
+		case done:
+			return p.chosen(), nil
+		}
+		p.render()
+	}
+	return nil, nil
+}
+
+// handleKey applies a key press and reports whether the user confirmed or
+// cancelled the selection
+func (p *Picker) handleKey(key []byte) (done, cancelled bool) {
+	switch string(key) {
+	case "\x1b", "\x03":
+		return false, true
+	case "\r", "\n":
+		return len(p.chosen()) > 0, false
+	case "\x1b[A", "\x10":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case "\x1b[B", "\x0e":
+		if p.cursor < len(p.matches)-1 {
+			p.cursor++
+		}
+	case "\t":
+		if len(p.matches) > 0 {
+			idx := p.matches[p.cursor]
+			if p.selected[idx] {
+				delete(p.selected, idx)
+			} else {
+				p.selected[idx] = true
+			}
+			if p.cursor < len(p.matches)-1 {
+				p.cursor++
+			}
+		}
+	case "\x7f", "\x08":
+		if p.query != "" {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case "\x15":
+		p.query = ""
+		p.filter()
+	default:
+		// Only printable ASCII extends the query; other escape sequences are ignored
+		if len(key) == 1 && key[0] >= 0x20 && key[0] < 0x7f {
+			p.query += string(key)
+			p.filter()
+		}
+	}
+	return false, false
+}
+
+// chosen returns the marked item names in item order, falling back to the
+// highlighted item when nothing is marked
+func (p *Picker) chosen() []string {
+	var names []string
+	for i, item := range p.items {
+		if p.selected[i] {
+			names = append(names, item.Name)
+		}
+	}
+	if len(names) == 0 && len(p.matches) > 0 {
+		names = append(names, p.items[p.matches[p.cursor]].Name)
+	}
+	return names
+}
+
+// filter recomputes the matching items for the current query, best match first
+func (p *Picker) filter() {
+	type scored struct {
+		index int
+		score int
+	}
+
+	var hits []scored
+	for i, item := range p.items {
+		if score, ok := fuzzyScore(p.query, item.Name); ok {
+			hits = append(hits, scored{i, score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+
+	p.matches = p.matches[:0]
+	for _, hit := range hits {
+		p.matches = append(p.matches, hit.index)
+	}
+	p.cursor = 0
+}
+
+// fuzzyScore reports whether every character of query appears in target in
+// order, ignoring case. Consecutive characters and characters at the start of
+// a word score higher so tighter matches sort first.
+func fuzzyScore(query, target string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(target))
+
+	score, qi, last := 0, 0, -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if ti == last+1 {
+			score += 5
+		}
+		if ti == 0 || strings.ContainsRune(" -_./:", t[ti-1]) {
+			score += 3
+		}
+		last = ti
+		qi++
+	}
+	return score, qi == len(q)
+}
+
+// render redraws the whole picker
+func (p *Picker) render() {
+	fmt.Fprint(p.out, "\x1b[H\x1b[2J"+p.frame())
+}
+
+// frame builds the picker contents
+func (p *Picker) frame() string {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&b, format, args...)
+		b.WriteString("\x1b[K\r\n")
+	}
+
+	nameWidth := 0
+	for _, item := range p.items {
+		if len(item.Name) > nameWidth {
+			nameWidth = len(item.Name)
+		}
+	}
+
+	line("Pick requests to run — [type] filter  [↑/↓] move  [tab] mark  [enter] run  [esc] cancel")
+	line("> %s", p.query)
+	line("")
+
+	// Keep the cursor inside the visible window
+	start := 0
+	if p.cursor >= pickerRows {
+		start = p.cursor - pickerRows + 1
+	}
+	for i := start; i < len(p.matches) && i < start+pickerRows; i++ {
+		idx := p.matches[i]
+		cursor, mark := " ", "[ ]"
+		if i == p.cursor {
+			cursor = ">"
+		}
+		if p.selected[idx] {
+			mark = "[x]"
+		}
+		line("%s %s %-*s  %s", cursor, mark, nameWidth, p.items[idx].Name, p.items[idx].Detail)
+	}
+
+	line("")
+	line("%d/%d matching, %d marked", len(p.matches), len(p.items), len(p.selected))
+	return b.String()
+}
@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("usr", "get-users"); !ok {
+		t.Error("Expected subsequence to match")
+	}
+	if _, ok := fuzzyScore("USR", "get-users"); !ok {
+		t.Error("Expected matching to ignore case")
+	}
+	if _, ok := fuzzyScore("sru", "get-users"); ok {
+		t.Error("Expected out-of-order characters not to match")
+	}
+	if score, ok := fuzzyScore("", "anything"); !ok || score != 0 {
+		t.Errorf("Expected empty query to match with zero score, got %d %v", score, ok)
+	}
+
+	tight, _ := fuzzyScore("user", "get-users")
+	loose, _ := fuzzyScore("user", "update-subscriber")
+	if tight <= loose {
+		t.Errorf("Expected consecutive match to score higher: %d <= %d", tight, loose)
+	}
+}
+
+func pickerKeys(p *Picker, keys ...string) (done, cancelled bool) {
+	for _, key := range keys {
+		done, cancelled = p.handleKey([]byte(key))
+	}
+	return done, cancelled
+}
+
+func TestPicker_FilterAndChoose(t *testing.T) {
+	p := NewPicker(io.Discard, []PickItem{
+		{Name: "update-subscriber"},
+		{Name: "health"},
+		{Name: "get-users"},
+	})
+
+	pickerKeys(p, "u", "s", "e", "r")
+	if want := []int{2, 0}; !reflect.DeepEqual(p.matches, want) {
+		t.Fatalf("Expected best match first, got %v", p.matches)
+	}
+
+	// Enter without marks runs the highlighted item
+	if done, _ := pickerKeys(p, "\r"); !done {
+		t.Fatal("Expected enter to confirm")
+	}
+	if got := p.chosen(); !reflect.DeepEqual(got, []string{"get-users"}) {
+		t.Errorf("Expected highlighted item, got %v", got)
+	}
+
+	// Marked items are returned in config order regardless of match order
+	pickerKeys(p, "\t", "\t")
+	if got := p.chosen(); !reflect.DeepEqual(got, []string{"update-subscriber", "get-users"}) {
+		t.Errorf("Expected marked items in config order, got %v", got)
+	}
+
+	// Marks survive changing the query
+	pickerKeys(p, "\x15")
+	if len(p.matches) != 3 || len(p.chosen()) != 2 {
+		t.Errorf("Expected cleared query to show all items with marks kept, got %v %v", p.matches, p.chosen())
+	}
+	if !strings.Contains(p.frame(), "3/3 matching, 2 marked") {
+		t.Errorf("Unexpected frame:\n%s", p.frame())
+	}
+}
+
+func TestPicker_Keys(t *testing.T) {
+	p := NewPicker(io.Discard, []PickItem{{Name: "a"}, {Name: "b"}})
+
+	pickerKeys(p, "\x1b[B", "\x1b[B")
+	if p.cursor != 1 {
+		t.Errorf("Expected cursor to stop at last item, got %d", p.cursor)
+	}
+	pickerKeys(p, "\x10")
+	if p.cursor != 0 {
+		t.Errorf("Expected ctrl-p to move up, got %d", p.cursor)
+	}
+
+	pickerKeys(p, "z", "\x7f", "\x7f")
+	if p.query != "" || len(p.matches) != 2 {
+		t.Errorf("Expected backspace to restore matches, got %q %v", p.query, p.matches)
+	}
+
+	// Enter with no matches is ignored
+	if done, _ := pickerKeys(p, "z", "\r"); done {
+		t.Error("Expected enter with nothing to run to be ignored")
+	}
+	if _, cancelled := pickerKeys(p, "\x1b"); !cancelled {
+		t.Error("Expected escape to cancel")
+	}
+}
@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+// progressWidth is the number of cells in the progress bar
+const progressWidth = 30
+
+// Progress reports completion of a fixed set of executions, as used by --once.
+// On a terminal it keeps a single redrawn bar below the log output; otherwise
+// it prints a plain line at every 10% of completion.
+type Progress struct {
+	mu          sync.Mutex
+	out         io.Writer
+	interactive bool
+	total       int
+	concurrency int
+	started     time.Time
+	completed   int
+	failed      int
+	busy        time.Duration
+	lastDecile  int
+	drawn       bool
+}
+
+// NewProgress creates a progress reporter for total executions running with
+// the given concurrency, which is used to estimate the remaining time
+func NewProgress(out io.Writer, total, concurrency int, interactive bool) *Progress {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &Progress{
+		out:         out,
+		interactive: interactive,
+		total:       total,
+		concurrency: concurrency,
+		started:     time.Now(),
+	}
+}
+
+// LogWriter returns a writer for log output that keeps the progress bar
+// below the log lines instead of interleaving with it
+func (p *Progress) LogWriter() io.Writer {
+	return progressLogWriter{p}
+}
+
+// Grow implements engine.Grower, adding the copies fan_out dispatches to
+// the total
+func (p *Progress) Grow(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.total += n
+	if p.interactive && p.drawn {
+		p.draw()
+	}
+}
+
+// Record implements engine.ResultRecorder
+func (p *Progress) Record(result engine.ExecutionResult) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.completed++
+	if !result.Success() {
+		p.failed++
+	}
+	p.busy += result.Duration
+
+	if p.interactive {
+		p.draw()
+		return nil
+	}
+
+	// Without a terminal, report each 10% step on its own line
+	decile := p.completed * 10 / max(p.total, 1)
+	if decile > p.lastDecile || p.completed == p.total {
+		p.lastDecile = decile
+		fmt.Fprintln(p.out, p.status())
+	}
+	return nil
+}
+
+// Close finishes the bar so subsequent output starts on a fresh line
+func (p *Progress) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.drawn {
+		fmt.Fprintln(p.out)
+		p.drawn = false
+	}
+}
+
+// draw redraws the bar in place
+func (p *Progress) draw() {
+	filled := progressWidth * p.completed / max(p.total, 1)
+	if filled > progressWidth {
+		filled = progressWidth
+	}
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressWidth-filled)
+	fmt.Fprintf(p.out, "\r\033[K[%s] %s", bar, p.status())
+	p.drawn = true
+}
+
+// clear erases the bar so a log line can be written in its place
+func (p *Progress) clear() {
+	if p.drawn {
+		fmt.Fprint(p.out, "\r\033[K")
+		p.drawn = false
+	}
+}
+
+// status renders the counters and remaining time estimate
+func (p *Progress) status() string {
+	status := fmt.Sprintf("%d/%d completed", p.completed, p.total)
+	if p.failed > 0 {
+		status += fmt.Sprintf(", %d failed", p.failed)
+	}
+	if eta := p.eta(); eta > 0 {
+		status += fmt.Sprintf(", ETA %s", eta.Round(100*time.Millisecond))
+	}
+	return status
+}
+
+// eta estimates the time left from the mean execution duration so far,
+// assuming the remaining executions run in batches of the concurrency limit
+func (p *Progress) eta() time.Duration {
+	remaining := p.total - p.completed
+	if remaining <= 0 || p.completed == 0 {
+		return 0
+	}
+	mean := p.busy / time.Duration(p.completed)
+	batches := (remaining + p.concurrency - 1) / p.concurrency
+	return mean * time.Duration(batches)
+}
+
+// progressLogWriter writes log output above the progress bar
+type progressLogWriter struct {
+	p *Progress
+}
+
+// Write implements io.Writer
+func (w progressLogWriter) Write(b []byte) (int, error) {
+	w.p.mu.Lock()
+	defer w.p.mu.Unlock()
+
+	if !w.p.interactive {
+		return w.p.out.Write(b)
+	}
+
+	redraw := w.p.drawn
+	w.p.clear()
+	n, err := w.p.out.Write(b)
+	if redraw {
+		w.p.draw()
+	}
+	return n, err
+}
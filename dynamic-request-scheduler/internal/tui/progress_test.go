@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+)
+
+func TestProgress_Interactive(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgress(&buf, 4, 2, true)
+	logger := log.New(p.LogWriter(), "", 0)
+
+	p.Record(engine.ExecutionResult{StatusCode: 200, Duration: time.Second})
+	out := buf.String()
+	if !strings.Contains(out, "1/4 completed") || !strings.Contains(out, "ETA 2s") {
+		t.Errorf("Unexpected progress line: %q", out)
+	}
+	if !strings.Contains(out, strings.Repeat("█", 7)+strings.Repeat("░", 23)) {
+		t.Errorf("Expected a quarter-filled bar: %q", out)
+	}
+
+	// Log lines clear the bar, then it is redrawn beneath them
+	buf.Reset()
+	logger.Print("request done")
+	if out := buf.String(); !strings.HasPrefix(out, "\r\033[Krequest done\n\r\033[K[") {
+		t.Errorf("Expected log line above redrawn bar: %q", out)
+	}
+
+	p.Record(engine.ExecutionResult{Error: "refused", Duration: time.Second})
+	p.Record(engine.ExecutionResult{StatusCode: 500, Duration: time.Second})
+	p.Record(engine.ExecutionResult{StatusCode: 200, Duration: time.Second})
+	buf.Reset()
+	p.Close()
+	if buf.String() != "\n" {
+		t.Errorf("Expected Close to end the bar line, got %q", buf.String())
+	}
+}
+
+func TestProgress_Status(t *testing.T) {
+	p := NewProgress(&bytes.Buffer{}, 10, 3, true)
+	for i := 0; i < 4; i++ {
+		p.Record(engine.ExecutionResult{StatusCode: 200, Duration: 2 * time.Second})
+	}
+	p.Record(engine.ExecutionResult{StatusCode: 503, Duration: 2 * time.Second})
+
+	// 5 remaining at concurrency 3 is two batches of ~2s
+	if got := p.status(); got != "5/10 completed, 1 failed, ETA 4s" {
+		t.Errorf("Unexpected status: %q", got)
+	}
+}
+
+func TestProgress_NonInteractive(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgress(&buf, 20, 5, false)
+	for i := 0; i < 20; i++ {
+		p.Record(engine.ExecutionResult{StatusCode: 200, Duration: time.Millisecond})
+	}
+	p.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 10 {
+		t.Errorf("Expected a line per 10%% step, got %d:\n%s", len(lines), buf.String())
+	}
+	if strings.Contains(buf.String(), "\r") {
+		t.Error("Expected no terminal control sequences without a terminal")
+	}
+	if last := lines[len(lines)-1]; last != "20/20 completed" {
+		t.Errorf("Unexpected final line: %q", last)
+	}
+}
+
+func TestProgress_Grow(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgress(&buf, 2, 1, false)
+
+	// Two firings, each fanned out to three copies
+	p.Grow(4)
+	for i := 0; i < 6; i++ {
+		p.Record(engine.ExecutionResult{StatusCode: 200, Duration: time.Millisecond})
+	}
+	p.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if last := lines[len(lines)-1]; last != "6/6 completed" {
+		t.Errorf("Unexpected final line: %q", last)
+	}
+	if p.eta() != 0 {
+		t.Errorf("Expected no time left, got %v", p.eta())
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// runListCommand implements the `list` subcommand, previewing requests and
+// their upcoming fire times without starting the scheduler
+func runListCommand(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	configPath := fs.String("config", "", "Path to configuration file (YAML or JSON)")
+	next := fs.Int("next", 3, "Number of upcoming fire times to show per request")
+	at := fs.String("at", "", "Compute fire times as of this time instead of now (RFC 3339, e.g. 2025-03-01T09:00:00Z)")
+	match := fs.String("match", "", "Only list requests whose name matches these comma-separated globs or /regex/ patterns")
+	tags := fs.String("tag", "", "Only list requests with any of these comma-separated tags")
+	workers := fs.Int("workers", 1, "Number of workers the requests are spread over, as for a run")
+	shardBy := fs.String("shard-by", spec.ShardByRequest, "Spread requests without a shard over the workers by request or by host, as for a run")
+	fs.Parse(args)
+	if err := applyEnvDefaults(fs); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	if *configPath == "" {
+		log.Fatalf("list requires --config")
+	}
+	if *next < 1 {
+		*next = 1
+	}
+	if err := spec.ValidateShardBy(*shardBy); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	cfg, err := spec.LoadConfigFile(*configPath)
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	filter, err := spec.NewRequestFilter(*match, splitList(*tags))
+	if err != nil {
+		log.Fatalf("Error parsing --match: %v", err)
+	}
+
+	now := time.Now()
+	if *at != "" {
+		if now, err = parseAt(*at); err != nil {
+			log.Fatalf("Error parsing --at: %v", err)
+		}
+	}
+	clock := &spec.FixedClock{Time: now}
+
+	scheduleEngine := spec.NewScheduleEngine()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	// Shards are assigned over the selected requests, as in a run with the
+	// same filters
+	requests := filter.Apply(cfg.Requests)
+	shards := spec.AssignShards(requests, *workers, *shardBy)
+
+	fmt.Fprintln(w, "NAME\tTAGS\tSHARD\tSCHEDULE\tNEXT RUNS")
+	for i, req := range requests {
+		schedule := req.Schedule.Type() + " " + req.Schedule.Expression()
+		if req.Schedule.Jitter != nil {
+			schedule += " (jitter " + *req.Schedule.Jitter + ")"
+		}
+
+		// Template schedules are evaluated against a throwaway context pinned to now
+		templateEngine := spec.NewTemplateEngine(&spec.EvaluationContext{Variables: make(map[string]interface{}), Clock: clock})
+		runs, err := scheduleEngine.NextRuns(now, req.Schedule, *next, templateEngine)
+		nextRuns := formatRuns(runs, now)
+		if err != nil {
+			nextRuns = "error: " + err.Error()
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", req.Name, orDash(strings.Join(req.Tags, ",")), shards[i], schedule, nextRuns)
+	}
+	w.Flush()
+}
+
+// formatRuns renders fire times in local time, flagging ones already in the past
+func formatRuns(runs []time.Time, now time.Time) string {
+	formatted := make([]string, 0, len(runs))
+	for _, run := range runs {
+		text := run.Local().Format("2006-01-02 15:04:05")
+		if run.Before(now) {
+			text += " (past, runs immediately)"
+		}
+		formatted = append(formatted, text)
+	}
+	return strings.Join(formatted, ", ")
+}
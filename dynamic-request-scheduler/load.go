@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+	"local-dev-tools/dynamic-request-scheduler/internal/sink"
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+	"local-dev-tools/dynamic-request-scheduler/internal/stats"
+)
+
+// runLoadCommand implements the `load` subcommand, sending the selected
+// requests at a target rate, or through ramping stages, and reporting
+// latency percentiles
+func runLoadCommand(args []string) int {
+	fs := flag.NewFlagSet("load", flag.ExitOnError)
+	configPath := fs.String("config", "", "Path to configuration file (YAML or JSON)")
+	match := fs.String("match", "", "Only send requests whose name matches these comma-separated globs or /regex/ patterns")
+	tags := fs.String("tag", "", "Only send requests with any of these comma-separated tags")
+	rps := fs.Float64("rps", 0, "Sustain this many requests per second for --duration")
+	duration := fs.Duration("duration", time.Minute, "How long to sustain --rps")
+	stages := fs.String("stages", "", "Ramp through comma-separated duration:rate stages instead of --rps (e.g. 30s:10,1m:50,30s:0)")
+	startRate := fs.Float64("start-rate", 0, "Rate the first of --stages ramps from")
+	maxInFlight := fs.Int("max-in-flight", 100, "Maximum concurrent requests; arrivals beyond it are dropped and reported")
+	timeout := fs.Duration("timeout", 30*time.Second, "Request timeout")
+	maxFailureRate := fs.Float64("max-failure-rate", 1, "Exit with status 1 when more than this fraction of requests fail (e.g. 0.01)")
+	resultsPath := fs.String("results", "", "Path to JSONL file receiving one record per execution")
+	verbose := fs.Bool("verbose", false, "Log every execution")
+	runID := fs.String("run-id", "", "Identifier for this run (generated if empty)")
+	runIDHeader := fs.String("run-id-header", engine.DefaultRunIDHeader, "Header carrying the run ID on every request (empty disables)")
+	executionIDHeader := fs.String("execution-id-header", engine.DefaultExecutionIDHeader, "Header carrying a unique ID per execution (empty disables)")
+	fs.Parse(args)
+	if err := applyEnvDefaults(fs); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	if *configPath == "" {
+		log.Printf("load requires --config")
+		return exitConfigError
+	}
+
+	plan := engine.LoadPlan{MaxInFlight: *maxInFlight, StartRate: *startRate}
+	switch {
+	case *stages != "" && *rps > 0:
+		log.Printf("--rps and --stages cannot be combined")
+		return exitConfigError
+	case *stages != "":
+		parsed, err := engine.ParseStages(*stages)
+		if err != nil {
+			log.Printf("Error parsing --stages: %v", err)
+			return exitConfigError
+		}
+		plan.Stages = parsed
+	case *rps > 0:
+		if *duration <= 0 {
+			log.Printf("--duration must be positive")
+			return exitConfigError
+		}
+		plan.StartRate = *rps
+		plan.Stages = []engine.Stage{{Duration: *duration, Target: *rps}}
+	default:
+		log.Printf("load requires --rps or --stages")
+		return exitConfigError
+	}
+	if *startRate < 0 || *maxInFlight < 1 || *maxFailureRate < 0 {
+		log.Printf("--start-rate and --max-failure-rate must not be negative and --max-in-flight must be at least 1")
+		return exitConfigError
+	}
+
+	cfg, err := spec.LoadConfigFile(*configPath)
+	if err != nil {
+		log.Printf("Error loading config: %v", err)
+		return exitConfigError
+	}
+	filter, err := spec.NewRequestFilter(*match, splitList(*tags))
+	if err != nil {
+		log.Printf("Error parsing --match: %v", err)
+		return exitConfigError
+	}
+	requests := filter.Apply(cfg.Requests)
+	if len(requests) == 0 {
+		log.Printf("No requests in %s match the --match/--tag filters", *configPath)
+		return exitConfigError
+	}
+
+	collector := stats.NewCollector()
+	report := stats.NewLoadReport()
+	config := engine.SchedulerConfig{
+		Timeout:           *timeout,
+		Recorders:         []engine.ResultRecorder{collector, report},
+		RunID:             *runID,
+		RunIDHeader:       *runIDHeader,
+		ExecutionIDHeader: *executionIDHeader,
+		Quiet:             !*verbose,
+	}
+
+	if err := usePlugins(cfg.Plugins, &config); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	if *resultsPath != "" {
+		writer, err := sink.NewJSONLWriter(*resultsPath)
+		if err != nil {
+			log.Printf("Error opening results file: %v", err)
+			return exitRuntimeError
+		}
+		defer writer.Close()
+		config.Recorders = append(config.Recorders, writer)
+	}
+
+	total := plan.Duration()
+	plan.Progress = func(status engine.LoadStatus) {
+		log.Printf("[%v/%v] rate %.1f/s, %d started, %d in flight, %d dropped",
+			status.Elapsed.Round(time.Second), total, status.Rate, status.Started, status.InFlight, status.Dropped)
+	}
+
+	scheduler := engine.NewScheduler(requests, config)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		fmt.Println("\nReceived shutdown signal, waiting for requests in flight...")
+		scheduler.Stop()
+	}()
+
+	fmt.Printf("Load testing %d requests from %s: %d stages over %v, up to %.1f req/s, max %d in flight\n",
+		len(requests), *configPath, len(plan.Stages), total, plan.MaxRate(), plan.MaxInFlight)
+
+	result, err := scheduler.RunLoad(plan)
+	if err != nil {
+		log.Printf("Load test error: %v", err)
+		return exitRuntimeError
+	}
+
+	report.Write(os.Stdout, result)
+	collector.WriteSummary(os.Stdout)
+
+	if rate := report.FailureRate(); rate > *maxFailureRate {
+		log.Printf("%.2f%% of requests failed, above --max-failure-rate %.2f%%", 100*rate, 100**maxFailureRate)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -1,55 +1,534 @@
-﻿package main
+package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"golang.org/x/term"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/audit"
+	"local-dev-tools/dynamic-request-scheduler/internal/daemon"
+	"local-dev-tools/dynamic-request-scheduler/internal/diff"
 	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+	"local-dev-tools/dynamic-request-scheduler/internal/history"
+	"local-dev-tools/dynamic-request-scheduler/internal/lock"
+	"local-dev-tools/dynamic-request-scheduler/internal/notify"
+	"local-dev-tools/dynamic-request-scheduler/internal/plugin"
+	"local-dev-tools/dynamic-request-scheduler/internal/sink"
 	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+	"local-dev-tools/dynamic-request-scheduler/internal/stats"
+	"local-dev-tools/dynamic-request-scheduler/internal/tui"
+)
+
+// Process exit codes
+const (
+	exitOK = 0
+	// exitFailure means at least one request errored or returned a non-2xx status in --once mode,
+	// or a request missed its SLO
+	exitFailure = 1
+	// exitConfigError means the configuration or flags were invalid
+	exitConfigError = 2
+	// exitRuntimeError means the scheduler could not be set up or run
+	exitRuntimeError = 3
 )
 
+// pickMode is set by the `pick` subcommand: requests are chosen interactively
+// and run once
+var pickMode bool
+
 func main() {
+	// Dispatch subcommands before parsing run flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "history":
+			runHistoryCommand(os.Args[2:])
+			return
+		case "list":
+			runListCommand(os.Args[2:])
+			return
+		case "load":
+			os.Exit(runLoadCommand(os.Args[2:]))
+		case "status":
+			os.Exit(runStatusCommand(os.Args[2:]))
+		case "stop":
+			os.Exit(runStopCommand(os.Args[2:]))
+		case "service":
+			os.Exit(runServiceCommand(os.Args[2:]))
+		case "cron":
+			os.Exit(runCronCommand(os.Args[2:]))
+		case "describe":
+			os.Exit(runDescribeCommand(os.Args[2:]))
+		case "run":
+			// run is the explicit form of the default command
+			os.Args = append(os.Args[:1], os.Args[2:]...)
+		case "pick":
+			// pick accepts the same flags as a normal run
+			pickMode = true
+			os.Args = append(os.Args[:1], os.Args[2:]...)
+		}
+	}
+
+	os.Exit(run())
+}
+
+// run executes the scheduler and returns the process exit code. Deferred
+// cleanup (closing sinks, flushing notifications) happens before it returns.
+func run() int {
 	// Parse command line flags
 	configPath := flag.String("config", "", "Path to configuration file (YAML or JSON)")
+	profileName := flag.String("profile", "", "Apply a named runner profile from the config's profiles section (flags given explicitly take precedence)")
 	intervalSeconds := flag.Int("interval", 60, "Request interval in seconds (legacy mode)")
 	dryRun := flag.Bool("dry-run", false, "Show resolved requests without sending")
+	at := flag.String("at", "", "Evaluate --dry-run templates and schedules as of this time (RFC 3339, e.g. 2025-03-01T09:00:00Z)")
+	timeScale := flag.String("time-scale", "", "Run on a virtual clock this many times faster than real time (e.g. 60x), for validating long schedules quickly")
 	once := flag.Bool("once", false, "Run all requests once and exit")
+	match := flag.String("match", "", "Only run requests whose name matches these comma-separated globs or /regex/ patterns")
+	only := flag.String("only", "", "Alias for --match")
+	tags := flag.String("tag", "", "Only run requests with any of these comma-separated tags")
+	watch := flag.Bool("watch", false, "With --once or --dry-run, re-run added or changed requests every time the config file is saved")
+	count := flag.Int("count", 0, "Send each selected request N times through the normal concurrency controls (implies --once)")
+	duration := flag.Duration("duration", 0, "Stop a continuous run and print the summary after this long (e.g. 30m; 0 runs until interrupted)")
+	exitWhenDone := flag.Bool("exit-when-done", false, "Stop a continuous run once every request has fired its last scheduled execution")
 	workers := flag.Int("workers", 1, "Number of worker goroutines")
+	shardBy := flag.String("shard-by", spec.ShardByRequest, "Spread requests without a shard over the workers by request or by host")
 	concurrency := flag.Int("concurrency", 10, "Maximum concurrent requests")
+	rps := flag.Float64("rps", 0, "Maximum executions started per second across all requests (0 for unlimited)")
 	timeout := flag.Duration("timeout", 30*time.Second, "HTTP request timeout")
+	maxResponseBytes := flag.Int64("max-response-bytes", engine.DefaultMaxResponseBytes, "Keep at most this many bytes of each HTTP response body, discarding the rest (0 keeps whole responses)")
+	traceHTTP := flag.String("trace-http", "", "Log the exact HTTP bytes sent and received by requests whose name matches these comma-separated globs or /regex/ patterns (\"*\" for all), with credentials redacted")
+	repeatLogInterval := flag.Duration("repeat-log-interval", engine.DefaultRepeatLogInterval, "Log a request that keeps failing the same way once per interval, with a count of the failures in between (0 logs every failure)")
+	slowThreshold := flag.Duration("slow", 0, "Report completed executions taking longer than this as slow (0 disables; requests may set slow_threshold)")
+	historyPath := flag.String("history", "", "Path to SQLite database for persisting execution history and request state")
+	resultsPath := flag.String("results", "", "Path to JSONL file receiving one record per execution")
+	noColor := flag.Bool("no-color", false, "Disable colored status output (also disabled by NO_COLOR or when stderr is not a terminal)")
+	auditPath := flag.String("audit-log", "", "Append a JSONL audit entry for every control action (pause, resume, trigger, stop) to this file")
+	showProgress := flag.Bool("progress", true, "Show a progress bar in --once mode (plain progress lines when stderr is not a terminal)")
+	tuiMode := flag.Bool("tui", false, "Show a live terminal dashboard (continuous mode only)")
+	desktopNotify := flag.Int("desktop-notify", 0, "Show a desktop notification after N consecutive failures of a request (0 disables)")
+	daemonMode := flag.Bool("daemon", false, "Run the scheduler in the background, logging to --log-file (manage it with the stop and status commands)")
+	lockPath := flag.String("lock", "", "Hold an exclusive lock on this file while running, so another scheduler using the same lock file waits or exits instead of double-firing")
+	lockWait := flag.Duration("lock-wait", 0, "How long to wait for --lock while another scheduler holds it (0 exits at once)")
+	pidFile := flag.String("pid-file", defaultPIDFile, "Path to the pid file used by --daemon, stop and status")
+	logFile := flag.String("log-file", "drs.log", "File receiving all output in --daemon mode")
+	metricsAddr := flag.String("metrics-addr", "", "Serve Prometheus metrics on this address (e.g. :9090)")
+	statsdAddr := flag.String("statsd-addr", "", "Send execution counters and timings to a StatsD/DogStatsD agent at this address (e.g. localhost:8125)")
+	statsdPrefix := flag.String("statsd-prefix", "drs", "Prefix for StatsD metric names")
+	statsdTags := flag.Bool("statsd-tags", true, "Send request and status class as DogStatsD tags (disable to embed them in metric names)")
+	runID := flag.String("run-id", "", "Identifier for this run (generated if empty)")
+	seed := flag.Int64("seed", 0, "Seed for template random values such as randInt, fake data and uuid (0 picks one, logged at startup)")
+	replay := flag.String("replay", "", "Replay a run recorded in --history with its seed, so executions send the same generated values")
+	replayTiming := flag.Bool("replay-timing", false, "With --replay, start the clock where the replayed run's clock started, at its --time-scale unless one is given")
+	runIDHeader := flag.String("run-id-header", engine.DefaultRunIDHeader, "Header carrying the run ID on every request (empty disables)")
+	executionIDHeader := flag.String("execution-id-header", engine.DefaultExecutionIDHeader, "Header carrying a unique ID per execution (empty disables)")
+	harPath := flag.String("har", "", "Write all traffic sent during the run to this HAR file on exit")
+	diffBaseline := flag.String("diff-baseline", "", "Directory of baseline responses to diff each response against (first response is saved as the baseline)")
+	diffUpdate := flag.Bool("diff-update", false, "Overwrite baselines with the latest responses instead of diffing")
+	diffIgnore := flag.String("diff-ignore", "", "Comma-separated JSON paths ignored by --diff-baseline for every request (e.g. meta.timestamp,items[*].id)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\nEvery option can also be set with a %sNAME environment variable (e.g. %s).\n",
+			envPrefix, envName("metrics-addr"))
+	}
 	flag.Parse()
+	explicit := explicitFlags(flag.CommandLine)
+	if err := applyEnvDefaults(flag.CommandLine); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
 
 	if *configPath == "" {
 		// Legacy mode - run with hardcoded request every interval
 		fmt.Printf("No config file specified, running in legacy mode with interval of %ds\n", *intervalSeconds)
 		runLegacyMode(*intervalSeconds)
-		return
+		return exitOK
 	}
 
 	// Load configuration
-	requests, err := spec.LoadConfig(*configPath)
+	cfg, err := spec.LoadConfigFile(*configPath)
 	if err != nil {
-		log.Fatalf("Error loading config: %v", err)
+		log.Printf("Error loading config: %v", err)
+		return exitConfigError
+	}
+	if *profileName != "" {
+		profile, err := cfg.Profile(*profileName)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitConfigError
+		}
+		if err := applyProfile(flag.CommandLine, profile, explicit); err != nil {
+			log.Printf("Error applying profile %s: %v", *profileName, err)
+			return exitConfigError
+		}
+		fmt.Printf("Using profile %s\n", *profileName)
+	}
+	if *rps < 0 {
+		log.Printf("--rps must not be negative")
+		return exitConfigError
+	}
+	if err := spec.ValidateShardBy(*shardBy); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+	if *count < 0 {
+		log.Printf("--count must not be negative")
+		return exitConfigError
+	}
+	if *count > 0 {
+		*once = true
+	}
+	if *duration < 0 || (*duration > 0 && (*once || *dryRun)) {
+		log.Printf("--duration must be positive and cannot be combined with --once, --count or --dry-run")
+		return exitConfigError
+	}
+
+	if *exitWhenDone && (*once || *dryRun) {
+		log.Printf("--exit-when-done only applies to continuous runs and cannot be combined with --once, --count or --dry-run")
+		return exitConfigError
 	}
 
-	fmt.Printf("Loaded %d requests from %s\n", len(requests), *configPath)
+	var replayed *history.Run
+	if *replay != "" {
+		if *historyPath == "" {
+			log.Printf("--replay requires --history")
+			return exitConfigError
+		}
+		if *seed != 0 {
+			log.Printf("--replay uses the replayed run's seed and cannot be combined with --seed")
+			return exitConfigError
+		}
+		run, err := lookupRun(*historyPath, *replay)
+		if err != nil {
+			log.Printf("Error loading run to replay: %v", err)
+			return exitConfigError
+		}
+		replayed = &run
+		*seed = run.Seed
+	}
+	if *replayTiming && (replayed == nil || *at != "") {
+		log.Printf("--replay-timing requires --replay and cannot be combined with --at")
+		return exitConfigError
+	}
+
+	var clock spec.Clock
+	if *at != "" {
+		if !*dryRun {
+			log.Printf("--at requires --dry-run")
+			return exitConfigError
+		}
+		instant, err := parseAt(*at)
+		if err != nil {
+			log.Printf("Error parsing --at: %v", err)
+			return exitConfigError
+		}
+		clock = &spec.FixedClock{Time: instant}
+	}
+	scale := 1.0
+	if *timeScale != "" {
+		if clock != nil {
+			log.Printf("--time-scale cannot be combined with --at")
+			return exitConfigError
+		}
+		scale, err = parseTimeScale(*timeScale)
+		if err != nil {
+			log.Printf("Error parsing --time-scale: %v", err)
+			return exitConfigError
+		}
+		clock = spec.NewScaledClock(time.Now(), scale)
+	}
+	if *replayTiming {
+		if *timeScale == "" {
+			scale = replayed.TimeScale
+		}
+		clock = spec.NewScaledClock(replayed.ClockStart, scale)
+	}
+
+	if *watch && (!*once && !*dryRun || *tuiMode || *daemonMode || pickMode) {
+		log.Printf("--watch requires --once or --dry-run and cannot be combined with --tui, --daemon or pick")
+		return exitConfigError
+	}
+
+	if *daemonMode && (*tuiMode || pickMode) {
+		log.Printf("--daemon cannot be combined with --tui or pick")
+		return exitConfigError
+	}
+
+	patterns := *match
+	if *only != "" {
+		patterns = strings.Trim(patterns+","+*only, ",")
+	}
+	filter, err := spec.NewRequestFilter(patterns, splitList(*tags))
+	if err != nil {
+		log.Printf("Error parsing --match/--only: %v", err)
+		return exitConfigError
+	}
+	requests := filter.Apply(cfg.Requests)
+	if len(requests) == 0 {
+		log.Printf("No requests in %s match the --match/--only/--tag filters", *configPath)
+		return exitConfigError
+	}
+	var traced *spec.RequestFilter
+	if *traceHTTP != "" {
+		if traced, err = spec.NewRequestFilter(*traceHTTP, nil); err != nil {
+			log.Printf("Error parsing --trace-http: %v", err)
+			return exitConfigError
+		}
+	}
+
+	if pickMode {
+		if *tuiMode {
+			log.Printf("pick cannot be combined with --tui")
+			return exitConfigError
+		}
+		requests, err = pickRequests(requests)
+		if err != nil {
+			log.Printf("Error running picker: %v", err)
+			return exitConfigError
+		}
+		if len(requests) == 0 {
+			fmt.Println("Nothing selected")
+			return exitOK
+		}
+		*once = true
+	}
+
+	if len(requests) < len(cfg.Requests) {
+		fmt.Printf("Selected %d of %d requests from %s\n", len(requests), len(cfg.Requests), *configPath)
+	} else {
+		fmt.Printf("Loaded %d requests from %s\n", len(requests), *configPath)
+	}
+
+	// Detach only once the config is known to be valid, so errors are reported in the foreground
+	if *daemonMode {
+		if !daemon.IsChild() {
+			pid, err := daemon.Start(os.Args[1:], *pidFile, *logFile)
+			if err != nil {
+				log.Printf("Error starting daemon: %v", err)
+				return exitRuntimeError
+			}
+			fmt.Printf("Scheduler running in the background (pid %d), logging to %s\n", pid, *logFile)
+			return exitOK
+		}
+		defer daemon.RemovePIDFile(*pidFile)
+	}
+
+	// Take the lock in the process that runs the requests, so a background
+	// scheduler holds it rather than the process that started it
+	if *lockPath != "" && !*dryRun {
+		held, err := acquireLock(*lockPath, *lockWait)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitRuntimeError
+		}
+		if held == nil {
+			return exitOK
+		}
+		defer held.Release()
+	}
 
 	// Create scheduler configuration
 	config := engine.SchedulerConfig{
 		Workers:     *workers,
+		ShardBy:     *shardBy,
 		Concurrency: *concurrency,
 		Once:        *once,
+		Count:       *count,
+		Duration:    *duration,
+		RPS:         *rps,
+		Clock:       clock,
 		DryRun:      *dryRun,
 		Timeout:     *timeout,
+
+		ExitWhenDone: *exitWhenDone,
+		Setup:        cfg.Setup,
+		Teardown:     cfg.Teardown,
+
+		SlowThreshold:     *slowThreshold,
+		RepeatLogInterval: repeatInterval(*repeatLogInterval),
+
+		MaxResponseBytes: responseLimit(*maxResponseBytes),
+		TraceHTTP:        traced,
+
+		RunID:             *runID,
+		Seed:              *seed,
+		RunIDHeader:       *runIDHeader,
+		ExecutionIDHeader: *executionIDHeader,
+
+		// The dashboard renders log lines itself, so color is only used for plain terminal output
+		Color: !*noColor && !*tuiMode && os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stderr.Fd())),
+	}
+
+	if err := usePlugins(cfg.Plugins, &config); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	collector := stats.NewCollector()
+	availability := stats.NewAvailability()
+	config.Recorders = append(config.Recorders, collector, availability)
+
+	if *metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", collector.Handler())
+		go func() {
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				log.Printf("Metrics server error: %v", err)
+			}
+		}()
+		fmt.Printf("Serving metrics on %s/metrics\n", *metricsAddr)
+	}
+
+	if *statsdAddr != "" {
+		emitter, err := sink.NewStatsDEmitter(*statsdAddr, *statsdPrefix, *statsdTags)
+		if err != nil {
+			log.Printf("Error connecting to StatsD: %v", err)
+			return exitRuntimeError
+		}
+		defer emitter.Close()
+		config.Recorders = append(config.Recorders, emitter)
+	}
+
+	var store *history.Store
+	if *historyPath != "" {
+		store, err = history.Open(*historyPath)
+		if err != nil {
+			log.Printf("Error opening history database: %v", err)
+			return exitRuntimeError
+		}
+		defer store.Close()
+		config.Recorders = append(config.Recorders, store)
+		config.StateStore = store
+	}
+
+	if *resultsPath != "" {
+		writer, err := sink.NewJSONLWriter(*resultsPath)
+		if err != nil {
+			log.Printf("Error opening results file: %v", err)
+			return exitRuntimeError
+		}
+		defer writer.Close()
+		config.Recorders = append(config.Recorders, writer)
+	}
+
+	if *harPath != "" {
+		writer, err := sink.NewHARWriter(*harPath)
+		if err != nil {
+			log.Printf("Error creating HAR file: %v", err)
+			return exitRuntimeError
+		}
+		defer func() {
+			if err := writer.Close(); err != nil {
+				log.Printf("Error writing HAR file: %v", err)
+			}
+		}()
+		config.Recorders = append(config.Recorders, writer)
+	}
+
+	var checker *diff.Checker
+	if *diffBaseline != "" {
+		checker, err = diff.NewChecker(*diffBaseline, *diffUpdate, splitList(*diffIgnore), requests)
+		if err != nil {
+			log.Printf("Error preparing response baselines: %v", err)
+			return exitRuntimeError
+		}
+		config.Recorders = append(config.Recorders, checker)
+	}
+
+	if len(cfg.Notifications) > 0 {
+		notifier := notify.NewWebhookNotifier(cfg.Notifications)
+		defer notifier.Wait()
+		config.Recorders = append(config.Recorders, notifier)
+	}
+
+	if *desktopNotify > 0 {
+		notifier := notify.NewDesktopNotifier(*desktopNotify)
+		defer notifier.Wait()
+		config.Recorders = append(config.Recorders, notifier)
+	}
+
+	var dashboard *tui.Dashboard
+	if *tuiMode {
+		if *once || *dryRun {
+			log.Printf("--tui cannot be combined with --once or --dry-run")
+			return exitConfigError
+		}
+		dashboard = tui.New(os.Stdout)
+		config.Recorders = append(config.Recorders, dashboard)
+		log.SetOutput(dashboard.LogWriter())
+	}
+
+	var progress *tui.Progress
+	if *once && !*dryRun && *showProgress && !*watch {
+		interactive := term.IsTerminal(int(os.Stderr.Fd()))
+		progress = tui.NewProgress(os.Stderr, len(requests)*max(*count, 1)+len(cfg.Setup)+len(cfg.Teardown), *concurrency, interactive)
+		config.Recorders = append(config.Recorders, progress)
+		log.SetOutput(progress.LogWriter())
+	}
+
+	if *watch {
+		return runWatch(*configPath, filter, cfg, requests, config)
 	}
 
 	// Create and start scheduler
 	scheduler := engine.NewScheduler(requests, config)
+	if !*once {
+		collector.WatchPressure(scheduler.Pressure)
+	}
+	collector.WatchWarmth(scheduler.Warmth)
+
+	if replayed != nil {
+		fmt.Printf("Replaying run %s (seed %d), started %s with: %s\n", replayed.ID, replayed.Seed,
+			replayed.StartedAt.Local().Format(time.RFC3339), strings.Join(replayed.Args, " "))
+	}
+	if store != nil && !*dryRun {
+		clockStart := time.Now()
+		if clock != nil {
+			clockStart = clock.Now()
+		}
+		run := history.Run{
+			ID:         scheduler.RunID(),
+			Seed:       scheduler.Seed(),
+			StartedAt:  time.Now(),
+			ClockStart: clockStart,
+			TimeScale:  scale,
+			Args:       os.Args[1:],
+		}
+		if err := store.RecordRun(run); err != nil {
+			log.Printf("Error recording run: %v", err)
+			return exitRuntimeError
+		}
+	}
+
+	// Control actions from the dashboard go through the audit log when enabled
+	var ctrl tui.Controller = scheduler
+	if *auditPath != "" {
+		auditLog, err := audit.Open(*auditPath)
+		if err != nil {
+			log.Printf("Error opening audit log: %v", err)
+			return exitRuntimeError
+		}
+		defer auditLog.Close()
+		ctrl = audit.NewController(scheduler, auditLog, scheduler.RunID(), audit.CurrentActor(), "tui", log.Printf)
+	}
+
+	if dashboard != nil {
+		go func() {
+			if err := dashboard.Run(ctrl, os.Stdin); err != nil {
+				fmt.Fprintf(os.Stderr, "Dashboard error: %v\n", err)
+				scheduler.Stop()
+			}
+		}()
+	}
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -59,12 +538,58 @@ func main() {
 		<-sigChan
 		fmt.Println("\nReceived shutdown signal, stopping scheduler...")
 		scheduler.Stop()
+
+		// A second signal abandons any teardown requests still to run
+		for range sigChan {
+			scheduler.Stop()
+		}
 	}()
 
 	// Start the scheduler
-	if err := scheduler.Start(); err != nil {
-		log.Fatalf("Scheduler error: %v", err)
+	err = scheduler.Start()
+
+	if dashboard != nil {
+		dashboard.Close()
+	}
+	if progress != nil {
+		progress.Close()
 	}
+	var dryRunErr *engine.DryRunError
+	if errors.As(err, &dryRunErr) {
+		log.Printf("Dry run failed: %v", err)
+		return exitConfigError
+	}
+	if err != nil {
+		log.Printf("Scheduler error: %v", err)
+		return exitRuntimeError
+	}
+	collector.WriteSummary(os.Stdout)
+	collector.WriteTimings(os.Stdout)
+	if !*once {
+		// A single pass has no timeline, so availability is only reported for continuous runs
+		availability.WriteReport(os.Stdout, time.Now())
+	}
+	if checker != nil {
+		checker.WriteReport(os.Stdout)
+	}
+	slos := stats.EvaluateSLOs(requests, collector.Snapshot())
+	stats.WriteSLOReport(os.Stdout, slos)
+
+	if *once {
+		if failures := collector.Failures(); failures > 0 {
+			log.Printf("%d request execution(s) failed", failures)
+			return exitFailure
+		}
+		if checker != nil && checker.Changed() > 0 {
+			log.Printf("%d response(s) differ from their baseline", checker.Changed())
+			return exitFailure
+		}
+	}
+	if missed := stats.SLOMissed(slos); missed > 0 {
+		log.Printf("%d SLO objective(s) missed", missed)
+		return exitFailure
+	}
+	return exitOK
 }
 
 func runLegacyMode(intervalSeconds int) {
@@ -76,17 +601,17 @@ func runLegacyMode(intervalSeconds int) {
 		},
 		HTTP: spec.HttpRequestSpec{
 			Method: "POST",
-			URL:    "https://localhost:10001/core/scheduler/tasks/run-once",
-			Headers: map[string]string{
+			URL:    spec.NewLiteralString("https://localhost:10001/core/scheduler/tasks/run-once"),
+			Headers: spec.LiteralHeaders(map[string]string{
 				"Content-Type": "application/json",
-			},
-			Body: map[string]interface{}{
+			}),
+			Body: spec.NewLiteralAny(map[string]interface{}{
 				"scheduled_for":        time.Now().Unix() + 600,
 				"task_request_method":  "GET",
 				"task_request_url":     "https://localhost:10001/fad/health",
 				"task_request_headers": nil,
 				"task_request_payload": nil,
-			},
+			}),
 		},
 	}
 
@@ -97,6 +622,9 @@ func runLegacyMode(intervalSeconds int) {
 		Once:        false,
 		DryRun:      false,
 		Timeout:     30 * time.Second,
+
+		RunIDHeader:       engine.DefaultRunIDHeader,
+		ExecutionIDHeader: engine.DefaultExecutionIDHeader,
 	}
 
 	scheduler := engine.NewScheduler([]spec.ScheduledRequest{*legacyRequest}, config)
@@ -117,7 +645,149 @@ func runLegacyMode(intervalSeconds int) {
 	}
 }
 
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseAt parses a --at instant as RFC 3339, or as a local date and time
+// without an offset
+func parseAt(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (expected e.g. 2025-03-01T09:00:00Z)", value)
+}
+
+// usePlugins registers the config's plugins with the scheduler: as the
+// executor for their request type and as transformers of resolved requests
+func usePlugins(specs []spec.PluginSpec, config *engine.SchedulerConfig) error {
+	for _, pluginSpec := range specs {
+		p, err := plugin.New(pluginSpec)
+		if err != nil {
+			return fmt.Errorf("plugin %s: %w", pluginSpec.Name, err)
+		}
+		if pluginSpec.Type != "" {
+			if config.Executors == nil {
+				config.Executors = make(map[string]engine.Executor)
+			}
+			config.Executors[pluginSpec.Type] = engine.PluginExecutor(p)
+		}
+		if pluginSpec.Transform {
+			config.Transformers = append(config.Transformers, p)
+		}
+	}
+	return nil
+}
+
+// acquireLock takes the --lock file, waiting up to wait while another
+// scheduler holds it. It returns nil without an error if interrupted while
+// waiting.
+func acquireLock(path string, wait time.Duration) (*lock.Lock, error) {
+	held, err := lock.TryAcquire(path)
+	var heldErr *lock.HeldError
+	if !errors.As(err, &heldErr) {
+		return held, err
+	}
+	if wait <= 0 {
+		return nil, fmt.Errorf("%w; not starting a second scheduler (use --lock-wait to wait for it)", err)
+	}
+
+	fmt.Printf("%v; waiting up to %v for it to be released\n", err, wait)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	held, err = lock.Acquire(ctx, path, wait)
+	if ctx.Err() != nil {
+		fmt.Println("Stopped waiting for the lock")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gave up after %v: %w", wait, err)
+	}
+	fmt.Printf("Acquired lock %s\n", path)
+	return held, nil
+}
+
+// lookupRun reads how a run recorded in the history database was started
+func lookupRun(path, id string) (history.Run, error) {
+	store, err := history.Open(path)
+	if err != nil {
+		return history.Run{}, err
+	}
+	defer store.Close()
+	return store.Run(id)
+}
+
+// parseTimeScale parses a --time-scale factor such as "60x" or "60"
+func parseTimeScale(value string) (float64, error) {
+	scale, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(value), "x"), 64)
+	if err != nil || scale <= 0 {
+		return 0, fmt.Errorf("invalid time scale %q (expected a positive factor such as 60x)", value)
+	}
+	return scale, nil
+}
+
+// responseLimit maps --max-response-bytes to the scheduler's limit, where 0
+// keeps whole responses rather than selecting the default
+func responseLimit(n int64) int64 {
+	if n == 0 {
+		return -1
+	}
+	return n
+}
+
+// repeatInterval maps --repeat-log-interval to the scheduler's interval,
+// where 0 logs every failure rather than selecting the default
+func repeatInterval(d time.Duration) time.Duration {
+	if d == 0 {
+		return -1
+	}
+	return d
+}
+
 // Helper function to create string pointers
 func stringPtr(s string) *string {
 	return &s
 }
+
+// pickRequests lets the user choose requests interactively, returning them in
+// config order or nil if the picker was cancelled
+func pickRequests(requests []spec.ScheduledRequest) ([]spec.ScheduledRequest, error) {
+	items := make([]tui.PickItem, len(requests))
+	for i, req := range requests {
+		method, url := req.Target()
+		detail := method + " " + url
+		if len(req.Tags) > 0 {
+			detail += "  [" + strings.Join(req.Tags, ",") + "]"
+		}
+		items[i] = tui.PickItem{Name: req.Name, Detail: detail}
+	}
+
+	names, err := tui.NewPicker(os.Stdout, items).Run(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	chosen := make(map[string]bool, len(names))
+	for _, name := range names {
+		chosen[name] = true
+	}
+	var picked []spec.ScheduledRequest
+	for _, req := range requests {
+		if chosen[req.Name] {
+			picked = append(picked, req)
+		}
+	}
+	return picked, nil
+}
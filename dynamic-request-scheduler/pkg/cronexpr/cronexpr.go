@@ -0,0 +1,23 @@
+// Package cronexpr exposes the scheduler's cron expression explainer to the
+// sibling tools in local-dev-tools, so an expression is described and
+// checked exactly as a cron schedule in a config would run it.
+package cronexpr
+
+import "local-dev-tools/dynamic-request-scheduler/internal/cronexpr"
+
+// Expr is a parsed cron expression. Explain describes it in words, Next
+// lists when it fires, Check says why it does not fire at a given time and
+// Report prints all of that for a terminal.
+type Expr = cronexpr.Expr
+
+// Parse parses five-field expressions, descriptors such as @daily and
+// @every 10m, and a leading CRON_TZ=<zone>, as the scheduler does
+func Parse(expr string) (*Expr, error) {
+	return cronexpr.Parse(expr)
+}
+
+// ParseInZone parses an expression that fires in the named zone, such as
+// Europe/London, unless it sets its own CRON_TZ
+func ParseInZone(expr, zone string) (*Expr, error) {
+	return cronexpr.ParseInZone(expr, zone)
+}
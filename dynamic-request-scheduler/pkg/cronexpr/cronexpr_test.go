@@ -0,0 +1,22 @@
+package cronexpr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	e, err := Parse("*/15 9-17 * * 1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := e.Explain(), "Every 15 minutes, between 09:00 and 17:59, on Monday through Friday"; got != want {
+		t.Errorf("Explain() = %q, want %q", got, want)
+	}
+	if runs := e.Next(time.Date(2025, 3, 3, 9, 0, 0, 0, time.Local), 2); len(runs) != 2 || runs[1].Minute() != 30 {
+		t.Errorf("Expected runs at 09:15 and 09:30, got %v", runs)
+	}
+	if _, err := Parse("not a schedule"); err == nil {
+		t.Errorf("Expected an error for an invalid expression")
+	}
+}
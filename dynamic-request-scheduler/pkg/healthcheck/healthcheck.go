@@ -0,0 +1,98 @@
+// Package healthcheck holds the TCP and HTTP readiness checks shared by the
+// sibling tools in local-dev-tools, so wait-for and healthboard judge a
+// service up or down the same way.
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/requests"
+)
+
+// maxErrorBody bounds the response body quoted in a failed check's error
+const maxErrorBody = 200
+
+// NewTransport returns a transport that opens a fresh connection per check,
+// so a dead server is not masked by an idle keep-alive connection
+func NewTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableKeepAlives = true
+	return transport
+}
+
+// TCP succeeds once address accepts a connection
+func TCP(ctx context.Context, address string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return Simplify(err)
+	}
+	return conn.Close()
+}
+
+// HTTP sends the literal request of spec with client and succeeds on one of
+// expect, or any 2xx when expect is empty, returning the response status.
+// The error for any other status quotes the start of the response body.
+func HTTP(ctx context.Context, client *http.Client, spec *requests.HTTP, expect []int) (string, error) {
+	var body io.Reader
+	if value := spec.Body.GetValue(); value != nil && spec.Method != "GET" && spec.Method != "HEAD" {
+		if s, ok := value.(string); ok {
+			body = strings.NewReader(s)
+		} else {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return "", fmt.Errorf("failed to marshal request body: %w", err)
+			}
+			body = bytes.NewReader(data)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, spec.Method, spec.URL.GetValue(), body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, value := range spec.Headers {
+		req.Header.Set(key, value.GetValue())
+	}
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", Simplify(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	ok := resp.StatusCode >= 200 && resp.StatusCode < 300
+	if len(expect) > 0 {
+		ok = slices.Contains(expect, resp.StatusCode)
+	}
+	if !ok {
+		if text := strings.TrimSpace(string(data)); text != "" {
+			return "", fmt.Errorf("status %s: %s", resp.Status, text)
+		}
+		return "", fmt.Errorf("status %s", resp.Status)
+	}
+	return resp.Status, nil
+}
+
+// Simplify strips the operation and address prefixes from network errors,
+// which repeat what the tools already show next to them
+func Simplify(err error) error {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Err != nil {
+		return opErr.Err
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package healthcheck
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/requests"
+)
+
+func TestHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ready":
+		case "/starting":
+			http.Error(w, "warming caches", http.StatusServiceUnavailable)
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" || string(body) != `{"ping":true}` {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		spec       requests.HTTP
+		expect     []int
+		wantStatus string
+		wantErr    string
+	}{
+		{name: "ready", spec: requests.HTTP{Method: "GET", URL: requests.LiteralString(server.URL + "/ready")}, wantStatus: "200 OK"},
+		{name: "error body", spec: requests.HTTP{Method: "GET", URL: requests.LiteralString(server.URL + "/starting")}, wantErr: "status 503 Service Unavailable: warming caches"},
+		{
+			name:       "json body",
+			spec:       requests.HTTP{Method: "POST", URL: requests.LiteralString(server.URL + "/echo"), Body: requests.LiteralBody(map[string]interface{}{"ping": true})},
+			wantStatus: "200 OK",
+		},
+		{name: "expected status", spec: requests.HTTP{Method: "GET", URL: requests.LiteralString(server.URL + "/admin")}, expect: []int{401}, wantStatus: "401 Unauthorized"},
+		{name: "unexpected status", spec: requests.HTTP{Method: "GET", URL: requests.LiteralString(server.URL + "/ready")}, expect: []int{401}, wantErr: "status 200 OK"},
+	}
+
+	client := &http.Client{Transport: NewTransport()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := HTTP(context.Background(), client, &tt.spec, tt.expect)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || status != tt.wantStatus {
+				t.Errorf("Expected %q, got %q, %v", tt.wantStatus, status, err)
+			}
+		})
+	}
+}
+
+func TestTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+
+	if err := TCP(context.Background(), addr); err != nil {
+		t.Errorf("Expected a connection, got %v", err)
+	}
+
+	// The error leaves out the dial operation and address
+	listener.Close()
+	if err := TCP(context.Background(), addr); err == nil || !strings.Contains(err.Error(), "connection refused") || strings.Contains(err.Error(), addr) {
+		t.Errorf("Expected a bare connection refused, got %v", err)
+	}
+}
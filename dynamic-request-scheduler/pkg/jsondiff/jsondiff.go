@@ -0,0 +1,30 @@
+// Package jsondiff exposes the scheduler's structural response comparison,
+// used by --diff-baseline, to the sibling tools in local-dev-tools, so
+// changes and ignore paths read the same everywhere.
+package jsondiff
+
+import "local-dev-tools/dynamic-request-scheduler/internal/diff"
+
+// Change is one difference, rendered by String as "+ path: value",
+// "- path: value" or "~ path: old -> new"
+type Change = diff.Change
+
+// Kinds of change
+const (
+	Added   = diff.Added
+	Removed = diff.Removed
+	Changed = diff.Changed
+)
+
+// Compare walks two decoded JSON values and returns their differences, in
+// path order. A path in ignore skips the value and everything beneath it;
+// "*" matches any single key and "[*]" any array index.
+func Compare(old, new interface{}, ignore []string) []Change {
+	return diff.Compare(old, new, ignore)
+}
+
+// Decode parses a JSON body for Compare, falling back to the raw text so
+// other bodies are compared as a whole
+func Decode(body []byte) interface{} {
+	return diff.DecodeBody(body)
+}
@@ -0,0 +1,30 @@
+package jsondiff
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	old := Decode([]byte(`{"id": 1, "name": "Ada", "updated_at": "2025-01-01", "roles": ["admin"]}`))
+	new := Decode([]byte(`{"id": 1, "name": "Grace", "updated_at": "2025-02-01", "roles": ["admin", "dev"]}`))
+
+	changes := Compare(old, new, []string{"updated_at"})
+	var lines []string
+	for _, change := range changes {
+		lines = append(lines, change.String())
+	}
+	want := []string{`~ name: "Ada" -> "Grace"`, `+ roles[1]: "dev"`}
+	if len(lines) != len(want) || lines[0] != want[0] || lines[1] != want[1] {
+		t.Errorf("Got changes %q, want %q", lines, want)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	if Decode(nil) != nil {
+		t.Errorf("Expected an empty body to decode to nil")
+	}
+	if got := Decode([]byte("<html>")); got != "<html>" {
+		t.Errorf("Expected text bodies as strings, got %#v", got)
+	}
+	if got, ok := Decode([]byte(`[1]`)).([]interface{}); !ok || len(got) != 1 {
+		t.Errorf("Expected a decoded array, got %#v", got)
+	}
+}
@@ -0,0 +1,26 @@
+package queues
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSQSClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("Action") != "ReceiveMessage" {
+			t.Errorf("Unexpected action %q", r.PostForm.Get("Action"))
+		}
+		fmt.Fprint(w, `<ReceiveMessageResponse><ReceiveMessageResult><Message><MessageId>m-1</MessageId><ReceiptHandle>h</ReceiptHandle><Body>hi</Body></Message></ReceiveMessageResult></ReceiveMessageResponse>`)
+	}))
+	defer server.Close()
+
+	messages, err := NewSQSClient(time.Second).ReceiveMessages(context.Background(), "", server.URL+"/000000000000/orders", 1, 0, time.Second)
+	if err != nil || len(messages) != 1 || messages[0].Body != "hi" {
+		t.Errorf("Expected one message, got %+v (%v)", messages, err)
+	}
+}
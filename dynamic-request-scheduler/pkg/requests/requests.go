@@ -0,0 +1,75 @@
+// Package requests exposes the scheduler's request specification types to
+// the sibling tools in local-dev-tools, so they accept the same http sections
+// as scheduler configs and resolve their templates the same way.
+package requests
+
+import (
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+// HTTP is the http section of a scheduled request: method, url, headers and
+// body, any of which may contain templates
+type HTTP = spec.HttpRequestSpec
+
+// String is a url or header value: a literal, which may contain inline
+// templates, or a {template: "..."} object
+type String = spec.DynamicString
+
+// Body is a request body: a literal value, whose strings may contain inline
+// templates, or a {template: "..."} object
+type Body = spec.DynamicAny
+
+// LiteralString returns a url or header value holding value
+func LiteralString(value string) String {
+	return spec.NewLiteralString(value)
+}
+
+// LiteralHeaders returns the headers of an http section holding headers
+func LiteralHeaders(headers map[string]string) map[string]String {
+	return spec.LiteralHeaders(headers)
+}
+
+// LiteralBody returns a request body holding value
+func LiteralBody(value interface{}) Body {
+	return spec.NewLiteralAny(value)
+}
+
+// Resolved is a request with every template evaluated
+type Resolved = spec.ResolvedRequest
+
+// Request is a scheduled request as written in a scheduler config
+type Request = spec.ScheduledRequest
+
+// TypeHTTP is the Type of plain HTTP requests
+const TypeHTTP = spec.TypeHTTP
+
+// Filter selects requests by name pattern and tag, as --match and --tags do
+type Filter = spec.RequestFilter
+
+// LoadConfig reads and validates the requests of a scheduler config file
+func LoadConfig(path string) ([]Request, error) {
+	return spec.LoadConfig(path)
+}
+
+// NewFilter builds a filter from comma-separated name patterns (globs, or
+// regular expressions between slashes) and tags; empty ones select
+// everything
+func NewFilter(match string, tags []string) (*Filter, error) {
+	return spec.NewRequestFilter(match, tags)
+}
+
+// ResolveHTTP validates req and evaluates the templates in its URL, headers
+// and body with engine
+func ResolveHTTP(engine *templating.Engine, req HTTP) (*Resolved, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// An epoch schedule resolves without consulting the clock or templates
+	var epoch int64
+	return spec.NewEvaluator(engine).EvaluateRequest(&spec.ScheduledRequest{
+		HTTP:     req,
+		Schedule: spec.ScheduleSpec{Epoch: &epoch},
+	})
+}
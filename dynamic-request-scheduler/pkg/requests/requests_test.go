@@ -0,0 +1,60 @@
+package requests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+func TestResolveHTTP(t *testing.T) {
+	t.Setenv("API_URL", "http://localhost:3000")
+	engine := templating.New(map[string]interface{}{"token": "abc"}, 1)
+
+	resolved, err := ResolveHTTP(engine, HTTP{
+		Method:  "GET",
+		URL:     LiteralString(`{{ env "API_URL" }}/health`),
+		Headers: LiteralHeaders(map[string]string{"Authorization": `Bearer {{ var "token" }}`}),
+	})
+	if err != nil {
+		t.Fatalf("ResolveHTTP failed: %v", err)
+	}
+	if resolved.URL != "http://localhost:3000/health" || resolved.Headers["Authorization"] != "Bearer abc" {
+		t.Errorf("Unexpected resolved request %+v", resolved)
+	}
+
+	if _, err := ResolveHTTP(engine, HTTP{Method: "FETCH", URL: LiteralString("http://localhost")}); err == nil {
+		t.Error("Expected an invalid method to be rejected")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.yaml")
+	os.WriteFile(path, []byte(`
+requests:
+  - name: get-user
+    tags: [users]
+    schedule: {relative: "1m"}
+    http: {method: GET, url: "http://localhost/users/1"}
+  - name: list-orders
+    schedule: {relative: "1m"}
+    http: {method: GET, url: "http://localhost/orders"}
+`), 0o644)
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].Type() != TypeHTTP {
+		t.Fatalf("Unexpected requests %+v", loaded)
+	}
+
+	filter, err := NewFilter("", []string{"users"})
+	if err != nil {
+		t.Fatalf("NewFilter failed: %v", err)
+	}
+	if selected := filter.Apply(loaded); len(selected) != 1 || selected[0].Name != "get-user" {
+		t.Errorf("Expected the tagged request, got %+v", selected)
+	}
+}
@@ -0,0 +1,109 @@
+// Package shaping holds the bandwidth limits and random faults shared by the
+// sibling tools in local-dev-tools, so chaos-proxy and netsim degrade
+// traffic the same way.
+package shaping
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ParseBandwidth parses a transfer rate such as "512B", "64KB", "1.5MB" or
+// "1MB/s" into bytes per second. Empty means unlimited.
+func ParseBandwidth(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	s := strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(value), "/s"))
+
+	multiplier := 1.0
+	for _, unit := range []struct {
+		suffix     string
+		multiplier float64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, multiplier = strings.TrimSuffix(s, unit.suffix), unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n <= 0 || int64(n*multiplier) < 1 {
+		return 0, fmt.Errorf("invalid bandwidth %q (use e.g. 64KB or 1MB)", value)
+	}
+	return int64(n * multiplier), nil
+}
+
+// Dice makes the random decisions for faults; it is safe for concurrent use
+type Dice struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewDice creates dice whose rolls are drawn from seed
+func NewDice(seed int64) *Dice {
+	return &Dice{rand: rand.New(rand.NewSource(seed))}
+}
+
+// Roll reports whether an event with probability rate happens
+func (d *Dice) Roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.rand.Float64() < rate
+}
+
+// Jittered returns latency varied by up to ±jitter, never negative
+func (d *Dice) Jittered(latency, jitter time.Duration) time.Duration {
+	if jitter > 0 {
+		d.mu.Lock()
+		latency += time.Duration(d.rand.Int63n(int64(2*jitter)+1)) - jitter
+		d.mu.Unlock()
+	}
+	return max(latency, 0)
+}
+
+// ThrottledWriter writes at most rate bytes per second. Time spent idle
+// does not build up credit for a later burst, as on a real link.
+type ThrottledWriter struct {
+	w    io.Writer
+	rate int64
+
+	// free is when the link has finished sending what was written so far
+	free time.Time
+}
+
+// NewThrottledWriter limits writes to w to rate bytes per second
+func NewThrottledWriter(w io.Writer, rate int64) *ThrottledWriter {
+	return &ThrottledWriter{w: w, rate: rate}
+}
+
+// Write sends p in slices of a tenth of a second's worth of bytes, each once
+// the link would have finished sending it
+func (t *ThrottledWriter) Write(p []byte) (int, error) {
+	chunk := max(int(t.rate/10), 1)
+	written := 0
+	for len(p) > 0 {
+		n := min(chunk, len(p))
+		if now := time.Now(); t.free.Before(now) {
+			t.free = now
+		}
+		t.free = t.free.Add(time.Duration(float64(n) / float64(t.rate) * float64(time.Second)))
+		time.Sleep(time.Until(t.free))
+
+		m, err := t.w.Write(p[:n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
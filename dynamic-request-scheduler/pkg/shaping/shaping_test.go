@@ -0,0 +1,89 @@
+package shaping
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	tests := map[string]int64{
+		"":       0,
+		"512B":   512,
+		"64KB":   64 << 10,
+		"64kb/s": 64 << 10,
+		"1.5MB":  3 << 19,
+		"1mb/s":  1 << 20,
+		"1GB":    1 << 30,
+		"2048":   2048,
+	}
+	for input, want := range tests {
+		if got, err := ParseBandwidth(input); err != nil || got != want {
+			t.Errorf("ParseBandwidth(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"fast", "0KB", "-1MB", "0.1B"} {
+		if _, err := ParseBandwidth(input); err == nil {
+			t.Errorf("ParseBandwidth(%q) should fail", input)
+		}
+	}
+}
+
+func TestDice(t *testing.T) {
+	d := NewDice(1)
+	for i := 0; i < 100; i++ {
+		if delay := d.Jittered(10*time.Millisecond, 30*time.Millisecond); delay < 0 || delay > 40*time.Millisecond {
+			t.Fatalf("Delay %v outside [0, 40ms]", delay)
+		}
+	}
+	if d.Roll(0) || !d.Roll(1) {
+		t.Error("Expected rates 0 and 1 to never and always happen")
+	}
+
+	// The same seed gives the same rolls
+	a, b := NewDice(7), NewDice(7)
+	for i := 0; i < 10; i++ {
+		if a.Roll(0.5) != b.Roll(0.5) {
+			t.Fatal("Expected dice with the same seed to agree")
+		}
+	}
+}
+
+func TestThrottledWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewThrottledWriter(&buf, 10<<10)
+
+	start := time.Now()
+	data := bytes.Repeat([]byte("x"), 3<<10)
+	if n, err := w.Write(data); err != nil || n != len(data) {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	// 3KB at 10KB/s takes about 300ms
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Expected about 300ms, took %v", elapsed)
+	}
+	if buf.Len() != len(data) {
+		t.Errorf("Expected %d bytes written, got %d", len(data), buf.Len())
+	}
+}
+
+func TestThrottledWriter_IdleBuildsNoCredit(t *testing.T) {
+	w := NewThrottledWriter(io.Discard, 10<<10)
+	data := bytes.Repeat([]byte("x"), 1<<10)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// A pause longer than the first write took must not let the next one
+	// through at full speed
+	time.Sleep(500 * time.Millisecond)
+	start := time.Now()
+	if _, err := w.Write(bytes.Repeat(data, 3)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("Expected 3KB at 10KB/s to take about 300ms after a pause, took %v", elapsed)
+	}
+}
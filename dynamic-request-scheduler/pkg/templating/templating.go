@@ -0,0 +1,31 @@
+// Package templating exposes the scheduler's template engine to the sibling
+// tools in local-dev-tools, so templates written for one tool behave the same
+// in the others.
+package templating
+
+import (
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// Engine evaluates templates with the scheduler's function library (now,
+// uuid, randInt, env, var, seq, ...). An Engine is not safe for concurrent
+// use.
+type Engine = spec.TemplateEngine
+
+// New creates an engine on the system clock. vars are returned by the var
+// function; seed makes randInt and randFloat reproducible.
+func New(vars map[string]interface{}, seed int64) *Engine {
+	if vars == nil {
+		vars = make(map[string]interface{})
+	}
+	return spec.NewTemplateEngine(&spec.EvaluationContext{
+		Variables: vars,
+		Seed:      seed,
+		Clock:     &spec.RealClock{},
+	})
+}
+
+// IsTemplate reports whether s contains template actions
+func IsTemplate(s string) bool {
+	return spec.IsTemplateString(s)
+}
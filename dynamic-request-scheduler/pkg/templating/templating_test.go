@@ -0,0 +1,21 @@
+package templating
+
+import (
+	"testing"
+)
+
+func TestEngine(t *testing.T) {
+	engine := New(map[string]interface{}{"team": "payments"}, 1)
+
+	got, err := engine.EvaluateTemplateWithData(`{{ var "team" | upper }}/{{ .ID }}/{{ seq }}`, map[string]string{"ID": "42"})
+	if err != nil {
+		t.Fatalf("EvaluateTemplateWithData failed: %v", err)
+	}
+	if got != "PAYMENTS/42/1" {
+		t.Errorf("Expected PAYMENTS/42/1, got %s", got)
+	}
+
+	if !IsTemplate("{{ uuid }}") || IsTemplate("plain") {
+		t.Error("IsTemplate did not detect template actions")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+// applyProfile sets the flags a profile configures, skipping any in
+// explicit, which were given on the command line. Values a profile sets
+// replace those from DRS_* environment variables.
+func applyProfile(fs *flag.FlagSet, profile spec.ProfileSpec, explicit map[string]bool) error {
+	values := make(map[string]string)
+	if profile.Workers != nil {
+		values["workers"] = strconv.Itoa(*profile.Workers)
+	}
+	if profile.Concurrency != nil {
+		values["concurrency"] = strconv.Itoa(*profile.Concurrency)
+	}
+	if profile.RPS != nil {
+		values["rps"] = strconv.FormatFloat(*profile.RPS, 'f', -1, 64)
+	}
+	if profile.Timeout != "" {
+		values["timeout"] = profile.Timeout
+	}
+	if profile.Once != nil {
+		values["once"] = strconv.FormatBool(*profile.Once)
+	}
+	if profile.Count != nil {
+		values["count"] = strconv.Itoa(*profile.Count)
+	}
+	if profile.Duration != "" {
+		values["duration"] = profile.Duration
+	}
+
+	for name, value := range values {
+		if explicit[name] {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
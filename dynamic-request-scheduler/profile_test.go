@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func TestApplyProfile_Precedence(t *testing.T) {
+	// The command line sets concurrency, the profile sets concurrency and
+	// timeout, and the environment sets both plus the metrics address
+	t.Setenv("DRS_CONCURRENCY", "20")
+	t.Setenv("DRS_TIMEOUT", "10s")
+	t.Setenv("DRS_METRICS_ADDR", ":9090")
+
+	fs, concurrency, noColor, timeout, metricsAddr := testFlags()
+	if err := fs.Parse([]string{"--concurrency", "5"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	explicit := explicitFlags(fs)
+	if err := applyEnvDefaults(fs); err != nil {
+		t.Fatalf("applyEnvDefaults failed: %v", err)
+	}
+
+	concurrencyValue := 50
+	profile := spec.ProfileSpec{Concurrency: &concurrencyValue, Timeout: "2s"}
+	if err := applyProfile(fs, profile, explicit); err != nil {
+		t.Fatalf("applyProfile failed: %v", err)
+	}
+
+	if *concurrency != 5 {
+		t.Errorf("Expected the command line to beat the profile, got concurrency %d", *concurrency)
+	}
+	if *timeout != 2*time.Second {
+		t.Errorf("Expected the profile to beat the environment, got timeout %v", *timeout)
+	}
+	if *metricsAddr != ":9090" {
+		t.Errorf("Expected the environment to fill in what the profile leaves unset, got metrics-addr %q", *metricsAddr)
+	}
+	if *noColor {
+		t.Error("Expected no-color to keep its default")
+	}
+}
+
+func TestApplyProfile_BeatsDefaults(t *testing.T) {
+	fs, concurrency, _, timeout, _ := testFlags()
+	if err := fs.Parse(nil); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	concurrencyValue := 3
+	profile := spec.ProfileSpec{Concurrency: &concurrencyValue}
+	if err := applyProfile(fs, profile, explicitFlags(fs)); err != nil {
+		t.Fatalf("applyProfile failed: %v", err)
+	}
+	if *concurrency != 3 || *timeout != 30*time.Second {
+		t.Errorf("Expected concurrency 3 and the default timeout, got %d and %v", *concurrency, *timeout)
+	}
+
+	bad := spec.ProfileSpec{Timeout: "soon"}
+	if err := applyProfile(fs, bad, nil); err == nil {
+		t.Error("Expected an invalid profile timeout to fail")
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/service"
+)
+
+// runServiceCommand implements `service install` and `service uninstall`,
+// registering the scheduler with the OS service manager so it survives reboots
+func runServiceCommand(args []string) int {
+	if len(args) == 0 || (args[0] != "install" && args[0] != "uninstall") {
+		fmt.Fprintln(os.Stderr, "Usage: dynamic-request-scheduler service install [--name drs] [--system] -- --config <path> [run options]")
+		fmt.Fprintln(os.Stderr, "       dynamic-request-scheduler service uninstall [--name drs] [--system]")
+		return exitConfigError
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("service "+action, flag.ExitOnError)
+	name := fs.String("name", "drs", "Service name")
+	system := fs.Bool("system", false, "Install a system-wide systemd unit instead of a per-user one (Linux, requires root)")
+	fs.Parse(args[1:])
+	if err := applyEnvDefaults(fs); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	cfg := service.Config{
+		Name:        *name,
+		Description: "Dynamic Request Scheduler (" + *name + ")",
+		Args:        fs.Args(),
+		System:      *system,
+	}
+
+	if action == "uninstall" {
+		if err := service.Uninstall(cfg); err != nil {
+			log.Printf("Error uninstalling service: %v", err)
+			return exitRuntimeError
+		}
+		fmt.Printf("Uninstalled service %s\n", *name)
+		return exitOK
+	}
+
+	if err := validateServiceArgs(cfg.Args); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	var err error
+	if cfg.Executable, err = os.Executable(); err != nil {
+		log.Printf("Error locating executable: %v", err)
+		return exitRuntimeError
+	}
+	if cfg.WorkingDir, err = os.Getwd(); err != nil {
+		log.Printf("Error resolving working directory: %v", err)
+		return exitRuntimeError
+	}
+
+	location, err := service.Install(cfg)
+	if err != nil {
+		log.Printf("Error installing service: %v", err)
+		return exitRuntimeError
+	}
+	fmt.Printf("Installed and started service %s (%s)\n", *name, location)
+	if runtime.GOOS == "linux" && !*system {
+		fmt.Println("To start it at boot without logging in, run: loginctl enable-linger $USER")
+	}
+	return exitOK
+}
+
+// validateServiceArgs checks the run options passed after -- describe a
+// foreground scheduler the service manager can supervise
+func validateServiceArgs(args []string) error {
+	hasConfig := false
+	for _, arg := range args {
+		name := strings.TrimLeft(strings.SplitN(arg, "=", 2)[0], "-")
+		switch name {
+		case "config":
+			hasConfig = true
+		case "daemon", "tui", "once", "count", "duration", "dry-run":
+			return fmt.Errorf("--%s cannot be used for a service", name)
+		}
+	}
+	if !hasConfig {
+		return fmt.Errorf("service install requires run options after --, including --config")
+	}
+	return nil
+}
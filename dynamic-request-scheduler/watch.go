@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+	"local-dev-tools/dynamic-request-scheduler/internal/stats"
+)
+
+// watchInterval is how often the config file is checked for changes
+const watchInterval = 500 * time.Millisecond
+
+// runWatch runs the selected requests once, then re-runs the requests that
+// were added or changed every time the config file is saved, until interrupted
+func runWatch(path string, filter *spec.RequestFilter, cfg *spec.Config, requests []spec.ScheduledRequest, config engine.SchedulerConfig) int {
+	stop := make(chan struct{})
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		<-sigChan
+		close(stop)
+	}()
+
+	return watchConfig(path, filter, cfg, requests, config, stop)
+}
+
+// watchConfig is runWatch until stop is closed. Only the requests are
+// reloaded: the scheduler keeps the setup, teardown, plugins, notifications
+// and other sections it started with, and a save that changes them says so.
+func watchConfig(path string, filter *spec.RequestFilter, cfg *spec.Config, requests []spec.ScheduledRequest, config engine.SchedulerConfig, stop <-chan struct{}) int {
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Printf("Error watching config: %v", err)
+		return exitRuntimeError
+	}
+	modTime := info.ModTime()
+
+	runWatchPass(requests, config, stop)
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		fmt.Printf("\nWatching %s for changes (Ctrl-C to exit)\n", path)
+
+	wait:
+		for {
+			select {
+			case <-stop:
+				fmt.Println("\nStopped watching")
+				return exitOK
+			case <-ticker.C:
+				info, err := os.Stat(path)
+				if err != nil || !info.ModTime().After(modTime) {
+					continue
+				}
+				modTime = info.ModTime()
+				break wait
+			}
+		}
+
+		next, err := spec.LoadConfigFile(path)
+		if err != nil {
+			log.Printf("Error loading config: %v", err)
+			continue
+		}
+		if !sameSections(cfg, next) {
+			fmt.Println("Config sections other than requests changed; restart to apply them")
+		}
+		cfg = next
+
+		selected := filter.Apply(cfg.Requests)
+		changed := changedRequests(requests, selected)
+		requests = selected
+
+		if len(changed) == 0 {
+			fmt.Println("Config saved with no request changes")
+			continue
+		}
+		fmt.Printf("Config changed, running %d request(s)\n", len(changed))
+		runWatchPass(changed, config, stop)
+	}
+}
+
+// runWatchPass runs requests once with a fresh summary, stopping early when
+// stop is closed
+func runWatchPass(requests []spec.ScheduledRequest, config engine.SchedulerConfig, stop <-chan struct{}) {
+	collector := stats.NewCollector()
+	config.Recorders = append(append([]engine.ResultRecorder(nil), config.Recorders...), collector)
+	scheduler := engine.NewScheduler(requests, config)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+			scheduler.Stop()
+		case <-done:
+		}
+	}()
+
+	if err := scheduler.Start(); err != nil {
+		log.Printf("Scheduler error: %v", err)
+		return
+	}
+	collector.WriteSummary(os.Stdout)
+}
+
+// changedRequests returns the requests in next that are new or differ from
+// the request with the same name in previous
+func changedRequests(previous, next []spec.ScheduledRequest) []spec.ScheduledRequest {
+	byName := make(map[string]spec.ScheduledRequest, len(previous))
+	for _, req := range previous {
+		byName[req.Name] = req
+	}
+
+	var changed []spec.ScheduledRequest
+	for _, req := range next {
+		if old, ok := byName[req.Name]; !ok || !reflect.DeepEqual(old, req) {
+			changed = append(changed, req)
+		}
+	}
+	return changed
+}
+
+// sameSections reports whether two configs agree on every section but the
+// requests, which watch mode reloads on its own
+func sameSections(previous, next *spec.Config) bool {
+	a, b := *previous, *next
+	a.Requests, b.Requests = nil, nil
+	// Keep-warm URLs become requests, which are compared separately
+	a.KeepWarm, b.KeepWarm = nil, nil
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/internal/engine"
+	"local-dev-tools/dynamic-request-scheduler/internal/spec"
+)
+
+func watchRequest(name, url string) spec.ScheduledRequest {
+	relative := "1s"
+	return spec.ScheduledRequest{
+		Name:     name,
+		Schedule: spec.ScheduleSpec{Relative: &relative},
+		HTTP:     spec.HttpRequestSpec{Method: "GET", URL: spec.NewLiteralString(url)},
+	}
+}
+
+func TestChangedRequests(t *testing.T) {
+	modified := watchRequest("b", "http://localhost/b")
+	modified.HTTP.Headers = spec.LiteralHeaders(map[string]string{"X-Version": "2"})
+
+	tests := []struct {
+		name     string
+		previous []spec.ScheduledRequest
+		next     []spec.ScheduledRequest
+		want     []string
+	}{
+		{
+			name:     "unchanged",
+			previous: []spec.ScheduledRequest{watchRequest("a", "http://localhost/a")},
+			next:     []spec.ScheduledRequest{watchRequest("a", "http://localhost/a")},
+		},
+		{
+			name:     "added",
+			previous: []spec.ScheduledRequest{watchRequest("a", "http://localhost/a")},
+			next:     []spec.ScheduledRequest{watchRequest("a", "http://localhost/a"), watchRequest("c", "http://localhost/c")},
+			want:     []string{"c"},
+		},
+		{
+			name:     "removed",
+			previous: []spec.ScheduledRequest{watchRequest("a", "http://localhost/a"), watchRequest("b", "http://localhost/b")},
+			next:     []spec.ScheduledRequest{watchRequest("a", "http://localhost/a")},
+		},
+		{
+			name:     "modified",
+			previous: []spec.ScheduledRequest{watchRequest("a", "http://localhost/a"), watchRequest("b", "http://localhost/b")},
+			next:     []spec.ScheduledRequest{watchRequest("a", "http://localhost/a"), modified},
+			want:     []string{"b"},
+		},
+		{
+			name:     "renamed",
+			previous: []spec.ScheduledRequest{watchRequest("a", "http://localhost/a")},
+			next:     []spec.ScheduledRequest{watchRequest("a2", "http://localhost/a")},
+			want:     []string{"a2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, req := range changedRequests(tt.previous, tt.next) {
+				got = append(got, req.Name)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("changedRequests = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameSections(t *testing.T) {
+	previous := &spec.Config{Requests: []spec.ScheduledRequest{watchRequest("a", "http://localhost/a")}}
+	next := &spec.Config{Requests: []spec.ScheduledRequest{watchRequest("b", "http://localhost/b")}}
+	if !sameSections(previous, next) {
+		t.Error("Expected request changes alone to leave the other sections the same")
+	}
+
+	next.Setup = []spec.ScheduledRequest{{Name: "login"}}
+	if sameSections(previous, next) {
+		t.Error("Expected a setup change to be reported")
+	}
+}
+
+func TestWatchConfig_RerunsOnlyChangedRequests(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		hits[r.URL.Path]++
+	}))
+	defer server.Close()
+	count := func(path string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return hits[path]
+	}
+	waitFor := func(what string, done func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !done() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", what)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(version string, modTime time.Time) {
+		t.Helper()
+		config := fmt.Sprintf(`requests:
+  - name: a
+    schedule: {relative: "1s"}
+    http: {method: GET, url: "%[1]s/a"}
+  - name: b
+    schedule: {relative: "1s"}
+    http:
+      method: GET
+      url: "%[1]s/b"
+      headers: {X-Version: "%[2]s"}
+`, server.URL, version)
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Chtimes failed: %v", err)
+		}
+	}
+
+	start := time.Now().Add(-time.Minute)
+	write("1", start)
+	cfg, err := spec.LoadConfigFile(path)
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+
+	filter, err := spec.NewRequestFilter("", nil)
+	if err != nil {
+		t.Fatalf("NewRequestFilter failed: %v", err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan int, 1)
+	go func() {
+		done <- watchConfig(path, filter, cfg, cfg.Requests, engine.SchedulerConfig{Once: true}, stop)
+	}()
+
+	waitFor("the first pass", func() bool { return count("/a") == 1 && count("/b") == 1 })
+
+	// Only b changes, so only b runs again
+	write("2", start.Add(time.Second))
+	waitFor("b to re-run", func() bool { return count("/b") == 2 })
+
+	// A save without request changes runs nothing
+	write("2", start.Add(2*time.Second))
+	time.Sleep(4 * watchInterval)
+
+	close(stop)
+	select {
+	case code := <-done:
+		if code != exitOK {
+			t.Errorf("Expected exit code %d, got %d", exitOK, code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected watching to stop")
+	}
+
+	if count("/a") != 1 || count("/b") != 2 {
+		t.Errorf("Expected a to run once and b twice, got %d and %d", count("/a"), count("/b"))
+	}
+}
@@ -0,0 +1,224 @@
+// Package env resolves named profiles of environment variables and writes
+// them as .env files.
+package env
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+// DefaultFile is where profiles are rendered when the config does not say
+const DefaultFile = ".env"
+
+// namePattern is what variable names are limited to, so every rendered
+// file can be sourced by a shell
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Config is a profiles file
+type Config struct {
+	// Default is the profile used when none is given
+	Default string `yaml:"default,omitempty"`
+
+	// File is the .env file profiles are rendered to
+	File string `yaml:"file,omitempty"`
+
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+// Profile is a named set of variables
+type Profile struct {
+	// Extends names a profile whose variables this one starts from
+	Extends string `yaml:"extends,omitempty"`
+
+	Description string `yaml:"description,omitempty"`
+
+	// Vars are in file order. Values may contain templates; var refers to
+	// the profile's variables defined before it.
+	Vars Vars `yaml:"vars"`
+}
+
+// Var is one variable
+type Var struct {
+	Name  string
+	Value string
+}
+
+// Vars keeps the order variables are written in
+type Vars []Var
+
+// UnmarshalYAML reads a mapping of names to scalar values
+func (v *Vars) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: vars must be a mapping of names to values", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			return fmt.Errorf("line %d: %s must be a single value", value.Line, key.Value)
+		}
+		text := value.Value
+		if value.Tag == "!!null" {
+			text = ""
+		}
+		*v = append(*v, Var{Name: key.Value, Value: text})
+	}
+	return nil
+}
+
+// LoadConfig reads and validates a profiles file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks the profiles and fills in defaults
+func (c *Config) Validate() error {
+	if len(c.Profiles) == 0 {
+		return fmt.Errorf("at least one profile is required")
+	}
+	if c.File == "" {
+		c.File = DefaultFile
+	}
+	if c.Default != "" && c.Profiles[c.Default] == nil {
+		return fmt.Errorf("default profile %q is not defined", c.Default)
+	}
+
+	for _, name := range c.Names() {
+		profile := c.Profiles[name]
+		if profile == nil {
+			return fmt.Errorf("profile %s: is empty", name)
+		}
+		seen := make(map[string]bool)
+		for _, v := range profile.Vars {
+			if !namePattern.MatchString(v.Name) {
+				return fmt.Errorf("profile %s: invalid variable name %q", name, v.Name)
+			}
+			if seen[v.Name] {
+				return fmt.Errorf("profile %s: variable %s is defined twice", name, v.Name)
+			}
+			seen[v.Name] = true
+		}
+		if _, err := c.chain(name); err != nil {
+			return fmt.Errorf("profile %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Names returns the profile names in sorted order
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// chain returns the profile and those it extends, base first
+func (c *Config) chain(name string) ([]*Profile, error) {
+	var chain []*Profile
+	visited := []string{name}
+	for current := name; ; {
+		profile := c.Profiles[current]
+		if profile == nil {
+			return nil, fmt.Errorf("extends undefined profile %q", current)
+		}
+		chain = append([]*Profile{profile}, chain...)
+		if profile.Extends == "" {
+			return chain, nil
+		}
+		for _, v := range visited {
+			if v == profile.Extends {
+				return nil, fmt.Errorf("extends itself: %s", strings.Join(append(visited, v), " → "))
+			}
+		}
+		current = profile.Extends
+		visited = append(visited, current)
+	}
+}
+
+// Resolve returns a profile's variables, or the default profile's when
+// name is empty, with templates evaluated. Variables inherited from an
+// extended profile keep their position when overridden, so the base
+// profile's templates see the override.
+func (c *Config) Resolve(name string) (Vars, error) {
+	if name == "" {
+		if c.Default == "" {
+			return nil, fmt.Errorf("no profile given and no default profile set")
+		}
+		name = c.Default
+	}
+	if c.Profiles[name] == nil {
+		return nil, fmt.Errorf("profile %q is not defined; have %s", name, strings.Join(c.Names(), ", "))
+	}
+	chain, err := c.chain(name)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %w", name, err)
+	}
+
+	var merged Vars
+	index := make(map[string]int)
+	for _, profile := range chain {
+		for _, v := range profile.Vars {
+			if i, ok := index[v.Name]; ok {
+				merged[i].Value = v.Value
+				continue
+			}
+			index[v.Name] = len(merged)
+			merged = append(merged, v)
+		}
+	}
+
+	engine := templating.New(nil, 0)
+	resolved := make(Vars, 0, len(merged))
+	for _, v := range merged {
+		value := v.Value
+		if templating.IsTemplate(value) {
+			if value, err = engine.EvaluateTemplate(value); err != nil {
+				return nil, fmt.Errorf("profile %s: %s: %w", name, v.Name, err)
+			}
+		}
+		engine.SetVariable(v.Name, value)
+		resolved = append(resolved, Var{Name: v.Name, Value: value})
+	}
+	return resolved, nil
+}
+
+// Environ returns environ with the variables set, replacing any existing
+// values, in the form of os.Environ
+func (v Vars) Environ(environ []string) []string {
+	set := make(map[string]bool, len(v))
+	for _, variable := range v {
+		set[variable.Name] = true
+	}
+	result := make([]string, 0, len(environ)+len(v))
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if !set[name] {
+			result = append(result, entry)
+		}
+	}
+	for _, variable := range v {
+		result = append(result, variable.Name+"="+variable.Value)
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testConfig = `
+default: dev
+profiles:
+  dev:
+    description: Everything local
+    vars:
+      API_PORT: 8080
+      API_URL: http://localhost:{{ var "API_PORT" }}
+      DEBUG: true
+      SMTP_HOST:
+  staging-sim:
+    extends: dev
+    vars:
+      API_PORT: 9090
+      LOG_LEVEL: warn
+  demo:
+    extends: staging-sim
+    vars:
+      GREETING: hello from {{ env "ENVCTL_TEST_USER" }}
+`
+
+func loadTestConfig(t *testing.T, content string) *Config {
+	t.Helper()
+	var config Config
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	return &config
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "envctl.yaml")
+	os.WriteFile(path, []byte(testConfig), 0o644)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.File != DefaultFile || config.Default != "dev" {
+		t.Errorf("Unexpected defaults %q, %q", config.File, config.Default)
+	}
+	if names := config.Names(); !reflect.DeepEqual(names, []string{"demo", "dev", "staging-sim"}) {
+		t.Errorf("Unexpected names %v", names)
+	}
+
+	want := Vars{{"API_PORT", "8080"}, {"API_URL", `http://localhost:{{ var "API_PORT" }}`}, {"DEBUG", "true"}, {"SMTP_HOST", ""}}
+	if got := config.Profiles["dev"].Vars; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the vars in file order, got %v", got)
+	}
+}
+
+func TestConfig_Resolve(t *testing.T) {
+	t.Setenv("ENVCTL_TEST_USER", "ada")
+	config := loadTestConfig(t, testConfig)
+
+	tests := map[string]Vars{
+		"": {{"API_PORT", "8080"}, {"API_URL", "http://localhost:8080"}, {"DEBUG", "true"}, {"SMTP_HOST", ""}},
+		// The override keeps its place, so API_URL uses it
+		"staging-sim": {{"API_PORT", "9090"}, {"API_URL", "http://localhost:9090"}, {"DEBUG", "true"}, {"SMTP_HOST", ""}, {"LOG_LEVEL", "warn"}},
+		"demo": {
+			{"API_PORT", "9090"}, {"API_URL", "http://localhost:9090"}, {"DEBUG", "true"}, {"SMTP_HOST", ""},
+			{"LOG_LEVEL", "warn"}, {"GREETING", "hello from ada"},
+		},
+	}
+	for name, want := range tests {
+		got, err := config.Resolve(name)
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Resolve(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	if _, err := config.Resolve("prod"); err == nil || !strings.Contains(err.Error(), "have demo, dev, staging-sim") {
+		t.Errorf("Expected an undefined profile error, got %v", err)
+	}
+	config.Default = ""
+	if _, err := config.Resolve(""); err == nil {
+		t.Errorf("Expected an error without a profile")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]string{
+		"at least one profile":          `{}`,
+		`default profile "prod"`:        "default: prod\nprofiles: {dev: {vars: {A: 1}}}",
+		`invalid variable name "A-B"`:   "profiles: {dev: {vars: {A-B: 1}}}",
+		`extends undefined profile "x"`: "profiles: {dev: {extends: x}}",
+		"extends itself: a → b → a":     "profiles: {a: {extends: b}, b: {extends: a}}",
+		"profile dev: is empty":         "profiles: {dev: }",
+	}
+	for want, content := range tests {
+		var config Config
+		if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+			t.Errorf("Unmarshal(%q) failed: %v", content, err)
+			continue
+		}
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %v", want, err)
+		}
+	}
+
+	var config Config
+	if err := yaml.Unmarshal([]byte("profiles: {dev: {vars: {A: [1, 2]}}}"), &config); err == nil || !strings.Contains(err.Error(), "A must be a single value") {
+		t.Errorf("Expected a list value to be rejected, got %v", err)
+	}
+}
+
+func TestVars_Environ(t *testing.T) {
+	vars := Vars{{"PATH", "/opt/bin"}, {"API_URL", "http://localhost"}}
+	got := vars.Environ([]string{"HOME=/home/ada", "PATH=/usr/bin", "TERM=xterm"})
+	want := []string{"HOME=/home/ada", "TERM=xterm", "PATH=/opt/bin", "API_URL=http://localhost"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Environ = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package env
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// headerPrefix starts the first line of rendered files, naming the profile
+const headerPrefix = "# Generated by envctl from profile "
+
+// ErrNotRendered means a file exists that envctl did not write, and is not
+// overwritten without being forced
+var ErrNotRendered = errors.New("was not written by envctl")
+
+// plainValue needs no quoting in .env files or shells
+var plainValue = regexp.MustCompile(`^[A-Za-z0-9_./:@,+=%-]*$`)
+
+// Quote formats a value for a .env file. Values are single-quoted, which
+// Docker Compose, dotenv libraries and shells all read literally, unless
+// they contain a single quote or newline and need double quotes.
+func Quote(value string) string {
+	switch {
+	case plainValue.MatchString(value):
+		return value
+	case !strings.ContainsAny(value, "'\n\r"):
+		return "'" + value + "'"
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`", "\n", `\n`, "\r", `\r`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// Write writes the variables as NAME=value lines, each prefixed with
+// prefix, such as "export "
+func (v Vars) Write(w io.Writer, prefix string) error {
+	for _, variable := range v {
+		if _, err := fmt.Fprintf(w, "%s%s=%s\n", prefix, variable.Name, Quote(variable.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render writes the profile's variables to path. A file envctl did not
+// write is only replaced when force is set.
+func Render(path, profile string, vars Vars, force bool) error {
+	if !force {
+		if _, err := RenderedProfile(path); errors.Is(err, ErrNotRendered) {
+			return fmt.Errorf("%s %w; use --force to replace it", path, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s%s; changes are lost when it is rendered again\n", headerPrefix, profile)
+	vars.Write(&buf, "")
+
+	// Replace the file in one step so watchers never see it half written
+	tmp := path + ".envctl-tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// RenderedProfile returns the profile last rendered to path, or nothing for
+// an empty file. It returns an error satisfying os.IsNotExist when there is
+// no file, and ErrNotRendered when the file was written by something else.
+func RenderedProfile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	first, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if first == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(first, headerPrefix) {
+		return "", ErrNotRendered
+	}
+	profile, _, _ := strings.Cut(strings.TrimPrefix(first, headerPrefix), ";")
+	return strings.TrimSpace(profile), nil
+}
@@ -0,0 +1,81 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"":                           "",
+		"http://localhost:8080/api":  "http://localhost:8080/api",
+		"postgres://u:p@db:5432/app": "postgres://u:p@db:5432/app",
+		"hello world":                "'hello world'",
+		"$HOME and #comment":         "'$HOME and #comment'",
+		"it's":                       `"it's"`,
+		"line one\nline \"two\" $x":  `"line one\nline \"two\" \$x"`,
+	}
+	for value, want := range tests {
+		if got := Quote(value); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestVars_Write(t *testing.T) {
+	var buf bytes.Buffer
+	Vars{{"A", "1"}, {"B", "two words"}}.Write(&buf, "export ")
+	if want := "export A=1\nexport B='two words'\n"; buf.String() != want {
+		t.Errorf("Got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if _, err := RenderedProfile(path); !os.IsNotExist(err) {
+		t.Errorf("Expected a missing file, got %v", err)
+	}
+
+	if err := Render(path, "dev", Vars{{"API_URL", "http://localhost:8080"}}, false); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasSuffix(string(data), "\nAPI_URL=http://localhost:8080\n") {
+		t.Errorf("Unexpected file:\n%s", data)
+	}
+	if profile, err := RenderedProfile(path); err != nil || profile != "dev" {
+		t.Errorf("RenderedProfile = %q, %v; want dev", profile, err)
+	}
+
+	// Rendering again replaces the file
+	if err := Render(path, "staging-sim", Vars{{"API_URL", "http://localhost:9090"}}, false); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if profile, _ := RenderedProfile(path); profile != "staging-sim" {
+		t.Errorf("Expected staging-sim, got %q", profile)
+	}
+}
+
+func TestRender_KeepsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	os.WriteFile(path, []byte("SECRET=hand-written\n"), 0o644)
+
+	err := Render(path, "dev", Vars{{"A", "1"}}, false)
+	if !errors.Is(err, ErrNotRendered) {
+		t.Fatalf("Expected ErrNotRendered, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "SECRET=hand-written\n" {
+		t.Errorf("Expected the file to be kept, got %q", data)
+	}
+
+	if err := Render(path, "dev", Vars{{"A", "1"}}, true); err != nil {
+		t.Fatalf("Forced render failed: %v", err)
+	}
+	if profile, _ := RenderedProfile(path); profile != "dev" {
+		t.Errorf("Expected the forced render, got %q", profile)
+	}
+}
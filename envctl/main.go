@@ -0,0 +1,258 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"local-dev-tools/envctl/internal/env"
+)
+
+// Process exit codes; exec exits with the command's code instead
+const (
+	exitOK = 0
+	// exitConfigError means the flags or profiles were invalid
+	exitConfigError = 2
+	// exitRuntimeError means a file could not be written or the command
+	// could not be started
+	exitRuntimeError = 3
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [options] [profile]\n\n", os.Args[0])
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  list     List the profiles")
+	fmt.Fprintln(os.Stderr, "  show     Print a profile's variables")
+	fmt.Fprintln(os.Stderr, "  render   Write a profile's variables to the .env file")
+	fmt.Fprintln(os.Stderr, "  exec     Run a command with a profile's variables set")
+	fmt.Fprintln(os.Stderr, "\nThe profile defaults to $ENVCTL_PROFILE, then the config's default.")
+	fmt.Fprintln(os.Stderr, "Run a command with -h for its options.")
+}
+
+func run(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return exitConfigError
+	}
+	switch args[0] {
+	case "list":
+		return runList(args[1:])
+	case "show":
+		return runShow(args[1:])
+	case "render":
+		return runRender(args[1:])
+	case "exec":
+		return runExec(args[1:])
+	case "-h", "-help", "--help", "help":
+		usage()
+		return exitOK
+	}
+	log.Printf("Unknown command %q", args[0])
+	usage()
+	return exitConfigError
+}
+
+// addConfigFlag adds the profiles file option shared by every command
+func addConfigFlag(fs *flag.FlagSet) *string {
+	path := os.Getenv("ENVCTL_CONFIG")
+	if path == "" {
+		path = "envctl.yaml"
+	}
+	return fs.String("config", path, "Profiles file (YAML or JSON); defaults to $ENVCTL_CONFIG, then envctl.yaml")
+}
+
+// loadProfile loads the config and resolves the profile given as the only
+// argument, or the default one
+func loadProfile(configPath string, args []string) (*env.Config, string, env.Vars, bool) {
+	if len(args) > 1 {
+		log.Printf("Expected one profile, got %s", strings.Join(args, " "))
+		return nil, "", nil, false
+	}
+	config, err := env.LoadConfig(configPath)
+	if err != nil {
+		log.Printf("Error loading config: %v", err)
+		return nil, "", nil, false
+	}
+
+	name := os.Getenv("ENVCTL_PROFILE")
+	if len(args) == 1 {
+		name = args[0]
+	}
+	if name == "" {
+		name = config.Default
+	}
+	vars, err := config.Resolve(name)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return nil, "", nil, false
+	}
+	return config, name, vars, true
+}
+
+func runList(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	configPath := addConfigFlag(fs)
+	fs.Parse(args)
+
+	config, err := env.LoadConfig(*configPath)
+	if err != nil {
+		log.Printf("Error loading config: %v", err)
+		return exitConfigError
+	}
+	file := configFile(*configPath, config)
+	rendered, _ := env.RenderedProfile(file)
+
+	width := 0
+	for _, name := range config.Names() {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	for _, name := range config.Names() {
+		profile := config.Profiles[name]
+		marker := " "
+		if name == rendered {
+			marker = "*"
+		}
+		var notes []string
+		if profile.Description != "" {
+			notes = append(notes, profile.Description)
+		}
+		if profile.Extends != "" {
+			notes = append(notes, "extends "+profile.Extends)
+		}
+		if name == config.Default {
+			notes = append(notes, "default")
+		}
+		fmt.Printf("%s %-*s  %s\n", marker, width, name, strings.Join(notes, "; "))
+	}
+	if rendered != "" {
+		fmt.Printf("\n* rendered to %s\n", file)
+	}
+	return exitOK
+}
+
+func runShow(args []string) int {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	configPath := addConfigFlag(fs)
+	export := fs.Bool("export", false, "Prefix each line with export, for eval \"$(envctl show --export <profile>)\"")
+	fs.Parse(args)
+
+	_, _, vars, ok := loadProfile(*configPath, fs.Args())
+	if !ok {
+		return exitConfigError
+	}
+	prefix := ""
+	if *export {
+		prefix = "export "
+	}
+	vars.Write(os.Stdout, prefix)
+	return exitOK
+}
+
+func runRender(args []string) int {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	configPath := addConfigFlag(fs)
+	output := fs.String("o", "", "File to write; defaults to the config's file setting (.env), relative to the config")
+	force := fs.Bool("force", false, "Replace the file even if envctl did not write it")
+	fs.Parse(args)
+
+	config, name, vars, ok := loadProfile(*configPath, fs.Args())
+	if !ok {
+		return exitConfigError
+	}
+	path := *output
+	if path == "" {
+		path = configFile(*configPath, config)
+	}
+	if err := env.Render(path, name, vars, *force); err != nil {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+	fmt.Printf("Rendered profile %s to %s (%d variables)\n", name, path, len(vars))
+	return exitOK
+}
+
+func runExec(args []string) int {
+	fs := flag.NewFlagSet("exec", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s exec [options] [profile] -- command [args...]\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	configPath := addConfigFlag(fs)
+
+	// Split before parsing, since flag consumes a leading --
+	var command []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, command = args[:i], args[i+1:]
+			break
+		}
+	}
+	fs.Parse(args)
+	if len(command) == 0 {
+		fs.Usage()
+		return exitConfigError
+	}
+	_, name, vars, ok := loadProfile(*configPath, fs.Args())
+	if !ok {
+		return exitConfigError
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = append(vars, env.Var{Name: "ENVCTL_PROFILE", Value: name}).Environ(os.Environ())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	// The command handles interrupts itself; pass on the ones sent only to
+	// envctl, such as a kill from another terminal
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+	go func() {
+		for sig := range signals {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		return exitErr.ExitCode()
+	case errors.As(err, &exitErr):
+		// Killed by a signal; exit as a shell would
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		fallthrough
+	default:
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+}
+
+// configFile returns the config's .env path, relative to the config file
+func configFile(configPath string, config *env.Config) string {
+	if filepath.IsAbs(config.File) {
+		return config.File
+	}
+	return filepath.Join(filepath.Dir(configPath), config.File)
+}
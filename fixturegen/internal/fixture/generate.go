@@ -0,0 +1,129 @@
+package fixture
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+// Data is what field templates are evaluated against
+type Data struct {
+	// Index is the row number, from 1
+	Index int
+
+	// Row holds the values of the fields before this one in the same row
+	Row map[string]interface{}
+
+	// Tables holds every column of the tables generated before this one,
+	// such as .Tables.users.id for the user IDs
+	Tables map[string]map[string][]interface{}
+}
+
+// Dataset is the generated rows of every table, in schema order
+type Dataset struct {
+	Tables []*Rows
+}
+
+// Rows is one generated table
+type Rows struct {
+	Name    string
+	Columns []string
+
+	// Values holds one value per column for each row: nil, string, int64,
+	// float64, bool, or anything decoded from JSON
+	Values [][]interface{}
+}
+
+// Generate renders every table's rows. The same schema and seed always
+// produce the same data, apart from uuid and the current time.
+func Generate(schema *Schema, seed int64) (*Dataset, error) {
+	engine := templating.New(nil, seed)
+	tables := make(map[string]map[string][]interface{})
+	dataset := &Dataset{}
+
+	for _, table := range schema.Tables {
+		rows := &Rows{Name: table.Name}
+		for _, field := range table.Fields {
+			rows.Columns = append(rows.Columns, field.Name)
+		}
+		columns := make(map[string][]interface{}, len(table.Fields))
+
+		for i := 1; i <= table.Count; i++ {
+			data := &Data{Index: i, Row: make(map[string]interface{}, len(table.Fields)), Tables: tables}
+			values := make([]interface{}, len(table.Fields))
+			for j, field := range table.Fields {
+				value, err := renderField(engine, field, data)
+				if err != nil {
+					return nil, fmt.Errorf("table %s, row %d: field %s: %w", table.Name, i, field.Name, err)
+				}
+				values[j] = value
+				data.Row[field.Name] = value
+				columns[field.Name] = append(columns[field.Name], value)
+			}
+			rows.Values = append(rows.Values, values)
+		}
+
+		tables[table.Name] = columns
+		dataset.Tables = append(dataset.Tables, rows)
+	}
+	return dataset, nil
+}
+
+// renderField evaluates the field's template and converts the result to
+// its type
+func renderField(engine *templating.Engine, field *Field, data *Data) (interface{}, error) {
+	if field.NullRate > 0 {
+		// Drawn from the seeded source so nulls are reproducible too
+		draw, err := engine.EvaluateTemplate("{{ randFloat }}")
+		if err != nil {
+			return nil, err
+		}
+		if f, _ := strconv.ParseFloat(draw, 64); f < field.NullRate {
+			return nil, nil
+		}
+	}
+
+	text := field.Value
+	if templating.IsTemplate(text) {
+		var err error
+		if text, err = engine.EvaluateTemplateWithData(text, data); err != nil {
+			return nil, err
+		}
+	}
+	return convert(text, field.Type)
+}
+
+// convert parses a rendered value as the given type
+func convert(text, fieldType string) (interface{}, error) {
+	switch fieldType {
+	case TypeInt:
+		n, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an int", text)
+		}
+		return n, nil
+	case TypeFloat:
+		f, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a float", text)
+		}
+		return f, nil
+	case TypeBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a bool", text)
+		}
+		return b, nil
+	case TypeJSON:
+		var value interface{}
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return nil, fmt.Errorf("%q is not JSON: %w", text, err)
+		}
+		return value, nil
+	default:
+		return text, nil
+	}
+}
@@ -0,0 +1,123 @@
+package fixture
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func parseSchema(t *testing.T, content string) *Schema {
+	t.Helper()
+	var schema Schema
+	if err := yaml.Unmarshal([]byte(content), &schema); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := schema.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	return &schema
+}
+
+const testSchema = `
+tables:
+  users:
+    count: 5
+    fields:
+      id: {type: int, value: "{{ .Index }}"}
+      name: "{{ firstName }}"
+      email: '{{ lower .Row.name }}{{ .Row.id }}@example.com'
+      score: {type: float, value: "{{ randDecimal 0 1 2 }}"}
+      active: {type: bool, value: "{{ randBool }}"}
+      tags: {type: json, value: '["{{ word }}"]'}
+      team: {value: "{{ company }}", null_rate: 0.5}
+  orders:
+    count: 20
+    fields:
+      user_id: {type: int, value: "{{ pick .Tables.users.id }}"}
+      status: fixed
+`
+
+func TestGenerate(t *testing.T) {
+	dataset, err := Generate(parseSchema(t, testSchema), 42)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	users, orders := dataset.Tables[0], dataset.Tables[1]
+	if len(users.Values) != 5 || len(orders.Values) != 20 {
+		t.Fatalf("Unexpected row counts %d and %d", len(users.Values), len(orders.Values))
+	}
+	if !reflect.DeepEqual(users.Columns, []string{"id", "name", "email", "score", "active", "tags", "team"}) {
+		t.Errorf("Unexpected columns %v", users.Columns)
+	}
+
+	nulls := 0
+	for i, row := range users.Values {
+		if row[0] != int64(i+1) {
+			t.Errorf("Expected id %d, got %#v", i+1, row[0])
+		}
+		if email := row[2].(string); !strings.HasPrefix(email, strings.ToLower(row[1].(string))) || !strings.HasSuffix(email, "@example.com") {
+			t.Errorf("Expected the email to use the row's name, got %q for %q", email, row[1])
+		}
+		if _, ok := row[3].(float64); !ok {
+			t.Errorf("Expected a float score, got %#v", row[3])
+		}
+		if _, ok := row[4].(bool); !ok {
+			t.Errorf("Expected a bool, got %#v", row[4])
+		}
+		if tags, ok := row[5].([]interface{}); !ok || len(tags) != 1 {
+			t.Errorf("Expected decoded JSON, got %#v", row[5])
+		}
+		if row[6] == nil {
+			nulls++
+		}
+	}
+	if nulls == 0 || nulls == 5 {
+		t.Errorf("Expected some null teams with null_rate 0.5, got %d of 5", nulls)
+	}
+
+	for _, row := range orders.Values {
+		if id := row[0].(int64); id < 1 || id > 5 {
+			t.Errorf("Expected user_id to refer to a user, got %d", id)
+		}
+		if row[1] != "fixed" {
+			t.Errorf("Expected the constant value, got %#v", row[1])
+		}
+	}
+}
+
+func TestGenerate_Seeded(t *testing.T) {
+	schema := `
+tables:
+  t:
+    count: 10
+    fields:
+      name: "{{ fullName }}"
+      n: {type: int, value: "{{ randInt 1 1000 }}"}
+      maybe: {value: x, null_rate: 0.5}
+`
+	first, _ := Generate(parseSchema(t, schema), 9)
+	second, _ := Generate(parseSchema(t, schema), 9)
+	other, _ := Generate(parseSchema(t, schema), 10)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same seed to give the same data")
+	}
+	if reflect.DeepEqual(first, other) {
+		t.Errorf("Expected another seed to give other data")
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	tests := map[string]string{
+		`table t, row 1: field n: "abc" is not an int`:   `tables: {t: {count: 1, fields: {n: {type: int, value: abc}}}}`,
+		`table t, row 1: field b: "maybe" is not a bool`: `tables: {t: {count: 1, fields: {b: {type: bool, value: maybe}}}}`,
+		`field j: "{" is not JSON`:                       `tables: {t: {count: 1, fields: {j: {type: json, value: "{"}}}}`,
+		"pick: empty list":                               `tables: {t: {count: 1, fields: {r: "{{ pick .Tables.missing.id }}"}}}`,
+	}
+	for want, content := range tests {
+		if _, err := Generate(parseSchema(t, content), 1); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package fixture
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Output formats
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatSQL  = "sql"
+)
+
+// sqlBatchSize is how many rows each INSERT statement carries
+const sqlBatchSize = 100
+
+// Extension returns the file extension for a format
+func Extension(format string) string {
+	return "." + format
+}
+
+// Write writes tables in the format. CSV holds a single table.
+func Write(w io.Writer, format string, tables []*Rows) error {
+	switch format {
+	case FormatJSON:
+		return WriteJSON(w, tables)
+	case FormatCSV:
+		if len(tables) != 1 {
+			return fmt.Errorf("csv holds one table, got %d", len(tables))
+		}
+		return WriteCSV(w, tables[0])
+	case FormatSQL:
+		return WriteSQL(w, tables)
+	default:
+		return fmt.Errorf("unknown format %q (use json, csv or sql)", format)
+	}
+}
+
+// WriteJSON writes an object with an array of rows for each table, one
+// row per line with the fields in schema order
+func WriteJSON(w io.Writer, tables []*Rows) error {
+	out := bufio.NewWriter(w)
+	out.WriteString("{")
+	for i, table := range tables {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		fmt.Fprintf(out, "\n  %s: [", quoteJSON(table.Name))
+		for j, values := range table.Values {
+			if j > 0 {
+				out.WriteString(",")
+			}
+			out.WriteString("\n    {")
+			for k, value := range values {
+				if k > 0 {
+					out.WriteString(", ")
+				}
+				data, err := json.Marshal(value)
+				if err != nil {
+					return fmt.Errorf("table %s: %s: %w", table.Name, table.Columns[k], err)
+				}
+				fmt.Fprintf(out, "%s: %s", quoteJSON(table.Columns[k]), data)
+			}
+			out.WriteString("}")
+		}
+		if len(table.Values) > 0 {
+			out.WriteString("\n  ")
+		}
+		out.WriteString("]")
+	}
+	out.WriteString("\n}\n")
+	return out.Flush()
+}
+
+func quoteJSON(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// WriteCSV writes a header row and the table's rows. Nulls are empty and
+// JSON values are written compactly.
+func WriteCSV(w io.Writer, table *Rows) error {
+	out := csv.NewWriter(w)
+	out.Write(table.Columns)
+	record := make([]string, len(table.Columns))
+	for _, values := range table.Values {
+		for i, value := range values {
+			text, err := formatText(value)
+			if err != nil {
+				return fmt.Errorf("table %s: %s: %w", table.Name, table.Columns[i], err)
+			}
+			record[i] = text
+		}
+		out.Write(record)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// WriteSQL writes INSERT statements of up to 100 rows each
+func WriteSQL(w io.Writer, tables []*Rows) error {
+	out := bufio.NewWriter(w)
+	for _, table := range tables {
+		for start := 0; start < len(table.Values); start += sqlBatchSize {
+			end := start + sqlBatchSize
+			if end > len(table.Values) {
+				end = len(table.Values)
+			}
+			fmt.Fprintf(out, "INSERT INTO %s (%s) VALUES\n", table.Name, strings.Join(table.Columns, ", "))
+			for i, values := range table.Values[start:end] {
+				literals := make([]string, len(values))
+				for j, value := range values {
+					literal, err := sqlLiteral(value)
+					if err != nil {
+						return fmt.Errorf("table %s: %s: %w", table.Name, table.Columns[j], err)
+					}
+					literals[j] = literal
+				}
+				separator := ","
+				if start+i == end-1 {
+					separator = ";"
+				}
+				fmt.Fprintf(out, "  (%s)%s\n", strings.Join(literals, ", "), separator)
+			}
+		}
+		if len(table.Values) > 0 {
+			out.WriteString("\n")
+		}
+	}
+	return out.Flush()
+}
+
+// sqlLiteral formats a value in standard SQL: NULL, TRUE and FALSE, bare
+// numbers and quoted strings, with JSON values as JSON strings
+func sqlLiteral(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case int64, float64:
+		return formatText(v)
+	default:
+		text, err := formatText(v)
+		if err != nil {
+			return "", err
+		}
+		return "'" + strings.ReplaceAll(text, "'", "''") + "'", nil
+	}
+}
+
+// formatText formats a value as plain text
+func formatText(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+}
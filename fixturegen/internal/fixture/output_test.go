@@ -0,0 +1,88 @@
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var testRows = []*Rows{
+	{
+		Name:    "users",
+		Columns: []string{"id", "name", "active", "score", "tags", "team"},
+		Values: [][]interface{}{
+			{int64(1), "Ada", true, 0.5, []interface{}{"admin"}, nil},
+			{int64(2), "Miles O'Brien", false, 12.0, map[string]interface{}{"a": 1.0}, "Acme, Inc"},
+		},
+	},
+	{Name: "empty", Columns: []string{"id"}},
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, testRows); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	want := `{
+  "users": [
+    {"id": 1, "name": "Ada", "active": true, "score": 0.5, "tags": ["admin"], "team": null},
+    {"id": 2, "name": "Miles O'Brien", "active": false, "score": 12, "tags": {"a":1}, "team": "Acme, Inc"}
+  ],
+  "empty": []
+}
+`
+	if buf.String() != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", buf.String(), want)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Errorf("Expected valid JSON: %v", err)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, testRows[:1]); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	want := `id,name,active,score,tags,team
+1,Ada,true,0.5,"[""admin""]",
+2,Miles O'Brien,false,12,"{""a"":1}","Acme, Inc"
+`
+	if buf.String() != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", buf.String(), want)
+	}
+
+	if err := Write(&buf, FormatCSV, testRows); err == nil {
+		t.Errorf("Expected CSV to refuse several tables")
+	}
+}
+
+func TestWriteSQL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatSQL, testRows); err != nil {
+		t.Fatalf("WriteSQL failed: %v", err)
+	}
+	want := "INSERT INTO users (id, name, active, score, tags, team) VALUES\n" +
+		"  (1, 'Ada', TRUE, 0.5, '[\"admin\"]', NULL),\n" +
+		"  (2, 'Miles O''Brien', FALSE, 12, '{\"a\":1}', 'Acme, Inc');\n\n"
+	if buf.String() != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteSQL_Batches(t *testing.T) {
+	rows := &Rows{Name: "t", Columns: []string{"n"}}
+	for i := 0; i < 250; i++ {
+		rows.Values = append(rows.Values, []interface{}{int64(i)})
+	}
+	var buf bytes.Buffer
+	WriteSQL(&buf, []*Rows{rows})
+	if n := strings.Count(buf.String(), "INSERT INTO t"); n != 3 {
+		t.Errorf("Expected 3 statements for 250 rows, got %d", n)
+	}
+	if n := strings.Count(buf.String(), ";\n"); n != 3 {
+		t.Errorf("Expected every statement to end, got %d", n)
+	}
+}
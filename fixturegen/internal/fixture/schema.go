@@ -0,0 +1,174 @@
+// Package fixture generates seed datasets from declarative schemas using
+// the scheduler's template functions.
+package fixture
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Field types, converting each rendered value
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeFloat  = "float"
+	TypeBool   = "bool"
+	TypeJSON   = "json"
+)
+
+// identifierPattern limits table and field names to ones that need no
+// quoting in SQL, CSV headers or templates
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Schema describes the tables to generate
+type Schema struct {
+	// Seed makes the data reproducible; overridden by --seed
+	Seed int64 `yaml:"seed,omitempty"`
+
+	// Tables are generated in order, so later tables can refer to the rows
+	// of earlier ones
+	Tables Tables `yaml:"tables"`
+}
+
+// Table is one set of rows
+type Table struct {
+	Name   string `yaml:"-"`
+	Count  int    `yaml:"count"`
+	Fields Fields `yaml:"fields"`
+}
+
+// Field is one column, rendered from a template for every row
+type Field struct {
+	Name string `yaml:"-"`
+
+	// Value is a template; see Data for what it can refer to
+	Value string `yaml:"value"`
+
+	// Type converts the rendered value; defaults to string
+	Type string `yaml:"type,omitempty"`
+
+	// NullRate is the fraction of rows, from 0 to 1, left null
+	NullRate float64 `yaml:"null_rate,omitempty"`
+}
+
+// Tables keeps the order tables are written in
+type Tables []*Table
+
+// UnmarshalYAML reads a mapping of table names to tables
+func (t *Tables) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: tables must be a mapping of names to tables", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		table := &Table{Name: node.Content[i].Value}
+		if err := node.Content[i+1].Decode(table); err != nil {
+			return err
+		}
+		*t = append(*t, table)
+	}
+	return nil
+}
+
+// Fields keeps the order fields are written in, which is also the order
+// they are rendered in
+type Fields []*Field
+
+// UnmarshalYAML reads a mapping of field names to templates, or to fields
+// with a value and type
+func (f *Fields) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: fields must be a mapping of names to values", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		field := &Field{Name: node.Content[i].Value}
+		value := node.Content[i+1]
+		switch value.Kind {
+		case yaml.ScalarNode:
+			field.Value = value.Value
+		case yaml.MappingNode:
+			if err := value.Decode(field); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("line %d: %s must be a template or a mapping with value and type", value.Line, field.Name)
+		}
+		*f = append(*f, field)
+	}
+	return nil
+}
+
+// LoadSchema reads and validates a schema file
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	var schema Schema
+	if err := yaml.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema file: %w", err)
+	}
+	if err := schema.Validate(); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// Validate checks the tables and fills in default types
+func (s *Schema) Validate() error {
+	if len(s.Tables) == 0 {
+		return fmt.Errorf("at least one table is required")
+	}
+	tables := make(map[string]bool)
+	for _, table := range s.Tables {
+		if !identifierPattern.MatchString(table.Name) {
+			return fmt.Errorf("invalid table name %q", table.Name)
+		}
+		if tables[table.Name] {
+			return fmt.Errorf("table %s is defined twice", table.Name)
+		}
+		tables[table.Name] = true
+		if table.Count < 0 {
+			return fmt.Errorf("table %s: count must not be negative", table.Name)
+		}
+		if len(table.Fields) == 0 {
+			return fmt.Errorf("table %s: at least one field is required", table.Name)
+		}
+
+		fields := make(map[string]bool)
+		for _, field := range table.Fields {
+			if !identifierPattern.MatchString(field.Name) {
+				return fmt.Errorf("table %s: invalid field name %q", table.Name, field.Name)
+			}
+			if fields[field.Name] {
+				return fmt.Errorf("table %s: field %s is defined twice", table.Name, field.Name)
+			}
+			fields[field.Name] = true
+
+			switch field.Type {
+			case "":
+				field.Type = TypeString
+			case TypeString, TypeInt, TypeFloat, TypeBool, TypeJSON:
+			default:
+				return fmt.Errorf("table %s: field %s: unknown type %q (use string, int, float, bool or json)", table.Name, field.Name, field.Type)
+			}
+			if field.NullRate < 0 || field.NullRate > 1 {
+				return fmt.Errorf("table %s: field %s: null_rate must be between 0 and 1", table.Name, field.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Table returns the table with the given name, or nil
+func (s *Schema) Table(name string) *Table {
+	for _, table := range s.Tables {
+		if table.Name == name {
+			return table
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package fixture
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoadSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	os.WriteFile(path, []byte(`
+seed: 7
+tables:
+  users:
+    count: 3
+    fields:
+      id: {type: int, value: "{{ .Index }}"}
+      name: "{{ fullName }}"
+      nickname: {value: "{{ word }}", null_rate: 0.5}
+  orders:
+    count: 5
+    fields:
+      user_id: {type: int, value: "{{ pick .Tables.users.id }}"}
+`), 0o644)
+
+	schema, err := LoadSchema(path)
+	if err != nil {
+		t.Fatalf("LoadSchema failed: %v", err)
+	}
+	if schema.Seed != 7 || len(schema.Tables) != 2 || schema.Tables[0].Name != "users" || schema.Tables[1].Name != "orders" {
+		t.Fatalf("Expected the tables in file order, got %+v", schema)
+	}
+
+	users := schema.Table("users")
+	var names []string
+	for _, field := range users.Fields {
+		names = append(names, field.Name+":"+field.Type)
+	}
+	if got := strings.Join(names, ","); got != "id:int,name:string,nickname:string" {
+		t.Errorf("Expected the fields in file order with types, got %s", got)
+	}
+	if users.Fields[2].NullRate != 0.5 || users.Fields[1].Value != "{{ fullName }}" {
+		t.Errorf("Unexpected fields %+v, %+v", users.Fields[1], users.Fields[2])
+	}
+	if schema.Table("missing") != nil {
+		t.Errorf("Expected no table")
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	tests := map[string]string{
+		"at least one table":                `{}`,
+		`invalid table name "user-list"`:    "tables: {user-list: {count: 1, fields: {a: x}}}",
+		"count must not be negative":        "tables: {t: {count: -1, fields: {a: x}}}",
+		"at least one field":                "tables: {t: {count: 1}}",
+		`invalid field name "first name"`:   `tables: {t: {count: 1, fields: {"first name": x}}}`,
+		`unknown type "date"`:               "tables: {t: {count: 1, fields: {a: {value: x, type: date}}}}",
+		"null_rate must be between 0 and 1": "tables: {t: {count: 1, fields: {a: {value: x, null_rate: 2}}}}",
+	}
+	for want, content := range tests {
+		var schema Schema
+		if err := yaml.Unmarshal([]byte(content), &schema); err != nil {
+			t.Errorf("Unmarshal(%q) failed: %v", content, err)
+			continue
+		}
+		if err := schema.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %v", want, err)
+		}
+	}
+
+	var schema Schema
+	if err := yaml.Unmarshal([]byte("tables: {t: {fields: {a: [1, 2]}}}"), &schema); err == nil {
+		t.Errorf("Expected a list field to be rejected")
+	}
+}
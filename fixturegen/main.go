@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"local-dev-tools/fixturegen/internal/fixture"
+)
+
+// countFlags collects repeated --count table=N flags
+type countFlags map[string]int
+
+func (c countFlags) String() string { return "" }
+
+func (c countFlags) Set(v string) error {
+	name, value, ok := strings.Cut(v, "=")
+	n, err := strconv.Atoi(value)
+	if !ok || err != nil || n < 0 {
+		return fmt.Errorf("expected table=count, got %q", v)
+	}
+	c[name] = n
+	return nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run generates the dataset and returns the process exit code
+func run(args []string) int {
+	fs := flag.NewFlagSet("fixturegen", flag.ExitOnError)
+	schemaPath := fs.String("schema", "fixtures.yaml", "Path to the schema file (YAML or JSON)")
+	format := fs.String("format", fixture.FormatJSON, "Output format: json, csv or sql")
+	out := fs.String("out", "", "File to write, or a directory for one file per table (stdout when empty)")
+	seed := fs.Int64("seed", 0, "Seed for the data, overriding the schema's (random when neither is set)")
+	only := fs.String("tables", "", "Comma-separated tables to write (all when empty); every table is still generated for references")
+	counts := countFlags{}
+	fs.Var(counts, "count", "Row count for a table as table=N, overriding the schema (repeatable)")
+	fs.Parse(args)
+
+	switch *format {
+	case fixture.FormatJSON, fixture.FormatCSV, fixture.FormatSQL:
+	default:
+		log.Printf("Error: unknown format %q (use json, csv or sql)", *format)
+		return 2
+	}
+
+	schema, err := fixture.LoadSchema(*schemaPath)
+	if err != nil {
+		log.Printf("Error loading schema: %v", err)
+		return 2
+	}
+	for name, n := range counts {
+		table := schema.Table(name)
+		if table == nil {
+			log.Printf("Error: --count: no table %s in the schema", name)
+			return 2
+		}
+		table.Count = n
+	}
+
+	if *seed == 0 {
+		*seed = schema.Seed
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano() % 1_000_000
+		if *seed == 0 {
+			*seed = 1
+		}
+	}
+
+	dataset, err := fixture.Generate(schema, *seed)
+	if err != nil {
+		log.Printf("Error generating data: %v", err)
+		return 2
+	}
+
+	tables := dataset.Tables
+	if *only != "" {
+		tables = nil
+		for _, name := range strings.Split(*only, ",") {
+			table := findRows(dataset, strings.TrimSpace(name))
+			if table == nil {
+				log.Printf("Error: --tables: no table %s in the schema", name)
+				return 2
+			}
+			tables = append(tables, table)
+		}
+	}
+
+	if *format == fixture.FormatCSV && len(tables) > 1 && !isDir(*out) {
+		log.Printf("Error: csv writes one table per file; give a directory with --out, or choose a table with --tables")
+		return 2
+	}
+	if err := write(*out, *format, tables); err != nil {
+		log.Printf("Error: %v", err)
+		return 3
+	}
+
+	var summary []string
+	for _, table := range tables {
+		summary = append(summary, fmt.Sprintf("%s (%d rows)", table.Name, len(table.Values)))
+	}
+	fmt.Fprintf(os.Stderr, "Generated %s with seed %d\n", strings.Join(summary, ", "), *seed)
+	return 0
+}
+
+func findRows(dataset *fixture.Dataset, name string) *fixture.Rows {
+	for _, table := range dataset.Tables {
+		if table.Name == name {
+			return table
+		}
+	}
+	return nil
+}
+
+// write sends the tables to stdout, a file, or one file per table in a
+// directory
+func write(out, format string, tables []*fixture.Rows) error {
+	if !isDir(out) {
+		var buf bytes.Buffer
+		if err := fixture.Write(&buf, format, tables); err != nil {
+			return err
+		}
+		if out == "" {
+			_, err := os.Stdout.Write(buf.Bytes())
+			return err
+		}
+		return os.WriteFile(out, buf.Bytes(), 0o644)
+	}
+
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return err
+	}
+	for _, table := range tables {
+		var buf bytes.Buffer
+		if err := fixture.Write(&buf, format, []*fixture.Rows{table}); err != nil {
+			return err
+		}
+		path := filepath.Join(out, table.Name+fixture.Extension(format))
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isDir reports whether --out names a directory: an existing one, or any
+// path ending in a slash
+func isDir(out string) bool {
+	if out == "" {
+		return false
+	}
+	info, err := os.Stat(out)
+	return strings.HasSuffix(out, "/") || (err == nil && info.IsDir())
+}
@@ -0,0 +1,197 @@
+// Package health probes a dev stack's services on an interval, keeps a
+// history of the results and serves them as a status page.
+package health
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/requests"
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+// Service kinds returned by Service.Kind
+const (
+	KindHTTP = "http"
+	KindTCP  = "tcp"
+)
+
+// Defaults for settings the config leaves out
+const (
+	DefaultListen   = ":8099"
+	DefaultInterval = 10 * time.Second
+	DefaultTimeout  = 5 * time.Second
+	DefaultHistory  = 24 * time.Hour
+)
+
+// Config is a services file
+type Config struct {
+	// Listen is the status page's address
+	Listen string `yaml:"listen,omitempty"`
+
+	// Interval is the time between probes of each service, Timeout bounds
+	// each probe, and History is how long results are kept
+	Interval string `yaml:"interval,omitempty"`
+	Timeout  string `yaml:"timeout,omitempty"`
+	History  string `yaml:"history,omitempty"`
+
+	Services []Service `yaml:"services"`
+
+	interval time.Duration
+	timeout  time.Duration
+	history  time.Duration
+}
+
+// Service is one part of the stack to probe. Exactly one of HTTP and TCP is
+// set.
+type Service struct {
+	Name string `yaml:"name,omitempty"`
+
+	// Group collects services under a heading on the status page
+	Group string `yaml:"group,omitempty"`
+
+	// HTTP is a request in the scheduler's http format; templates are
+	// resolved once, at startup
+	HTTP *requests.HTTP `yaml:"http,omitempty"`
+
+	// ExpectStatus lists the healthy status codes; any 2xx when empty
+	ExpectStatus []int `yaml:"expect_status,omitempty"`
+
+	// TCP is a host:port that must accept connections
+	TCP string `yaml:"tcp,omitempty"`
+}
+
+// LoadConfig reads a YAML or JSON services file. It is validated once flags
+// have been applied.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return &config, nil
+}
+
+// Validate checks the settings and every service, filling defaults
+func (c *Config) Validate() error {
+	if c.Listen == "" {
+		c.Listen = DefaultListen
+	}
+	for _, field := range []struct {
+		name     string
+		value    string
+		parsed   *time.Duration
+		fallback time.Duration
+	}{
+		{"interval", c.Interval, &c.interval, DefaultInterval},
+		{"timeout", c.Timeout, &c.timeout, DefaultTimeout},
+		{"history", c.History, &c.history, DefaultHistory},
+	} {
+		*field.parsed = field.fallback
+		if field.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(field.value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%s must be a positive duration (e.g. 30s)", field.name)
+		}
+		*field.parsed = d
+	}
+	if c.history < c.interval {
+		return fmt.Errorf("history %v is shorter than the interval %v", c.history, c.interval)
+	}
+
+	if len(c.Services) == 0 {
+		return fmt.Errorf("no services to probe")
+	}
+	names := make(map[string]bool)
+	for i := range c.Services {
+		service := &c.Services[i]
+		if err := service.Validate(); err != nil {
+			return fmt.Errorf("service %d: %w", i, err)
+		}
+		if names[service.Name] {
+			return fmt.Errorf("service %d: duplicate name %q", i, service.Name)
+		}
+		names[service.Name] = true
+	}
+	return nil
+}
+
+// Kind returns the kind of probe the service needs
+func (s *Service) Kind() string {
+	if s.HTTP != nil {
+		return KindHTTP
+	}
+	return KindTCP
+}
+
+// Address returns what the service's probe checks
+func (s *Service) Address() string {
+	if s.HTTP != nil {
+		return s.HTTP.URL.Raw()
+	}
+	return "tcp://" + s.TCP
+}
+
+// Validate checks that exactly one probe is configured and fills defaults
+func (s *Service) Validate() error {
+	if (s.HTTP != nil) == (s.TCP != "") {
+		return fmt.Errorf("exactly one of http or tcp must be set")
+	}
+
+	if s.HTTP != nil {
+		if s.HTTP.Method == "" {
+			s.HTTP.Method = "GET"
+		}
+		if err := s.HTTP.Validate(); err != nil {
+			return err
+		}
+		for _, status := range s.ExpectStatus {
+			if status < 100 || status > 599 {
+				return fmt.Errorf("expect_status %d is not an HTTP status code", status)
+			}
+		}
+	} else {
+		if host, port, err := net.SplitHostPort(s.TCP); err != nil || host == "" || port == "" {
+			return fmt.Errorf("tcp: address %q must be host:port", s.TCP)
+		}
+		if len(s.ExpectStatus) > 0 {
+			return fmt.Errorf("expect_status only applies to http services")
+		}
+	}
+
+	if s.Name == "" {
+		s.Name = s.Address()
+	}
+	return nil
+}
+
+// Resolve evaluates the templates of an HTTP service with engine
+func (s *Service) Resolve(engine *templating.Engine) error {
+	if s.HTTP == nil {
+		return nil
+	}
+	resolved, err := requests.ResolveHTTP(engine, *s.HTTP)
+	if err != nil {
+		return err
+	}
+	if s.Name == s.HTTP.URL.Raw() {
+		s.Name = resolved.URL
+	}
+	s.HTTP = &requests.HTTP{
+		Method:  resolved.Method,
+		URL:     requests.LiteralString(resolved.URL),
+		Headers: requests.LiteralHeaders(resolved.Headers),
+		Body:    requests.LiteralBody(resolved.Body),
+	}
+	return nil
+}
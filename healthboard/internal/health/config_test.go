@@ -0,0 +1,91 @@
+package health
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/requests"
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	os.WriteFile(path, []byte(`
+interval: 30s
+services:
+  - name: api
+    group: backend
+    http:
+      url: http://localhost:3000/health
+    expect_status: [200, 204]
+  - tcp: localhost:5432
+`), 0o644)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if config.Listen != DefaultListen || config.interval != 30*time.Second || config.timeout != DefaultTimeout || config.history != DefaultHistory {
+		t.Errorf("Got settings %s %v %v %v", config.Listen, config.interval, config.timeout, config.history)
+	}
+	api := config.Services[0]
+	if api.Kind() != KindHTTP || api.HTTP.Method != "GET" || api.Group != "backend" {
+		t.Errorf("Got api %+v", api)
+	}
+	if db := config.Services[1]; db.Kind() != KindTCP || db.Name != "tcp://localhost:5432" {
+		t.Errorf("Got database %+v", db)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	api := Service{Name: "api", HTTP: &requests.HTTP{URL: requests.LiteralString("http://localhost:3000/health")}}
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{name: "no services", config: Config{}, wantErr: "no services"},
+		{name: "bad interval", config: Config{Interval: "often", Services: []Service{api}}, wantErr: "interval"},
+		{name: "history shorter than interval", config: Config{Interval: "1m", History: "30s", Services: []Service{api}}, wantErr: "history"},
+		{name: "both probes", config: Config{Services: []Service{{HTTP: api.HTTP, TCP: "localhost:1"}}}, wantErr: "exactly one"},
+		{name: "no probe", config: Config{Services: []Service{{Name: "nothing"}}}, wantErr: "exactly one"},
+		{name: "bad tcp", config: Config{Services: []Service{{TCP: "localhost"}}}, wantErr: "host:port"},
+		{name: "status on tcp", config: Config{Services: []Service{{TCP: "localhost:1", ExpectStatus: []int{200}}}}, wantErr: "only applies"},
+		{name: "bad status", config: Config{Services: []Service{{HTTP: &requests.HTTP{URL: requests.LiteralString("http://localhost")}, ExpectStatus: []int{42}}}}, wantErr: "not an HTTP status"},
+		{name: "duplicate names", config: Config{Services: []Service{{Name: "db", TCP: "localhost:1"}, {Name: "db", TCP: "localhost:2"}}}, wantErr: "duplicate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_Resolve(t *testing.T) {
+	t.Setenv("API_URL", "http://localhost:3000")
+	service := Service{HTTP: &requests.HTTP{
+		URL:     requests.LiteralString(`{{ env "API_URL" }}/health`),
+		Headers: requests.LiteralHeaders(map[string]string{"X-Probe": `{{ "healthboard" | upper }}`}),
+	}}
+	if err := service.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if err := service.Resolve(templating.New(nil, 0)); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	if service.Name != "http://localhost:3000/health" || service.HTTP.URL.GetValue() != service.Name || service.HTTP.Headers["X-Probe"].GetValue() != "HEALTHBOARD" {
+		t.Errorf("Got %+v %+v", service, service.HTTP)
+	}
+}
@@ -0,0 +1,168 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxSamples bounds each service's history, however long it is configured
+const maxSamples = 100000
+
+// Status is a service's current state and history
+type Status struct {
+	Name    string `json:"name"`
+	Group   string `json:"group,omitempty"`
+	Kind    string `json:"kind"`
+	Address string `json:"address"`
+
+	// Checked is false until the first probe completes
+	Checked bool `json:"checked"`
+	Up      bool `json:"up"`
+
+	// Since is when the service last changed state, or was first probed
+	Since time.Time `json:"since"`
+
+	// Uptime is the fraction of the kept samples that were up
+	Uptime float64 `json:"uptime"`
+
+	// Samples are the kept results, oldest first
+	Samples []Sample `json:"samples"`
+}
+
+// Last returns the latest sample, or a zero one before the first probe
+func (s *Status) Last() Sample {
+	if len(s.Samples) == 0 {
+		return Sample{}
+	}
+	return s.Samples[len(s.Samples)-1]
+}
+
+// history is what the monitor keeps for one service
+type history struct {
+	samples []Sample
+	up      int
+	since   time.Time
+}
+
+// Monitor probes every service on an interval and keeps their results.
+// Status may be called while Run is running.
+type Monitor struct {
+	services []Service
+	prober   *Prober
+	interval time.Duration
+	keep     int
+	logf     func(format string, args ...interface{})
+
+	mu        sync.Mutex
+	histories []history
+}
+
+// NewMonitor creates a monitor for a validated config. Changes of state are
+// reported to logf.
+func NewMonitor(config *Config, logf func(format string, args ...interface{})) *Monitor {
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+	return &Monitor{
+		services:  config.Services,
+		prober:    NewProber(config.timeout),
+		interval:  config.interval,
+		keep:      min(max(int(config.history/config.interval), 1), maxSamples),
+		logf:      logf,
+		histories: make([]history, len(config.Services)),
+	}
+}
+
+// Run probes every service straight away and then on each interval, until
+// the context ends
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.ProbeAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProbeAll probes every service at once and records the results
+func (m *Monitor) ProbeAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := range m.services {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sample := m.prober.Probe(ctx, &m.services[i])
+			if ctx.Err() == nil {
+				m.record(i, sample)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// record adds a sample to a service's history, dropping the oldest once the
+// history is full
+func (m *Monitor) record(i int, sample Sample) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h := &m.histories[i]
+	if n := len(h.samples); n == 0 || h.samples[n-1].Up != sample.Up {
+		h.since = sample.Time
+		m.logChange(&m.services[i], sample, n == 0)
+	}
+
+	if len(h.samples) == m.keep {
+		if h.samples[0].Up {
+			h.up--
+		}
+		h.samples = append(h.samples[:0], h.samples[1:]...)
+	}
+	h.samples = append(h.samples, sample)
+	if sample.Up {
+		h.up++
+	}
+}
+
+func (m *Monitor) logChange(service *Service, sample Sample, first bool) {
+	switch {
+	case sample.Up:
+		m.logf("✓ %s is up (%s, %s)", service.Name, sample.Detail, formatLatency(sample.Latency))
+	case first:
+		m.logf("✗ %s is down: %s", service.Name, sample.Detail)
+	default:
+		m.logf("✗ %s went down: %s", service.Name, sample.Detail)
+	}
+}
+
+// Status returns every service's state, in config order
+func (m *Monitor) Status() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]Status, len(m.services))
+	for i := range m.services {
+		service, h := &m.services[i], &m.histories[i]
+		status := Status{
+			Name:    service.Name,
+			Group:   service.Group,
+			Kind:    service.Kind(),
+			Address: service.Address(),
+			Checked: len(h.samples) > 0,
+			Since:   h.since,
+			Samples: append([]Sample(nil), h.samples...),
+		}
+		if status.Checked {
+			status.Up = status.Last().Up
+			status.Uptime = float64(h.up) / float64(len(h.samples))
+		}
+		statuses[i] = status
+	}
+	return statuses
+}
@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/requests"
+)
+
+func newTestMonitor(t *testing.T, config *Config, logf func(string, ...interface{})) *Monitor {
+	t.Helper()
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Invalid config: %v", err)
+	}
+	return NewMonitor(config, logf)
+}
+
+func TestMonitor_ProbeAll(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	var logged []string
+	config := &Config{Services: []Service{
+		{Name: "api", Group: "backend", HTTP: &requests.HTTP{URL: requests.LiteralString(server.URL)}},
+		{Name: "db", TCP: "127.0.0.1:1"},
+	}}
+	monitor := newTestMonitor(t, config, func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+
+	if status := monitor.Status(); status[0].Checked || status[0].Up {
+		t.Errorf("Got %+v before the first probe", status[0])
+	}
+
+	monitor.ProbeAll(context.Background())
+	healthy.Store(true)
+	monitor.ProbeAll(context.Background())
+	monitor.ProbeAll(context.Background())
+
+	status := monitor.Status()
+	api, db := status[0], status[1]
+	if !api.Checked || !api.Up || len(api.Samples) != 3 || api.Kind != KindHTTP || api.Group != "backend" {
+		t.Errorf("Got api %+v", api)
+	}
+	if api.Uptime < 0.66 || api.Uptime > 0.67 {
+		t.Errorf("Got api uptime %v, want 2/3", api.Uptime)
+	}
+	if !api.Since.Equal(api.Samples[1].Time) {
+		t.Errorf("Api up since %v, want the second probe at %v", api.Since, api.Samples[1].Time)
+	}
+	if db.Up || db.Uptime != 0 || !db.Since.Equal(db.Samples[0].Time) || db.Address != "tcp://127.0.0.1:1" {
+		t.Errorf("Got db %+v", db)
+	}
+
+	// Changes are logged once each: both first results, then the api recovering
+	if len(logged) != 3 {
+		t.Errorf("Got log lines %q", logged)
+	}
+}
+
+func TestMonitor_KeepsHistory(t *testing.T) {
+	config := &Config{Interval: "1s", History: "3s", Services: []Service{{Name: "api", TCP: "localhost:1"}}}
+	monitor := newTestMonitor(t, config, nil)
+
+	start := time.Now()
+	for i, up := range []bool{false, true, true, false, true} {
+		monitor.record(0, Sample{Time: start.Add(time.Duration(i) * time.Second), Up: up})
+	}
+
+	status := monitor.Status()[0]
+	if len(status.Samples) != 3 || !status.Samples[0].Time.Equal(start.Add(2*time.Second)) {
+		t.Errorf("Kept samples %+v, want the last 3", status.Samples)
+	}
+	if status.Uptime < 0.66 || status.Uptime > 0.67 {
+		t.Errorf("Got uptime %v, want 2/3", status.Uptime)
+	}
+	if !status.Since.Equal(start.Add(4 * time.Second)) {
+		t.Errorf("Got since %v", status.Since)
+	}
+}
@@ -0,0 +1,188 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+)
+
+// barSamples is how many of the latest samples each service's bar shows
+const barSamples = 60
+
+var statusPage = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{ .Refresh }}">
+<title>{{ if eq .Up .Total }}✓{{ else }}✗{{ end }} {{ .Up }}/{{ .Total }} up</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 64rem; margin: 2rem auto; color: #222; }
+table { width: 100%; border-collapse: collapse; margin-bottom: 2rem; }
+th, td { text-align: left; padding: .4rem .5rem; border-bottom: 1px solid #eee; vertical-align: middle; }
+th { font-weight: 600; color: #666; font-size: .85rem; }
+.muted { color: #666; font-size: .85rem; }
+.dot { font-size: 1.2rem; }
+.up { color: #1a7f37; } .down { color: #cf222e; } .pending { color: #999; }
+.bar { display: flex; gap: 1px; }
+.bar span { width: 5px; height: 1.2rem; border-radius: 1px; background: #ddd; }
+.bar .up { background: #2da44e; } .bar .down { background: #cf222e; }
+.detail { max-width: 20rem; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+</style>
+</head>
+<body>
+<h1>{{ .Up }} of {{ .Total }} services up</h1>
+<p class="muted">Probed every {{ .Interval }}; uptime covers the last {{ .Window }}. Updated {{ .Updated }}.</p>
+{{ range .Groups }}{{ if .Name }}<h2>{{ .Name }}</h2>
+{{ end }}<table>
+<tr><th></th><th>Service</th><th>Status</th><th>For</th><th>Uptime</th><th>Last {{ $.Bars }} probes</th></tr>
+{{ range .Rows }}<tr>
+<td class="dot {{ .State }}">●</td>
+<td>{{ .Name }}<br><span class="muted">{{ .Address }}</span></td>
+<td class="detail" title="{{ .Detail }}">{{ .Detail }}{{ if .Latency }} <span class="muted">{{ .Latency }}</span>{{ end }}</td>
+<td>{{ .For }}</td>
+<td>{{ .Uptime }}</td>
+<td><div class="bar">{{ range .Bars }}<span class="{{ .Class }}" title="{{ .Title }}"></span>{{ end }}</div></td>
+</tr>
+{{ end }}</table>
+{{ end }}</body>
+</html>
+`))
+
+// pageData is what the status page shows
+type pageData struct {
+	Up, Total int
+	Groups    []pageGroup
+	Interval  time.Duration
+	Window    string
+	Updated   string
+	Refresh   int
+	Bars      int
+}
+
+type pageGroup struct {
+	Name string
+	Rows []pageRow
+}
+
+type pageRow struct {
+	Name, Address string
+	State         string
+	Detail        string
+	Latency       string
+	For           string
+	Uptime        string
+	Bars          []pageBar
+}
+
+type pageBar struct {
+	Class, Title string
+}
+
+// Handler serves the status page at / and the statuses as JSON at
+// /api/status
+func (m *Monitor) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(m.Status())
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := statusPage.Execute(w, m.page(time.Now())); err != nil {
+			log.Printf("Failed to render status page: %v", err)
+		}
+	})
+	return mux
+}
+
+// page lays out the current statuses, grouped in the order groups first
+// appear in the config
+func (m *Monitor) page(now time.Time) pageData {
+	data := pageData{
+		Interval: m.interval,
+		Window:   formatDuration(time.Duration(m.keep) * m.interval),
+		Updated:  now.Format("15:04:05"),
+		Refresh:  max(int(m.interval.Seconds()), 1),
+		Bars:     barSamples,
+	}
+
+	groups := make(map[string]int)
+	for _, status := range m.Status() {
+		data.Total++
+		if status.Up {
+			data.Up++
+		}
+
+		i, ok := groups[status.Group]
+		if !ok {
+			i = len(data.Groups)
+			groups[status.Group] = i
+			data.Groups = append(data.Groups, pageGroup{Name: status.Group})
+		}
+		data.Groups[i].Rows = append(data.Groups[i].Rows, row(&status, now))
+	}
+	return data
+}
+
+func row(status *Status, now time.Time) pageRow {
+	r := pageRow{Name: status.Name, Address: status.Address, State: "pending", Detail: "waiting for the first probe"}
+	if status.Checked {
+		last := status.Last()
+		r.State = "down"
+		if status.Up {
+			r.State = "up"
+			r.Latency = formatLatency(last.Latency)
+		}
+		r.Detail = last.Detail
+		r.For = formatDuration(now.Sub(status.Since))
+		r.Uptime = fmt.Sprintf("%.1f%%", status.Uptime*100)
+	}
+
+	samples := status.Samples[max(len(status.Samples)-barSamples, 0):]
+	r.Bars = make([]pageBar, barSamples-len(samples), barSamples)
+	for _, s := range samples {
+		bar := pageBar{Class: "down", Title: s.Time.Format("15:04:05") + " " + s.Detail}
+		if s.Up {
+			bar.Class = "up"
+			bar.Title += " " + formatLatency(s.Latency)
+		}
+		r.Bars = append(r.Bars, bar)
+	}
+	return r
+}
+
+// formatLatency rounds a probe's latency for display
+func formatLatency(d time.Duration) string {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+// formatDuration shows a duration in its two largest units, leaving out a
+// second unit of zero
+func formatDuration(d time.Duration) string {
+	var large, small int
+	var units string
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		large, small, units = int(d.Minutes()), int(d.Seconds())%60, "ms"
+	case d < 24*time.Hour:
+		large, small, units = int(d.Hours()), int(d.Minutes())%60, "hm"
+	default:
+		large, small, units = int(d.Hours())/24, int(d.Hours())%24, "dh"
+	}
+	if small == 0 {
+		return fmt.Sprintf("%d%c", large, units[0])
+	}
+	return fmt.Sprintf("%d%c %d%c", large, units[0], small, units[1])
+}
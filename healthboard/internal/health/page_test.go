@@ -0,0 +1,87 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonitor_Page(t *testing.T) {
+	config := &Config{Services: []Service{
+		{Name: "api", Group: "backend", TCP: "localhost:1"},
+		{Name: "web", TCP: "localhost:2"},
+		{Name: "db", Group: "backend", TCP: "localhost:3"},
+	}}
+	monitor := newTestMonitor(t, config, nil)
+	now := time.Now()
+	monitor.record(0, Sample{Time: now.Add(-90 * time.Second), Up: true, Latency: 1500 * time.Microsecond, Detail: "connected"})
+	monitor.record(2, Sample{Time: now.Add(-10 * time.Second), Detail: "connection refused"})
+
+	page := monitor.page(now)
+	if page.Up != 1 || page.Total != 3 || len(page.Groups) != 2 {
+		t.Fatalf("Got %d/%d up in %d groups", page.Up, page.Total, len(page.Groups))
+	}
+	backend := page.Groups[0]
+	if backend.Name != "backend" || len(backend.Rows) != 2 || page.Groups[1].Rows[0].Name != "web" {
+		t.Errorf("Got groups %+v", page.Groups)
+	}
+
+	api, db, web := backend.Rows[0], backend.Rows[1], page.Groups[1].Rows[0]
+	if api.State != "up" || api.Latency != "2ms" || api.For != "1m 30s" || api.Uptime != "100.0%" {
+		t.Errorf("Got api %+v", api)
+	}
+	if db.State != "down" || db.Detail != "connection refused" || db.For != "10s" || db.Uptime != "0.0%" {
+		t.Errorf("Got db %+v", db)
+	}
+	if web.State != "pending" || web.Uptime != "" {
+		t.Errorf("Got web %+v", web)
+	}
+	if len(api.Bars) != barSamples || api.Bars[barSamples-1].Class != "up" || api.Bars[0].Class != "" {
+		t.Errorf("Got %d bars ending %+v", len(api.Bars), api.Bars[len(api.Bars)-1])
+	}
+}
+
+func TestMonitor_Handler(t *testing.T) {
+	monitor := newTestMonitor(t, &Config{Services: []Service{{Name: "db", TCP: "localhost:5432"}}}, nil)
+	monitor.record(0, Sample{Time: time.Now(), Up: true, Detail: "connected"})
+	handler := monitor.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "1 of 1 services up") || !strings.Contains(rec.Body.String(), "tcp://localhost:5432") {
+		t.Errorf("Got page %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	var statuses []Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(statuses) != 1 || !statuses[0].Up || statuses[0].Name != "db" || len(statuses[0].Samples) != 1 {
+		t.Errorf("Got %+v", statuses)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/favicon.ico", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Got %d for an unknown path", rec.Code)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		42 * time.Second:               "42s",
+		12*time.Minute + 5*time.Second: "12m 5s",
+		3*time.Hour + 20*time.Minute:   "3h 20m",
+		50*time.Hour + 59*time.Minute:  "2d 2h",
+	}
+	for d, want := range tests {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
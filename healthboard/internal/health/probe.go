@@ -0,0 +1,76 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/healthcheck"
+)
+
+// Sample is the result of one probe
+type Sample struct {
+	Time    time.Time     `json:"time"`
+	Up      bool          `json:"up"`
+	Latency time.Duration `json:"latency_ns"`
+
+	// Detail is the HTTP status of a healthy response, "connected" for a
+	// healthy TCP service, or why the service is down
+	Detail string `json:"detail,omitempty"`
+}
+
+// Prober runs single probes, each bounded by a timeout
+type Prober struct {
+	timeout time.Duration
+	client  *http.Client
+}
+
+// NewProber creates a prober whose probes each complete within timeout
+func NewProber(timeout time.Duration) *Prober {
+	return &Prober{
+		timeout: timeout,
+		client: &http.Client{
+			Transport: healthcheck.NewTransport(),
+			// Report redirects, such as to a login page, rather than follow
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// Probe checks the service once
+func (p *Prober) Probe(ctx context.Context, service *Service) Sample {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	var detail string
+	var err error
+	if service.HTTP != nil {
+		detail, err = p.probeHTTP(ctx, service)
+	} else {
+		detail, err = p.probeTCP(ctx, service)
+	}
+	sample := Sample{Time: start, Up: err == nil, Latency: time.Since(start), Detail: detail}
+	if err != nil {
+		sample.Detail = err.Error()
+		if ctx.Err() != nil {
+			sample.Detail = fmt.Sprintf("no response within %v", p.timeout)
+		}
+	}
+	return sample
+}
+
+// probeTCP is healthy once the address accepts a connection
+func (p *Prober) probeTCP(ctx context.Context, service *Service) (string, error) {
+	if err := healthcheck.TCP(ctx, service.TCP); err != nil {
+		return "", err
+	}
+	return "connected", nil
+}
+
+// probeHTTP is healthy on an expected status, any 2xx by default, and
+// returns the status
+func (p *Prober) probeHTTP(ctx context.Context, service *Service) (string, error) {
+	return healthcheck.HTTP(ctx, p.client, service.HTTP, service.ExpectStatus)
+}
@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/requests"
+)
+
+func TestProber_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		case "/warming":
+			http.Error(w, "warming caches", http.StatusServiceUnavailable)
+		case "/login":
+			http.Redirect(w, r, "/signin", http.StatusFound)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		expect     []int
+		wantUp     bool
+		wantDetail string
+	}{
+		{name: "healthy", path: "/health", wantUp: true, wantDetail: "200 OK"},
+		{name: "error body", path: "/warming", wantDetail: "status 503 Service Unavailable: warming caches"},
+		{name: "expected error", path: "/warming", expect: []int{503}, wantUp: true, wantDetail: "503 Service Unavailable"},
+		{name: "redirect not followed", path: "/login", wantDetail: "status 302 Found"},
+		{name: "timeout", path: "/slow", wantDetail: "no response within 50ms"},
+	}
+
+	prober := NewProber(50 * time.Millisecond)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &Service{
+				HTTP: &requests.HTTP{
+					Method:  "GET",
+					URL:     requests.LiteralString(server.URL + tt.path),
+					Headers: requests.LiteralHeaders(map[string]string{"Authorization": "Bearer token"}),
+				},
+				ExpectStatus: tt.expect,
+			}
+			sample := prober.Probe(context.Background(), service)
+			if sample.Up != tt.wantUp || !strings.HasPrefix(sample.Detail, tt.wantDetail) {
+				t.Errorf("Got up=%v %q, want up=%v %q", sample.Up, sample.Detail, tt.wantUp, tt.wantDetail)
+			}
+			if sample.Time.IsZero() || sample.Latency <= 0 {
+				t.Errorf("Got time %v and latency %v", sample.Time, sample.Latency)
+			}
+		})
+	}
+}
+
+func TestProber_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	prober := NewProber(time.Second)
+
+	if sample := prober.Probe(context.Background(), &Service{TCP: addr}); !sample.Up || sample.Detail != "connected" {
+		t.Errorf("Got %+v for an open port", sample)
+	}
+
+	listener.Close()
+	sample := prober.Probe(context.Background(), &Service{TCP: addr})
+	if sample.Up || !strings.Contains(sample.Detail, "refused") {
+		t.Errorf("Got %+v for a closed port", sample)
+	}
+	if strings.Contains(sample.Detail, addr) {
+		t.Errorf("Detail %q repeats the address", sample.Detail)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+	"local-dev-tools/healthboard/internal/health"
+)
+
+// Process exit codes
+const (
+	exitOK = 0
+	// exitConfigError means the flags or services file were invalid
+	exitConfigError = 2
+	// exitRuntimeError means the status page could not be served
+	exitRuntimeError = 3
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run probes the services and serves the status page until interrupted,
+// and returns the process exit code
+func run() int {
+	configPath := flag.String("config", "", "Services file (YAML or JSON, required)")
+	listen := flag.String("listen", health.DefaultListen, "Address to serve the status page on")
+	interval := flag.Duration("interval", health.DefaultInterval, "Time between probes of each service")
+	timeout := flag.Duration("timeout", health.DefaultTimeout, "Timeout for each probe")
+	keep := flag.Duration("history", health.DefaultHistory, "How long to keep probe results")
+	quiet := flag.Bool("quiet", false, "Do not log services going up and down")
+	flag.Parse()
+
+	if *configPath == "" {
+		log.Printf("Error: --config is required")
+		flag.Usage()
+		return exitConfigError
+	}
+	config, err := health.LoadConfig(*configPath)
+	if err != nil {
+		log.Printf("Error loading config: %v", err)
+		return exitConfigError
+	}
+
+	// Flags given explicitly take precedence over the config file
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "listen":
+			config.Listen = *listen
+		case "interval":
+			config.Interval = interval.String()
+		case "timeout":
+			config.Timeout = timeout.String()
+		case "history":
+			config.History = keep.String()
+		}
+	})
+	if err := config.Validate(); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	engine := templating.New(nil, 0)
+	for i := range config.Services {
+		if err := config.Services[i].Resolve(engine); err != nil {
+			log.Printf("Error resolving service %s: %v", config.Services[i].Name, err)
+			return exitConfigError
+		}
+	}
+
+	listener, err := net.Listen("tcp", config.Listen)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+
+	logf := log.Printf
+	if *quiet {
+		logf = nil
+	}
+	monitor := health.NewMonitor(config, logf)
+	server := &http.Server{Handler: monitor.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go monitor.Run(ctx)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Probing %d services; status page at http://%s", len(config.Services), pageAddress(listener.Addr()))
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+	log.Printf("Stopped")
+	return exitOK
+}
+
+// pageAddress returns a browsable address for the listener, replacing an
+// unspecified host with localhost
+func pageAddress(addr net.Addr) string {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok || !tcp.IP.IsUnspecified() {
+		return addr.String()
+	}
+	return net.JoinHostPort("localhost", fmt.Sprint(tcp.Port))
+}
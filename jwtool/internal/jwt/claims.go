@@ -0,0 +1,134 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+// timeClaims are the registered claims holding NumericDates, which are
+// converted to numbers when a template renders them
+var timeClaims = map[string]bool{"exp": true, "nbf": true, "iat": true, "auth_time": true}
+
+// LoadClaims reads a claims template: a YAML or JSON object whose string
+// values may contain templates
+func LoadClaims(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claims file: %w", err)
+	}
+
+	var claims map[string]interface{}
+	if err := yaml.Unmarshal(data, &claims); err != nil {
+		return nil, fmt.Errorf("failed to parse claims file: %w", err)
+	}
+	if claims == nil {
+		claims = make(map[string]interface{})
+	}
+	return claims, nil
+}
+
+// ParseClaim parses a name=value claim given on the command line. The value
+// is read as YAML, so numbers, booleans and lists such as [admin,dev] keep
+// their type.
+func ParseClaim(arg string) (string, interface{}, error) {
+	name, raw, ok := strings.Cut(arg, "=")
+	if !ok || name == "" {
+		return "", nil, fmt.Errorf("claim %q must be name=value", arg)
+	}
+
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+		// Not valid YAML, e.g. an unquoted template; use it verbatim
+		return name, raw, nil
+	}
+	if value == nil && raw != "null" && raw != "~" {
+		value = raw
+	}
+	return name, value, nil
+}
+
+// ResolveClaims evaluates the templates in claims with engine. Time claims
+// (exp, nbf, iat and auth_time) rendered by a template, such as
+// '{{ addHours 1 now | unix }}', become numbers as the spec requires.
+func ResolveClaims(engine *templating.Engine, claims map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(claims))
+	for name, value := range claims {
+		value, err := resolveValue(engine, value)
+		if err != nil {
+			return nil, fmt.Errorf("claim %s: %w", name, err)
+		}
+		if timeClaims[name] {
+			if value, err = numericDate(value); err != nil {
+				return nil, fmt.Errorf("claim %s: %w", name, err)
+			}
+		}
+		resolved[name] = value
+	}
+	return resolved, nil
+}
+
+// ApplyLifetime sets iat to now and exp to now+ttl unless the claims already
+// set them
+func ApplyLifetime(claims map[string]interface{}, now time.Time, ttl time.Duration) {
+	if _, ok := claims["iat"]; !ok {
+		claims["iat"] = now.Unix()
+	}
+	if _, ok := claims["exp"]; !ok && ttl > 0 {
+		claims["exp"] = now.Add(ttl).Unix()
+	}
+}
+
+func resolveValue(engine *templating.Engine, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if templating.IsTemplate(v) {
+			return engine.EvaluateTemplate(v)
+		}
+		return v, nil
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			item, err := resolveValue(engine, item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = item
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			item, err := resolveValue(engine, item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = item
+		}
+		return resolved, nil
+	}
+	return value, nil
+}
+
+func numericDate(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int, int64, float64:
+		return v, nil
+	case string:
+		if seconds, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return seconds, nil
+		}
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(v)); err == nil {
+			return t.Unix(), nil
+		}
+		return nil, fmt.Errorf("%q is not unix seconds or an RFC 3339 time (pipe times through unix)", v)
+	}
+	return nil, errors.New("must be unix seconds")
+}
@@ -0,0 +1,105 @@
+package jwt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+func TestResolveClaims(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claims.yaml")
+	os.WriteFile(path, []byte(`
+iss: http://localhost:8080
+sub: '{{ env "JWTOOL_TEST_USER" }}'
+exp: '{{ addHours 1 now | unix }}'
+nbf: '{{ now | rfc3339 }}'
+iat: 1700000000
+jti: '{{ uuid }}'
+org:
+  id: '{{ randInt 100 999 }}'
+  roles: ['{{ env "JWTOOL_TEST_ROLE" }}', viewer]
+`), 0o644)
+	t.Setenv("JWTOOL_TEST_USER", "alice")
+	t.Setenv("JWTOOL_TEST_ROLE", "admin")
+
+	template, err := LoadClaims(path)
+	if err != nil {
+		t.Fatalf("LoadClaims failed: %v", err)
+	}
+	claims, err := ResolveClaims(templating.New(nil, 1), template)
+	if err != nil {
+		t.Fatalf("ResolveClaims failed: %v", err)
+	}
+
+	now := time.Now().Unix()
+	if exp, ok := claims["exp"].(int64); !ok || exp < now+3590 || exp > now+3610 {
+		t.Errorf("Expected exp to be a number an hour from now, got %#v", claims["exp"])
+	}
+	if nbf, ok := claims["nbf"].(int64); !ok || nbf < now-10 || nbf > now+10 {
+		t.Errorf("Expected an RFC 3339 nbf to become unix seconds, got %#v", claims["nbf"])
+	}
+	if claims["iat"] != 1700000000 || claims["sub"] != "alice" || claims["iss"] != "http://localhost:8080" {
+		t.Errorf("Unexpected claims %v", claims)
+	}
+	if jti, _ := claims["jti"].(string); len(jti) != 36 {
+		t.Errorf("Expected a UUID jti, got %v", claims["jti"])
+	}
+	// Only time claims are converted; other rendered numbers stay strings
+	org := claims["org"].(map[string]interface{})
+	if _, ok := org["id"].(string); !ok || !reflect.DeepEqual(org["roles"], []interface{}{"admin", "viewer"}) {
+		t.Errorf("Unexpected nested claims %v", org)
+	}
+
+	if _, err := ResolveClaims(templating.New(nil, 1), map[string]interface{}{"exp": "soon"}); err == nil || !strings.Contains(err.Error(), "claim exp") {
+		t.Errorf("Expected a non-time exp to be rejected, got %v", err)
+	}
+}
+
+func TestParseClaim(t *testing.T) {
+	tests := map[string]struct {
+		name  string
+		value interface{}
+	}{
+		"sub=alice":          {"sub", "alice"},
+		"admin=true":         {"admin", true},
+		"level=3":            {"level", 3},
+		"roles=[admin, dev]": {"roles", []interface{}{"admin", "dev"}},
+		"jti={{ uuid }}":     {"jti", "{{ uuid }}"},
+		"note=":              {"note", ""},
+		"url=http://x/?a=b":  {"url", "http://x/?a=b"},
+		"nothing=null":       {"nothing", nil},
+	}
+	for arg, want := range tests {
+		name, value, err := ParseClaim(arg)
+		if err != nil || name != want.name || !reflect.DeepEqual(value, want.value) {
+			t.Errorf("ParseClaim(%q) = %q, %#v, %v; want %q, %#v", arg, name, value, err, want.name, want.value)
+		}
+	}
+	if _, _, err := ParseClaim("sub"); err == nil {
+		t.Error("Expected a claim without = to be rejected")
+	}
+}
+
+func TestApplyLifetime(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	claims := map[string]interface{}{}
+	ApplyLifetime(claims, now, time.Hour)
+	if claims["iat"] != int64(1700000000) || claims["exp"] != int64(1700003600) {
+		t.Errorf("Expected iat and exp to be set, got %v", claims)
+	}
+
+	claims = map[string]interface{}{"exp": 5}
+	ApplyLifetime(claims, now, time.Hour)
+	if claims["exp"] != 5 {
+		t.Errorf("Expected an explicit exp to be kept, got %v", claims["exp"])
+	}
+	claims = map[string]interface{}{}
+	if ApplyLifetime(claims, now, 0); claims["exp"] != nil {
+		t.Errorf("Expected no exp with a zero ttl, got %v", claims["exp"])
+	}
+}
@@ -0,0 +1,52 @@
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Describe writes the token's header and claims as indented JSON, followed
+// by the time claims in readable form relative to now
+func Describe(w io.Writer, token *Token, now time.Time) error {
+	for _, section := range []struct {
+		name  string
+		value map[string]interface{}
+	}{{"Header", token.Header}, {"Claims", token.Claims}} {
+		data, err := json.MarshalIndent(section.value, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s:\n%s\n\n", section.name, data)
+	}
+
+	for _, claim := range []struct{ name, label string }{
+		{"iat", "Issued"},
+		{"nbf", "Not before"},
+		{"exp", "Expires"},
+		{"auth_time", "Authenticated"},
+	} {
+		t, ok, err := token.Time(claim.name)
+		if err != nil {
+			fmt.Fprintf(w, "%-14s %v\n", claim.label+":", err)
+			continue
+		}
+		if ok {
+			fmt.Fprintf(w, "%-14s %s (%s)\n", claim.label+":", t.Local().Format(time.RFC3339), relative(t, now))
+		}
+	}
+	return nil
+}
+
+// relative describes t relative to now, e.g. "in 59m0s" or "2h0m0s ago"
+func relative(t, now time.Time) string {
+	d := t.Sub(now).Round(time.Second)
+	switch {
+	case d > 0:
+		return "in " + d.String()
+	case d < 0:
+		return (-d).String() + " ago"
+	}
+	return "now"
+}
@@ -0,0 +1,35 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDescribe(t *testing.T) {
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","iat":1700000000,"exp":1700003600,"nbf":"soon"}`))
+	token, err := Parse("eyJhbGciOiJIUzI1NiJ9." + claims + ".")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := Describe(&out, token, time.Unix(1700000600, 0)); err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+	output := out.String()
+	for _, want := range []string{
+		"Header:\n{\n  \"alg\": \"HS256\"\n}\n",
+		"  \"exp\": 1700003600,\n",
+		"  \"sub\": \"alice\"\n",
+		"(10m0s ago)\n",
+		"(in 50m0s)\n",
+		"Not before:    nbf claim must be a number of seconds, got soon\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+// Algorithms lists the supported signing algorithms
+var Algorithms = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// KeysConfig is a keys file: named keys to mint and verify tokens with
+type KeysConfig struct {
+	Keys map[string]KeySpec `yaml:"keys"`
+
+	// dir is the file's directory, which key paths are relative to
+	dir string
+}
+
+// KeySpec configures one key. Exactly one of Secret and PrivateKey or
+// PublicKey is set.
+type KeySpec struct {
+	// Algorithm is inferred from the key when empty: HS256 for secrets,
+	// RS256 for RSA, ES256/384/512 by curve and EdDSA for Ed25519
+	Algorithm string `yaml:"alg,omitempty"`
+
+	// ID is written to the kid header of minted tokens
+	ID string `yaml:"kid,omitempty"`
+
+	// Secret is the HMAC secret; templates such as {{ env "JWT_SECRET" }}
+	// are evaluated when the key is loaded
+	Secret string `yaml:"secret,omitempty"`
+
+	// PrivateKey and PublicKey are PEM file paths. The public key is
+	// derived from the private key when only that is given; a public key
+	// alone can only verify.
+	PrivateKey string `yaml:"private_key,omitempty"`
+	PublicKey  string `yaml:"public_key,omitempty"`
+}
+
+// Key signs or verifies tokens with one algorithm
+type Key struct {
+	Algorithm string
+	ID        string
+
+	secret  []byte
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// CanSign reports whether the key can mint tokens, rather than only verify
+// them
+func (k *Key) CanSign() bool {
+	return k.secret != nil || k.private != nil
+}
+
+// LoadKeys reads a keys file
+func LoadKeys(path string) (*KeysConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys file: %w", err)
+	}
+
+	var config KeysConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse keys file: %w", err)
+	}
+	if len(config.Keys) == 0 {
+		return nil, errors.New("keys file defines no keys")
+	}
+	for name, spec := range config.Keys {
+		if err := spec.Validate(); err != nil {
+			return nil, fmt.Errorf("key %q: %w", name, err)
+		}
+	}
+	config.dir = filepath.Dir(path)
+	return &config, nil
+}
+
+// Key loads the named key. The name may be empty when the file defines a
+// single key.
+func (c *KeysConfig) Key(name string, engine *templating.Engine) (*Key, error) {
+	if name == "" {
+		if len(c.Keys) != 1 {
+			return nil, fmt.Errorf("keys file defines several keys, choose one of: %s", strings.Join(c.Names(), ", "))
+		}
+		for only := range c.Keys {
+			name = only
+		}
+	}
+	spec, ok := c.Keys[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q, available: %s", name, strings.Join(c.Names(), ", "))
+	}
+	spec.PrivateKey = c.resolvePath(spec.PrivateKey)
+	spec.PublicKey = c.resolvePath(spec.PublicKey)
+	return spec.Load(engine)
+}
+
+// Names returns the key names in sorted order
+func (c *KeysConfig) Names() []string {
+	names := make([]string, 0, len(c.Keys))
+	for name := range c.Keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *KeysConfig) resolvePath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.dir, path)
+}
+
+// Validate checks that the spec names one kind of key and a supported
+// algorithm
+func (s *KeySpec) Validate() error {
+	if s.Secret == "" && s.PrivateKey == "" && s.PublicKey == "" {
+		return errors.New("a secret, private_key or public_key is required")
+	}
+	if s.Secret != "" && (s.PrivateKey != "" || s.PublicKey != "") {
+		return errors.New("secret cannot be combined with private_key or public_key")
+	}
+	if s.Algorithm != "" && !isAlgorithm(s.Algorithm) {
+		return fmt.Errorf("unsupported alg %q (supported: %s)", s.Algorithm, strings.Join(Algorithms, ", "))
+	}
+	if s.Algorithm != "" && strings.HasPrefix(s.Algorithm, "HS") != (s.Secret != "") {
+		if s.Secret != "" {
+			return fmt.Errorf("alg %s needs private_key or public_key, not a secret", s.Algorithm)
+		}
+		return fmt.Errorf("alg %s needs a secret", s.Algorithm)
+	}
+	return nil
+}
+
+// Load evaluates the secret template or reads the PEM files, and checks the
+// key matches the algorithm
+func (s *KeySpec) Load(engine *templating.Engine) (*Key, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	key := &Key{Algorithm: s.Algorithm, ID: s.ID}
+
+	if s.Secret != "" {
+		secret := s.Secret
+		if templating.IsTemplate(secret) {
+			var err error
+			if secret, err = engine.EvaluateTemplate(secret); err != nil {
+				return nil, fmt.Errorf("failed to evaluate secret: %w", err)
+			}
+			if secret == "" {
+				return nil, errors.New("secret evaluated to an empty string")
+			}
+		}
+		key.secret = []byte(secret)
+		if key.Algorithm == "" {
+			key.Algorithm = "HS256"
+		}
+		return key, nil
+	}
+
+	if s.PrivateKey != "" {
+		private, err := readPrivateKey(s.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		key.private = private
+		key.public = private.Public()
+	}
+	if s.PublicKey != "" {
+		public, err := readPublicKey(s.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		if key.private != nil && !publicKeysEqual(public, key.public) {
+			return nil, errors.New("public_key does not match private_key")
+		}
+		key.public = public
+	}
+
+	if key.Algorithm == "" {
+		alg, err := defaultAlgorithm(key.public)
+		if err != nil {
+			return nil, err
+		}
+		key.Algorithm = alg
+	}
+	if err := checkKeyType(key.Algorithm, key.public); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func isAlgorithm(alg string) bool {
+	for _, supported := range Algorithms {
+		if alg == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultAlgorithm picks the algorithm conventionally used with a key
+func defaultAlgorithm(public crypto.PublicKey) (string, error) {
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		return "RS256", nil
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			return "ES256", nil
+		case elliptic.P384():
+			return "ES384", nil
+		case elliptic.P521():
+			return "ES512", nil
+		}
+		return "", fmt.Errorf("unsupported elliptic curve %s", pub.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return "EdDSA", nil
+	}
+	return "", fmt.Errorf("unsupported key type %T", public)
+}
+
+// checkKeyType rejects keys that cannot be used with alg
+func checkKeyType(alg string, public crypto.PublicKey) error {
+	ok := false
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		ok = strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		want, err := defaultAlgorithm(pub)
+		ok = err == nil && alg == want
+	case ed25519.PublicKey:
+		ok = alg == "EdDSA"
+	}
+	if !ok {
+		return fmt.Errorf("alg %s cannot be used with a %s key", alg, keyType(public))
+	}
+	return nil
+}
+
+func keyType(public crypto.PublicKey) string {
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("%d-bit RSA", pub.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA " + pub.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	}
+	return fmt.Sprintf("%T", public)
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	eq, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && eq.Equal(b)
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", path)
+	}
+	return block, nil
+}
+
+// readPrivateKey reads a PKCS#8, PKCS#1 or SEC 1 private key
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// readPublicKey reads a PKIX or PKCS#1 public key, or the key of a
+// certificate
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key crypto.PublicKey
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+	return key, nil
+}
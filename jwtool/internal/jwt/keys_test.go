@@ -0,0 +1,136 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+// writeKeyPair writes a PKCS#8 private key and PKIX public key to dir
+func writeKeyPair(t *testing.T, dir, name string, private crypto.Signer) (string, string) {
+	t.Helper()
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("Failed to marshal private key: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	privatePath := filepath.Join(dir, name+".pem")
+	publicPath := filepath.Join(dir, name+".pub.pem")
+	os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600)
+	os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o644)
+	return privatePath, publicPath
+}
+
+func generateRSA(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	return key
+}
+
+func TestKeySpec_InfersAlgorithm(t *testing.T) {
+	dir := t.TempDir()
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	_, ed, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := map[string]crypto.Signer{"RS256": generateRSA(t), "ES256": p256, "ES384": p384, "EdDSA": ed}
+	for want, private := range tests {
+		privatePath, publicPath := writeKeyPair(t, dir, want, private)
+		for _, spec := range []KeySpec{{PrivateKey: privatePath}, {PublicKey: publicPath}, {PrivateKey: privatePath, PublicKey: publicPath}} {
+			key, err := spec.Load(nil)
+			if err != nil {
+				t.Errorf("%s: Load(%+v) failed: %v", want, spec, err)
+				continue
+			}
+			if key.Algorithm != want || key.CanSign() != (spec.PrivateKey != "") {
+				t.Errorf("%s: got alg %s, can sign %v for %+v", want, key.Algorithm, key.CanSign(), spec)
+			}
+		}
+	}
+}
+
+func TestKeySpec_Errors(t *testing.T) {
+	dir := t.TempDir()
+	rsaPrivate, rsaPublic := writeKeyPair(t, dir, "rsa", generateRSA(t))
+	ec, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, ecPublic := writeKeyPair(t, dir, "ec", ec)
+
+	tests := map[string]KeySpec{
+		"secret, private_key or public_key is required": {Algorithm: "HS256"},
+		"cannot be combined":                            {Secret: "x", PrivateKey: rsaPrivate},
+		"unsupported alg":                               {Secret: "x", Algorithm: "none"},
+		"needs a secret":                                {PrivateKey: rsaPrivate, Algorithm: "HS256"},
+		"needs private_key or public_key":               {Secret: "x", Algorithm: "RS256"},
+		"cannot be used with a ECDSA P-256 key":         {PublicKey: ecPublic, Algorithm: "ES384"},
+		"cannot be used with a 2048-bit RSA key":        {PublicKey: rsaPublic, Algorithm: "EdDSA"},
+		"does not match private_key":                    {PrivateKey: rsaPrivate, PublicKey: ecPublic},
+		"not PEM encoded":                               {PublicKey: filepath.Join(dir, "missing")},
+	}
+	os.WriteFile(filepath.Join(dir, "missing"), []byte("not a key"), 0o644)
+	for want, spec := range tests {
+		if _, err := spec.Load(templating.New(nil, 1)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load(%+v): expected error containing %q, got %v", spec, want, err)
+		}
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeKeyPair(t, dir, "signing", generateRSA(t))
+	path := filepath.Join(dir, "keys.yaml")
+	os.WriteFile(path, []byte(`
+keys:
+  local:
+    secret: '{{ env "JWTOOL_TEST_SECRET" }}'
+  rsa:
+    alg: PS256
+    kid: dev-1
+    private_key: signing.pem
+`), 0o644)
+	t.Setenv("JWTOOL_TEST_SECRET", "s3cret")
+
+	config, err := LoadKeys(path)
+	if err != nil {
+		t.Fatalf("LoadKeys failed: %v", err)
+	}
+	engine := templating.New(nil, 1)
+
+	local, err := config.Key("local", engine)
+	if err != nil || local.Algorithm != "HS256" || string(local.secret) != "s3cret" {
+		t.Errorf("Expected an HS256 key with the env secret, got %+v, %v", local, err)
+	}
+	// Key paths are relative to the keys file
+	signing, err := config.Key("rsa", engine)
+	if err != nil || signing.Algorithm != "PS256" || signing.ID != "dev-1" || !signing.CanSign() {
+		t.Errorf("Expected a PS256 signing key, got %+v, %v", signing, err)
+	}
+
+	if _, err := config.Key("", engine); err == nil || !strings.Contains(err.Error(), "choose one of: local, rsa") {
+		t.Errorf("Expected the key names to be listed, got %v", err)
+	}
+	if _, err := config.Key("prod", engine); err == nil || !strings.Contains(err.Error(), `unknown key "prod"`) {
+		t.Errorf("Expected an unknown key error, got %v", err)
+	}
+
+	t.Setenv("JWTOOL_TEST_SECRET", "")
+	if _, err := config.Key("local", engine); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Errorf("Expected an empty secret to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+// Package jwt mints, decodes and verifies JSON Web Tokens signed with HMAC,
+// RSA, ECDSA or Ed25519 keys.
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Token is a decoded token. Decoding does not verify the signature.
+type Token struct {
+	Header    map[string]interface{}
+	Claims    map[string]interface{}
+	Signature []byte
+
+	// signingInput is the encoded header and claims the signature covers
+	signingInput string
+}
+
+// Algorithm returns the alg header
+func (t *Token) Algorithm() string {
+	alg, _ := t.Header["alg"].(string)
+	return alg
+}
+
+// Sign mints a token carrying claims
+func Sign(claims map[string]interface{}, key *Key) (string, error) {
+	if !key.CanSign() {
+		return "", errors.New("key has no secret or private key to sign with")
+	}
+
+	header := map[string]interface{}{"alg": key.Algorithm, "typ": "JWT"}
+	if key.ID != "" {
+		header["kid"] = key.ID
+	}
+	encodedHeader, err := encodeSegment(header)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := encodeSegment(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode claims: %w", err)
+	}
+
+	input := encodedHeader + "." + encodedClaims
+	signature, err := key.sign([]byte(input))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Parse decodes a compact token. Surrounding whitespace and a "Bearer "
+// prefix, as copied from an Authorization header, are ignored.
+func Parse(raw string) (*Token, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > 7 && strings.EqualFold(raw[:7], "bearer ") {
+		raw = strings.TrimSpace(raw[7:])
+	}
+
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		if len(parts) == 5 {
+			return nil, errors.New("token is encrypted (JWE), only signed tokens can be decoded")
+		}
+		return nil, fmt.Errorf("token must have 3 dot-separated parts, got %d", len(parts))
+	}
+
+	token := &Token{signingInput: parts[0] + "." + parts[1]}
+	if err := decodeSegment(parts[0], &token.Header); err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	if err := decodeSegment(parts[1], &token.Claims); err != nil {
+		return nil, fmt.Errorf("invalid claims: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	token.Signature = signature
+	return token, nil
+}
+
+// Verify checks the token's signature with key. The token's alg must match
+// the key's, so a token cannot pick a weaker algorithm, or none.
+func (t *Token) Verify(key *Key) error {
+	if alg := t.Algorithm(); alg != key.Algorithm {
+		return fmt.Errorf("token alg %q does not match the key's %s", alg, key.Algorithm)
+	}
+	return key.verify([]byte(t.signingInput), t.Signature)
+}
+
+// CheckTimes validates the exp and nbf claims at now, allowing leeway for
+// clock skew
+func (t *Token) CheckTimes(now time.Time, leeway time.Duration) error {
+	if exp, ok, err := t.Time("exp"); err != nil {
+		return err
+	} else if ok && !now.Before(exp.Add(leeway)) {
+		return fmt.Errorf("token expired %s ago, at %s", now.Sub(exp).Round(time.Second), exp.Format(time.RFC3339))
+	}
+	if nbf, ok, err := t.Time("nbf"); err != nil {
+		return err
+	} else if ok && now.Add(leeway).Before(nbf) {
+		return fmt.Errorf("token is not valid for another %s, until %s", nbf.Sub(now).Round(time.Second), nbf.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Time returns a NumericDate claim such as exp, and whether it is present
+func (t *Token) Time(name string) (time.Time, bool, error) {
+	value, ok := t.Claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("%s claim must be a number of seconds, got %v", name, value)
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%s claim must be a number of seconds, got %v", name, value)
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))).UTC(), true, nil
+}
+
+func encodeSegment(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeSegment decodes base64url JSON, keeping numbers exact
+func decodeSegment(segment string, v interface{}) error {
+	// Padding is not allowed by the spec but some issuers add it anyway
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return fmt.Errorf("not base64url: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("not a JSON object: %w", err)
+	}
+	return nil
+}
+
+// hash returns the hash function of alg, e.g. SHA-256 for RS256
+func hash(alg string) crypto.Hash {
+	switch alg[len(alg)-3:] {
+	case "384":
+		return crypto.SHA384
+	case "512":
+		return crypto.SHA512
+	}
+	return crypto.SHA256
+}
+
+func digest(alg string, input []byte) []byte {
+	h := hash(alg).New()
+	h.Write(input)
+	return h.Sum(nil)
+}
+
+func (k *Key) sign(input []byte) ([]byte, error) {
+	switch {
+	case k.secret != nil:
+		mac := hmac.New(hash(k.Algorithm).New, k.secret)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	case k.Algorithm == "EdDSA":
+		return ed25519.Sign(k.private.(ed25519.PrivateKey), input), nil
+	case strings.HasPrefix(k.Algorithm, "RS"):
+		return rsa.SignPKCS1v15(rand.Reader, k.private.(*rsa.PrivateKey), hash(k.Algorithm), digest(k.Algorithm, input))
+	case strings.HasPrefix(k.Algorithm, "PS"):
+		return rsa.SignPSS(rand.Reader, k.private.(*rsa.PrivateKey), hash(k.Algorithm), digest(k.Algorithm, input),
+			&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	}
+
+	// ECDSA signatures are the fixed-size r and s, not ASN.1
+	private := k.private.(*ecdsa.PrivateKey)
+	r, s, err := ecdsa.Sign(rand.Reader, private, digest(k.Algorithm, input))
+	if err != nil {
+		return nil, err
+	}
+	size := (private.Curve.Params().BitSize + 7) / 8
+	signature := make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	s.FillBytes(signature[size:])
+	return signature, nil
+}
+
+var errSignature = errors.New("signature is invalid")
+
+func (k *Key) verify(input, signature []byte) error {
+	switch {
+	case k.secret != nil:
+		mac := hmac.New(hash(k.Algorithm).New, k.secret)
+		mac.Write(input)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return errSignature
+		}
+		return nil
+	case k.Algorithm == "EdDSA":
+		if !ed25519.Verify(k.public.(ed25519.PublicKey), input, signature) {
+			return errSignature
+		}
+		return nil
+	case strings.HasPrefix(k.Algorithm, "RS"):
+		if rsa.VerifyPKCS1v15(k.public.(*rsa.PublicKey), hash(k.Algorithm), digest(k.Algorithm, input), signature) != nil {
+			return errSignature
+		}
+		return nil
+	case strings.HasPrefix(k.Algorithm, "PS"):
+		if rsa.VerifyPSS(k.public.(*rsa.PublicKey), hash(k.Algorithm), digest(k.Algorithm, input), signature,
+			&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}) != nil {
+			return errSignature
+		}
+		return nil
+	}
+
+	public := k.public.(*ecdsa.PublicKey)
+	size := (public.Curve.Params().BitSize + 7) / 8
+	if len(signature) != 2*size {
+		return errSignature
+	}
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	if !ecdsa.Verify(public, digest(k.Algorithm, input), r, s) {
+		return errSignature
+	}
+	return nil
+}
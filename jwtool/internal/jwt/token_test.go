@@ -0,0 +1,143 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify_AllAlgorithms(t *testing.T) {
+	rsaKey := generateRSA(t)
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	p521, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	_, ed, _ := ed25519.GenerateKey(rand.Reader)
+	signers := map[string]crypto.Signer{
+		"RS": rsaKey, "PS": rsaKey, "ES256": p256, "ES384": p384, "ES512": p521, "EdDSA": ed,
+	}
+
+	claims := map[string]interface{}{"sub": "alice", "admin": true}
+	for _, alg := range Algorithms {
+		key := &Key{Algorithm: alg, ID: "k1"}
+		if strings.HasPrefix(alg, "HS") {
+			key.secret = []byte("s3cret")
+		} else {
+			signer, ok := signers[alg]
+			if !ok {
+				signer = signers[alg[:2]]
+			}
+			key.private, key.public = signer, signer.Public()
+		}
+
+		raw, err := Sign(claims, key)
+		if err != nil {
+			t.Errorf("%s: Sign failed: %v", alg, err)
+			continue
+		}
+		token, err := Parse(raw)
+		if err != nil {
+			t.Errorf("%s: Parse failed: %v", alg, err)
+			continue
+		}
+		if token.Algorithm() != alg || token.Header["kid"] != "k1" || token.Claims["sub"] != "alice" || token.Claims["admin"] != true {
+			t.Errorf("%s: unexpected token %+v", alg, token)
+		}
+		if err := token.Verify(key); err != nil {
+			t.Errorf("%s: Verify failed: %v", alg, err)
+		}
+
+		// Verifying needs only the public key
+		public := &Key{Algorithm: alg, secret: key.secret, public: key.public}
+		if err := token.Verify(public); err != nil {
+			t.Errorf("%s: Verify with the public key failed: %v", alg, err)
+		}
+
+		token.Signature[0] ^= 0xff
+		if err := token.Verify(key); err != errSignature {
+			t.Errorf("%s: expected a tampered signature to fail, got %v", alg, err)
+		}
+	}
+}
+
+func TestVerify_RejectsOtherAlgorithms(t *testing.T) {
+	key := &Key{Algorithm: "HS256", secret: []byte("s3cret")}
+	raw, _ := Sign(map[string]interface{}{"sub": "alice"}, key)
+
+	// An unsigned token claiming alg none must not verify
+	parts := strings.Split(raw, ".")
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	token, err := Parse(header + "." + parts[1] + ".")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if err := token.Verify(key); err == nil || !strings.Contains(err.Error(), `token alg "none" does not match the key's HS256`) {
+		t.Errorf("Expected alg none to be rejected, got %v", err)
+	}
+
+	if _, err := Sign(nil, &Key{Algorithm: "RS256", public: generateRSA(t).Public()}); err == nil {
+		t.Error("Expected signing with a public key to fail")
+	}
+}
+
+func TestParse(t *testing.T) {
+	key := &Key{Algorithm: "HS256", secret: []byte("s3cret")}
+	raw, _ := Sign(map[string]interface{}{"exp": 1700000000}, key)
+
+	token, err := Parse("  Bearer " + raw + "\n")
+	if err != nil {
+		t.Fatalf("Expected a pasted Authorization header to parse, got %v", err)
+	}
+	if token.Claims["exp"] != json.Number("1700000000") {
+		t.Errorf("Expected exp to decode as an exact number, got %#v", token.Claims["exp"])
+	}
+
+	tests := map[string]string{
+		"abc":                "3 dot-separated parts, got 1",
+		"a.b.c.d.e":          "encrypted (JWE)",
+		"!!.e30.":            "invalid header",
+		"e30.WzFd.":          "invalid claims",
+		"e30.e30.not*base64": "invalid signature encoding",
+	}
+	for raw, want := range tests {
+		if _, err := Parse(raw); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q): expected error containing %q, got %v", raw, want, err)
+		}
+	}
+}
+
+func TestCheckTimes(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	token := func(claims string) *Token {
+		parsed, err := Parse("e30." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".")
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		return parsed
+	}
+
+	if err := token(`{"exp":1700000060,"nbf":1699999990}`).CheckTimes(now, 0); err != nil {
+		t.Errorf("Expected a current token to pass, got %v", err)
+	}
+	if err := token(`{}`).CheckTimes(now, 0); err != nil {
+		t.Errorf("Expected a token without times to pass, got %v", err)
+	}
+	if err := token(`{"exp":1699999880}`).CheckTimes(now, 0); err == nil || !strings.HasPrefix(err.Error(), "token expired 2m0s ago") {
+		t.Errorf("Expected an expired token, got %v", err)
+	}
+	if err := token(`{"exp":1699999880}`).CheckTimes(now, 5*time.Minute); err != nil {
+		t.Errorf("Expected the leeway to allow the token, got %v", err)
+	}
+	if err := token(`{"nbf":1700000030}`).CheckTimes(now, 0); err == nil || !strings.HasPrefix(err.Error(), "token is not valid for another 30s") {
+		t.Errorf("Expected a token not valid yet, got %v", err)
+	}
+	if err := token(`{"exp":"tomorrow"}`).CheckTimes(now, 0); err == nil || !strings.Contains(err.Error(), "must be a number") {
+		t.Errorf("Expected a non-numeric exp to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+	"local-dev-tools/jwtool/internal/jwt"
+)
+
+// Process exit codes
+const (
+	exitOK = 0
+	// exitInvalid means the token failed to decode or verify
+	exitInvalid = 1
+	// exitConfigError means the flags, keys or claims were invalid
+	exitConfigError = 2
+	// exitRuntimeError means the token could not be signed or read
+	exitRuntimeError = 3
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [options]\n\n", os.Args[0])
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  mint     Sign a token from a claims template")
+	fmt.Fprintln(os.Stderr, "  decode   Print a token's header and claims without verifying it")
+	fmt.Fprintln(os.Stderr, "  verify   Check a token's signature, expiry and not-before time")
+	fmt.Fprintln(os.Stderr, "\nRun a command with -h for its options.")
+}
+
+func run(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return exitConfigError
+	}
+	switch args[0] {
+	case "mint":
+		return runMint(args[1:])
+	case "decode":
+		return runDecode(args[1:])
+	case "verify":
+		return runVerify(args[1:])
+	case "-h", "-help", "--help", "help":
+		usage()
+		return exitOK
+	}
+	log.Printf("Unknown command %q", args[0])
+	usage()
+	return exitConfigError
+}
+
+// keyFlags selects the key to mint or verify with, from a keys file or
+// given inline
+type keyFlags struct {
+	keysPath   *string
+	name       *string
+	secret     *string
+	privateKey *string
+	publicKey  *string
+	alg        *string
+	kid        *string
+}
+
+func addKeyFlags(fs *flag.FlagSet) *keyFlags {
+	return &keyFlags{
+		keysPath:   fs.String("keys", os.Getenv("JWTOOL_KEYS"), "Keys file (YAML or JSON); defaults to $JWTOOL_KEYS"),
+		name:       fs.String("key", "", "Name of the key in --keys; optional when it defines one key"),
+		secret:     fs.String("secret", "", "HMAC secret, instead of --keys (templates allowed, e.g. '{{ env \"JWT_SECRET\" }}')"),
+		privateKey: fs.String("private-key", "", "PEM private key file, instead of --keys"),
+		publicKey:  fs.String("public-key", "", "PEM public key or certificate file, instead of --keys"),
+		alg:        fs.String("alg", "", "Algorithm of an inline key (inferred from the key when empty): "+strings.Join(jwt.Algorithms, ", ")),
+		kid:        fs.String("kid", "", "Key ID written to the header of minted tokens, for an inline key"),
+	}
+}
+
+func (f *keyFlags) load(engine *templating.Engine) (*jwt.Key, error) {
+	inline := *f.secret != "" || *f.privateKey != "" || *f.publicKey != ""
+	switch {
+	case inline && *f.keysPath != "":
+		return nil, errors.New("give a key either inline or with --keys, not both")
+	case inline:
+		spec := jwt.KeySpec{Algorithm: *f.alg, ID: *f.kid, Secret: *f.secret, PrivateKey: *f.privateKey, PublicKey: *f.publicKey}
+		return spec.Load(engine)
+	case *f.keysPath == "":
+		return nil, errors.New("a key is required: use --keys, --secret, --private-key or --public-key")
+	}
+
+	config, err := jwt.LoadKeys(*f.keysPath)
+	if err != nil {
+		return nil, err
+	}
+	return config.Key(*f.name, engine)
+}
+
+// claimFlags collects repeated --claim name=value flags
+type claimFlags []string
+
+func (c *claimFlags) String() string     { return strings.Join(*c, ", ") }
+func (c *claimFlags) Set(v string) error { *c = append(*c, v); return nil }
+
+func runMint(args []string) int {
+	fs := flag.NewFlagSet("mint", flag.ExitOnError)
+	keys := addKeyFlags(fs)
+	claimsPath := fs.String("claims", "", "Claims template file (YAML or JSON); string values may contain templates")
+	var claims claimFlags
+	fs.Var(&claims, "claim", "Claim as name=value, overriding the claims file (repeatable; value read as YAML, e.g. roles=[admin])")
+	ttl := fs.Duration("ttl", time.Hour, "Lifetime used for exp when the claims do not set it (0 for none)")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		log.Printf("Unexpected arguments: %s", strings.Join(fs.Args(), " "))
+		return exitConfigError
+	}
+
+	engine := templating.New(nil, 0)
+	key, err := keys.load(engine)
+	if err != nil {
+		log.Printf("Error loading key: %v", err)
+		return exitConfigError
+	}
+
+	template := make(map[string]interface{})
+	if *claimsPath != "" {
+		if template, err = jwt.LoadClaims(*claimsPath); err != nil {
+			log.Printf("Error: %v", err)
+			return exitConfigError
+		}
+	}
+	for _, arg := range claims {
+		name, value, err := jwt.ParseClaim(arg)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitConfigError
+		}
+		template[name] = value
+	}
+
+	resolved, err := jwt.ResolveClaims(engine, template)
+	if err != nil {
+		log.Printf("Error evaluating claims: %v", err)
+		return exitConfigError
+	}
+	jwt.ApplyLifetime(resolved, time.Now(), *ttl)
+
+	token, err := jwt.Sign(resolved, key)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+	fmt.Println(token)
+	return exitOK
+}
+
+func runDecode(args []string) int {
+	fs := flag.NewFlagSet("decode", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s decode [token]\n\nReads the token from stdin when not given.\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	token, code := readToken(fs)
+	if token == nil {
+		return code
+	}
+	if err := jwt.Describe(os.Stdout, token, time.Now()); err != nil {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+	fmt.Fprintln(os.Stderr, "\nThe signature was not verified; use verify with the issuer's key to check it.")
+	return exitOK
+}
+
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	keys := addKeyFlags(fs)
+	leeway := fs.Duration("leeway", 0, "Clock skew allowed when checking exp and nbf")
+	fs.Parse(args)
+
+	key, err := keys.load(templating.New(nil, 0))
+	if err != nil {
+		log.Printf("Error loading key: %v", err)
+		return exitConfigError
+	}
+	token, code := readToken(fs)
+	if token == nil {
+		return code
+	}
+
+	if err := token.Verify(key); err != nil {
+		fmt.Printf("✗ %v\n", err)
+		return exitInvalid
+	}
+	if err := token.CheckTimes(time.Now(), *leeway); err != nil {
+		fmt.Printf("✗ signature valid (%s) but %v\n", key.Algorithm, err)
+		return exitInvalid
+	}
+	fmt.Printf("✓ signature valid (%s)", key.Algorithm)
+	if exp, ok, _ := token.Time("exp"); ok {
+		fmt.Printf(", expires in %s", time.Until(exp).Round(time.Second))
+	}
+	fmt.Println()
+	return exitOK
+}
+
+// readToken parses the token given as the only argument, or on stdin
+func readToken(fs *flag.FlagSet) (*jwt.Token, int) {
+	var raw string
+	switch {
+	case fs.NArg() > 1:
+		log.Printf("Expected a single token, got %d arguments", fs.NArg())
+		return nil, exitConfigError
+	case fs.NArg() == 1 && fs.Arg(0) != "-":
+		raw = fs.Arg(0)
+	default:
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Printf("Error reading token: %v", err)
+			return nil, exitRuntimeError
+		}
+		raw = string(data)
+	}
+
+	token, err := jwt.Parse(raw)
+	if err != nil {
+		log.Printf("Invalid token: %v", err)
+		return nil, exitInvalid
+	}
+	return token, exitOK
+}
@@ -0,0 +1,101 @@
+package tail
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// maxLearnedIDs bounds the IDs a filter learns from matching lines
+const maxLearnedIDs = 10000
+
+// uuidPattern finds IDs in the format the scheduler generates
+var uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// Filter selects lines by pattern and by correlation ID. A line matching
+// an ID teaches the filter the other UUIDs on it, so a service line logging
+// both the scheduler's run ID and request ID brings in every other line
+// with that request ID, wherever it is logged.
+type Filter struct {
+	// Pattern must match lines when set
+	Pattern *regexp.Regexp
+
+	seeds []string
+
+	mu      sync.Mutex
+	learned map[string]bool
+}
+
+// NewFilter creates a filter for lines matching pattern, which may be nil,
+// and containing any of ids, when given
+func NewFilter(pattern *regexp.Regexp, ids []string) *Filter {
+	f := &Filter{Pattern: pattern, learned: make(map[string]bool)}
+	for _, id := range ids {
+		if id != "" {
+			f.seeds = append(f.seeds, strings.ToLower(id))
+		}
+	}
+	return f
+}
+
+// Match reports whether the line passes the filter. Notices always pass.
+func (f *Filter) Match(line Line) bool {
+	if line.Notice {
+		return true
+	}
+	if f.Pattern != nil && !f.Pattern.MatchString(line.Text) {
+		return false
+	}
+	if len(f.seeds) == 0 {
+		return true
+	}
+
+	lower := strings.ToLower(line.Text)
+	found := uuidPattern.FindAllString(lower, -1)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	matched := false
+	for _, seed := range f.seeds {
+		if strings.Contains(lower, seed) {
+			matched = true
+			break
+		}
+	}
+	for _, id := range found {
+		if matched {
+			break
+		}
+		matched = f.learned[id]
+	}
+	if !matched {
+		return false
+	}
+
+	// Learn the line's other IDs
+	for _, id := range found {
+		if len(f.learned) >= maxLearnedIDs {
+			break
+		}
+		if !f.isSeed(id) {
+			f.learned[id] = true
+		}
+	}
+	return true
+}
+
+func (f *Filter) isSeed(id string) bool {
+	for _, seed := range f.seeds {
+		if seed == id {
+			return true
+		}
+	}
+	return false
+}
+
+// IDs returns how many IDs the filter matches, including learned ones
+func (f *Filter) IDs() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.seeds) + len(f.learned)
+}
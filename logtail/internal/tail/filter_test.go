@@ -0,0 +1,77 @@
+package tail
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+const (
+	runID     = "0b6f3c9e-5a1d-4c8e-9f2a-7d3e1b4c6a80"
+	requestID = "9c2e4f71-3b8a-4d6e-a5f0-1e7c9b2d4a63"
+)
+
+func TestFilter_LearnsRequestIDs(t *testing.T) {
+	filter := NewFilter(nil, []string{runID})
+
+	lines := []struct {
+		text string
+		want bool
+	}{
+		// Not yet known to belong to the run
+		{"orders: reserved stock request_id=" + requestID, false},
+		{"api: POST /orders run_id=" + runID + " request_id=" + requestID, true},
+		{"orders: reserved stock request_id=" + requestID, true},
+		{"ORDERS: REQUEST_ID=" + "9C2E4F71-3B8A-4D6E-A5F0-1E7C9B2D4A63", true},
+		{"orders: unrelated request_id=5e1a8b3c-7d2f-4a6e-b9c0-3f8d2e1a7b54", false},
+	}
+	for _, l := range lines {
+		if got := filter.Match(Line{Text: l.text}); got != l.want {
+			t.Errorf("Match(%q) = %v, want %v", l.text, got, l.want)
+		}
+	}
+	if filter.IDs() != 2 {
+		t.Errorf("Expected the run and request IDs, got %d", filter.IDs())
+	}
+}
+
+func TestFilter_Pattern(t *testing.T) {
+	filter := NewFilter(regexp.MustCompile(`ERROR`), []string{requestID})
+	tests := map[string]bool{
+		"ERROR request " + requestID + " failed": true,
+		"INFO request " + requestID + " started": false,
+		"ERROR something else":                   false,
+	}
+	for text, want := range tests {
+		if got := filter.Match(Line{Text: text}); got != want {
+			t.Errorf("Match(%q) = %v, want %v", text, got, want)
+		}
+	}
+
+	if !filter.Match(Line{Text: "file was truncated", Notice: true}) {
+		t.Errorf("Expected notices to pass")
+	}
+	if !NewFilter(nil, nil).Match(Line{Text: "anything"}) {
+		t.Errorf("Expected an empty filter to pass everything")
+	}
+}
+
+func TestFilter_BoundsLearnedIDs(t *testing.T) {
+	filter := NewFilter(nil, []string{"seed"})
+	for filter.IDs() < maxLearnedIDs+1 {
+		before := filter.IDs()
+		filter.Match(Line{Text: "seed " + uuidFor(before)})
+		if filter.IDs() == before {
+			t.Fatalf("Expected an ID to be learned at %d", before)
+		}
+	}
+	filter.Match(Line{Text: "seed " + uuidFor(maxLearnedIDs)})
+	if filter.IDs() != maxLearnedIDs+1 {
+		t.Errorf("Expected learning to stop at %d IDs, got %d", maxLearnedIDs, filter.IDs()-1)
+	}
+}
+
+// uuidFor returns a distinct UUID-shaped ID for n
+func uuidFor(n int) string {
+	return fmt.Sprintf("00000000-0000-4000-8000-%012x", n)
+}
@@ -0,0 +1,299 @@
+package tail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// pollInterval is how often files are checked for new lines
+	pollInterval = 250 * time.Millisecond
+
+	// restartDelay is the wait before reattaching to a container
+	restartDelay = time.Second
+
+	// dockerCommand is replaced in tests
+	dockerCommand = "docker"
+)
+
+// maxBacklogRead bounds how much of a file's end is read for its last lines
+const maxBacklogRead = 1 << 20
+
+// Line is one line from a source
+type Line struct {
+	Source string
+	Text   string
+	Time   time.Time
+
+	// Notice marks the tailer's own messages about the source, such as a
+	// file being rotated, which are never filtered out
+	Notice bool
+}
+
+// follow emits the source's lines, starting with its last backlog lines,
+// until ctx is done or a command source exits
+func (s *Source) follow(ctx context.Context, backlog int, emit func(Line)) {
+	switch s.Kind() {
+	case KindContainer:
+		s.followContainer(ctx, backlog, emit)
+	case KindCommand:
+		s.followCommand(ctx, emit)
+	default:
+		s.followFile(ctx, backlog, emit)
+	}
+}
+
+func (s *Source) emitter(emit func(Line)) (line func(string), notice func(string, ...interface{})) {
+	line = func(text string) {
+		emit(Line{Source: s.Name, Text: text, Time: time.Now()})
+	}
+	notice = func(format string, args ...interface{}) {
+		emit(Line{Source: s.Name, Text: fmt.Sprintf(format, args...), Time: time.Now(), Notice: true})
+	}
+	return line, notice
+}
+
+// followFile polls the file for appended lines. A file that shrinks was
+// truncated and is read again from the start; a file replaced by another
+// was rotated and the new one is read from the start.
+func (s *Source) followFile(ctx context.Context, backlog int, emit func(Line)) {
+	line, notice := s.emitter(emit)
+	var (
+		file    *os.File
+		partial []byte
+		offset  int64
+		waiting bool
+	)
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	for first := true; ; first = false {
+		if file == nil {
+			var err error
+			if file, err = os.Open(s.File); err != nil {
+				if !waiting {
+					notice("waiting for %s: %v", s.File, err)
+					waiting = true
+				}
+			} else {
+				if waiting {
+					notice("following %s", s.File)
+				} else if !first {
+					notice("%s was replaced; following the new file", s.File)
+				}
+				waiting = false
+				offset = 0
+				if first {
+					offset = readBacklog(file, backlog, line)
+				}
+			}
+		}
+
+		if file != nil {
+			offset, partial = readLines(file, offset, partial, line)
+
+			current, statErr := os.Stat(s.File)
+			opened, openErr := file.Stat()
+			switch {
+			case statErr != nil || openErr != nil || !os.SameFile(current, opened):
+				// Rotated or removed; anything written to the old file
+				// before the switch was read above
+				file.Close()
+				file, partial = nil, nil
+				continue
+			case opened.Size() < offset:
+				notice("%s was truncated", s.File)
+				offset, partial = 0, nil
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// readBacklog emits the last n complete lines of the file and returns the
+// offset following them
+func readBacklog(file *os.File, n int, line func(string)) int64 {
+	info, err := file.Stat()
+	if err != nil {
+		return 0
+	}
+	size := info.Size()
+	if n <= 0 {
+		return size
+	}
+
+	start := size - maxBacklogRead
+	if start < 0 {
+		start = 0
+	}
+	data := make([]byte, size-start)
+	if _, err := file.ReadAt(data, start); err != nil && !errors.Is(err, io.EOF) {
+		return size
+	}
+
+	// Leave a partial last line to be read with the rest of it
+	end := bytes.LastIndexByte(data, '\n') + 1
+	lines := strings.Split(string(data[:end]), "\n")
+	lines = lines[:len(lines)-1]
+	if start > 0 && len(lines) > 0 {
+		// The first line read is probably incomplete
+		lines = lines[1:]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	for _, text := range lines {
+		line(strings.TrimSuffix(text, "\r"))
+	}
+	return start + int64(end)
+}
+
+// readLines emits the complete lines after offset, returning the new offset
+// and any incomplete last line
+func readLines(file *os.File, offset int64, partial []byte, line func(string)) (int64, []byte) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := file.ReadAt(buf, offset)
+		offset += int64(n)
+		data := append(partial, buf[:n]...)
+		for {
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			line(strings.TrimSuffix(string(data[:i]), "\r"))
+			data = data[i+1:]
+		}
+		partial = append([]byte(nil), data...)
+		if err != nil || n == 0 {
+			return offset, partial
+		}
+	}
+}
+
+// followContainer follows docker logs, reattaching from the last line's
+// timestamp whenever the container stops or restarts
+func (s *Source) followContainer(ctx context.Context, backlog int, emit func(Line)) {
+	_, notice := s.emitter(emit)
+	var since string
+	failing := false
+
+	for {
+		args := []string{"logs", "--follow", "--timestamps"}
+		if since == "" {
+			args = append(args, "--tail", strconv.Itoa(backlog))
+		} else {
+			args = append(args, "--since", since)
+		}
+		args = append(args, s.Container)
+
+		received := false
+		err := runLines(ctx, append([]string{dockerCommand}, args...), func(text string) {
+			timestamp, rest, _ := strings.Cut(text, " ")
+			t, err := time.Parse(time.RFC3339Nano, timestamp)
+			if err != nil {
+				// Only docker's own messages lack a timestamp; they are
+				// reported with the exit error
+				return
+			}
+			if failing && !received {
+				notice("attached to container %s", s.Container)
+			}
+			received = true
+			// --since is inclusive, so resume just after this line
+			since = t.Add(time.Nanosecond).Format(time.RFC3339Nano)
+			emit(Line{Source: s.Name, Text: rest, Time: t.Local()})
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && !received {
+			if !failing {
+				notice("waiting for container %s: %v", s.Container, err)
+			}
+			failing = true
+		} else if received {
+			failing = false
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(restartDelay):
+		}
+	}
+}
+
+// followCommand runs the command once and emits its output
+func (s *Source) followCommand(ctx context.Context, emit func(Line)) {
+	line, notice := s.emitter(emit)
+	if err := runLines(ctx, s.Command, line); err != nil && ctx.Err() == nil {
+		notice("%s exited: %v", s.Command[0], err)
+	} else if ctx.Err() == nil {
+		notice("%s exited", s.Command[0])
+	}
+}
+
+// runLines runs a command, calling line for each line of its combined
+// output, and returns its error with the last line of output, if any
+func runLines(ctx context.Context, args []string, line func(string)) error {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	err = cmd.Start()
+	writer.Close()
+	if err != nil {
+		reader.Close()
+		return err
+	}
+
+	// Children of the command may hold the pipe open after it is killed
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case <-ctx.Done():
+			reader.Close()
+		case <-stopped:
+		}
+	}()
+
+	var last string
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		last = strings.TrimSuffix(scanner.Text(), "\r")
+		line(last)
+	}
+	reader.Close()
+
+	if err := cmd.Wait(); err != nil {
+		if last != "" {
+			return fmt.Errorf("%w: %s", err, last)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package tail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func init() {
+	pollInterval = 10 * time.Millisecond
+	restartDelay = 10 * time.Millisecond
+}
+
+// collector records emitted lines
+type collector struct {
+	mu    sync.Mutex
+	lines []Line
+}
+
+func (c *collector) emit(line Line) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lines = append(c.lines, line)
+}
+
+// texts returns the lines, with notices prefixed by "-- "
+func (c *collector) texts() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var texts []string
+	for _, l := range c.lines {
+		if l.Notice {
+			texts = append(texts, "-- "+l.Text)
+		} else {
+			texts = append(texts, l.Text)
+		}
+	}
+	return texts
+}
+
+// waitFor waits until the last line collected is want
+func (c *collector) waitFor(t *testing.T, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if texts := c.texts(); len(texts) > 0 && texts[len(texts)-1] == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %q, got %q", want, c.texts())
+}
+
+// start follows the sources until the test ends
+func start(t *testing.T, sources []Source, backlog int, filter *Filter) *collector {
+	t.Helper()
+	c := &collector{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, sources, backlog, filter, c.emit)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Errorf("Run did not stop")
+		}
+	})
+	return c
+}
+
+func appendFile(t *testing.T, path, text string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	f.WriteString(text)
+	f.Close()
+}
+
+func TestFollowFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "one\ntwo\nthree\npart")
+
+	c := start(t, []Source{{Name: "app", File: path}}, 2, nil)
+	c.waitFor(t, "three")
+	if got := strings.Join(c.texts(), "|"); got != "two|three" {
+		t.Errorf("Expected the last 2 complete lines, got %q", got)
+	}
+
+	appendFile(t, path, "ial\nfour\r\n")
+	c.waitFor(t, "four")
+
+	// Truncated in place
+	os.WriteFile(path, []byte("fresh\n"), 0o644)
+	c.waitFor(t, "fresh")
+
+	// Rotated: the old file is moved away and a new one created
+	os.Rename(path, path+".1")
+	appendFile(t, path, "rotated\n")
+	c.waitFor(t, "rotated")
+
+	want := []string{
+		"two", "three", "partial", "four",
+		"-- " + path + " was truncated", "fresh",
+		"-- " + path + " was replaced; following the new file", "rotated",
+	}
+	if got := c.texts(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Got lines %q, want %q", got, want)
+	}
+	for _, l := range c.lines {
+		if l.Source != "app" {
+			t.Errorf("Expected source app, got %q", l.Source)
+		}
+	}
+}
+
+func TestFollowFile_WaitsForFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "later.log")
+	c := start(t, []Source{{Name: "later", File: path}}, 10, nil)
+
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, path, "started\n")
+	c.waitFor(t, "started")
+
+	texts := c.texts()
+	if len(texts) != 3 || !strings.HasPrefix(texts[0], "-- waiting for "+path) || texts[1] != "-- following "+path {
+		t.Errorf("Unexpected lines %q", texts)
+	}
+}
+
+func TestFollowContainer(t *testing.T) {
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	// The first attach prints two lines and exits as if the container
+	// stopped; the second fails as if it were being recreated; the third
+	// resumes
+	script := `#!/bin/sh
+echo "$*" >> ` + calls + `
+n=$(wc -l < ` + calls + `)
+case $n in
+1) echo "2026-03-01T10:00:00.000000001Z first"
+   echo "2026-03-01T10:00:00.000000002Z second" ;;
+2) echo "Error response from daemon: No such container: db" >&2; exit 1 ;;
+*) echo "2026-03-01T10:00:05.5Z third"; sleep 10 ;;
+esac
+`
+	path := filepath.Join(dir, "docker")
+	os.WriteFile(path, []byte(script), 0o755)
+	previous := dockerCommand
+	dockerCommand = path
+	t.Cleanup(func() { dockerCommand = previous })
+
+	c := start(t, []Source{{Name: "db", Container: "db"}}, 5, nil)
+	c.waitFor(t, "third")
+
+	want := []string{
+		"first", "second",
+		"-- waiting for container db: exit status 1: Error response from daemon: No such container: db",
+		"-- attached to container db", "third",
+	}
+	if got := c.texts(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Got lines %q, want %q", got, want)
+	}
+	if c.lines[0].Time.UTC() != time.Date(2026, 3, 1, 10, 0, 0, 1, time.UTC) {
+		t.Errorf("Expected docker's timestamp, got %v", c.lines[0].Time)
+	}
+
+	data, _ := os.ReadFile(calls)
+	args := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if args[0] != "logs --follow --timestamps --tail 5 db" || args[1] != "logs --follow --timestamps --since 2026-03-01T10:00:00.000000003Z db" {
+		t.Errorf("Unexpected docker arguments %q", args)
+	}
+}
+
+func TestFollowCommand(t *testing.T) {
+	sources := []Source{
+		{Name: "ok", Command: []string{"sh", "-c", "echo out; echo err >&2"}},
+		{Name: "bad", Command: []string{"sh", "-c", "echo crashed; exit 3"}},
+	}
+	c := &collector{}
+	Run(context.Background(), sources, 0, nil, c.emit)
+
+	got := make(map[string][]string)
+	for _, l := range c.lines {
+		text := l.Text
+		if l.Notice {
+			text = "-- " + text
+		}
+		got[l.Source] = append(got[l.Source], text)
+	}
+	if strings.Join(got["ok"], "|") != "out|err|-- sh exited" {
+		t.Errorf("Unexpected lines from ok: %q", got["ok"])
+	}
+	if strings.Join(got["bad"], "|") != "crashed|-- sh exited: exit status 3: crashed" {
+		t.Errorf("Unexpected lines from bad: %q", got["bad"])
+	}
+}
+
+func TestRun_Filters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.log")
+	appendFile(t, path, "GET /health\nPOST /orders request_id="+requestID+"\n")
+
+	c := start(t, []Source{{Name: "api", File: path}}, 10, NewFilter(nil, []string{requestID}))
+	appendFile(t, path, "GET /health\norder saved request_id="+requestID+"\n")
+	c.waitFor(t, "order saved request_id="+requestID)
+
+	if got := c.texts(); len(got) != 2 {
+		t.Errorf("Expected only the request's lines, got %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+package tail
+
+import (
+	"fmt"
+	"io"
+)
+
+// palette is cycled through to color each source's name
+var palette = []string{"36", "33", "35", "32", "34", "91", "96", "93", "95", "92"}
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiDim   = "\x1b[2m"
+)
+
+// timeFormat is the clock time each line is printed with
+const timeFormat = "15:04:05.000"
+
+// Printer writes merged lines with a timestamp and an aligned, colored
+// source name
+type Printer struct {
+	w      io.Writer
+	color  bool
+	width  int
+	colors map[string]string
+}
+
+// NewPrinter creates a printer for the sources, coloring names when color
+// is true
+func NewPrinter(w io.Writer, sources []Source, color bool) *Printer {
+	p := &Printer{w: w, color: color, colors: make(map[string]string)}
+	for i, s := range sources {
+		if len(s.Name) > p.width {
+			p.width = len(s.Name)
+		}
+		p.colors[s.Name] = palette[i%len(palette)]
+	}
+	return p
+}
+
+// Print writes one line. Notices are dimmed, or marked with "--" without
+// color.
+func (p *Printer) Print(line Line) {
+	name := fmt.Sprintf("%-*s", p.width, line.Source)
+	timestamp := line.Time.Format(timeFormat)
+
+	switch {
+	case !p.color && line.Notice:
+		fmt.Fprintf(p.w, "%s %s │ -- %s\n", timestamp, name, line.Text)
+	case !p.color:
+		fmt.Fprintf(p.w, "%s %s │ %s\n", timestamp, name, line.Text)
+	case line.Notice:
+		fmt.Fprintf(p.w, "%s%s%s \x1b[%sm%s%s │ %s%s%s\n", ansiDim, timestamp, ansiReset, p.colors[line.Source], name, ansiReset, ansiDim, line.Text, ansiReset)
+	default:
+		fmt.Fprintf(p.w, "%s%s%s \x1b[%sm%s%s │ %s\n", ansiDim, timestamp, ansiReset, p.colors[line.Source], name, ansiReset, line.Text)
+	}
+}
@@ -0,0 +1,30 @@
+package tail
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestPrinter(t *testing.T) {
+	at := time.Date(2026, 3, 1, 14, 5, 9, 123e6, time.Local)
+	sources := []Source{{Name: "api"}, {Name: "postgres"}}
+
+	var buf bytes.Buffer
+	printer := NewPrinter(&buf, sources, false)
+	printer.Print(Line{Source: "api", Text: "GET /health 200", Time: at})
+	printer.Print(Line{Source: "postgres", Text: "container restarted", Time: at, Notice: true})
+	want := "14:05:09.123 api      │ GET /health 200\n" +
+		"14:05:09.123 postgres │ -- container restarted\n"
+	if buf.String() != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	printer = NewPrinter(&buf, sources, true)
+	printer.Print(Line{Source: "postgres", Text: "ready", Time: at})
+	want = "\x1b[2m14:05:09.123\x1b[0m \x1b[33mpostgres\x1b[0m │ ready\n"
+	if buf.String() != want {
+		t.Errorf("Got %q, want %q", buf.String(), want)
+	}
+}
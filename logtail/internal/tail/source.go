@@ -0,0 +1,141 @@
+// Package tail follows log files, Docker containers and commands, merging
+// their lines into one stream that can be filtered by the scheduler's
+// correlation IDs.
+package tail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Source kinds returned by Source.Kind
+const (
+	KindFile      = "file"
+	KindContainer = "container"
+	KindCommand   = "command"
+)
+
+// Config is a sources file
+type Config struct {
+	Sources []Source `yaml:"sources"`
+}
+
+// Source is one log to follow. Exactly one of File, Container and Command
+// is set.
+type Source struct {
+	// Name labels the source's lines; derived from the file, container or
+	// command when empty
+	Name string `yaml:"name,omitempty"`
+
+	// File is followed across truncation and rotation, and waited for if it
+	// does not exist yet
+	File string `yaml:"file,omitempty"`
+
+	// Container is a Docker container whose output is followed across
+	// restarts
+	Container string `yaml:"container,omitempty"`
+
+	// Command is run once and its standard output and error followed
+	Command []string `yaml:"command,omitempty"`
+}
+
+// Kind returns how the source is followed
+func (s *Source) Kind() string {
+	switch {
+	case s.Container != "":
+		return KindContainer
+	case len(s.Command) > 0:
+		return KindCommand
+	default:
+		return KindFile
+	}
+}
+
+// Validate checks that exactly one of file, container and command is set
+// and fills in the name
+func (s *Source) Validate() error {
+	set := 0
+	for _, ok := range []bool{s.File != "", s.Container != "", len(s.Command) > 0} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of file, container or command must be set")
+	}
+
+	if s.Name == "" {
+		switch s.Kind() {
+		case KindFile:
+			s.Name = strings.TrimSuffix(filepath.Base(s.File), filepath.Ext(s.File))
+		case KindContainer:
+			s.Name = s.Container
+		case KindCommand:
+			s.Name = filepath.Base(s.Command[0])
+		}
+	}
+	return nil
+}
+
+// ParseSource reads a source given on the command line: a file path,
+// docker:<container> or cmd:<command line>, optionally prefixed with
+// name= to label it
+func ParseSource(arg string) (Source, error) {
+	var source Source
+	if name, spec, ok := strings.Cut(arg, "="); ok && !strings.ContainsAny(name, "/:\\ ") {
+		source.Name, arg = name, spec
+	}
+
+	switch {
+	case strings.HasPrefix(arg, "docker:"):
+		source.Container = strings.TrimPrefix(arg, "docker:")
+	case strings.HasPrefix(arg, "cmd:"):
+		source.Command = strings.Fields(strings.TrimPrefix(arg, "cmd:"))
+	default:
+		source.File = arg
+	}
+	if err := source.Validate(); err != nil {
+		return Source{}, fmt.Errorf("invalid source %q: %w", arg, err)
+	}
+	return source, nil
+}
+
+// LoadConfig reads and validates a sources file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	for i := range config.Sources {
+		if err := config.Sources[i].Validate(); err != nil {
+			return nil, fmt.Errorf("source %d: %w", i, err)
+		}
+	}
+	return &config, nil
+}
+
+// UniqueNames suffixes repeated source names with a number so every source
+// can be told apart
+func UniqueNames(sources []Source) {
+	counts := make(map[string]int)
+	for _, s := range sources {
+		counts[s.Name]++
+	}
+	seen := make(map[string]int)
+	for i := range sources {
+		name := sources[i].Name
+		if counts[name] > 1 {
+			seen[name]++
+			sources[i].Name = fmt.Sprintf("%s-%d", name, seen[name])
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package tail
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := map[string]Source{
+		"/var/log/api.log":            {Name: "api", File: "/var/log/api.log"},
+		"web=./logs/nginx/access.log": {Name: "web", File: "./logs/nginx/access.log"},
+		"docker:myapp-db-1":           {Name: "myapp-db-1", Container: "myapp-db-1"},
+		"db=docker:myapp-db-1":        {Name: "db", Container: "myapp-db-1"},
+		"cmd:kubectl logs -f deploy/orders": {
+			Name: "kubectl", Command: []string{"kubectl", "logs", "-f", "deploy/orders"},
+		},
+		"orders=cmd:kubectl logs -f deploy/orders": {
+			Name: "orders", Command: []string{"kubectl", "logs", "-f", "deploy/orders"},
+		},
+		// An = in a path is not a name
+		"/tmp/run=1/app.log": {Name: "app", File: "/tmp/run=1/app.log"},
+	}
+	for arg, want := range tests {
+		got, err := ParseSource(arg)
+		if err != nil {
+			t.Errorf("ParseSource(%q) failed: %v", arg, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseSource(%q) = %+v, want %+v", arg, got, want)
+		}
+	}
+
+	for _, arg := range []string{"", "docker:", "cmd:", "name="} {
+		if _, err := ParseSource(arg); err == nil {
+			t.Errorf("Expected ParseSource(%q) to fail", arg)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sources.yaml")
+	os.WriteFile(path, []byte(`
+sources:
+  - file: logs/api.log
+  - name: db
+    container: myapp-db-1
+  - name: worker
+    command: [kubectl, logs, -f, deploy/worker]
+`), 0o644)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	want := []struct{ name, kind string }{{"api", KindFile}, {"db", KindContainer}, {"worker", KindCommand}}
+	for i, w := range want {
+		if s := config.Sources[i]; s.Name != w.name || s.Kind() != w.kind {
+			t.Errorf("Source %d: got %s (%s), want %s (%s)", i, s.Name, s.Kind(), w.name, w.kind)
+		}
+	}
+
+	os.WriteFile(path, []byte("sources:\n  - file: a.log\n    container: a\n"), 0o644)
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "source 0: exactly one") {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+}
+
+func TestUniqueNames(t *testing.T) {
+	sources := []Source{{Name: "app"}, {Name: "db"}, {Name: "app"}}
+	UniqueNames(sources)
+	var names []string
+	for _, s := range sources {
+		names = append(names, s.Name)
+	}
+	if want := []string{"app-1", "db", "app-2"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Got names %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,35 @@
+package tail
+
+import (
+	"context"
+	"sync"
+)
+
+// Run follows every source, starting with its last backlog lines, and calls
+// emit with each line that passes the filter, one at a time. It returns
+// when ctx is done, or once every source has ended, which only command
+// sources do.
+func Run(ctx context.Context, sources []Source, backlog int, filter *Filter, emit func(Line)) {
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	merged := func(line Line) {
+		if filter != nil && !filter.Match(line) {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		emit(line)
+	}
+
+	for i := range sources {
+		source := sources[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			source.follow(ctx, backlog, merged)
+		}()
+	}
+	wg.Wait()
+}
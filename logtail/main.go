@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"local-dev-tools/logtail/internal/tail"
+)
+
+// stringList collects a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run follows the sources until interrupted and returns the process exit
+// code
+func run(args []string) int {
+	fs := flag.NewFlagSet("logtail", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] [name=]source...\n\nSources are file paths, docker:<container> or cmd:<command line>.\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", "", "Path to a sources file (YAML or JSON), followed along with any sources given as arguments")
+	lines := fs.Int("lines", 10, "Number of existing lines to show from each file and container")
+	grep := fs.String("grep", "", "Only show lines matching this regular expression")
+	color := fs.String("color", "auto", "Color source names: auto, always or never")
+	var runIDs, requestIDs stringList
+	fs.Var(&runIDs, "run", "Only show lines for this scheduler run ID, and the request IDs logged with it (repeatable)")
+	fs.Var(&requestIDs, "request", "Only show lines for this request ID (repeatable)")
+	fs.Parse(args)
+
+	var sources []tail.Source
+	if *configPath != "" {
+		config, err := tail.LoadConfig(*configPath)
+		if err != nil {
+			log.Printf("Error loading config: %v", err)
+			return 2
+		}
+		sources = append(sources, config.Sources...)
+	}
+	for _, arg := range fs.Args() {
+		source, err := tail.ParseSource(arg)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return 2
+		}
+		sources = append(sources, source)
+	}
+	if len(sources) == 0 {
+		fs.Usage()
+		return 2
+	}
+	tail.UniqueNames(sources)
+
+	var pattern *regexp.Regexp
+	if *grep != "" {
+		var err error
+		if pattern, err = regexp.Compile(*grep); err != nil {
+			log.Printf("Error: invalid --grep: %v", err)
+			return 2
+		}
+	}
+	filter := tail.NewFilter(pattern, append(runIDs, requestIDs...))
+
+	useColor, err := colorEnabled(*color)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 2
+	}
+	printer := tail.NewPrinter(os.Stdout, sources, useColor)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	tail.Run(ctx, sources, *lines, filter, printer.Print)
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "\nShutting down...")
+	}
+	return 0
+}
+
+// colorEnabled resolves the --color option; auto colors a terminal unless
+// NO_COLOR is set
+func colorEnabled(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		info, err := os.Stdout.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	default:
+		return false, fmt.Errorf("invalid --color %q: must be auto, always or never", mode)
+	}
+}
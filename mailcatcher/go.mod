@@ -0,0 +1,3 @@
+module local-dev-tools/mailcatcher
+
+go 1.21
@@ -0,0 +1,185 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxWait bounds the wait parameter of /messages/latest
+const maxWait = 5 * time.Minute
+
+// API serves a store's messages over HTTP
+type API struct {
+	store *Store
+}
+
+// NewAPI creates the HTTP API for store
+func NewAPI(store *Store) *API {
+	return &API{store: store}
+}
+
+// ServeHTTP routes /messages, /messages/latest and /messages/{id}[/raw,
+// /html, /text]
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if parts[0] != "messages" || len(parts) > 3 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no endpoint %s", r.URL.Path))
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			a.list(w, r)
+		case http.MethodDelete:
+			writeJSON(w, http.StatusOK, map[string]int{"deleted": a.store.Clear()})
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "use GET or DELETE")
+		}
+		return
+	}
+
+	if parts[1] == "latest" && len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "use GET")
+			return
+		}
+		a.latest(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("invalid message id %q", parts[1]))
+		return
+	}
+	if r.Method == http.MethodDelete && len(parts) == 2 {
+		if !a.store.Delete(id) {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("no message %d", id))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "use GET or DELETE")
+		return
+	}
+
+	message := a.store.Get(id)
+	if message == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no message %d", id))
+		return
+	}
+	view := ""
+	if len(parts) == 3 {
+		view = parts[2]
+	}
+	a.serveMessage(w, message, view)
+}
+
+func (a *API) list(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	messages := a.store.List(filter)
+	summaries := make([]Summary, len(messages))
+	for i, message := range messages {
+		summaries[i] = message.Summary()
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"messages": summaries, "count": len(summaries)})
+}
+
+// latest serves the newest matching message, waiting up to the wait
+// parameter for one to arrive, or 404 when there is none
+func (a *API) latest(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, err := parseFilter(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var wait time.Duration
+	if raw := query.Get("wait"); raw != "" {
+		if wait, err = time.ParseDuration(raw); err != nil || wait < 0 || wait > maxWait {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("wait must be a duration up to %v", maxWait))
+			return
+		}
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+
+	message := a.store.Latest(ctx, filter)
+	if message == nil {
+		writeError(w, http.StatusNotFound, "no matching message")
+		return
+	}
+	a.serveMessage(w, message, query.Get("view"))
+}
+
+// serveMessage writes the message as JSON, or one of its raw, html or text
+// views
+func (a *API) serveMessage(w http.ResponseWriter, message *Message, view string) {
+	switch view {
+	case "":
+		writeJSON(w, http.StatusOK, message)
+	case "raw":
+		w.Header().Set("Content-Type", "message/rfc822")
+		w.Write(message.raw)
+	case "html":
+		if message.HTML == "" {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("message %d has no HTML body", message.ID))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(message.HTML))
+	case "text":
+		if message.Text == "" {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("message %d has no text body", message.ID))
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(message.Text))
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown view %q (raw, html or text)", view))
+	}
+}
+
+// parseFilter reads the to, from, subject and since query parameters. since
+// is an RFC 3339 time, or a duration meaning that long ago.
+func parseFilter(query url.Values) (Filter, error) {
+	filter := Filter{To: query.Get("to"), From: query.Get("from"), Subject: query.Get("subject")}
+	if since := query.Get("since"); since != "" {
+		if d, err := time.ParseDuration(since); err == nil {
+			filter.Since = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, since); err == nil {
+			filter.Since = t
+		} else {
+			return Filter{}, fmt.Errorf("since must be an RFC 3339 time or a duration such as 5m")
+		}
+	}
+	return filter, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// Bodies and links are more readable with & and < left alone
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
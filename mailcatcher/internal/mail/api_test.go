@@ -0,0 +1,90 @@
+package mail
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func serve(api *API, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestAPI_Messages(t *testing.T) {
+	store := NewStore(0)
+	api := NewAPI(store)
+	store.Add("app@local", []string{"alice@example.com"}, []byte(multipartMessage))
+	addMessage(store, "billing@local", "bob@example.com", "Invoice")
+
+	rec := serve(api, http.MethodGet, "/messages?to=alice")
+	var list struct {
+		Count    int       `json:"count"`
+		Messages []Summary `json:"messages"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &list)
+	if rec.Code != http.StatusOK || list.Count != 1 || list.Messages[0].Subject != "Héllo world" || list.Messages[0].Attachments != 1 {
+		t.Errorf("Unexpected list %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = serve(api, http.MethodGet, "/messages/1")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"links":["https://app.local/verify?token=abc&x=1"`) {
+		t.Errorf("Expected the full message with unescaped links, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	for view, contentType := range map[string]string{"raw": "message/rfc822", "html": "text/html; charset=utf-8", "text": "text/plain; charset=utf-8"} {
+		rec = serve(api, http.MethodGet, "/messages/1/"+view)
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != contentType {
+			t.Errorf("Expected the %s view, got %d %s", view, rec.Code, rec.Header().Get("Content-Type"))
+		}
+	}
+	if rec := serve(api, http.MethodGet, "/messages/2/html"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing HTML body, got %d", rec.Code)
+	}
+
+	for _, target := range []string{"/messages/9", "/messages/abc", "/other", "/messages/1/pdf"} {
+		if rec := serve(api, http.MethodGet, target); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s, got %d", target, rec.Code)
+		}
+	}
+	if rec := serve(api, http.MethodGet, "/messages?since=yesterday"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid since, got %d", rec.Code)
+	}
+
+	if rec := serve(api, http.MethodDelete, "/messages/2"); rec.Code != http.StatusNoContent || store.Get(2) != nil {
+		t.Errorf("Expected message 2 to be deleted, got %d", rec.Code)
+	}
+	if rec := serve(api, http.MethodDelete, "/messages"); rec.Code != http.StatusOK || rec.Body.String() != "{\"deleted\":1}\n" {
+		t.Errorf("Expected all messages to be deleted, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAPI_Latest(t *testing.T) {
+	store := NewStore(0)
+	api := NewAPI(store)
+
+	start := time.Now()
+	if rec := serve(api, http.MethodGet, "/messages/latest?to=alice&wait=50ms"); rec.Code != http.StatusNotFound || time.Since(start) < 50*time.Millisecond {
+		t.Errorf("Expected 404 after waiting, got %d", rec.Code)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		addMessage(store, "app@local", "alice@example.com", "Welcome")
+	}()
+	rec := serve(api, http.MethodGet, "/messages/latest?to=alice&subject=welcome&since=1m&wait=2s")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"subject":"Welcome"`) {
+		t.Errorf("Expected the awaited message, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := serve(api, http.MethodGet, "/messages/latest?view=text"); rec.Code != http.StatusOK || rec.Body.String() != "body\r\n" {
+		t.Errorf("Expected the text view of the latest message, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := serve(api, http.MethodGet, "/messages/latest?wait=1h"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected waits beyond the maximum to be rejected, got %d", rec.Code)
+	}
+}
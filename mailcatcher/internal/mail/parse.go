@@ -0,0 +1,133 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+)
+
+// maxPartDepth bounds the nesting of multipart messages
+const maxPartDepth = 10
+
+var (
+	wordDecoder = new(mime.WordDecoder)
+
+	// linkPattern finds URLs in text and in HTML attributes
+	linkPattern = regexp.MustCompile(`https?://[^\s"'<>)\]]+`)
+)
+
+// parseMessage decodes the headers, bodies and attachments of a raw
+// message. Decoding problems are recorded in ParseError rather than
+// failing, so malformed messages are still caught.
+func parseMessage(raw []byte) *Message {
+	message := &Message{raw: raw, Size: len(raw), Headers: map[string][]string{}}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		message.ParseError = fmt.Sprintf("invalid message: %v", err)
+		return message
+	}
+	for name, values := range parsed.Header {
+		for _, value := range values {
+			if decoded, err := wordDecoder.DecodeHeader(value); err == nil {
+				value = decoded
+			}
+			message.Headers[name] = append(message.Headers[name], value)
+		}
+	}
+	if subject := message.Headers["Subject"]; len(subject) > 0 {
+		message.Subject = subject[0]
+	}
+
+	if err := message.readPart(textproto.MIMEHeader(parsed.Header), parsed.Body, 0); err != nil {
+		message.ParseError = err.Error()
+	}
+	message.Links = findLinks(message.Text + "\n" + message.HTML)
+	return message
+}
+
+// readPart decodes one MIME part, recursing into multipart containers
+func (m *Message) readPart(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	if depth > maxPartDepth {
+		return fmt.Errorf("multipart nesting deeper than %d", maxPartDepth)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("invalid %s part: %v", mediaType, err)
+			}
+			if err := m.readPart(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := io.ReadAll(decodeTransfer(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("invalid %s body: %v", mediaType, err)
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if decoded, err := wordDecoder.DecodeHeader(filename); err == nil {
+		filename = decoded
+	}
+
+	switch {
+	case disposition == "attachment" || filename != "" || !strings.HasPrefix(mediaType, "text/"):
+		m.Attachments = append(m.Attachments, Attachment{Filename: filename, ContentType: mediaType, Size: len(content)})
+	case mediaType == "text/html" && m.HTML == "":
+		m.HTML = string(content)
+	case mediaType != "text/html" && m.Text == "":
+		m.Text = string(content)
+	}
+	return nil
+}
+
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		// The decoder skips the line breaks of wrapped bodies
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+// findLinks returns the distinct URLs in text, in order of appearance
+func findLinks(text string) []string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, link := range linkPattern.FindAllString(text, -1) {
+		// HTML bodies escape & in attribute values
+		link = strings.TrimRight(strings.ReplaceAll(link, "&amp;", "&"), ".,;:!?")
+		if !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+	return links
+}
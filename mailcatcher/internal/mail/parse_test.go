@@ -0,0 +1,85 @@
+package mail
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const multipartMessage = "From: App <app@local>\r\n" +
+	"To: alice@example.com\r\n" +
+	"Cc: Bob <bob@example.com>\r\n" +
+	"Subject: =?UTF-8?Q?H=C3=A9llo?= world\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Verify at https://app.local/verify?token=3Dabc&x=1.\r\n" +
+	"Caf=C3=A9\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"PGEgaHJlZj0iaHR0cHM6Ly9hcHAubG9jYWwvdmVyaWZ5P3Rva2VuPWFiYyZhbXA7eD0xIj5WZXJp\r\n" +
+	"Znk8L2E+IDxhIGhyZWY9Imh0dHA6Ly9hcHAubG9jYWwvdW5zdWJzY3JpYmUiPng8L2E+\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=\"invoice.pdf\"\r\n" +
+	"Content-Disposition: attachment; filename=\"invoice.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"AAECAw==\r\n" +
+	"--outer--\r\n"
+
+func TestParseMessage_Multipart(t *testing.T) {
+	message := parseMessage([]byte(multipartMessage))
+	if message.ParseError != "" {
+		t.Fatalf("Unexpected parse error: %s", message.ParseError)
+	}
+
+	if message.Subject != "Héllo world" {
+		t.Errorf("Expected the decoded subject, got %q", message.Subject)
+	}
+	if message.Text != "Verify at https://app.local/verify?token=abc&x=1.\r\nCafé" {
+		t.Errorf("Expected the quoted-printable text, got %q", message.Text)
+	}
+	if !strings.HasPrefix(message.HTML, `<a href="https://app.local/verify?token=abc&amp;x=1">Verify</a>`) {
+		t.Errorf("Expected the base64 HTML, got %q", message.HTML)
+	}
+	wantLinks := []string{"https://app.local/verify?token=abc&x=1", "http://app.local/unsubscribe"}
+	if !reflect.DeepEqual(message.Links, wantLinks) {
+		t.Errorf("Expected links %v, got %v", wantLinks, message.Links)
+	}
+	wantAttachments := []Attachment{{Filename: "invoice.pdf", ContentType: "application/pdf", Size: 4}}
+	if !reflect.DeepEqual(message.Attachments, wantAttachments) {
+		t.Errorf("Expected attachments %v, got %v", wantAttachments, message.Attachments)
+	}
+	if message.Headers["Cc"][0] != "Bob <bob@example.com>" {
+		t.Errorf("Unexpected headers %v", message.Headers)
+	}
+}
+
+func TestParseMessage_Plain(t *testing.T) {
+	message := parseMessage([]byte("Subject: hi\r\n\r\nJust text\r\n"))
+	if message.ParseError != "" || message.Subject != "hi" || message.Text != "Just text\r\n" || message.HTML != "" {
+		t.Errorf("Unexpected message %+v", message)
+	}
+}
+
+func TestParseMessage_Malformed(t *testing.T) {
+	message := parseMessage([]byte("not a message"))
+	if message.ParseError == "" || string(message.Raw()) != "not a message" || message.Size != 13 {
+		t.Errorf("Expected the raw message with a parse error, got %+v", message)
+	}
+
+	message = parseMessage([]byte("Subject: x\r\nContent-Type: multipart/mixed; boundary=b\r\n\r\n--b\r\nbroken"))
+	if message.ParseError == "" || message.Subject != "x" {
+		t.Errorf("Expected headers with a parse error, got %+v", message)
+	}
+}
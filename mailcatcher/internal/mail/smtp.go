@@ -0,0 +1,270 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commandTimeout bounds how long a client may take to send each command
+const commandTimeout = 5 * time.Minute
+
+// SMTPServer accepts messages for any sender and recipient and adds them to
+// a store. It supports AUTH, accepting any credentials, but not STARTTLS.
+type SMTPServer struct {
+	Store *Store
+
+	// Hostname is announced in the greeting, "mailcatcher" when empty
+	Hostname string
+
+	// MaxSize rejects larger messages; unlimited when 0
+	MaxSize int
+
+	// Logf receives one line per received message and may be nil
+	Logf func(format string, args ...interface{})
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]bool
+	closed   bool
+}
+
+// Serve accepts connections on l until Close is called
+func (s *SMTPServer) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return net.ErrClosed
+	}
+	s.listener = l
+	s.conns = make(map[net.Conn]bool)
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return net.ErrClosed
+			}
+			return err
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+
+		go func() {
+			s.serveConn(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Close stops accepting connections and closes open sessions
+func (s *SMTPServer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+// session is the state of one SMTP conversation
+type session struct {
+	from       string
+	recipients []string
+	started    bool
+}
+
+func (s *SMTPServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	hostname := s.Hostname
+	if hostname == "" {
+		hostname = "mailcatcher"
+	}
+
+	reply := func(format string, args ...interface{}) bool {
+		return text.PrintfLine(format, args...) == nil
+	}
+	reply("220 %s ESMTP mailcatcher ready", hostname)
+
+	var state session
+	for {
+		conn.SetReadDeadline(time.Now().Add(commandTimeout))
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		verb = strings.ToUpper(verb)
+		arg = strings.TrimSpace(arg)
+
+		switch verb {
+		case "HELO":
+			state = session{}
+			reply("250 %s", hostname)
+		case "EHLO":
+			state = session{}
+			text.PrintfLine("250-%s greets %s", hostname, arg)
+			if s.MaxSize > 0 {
+				text.PrintfLine("250-SIZE %d", s.MaxSize)
+			}
+			text.PrintfLine("250-8BITMIME")
+			text.PrintfLine("250-SMTPUTF8")
+			text.PrintfLine("250-AUTH PLAIN LOGIN")
+			reply("250 HELP")
+		case "AUTH":
+			if !s.authenticate(text, arg) {
+				return
+			}
+		case "MAIL":
+			address, err := parsePath(arg, "FROM:")
+			if err != nil {
+				reply("501 5.5.4 %v", err)
+				continue
+			}
+			state = session{from: address, started: true}
+			reply("250 2.1.0 OK")
+		case "RCPT":
+			if !state.started {
+				reply("503 5.5.1 MAIL first")
+				continue
+			}
+			address, err := parsePath(arg, "TO:")
+			if err != nil || address == "" {
+				reply("501 5.5.4 invalid recipient")
+				continue
+			}
+			state.recipients = append(state.recipients, address)
+			reply("250 2.1.5 OK")
+		case "DATA":
+			if len(state.recipients) == 0 {
+				reply("503 5.5.1 RCPT first")
+				continue
+			}
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			if !s.receive(text, state) {
+				return
+			}
+			state = session{}
+		case "RSET":
+			state = session{}
+			reply("250 2.0.0 OK")
+		case "NOOP":
+			reply("250 2.0.0 OK")
+		case "VRFY":
+			reply("252 2.5.0 Cannot verify, but will accept")
+		case "QUIT":
+			reply("221 2.0.0 Bye")
+			return
+		case "STARTTLS":
+			reply("502 5.5.1 TLS is not supported")
+		default:
+			reply("500 5.5.2 Unknown command %s", verb)
+		}
+	}
+}
+
+// receive reads a message body and stores it, replying with the result. It
+// returns false when the connection is unusable.
+func (s *SMTPServer) receive(text *textproto.Conn, state session) bool {
+	dot := text.DotReader()
+	body := dot
+	if s.MaxSize > 0 {
+		body = io.LimitReader(dot, int64(s.MaxSize)+1)
+	}
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return false
+	}
+	if s.MaxSize > 0 && len(raw) > s.MaxSize {
+		// Drain the rest so the session can continue
+		if _, err := io.Copy(io.Discard, dot); err != nil {
+			return false
+		}
+		text.PrintfLine("552 5.3.4 Message exceeds the maximum size of %d bytes", s.MaxSize)
+		return true
+	}
+
+	message := s.Store.Add(state.from, state.recipients, raw)
+	if s.Logf != nil {
+		s.Logf("Received message %d from %s to %s: %q (%d bytes)", message.ID, state.from, strings.Join(state.recipients, ", "), message.Subject, message.Size)
+	}
+	text.PrintfLine("250 2.0.0 OK queued as %d", message.ID)
+	return true
+}
+
+// authenticate runs an AUTH PLAIN or LOGIN exchange, accepting any
+// credentials. It returns false when the connection is unusable.
+func (s *SMTPServer) authenticate(text *textproto.Conn, arg string) bool {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	prompts := 0
+	switch strings.ToUpper(mechanism) {
+	case "PLAIN":
+		if initial == "" {
+			prompts = 1
+		}
+	case "LOGIN":
+		// Username and password prompts, fewer when an initial response
+		// carried the username
+		prompts = 2
+		if initial != "" {
+			prompts = 1
+		}
+	default:
+		return text.PrintfLine("504 5.5.4 Unsupported authentication mechanism") == nil
+	}
+
+	challenges := []string{"VXNlcm5hbWU6", "UGFzc3dvcmQ6"} // "Username:", "Password:"
+	for i := 0; i < prompts; i++ {
+		challenge := ""
+		if strings.ToUpper(mechanism) == "LOGIN" {
+			challenge = challenges[2-prompts+i]
+		}
+		if err := text.PrintfLine("334 %s", challenge); err != nil {
+			return false
+		}
+		response, err := text.ReadLine()
+		if err != nil {
+			return false
+		}
+		if response == "*" {
+			return text.PrintfLine("501 5.0.0 Authentication cancelled") == nil
+		}
+	}
+	return text.PrintfLine("235 2.7.0 Authentication successful") == nil
+}
+
+// parsePath extracts the address from "FROM:<a@b> SIZE=123" style
+// arguments. The null sender <> yields an empty address.
+func parsePath(arg, prefix string) (string, error) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", fmt.Errorf("expected %s<address>", prefix)
+	}
+	path := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(path, "<") {
+		// Some clients omit the brackets
+		address, _, _ := strings.Cut(path, " ")
+		return address, nil
+	}
+	end := strings.Index(path, ">")
+	if end < 0 {
+		return "", errors.New("unterminated address")
+	}
+	return path[1:end], nil
+}
@@ -0,0 +1,151 @@
+package mail
+
+import (
+	"bufio"
+	"net"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+func startSMTP(t *testing.T, maxSize int) (*SMTPServer, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := &SMTPServer{Store: NewStore(0), MaxSize: maxSize}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return server, listener.Addr().String()
+}
+
+func TestSMTPServer_SendMail(t *testing.T) {
+	server, addr := startSMTP(t, 0)
+
+	// net/smtp only sends PLAIN credentials over TLS or to localhost
+	auth := smtp.PlainAuth("", "user", "anything", "127.0.0.1")
+	body := "From: app@local\r\nTo: alice@example.com\r\nSubject: Welcome\r\n\r\nHello\r\n.leading dot\r\n"
+	err := smtp.SendMail(addr, auth, "app@local", []string{"alice@example.com", "bcc@example.com"}, []byte(body))
+	if err != nil {
+		t.Fatalf("SendMail failed: %v", err)
+	}
+
+	messages := server.Store.List(Filter{})
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	m := messages[0]
+	if m.From != "app@local" || strings.Join(m.To, ",") != "alice@example.com,bcc@example.com" || m.Subject != "Welcome" {
+		t.Errorf("Unexpected message %+v", m)
+	}
+	if m.Text != "Hello\n.leading dot\n" {
+		t.Errorf("Expected dot-stuffing to be undone, got %q", m.Text)
+	}
+}
+
+// converse sends each command and returns the replies' first lines
+func converse(t *testing.T, addr string, commands ...string) []string {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	readReply := func() string {
+		var first string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			if first == "" {
+				first = strings.TrimRight(line, "\r\n")
+			}
+			// Continuation lines have a dash after the code
+			if len(line) < 4 || line[3] != '-' {
+				return first
+			}
+		}
+	}
+
+	replies := []string{readReply()}
+	for _, command := range commands {
+		conn.Write([]byte(command + "\r\n"))
+		if strings.HasSuffix(command, "\r\n.") || !strings.Contains(command, "\r\n") {
+			replies = append(replies, readReply())
+		}
+	}
+	return replies
+}
+
+func TestSMTPServer_Protocol(t *testing.T) {
+	server, addr := startSMTP(t, 40)
+	replies := converse(t, addr,
+		"EHLO client",
+		"RCPT TO:<a@b>",
+		"MAIL FROM:<>",
+		"RCPT TO:bounce@example.com",
+		"AUTH LOGIN",
+		"dXNlcg==",
+		"cGFzcw==",
+		"AUTH CRAM-MD5",
+		"STARTTLS",
+		"DATA",
+		"Subject: too long\r\n\r\nthis body is longer than forty bytes\r\n.",
+		"MAIL FROM:<app@local> SIZE=10",
+		"RCPT TO:<x@y>",
+		"DATA",
+		"Subject: ok\r\n\r\nshort\r\n.",
+		"HELP",
+		"QUIT",
+	)
+	want := []string{
+		"220 mailcatcher ESMTP mailcatcher ready",
+		"250-mailcatcher greets client",
+		"503 5.5.1 MAIL first",
+		"250 2.1.0 OK",
+		"250 2.1.5 OK",
+		"334 VXNlcm5hbWU6",
+		"334 UGFzc3dvcmQ6",
+		"235 2.7.0 Authentication successful",
+		"504 5.5.4 Unsupported authentication mechanism",
+		"502 5.5.1 TLS is not supported",
+		"354 End data with <CR><LF>.<CR><LF>",
+		"552 5.3.4 Message exceeds the maximum size of 40 bytes",
+		"250 2.1.0 OK",
+		"250 2.1.5 OK",
+		"354 End data with <CR><LF>.<CR><LF>",
+		"250 2.0.0 OK queued as 1",
+		"500 5.5.2 Unknown command HELP",
+		"221 2.0.0 Bye",
+	}
+	if strings.Join(replies, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected replies:\n%s\nwant:\n%s", strings.Join(replies, "\n"), strings.Join(want, "\n"))
+	}
+
+	if m := server.Store.Get(1); m == nil || m.Subject != "ok" || m.From != "app@local" {
+		t.Errorf("Expected only the short message to be stored, got %+v", m)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := map[string]string{
+		"FROM:<a@b>":             "a@b",
+		"from: <a@b> SIZE=100":   "a@b",
+		"FROM:<>":                "",
+		"FROM:a@b BODY=8BITMIME": "a@b",
+	}
+	for arg, want := range tests {
+		if got, err := parsePath(arg, "FROM:"); err != nil || got != want {
+			t.Errorf("parsePath(%q) = %q, %v; want %q", arg, got, err, want)
+		}
+	}
+	for _, arg := range []string{"TO:<a@b>", "FROM:<a@b"} {
+		if _, err := parsePath(arg, "FROM:"); err == nil {
+			t.Errorf("Expected %q to be rejected", arg)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package mail accepts messages over SMTP, keeps them in memory and serves
+// them over an HTTP API for end-to-end assertions.
+package mail
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a received message
+type Message struct {
+	ID         int       `json:"id"`
+	ReceivedAt time.Time `json:"received_at"`
+
+	// From and To are the SMTP envelope sender and recipients, which
+	// include Bcc recipients absent from the headers
+	From string   `json:"from"`
+	To   []string `json:"to"`
+
+	Subject     string              `json:"subject"`
+	Headers     map[string][]string `json:"headers"`
+	Text        string              `json:"text,omitempty"`
+	HTML        string              `json:"html,omitempty"`
+	Links       []string            `json:"links,omitempty"`
+	Attachments []Attachment        `json:"attachments,omitempty"`
+	Size        int                 `json:"size"`
+
+	// ParseError is set when the content could not be fully decoded; the
+	// raw message is still available
+	ParseError string `json:"parse_error,omitempty"`
+
+	raw []byte
+}
+
+// Attachment describes a non-inline part of a message
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+// Summary is the listing form of a message
+type Summary struct {
+	ID          int       `json:"id"`
+	ReceivedAt  time.Time `json:"received_at"`
+	From        string    `json:"from"`
+	To          []string  `json:"to"`
+	Subject     string    `json:"subject"`
+	Size        int       `json:"size"`
+	Attachments int       `json:"attachments"`
+}
+
+// Summary returns the message without its content
+func (m *Message) Summary() Summary {
+	return Summary{
+		ID:          m.ID,
+		ReceivedAt:  m.ReceivedAt,
+		From:        m.From,
+		To:          m.To,
+		Subject:     m.Subject,
+		Size:        m.Size,
+		Attachments: len(m.Attachments),
+	}
+}
+
+// Raw returns the message as received
+func (m *Message) Raw() []byte {
+	return m.raw
+}
+
+// Filter selects messages. Empty fields match everything; strings match
+// case-insensitive substrings.
+type Filter struct {
+	// To matches envelope recipients and the To and Cc headers
+	To string
+
+	// From matches the envelope sender and the From header
+	From    string
+	Subject string
+
+	// Since matches messages received at or after it
+	Since time.Time
+}
+
+// Match reports whether m passes the filter
+func (f Filter) Match(m *Message) bool {
+	if !f.Since.IsZero() && m.ReceivedAt.Before(f.Since) {
+		return false
+	}
+	if f.Subject != "" && !containsFold(m.Subject, f.Subject) {
+		return false
+	}
+	if f.From != "" && !anyContainsFold(append([]string{m.From}, m.Headers["From"]...), f.From) {
+		return false
+	}
+	if f.To != "" {
+		recipients := append(append(append([]string{}, m.To...), m.Headers["To"]...), m.Headers["Cc"]...)
+		if !anyContainsFold(recipients, f.To) {
+			return false
+		}
+	}
+	return true
+}
+
+// Store keeps the most recent messages in memory
+type Store struct {
+	limit int
+
+	mu       sync.Mutex
+	messages []*Message
+	nextID   int
+
+	// added is closed and replaced whenever a message is added, waking
+	// waiters
+	added chan struct{}
+}
+
+// NewStore creates a store keeping at most limit messages, dropping the
+// oldest beyond it
+func NewStore(limit int) *Store {
+	return &Store{limit: limit, nextID: 1, added: make(chan struct{})}
+}
+
+// Add parses and stores a message received from the envelope sender for
+// the recipients
+func (s *Store) Add(from string, to []string, raw []byte) *Message {
+	message := parseMessage(raw)
+	message.From = from
+	message.To = to
+	message.ReceivedAt = time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	message.ID = s.nextID
+	s.nextID++
+	s.messages = append(s.messages, message)
+	if s.limit > 0 && len(s.messages) > s.limit {
+		s.messages = s.messages[len(s.messages)-s.limit:]
+	}
+	close(s.added)
+	s.added = make(chan struct{})
+	return message
+}
+
+// List returns the messages matching the filter, newest first
+func (s *Store) List(filter Filter) []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []*Message
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if filter.Match(s.messages[i]) {
+			matched = append(matched, s.messages[i])
+		}
+	}
+	return matched
+}
+
+// Get returns the message with the given ID, or nil
+func (s *Store) Get(id int) *Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, message := range s.messages {
+		if message.ID == id {
+			return message
+		}
+	}
+	return nil
+}
+
+// Delete removes a message and reports whether it existed
+func (s *Store) Delete(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, message := range s.messages {
+		if message.ID == id {
+			s.messages = append(s.messages[:i], s.messages[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Clear removes every message and returns how many there were
+func (s *Store) Clear() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.messages)
+	s.messages = nil
+	return n
+}
+
+// Latest returns the newest message matching the filter, waiting for one
+// to arrive until ctx is done. It returns nil if none arrived.
+func (s *Store) Latest(ctx context.Context, filter Filter) *Message {
+	for {
+		s.mu.Lock()
+		var latest *Message
+		for i := len(s.messages) - 1; i >= 0; i-- {
+			if filter.Match(s.messages[i]) {
+				latest = s.messages[i]
+				break
+			}
+		}
+		added := s.added
+		s.mu.Unlock()
+
+		if latest != nil {
+			return latest
+		}
+		select {
+		case <-added:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func anyContainsFold(values []string, substr string) bool {
+	for _, v := range values {
+		if containsFold(v, substr) {
+			return true
+		}
+	}
+	return false
+}
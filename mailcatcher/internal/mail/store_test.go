@@ -0,0 +1,85 @@
+package mail
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func addMessage(s *Store, from, to, subject string) *Message {
+	return s.Add(from, []string{to}, []byte("From: "+from+"\r\nTo: "+to+"\r\nSubject: "+subject+"\r\n\r\nbody\r\n"))
+}
+
+func TestStore_ListAndFilter(t *testing.T) {
+	s := NewStore(0)
+	addMessage(s, "app@local", "alice@example.com", "Welcome")
+	addMessage(s, "billing@local", "bob@example.com", "Your invoice")
+	// Bcc recipients appear in the envelope only
+	s.Add("app@local", []string{"carol@example.com"}, []byte("To: undisclosed-recipients:;\r\nSubject: Reset your password\r\n\r\n"))
+
+	tests := []struct {
+		filter Filter
+		want   []int
+	}{
+		{Filter{}, []int{3, 2, 1}},
+		{Filter{To: "ALICE"}, []int{1}},
+		{Filter{To: "carol"}, []int{3}},
+		{Filter{From: "billing"}, []int{2}},
+		{Filter{Subject: "password", From: "app"}, []int{3}},
+		{Filter{Since: time.Now().Add(time.Hour)}, nil},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, m := range s.List(tt.filter) {
+			got = append(got, m.ID)
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+			t.Errorf("List(%+v) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestStore_LimitAndDelete(t *testing.T) {
+	s := NewStore(2)
+	for i := 0; i < 3; i++ {
+		addMessage(s, "a@local", "b@local", "hi")
+	}
+	if s.Get(1) != nil || s.Get(2) == nil || s.Get(3) == nil {
+		t.Error("Expected the oldest message to be dropped beyond the limit")
+	}
+
+	if !s.Delete(2) || s.Delete(2) || s.Get(2) != nil {
+		t.Error("Expected message 2 to be deleted once")
+	}
+	if n := s.Clear(); n != 1 || len(s.List(Filter{})) != 0 {
+		t.Errorf("Expected Clear to remove 1 message, got %d", n)
+	}
+	// IDs keep increasing after a clear
+	if m := addMessage(s, "a@local", "b@local", "hi"); m.ID != 4 {
+		t.Errorf("Expected ID 4, got %d", m.ID)
+	}
+}
+
+func TestStore_LatestWaits(t *testing.T) {
+	s := NewStore(0)
+	addMessage(s, "app@local", "bob@example.com", "Other")
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		addMessage(s, "app@local", "carol@example.com", "Unrelated")
+		time.Sleep(50 * time.Millisecond)
+		addMessage(s, "app@local", "alice@example.com", "Welcome")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if m := s.Latest(ctx, Filter{To: "alice"}); m == nil || m.Subject != "Welcome" {
+		t.Fatalf("Expected the awaited message, got %+v", m)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if m := s.Latest(ctx, Filter{To: "dave"}); m != nil {
+		t.Errorf("Expected no message, got %+v", m)
+	}
+}
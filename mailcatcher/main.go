@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"local-dev-tools/mailcatcher/internal/mail"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run accepts SMTP and serves the HTTP API until interrupted and returns
+// the process exit code
+func run() int {
+	smtpAddr := flag.String("smtp", ":1025", "Address to accept SMTP on")
+	httpAddr := flag.String("http", ":1080", "Address to serve the HTTP API on")
+	maxMessages := flag.Int("max-messages", 1000, "Messages kept in memory; the oldest are dropped beyond it (0 for unlimited)")
+	maxSize := flag.Int("max-size", 10<<20, "Largest message accepted, in bytes (0 for unlimited)")
+	hostname := flag.String("hostname", "mailcatcher", "Hostname announced to SMTP clients")
+	quiet := flag.Bool("quiet", false, "Do not log each received message")
+	flag.Parse()
+
+	if *maxMessages < 0 || *maxSize < 0 {
+		log.Printf("--max-messages and --max-size must not be negative")
+		return 2
+	}
+
+	store := mail.NewStore(*maxMessages)
+	smtpServer := &mail.SMTPServer{Store: store, Hostname: *hostname, MaxSize: *maxSize, Logf: log.Printf}
+	if *quiet {
+		smtpServer.Logf = nil
+	}
+	httpServer := &http.Server{Addr: *httpAddr, Handler: mail.NewAPI(store)}
+
+	smtpListener, err := net.Listen("tcp", *smtpAddr)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 3
+	}
+	httpListener, err := net.Listen("tcp", *httpAddr)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 3
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		fmt.Println("\nShutting down...")
+		smtpServer.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(ctx)
+	}()
+
+	errs := make(chan error, 2)
+	go func() { errs <- smtpServer.Serve(smtpListener) }()
+	go func() { errs <- httpServer.Serve(httpListener) }()
+	fmt.Printf("Accepting SMTP on %s; messages at http://localhost%s/messages\n", *smtpAddr, httpPort(*httpAddr))
+
+	// Both servers stop on shutdown; either failing earlier is fatal
+	code := 0
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
+			log.Printf("Server error: %v", err)
+			code = 3
+			smtpServer.Close()
+			httpServer.Close()
+		}
+	}
+	return code
+}
+
+// httpPort returns the :port part of a listen address
+func httpPort(addr string) string {
+	if _, port, err := net.SplitHostPort(addr); err == nil {
+		return ":" + port
+	}
+	return addr
+}
@@ -0,0 +1,194 @@
+package idp
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// authorizeParams are the authorization request parameters carried from the
+// sign-in page back to the provider
+var authorizeParams = []string{
+	"response_type", "client_id", "redirect_uri", "scope", "state", "nonce",
+	"code_challenge", "code_challenge_method",
+}
+
+var signInPage = template.Must(template.New("sign-in").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Sign in to {{ .Client }}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 28rem; margin: 4rem auto; color: #222; }
+button { display: block; width: 100%; margin: .5rem 0; padding: .75rem; font-size: 1rem; text-align: left; cursor: pointer; }
+.muted { color: #666; font-size: .9rem; }
+</style>
+</head>
+<body>
+<h1>Sign in to {{ .Client }}</h1>
+<p class="muted">Local test identity provider. Pick a user; no password is needed.{{ if .Scope }} Requested scope: <code>{{ .Scope }}</code>.{{ end }}</p>
+<form method="post" action="authorize">
+{{ range $name, $value := .Params }}<input type="hidden" name="{{ $name }}" value="{{ $value }}">
+{{ end }}{{ range .Users }}<button type="submit" name="username" value="{{ .Username }}">{{ .Username }}{{ with index .Claims "email" }} <span class="muted">{{ . }}</span>{{ end }}</button>
+{{ end }}<button type="submit" name="deny" value="1" class="muted">Deny access</button>
+</form>
+</body>
+</html>
+`))
+
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Authorization error</title></head>
+<body style="font-family: system-ui, sans-serif; max-width: 28rem; margin: 4rem auto;">
+<h1>Authorization error</h1>
+<p>{{ . }}</p>
+</body>
+</html>
+`))
+
+// handleAuthorize implements the authorization code flow. GET shows the
+// sign-in page, or signs in straight away when login_hint names a user;
+// POST is the sign-in page's form.
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.renderError(w, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	params := r.Form
+
+	// Until the client and redirect URI are known good, errors are shown
+	// rather than redirected
+	client := s.client(params.Get("client_id"))
+	if client == nil {
+		s.renderError(w, fmt.Sprintf("Unknown client %q.", params.Get("client_id")))
+		return
+	}
+	redirectURI := params.Get("redirect_uri")
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if redirectURI == "" {
+		s.renderError(w, "redirect_uri is required.")
+		return
+	}
+	if u, err := url.Parse(redirectURI); err != nil || !u.IsAbs() || !client.allowsRedirect(redirectURI) {
+		s.renderError(w, fmt.Sprintf("redirect_uri %q is not registered for client %s.", redirectURI, client.ID))
+		return
+	}
+
+	fail := func(code, description string) {
+		s.logf("Authorization request from %s rejected: %s: %s", client.ID, code, description)
+		s.redirect(w, r, redirectURI, url.Values{"error": {code}, "error_description": {description}, "state": {params.Get("state")}})
+	}
+	if params.Get("response_type") != "code" {
+		fail("unsupported_response_type", "only response_type=code is supported")
+		return
+	}
+	if !client.allows(GrantAuthorizationCode) {
+		fail("unauthorized_client", fmt.Sprintf("client %s may not use the authorization_code grant", client.ID))
+		return
+	}
+	scopes, err := client.grantScopes(params.Get("scope"))
+	if err != nil {
+		fail("invalid_scope", err.Error())
+		return
+	}
+	method := params.Get("code_challenge_method")
+	switch {
+	case params.Get("code_challenge") == "" && client.public():
+		fail("invalid_request", "public clients must use PKCE (code_challenge)")
+		return
+	case method == "":
+		method = "plain"
+	case method != "S256" && method != "plain":
+		fail("invalid_request", fmt.Sprintf("unsupported code_challenge_method %q", method))
+		return
+	}
+
+	var user *User
+	switch {
+	case r.Method == http.MethodPost && params.Get("deny") != "":
+		fail("access_denied", "the user denied access")
+		return
+	case r.Method == http.MethodPost:
+		if user = s.user(params.Get("username")); user == nil {
+			s.renderError(w, fmt.Sprintf("Unknown user %q.", params.Get("username")))
+			return
+		}
+	case params.Get("login_hint") != "":
+		if user = s.user(params.Get("login_hint")); user == nil {
+			fail("login_required", fmt.Sprintf("login_hint %q is not a configured user", params.Get("login_hint")))
+			return
+		}
+	case params.Get("prompt") == "none":
+		fail("login_required", "prompt=none needs a login_hint naming the user")
+		return
+	case len(s.config.Users) == 0:
+		s.renderError(w, "No users are configured; add some under users in the config.")
+		return
+	default:
+		s.renderSignIn(w, client, params)
+		return
+	}
+
+	code := randomToken()
+	now := s.now()
+	s.mu.Lock()
+	pruneExpired(s.codes, now)
+	s.codes[code] = &authorization{
+		client:              client,
+		user:                user,
+		scopes:              scopes,
+		nonce:               params.Get("nonce"),
+		authTime:            now,
+		expires:             now.Add(codeTTL),
+		redirectURI:         params.Get("redirect_uri"),
+		codeChallenge:       params.Get("code_challenge"),
+		codeChallengeMethod: method,
+	}
+	s.mu.Unlock()
+
+	s.logf("Signed in %s to %s", user.Username, client.ID)
+	s.redirect(w, r, redirectURI, url.Values{"code": {code}, "state": {params.Get("state")}})
+}
+
+// redirect sends the authorization response to the client's redirect URI
+func (s *Server) redirect(w http.ResponseWriter, r *http.Request, redirectURI string, values url.Values) {
+	if values.Get("state") == "" {
+		values.Del("state")
+	}
+	values.Set("iss", s.config.Issuer)
+
+	u, _ := url.Parse(redirectURI)
+	query := u.Query()
+	for name, value := range values {
+		query[name] = value
+	}
+	u.RawQuery = query.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+func (s *Server) renderSignIn(w http.ResponseWriter, client *Client, params url.Values) {
+	hidden := make(map[string]string)
+	for _, name := range authorizeParams {
+		if value := params.Get(name); value != "" {
+			hidden[name] = value
+		}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	signInPage.Execute(w, map[string]interface{}{
+		"Client": client.ID,
+		"Scope":  strings.TrimSpace(params.Get("scope")),
+		"Params": hidden,
+		"Users":  s.config.Users,
+	})
+}
+
+func (s *Server) renderError(w http.ResponseWriter, message string) {
+	s.logf("Authorization request rejected: %s", message)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	errorPage.Execute(w, message)
+}
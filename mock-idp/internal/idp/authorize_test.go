@@ -0,0 +1,142 @@
+package idp
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// authorize sends an authorization request and returns the response
+func authorize(s *Server, method string, params url.Values) *httptest.ResponseRecorder {
+	var req *http.Request
+	if method == http.MethodPost {
+		req = httptest.NewRequest(method, "/authorize", strings.NewReader(params.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, "/authorize?"+params.Encode(), nil)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+// redirectParams returns the query of the redirect a response sends
+func redirectParams(t *testing.T, rec *httptest.ResponseRecorder) url.Values {
+	t.Helper()
+	if rec.Code != http.StatusFound {
+		t.Fatalf("Expected a redirect, got %d %s", rec.Code, rec.Body.String())
+	}
+	u, _ := url.Parse(rec.Header().Get("Location"))
+	if got := u.Scheme + "://" + u.Host + u.Path; got != "http://localhost:3000/callback" {
+		t.Fatalf("Expected a redirect to the callback, got %s", got)
+	}
+	return u.Query()
+}
+
+func TestAuthorize_CodeFlowWithPKCE(t *testing.T) {
+	s := newTestServer(t)
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	sum := sha256.Sum256([]byte(verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"web"},
+		"scope":                 {"openid"},
+		"state":                 {"xyz"},
+		"nonce":                 {"n-0S6"},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	// Without a login hint the sign-in page lists the users
+	rec := authorize(s, http.MethodGet, params)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `name="username" value="alice"`) ||
+		!strings.Contains(rec.Body.String(), `name="state" value="xyz"`) {
+		t.Fatalf("Expected the sign-in page, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	params.Set("username", "alice")
+	query := redirectParams(t, authorize(s, http.MethodPost, params))
+	if query.Get("state") != "xyz" || query.Get("iss") != "http://localhost:9000" || query.Get("code") == "" {
+		t.Fatalf("Unexpected authorization response %v", query)
+	}
+	code := query.Get("code")
+
+	form := url.Values{"grant_type": {GrantAuthorizationCode}, "client_id": {"web"}, "code": {code}, "code_verifier": {"wrong"}}
+	if status, body := postForm(t, s, "/token", "", "", form); status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Errorf("Expected a wrong verifier to be rejected, got %d %v", status, body)
+	}
+
+	// A failed exchange used up the code
+	params.Del("username")
+	params.Set("login_hint", "alice")
+	code = redirectParams(t, authorize(s, http.MethodGet, params)).Get("code")
+	form.Set("code", code)
+	form.Set("code_verifier", verifier)
+	status, body := postForm(t, s, "/token", "", "", form)
+	if status != http.StatusOK || body["refresh_token"] == nil {
+		t.Fatalf("Expected tokens, got %d %v", status, body)
+	}
+	idClaims, err := s.signer.Verify(body["id_token"].(string))
+	if err != nil || idClaims["nonce"] != "n-0S6" || idClaims["sub"] != "alice" {
+		t.Errorf("Unexpected ID token %v, %v", idClaims, err)
+	}
+
+	if status, body := postForm(t, s, "/token", "", "", form); status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Errorf("Expected a code to be single use, got %d %v", status, body)
+	}
+}
+
+func TestAuthorize_Errors(t *testing.T) {
+	s := newTestServer(t)
+	base := func() url.Values {
+		return url.Values{"response_type": {"code"}, "client_id": {"backend"}, "redirect_uri": {"http://localhost:3000/callback"}, "state": {"s1"}}
+	}
+
+	// Problems with the client or redirect URI are shown, not redirected
+	for _, params := range []url.Values{
+		{"response_type": {"code"}, "client_id": {"nobody"}},
+		{"response_type": {"code"}, "client_id": {"backend"}},
+		{"response_type": {"code"}, "client_id": {"web"}, "redirect_uri": {"http://evil.example/callback"}},
+	} {
+		if rec := authorize(s, http.MethodGet, params); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Authorization error") {
+			t.Errorf("Expected an error page for %v, got %d", params, rec.Code)
+		}
+	}
+
+	tests := map[string]func(url.Values){
+		"unsupported_response_type": func(p url.Values) { p.Set("response_type", "token") },
+		"login_required":            func(p url.Values) { p.Set("prompt", "none") },
+		"access_denied":             func(p url.Values) { p.Set("deny", "1") },
+	}
+	for want, modify := range tests {
+		params := base()
+		modify(params)
+		method := http.MethodGet
+		if params.Get("deny") != "" {
+			method = http.MethodPost
+		}
+		query := redirectParams(t, authorize(s, method, params))
+		if query.Get("error") != want || query.Get("state") != "s1" {
+			t.Errorf("Expected error %s, got %v", want, query)
+		}
+	}
+
+	// Public clients must use PKCE
+	query := redirectParams(t, authorize(s, http.MethodGet, url.Values{"response_type": {"code"}, "client_id": {"web"}, "login_hint": {"alice"}}))
+	if query.Get("error") != "invalid_request" || !strings.Contains(query.Get("error_description"), "PKCE") {
+		t.Errorf("Expected PKCE to be required, got %v", query)
+	}
+
+	// Confidential clients may skip PKCE but must repeat the redirect URI
+	params := base()
+	params.Set("login_hint", "bob")
+	code := redirectParams(t, authorize(s, http.MethodGet, params)).Get("code")
+	status, body := postForm(t, s, "/token", "backend", "b4ckend", url.Values{"grant_type": {GrantAuthorizationCode}, "code": {code}})
+	if status != http.StatusBadRequest || !strings.Contains(body["error_description"].(string), "redirect_uri") {
+		t.Errorf("Expected a missing redirect_uri to be rejected, got %d %v", status, body)
+	}
+}
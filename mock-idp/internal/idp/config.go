@@ -0,0 +1,240 @@
+// Package idp is a minimal OAuth 2.0 and OpenID Connect identity provider
+// for local development: discovery, JWKS, authorize, token, userinfo and
+// introspection endpoints over configured clients and users.
+package idp
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Grant types accepted by the token endpoint
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+	GrantPassword          = "password"
+	GrantRefreshToken      = "refresh_token"
+)
+
+var allGrants = []string{GrantAuthorizationCode, GrantClientCredentials, GrantPassword, GrantRefreshToken}
+
+// Config is the identity provider's configuration file
+type Config struct {
+	// Issuer is the iss of issued tokens and the base URL of the endpoints;
+	// derived from the listen address when empty
+	Issuer string `yaml:"issuer,omitempty"`
+
+	// TokenTTL is the lifetime of access and ID tokens, 1h by default
+	TokenTTL string `yaml:"token_ttl,omitempty"`
+
+	// RefreshTokenTTL is the lifetime of refresh tokens, 24h by default
+	RefreshTokenTTL string `yaml:"refresh_token_ttl,omitempty"`
+
+	// Key is a PEM RSA private key file signing the tokens. It is generated,
+	// and written there, when missing; tokens are signed with a throwaway
+	// key when empty.
+	Key string `yaml:"key,omitempty"`
+
+	// Variables are returned by the var template function in claims
+	Variables map[string]interface{} `yaml:"variables,omitempty"`
+
+	Clients []Client `yaml:"clients"`
+	Users   []User   `yaml:"users,omitempty"`
+
+	tokenTTL   time.Duration
+	refreshTTL time.Duration
+}
+
+// Client is an application allowed to request tokens
+type Client struct {
+	ID string `yaml:"id"`
+
+	// Secret authenticates confidential clients. Public clients have none
+	// and must use PKCE with the authorization code grant.
+	Secret string `yaml:"secret,omitempty"`
+
+	// Grants lists the allowed grant types; all those the client can use
+	// when empty
+	Grants []string `yaml:"grants,omitempty"`
+
+	// RedirectURIs lists the allowed redirect_uri values; any URI is
+	// allowed when empty
+	RedirectURIs []string `yaml:"redirect_uris,omitempty"`
+
+	// Scopes lists the scopes the client may request, granted by default
+	// when it requests none; any scope is allowed when empty
+	Scopes []string `yaml:"scopes,omitempty"`
+
+	// Audience is the aud of access tokens, the client ID when empty
+	Audience string `yaml:"audience,omitempty"`
+
+	// Claims are added to access tokens issued to the client; string
+	// values may contain templates
+	Claims map[string]interface{} `yaml:"claims,omitempty"`
+}
+
+// User is an account that can sign in on the authorize page or with the
+// password grant
+type User struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password,omitempty"`
+
+	// Subject is the sub claim, the username when empty
+	Subject string `yaml:"sub,omitempty"`
+
+	// Claims are added to the user's access tokens, ID tokens and userinfo;
+	// string values may contain templates
+	Claims map[string]interface{} `yaml:"claims,omitempty"`
+}
+
+// LoadConfig reads and validates a YAML or JSON config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks the clients and users and parses the durations
+func (c *Config) Validate() error {
+	if c.Issuer != "" {
+		u, err := url.Parse(c.Issuer)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("issuer must be an http or https URL without query or fragment")
+		}
+		c.Issuer = strings.TrimSuffix(c.Issuer, "/")
+	}
+
+	var err error
+	if c.tokenTTL, err = parseTTL("token_ttl", c.TokenTTL, time.Hour); err != nil {
+		return err
+	}
+	if c.refreshTTL, err = parseTTL("refresh_token_ttl", c.RefreshTokenTTL, 24*time.Hour); err != nil {
+		return err
+	}
+
+	if len(c.Clients) == 0 {
+		return fmt.Errorf("at least one client must be defined")
+	}
+	seen := make(map[string]bool)
+	for i := range c.Clients {
+		client := &c.Clients[i]
+		if err := client.validate(); err != nil {
+			return fmt.Errorf("client %d (%s): %w", i, client.ID, err)
+		}
+		if seen[client.ID] {
+			return fmt.Errorf("client %d: duplicate id %q", i, client.ID)
+		}
+		seen[client.ID] = true
+	}
+
+	seen = make(map[string]bool)
+	for i := range c.Users {
+		user := &c.Users[i]
+		if user.Username == "" {
+			return fmt.Errorf("user %d: username is required", i)
+		}
+		if user.Subject == "" {
+			user.Subject = user.Username
+		}
+		if seen[user.Username] {
+			return fmt.Errorf("user %d: duplicate username %q", i, user.Username)
+		}
+		seen[user.Username] = true
+	}
+	return nil
+}
+
+func (c *Client) validate() error {
+	if c.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if len(c.Grants) == 0 {
+		for _, grant := range allGrants {
+			if grant != GrantClientCredentials || c.Secret != "" {
+				c.Grants = append(c.Grants, grant)
+			}
+		}
+	}
+	for _, grant := range c.Grants {
+		if !contains(allGrants, grant) {
+			return fmt.Errorf("unsupported grant %q (supported: %s)", grant, strings.Join(allGrants, ", "))
+		}
+		if grant == GrantClientCredentials && c.Secret == "" {
+			return fmt.Errorf("public clients cannot use the client_credentials grant")
+		}
+	}
+	for _, uri := range c.RedirectURIs {
+		if u, err := url.Parse(uri); err != nil || !u.IsAbs() || u.Fragment != "" {
+			return fmt.Errorf("redirect uri %q must be absolute without a fragment", uri)
+		}
+	}
+	if c.Audience == "" {
+		c.Audience = c.ID
+	}
+	return nil
+}
+
+// public reports whether the client has no secret
+func (c *Client) public() bool {
+	return c.Secret == ""
+}
+
+func (c *Client) allows(grant string) bool {
+	return contains(c.Grants, grant)
+}
+
+// allowsRedirect reports whether uri is a registered redirect URI
+func (c *Client) allowsRedirect(uri string) bool {
+	return len(c.RedirectURIs) == 0 || contains(c.RedirectURIs, uri)
+}
+
+// grantScopes returns the scopes to grant for a space-separated request,
+// the client's default scopes when it requests none. openid is always
+// allowed.
+func (c *Client) grantScopes(requested string) ([]string, error) {
+	scopes := strings.Fields(requested)
+	if len(scopes) == 0 {
+		return c.Scopes, nil
+	}
+	for _, scope := range scopes {
+		if len(c.Scopes) > 0 && scope != "openid" && !contains(c.Scopes, scope) {
+			return nil, fmt.Errorf("scope %q is not allowed for client %s", scope, c.ID)
+		}
+	}
+	return scopes, nil
+}
+
+func parseTTL(field, value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration (e.g. 1h)", field)
+	}
+	return d, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package idp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idp.yaml")
+	os.WriteFile(path, []byte(`
+issuer: http://localhost:9000/
+token_ttl: 15m
+clients:
+  - id: scheduler
+    secret: s3cret
+  - id: web
+    redirect_uris: [http://localhost:3000/callback]
+    scopes: [openid, profile]
+users:
+  - username: alice
+    claims:
+      roles: [admin]
+`), 0o644)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.Issuer != "http://localhost:9000" || config.tokenTTL != 15*time.Minute || config.refreshTTL != 24*time.Hour {
+		t.Errorf("Unexpected config %+v", config)
+	}
+
+	scheduler, web := config.Clients[0], config.Clients[1]
+	if !reflect.DeepEqual(scheduler.Grants, allGrants) || scheduler.Audience != "scheduler" {
+		t.Errorf("Expected a confidential client to allow every grant, got %+v", scheduler)
+	}
+	// Public clients cannot authenticate for client_credentials
+	if !reflect.DeepEqual(web.Grants, []string{GrantAuthorizationCode, GrantPassword, GrantRefreshToken}) {
+		t.Errorf("Unexpected public client grants %v", web.Grants)
+	}
+	if config.Users[0].Subject != "alice" {
+		t.Errorf("Expected the username as subject, got %q", config.Users[0].Subject)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]Config{
+		"at least one client":        {},
+		"issuer must be":             {Issuer: "localhost:9000", Clients: []Client{{ID: "a"}}},
+		"token_ttl must be":          {TokenTTL: "-1h", Clients: []Client{{ID: "a"}}},
+		"id is required":             {Clients: []Client{{Secret: "x"}}},
+		"duplicate id":               {Clients: []Client{{ID: "a"}, {ID: "a"}}},
+		"unsupported grant":          {Clients: []Client{{ID: "a", Grants: []string{"implicit"}}}},
+		"public clients cannot":      {Clients: []Client{{ID: "a", Grants: []string{GrantClientCredentials}}}},
+		"must be absolute":           {Clients: []Client{{ID: "a", RedirectURIs: []string{"/callback"}}}},
+		"username is required":       {Clients: []Client{{ID: "a"}}, Users: []User{{Password: "x"}}},
+		`duplicate username "alice"`: {Clients: []Client{{ID: "a"}}, Users: []User{{Username: "alice"}, {Username: "alice"}}},
+	}
+	for want, config := range tests {
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestClient_GrantScopes(t *testing.T) {
+	client := Client{ID: "web", Scopes: []string{"read", "write"}}
+	tests := map[string][]string{
+		"":             {"read", "write"},
+		"read":         {"read"},
+		"openid  read": {"openid", "read"},
+	}
+	for requested, want := range tests {
+		if got, err := client.grantScopes(requested); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("grantScopes(%q) = %v, %v; want %v", requested, got, err, want)
+		}
+	}
+	if _, err := client.grantScopes("admin"); err == nil {
+		t.Error("Expected an unlisted scope to be rejected")
+	}
+	if got, err := (&Client{ID: "any"}).grantScopes("anything at all"); err != nil || len(got) != 3 {
+		t.Errorf("Expected any scope without a scope list, got %v, %v", got, err)
+	}
+}
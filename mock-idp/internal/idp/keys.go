@@ -0,0 +1,167 @@
+package idp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// Signer signs and verifies RS256 tokens with one RSA key
+type Signer struct {
+	key *rsa.PrivateKey
+
+	// kid is the key's RFC 7638 thumbprint, stable across restarts when the
+	// key is kept in a file
+	kid string
+}
+
+// NewSigner wraps an RSA private key
+func NewSigner(key *rsa.PrivateKey) *Signer {
+	thumbprint := sha256.Sum256([]byte(fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`,
+		encodeInt(big.NewInt(int64(key.E))), encodeInt(key.N))))
+	return &Signer{key: key, kid: base64.RawURLEncoding.EncodeToString(thumbprint[:])}
+}
+
+// LoadSigner reads the PEM RSA private key at path, generating it and
+// writing it there when the file does not exist. An empty path generates a
+// key that is not kept.
+func LoadSigner(path string) (*Signer, bool, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			key, err := parsePrivateKey(data)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to parse key %s: %w", path, err)
+			}
+			return NewSigner(key), false, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, false, fmt.Errorf("failed to read key: %w", err)
+		}
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to generate key: %w", err)
+	}
+	if path != "" {
+		der, _ := x509.MarshalPKCS8PrivateKey(key)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+			return nil, false, fmt.Errorf("failed to write key: %w", err)
+		}
+	}
+	return NewSigner(key), true, nil
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("not PEM encoded")
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("only RSA keys are supported, got %T", key)
+	}
+	return rsaKey, nil
+}
+
+// KeyID returns the kid header of signed tokens
+func (s *Signer) KeyID() string {
+	return s.kid
+}
+
+// JWKS returns the JSON Web Key Set publishing the public key
+func (s *Signer) JWKS() map[string]interface{} {
+	return map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": s.kid,
+			"n":   encodeInt(s.key.N),
+			"e":   encodeInt(big.NewInt(int64(s.key.E))),
+		}},
+	}
+}
+
+// Sign returns an RS256 token carrying claims
+func (s *Signer) Sign(claims map[string]interface{}) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": s.kid})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode claims: %w", err)
+	}
+
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks a token this signer issued and returns its claims. Expiry
+// is left to the caller.
+func (s *Signer) Verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "RS256" || header.Kid != s.kid {
+		return nil, errors.New("token was not issued by this provider")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(&s.key.PublicKey, crypto.SHA256, digest[:], signature) != nil {
+		return nil, errors.New("invalid signature")
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errors.New("malformed claims")
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// encodeInt encodes a big-endian unsigned integer as base64url, as JWKs
+// require
+func encodeInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
@@ -0,0 +1,93 @@
+package idp
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var (
+	testSignerOnce sync.Once
+	testSigner     *Signer
+)
+
+// newTestSigner returns a signer shared by the tests, since generating RSA
+// keys is slow
+func newTestSigner(t *testing.T) *Signer {
+	t.Helper()
+	testSignerOnce.Do(func() {
+		signer, _, err := LoadSigner("")
+		if err != nil {
+			t.Fatalf("LoadSigner failed: %v", err)
+		}
+		testSigner = signer
+	})
+	return testSigner
+}
+
+func TestLoadSigner_GeneratesAndKeeps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key.pem")
+
+	first, generated, err := LoadSigner(path)
+	if err != nil || !generated {
+		t.Fatalf("Expected a key to be generated, got %v, %v", generated, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("Expected the key to be written privately, got %v, %v", info, err)
+	}
+
+	second, generated, err := LoadSigner(path)
+	if err != nil || generated {
+		t.Fatalf("Expected the key to be read back, got %v, %v", generated, err)
+	}
+	if first.KeyID() != second.KeyID() {
+		t.Errorf("Expected the same key ID across loads, got %s and %s", first.KeyID(), second.KeyID())
+	}
+
+	os.WriteFile(path, []byte("not a key"), 0o600)
+	if _, _, err := LoadSigner(path); err == nil || !strings.Contains(err.Error(), "not PEM encoded") {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+}
+
+func TestSigner_SignVerify(t *testing.T) {
+	signer := newTestSigner(t)
+	token, err := signer.Sign(map[string]interface{}{"sub": "alice"})
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	claims, err := signer.Verify(token)
+	if err != nil || claims["sub"] != "alice" {
+		t.Errorf("Expected the claims back, got %v, %v", claims, err)
+	}
+
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"mallory"}`)) + "." + parts[2]
+	if _, err := signer.Verify(tampered); err == nil || err.Error() != "invalid signature" {
+		t.Errorf("Expected a tampered token to fail, got %v", err)
+	}
+	if _, err := signer.Verify("a.b"); err == nil {
+		t.Error("Expected a malformed token to fail")
+	}
+}
+
+func TestSigner_JWKS(t *testing.T) {
+	signer := newTestSigner(t)
+	key := signer.JWKS()["keys"].([]map[string]string)[0]
+	if key["kid"] != signer.KeyID() || key["alg"] != "RS256" || key["e"] != "AQAB" {
+		t.Errorf("Unexpected JWK %v", key)
+	}
+
+	// The published key verifies the signer's tokens
+	n, _ := base64.RawURLEncoding.DecodeString(key["n"])
+	public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+	if !public.Equal(&signer.key.PublicKey) {
+		t.Error("Expected the JWK modulus to match the signing key")
+	}
+}
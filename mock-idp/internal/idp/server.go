@@ -0,0 +1,568 @@
+package idp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+// codeTTL is how long an authorization code can be exchanged
+const codeTTL = time.Minute
+
+// authorization is what an authorization code or refresh token stands for
+type authorization struct {
+	client   *Client
+	user     *User
+	scopes   []string
+	nonce    string
+	authTime time.Time
+	expires  time.Time
+
+	// Set for authorization codes only
+	redirectURI         string
+	codeChallenge       string
+	codeChallengeMethod string
+}
+
+// Server is the identity provider's HTTP handler
+type Server struct {
+	config *Config
+	signer *Signer
+	logf   func(format string, args ...interface{})
+	mux    *http.ServeMux
+
+	// now is the clock used for token times, replaced in tests
+	now func() time.Time
+
+	// mu guards the engine, which is not safe for concurrent use, and the
+	// outstanding codes and refresh tokens
+	mu            sync.Mutex
+	engine        *templating.Engine
+	codes         map[string]*authorization
+	refreshTokens map[string]*authorization
+}
+
+// NewServer creates a provider for a validated config whose Issuer is set.
+// logf receives one line per issued token or rejected request and may be
+// nil.
+func NewServer(config *Config, signer *Signer, logf func(format string, args ...interface{})) *Server {
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+	s := &Server{
+		config:        config,
+		signer:        signer,
+		logf:          logf,
+		mux:           http.NewServeMux(),
+		now:           time.Now,
+		engine:        templating.New(config.Variables, 0),
+		codes:         make(map[string]*authorization),
+		refreshTokens: make(map[string]*authorization),
+	}
+	s.mux.HandleFunc("/.well-known/openid-configuration", s.handleDiscovery)
+	s.mux.HandleFunc("/.well-known/jwks.json", s.handleJWKS)
+	s.mux.HandleFunc("/authorize", s.handleAuthorize)
+	s.mux.HandleFunc("/token", s.handleToken)
+	s.mux.HandleFunc("/userinfo", s.handleUserinfo)
+	s.mux.HandleFunc("/introspect", s.handleIntrospect)
+	return s
+}
+
+// ServeHTTP routes the request to the endpoint handlers
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	issuer := s.config.Issuer
+	scopes := []string{"openid", "profile", "email", "offline_access"}
+	for _, client := range s.config.Clients {
+		for _, scope := range client.Scopes {
+			if !contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                         issuer,
+		"authorization_endpoint":                         issuer + "/authorize",
+		"token_endpoint":                                 issuer + "/token",
+		"userinfo_endpoint":                              issuer + "/userinfo",
+		"introspection_endpoint":                         issuer + "/introspect",
+		"jwks_uri":                                       issuer + "/.well-known/jwks.json",
+		"response_types_supported":                       []string{"code"},
+		"response_modes_supported":                       []string{"query"},
+		"grant_types_supported":                          allGrants,
+		"subject_types_supported":                        []string{"public"},
+		"id_token_signing_alg_values_supported":          []string{"RS256"},
+		"token_endpoint_auth_methods_supported":          []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":               []string{"S256", "plain"},
+		"scopes_supported":                               scopes,
+		"claims_supported":                               []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "azp"},
+		"authorization_response_iss_parameter_supported": true,
+	})
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.signer.JWKS())
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeOAuthError(w, http.StatusMethodNotAllowed, "invalid_request", "the token endpoint only accepts POST")
+		return
+	}
+	client, status, err := s.authenticateClient(r)
+	if err != nil {
+		s.logf("Token request rejected: %v", err)
+		if status == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+		}
+		writeOAuthError(w, status, "invalid_client", err.Error())
+		return
+	}
+
+	grant := r.PostForm.Get("grant_type")
+	if !contains(allGrants, grant) {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", fmt.Sprintf("grant_type %q is not supported", grant))
+		return
+	}
+	if !client.allows(grant) {
+		writeOAuthError(w, http.StatusBadRequest, "unauthorized_client", fmt.Sprintf("client %s may not use the %s grant", client.ID, grant))
+		return
+	}
+
+	var auth *authorization
+	var code, description string
+	switch grant {
+	case GrantClientCredentials:
+		auth, code, description = s.clientCredentialsGrant(client, r.PostForm)
+	case GrantPassword:
+		auth, code, description = s.passwordGrant(client, r.PostForm)
+	case GrantAuthorizationCode:
+		auth, code, description = s.authorizationCodeGrant(client, r.PostForm)
+	case GrantRefreshToken:
+		auth, code, description = s.refreshTokenGrant(client, r.PostForm)
+	}
+	if auth == nil {
+		s.logf("%s request from %s rejected: %s: %s", grant, client.ID, code, description)
+		writeOAuthError(w, http.StatusBadRequest, code, description)
+		return
+	}
+
+	response, err := s.issueTokens(auth)
+	if err != nil {
+		s.logf("Failed to issue tokens to %s: %v", client.ID, err)
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	if auth.user != nil {
+		s.logf("Issued tokens to %s for %s (%s, scope %q)", client.ID, auth.user.Username, grant, strings.Join(auth.scopes, " "))
+	} else {
+		s.logf("Issued tokens to %s (%s, scope %q)", client.ID, grant, strings.Join(auth.scopes, " "))
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, response)
+}
+
+// authenticateClient identifies the client with HTTP Basic credentials or
+// client_id and client_secret form fields, and parses the form
+func (s *Server) authenticateClient(r *http.Request) (*Client, int, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid form: %v", err)
+	}
+
+	id, secret, basic := r.BasicAuth()
+	if basic {
+		// Basic credentials are form-encoded before being joined
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if id == "" {
+		return nil, http.StatusUnauthorized, fmt.Errorf("client authentication is required")
+	}
+
+	client := s.client(id)
+	switch {
+	case client == nil:
+		return nil, http.StatusUnauthorized, fmt.Errorf("unknown client %q", id)
+	case client.public() && secret != "":
+		return nil, http.StatusUnauthorized, fmt.Errorf("client %s is public and has no secret", id)
+	case subtle.ConstantTimeCompare([]byte(secret), []byte(client.Secret)) != 1:
+		return nil, http.StatusUnauthorized, fmt.Errorf("invalid secret for client %s", id)
+	}
+	return client, http.StatusOK, nil
+}
+
+func (s *Server) clientCredentialsGrant(client *Client, form url.Values) (*authorization, string, string) {
+	scopes, err := client.grantScopes(form.Get("scope"))
+	if err != nil {
+		return nil, "invalid_scope", err.Error()
+	}
+	return &authorization{client: client, scopes: scopes, authTime: s.now()}, "", ""
+}
+
+func (s *Server) passwordGrant(client *Client, form url.Values) (*authorization, string, string) {
+	user := s.user(form.Get("username"))
+	if user == nil || subtle.ConstantTimeCompare([]byte(form.Get("password")), []byte(user.Password)) != 1 {
+		return nil, "invalid_grant", "invalid username or password"
+	}
+	scopes, err := client.grantScopes(form.Get("scope"))
+	if err != nil {
+		return nil, "invalid_scope", err.Error()
+	}
+	return &authorization{client: client, user: user, scopes: scopes, authTime: s.now()}, "", ""
+}
+
+func (s *Server) authorizationCodeGrant(client *Client, form url.Values) (*authorization, string, string) {
+	s.mu.Lock()
+	auth := s.codes[form.Get("code")]
+	// Codes are single use, whether or not the exchange succeeds
+	delete(s.codes, form.Get("code"))
+	s.mu.Unlock()
+
+	switch {
+	case auth == nil || s.now().After(auth.expires):
+		return nil, "invalid_grant", "authorization code is invalid, expired or already used"
+	case auth.client != client:
+		return nil, "invalid_grant", "authorization code was issued to another client"
+	case auth.redirectURI != "" && form.Get("redirect_uri") != auth.redirectURI:
+		return nil, "invalid_grant", "redirect_uri does not match the authorization request"
+	}
+
+	if auth.codeChallenge != "" {
+		verifier := form.Get("code_verifier")
+		if verifier == "" {
+			return nil, "invalid_request", "code_verifier is required"
+		}
+		if auth.codeChallengeMethod == "S256" {
+			sum := sha256.Sum256([]byte(verifier))
+			verifier = base64.RawURLEncoding.EncodeToString(sum[:])
+		}
+		if subtle.ConstantTimeCompare([]byte(verifier), []byte(auth.codeChallenge)) != 1 {
+			return nil, "invalid_grant", "code_verifier does not match the code_challenge"
+		}
+	}
+	return auth, "", ""
+}
+
+func (s *Server) refreshTokenGrant(client *Client, form url.Values) (*authorization, string, string) {
+	s.mu.Lock()
+	auth := s.refreshTokens[form.Get("refresh_token")]
+	s.mu.Unlock()
+
+	switch {
+	case auth == nil || s.now().After(auth.expires):
+		return nil, "invalid_grant", "refresh token is invalid or expired"
+	case auth.client != client:
+		return nil, "invalid_grant", "refresh token was issued to another client"
+	}
+
+	// A refresh may narrow the original scopes but not widen them
+	scopes := auth.scopes
+	if requested := strings.Fields(form.Get("scope")); len(requested) > 0 {
+		for _, scope := range requested {
+			if !contains(auth.scopes, scope) {
+				return nil, "invalid_scope", fmt.Sprintf("scope %q was not originally granted", scope)
+			}
+		}
+		scopes = requested
+	}
+	refreshed := *auth
+	refreshed.scopes = scopes
+	refreshed.nonce = ""
+	return &refreshed, "", ""
+}
+
+// issueTokens creates the token response for an authorization: an access
+// token, an ID token when a user granted the openid scope, and a refresh
+// token when the client may use one
+func (s *Server) issueTokens(auth *authorization) (map[string]interface{}, error) {
+	now := s.now()
+	expires := now.Add(s.config.tokenTTL)
+	subject := auth.client.ID
+	if auth.user != nil {
+		subject = auth.user.Subject
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Configured claims come first so the registered ones cannot be
+	// overridden by accident
+	claims := make(map[string]interface{})
+	if auth.user != nil {
+		if err := s.resolveClaims(claims, auth.user.Claims); err != nil {
+			return nil, fmt.Errorf("user %s claims: %w", auth.user.Username, err)
+		}
+	}
+	if err := s.resolveClaims(claims, auth.client.Claims); err != nil {
+		return nil, fmt.Errorf("client %s claims: %w", auth.client.ID, err)
+	}
+	for name, value := range map[string]interface{}{
+		"iss":       s.config.Issuer,
+		"sub":       subject,
+		"aud":       auth.client.Audience,
+		"exp":       expires.Unix(),
+		"iat":       now.Unix(),
+		"jti":       randomToken(),
+		"client_id": auth.client.ID,
+	} {
+		claims[name] = value
+	}
+	if len(auth.scopes) > 0 {
+		claims["scope"] = strings.Join(auth.scopes, " ")
+	}
+	accessToken, err := s.signer.Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	response := map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int64(s.config.tokenTTL / time.Second),
+	}
+	if len(auth.scopes) > 0 {
+		response["scope"] = strings.Join(auth.scopes, " ")
+	}
+
+	if auth.user != nil && contains(auth.scopes, "openid") {
+		idClaims := make(map[string]interface{})
+		if err := s.resolveClaims(idClaims, auth.user.Claims); err != nil {
+			return nil, fmt.Errorf("user %s claims: %w", auth.user.Username, err)
+		}
+		for name, value := range map[string]interface{}{
+			"iss":       s.config.Issuer,
+			"sub":       subject,
+			"aud":       auth.client.ID,
+			"azp":       auth.client.ID,
+			"exp":       expires.Unix(),
+			"iat":       now.Unix(),
+			"auth_time": auth.authTime.Unix(),
+		} {
+			idClaims[name] = value
+		}
+		if auth.nonce != "" {
+			idClaims["nonce"] = auth.nonce
+		}
+		if response["id_token"], err = s.signer.Sign(idClaims); err != nil {
+			return nil, err
+		}
+	}
+
+	if auth.user != nil && auth.client.allows(GrantRefreshToken) {
+		refresh := *auth
+		refresh.expires = now.Add(s.config.refreshTTL)
+		refresh.redirectURI, refresh.codeChallenge, refresh.codeChallengeMethod = "", "", ""
+		token := randomToken()
+		pruneExpired(s.refreshTokens, now)
+		s.refreshTokens[token] = &refresh
+		response["refresh_token"] = token
+	}
+	return response, nil
+}
+
+// resolveClaims evaluates the templates in source into claims. The caller
+// holds s.mu.
+func (s *Server) resolveClaims(claims, source map[string]interface{}) error {
+	for name, value := range source {
+		resolved, err := s.resolveValue(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		claims[name] = resolved
+	}
+	return nil
+}
+
+func (s *Server) resolveValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if templating.IsTemplate(v) {
+			return s.engine.EvaluateTemplate(v)
+		}
+		return v, nil
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			item, err := s.resolveValue(item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = item
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			item, err := s.resolveValue(item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = item
+		}
+		return resolved, nil
+	}
+	return value, nil
+}
+
+// verifyAccessToken returns the claims of an unexpired access token this
+// provider issued
+func (s *Server) verifyAccessToken(token string) (map[string]interface{}, error) {
+	claims, err := s.signer.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims["iss"] != s.config.Issuer {
+		return nil, fmt.Errorf("token was issued by %v", claims["iss"])
+	}
+	// ID tokens are signed with the same key but carry no client_id
+	if _, ok := claims["client_id"]; !ok {
+		return nil, fmt.Errorf("not an access token")
+	}
+	exp, ok := claims["exp"].(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("token has no exp")
+	}
+	if seconds, _ := exp.Int64(); !s.now().Before(time.Unix(seconds, 0)) {
+		return nil, fmt.Errorf("token expired")
+	}
+	return claims, nil
+}
+
+func (s *Server) handleUserinfo(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="userinfo"`)
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_request", "a Bearer access token is required")
+		return
+	}
+	claims, err := s.verifyAccessToken(token)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="userinfo", error="invalid_token", error_description=%q`, err.Error()))
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_token", err.Error())
+		return
+	}
+
+	info := map[string]interface{}{}
+	// Tokens from the client_credentials grant have the client as subject
+	if user := s.userBySubject(claims["sub"]); user != nil && claims["sub"] != claims["client_id"] {
+		s.mu.Lock()
+		err = s.resolveClaims(info, user.Claims)
+		s.mu.Unlock()
+		if err != nil {
+			writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+			return
+		}
+	}
+	info["sub"] = claims["sub"]
+	writeJSON(w, http.StatusOK, info)
+}
+
+// handleIntrospect implements RFC 7662 token introspection for access and
+// refresh tokens
+func (s *Server) handleIntrospect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeOAuthError(w, http.StatusMethodNotAllowed, "invalid_request", "the introspection endpoint only accepts POST")
+		return
+	}
+	if _, status, err := s.authenticateClient(r); err != nil {
+		if status == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", `Basic realm="introspect"`)
+		}
+		writeOAuthError(w, status, "invalid_client", err.Error())
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if claims, err := s.verifyAccessToken(token); err == nil {
+		claims["active"] = true
+		claims["token_type"] = "Bearer"
+		writeJSON(w, http.StatusOK, claims)
+		return
+	}
+
+	s.mu.Lock()
+	auth := s.refreshTokens[token]
+	s.mu.Unlock()
+	if auth == nil || s.now().After(auth.expires) {
+		writeJSON(w, http.StatusOK, map[string]bool{"active": false})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"active":     true,
+		"token_type": "refresh_token",
+		"client_id":  auth.client.ID,
+		"sub":        auth.user.Subject,
+		"scope":      strings.Join(auth.scopes, " "),
+		"exp":        auth.expires.Unix(),
+		"iss":        s.config.Issuer,
+	})
+}
+
+func (s *Server) client(id string) *Client {
+	for i := range s.config.Clients {
+		if s.config.Clients[i].ID == id {
+			return &s.config.Clients[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) user(username string) *User {
+	for i := range s.config.Users {
+		if s.config.Users[i].Username == username {
+			return &s.config.Users[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) userBySubject(subject interface{}) *User {
+	for i := range s.config.Users {
+		if s.config.Users[i].Subject == subject {
+			return &s.config.Users[i]
+		}
+	}
+	return nil
+}
+
+// pruneExpired drops expired codes or refresh tokens
+func pruneExpired(auths map[string]*authorization, now time.Time) {
+	for token, auth := range auths {
+		if now.After(auth.expires) {
+			delete(auths, token)
+		}
+	}
+}
+
+// randomToken returns an unguessable opaque token
+func randomToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeOAuthError writes an RFC 6749 error response
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
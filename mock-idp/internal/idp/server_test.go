@@ -0,0 +1,194 @@
+package idp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	config := &Config{
+		Issuer: "http://localhost:9000",
+		Clients: []Client{
+			{ID: "scheduler", Secret: "s3cret", Grants: []string{GrantClientCredentials}, Scopes: []string{"read", "write"}, Audience: "orders-api",
+				Claims: map[string]interface{}{"roles": []interface{}{"service"}}},
+			{ID: "backend", Secret: "b4ckend"},
+			{ID: "web", RedirectURIs: []string{"http://localhost:3000/callback"}},
+		},
+		Users: []User{
+			{Username: "alice", Password: "alice", Claims: map[string]interface{}{"email": "alice@example.com", "session": "{{ var \"session\" }}"}},
+			{Username: "bob", Password: "bob", Subject: "user-2"},
+		},
+		Variables: map[string]interface{}{"session": "abc"},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Invalid config: %v", err)
+	}
+	return NewServer(config, newTestSigner(t), nil)
+}
+
+// postForm sends a form to the server, authenticating with basic
+// credentials when user is set, and decodes the JSON response
+func postForm(t *testing.T, s *Server, path, user, password string, form url.Values) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected JSON from %s, got %q", path, rec.Body.String())
+	}
+	return rec.Code, body
+}
+
+func TestServer_ClientCredentials(t *testing.T) {
+	s := newTestServer(t)
+	status, body := postForm(t, s, "/token", "scheduler", "s3cret", url.Values{"grant_type": {GrantClientCredentials}, "scope": {"read"}})
+	if status != http.StatusOK || body["token_type"] != "Bearer" || body["expires_in"] != 3600.0 || body["scope"] != "read" {
+		t.Fatalf("Unexpected response %d %v", status, body)
+	}
+	if body["refresh_token"] != nil || body["id_token"] != nil {
+		t.Errorf("Expected only an access token, got %v", body)
+	}
+
+	claims, err := s.signer.Verify(body["access_token"].(string))
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	for name, want := range map[string]interface{}{
+		"iss": "http://localhost:9000", "sub": "scheduler", "aud": "orders-api", "client_id": "scheduler", "scope": "read",
+	} {
+		if claims[name] != want {
+			t.Errorf("Expected %s %v, got %v", name, want, claims[name])
+		}
+	}
+	if roles, _ := claims["roles"].([]interface{}); len(roles) != 1 || roles[0] != "service" {
+		t.Errorf("Expected the client's claims, got %v", claims["roles"])
+	}
+}
+
+func TestServer_TokenErrors(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		name, user, password string
+		form                 url.Values
+		status               int
+		code                 string
+	}{
+		{"wrong secret", "scheduler", "nope", url.Values{"grant_type": {GrantClientCredentials}}, 401, "invalid_client"},
+		{"no client", "", "", url.Values{"grant_type": {GrantClientCredentials}}, 401, "invalid_client"},
+		{"public with secret", "web", "x", url.Values{"grant_type": {GrantPassword}}, 401, "invalid_client"},
+		{"unknown grant", "backend", "b4ckend", url.Values{"grant_type": {"implicit"}}, 400, "unsupported_grant_type"},
+		{"grant not allowed", "scheduler", "s3cret", url.Values{"grant_type": {GrantPassword}}, 400, "unauthorized_client"},
+		{"scope not allowed", "scheduler", "s3cret", url.Values{"grant_type": {GrantClientCredentials}, "scope": {"admin"}}, 400, "invalid_scope"},
+		{"wrong password", "backend", "b4ckend", url.Values{"grant_type": {GrantPassword}, "username": {"alice"}, "password": {"bob"}}, 400, "invalid_grant"},
+		{"unknown refresh token", "backend", "b4ckend", url.Values{"grant_type": {GrantRefreshToken}, "refresh_token": {"x"}}, 400, "invalid_grant"},
+	}
+	for _, tt := range tests {
+		status, body := postForm(t, s, "/token", tt.user, tt.password, tt.form)
+		if status != tt.status || body["error"] != tt.code {
+			t.Errorf("%s: expected %d %s, got %d %v", tt.name, tt.status, tt.code, status, body)
+		}
+	}
+
+	// Credentials may also be sent in the form
+	status, _ := postForm(t, s, "/token", "", "", url.Values{"grant_type": {GrantClientCredentials}, "client_id": {"scheduler"}, "client_secret": {"s3cret"}})
+	if status != http.StatusOK {
+		t.Errorf("Expected client_secret_post to be accepted, got %d", status)
+	}
+}
+
+func TestServer_PasswordAndRefresh(t *testing.T) {
+	s := newTestServer(t)
+	status, body := postForm(t, s, "/token", "backend", "b4ckend", url.Values{
+		"grant_type": {GrantPassword}, "username": {"alice"}, "password": {"alice"}, "scope": {"openid orders"},
+	})
+	if status != http.StatusOK || body["refresh_token"] == nil {
+		t.Fatalf("Unexpected response %d %v", status, body)
+	}
+
+	idClaims, err := s.signer.Verify(body["id_token"].(string))
+	if err != nil || idClaims["sub"] != "alice" || idClaims["aud"] != "backend" || idClaims["email"] != "alice@example.com" || idClaims["session"] != "abc" {
+		t.Errorf("Unexpected ID token %v, %v", idClaims, err)
+	}
+	if _, err := s.verifyAccessToken(body["id_token"].(string)); err == nil {
+		t.Error("Expected an ID token not to be accepted as an access token")
+	}
+
+	// Refreshing may narrow the scopes but not widen them
+	refresh := body["refresh_token"].(string)
+	status, body = postForm(t, s, "/token", "backend", "b4ckend", url.Values{"grant_type": {GrantRefreshToken}, "refresh_token": {refresh}, "scope": {"orders"}})
+	if status != http.StatusOK || body["scope"] != "orders" || body["id_token"] != nil {
+		t.Errorf("Unexpected refresh response %d %v", status, body)
+	}
+	status, body = postForm(t, s, "/token", "backend", "b4ckend", url.Values{"grant_type": {GrantRefreshToken}, "refresh_token": {refresh}, "scope": {"admin"}})
+	if status != http.StatusBadRequest || body["error"] != "invalid_scope" {
+		t.Errorf("Expected a wider scope to be rejected, got %d %v", status, body)
+	}
+
+	s.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	status, body = postForm(t, s, "/token", "backend", "b4ckend", url.Values{"grant_type": {GrantRefreshToken}, "refresh_token": {refresh}})
+	if status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Errorf("Expected an expired refresh token to be rejected, got %d %v", status, body)
+	}
+}
+
+func TestServer_UserinfoAndIntrospect(t *testing.T) {
+	s := newTestServer(t)
+	_, body := postForm(t, s, "/token", "backend", "b4ckend", url.Values{"grant_type": {GrantPassword}, "username": {"alice"}, "password": {"alice"}})
+	token := body["access_token"].(string)
+
+	req := httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"email":"alice@example.com"`) || !strings.Contains(rec.Body.String(), `"sub":"alice"`) {
+		t.Errorf("Unexpected userinfo %d %s", rec.Code, rec.Body.String())
+	}
+
+	status, body := postForm(t, s, "/introspect", "scheduler", "s3cret", url.Values{"token": {token}})
+	if status != http.StatusOK || body["active"] != true || body["sub"] != "alice" || body["client_id"] != "backend" {
+		t.Errorf("Expected an active token, got %d %v", status, body)
+	}
+	status, body = postForm(t, s, "/introspect", "scheduler", "s3cret", url.Values{"token": {body["jti"].(string)}})
+	if status != http.StatusOK || body["active"] != false {
+		t.Errorf("Expected an unknown token to be inactive, got %d %v", status, body)
+	}
+	if status, _ := postForm(t, s, "/introspect", "", "", url.Values{"token": {token}}); status != http.StatusUnauthorized {
+		t.Errorf("Expected introspection to require client authentication, got %d", status)
+	}
+
+	// Expired tokens are rejected
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Header().Get("WWW-Authenticate"), `error="invalid_token"`) {
+		t.Errorf("Expected an expired token to be rejected, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestServer_Discovery(t *testing.T) {
+	s := newTestServer(t)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil))
+
+	var doc map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &doc)
+	if doc["issuer"] != "http://localhost:9000" || doc["token_endpoint"] != "http://localhost:9000/token" ||
+		doc["jwks_uri"] != "http://localhost:9000/.well-known/jwks.json" {
+		t.Errorf("Unexpected discovery document %v", doc)
+	}
+	if scopes := doc["scopes_supported"].([]interface{}); len(scopes) != 6 {
+		t.Errorf("Expected the clients' scopes to be listed, got %v", scopes)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"local-dev-tools/mock-idp/internal/idp"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run serves the identity provider until interrupted and returns the
+// process exit code
+func run() int {
+	configPath := flag.String("config", "idp.yaml", "Path to the clients and users file (YAML or JSON)")
+	addr := flag.String("addr", ":9000", "Address to listen on")
+	issuer := flag.String("issuer", "", "Issuer URL, overriding the config (default http://localhost:<port>)")
+	quiet := flag.Bool("quiet", false, "Do not log issued tokens and rejected requests")
+	flag.Parse()
+
+	config, err := idp.LoadConfig(*configPath)
+	if err != nil {
+		log.Printf("Error loading config: %v", err)
+		return 2
+	}
+	if *issuer != "" {
+		config.Issuer = *issuer
+		if err := config.Validate(); err != nil {
+			log.Printf("Error: %v", err)
+			return 2
+		}
+	}
+	if config.Issuer == "" {
+		_, port, err := net.SplitHostPort(*addr)
+		if err != nil {
+			log.Printf("Invalid --addr: %v", err)
+			return 2
+		}
+		config.Issuer = "http://localhost:" + port
+	}
+
+	signer, generated, err := idp.LoadSigner(config.Key)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 2
+	}
+	switch {
+	case config.Key == "":
+		fmt.Println("Signing with a throwaway key; tokens will not verify after a restart (set key to keep one)")
+	case generated:
+		fmt.Printf("Generated signing key %s\n", config.Key)
+	}
+
+	logf := log.Printf
+	if *quiet {
+		logf = nil
+	}
+	server := &http.Server{
+		Addr:    *addr,
+		Handler: idp.NewServer(config, signer, logf),
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		fmt.Println("\nShutting down...")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	fmt.Printf("Issuer %s with %d clients and %d users on %s\n", config.Issuer, len(config.Clients), len(config.Users), *addr)
+	fmt.Printf("Discovery: %s/.well-known/openid-configuration\n", config.Issuer)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Server error: %v", err)
+		return 3
+	}
+	return 0
+}
@@ -0,0 +1,153 @@
+// Package mock serves HTTP routes defined in a config file, with templated
+// responses, injected latency and random failures.
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the mock server's configuration file
+type Config struct {
+	// Variables are returned by the var template function
+	Variables map[string]interface{} `yaml:"variables,omitempty"`
+	Routes    []Route                `yaml:"routes"`
+}
+
+// Route describes the requests a route matches and the response it serves
+type Route struct {
+	Name string `yaml:"name,omitempty"`
+
+	// Method matches any method when empty
+	Method string `yaml:"method,omitempty"`
+
+	// Path matches literal segments, {name} matches a single segment and a
+	// trailing * matches the rest of the path
+	Path string `yaml:"path"`
+
+	// Status defaults to 200
+	Status  int               `yaml:"status,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Body is a template string, or structured data whose strings are
+	// templates and which is served as JSON
+	Body interface{} `yaml:"body,omitempty"`
+
+	// Latency delays the response (e.g. "150ms"), varied by up to ±Jitter
+	Latency string `yaml:"latency,omitempty"`
+	Jitter  string `yaml:"jitter,omitempty"`
+
+	Failure *Failure `yaml:"failure,omitempty"`
+
+	latency time.Duration
+	jitter  time.Duration
+}
+
+// Failure replaces a fraction of a route's responses with an error
+type Failure struct {
+	// Rate is the fraction of requests that fail, between 0 and 1
+	Rate float64 `yaml:"rate"`
+
+	// Status defaults to 500
+	Status int `yaml:"status,omitempty"`
+
+	// Body defaults to the status text
+	Body interface{} `yaml:"body,omitempty"`
+}
+
+// LoadConfig reads and validates a YAML or JSON config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks every route and parses their durations
+func (c *Config) Validate() error {
+	if len(c.Routes) == 0 {
+		return fmt.Errorf("at least one route must be defined")
+	}
+	for i := range c.Routes {
+		route := &c.Routes[i]
+		if err := route.validate(); err != nil {
+			return fmt.Errorf("route %d (%s): %w", i, route.label(), err)
+		}
+	}
+	return nil
+}
+
+func (r *Route) validate() error {
+	if !strings.HasPrefix(r.Path, "/") {
+		return fmt.Errorf("path must start with /")
+	}
+	if i := strings.Index(r.Path, "*"); i >= 0 && i != len(r.Path)-1 {
+		return fmt.Errorf("* is only allowed at the end of the path")
+	}
+
+	r.Method = strings.ToUpper(r.Method)
+	if r.Status == 0 {
+		r.Status = http.StatusOK
+	}
+	if r.Status < 100 || r.Status > 599 {
+		return fmt.Errorf("invalid status %d", r.Status)
+	}
+
+	var err error
+	if r.latency, err = parseDuration("latency", r.Latency); err != nil {
+		return err
+	}
+	if r.jitter, err = parseDuration("jitter", r.Jitter); err != nil {
+		return err
+	}
+
+	if f := r.Failure; f != nil {
+		if f.Rate < 0 || f.Rate > 1 {
+			return fmt.Errorf("failure rate must be between 0 and 1")
+		}
+		if f.Status == 0 {
+			f.Status = http.StatusInternalServerError
+		}
+		if f.Status < 100 || f.Status > 599 {
+			return fmt.Errorf("invalid failure status %d", f.Status)
+		}
+	}
+	return nil
+}
+
+// label names the route in logs and errors
+func (r *Route) label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	method := r.Method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + r.Path
+}
+
+func parseDuration(field, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", field, value)
+	}
+	return d, nil
+}
@@ -0,0 +1,74 @@
+package mock
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "mock.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `
+variables:
+  region: eu
+routes:
+  - name: "Get user"
+    method: get
+    path: /users/{id}
+    latency: 150ms
+    jitter: 50ms
+    failure:
+      rate: 0.25
+  - path: /health
+    status: 204
+`)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	user, health := config.Routes[0], config.Routes[1]
+	if user.Method != "GET" || user.Status != 200 || user.latency != 150*time.Millisecond || user.jitter != 50*time.Millisecond {
+		t.Errorf("Unexpected route defaults: %+v", user)
+	}
+	if user.Failure.Status != 500 || user.Failure.Rate != 0.25 {
+		t.Errorf("Unexpected failure defaults: %+v", user.Failure)
+	}
+	if health.Status != 204 || health.label() != "* /health" {
+		t.Errorf("Unexpected route: %+v", health)
+	}
+	if config.Variables["region"] != "eu" {
+		t.Errorf("Expected variables, got %v", config.Variables)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"at least one route":       `routes: []`,
+		"path must start with /":   `routes: [{path: "users"}]`,
+		"* is only allowed":        `routes: [{path: "/files/*/meta"}]`,
+		"invalid status 700":       `routes: [{path: "/", status: 700}]`,
+		`invalid latency "soon"`:   `routes: [{path: "/", latency: soon}]`,
+		"failure rate must be":     `routes: [{path: "/", failure: {rate: 2}}]`,
+		"route 0 (Broken)":         `routes: [{name: Broken, path: "/", jitter: "-1s"}]`,
+		"failed to parse config":   `routes: [`,
+		"invalid failure status 1": `routes: [{path: "/", failure: {rate: 1, status: 1}}]`,
+	}
+
+	for want, content := range tests {
+		if _, err := LoadConfig(writeConfig(t, content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+// maxRequestBody bounds the request bodies made available to templates
+const maxRequestBody = 10 << 20
+
+// RequestData is the data templates are evaluated against
+type RequestData struct {
+	Method  string
+	Path    string
+	Params  map[string]string
+	Query   map[string]string
+	Headers map[string]string
+	Body    string
+
+	// JSON is the request body decoded as JSON, or nil
+	JSON interface{}
+}
+
+// Server serves the routes of a config
+type Server struct {
+	routes []Route
+	logf   func(format string, args ...interface{})
+
+	// mu guards the engine and random source, which are not safe for
+	// concurrent use
+	mu     sync.Mutex
+	engine *templating.Engine
+	rand   *rand.Rand
+}
+
+// NewServer creates a server for a validated config. seed makes failures,
+// jitter and the random template functions reproducible; logf receives one
+// line per request and may be nil.
+func NewServer(config *Config, seed int64, logf func(format string, args ...interface{})) *Server {
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+	return &Server{
+		routes: config.Routes,
+		logf:   logf,
+		engine: templating.New(config.Variables, seed),
+		rand:   rand.New(rand.NewSource(seed)),
+	}
+}
+
+// ServeHTTP serves the first route matching the request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	route, params := s.match(r)
+	if route == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{
+			"error": fmt.Sprintf("no route matches %s %s", r.Method, r.URL.Path),
+		})
+		s.logf("%s %s -> %d (no matching route)", r.Method, r.URL.Path, http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	data := newRequestData(r, params, body)
+
+	s.mu.Lock()
+	delay := route.latency
+	if route.jitter > 0 {
+		delay += time.Duration(s.rand.Int63n(int64(2*route.jitter)+1)) - route.jitter
+	}
+	failed := route.Failure != nil && s.rand.Float64() < route.Failure.Rate
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	status, responseBody := route.Status, route.Body
+	if failed {
+		status, responseBody = route.Failure.Status, route.Failure.Body
+		if responseBody == nil {
+			responseBody = http.StatusText(status)
+		}
+	}
+
+	if err := s.respond(w, route, status, responseBody, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logf("%s %s -> %d (%s: %v)", r.Method, r.URL.Path, http.StatusInternalServerError, route.label(), err)
+		return
+	}
+
+	note := ""
+	if failed {
+		note = ", injected failure"
+	}
+	s.logf("%s %s -> %d (%s, %v%s)", r.Method, r.URL.Path, status, route.label(), time.Since(start).Round(time.Millisecond), note)
+}
+
+// match returns the first route matching the request and its path parameters
+func (s *Server) match(r *http.Request) (*Route, map[string]string) {
+	for i := range s.routes {
+		route := &s.routes[i]
+		if route.Method != "" && route.Method != r.Method {
+			continue
+		}
+		if params, ok := matchPath(route.Path, r.URL.Path); ok {
+			return route, params
+		}
+	}
+	return nil, nil
+}
+
+// matchPath matches a request path against a route pattern
+func matchPath(pattern, path string) (map[string]string, bool) {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	params := make(map[string]string)
+
+	for i, part := range patternParts {
+		if part == "*" && i == len(patternParts)-1 {
+			params["*"] = strings.Join(pathParts[min(i, len(pathParts)):], "/")
+			return params, true
+		}
+		if i >= len(pathParts) {
+			return nil, false
+		}
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if pathParts[i] == "" {
+				return nil, false
+			}
+			params[part[1:len(part)-1]] = pathParts[i]
+			continue
+		}
+		if part != pathParts[i] {
+			return nil, false
+		}
+	}
+	return params, len(patternParts) == len(pathParts)
+}
+
+// respond renders the headers and body of a response and writes it
+func (s *Server) respond(w http.ResponseWriter, route *Route, status int, body interface{}, data *RequestData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, value := range route.Headers {
+		rendered, err := s.render(value, data)
+		if err != nil {
+			return fmt.Errorf("header %s: %w", key, err)
+		}
+		w.Header().Set(key, rendered)
+	}
+
+	var payload []byte
+	switch value := body.(type) {
+	case nil:
+	case string:
+		rendered, err := s.render(value, data)
+		if err != nil {
+			return fmt.Errorf("body: %w", err)
+		}
+		payload = []byte(rendered)
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+	default:
+		resolved, err := s.resolve(value, data)
+		if err != nil {
+			return fmt.Errorf("body: %w", err)
+		}
+		if payload, err = json.Marshal(resolved); err != nil {
+			return fmt.Errorf("body: %w", err)
+		}
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+	}
+
+	w.WriteHeader(status)
+	w.Write(payload)
+	return nil
+}
+
+// render evaluates a template string against the request
+func (s *Server) render(value string, data *RequestData) (string, error) {
+	if !templating.IsTemplate(value) {
+		return value, nil
+	}
+	return s.engine.EvaluateTemplateWithData(value, data)
+}
+
+// resolve renders every string in structured data
+func (s *Server) resolve(value interface{}, data *RequestData) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return s.render(v, data)
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			renderedKey, err := s.render(key, data)
+			if err != nil {
+				return nil, err
+			}
+			if resolved[renderedKey], err = s.resolve(item, data); err != nil {
+				return nil, err
+			}
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			var err error
+			if resolved[i], err = s.resolve(item, data); err != nil {
+				return nil, err
+			}
+		}
+		return resolved, nil
+	default:
+		return v, nil
+	}
+}
+
+func newRequestData(r *http.Request, params map[string]string, body []byte) *RequestData {
+	data := &RequestData{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Params:  params,
+		Query:   make(map[string]string),
+		Headers: make(map[string]string),
+		Body:    string(body),
+	}
+	for key, values := range r.URL.Query() {
+		data.Query[key] = values[0]
+	}
+	for key, values := range r.Header {
+		data.Headers[key] = values[0]
+	}
+	if len(body) > 0 {
+		json.Unmarshal(body, &data.JSON)
+	}
+	return data
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,149 @@
+package mock
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, routes ...Route) *httptest.Server {
+	t.Helper()
+
+	config := &Config{Variables: map[string]interface{}{"region": "eu"}, Routes: routes}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Invalid config: %v", err)
+	}
+	server := httptest.NewServer(NewServer(config, 1, nil))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, method, url, body string) (*http.Response, string) {
+	t.Helper()
+
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+func TestServer_TemplatedResponses(t *testing.T) {
+	server := newTestServer(t,
+		Route{
+			Method:  "GET",
+			Path:    "/users/{id}",
+			Headers: map[string]string{"X-Region": `{{ var "region" }}`},
+			Body: map[string]interface{}{
+				"id":     "{{ .Params.id }}",
+				"page":   "{{ .Query.page }}",
+				"tags":   []interface{}{"{{ upper .Params.id }}", 1},
+				"static": true,
+			},
+		},
+		Route{
+			Method: "POST",
+			Path:   "/echo",
+			Status: 201,
+			Body:   `hello {{ .JSON.name }} via {{ .Method }}`,
+		},
+		Route{Path: "/files/*", Body: "{{ index .Params \"*\" }}"},
+	)
+
+	resp, body := get(t, "GET", server.URL+"/users/ab?page=2", "")
+	var user map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &user); err != nil {
+		t.Fatalf("Expected JSON body, got %s", body)
+	}
+	if user["id"] != "ab" || user["page"] != "2" || user["tags"].([]interface{})[0] != "AB" || user["static"] != true {
+		t.Errorf("Unexpected body %v", user)
+	}
+	if resp.Header.Get("X-Region") != "eu" || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected headers %v", resp.Header)
+	}
+
+	resp, body = get(t, "POST", server.URL+"/echo", `{"name": "Ada"}`)
+	if resp.StatusCode != 201 || body != "hello Ada via POST" || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		t.Errorf("Unexpected response %d %q", resp.StatusCode, body)
+	}
+
+	if _, body = get(t, "GET", server.URL+"/files/a/b.txt", ""); body != "a/b.txt" {
+		t.Errorf("Expected wildcard match, got %q", body)
+	}
+
+	resp, body = get(t, "DELETE", server.URL+"/users/ab", "")
+	if resp.StatusCode != 404 || !strings.Contains(body, "no route matches DELETE /users/ab") {
+		t.Errorf("Expected 404 for unmatched method, got %d %s", resp.StatusCode, body)
+	}
+}
+
+func TestServer_LatencyAndFailures(t *testing.T) {
+	server := newTestServer(t,
+		Route{Path: "/slow", Latency: "100ms", Jitter: "20ms", Body: "ok"},
+		Route{Path: "/down", Body: "ok", Failure: &Failure{Rate: 1, Status: 503}},
+		Route{Path: "/flaky", Body: "ok", Failure: &Failure{Rate: 0.5, Body: map[string]interface{}{"error": "{{ .Path }}"}}},
+	)
+
+	start := time.Now()
+	if _, body := get(t, "GET", server.URL+"/slow", ""); body != "ok" {
+		t.Errorf("Expected ok, got %q", body)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected at least 80ms of latency, got %v", elapsed)
+	}
+
+	resp, body := get(t, "GET", server.URL+"/down", "")
+	if resp.StatusCode != 503 || body != "Service Unavailable" {
+		t.Errorf("Expected injected 503, got %d %q", resp.StatusCode, body)
+	}
+
+	failures := 0
+	for i := 0; i < 200; i++ {
+		resp, body := get(t, "GET", server.URL+"/flaky", "")
+		if resp.StatusCode == 500 {
+			failures++
+			if body != `{"error":"/flaky"}` {
+				t.Fatalf("Unexpected failure body %q", body)
+			}
+		}
+	}
+	if failures < 60 || failures > 140 {
+		t.Errorf("Expected about half of 200 requests to fail, got %d", failures)
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          map[string]string
+	}{
+		{"/", "/", map[string]string{}},
+		{"/users", "/users/", map[string]string{}},
+		{"/users/{id}", "/users/7", map[string]string{"id": "7"}},
+		{"/users/{id}", "/users", nil},
+		{"/users/{id}", "/users/7/posts", nil},
+		{"/static/*", "/static", map[string]string{"*": ""}},
+		{"/static/*", "/static/css/site.css", map[string]string{"*": "css/site.css"}},
+		{"/orgs/{org}/repos/{repo}", "/orgs/acme/repos/tools", map[string]string{"org": "acme", "repo": "tools"}},
+	}
+
+	for _, tt := range tests {
+		got, ok := matchPath(tt.pattern, tt.path)
+		if ok != (tt.want != nil) {
+			t.Errorf("matchPath(%q, %q) matched = %v", tt.pattern, tt.path, ok)
+			continue
+		}
+		for key, value := range tt.want {
+			if got[key] != value {
+				t.Errorf("matchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		}
+	}
+}
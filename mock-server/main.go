@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"local-dev-tools/mock-server/internal/mock"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run serves the configured routes until interrupted and returns the process
+// exit code
+func run() int {
+	configPath := flag.String("config", "mock.yaml", "Path to the routes file (YAML or JSON)")
+	addr := flag.String("addr", ":8080", "Address to listen on")
+	seed := flag.Int64("seed", 0, "Seed for failure injection, jitter and random template functions (0 picks one from the clock)")
+	quiet := flag.Bool("quiet", false, "Do not log each request")
+	flag.Parse()
+
+	config, err := mock.LoadConfig(*configPath)
+	if err != nil {
+		log.Printf("Error loading config: %v", err)
+		return 2
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	logf := log.Printf
+	if *quiet {
+		logf = nil
+	}
+
+	server := &http.Server{
+		Addr:    *addr,
+		Handler: mock.NewServer(config, *seed, logf),
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		fmt.Println("\nShutting down...")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	fmt.Printf("Serving %d routes from %s on %s\n", len(config.Routes), *configPath, *addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Server error: %v", err)
+		return 3
+	}
+	return 0
+}
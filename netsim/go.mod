@@ -0,0 +1,10 @@
+module local-dev-tools/netsim
+
+go 1.21
+
+require (
+	gopkg.in/yaml.v3 v3.0.1
+	local-dev-tools/dynamic-request-scheduler v0.0.0
+)
+
+replace local-dev-tools/dynamic-request-scheduler => ../dynamic-request-scheduler
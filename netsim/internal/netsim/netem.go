@@ -0,0 +1,147 @@
+package netsim
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Runner runs a command and returns its combined output
+type Runner func(name string, args ...string) ([]byte, error)
+
+// ExecRunner runs commands with os/exec
+func ExecRunner(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// Netem applies profiles to an interface's outgoing traffic with tc. Every
+// packet on the loopback interface is outgoing once, so on lo both
+// directions of a local connection are degraded.
+type Netem struct {
+	Interface string
+
+	// Ports limits the profile to TCP and UDP traffic to or from these
+	// ports; empty degrades everything on the interface
+	Ports []int
+
+	Run Runner
+}
+
+// Apply replaces the interface's root qdisc with one for the profile
+func (n *Netem) Apply(p *Profile) error {
+	// Start from the default qdisc; there is nothing to delete the first time
+	n.Clear()
+	for _, args := range n.ApplyCommands(p) {
+		if err := n.tc(args); err != nil {
+			n.Clear()
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear restores the interface's default qdisc. Clearing an interface that
+// has no profile applied is not an error.
+func (n *Netem) Clear() error {
+	err := n.tc(n.ClearCommand())
+	if err != nil && (strings.Contains(err.Error(), "handle of zero") || strings.Contains(err.Error(), "No such file or directory")) {
+		return nil
+	}
+	return err
+}
+
+// Status returns tc's description of the interface's qdiscs
+func (n *Netem) Status() (string, error) {
+	out, err := n.Run("tc", "qdisc", "show", "dev", n.Interface)
+	if err != nil {
+		return "", tcError(err, out)
+	}
+	return string(out), nil
+}
+
+// ApplyCommands returns the tc arguments that set up the profile. With ports,
+// a prio qdisc sends matching packets to a fourth band that has netem, and
+// everything else through the usual three untouched.
+func (n *Netem) ApplyCommands(p *Profile) [][]string {
+	netem := p.netemArgs()
+	if len(n.Ports) == 0 {
+		return [][]string{append([]string{"qdisc", "add", "dev", n.Interface, "root", "handle", "1:", "netem"}, netem...)}
+	}
+
+	commands := [][]string{
+		{"qdisc", "add", "dev", n.Interface, "root", "handle", "1:", "prio", "bands", "4"},
+		append([]string{"qdisc", "add", "dev", n.Interface, "parent", "1:4", "handle", "40:", "netem"}, netem...),
+	}
+	for _, port := range n.Ports {
+		for _, protocol := range []struct{ name, match string }{{"ip", "ip"}, {"ipv6", "ip6"}} {
+			for _, field := range []string{"dport", "sport"} {
+				commands = append(commands, []string{
+					"filter", "add", "dev", n.Interface, "parent", "1:", "protocol", protocol.name, "prio", "1",
+					"u32", "match", protocol.match, field, strconv.Itoa(port), "0xffff", "flowid", "1:4",
+				})
+			}
+		}
+	}
+	return commands
+}
+
+// ClearCommand returns the tc arguments that remove the profile
+func (n *Netem) ClearCommand() []string {
+	return []string{"qdisc", "del", "dev", n.Interface, "root"}
+}
+
+// netemArgs returns the netem options for the profile
+func (p *Profile) netemArgs() []string {
+	var args []string
+	if p.latency > 0 {
+		args = append(args, "delay", tcTime(p.latency))
+		if p.jitter > 0 {
+			args = append(args, tcTime(p.jitter))
+		}
+	}
+	if p.Loss > 0 {
+		args = append(args, "loss", strconv.FormatFloat(p.Loss*100, 'f', -1, 64)+"%")
+	}
+	if p.bandwidth > 0 {
+		args = append(args, "rate", strconv.FormatInt(p.bandwidth*8, 10)+"bit")
+	}
+	if len(args) == 0 {
+		// netem needs at least one option; no delay passes traffic untouched
+		args = append(args, "delay", "0ms")
+	}
+	return args
+}
+
+// tcTime formats a duration in the microseconds tc understands
+func tcTime(d time.Duration) string {
+	return strconv.FormatInt(d.Microseconds(), 10) + "us"
+}
+
+func (n *Netem) tc(args []string) error {
+	out, err := n.Run("tc", args...)
+	if err != nil {
+		return fmt.Errorf("tc %s: %w", strings.Join(args, " "), tcError(err, out))
+	}
+	return nil
+}
+
+// tcError explains tc's common failures
+func tcError(err error, out []byte) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("tc not found; install iproute2, or use netsim proxy")
+	}
+	message := strings.TrimSpace(string(out))
+	if message == "" {
+		return err
+	}
+	if strings.Contains(message, "Operation not permitted") {
+		return fmt.Errorf("%s (tc needs root; try sudo)", message)
+	}
+	if strings.Contains(message, "qdisc kind is unknown") {
+		return fmt.Errorf("%s (the kernel lacks netem; try sudo modprobe sch_netem, or use netsim proxy)", message)
+	}
+	return errors.New(message)
+}
@@ -0,0 +1,96 @@
+package netsim
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeTC records the tc commands run and fails those containing failOn
+type fakeTC struct {
+	commands []string
+	failOn   string
+	output   string
+}
+
+func (f *fakeTC) run(name string, args ...string) ([]byte, error) {
+	command := name + " " + strings.Join(args, " ")
+	f.commands = append(f.commands, command)
+	if f.failOn != "" && strings.Contains(command, f.failOn) {
+		return []byte(f.output), errors.New("exit status 2")
+	}
+	return nil, nil
+}
+
+func validProfile(t *testing.T, p Profile) *Profile {
+	t.Helper()
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Invalid profile: %v", err)
+	}
+	return &p
+}
+
+func TestNetem_ApplyInterface(t *testing.T) {
+	tc := &fakeTC{failOn: "qdisc del", output: "Error: Cannot delete qdisc with handle of zero."}
+	netem := &Netem{Interface: "lo", Run: tc.run}
+	p := validProfile(t, Profile{Latency: "100ms", Jitter: "1.5ms", Loss: 0.02, Bandwidth: "96KB"})
+
+	if err := netem.Apply(p); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []string{
+		"tc qdisc del dev lo root",
+		"tc qdisc add dev lo root handle 1: netem delay 100000us 1500us loss 2% rate 786432bit",
+	}
+	if fmt.Sprint(tc.commands) != fmt.Sprint(want) {
+		t.Errorf("Got commands:\n%s\nWant:\n%s", strings.Join(tc.commands, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestNetem_ApplyPorts(t *testing.T) {
+	netem := &Netem{Interface: "eth0", Ports: []int{8080}}
+	commands := netem.ApplyCommands(validProfile(t, Profile{}))
+
+	var got []string
+	for _, args := range commands {
+		got = append(got, strings.Join(args, " "))
+	}
+	want := []string{
+		"qdisc add dev eth0 root handle 1: prio bands 4",
+		"qdisc add dev eth0 parent 1:4 handle 40: netem delay 0ms",
+		"filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip dport 8080 0xffff flowid 1:4",
+		"filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip sport 8080 0xffff flowid 1:4",
+		"filter add dev eth0 parent 1: protocol ipv6 prio 1 u32 match ip6 dport 8080 0xffff flowid 1:4",
+		"filter add dev eth0 parent 1: protocol ipv6 prio 1 u32 match ip6 sport 8080 0xffff flowid 1:4",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Got commands:\n%s\nWant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestNetem_ApplyFailure(t *testing.T) {
+	tc := &fakeTC{failOn: "add", output: "RTNETLINK answers: Operation not permitted"}
+	netem := &Netem{Interface: "lo", Run: tc.run}
+
+	err := netem.Apply(validProfile(t, Profile{Latency: "10ms"}))
+	if err == nil || !strings.Contains(err.Error(), "needs root") {
+		t.Errorf("Got error %v, want a hint about root", err)
+	}
+	// The failed setup is cleared again
+	if last := tc.commands[len(tc.commands)-1]; last != "tc qdisc del dev lo root" {
+		t.Errorf("Last command was %q", last)
+	}
+}
+
+func TestNetem_Clear(t *testing.T) {
+	tc := &fakeTC{failOn: "qdisc del", output: "Error: Cannot delete qdisc with handle of zero."}
+	if err := (&Netem{Interface: "lo", Run: tc.run}).Clear(); err != nil {
+		t.Errorf("Clearing a clean interface failed: %v", err)
+	}
+
+	tc = &fakeTC{failOn: "qdisc del", output: "Cannot find device \"eth9\""}
+	if err := (&Netem{Interface: "eth9", Run: tc.run}).Clear(); err == nil || !strings.Contains(err.Error(), "eth9") {
+		t.Errorf("Got error %v", err)
+	}
+}
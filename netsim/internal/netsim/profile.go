@@ -0,0 +1,176 @@
+// Package netsim degrades local network traffic according to named profiles,
+// either in the kernel with tc/netem or in a userspace TCP proxy.
+package netsim
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/shaping"
+)
+
+// Profile is a named set of network conditions. Latency, jitter and loss
+// apply to each direction separately, so a round trip sees them twice.
+type Profile struct {
+	Description string `yaml:"description,omitempty"`
+
+	// Latency delays every packet, varied by up to ±Jitter
+	Latency string `yaml:"latency,omitempty"`
+	Jitter  string `yaml:"jitter,omitempty"`
+
+	// Loss is the fraction of packets lost, 0-1
+	Loss float64 `yaml:"loss,omitempty"`
+
+	// Bandwidth caps the transfer rate (e.g. "96KB" per second)
+	Bandwidth string `yaml:"bandwidth,omitempty"`
+
+	latency   time.Duration
+	jitter    time.Duration
+	bandwidth int64
+}
+
+// Builtin are the profiles available without a profiles file. The figures
+// are typical rather than measured, and one-way.
+var Builtin = map[string]*Profile{
+	"wifi": {
+		Description: "Busy home Wi-Fi",
+		Latency:     "10ms", Jitter: "5ms", Loss: 0.005,
+	},
+	"4g": {
+		Description: "Good mobile signal",
+		Latency:     "40ms", Jitter: "15ms", Loss: 0.005, Bandwidth: "1.5MB",
+	},
+	"3g": {
+		Description: "Weak mobile signal",
+		Latency:     "100ms", Jitter: "40ms", Loss: 0.02, Bandwidth: "96KB",
+	},
+	"2g": {
+		Description: "Edge of coverage",
+		Latency:     "300ms", Jitter: "100ms", Loss: 0.05, Bandwidth: "30KB",
+	},
+	"satellite": {
+		Description: "Geostationary satellite link",
+		Latency:     "300ms", Jitter: "20ms", Loss: 0.01, Bandwidth: "1MB",
+	},
+	"flaky": {
+		Description: "Unreliable link with heavy loss",
+		Latency:     "50ms", Jitter: "50ms", Loss: 0.1,
+	},
+}
+
+// profilesFile is the layout of a profiles file
+type profilesFile struct {
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+// Profiles are the profiles that can be applied by name
+type Profiles map[string]*Profile
+
+// LoadProfiles returns the built-in profiles, plus those in the YAML or JSON
+// file at path when it is not empty. File profiles replace built-in ones of
+// the same name.
+func LoadProfiles(path string) (Profiles, error) {
+	profiles := make(Profiles, len(Builtin))
+	for name, p := range Builtin {
+		copied := *p
+		profiles[name] = &copied
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read profiles file: %w", err)
+		}
+		var file profilesFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse profiles file: %w", err)
+		}
+		for name, p := range file.Profiles {
+			if p == nil {
+				p = &Profile{}
+			}
+			profiles[name] = p
+		}
+	}
+
+	for name, p := range profiles {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", name, err)
+		}
+	}
+	return profiles, nil
+}
+
+// Get returns the named profile
+func (p Profiles) Get(name string) (*Profile, error) {
+	profile, ok := p[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(p.Names(), ", "))
+	}
+	return profile, nil
+}
+
+// Names returns the profile names in order
+func (p Profiles) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks the profile and parses its durations and rate
+func (p *Profile) Validate() error {
+	var err error
+	if p.latency, err = parseDuration("latency", p.Latency); err != nil {
+		return err
+	}
+	if p.jitter, err = parseDuration("jitter", p.Jitter); err != nil {
+		return err
+	}
+	if p.jitter > p.latency {
+		return fmt.Errorf("jitter %v is larger than latency %v", p.jitter, p.latency)
+	}
+	if p.bandwidth, err = shaping.ParseBandwidth(p.Bandwidth); err != nil {
+		return err
+	}
+	if p.Loss < 0 || p.Loss > 1 {
+		return fmt.Errorf("loss must be between 0 and 1")
+	}
+	return nil
+}
+
+// String summarises the profile's conditions
+func (p *Profile) String() string {
+	var parts []string
+	if p.latency > 0 {
+		parts = append(parts, fmt.Sprintf("latency %v±%v", p.latency, p.jitter))
+	}
+	if p.Loss > 0 {
+		parts = append(parts, fmt.Sprintf("loss %g%%", p.Loss*100))
+	}
+	if p.bandwidth > 0 {
+		parts = append(parts, "bandwidth "+p.Bandwidth+"/s")
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func parseDuration(field, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", field, value)
+	}
+	return d, nil
+}
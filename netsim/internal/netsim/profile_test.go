@@ -0,0 +1,84 @@
+package netsim
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuiltinProfiles(t *testing.T) {
+	profiles, err := LoadProfiles("")
+	if err != nil {
+		t.Fatalf("Built-in profiles are invalid: %v", err)
+	}
+	if len(profiles) != len(Builtin) {
+		t.Errorf("Got %d profiles, want %d", len(profiles), len(Builtin))
+	}
+
+	p, err := profiles.Get("3g")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := p.String(); got != "latency 100ms±40ms, loss 2%, bandwidth 96KB/s" {
+		t.Errorf("Got %q", got)
+	}
+	if _, err := profiles.Get("5g"); err == nil {
+		t.Error("Expected an error for an unknown profile")
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.yaml")
+	os.WriteFile(path, []byte(`
+profiles:
+  3g:
+    latency: 200ms
+  vpn:
+    description: Office VPN
+    latency: 30ms
+    jitter: 5ms
+    bandwidth: 2MB
+`), 0o644)
+
+	profiles, err := LoadProfiles(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := len(profiles.Names()); got != len(Builtin)+1 {
+		t.Errorf("Got %d profiles, want %d", got, len(Builtin)+1)
+	}
+	if p := profiles["vpn"]; p.latency != 30*time.Millisecond || p.bandwidth != 2<<20 {
+		t.Errorf("Got vpn %+v", p)
+	}
+	// File profiles replace built-in ones entirely
+	if p := profiles["3g"]; p.latency != 200*time.Millisecond || p.Loss != 0 || p.bandwidth != 0 {
+		t.Errorf("Got 3g %+v", p)
+	}
+	if Builtin["3g"].Latency != "100ms" {
+		t.Error("Loading a file changed the built-in profiles")
+	}
+}
+
+func TestProfile_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		wantErr bool
+	}{
+		{name: "empty", profile: Profile{}},
+		{name: "full", profile: Profile{Latency: "50ms", Jitter: "10ms", Loss: 0.1, Bandwidth: "64KB/s"}},
+		{name: "bad latency", profile: Profile{Latency: "fast"}, wantErr: true},
+		{name: "jitter above latency", profile: Profile{Latency: "10ms", Jitter: "20ms"}, wantErr: true},
+		{name: "loss above 1", profile: Profile{Loss: 2}, wantErr: true},
+		{name: "bad bandwidth", profile: Profile{Bandwidth: "lots"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.profile.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
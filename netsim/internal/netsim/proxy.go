@@ -0,0 +1,197 @@
+package netsim
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/shaping"
+)
+
+// segmentSize is the payload of one TCP segment on a typical link, the unit
+// loss is rolled for
+const segmentSize = 1460
+
+// minRetransmitTimeout is the shortest time Linux waits before resending a
+// lost segment
+const minRetransmitTimeout = 200 * time.Millisecond
+
+// Forward is a local address proxied to a target
+type Forward struct {
+	Listen string
+	Target string
+}
+
+// ParseForward reads a listen=target mapping such as 18080=localhost:8080.
+// A bare listen port listens on every interface.
+func ParseForward(s string) (Forward, error) {
+	listen, target, ok := strings.Cut(s, "=")
+	if !ok || listen == "" || target == "" {
+		return Forward{}, fmt.Errorf("invalid forward %q: must be listen=host:port (e.g. 18080=localhost:8080)", s)
+	}
+	if _, err := strconv.Atoi(listen); err == nil {
+		listen = ":" + listen
+	}
+	if _, _, err := net.SplitHostPort(listen); err != nil {
+		return Forward{}, fmt.Errorf("invalid forward %q: bad listen address: %w", s, err)
+	}
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		return Forward{}, fmt.Errorf("invalid forward %q: bad target: %w", s, err)
+	}
+	return Forward{Listen: listen, Target: target}, nil
+}
+
+// Proxy relays TCP connections from a listener to a target, applying a
+// profile to the data in each direction. TCP hides loss from applications,
+// so a lost segment shows up as the stall while it is resent.
+type Proxy struct {
+	target  string
+	profile *Profile
+	dice    *shaping.Dice
+	logf    func(format string, args ...interface{})
+}
+
+// NewProxy creates a proxy to target for a validated profile. Random delays
+// and losses are drawn from seed.
+func NewProxy(target string, profile *Profile, seed int64, logf func(format string, args ...interface{})) *Proxy {
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+	return &Proxy{target: target, profile: profile, dice: shaping.NewDice(seed), logf: logf}
+}
+
+// Serve accepts connections until the listener is closed
+func (p *Proxy) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go p.handle(conn)
+	}
+}
+
+func (p *Proxy) handle(client net.Conn) {
+	defer client.Close()
+	start := time.Now()
+	remote := client.RemoteAddr().String()
+
+	upstream, err := net.DialTimeout("tcp", p.target, 10*time.Second)
+	if err != nil {
+		p.logf("%s -> %s: %v", remote, p.target, err)
+		return
+	}
+	defer upstream.Close()
+
+	var wg sync.WaitGroup
+	var sent, received relayStats
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		sent = p.relay(upstream, client)
+	}()
+	go func() {
+		defer wg.Done()
+		received = p.relay(client, upstream)
+	}()
+	wg.Wait()
+
+	p.logf("%s -> %s: closed after %v (%d bytes sent, %d received, %d segments lost)",
+		remote, p.target, time.Since(start).Round(time.Millisecond), sent.bytes, received.bytes, sent.lost+received.lost)
+}
+
+// chunk is data read from one side, due to be written to the other
+type chunk struct {
+	data []byte
+	due  time.Time
+}
+
+// relayStats counts what one direction relayed
+type relayStats struct {
+	bytes int64
+	lost  int
+}
+
+// relay copies src to dst, then closes dst for writing so the other side sees
+// EOF. Data is read as soon as it arrives and written once its delay has
+// passed, so latency does not limit throughput, as on a real link.
+func (p *Proxy) relay(dst, src net.Conn) relayStats {
+	var stats relayStats
+	chunks := make(chan chunk, 64)
+	go func() {
+		defer close(chunks)
+		var last time.Time
+		for {
+			buf := make([]byte, 32<<10)
+			n, err := src.Read(buf)
+			if n > 0 {
+				delay, lost := p.delay(n)
+				stats.lost += lost
+				// A byte stream cannot be reordered, so jitter never lets a
+				// chunk overtake the one before it
+				due := time.Now().Add(delay)
+				if due.Before(last) {
+					due = last
+				}
+				last = due
+				chunks <- chunk{data: buf[:n], due: due}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var w io.Writer = dst
+	if p.profile.bandwidth > 0 {
+		w = shaping.NewThrottledWriter(dst, p.profile.bandwidth)
+	}
+	for c := range chunks {
+		if wait := time.Until(c.due); wait > 0 {
+			time.Sleep(wait)
+		}
+		n, err := w.Write(c.data)
+		stats.bytes += int64(n)
+		if err != nil {
+			// Unblock the reader, then let it finish
+			src.Close()
+			for range chunks {
+			}
+			break
+		}
+	}
+
+	if tcp, ok := dst.(*net.TCPConn); ok {
+		tcp.CloseWrite()
+	} else {
+		dst.Close()
+	}
+	return stats
+}
+
+// delay returns how long n bytes take to cross the link: the latency varied
+// by jitter, plus a retransmission timeout for each segment lost
+func (p *Proxy) delay(n int) (time.Duration, int) {
+	delay := p.dice.Jittered(p.profile.latency, p.profile.jitter)
+	lost := 0
+	for segments := (n + segmentSize - 1) / segmentSize; segments > 0; segments-- {
+		if p.dice.Roll(p.profile.Loss) {
+			lost++
+		}
+	}
+	return delay + time.Duration(lost)*p.retransmitTimeout(), lost
+}
+
+// retransmitTimeout approximates how long a lost segment takes to resend:
+// the minimum timeout plus the round trip
+func (p *Proxy) retransmitTimeout() time.Duration {
+	return minRetransmitTimeout + 2*p.profile.latency
+}
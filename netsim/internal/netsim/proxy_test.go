@@ -0,0 +1,143 @@
+package netsim
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// startEcho runs a TCP server echoing everything it receives
+func startEcho(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// dialProxy starts a proxy to an echo server and connects to it
+func dialProxy(t *testing.T, profile Profile) net.Conn {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go NewProxy(startEcho(t), validProfile(t, profile), 1, nil).Serve(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// roundTrip writes data and reads it back, returning how long it took
+func roundTrip(t *testing.T, conn net.Conn, data []byte) time.Duration {
+	t.Helper()
+
+	start := time.Now()
+	if _, err := conn.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	got := make([]byte, len(data))
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("Echo was corrupted")
+	}
+	return time.Since(start)
+}
+
+func TestProxy_Latency(t *testing.T) {
+	conn := dialProxy(t, Profile{Latency: "50ms"})
+
+	// The delay applies in each direction
+	if elapsed := roundTrip(t, conn, []byte("ping")); elapsed < 100*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Round trip took %v, want about 100ms", elapsed)
+	}
+}
+
+func TestProxy_LatencyDoesNotLimitThroughput(t *testing.T) {
+	conn := dialProxy(t, Profile{Latency: "100ms"})
+
+	start := time.Now()
+	go func() {
+		for i := 0; i < 10; i++ {
+			conn.Write([]byte("0123456789"))
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	got := make([]byte, 100)
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	// Ten writes queued behind each other would take over two seconds
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Took %v to relay 10 writes with 100ms latency", elapsed)
+	}
+}
+
+func TestProxy_Loss(t *testing.T) {
+	conn := dialProxy(t, Profile{Loss: 1})
+
+	// Every segment is lost once each way, costing a retransmission timeout
+	if elapsed := roundTrip(t, conn, []byte("ping")); elapsed < 2*minRetransmitTimeout {
+		t.Errorf("Round trip took %v, want at least %v", elapsed, 2*minRetransmitTimeout)
+	}
+}
+
+func TestProxy_Bandwidth(t *testing.T) {
+	conn := dialProxy(t, Profile{Bandwidth: "16KB"})
+
+	// 4KB at 16KB/s takes a quarter of a second to arrive; the echo streams
+	// back as it arrives, so the round trip is only a little longer
+	if elapsed := roundTrip(t, conn, bytes.Repeat([]byte("x"), 4<<10)); elapsed < 250*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Round trip took %v, want about 300ms", elapsed)
+	}
+}
+
+func TestParseForward(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    Forward
+		wantErr bool
+	}{
+		{arg: "18080=localhost:8080", want: Forward{Listen: ":18080", Target: "localhost:8080"}},
+		{arg: "127.0.0.1:15432=db:5432", want: Forward{Listen: "127.0.0.1:15432", Target: "db:5432"}},
+		{arg: "18080", wantErr: true},
+		{arg: "18080=localhost", wantErr: true},
+		{arg: "=localhost:8080", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseForward(tt.arg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseForward(%q) error = %v, wantErr %v", tt.arg, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseForward(%q) = %+v, want %+v", tt.arg, got, tt.want)
+		}
+	}
+}
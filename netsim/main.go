@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"local-dev-tools/netsim/internal/netsim"
+)
+
+// Process exit codes
+const (
+	exitOK = 0
+	// exitConfigError means the flags or profiles were invalid
+	exitConfigError = 2
+	// exitRuntimeError means tc failed or a port could not be listened on
+	exitRuntimeError = 3
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [options] [profile] [forwards...]\n\n", os.Args[0])
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  profiles  List the profiles")
+	fmt.Fprintln(os.Stderr, "  apply     Apply a profile to an interface with tc/netem (Linux, root)")
+	fmt.Fprintln(os.Stderr, "  clear     Remove the profile from an interface")
+	fmt.Fprintln(os.Stderr, "  status    Show an interface's queueing disciplines")
+	fmt.Fprintln(os.Stderr, "  proxy     Proxy local ports to services through a profile (any OS)")
+	fmt.Fprintln(os.Stderr, "\nRun a command with -h for its options.")
+}
+
+func run(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return exitConfigError
+	}
+	switch args[0] {
+	case "profiles":
+		return runProfiles(args[1:])
+	case "apply":
+		return runApply(args[1:])
+	case "clear":
+		return runClear(args[1:])
+	case "status":
+		return runStatus(args[1:])
+	case "proxy":
+		return runProxy(args[1:])
+	case "-h", "-help", "--help", "help":
+		usage()
+		return exitOK
+	}
+	log.Printf("Unknown command %q", args[0])
+	usage()
+	return exitConfigError
+}
+
+// addProfilesFlag adds the profiles file option shared by the commands that
+// take a profile
+func addProfilesFlag(fs *flag.FlagSet) *string {
+	return fs.String("profiles", os.Getenv("NETSIM_PROFILES"), "Extra profiles file (YAML or JSON); defaults to $NETSIM_PROFILES")
+}
+
+// addInterfaceFlag adds the interface option shared by the tc commands
+func addInterfaceFlag(fs *flag.FlagSet) *string {
+	return fs.String("iface", "lo", "Network interface")
+}
+
+// loadProfile loads the profiles and returns the one named by the first
+// argument
+func loadProfile(path string, args []string) (*netsim.Profile, string, bool) {
+	if len(args) == 0 {
+		log.Printf("Expected a profile; run %s profiles to list them", os.Args[0])
+		return nil, "", false
+	}
+	profiles, err := netsim.LoadProfiles(path)
+	if err != nil {
+		log.Printf("Error loading profiles: %v", err)
+		return nil, "", false
+	}
+	profile, err := profiles.Get(args[0])
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return nil, "", false
+	}
+	return profile, args[0], true
+}
+
+// checkTC reports whether tc can be used here
+func checkTC() bool {
+	if runtime.GOOS != "linux" {
+		log.Printf("Error: tc/netem is only available on Linux; use %s proxy instead", os.Args[0])
+		return false
+	}
+	return true
+}
+
+func runProfiles(args []string) int {
+	fs := flag.NewFlagSet("profiles", flag.ExitOnError)
+	profilesPath := addProfilesFlag(fs)
+	fs.Parse(args)
+
+	profiles, err := netsim.LoadProfiles(*profilesPath)
+	if err != nil {
+		log.Printf("Error loading profiles: %v", err)
+		return exitConfigError
+	}
+	width := 0
+	for _, name := range profiles.Names() {
+		width = max(width, len(name))
+	}
+	for _, name := range profiles.Names() {
+		profile := profiles[name]
+		fmt.Printf("%-*s  %s", width, name, profile)
+		if profile.Description != "" {
+			fmt.Printf(" (%s)", profile.Description)
+		}
+		fmt.Println()
+	}
+	return exitOK
+}
+
+func runApply(args []string) int {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s apply [options] <profile>\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	profilesPath := addProfilesFlag(fs)
+	iface := addInterfaceFlag(fs)
+	ports := fs.String("ports", "", "Comma-separated ports to degrade traffic to and from; empty for the whole interface")
+	duration := fs.Duration("for", 0, "Clear the profile after this long, or when interrupted; 0 leaves it applied")
+	dryRun := fs.Bool("dry-run", false, "Print the tc commands instead of running them")
+	fs.Parse(args)
+
+	profile, name, ok := loadProfile(*profilesPath, fs.Args())
+	if !ok {
+		return exitConfigError
+	}
+	netem := &netsim.Netem{Interface: *iface, Run: netsim.ExecRunner}
+	for _, port := range splitList(*ports) {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			log.Printf("Error: invalid port %q", port)
+			return exitConfigError
+		}
+		netem.Ports = append(netem.Ports, n)
+	}
+
+	if *dryRun {
+		fmt.Println("tc " + strings.Join(netem.ClearCommand(), " "))
+		for _, command := range netem.ApplyCommands(profile) {
+			fmt.Println("tc " + strings.Join(command, " "))
+		}
+		return exitOK
+	}
+	if !checkTC() {
+		return exitConfigError
+	}
+
+	if err := netem.Apply(profile); err != nil {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+	scope := *iface
+	if len(netem.Ports) > 0 {
+		scope += " ports " + *ports
+	}
+	fmt.Printf("Applied %s to %s: %s\n", name, scope, profile)
+	if *duration == 0 {
+		fmt.Printf("Run %s clear --iface %s to remove it\n", os.Args[0], *iface)
+		return exitOK
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	select {
+	case <-ctx.Done():
+	case <-time.After(*duration):
+	}
+	if err := netem.Clear(); err != nil {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+	fmt.Printf("Cleared %s\n", *iface)
+	return exitOK
+}
+
+func runClear(args []string) int {
+	fs := flag.NewFlagSet("clear", flag.ExitOnError)
+	iface := addInterfaceFlag(fs)
+	dryRun := fs.Bool("dry-run", false, "Print the tc command instead of running it")
+	fs.Parse(args)
+
+	netem := &netsim.Netem{Interface: *iface, Run: netsim.ExecRunner}
+	if *dryRun {
+		fmt.Println("tc " + strings.Join(netem.ClearCommand(), " "))
+		return exitOK
+	}
+	if !checkTC() {
+		return exitConfigError
+	}
+	if err := netem.Clear(); err != nil {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+	fmt.Printf("Cleared %s\n", *iface)
+	return exitOK
+}
+
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	iface := addInterfaceFlag(fs)
+	fs.Parse(args)
+
+	if !checkTC() {
+		return exitConfigError
+	}
+	status, err := (&netsim.Netem{Interface: *iface, Run: netsim.ExecRunner}).Status()
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+	fmt.Print(status)
+	if !strings.Contains(status, "netem") {
+		fmt.Printf("No profile applied to %s\n", *iface)
+	}
+	return exitOK
+}
+
+func runProxy(args []string) int {
+	fs := flag.NewFlagSet("proxy", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s proxy [options] <profile> <listen>=<host:port>...\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	profilesPath := addProfilesFlag(fs)
+	seed := fs.Int64("seed", 0, "Seed for the random delays and losses (0 picks one from the clock)")
+	quiet := fs.Bool("quiet", false, "Do not log each connection")
+	fs.Parse(args)
+
+	profile, name, ok := loadProfile(*profilesPath, fs.Args())
+	if !ok {
+		return exitConfigError
+	}
+	if fs.NArg() < 2 {
+		log.Printf("Expected at least one forward, such as 18080=localhost:8080")
+		return exitConfigError
+	}
+	var forwards []netsim.Forward
+	for _, arg := range fs.Args()[1:] {
+		forward, err := netsim.ParseForward(arg)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitConfigError
+		}
+		forwards = append(forwards, forward)
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	logf := log.Printf
+	if *quiet {
+		logf = nil
+	}
+
+	var listeners []net.Listener
+	defer func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+	}()
+	for i, forward := range forwards {
+		listener, err := net.Listen("tcp", forward.Listen)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitRuntimeError
+		}
+		listeners = append(listeners, listener)
+		log.Printf("Proxying %s -> %s with %s: %s", listener.Addr(), forward.Target, name, profile)
+		go netsim.NewProxy(forward.Target, profile, *seed+int64(i), logf).Serve(listener)
+	}
+	log.Printf("Seed %d; press Ctrl+C to stop", *seed)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	log.Printf("Stopping")
+	return exitOK
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
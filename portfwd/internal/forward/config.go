@@ -0,0 +1,205 @@
+// Package forward maintains named local port forwards to TCP services,
+// Docker containers, hosts behind SSH and anything reachable through a
+// command's standard input and output.
+package forward
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Forward kinds returned by Forward.Kind
+const (
+	KindTCP       = "tcp"
+	KindContainer = "container"
+	KindSSH       = "ssh"
+	KindCommand   = "command"
+)
+
+// Config is a forwards file
+type Config struct {
+	// ConnectTimeout bounds how long a new connection waits for the
+	// upstream, retrying, before it is closed; 10s by default
+	ConnectTimeout string `yaml:"connect_timeout,omitempty"`
+
+	// CheckInterval is how often tcp and container upstreams are probed for
+	// the status; 10s by default, "0" disables probing
+	CheckInterval string `yaml:"check_interval,omitempty"`
+
+	Forwards []Forward `yaml:"forwards"`
+
+	connectTimeout time.Duration
+	checkInterval  time.Duration
+}
+
+// Forward listens on a local address and relays each connection to an
+// upstream. At most one of Container, SSH and Command is set; without any,
+// Target is dialled directly.
+type Forward struct {
+	Name string `yaml:"name"`
+
+	// Listen is the local host:port, or a port on 127.0.0.1
+	Listen string `yaml:"listen"`
+
+	// Target is the upstream host:port. With Container it may be just the
+	// port, reached on the container's address.
+	Target string `yaml:"target,omitempty"`
+
+	// Container is a Docker container name or ID whose address is looked up
+	// for every connection, so it survives the container being recreated
+	Container string `yaml:"container,omitempty"`
+
+	// SSH is the [user@]host[:port] that Target is reached through
+	SSH string `yaml:"ssh,omitempty"`
+
+	// Command is run for every connection, which is relayed over its
+	// standard input and output
+	Command []string `yaml:"command,omitempty"`
+}
+
+// Kind returns how the forward reaches its upstream
+func (f *Forward) Kind() string {
+	switch {
+	case f.Container != "":
+		return KindContainer
+	case f.SSH != "":
+		return KindSSH
+	case len(f.Command) > 0:
+		return KindCommand
+	default:
+		return KindTCP
+	}
+}
+
+// Upstream describes where the forward's connections go, for output
+func (f *Forward) Upstream() string {
+	switch f.Kind() {
+	case KindContainer:
+		return fmt.Sprintf("container %s port %s", f.Container, f.targetPort())
+	case KindSSH:
+		return fmt.Sprintf("%s via ssh %s", f.Target, f.SSH)
+	case KindCommand:
+		return strings.Join(f.Command, " ")
+	default:
+		return f.Target
+	}
+}
+
+// LoadConfig reads and validates a YAML or JSON forwards file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks the forwards and parses the durations
+func (c *Config) Validate() error {
+	var err error
+	if c.connectTimeout, err = parseDuration("connect_timeout", c.ConnectTimeout, 10*time.Second); err != nil {
+		return err
+	}
+	if c.checkInterval, err = parseDuration("check_interval", c.CheckInterval, 10*time.Second); err != nil {
+		return err
+	}
+
+	if len(c.Forwards) == 0 {
+		return fmt.Errorf("at least one forward must be defined")
+	}
+	names := make(map[string]bool)
+	listens := make(map[string]string)
+	for i := range c.Forwards {
+		f := &c.Forwards[i]
+		if err := f.validate(); err != nil {
+			return fmt.Errorf("forward %d (%s): %w", i, f.Name, err)
+		}
+		if names[f.Name] {
+			return fmt.Errorf("forward %d: duplicate name %q", i, f.Name)
+		}
+		names[f.Name] = true
+		if other, ok := listens[f.Listen]; ok {
+			return fmt.Errorf("forward %d (%s): listens on %s like %s", i, f.Name, f.Listen, other)
+		}
+		listens[f.Listen] = f.Name
+	}
+	return nil
+}
+
+func (f *Forward) validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if _, err := strconv.Atoi(f.Listen); err == nil {
+		f.Listen = net.JoinHostPort("127.0.0.1", f.Listen)
+	}
+	if _, port, err := net.SplitHostPort(f.Listen); err != nil || !validPort(port) {
+		return fmt.Errorf("listen must be host:port or a port, got %q", f.Listen)
+	}
+
+	kinds := 0
+	for _, set := range []bool{f.Container != "", f.SSH != "", len(f.Command) > 0} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds > 1 {
+		return fmt.Errorf("only one of container, ssh and command may be set")
+	}
+
+	switch f.Kind() {
+	case KindCommand:
+		if f.Target != "" {
+			return fmt.Errorf("target cannot be combined with command")
+		}
+	case KindContainer:
+		if !validPort(f.targetPort()) {
+			return fmt.Errorf("target must be the container's port, got %q", f.Target)
+		}
+	default:
+		if _, port, err := net.SplitHostPort(f.Target); err != nil || !validPort(port) {
+			return fmt.Errorf("target must be host:port, got %q", f.Target)
+		}
+	}
+	return nil
+}
+
+// targetPort returns the port of Target, which may be just a port
+func (f *Forward) targetPort() string {
+	if _, port, err := net.SplitHostPort(f.Target); err == nil {
+		return port
+	}
+	return f.Target
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 0 && n <= 65535
+}
+
+// parseDuration parses a non-negative duration, returning def when empty
+func parseDuration(field, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative duration such as 10s, got %q", field, value)
+	}
+	return d, nil
+}
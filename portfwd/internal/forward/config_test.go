@@ -0,0 +1,73 @@
+package forward
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "portfwd.yaml")
+	os.WriteFile(path, []byte(`
+connect_timeout: 30s
+check_interval: "0"
+forwards:
+  - name: postgres
+    listen: 5432
+    target: db.internal:5432
+    ssh: deploy@bastion:2222
+  - name: redis
+    listen: 0.0.0.0:6379
+    container: myapp-redis-1
+    target: 6379
+  - name: api
+    listen: 8080
+    target: localhost:3000
+  - name: pod
+    listen: 9090
+    command: [kubectl, exec, -i, deploy/api, --, nc, localhost, "8080"]
+`), 0o644)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.connectTimeout != 30*time.Second || config.checkInterval != 0 {
+		t.Errorf("Unexpected durations %v and %v", config.connectTimeout, config.checkInterval)
+	}
+
+	want := []struct{ kind, listen, upstream string }{
+		{KindSSH, "127.0.0.1:5432", "db.internal:5432 via ssh deploy@bastion:2222"},
+		{KindContainer, "0.0.0.0:6379", "container myapp-redis-1 port 6379"},
+		{KindTCP, "127.0.0.1:8080", "localhost:3000"},
+		{KindCommand, "127.0.0.1:9090", "kubectl exec -i deploy/api -- nc localhost 8080"},
+	}
+	for i, w := range want {
+		f := config.Forwards[i]
+		if f.Kind() != w.kind || f.Listen != w.listen || f.Upstream() != w.upstream {
+			t.Errorf("Forward %s: got %s, %s, %q; want %s, %s, %q", f.Name, f.Kind(), f.Listen, f.Upstream(), w.kind, w.listen, w.upstream)
+		}
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]Config{
+		"at least one forward":          {},
+		"connect_timeout must be":       {ConnectTimeout: "-1s", Forwards: []Forward{{Name: "a", Listen: "1", Target: "b:1"}}},
+		"name is required":              {Forwards: []Forward{{Listen: "1", Target: "b:1"}}},
+		"listen must be":                {Forwards: []Forward{{Name: "a", Listen: "localhost", Target: "b:1"}}},
+		"only one of":                   {Forwards: []Forward{{Name: "a", Listen: "1", Target: "b:1", SSH: "h", Container: "c"}}},
+		"cannot be combined":            {Forwards: []Forward{{Name: "a", Listen: "1", Target: "b:1", Command: []string{"cat"}}}},
+		"container's port":              {Forwards: []Forward{{Name: "a", Listen: "1", Target: "redis", Container: "c"}}},
+		"target must be host:port":      {Forwards: []Forward{{Name: "a", Listen: "1", Target: "3000"}}},
+		`duplicate name "a"`:            {Forwards: []Forward{{Name: "a", Listen: "1", Target: "b:1"}, {Name: "a", Listen: "2", Target: "b:1"}}},
+		"listens on 127.0.0.1:1 like a": {Forwards: []Forward{{Name: "a", Listen: "1", Target: "b:1"}, {Name: "b", Listen: "127.0.0.1:1", Target: "b:1"}}},
+	}
+	for want, config := range tests {
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %v", want, err)
+		}
+	}
+}
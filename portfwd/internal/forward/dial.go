@@ -0,0 +1,210 @@
+package forward
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// dockerCommand and sshCommand are replaced in tests
+	dockerCommand = "docker"
+	sshCommand    = "ssh"
+
+	// controlDir holds the SSH control sockets shared by connections
+	controlDir = filepath.Join(os.TempDir(), "portfwd-ssh")
+)
+
+// dial opens a connection to the forward's upstream
+func (f *Forward) dial(ctx context.Context) (io.ReadWriteCloser, error) {
+	var dialer net.Dialer
+	switch f.Kind() {
+	case KindContainer:
+		ip, err := containerIP(ctx, f.Container)
+		if err != nil {
+			return nil, err
+		}
+		return dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, f.targetPort()))
+	case KindSSH:
+		return startCommand(sshArgs(f.SSH, f.Target))
+	case KindCommand:
+		return startCommand(f.Command)
+	default:
+		return dialer.DialContext(ctx, "tcp", f.Target)
+	}
+}
+
+// containerIP looks up the address of a running container on its first
+// network with one
+func containerIP(ctx context.Context, container string) (string, error) {
+	cmd := exec.CommandContext(ctx, dockerCommand, "inspect", "--format",
+		"{{.State.Running}} {{range .NetworkSettings.Networks}}{{.IPAddress}} {{end}}", container)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("docker inspect %s: %s", container, message)
+		}
+		return "", fmt.Errorf("docker inspect %s: %w", container, err)
+	}
+
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 || fields[0] != "true" {
+		return "", fmt.Errorf("container %s is not running", container)
+	}
+	if len(fields) == 1 {
+		return "", fmt.Errorf("container %s has no network address", container)
+	}
+	return fields[1], nil
+}
+
+// sshArgs builds an ssh command relaying stdio to target. Connections share
+// one SSH session through a control socket, so only the first pays for the
+// handshake, and a dropped session is re-established by the next one.
+func sshArgs(destination, target string) []string {
+	args := []string{sshCommand,
+		"-o", "BatchMode=yes",
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath=" + filepath.Join(controlDir, "%C"),
+		"-o", "ControlPersist=5m",
+		"-o", "ServerAliveInterval=15",
+	}
+	if host, port, err := net.SplitHostPort(destination); err == nil {
+		destination = host
+		args = append(args, "-p", port)
+	}
+	return append(args, "-W", target, destination)
+}
+
+// commandConn relays over a command's standard input and output
+type commandConn struct {
+	cmd    *exec.Cmd
+	stdin  *os.File
+	stdout *os.File
+	stderr bytes.Buffer
+
+	closeOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+// startCommand runs args and returns its stdio as a connection. A command
+// that fails straight away is reported as a failed dial, with its stderr.
+func startCommand(args []string) (io.ReadWriteCloser, error) {
+	if args[0] == sshCommand {
+		os.MkdirAll(controlDir, 0o700)
+	}
+
+	// Pipes the command holds directly, rather than exec's copying
+	// goroutines, so output written just before it exits is not lost
+	stdinReader, stdinWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		stdinReader.Close()
+		stdinWriter.Close()
+		return nil, err
+	}
+
+	c := &commandConn{cmd: exec.Command(args[0], args[1:]...), stdin: stdinWriter, stdout: stdoutReader, done: make(chan struct{})}
+	c.cmd.Stdin = stdinReader
+	c.cmd.Stdout = stdoutWriter
+	c.cmd.Stderr = &c.stderr
+	// A background ssh control master may inherit stderr; do not wait on it
+	c.cmd.WaitDelay = time.Second
+	err = c.cmd.Start()
+	stdinReader.Close()
+	stdoutWriter.Close()
+	if err != nil {
+		stdinWriter.Close()
+		stdoutReader.Close()
+		return nil, err
+	}
+	go func() {
+		c.err = c.cmd.Wait()
+		close(c.done)
+	}()
+
+	select {
+	case <-c.done:
+		if c.err != nil {
+			stdinWriter.Close()
+			stdoutReader.Close()
+			return nil, c.exitError()
+		}
+	case <-time.After(commandStartGrace):
+	}
+	return c, nil
+}
+
+// commandStartGrace is how long a command must stay up to count as
+// connected
+var commandStartGrace = 200 * time.Millisecond
+
+func (c *commandConn) Read(p []byte) (int, error) {
+	return c.stdout.Read(p)
+}
+
+func (c *commandConn) Write(p []byte) (int, error) {
+	return c.stdin.Write(p)
+}
+
+// CloseWrite closes the command's input, telling it the client is done
+func (c *commandConn) CloseWrite() error {
+	return c.stdin.Close()
+}
+
+// Close closes the command's input and kills it if it does not exit
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		select {
+		case <-c.done:
+		case <-time.After(2 * time.Second):
+			c.cmd.Process.Kill()
+			<-c.done
+		}
+		c.stdout.Close()
+	})
+	return nil
+}
+
+// Err returns why the command failed if it exited with an error. Its
+// output may end just before it exits, so it is given a moment to.
+func (c *commandConn) Err() error {
+	select {
+	case <-c.done:
+		if c.err != nil {
+			return c.exitError()
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+	return nil
+}
+
+func (c *commandConn) exitError() error {
+	message := strings.TrimSpace(c.stderr.String())
+	if message == "" {
+		message = "no output"
+	}
+	if lines := strings.Split(message, "\n"); len(lines) > 1 {
+		message = lines[len(lines)-1]
+	}
+	var exitErr *exec.ExitError
+	if errors.As(c.err, &exitErr) {
+		return fmt.Errorf("%s exited with status %d: %s", filepath.Base(c.cmd.Path), exitErr.ExitCode(), message)
+	}
+	return c.err
+}
@@ -0,0 +1,98 @@
+package forward
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeDocker replaces the docker command with a shell script
+func fakeDocker(t *testing.T, script string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "docker")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	previous := dockerCommand
+	dockerCommand = path
+	t.Cleanup(func() { dockerCommand = previous })
+}
+
+func TestContainerIP(t *testing.T) {
+	fakeDocker(t, "echo 'true 172.20.0.5 10.0.0.2 '")
+	if ip, err := containerIP(context.Background(), "redis"); err != nil || ip != "172.20.0.5" {
+		t.Errorf("containerIP = %q, %v; want the first network's address", ip, err)
+	}
+
+	tests := map[string]string{
+		"is not running":         "echo 'false '",
+		"has no network address": "echo 'true '",
+		"No such object: redis":  "echo 'Error: No such object: redis' >&2; exit 1",
+	}
+	for want, script := range tests {
+		fakeDocker(t, script)
+		if _, err := containerIP(context.Background(), "redis"); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestSSHArgs(t *testing.T) {
+	args := sshArgs("deploy@bastion:2222", "db:5432")
+	tail := args[len(args)-5:]
+	if args[0] != "ssh" || !reflect.DeepEqual(tail, []string{"-p", "2222", "-W", "db:5432", "deploy@bastion"}) {
+		t.Errorf("Unexpected ssh command %v", args)
+	}
+	if !strings.Contains(strings.Join(args, " "), "ControlMaster=auto") {
+		t.Errorf("Expected connections to share a control master, got %v", args)
+	}
+
+	args = sshArgs("bastion", "db:5432")
+	if strings.Contains(strings.Join(args, " "), "-p ") || args[len(args)-1] != "bastion" {
+		t.Errorf("Expected no port option, got %v", args)
+	}
+}
+
+func TestStartCommand(t *testing.T) {
+	conn, err := startCommand([]string{"sh", "-c", "tr a-z A-Z"})
+	if err != nil {
+		t.Fatalf("startCommand failed: %v", err)
+	}
+	conn.Write([]byte("hello"))
+	conn.(*commandConn).CloseWrite()
+	out, _ := io.ReadAll(conn)
+	conn.Close()
+	if string(out) != "HELLO" {
+		t.Errorf("Expected the command's output, got %q", out)
+	}
+	if err := conn.(*commandConn).Err(); err != nil {
+		t.Errorf("Expected a clean exit, got %v", err)
+	}
+}
+
+func TestStartCommand_QuickOutput(t *testing.T) {
+	// Output written just before a successful exit is still read
+	conn, err := startCommand([]string{"echo", "banner"})
+	if err != nil {
+		t.Fatalf("startCommand failed: %v", err)
+	}
+	defer conn.Close()
+	if out, _ := io.ReadAll(conn); string(out) != "banner\n" {
+		t.Errorf("Expected the output, got %q", out)
+	}
+}
+
+func TestStartCommand_Fails(t *testing.T) {
+	_, err := startCommand([]string{"sh", "-c", "echo connecting >&2; echo 'connection refused' >&2; exit 255"})
+	if err == nil || err.Error() != "sh exited with status 255: connection refused" {
+		t.Errorf("Expected the last line of stderr, got %v", err)
+	}
+
+	if _, err := startCommand([]string{filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Errorf("Expected a missing command to fail")
+	}
+}
@@ -0,0 +1,379 @@
+package forward
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Forward states reported in Status
+const (
+	StateStarting     = "starting"
+	StateReady        = "ready"
+	StateUpstreamDown = "upstream down"
+	StateListenFailed = "listen failed"
+	StateStopped      = "stopped"
+)
+
+const (
+	// maxListenBackoff caps the wait between attempts to listen
+	maxListenBackoff = 30 * time.Second
+
+	// maxDialBackoff caps the wait between attempts to reach the upstream
+	// for one connection
+	maxDialBackoff = 2 * time.Second
+
+	// probeTimeout bounds each upstream probe
+	probeTimeout = 5 * time.Second
+)
+
+// Status is a snapshot of one forward
+type Status struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Listen   string `json:"listen"`
+	Upstream string `json:"upstream"`
+	State    string `json:"state"`
+
+	// Error is why the forward is not ready
+	Error string    `json:"error,omitempty"`
+	Since time.Time `json:"since"`
+
+	Active      int64 `json:"active"`
+	Connections int64 `json:"connections"`
+	Failures    int64 `json:"failures"`
+	BytesIn     int64 `json:"bytes_in"`
+	BytesOut    int64 `json:"bytes_out"`
+}
+
+// Manager runs a config's forwards
+type Manager struct {
+	forwarders []*forwarder
+}
+
+// NewManager creates a manager for a validated config. logf receives state
+// changes and may be nil.
+func NewManager(config *Config, logf func(format string, args ...interface{})) *Manager {
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+	m := &Manager{}
+	for _, f := range config.Forwards {
+		m.forwarders = append(m.forwarders, &forwarder{
+			Forward:        f,
+			connectTimeout: config.connectTimeout,
+			checkInterval:  config.checkInterval,
+			logf:           logf,
+			state:          StateStarting,
+			since:          time.Now(),
+			conns:          make(map[io.Closer]bool),
+		})
+	}
+	return m
+}
+
+// Run serves every forward until ctx is done, then closes their listeners
+// and connections
+func (m *Manager) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, f := range m.forwarders {
+		wg.Add(1)
+		go func(f *forwarder) {
+			defer wg.Done()
+			f.run(ctx)
+		}(f)
+	}
+	wg.Wait()
+}
+
+// Status returns a snapshot of every forward, in config order
+func (m *Manager) Status() []Status {
+	statuses := make([]Status, len(m.forwarders))
+	for i, f := range m.forwarders {
+		statuses[i] = f.status()
+	}
+	return statuses
+}
+
+// Addr returns the address the forward listens on, useful with port 0
+func (m *Manager) Addr(name string) net.Addr {
+	for _, f := range m.forwarders {
+		if f.Name == name {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if f.listener != nil {
+				return f.listener.Addr()
+			}
+		}
+	}
+	return nil
+}
+
+// forwarder runs one forward
+type forwarder struct {
+	Forward
+	connectTimeout time.Duration
+	checkInterval  time.Duration
+	logf           func(format string, args ...interface{})
+
+	mu        sync.Mutex
+	state     string
+	lastError string
+	since     time.Time
+	listener  net.Listener
+	conns     map[io.Closer]bool
+	stopping  bool
+
+	active, connections, failures atomic.Int64
+	bytesIn, bytesOut             atomic.Int64
+}
+
+func (f *forwarder) run(ctx context.Context) {
+	defer f.setState(StateStopped, nil)
+
+	listener := f.listen(ctx)
+	if listener == nil {
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+		f.mu.Lock()
+		f.stopping = true
+		for conn := range f.conns {
+			conn.Close()
+		}
+		f.mu.Unlock()
+	}()
+
+	if f.checkInterval > 0 && (f.Kind() == KindTCP || f.Kind() == KindContainer) {
+		go f.probe(ctx)
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		client, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.handle(ctx, client)
+		}()
+	}
+}
+
+// listen binds the local address, retrying with backoff while it fails,
+// and returns nil once ctx is done
+func (f *forwarder) listen(ctx context.Context) net.Listener {
+	backoff := time.Second
+	for {
+		listener, err := net.Listen("tcp", f.Listen)
+		if err == nil {
+			f.mu.Lock()
+			f.listener = listener
+			f.mu.Unlock()
+			f.setState(StateReady, nil)
+			return listener
+		}
+		f.setState(StateListenFailed, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxListenBackoff)
+	}
+}
+
+// handle relays one client connection, retrying the upstream for up to the
+// connect timeout so clients survive the upstream restarting
+func (f *forwarder) handle(ctx context.Context, client net.Conn) {
+	defer client.Close()
+	if !f.track(client) {
+		return
+	}
+	defer f.untrack(client)
+	f.connections.Add(1)
+	f.active.Add(1)
+	defer f.active.Add(-1)
+
+	upstream, err := f.dialRetrying(ctx)
+	if err != nil {
+		f.failures.Add(1)
+		if ctx.Err() == nil {
+			f.setState(StateUpstreamDown, err)
+		}
+		return
+	}
+	if !f.track(upstream) {
+		upstream.Close()
+		return
+	}
+	defer f.untrack(upstream)
+	f.setState(StateReady, nil)
+
+	f.relay(client, upstream)
+	if failed, ok := upstream.(interface{ Err() error }); ok {
+		if err := failed.Err(); err != nil {
+			f.failures.Add(1)
+			f.setState(StateUpstreamDown, err)
+		}
+	}
+	upstream.Close()
+}
+
+func (f *forwarder) dialRetrying(ctx context.Context) (io.ReadWriteCloser, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.connectTimeout)
+	defer cancel()
+
+	backoff := 250 * time.Millisecond
+	for {
+		upstream, err := f.dial(ctx)
+		if err == nil {
+			return upstream, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxDialBackoff)
+	}
+}
+
+// relay copies both ways until both directions are finished, passing on
+// half-closes where the connections support them
+func (f *forwarder) relay(client net.Conn, upstream io.ReadWriteCloser) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		n, _ := io.Copy(upstream, client)
+		f.bytesOut.Add(n)
+		closeWrite(upstream)
+	}()
+	go func() {
+		defer wg.Done()
+		n, _ := io.Copy(client, upstream)
+		f.bytesIn.Add(n)
+		closeWrite(client)
+	}()
+	wg.Wait()
+}
+
+// closeWrite half-closes c, or closes it when it cannot be half-closed
+func closeWrite(c io.Closer) {
+	if halfCloser, ok := c.(interface{ CloseWrite() error }); ok {
+		halfCloser.CloseWrite()
+		return
+	}
+	c.Close()
+}
+
+// probe dials the upstream every check interval to keep the state current
+// between connections
+func (f *forwarder) probe(ctx context.Context) {
+	ticker := time.NewTicker(f.checkInterval)
+	defer ticker.Stop()
+	for {
+		probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		conn, err := f.dial(probeCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			f.setState(StateUpstreamDown, err)
+		} else {
+			conn.Close()
+			f.setState(StateReady, nil)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *forwarder) track(c io.Closer) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stopping {
+		return false
+	}
+	f.conns[c] = true
+	return true
+}
+
+func (f *forwarder) untrack(c io.Closer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.conns, c)
+}
+
+// setState records the state and logs changes to it
+func (f *forwarder) setState(state string, err error) {
+	f.mu.Lock()
+	previous := f.state
+	if previous == StateStopped {
+		f.mu.Unlock()
+		return
+	}
+	f.lastError = ""
+	if err != nil {
+		f.lastError = err.Error()
+	}
+	if state != previous {
+		f.state = state
+		f.since = time.Now()
+	}
+	f.mu.Unlock()
+	if state == previous {
+		return
+	}
+
+	switch state {
+	case StateReady:
+		if previous == StateUpstreamDown {
+			f.logf("%s: upstream %s reachable again", f.Name, f.Upstream())
+		} else {
+			f.logf("%s: forwarding %s → %s", f.Name, f.Listen, f.Upstream())
+		}
+	case StateUpstreamDown:
+		f.logf("%s: upstream %s unreachable: %v", f.Name, f.Upstream(), err)
+	case StateListenFailed:
+		f.logf("%s: cannot listen on %s, retrying: %v", f.Name, f.Listen, err)
+	}
+}
+
+func (f *forwarder) status() Status {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	listen := f.Listen
+	if f.listener != nil {
+		listen = f.listener.Addr().String()
+	}
+	return Status{
+		Name:        f.Name,
+		Kind:        f.Kind(),
+		Listen:      listen,
+		Upstream:    f.Upstream(),
+		State:       f.state,
+		Error:       f.lastError,
+		Since:       f.since,
+		Active:      f.active.Load(),
+		Connections: f.connections.Load(),
+		Failures:    f.failures.Load(),
+		BytesIn:     f.bytesIn.Load(),
+		BytesOut:    f.bytesOut.Load(),
+	}
+}
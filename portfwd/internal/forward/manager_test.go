@@ -0,0 +1,204 @@
+package forward
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startEcho runs a TCP server echoing each connection until closed
+func startEcho(t *testing.T, addr string) net.Listener {
+	t.Helper()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return listener
+}
+
+// startManager validates config and runs it until the test ends
+func startManager(t *testing.T, config *Config) *Manager {
+	t.Helper()
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	manager := NewManager(config, t.Logf)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		manager.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Errorf("Manager did not stop")
+		}
+	})
+	return manager
+}
+
+// waitAddr waits for a forward to listen and returns its address
+func waitAddr(t *testing.T, m *Manager, name string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if addr := m.Addr(name); addr != nil {
+			return addr.String()
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Forward %s did not listen", name)
+	return ""
+}
+
+// roundTrip sends message through addr and returns what comes back once
+// the write side is closed
+func roundTrip(t *testing.T, addr, message string) string {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	conn.Write([]byte(message))
+	conn.(*net.TCPConn).CloseWrite()
+	reply, _ := io.ReadAll(conn)
+	return string(reply)
+}
+
+func findStatus(m *Manager, name string) Status {
+	for _, status := range m.Status() {
+		if status.Name == name {
+			return status
+		}
+	}
+	return Status{}
+}
+
+func TestManager_ForwardTCP(t *testing.T) {
+	echo := startEcho(t, "127.0.0.1:0")
+	manager := startManager(t, &Config{Forwards: []Forward{{Name: "echo", Listen: "127.0.0.1:0", Target: echo.Addr().String()}}})
+	addr := waitAddr(t, manager, "echo")
+
+	if reply := roundTrip(t, addr, "hello"); reply != "hello" {
+		t.Errorf("Expected the echo, got %q", reply)
+	}
+
+	status := findStatus(manager, "echo")
+	if status.State != StateReady || status.Connections != 1 || status.BytesIn != 5 || status.BytesOut != 5 || status.Listen != addr {
+		t.Errorf("Unexpected status %+v", status)
+	}
+}
+
+func TestManager_RetriesUpstream(t *testing.T) {
+	// Reserve a port for an upstream that starts after the client connects
+	reserved := startEcho(t, "127.0.0.1:0")
+	target := reserved.Addr().String()
+	reserved.Close()
+
+	manager := startManager(t, &Config{CheckInterval: "0", Forwards: []Forward{{Name: "late", Listen: "127.0.0.1:0", Target: target}}})
+	addr := waitAddr(t, manager, "late")
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		startEcho(t, target)
+	}()
+	if reply := roundTrip(t, addr, "waited"); reply != "waited" {
+		t.Errorf("Expected the connection to wait for the upstream, got %q", reply)
+	}
+}
+
+func TestManager_UpstreamDown(t *testing.T) {
+	reserved := startEcho(t, "127.0.0.1:0")
+	target := reserved.Addr().String()
+	reserved.Close()
+
+	manager := startManager(t, &Config{ConnectTimeout: "100ms", CheckInterval: "50ms", Forwards: []Forward{{Name: "down", Listen: "127.0.0.1:0", Target: target}}})
+	addr := waitAddr(t, manager, "down")
+
+	if reply := roundTrip(t, addr, "lost"); reply != "" {
+		t.Errorf("Expected the client to be closed, got %q", reply)
+	}
+	status := findStatus(manager, "down")
+	if status.State != StateUpstreamDown || status.Failures != 1 || !strings.Contains(status.Error, "connection refused") {
+		t.Errorf("Unexpected status %+v", status)
+	}
+
+	// The probe notices the upstream coming back
+	startEcho(t, target)
+	deadline := time.Now().Add(5 * time.Second)
+	for findStatus(manager, "down").State != StateReady && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status := findStatus(manager, "down"); status.State != StateReady || status.Error != "" {
+		t.Errorf("Expected the forward to recover, got %+v", status)
+	}
+}
+
+func TestManager_RetriesListen(t *testing.T) {
+	busy := startEcho(t, "127.0.0.1:0")
+	listen := busy.Addr().String()
+	echo := startEcho(t, "127.0.0.1:0")
+
+	manager := startManager(t, &Config{Forwards: []Forward{{Name: "busy", Listen: listen, Target: echo.Addr().String()}}})
+	deadline := time.Now().Add(5 * time.Second)
+	for findStatus(manager, "busy").State != StateListenFailed && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status := findStatus(manager, "busy"); !strings.Contains(status.Error, "address already in use") {
+		t.Errorf("Expected the listen failure, got %+v", status)
+	}
+
+	busy.Close()
+	waitAddr(t, manager, "busy")
+	if reply := roundTrip(t, listen, "free"); reply != "free" {
+		t.Errorf("Expected the forward once the port was free, got %q", reply)
+	}
+}
+
+func TestManager_ForwardSSH(t *testing.T) {
+	// A stand-in ssh relaying to the target itself
+	path := filepath.Join(t.TempDir(), "ssh")
+	os.WriteFile(path, []byte("#!/bin/sh\nexec tr a-z A-Z\n"), 0o755)
+	previous := sshCommand
+	sshCommand = path
+	t.Cleanup(func() { sshCommand = previous })
+
+	manager := startManager(t, &Config{Forwards: []Forward{{Name: "ssh", Listen: "127.0.0.1:0", Target: "db:5432", SSH: "bastion"}}})
+	if reply := roundTrip(t, waitAddr(t, manager, "ssh"), "select"); reply != "SELECT" {
+		t.Errorf("Expected the reply through ssh, got %q", reply)
+	}
+}
+
+func TestManager_CommandFails(t *testing.T) {
+	manager := startManager(t, &Config{ConnectTimeout: "100ms", Forwards: []Forward{{
+		Name: "broken", Listen: "127.0.0.1:0", Command: []string{"sh", "-c", "echo 'pod not found' >&2; exit 1"},
+	}}})
+
+	roundTrip(t, waitAddr(t, manager, "broken"), "x")
+	if status := findStatus(manager, "broken"); status.State != StateUpstreamDown || status.Error != "sh exited with status 1: pod not found" {
+		t.Errorf("Unexpected status %+v", status)
+	}
+}
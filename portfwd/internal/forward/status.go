@@ -0,0 +1,56 @@
+package forward
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"text/tabwriter"
+	"time"
+)
+
+// StatusHandler serves the manager's status as JSON
+func StatusHandler(m *Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string][]Status{"forwards": m.Status()})
+	})
+}
+
+// WriteStatus prints statuses as a table, with how long each has been in
+// its state as of now
+func WriteStatus(w io.Writer, statuses []Status, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATE\tLISTEN\tUPSTREAM\tACTIVE\tCONNECTIONS\tFAILURES\tTRAFFIC")
+	for _, s := range statuses {
+		state := fmt.Sprintf("%s for %s", s.State, now.Sub(s.Since).Round(time.Second))
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s in, %s out\n",
+			s.Name, state, s.Listen, s.Upstream, s.Active, s.Connections, s.Failures, formatBytes(s.BytesIn), formatBytes(s.BytesOut))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, s := range statuses {
+		if s.Error != "" {
+			fmt.Fprintf(w, "%s: %s\n", s.Name, s.Error)
+		}
+	}
+	return nil
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fGB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
@@ -0,0 +1,45 @@
+package forward
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusHandler(t *testing.T) {
+	echo := startEcho(t, "127.0.0.1:0")
+	manager := startManager(t, &Config{Forwards: []Forward{{Name: "echo", Listen: "127.0.0.1:0", Target: echo.Addr().String()}}})
+	waitAddr(t, manager, "echo")
+
+	rec := httptest.NewRecorder()
+	StatusHandler(manager).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	var body struct {
+		Forwards []Status `json:"forwards"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || len(body.Forwards) != 1 || body.Forwards[0].Kind != KindTCP {
+		t.Errorf("Unexpected status response %s", rec.Body)
+	}
+}
+
+func TestWriteStatus(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	statuses := []Status{
+		{Name: "postgres", Listen: "127.0.0.1:5432", Upstream: "db:5432 via ssh bastion", State: StateReady, Since: now.Add(-90 * time.Second), Connections: 3, BytesIn: 2048, BytesOut: 512},
+		{Name: "redis", Listen: "127.0.0.1:6379", Upstream: "container redis port 6379", State: StateUpstreamDown, Error: "container redis is not running", Since: now.Add(-5 * time.Second), Failures: 2},
+	}
+
+	var out strings.Builder
+	if err := WriteStatus(&out, statuses, now); err != nil {
+		t.Fatalf("WriteStatus failed: %v", err)
+	}
+	want := `NAME      STATE                 LISTEN          UPSTREAM                   ACTIVE  CONNECTIONS  FAILURES  TRAFFIC
+postgres  ready for 1m30s       127.0.0.1:5432  db:5432 via ssh bastion    0       3            0         2.0KB in, 512B out
+redis     upstream down for 5s  127.0.0.1:6379  container redis port 6379  0       0            2         0B in, 0B out
+redis: container redis is not running
+`
+	if out.String() != want {
+		t.Errorf("Unexpected table:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
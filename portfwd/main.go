@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"local-dev-tools/portfwd/internal/forward"
+)
+
+const defaultStatusAddr = "127.0.0.1:7071"
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "status" {
+		os.Exit(runStatus(os.Args[2:]))
+	}
+	os.Exit(run(os.Args[1:]))
+}
+
+// run maintains the configured forwards until interrupted and returns the
+// process exit code
+func run(args []string) int {
+	fs := flag.NewFlagSet("portfwd", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n       %s status [--status-addr addr]\n\nOptions:\n", os.Args[0], os.Args[0])
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", "portfwd.yaml", "Path to the forwards file (YAML or JSON)")
+	statusAddr := fs.String("status-addr", defaultStatusAddr, "Address to serve the JSON status on, read by the status command (empty to disable)")
+	fs.Parse(args)
+
+	config, err := forward.LoadConfig(*configPath)
+	if err != nil {
+		log.Printf("Error loading config: %v", err)
+		return 2
+	}
+
+	manager := forward.NewManager(config, log.Printf)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if *statusAddr != "" {
+		listener, err := net.Listen("tcp", *statusAddr)
+		if err != nil {
+			// Another instance may hold the port; forwarding still works
+			log.Printf("Warning: status not served: %v", err)
+		} else {
+			server := &http.Server{Handler: forward.StatusHandler(manager)}
+			go server.Serve(listener)
+			defer server.Close()
+		}
+	}
+
+	fmt.Printf("Maintaining %d forwards from %s\n", len(config.Forwards), *configPath)
+	manager.Run(ctx)
+	fmt.Println("\nShutting down...")
+	return 0
+}
+
+// runStatus prints the status of a running instance
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("portfwd status", flag.ExitOnError)
+	statusAddr := fs.String("status-addr", defaultStatusAddr, "Status address of the running instance")
+	fs.Parse(args)
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("http://" + *statusAddr + "/")
+	if err != nil {
+		log.Printf("Error: no portfwd status at %s: %v", *statusAddr, err)
+		return 3
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Forwards []forward.Status `json:"forwards"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		log.Printf("Error: invalid status from %s: %v", *statusAddr, err)
+		return 3
+	}
+	if err := forward.WriteStatus(os.Stdout, body.Forwards, time.Now()); err != nil {
+		log.Printf("Error: %v", err)
+		return 3
+	}
+	for _, status := range body.Forwards {
+		if status.State != forward.StateReady {
+			return 1
+		}
+	}
+	return 0
+}
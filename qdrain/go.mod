@@ -0,0 +1,12 @@
+module local-dev-tools/qdrain
+
+go 1.21
+
+require local-dev-tools/dynamic-request-scheduler v0.0.0
+
+require (
+	github.com/robfig/cron/v3 v3.0.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace local-dev-tools/dynamic-request-scheduler => ../dynamic-request-scheduler
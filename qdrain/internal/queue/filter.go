@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Filter selects messages by body, key and headers. Every condition that is
+// set must match; a nil Filter matches everything.
+type Filter struct {
+	// Grep matches anywhere in the body
+	Grep *regexp.Regexp
+
+	// Key is a glob matched against the whole key, such as order.*
+	Key string
+
+	// Headers are name=glob conditions; names are not case-sensitive
+	Headers []HeaderMatch
+}
+
+// HeaderMatch is a header that must be present with a value matching Pattern
+type HeaderMatch struct {
+	Name    string
+	Pattern string
+}
+
+// ParseHeaderMatch reads a name=glob condition
+func ParseHeaderMatch(s string) (HeaderMatch, error) {
+	name, pattern, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return HeaderMatch{}, fmt.Errorf("invalid header filter %q: must be name=value", s)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return HeaderMatch{}, fmt.Errorf("invalid header filter %q: %w", s, err)
+	}
+	return HeaderMatch{Name: name, Pattern: pattern}, nil
+}
+
+// Validate checks the globs
+func (f *Filter) Validate() error {
+	if _, err := path.Match(f.Key, ""); err != nil {
+		return fmt.Errorf("invalid key pattern %q: %w", f.Key, err)
+	}
+	return nil
+}
+
+// Match reports whether m passes every condition
+func (f *Filter) Match(m *Message) bool {
+	if f == nil {
+		return true
+	}
+	if f.Key != "" {
+		if ok, _ := path.Match(f.Key, m.Key); !ok {
+			return false
+		}
+	}
+	for _, h := range f.Headers {
+		if !h.match(m.Headers) {
+			return false
+		}
+	}
+	return f.Grep == nil || f.Grep.Match(m.Body)
+}
+
+func (h HeaderMatch) match(headers map[string]string) bool {
+	for name, value := range headers {
+		if strings.EqualFold(name, h.Name) {
+			if ok, _ := path.Match(h.Pattern, value); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package queue
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFilter_Match(t *testing.T) {
+	m := &Message{
+		Key:     "order.created",
+		Headers: map[string]string{"Source": "scheduler", "tenant": "acme"},
+		Body:    []byte(`{"id":42,"status":"paid"}`),
+	}
+
+	tests := []struct {
+		name   string
+		filter *Filter
+		want   bool
+	}{
+		{name: "nil", filter: nil, want: true},
+		{name: "empty", filter: &Filter{}, want: true},
+		{name: "grep", filter: &Filter{Grep: regexp.MustCompile(`"status":"paid"`)}, want: true},
+		{name: "grep miss", filter: &Filter{Grep: regexp.MustCompile(`refunded`)}, want: false},
+		{name: "key glob", filter: &Filter{Key: "order.*"}, want: true},
+		{name: "key must match whole", filter: &Filter{Key: "order"}, want: false},
+		{name: "header ignores name case", filter: &Filter{Headers: []HeaderMatch{{Name: "source", Pattern: "sched*"}}}, want: true},
+		{name: "header value", filter: &Filter{Headers: []HeaderMatch{{Name: "tenant", Pattern: "globex"}}}, want: false},
+		{name: "header missing", filter: &Filter{Headers: []HeaderMatch{{Name: "trace", Pattern: "*"}}}, want: false},
+		{name: "all", filter: &Filter{
+			Grep:    regexp.MustCompile(`42`),
+			Key:     "order.*",
+			Headers: []HeaderMatch{{Name: "tenant", Pattern: "acme"}},
+		}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(m); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHeaderMatch(t *testing.T) {
+	got, err := ParseHeaderMatch("content_type=application/*")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got != (HeaderMatch{Name: "content_type", Pattern: "application/*"}) {
+		t.Errorf("Got %+v", got)
+	}
+
+	for _, s := range []string{"content_type", "=json", "tenant=[acme"} {
+		if _, err := ParseHeaderMatch(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestFilter_Validate(t *testing.T) {
+	if err := (&Filter{Key: "order.*"}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := (&Filter{Key: "order.[a"}).Validate(); err == nil {
+		t.Error("Expected an error for a malformed glob")
+	}
+}
@@ -0,0 +1,132 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Printer writes messages as readable text or as JSON lines
+type Printer struct {
+	w      io.Writer
+	asJSON bool
+}
+
+// NewPrinter creates a printer writing to w
+func NewPrinter(w io.Writer, asJSON bool) *Printer {
+	return &Printer{w: w, asJSON: asJSON}
+}
+
+// Print writes one message
+func (p *Printer) Print(m *Message) error {
+	if p.asJSON {
+		return p.printJSON(m)
+	}
+
+	var b strings.Builder
+	b.WriteString(position(m))
+	if !m.Time.IsZero() {
+		b.WriteString("  " + m.Time.Local().Format("2006-01-02 15:04:05.000"))
+	}
+	if m.Key != "" {
+		b.WriteString("  key=" + m.Key)
+	}
+	if m.ID != "" {
+		b.WriteString("  id=" + m.ID)
+	}
+	if m.Redelivered {
+		b.WriteString("  redelivered")
+	}
+	b.WriteByte('\n')
+
+	names := make([]string, 0, len(m.Headers))
+	for name := range m.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %s: %s\n", name, m.Headers[name])
+	}
+
+	b.WriteString(formatBody(m.Body))
+	b.WriteString("\n\n")
+	_, err := io.WriteString(p.w, b.String())
+	return err
+}
+
+// position locates a message: topic[partition]@offset for Kafka records and
+// the queue name otherwise
+func position(m *Message) string {
+	if m.Partition != nil {
+		return fmt.Sprintf("%s[%d]@%d", m.Queue, *m.Partition, m.Offset)
+	}
+	return m.Queue
+}
+
+// formatBody indents the body under the message, laying out JSON and
+// summarising binary data
+func formatBody(body []byte) string {
+	switch {
+	case len(body) == 0:
+		return "  (empty)"
+	case !utf8.Valid(body):
+		return fmt.Sprintf("  <%d bytes of binary data>", len(body))
+	}
+
+	var indented bytes.Buffer
+	if json.Valid(body) && json.Indent(&indented, body, "  ", "  ") == nil {
+		return "  " + indented.String()
+	}
+	return "  " + strings.ReplaceAll(strings.TrimRight(string(body), "\n"), "\n", "\n  ")
+}
+
+// jsonMessage is the JSON form of a message. Bodies that are not UTF-8 are
+// base64-encoded.
+type jsonMessage struct {
+	Queue       string            `json:"queue"`
+	Partition   *int32            `json:"partition,omitempty"`
+	Offset      *int64            `json:"offset,omitempty"`
+	ID          string            `json:"id,omitempty"`
+	Key         string            `json:"key,omitempty"`
+	Time        *time.Time        `json:"time,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Redelivered bool              `json:"redelivered,omitempty"`
+	Body        *string           `json:"body,omitempty"`
+	BodyBase64  []byte            `json:"body_base64,omitempty"`
+}
+
+func (p *Printer) printJSON(m *Message) error {
+	out := jsonMessage{
+		Queue:       m.Queue,
+		Partition:   m.Partition,
+		ID:          m.ID,
+		Key:         m.Key,
+		Headers:     m.Headers,
+		Redelivered: m.Redelivered,
+	}
+	if m.Partition != nil {
+		out.Offset = &m.Offset
+	}
+	if !m.Time.IsZero() {
+		t := m.Time.UTC()
+		out.Time = &t
+	}
+	if utf8.Valid(m.Body) {
+		body := string(m.Body)
+		out.Body = &body
+	} else {
+		out.BodyBase64 = m.Body
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	_, err = p.w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestPrinter_Text(t *testing.T) {
+	partition := int32(1)
+	sent := time.Date(2024, 5, 1, 12, 30, 0, 0, time.Local)
+	var buf bytes.Buffer
+	p := NewPrinter(&buf, false)
+
+	p.Print(&Message{
+		Queue:     "orders",
+		Partition: &partition,
+		Offset:    7,
+		Key:       "order-1",
+		Time:      sent,
+		Headers:   map[string]string{"tenant": "acme", "source": "scheduler"},
+		Body:      []byte(`{"id":1,"items":[2]}`),
+	})
+	p.Print(&Message{Queue: "orders", ID: "m-1", Redelivered: true, Body: []byte("line one\nline two\n")})
+	p.Print(&Message{Queue: "orders"})
+	p.Print(&Message{Queue: "orders", Body: []byte{0xff, 0xfe, 0x00}})
+
+	want := `orders[1]@7  2024-05-01 12:30:00.000  key=order-1
+  source: scheduler
+  tenant: acme
+  {
+    "id": 1,
+    "items": [
+      2
+    ]
+  }
+
+orders  id=m-1  redelivered
+  line one
+  line two
+
+orders
+  (empty)
+
+orders
+  <3 bytes of binary data>
+
+`
+	if buf.String() != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", buf.String(), want)
+	}
+}
+
+func TestPrinter_JSON(t *testing.T) {
+	partition := int32(0)
+	var buf bytes.Buffer
+	p := NewPrinter(&buf, true)
+
+	p.Print(&Message{
+		Queue:     "orders",
+		Partition: &partition,
+		Offset:    3,
+		Time:      time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		Body:      []byte(`{"id":1}`),
+	})
+	p.Print(&Message{Queue: "orders", ID: "m-1", Headers: map[string]string{"tenant": "acme"}, Body: []byte{0xff}})
+
+	want := `{"queue":"orders","partition":0,"offset":3,"time":"2024-05-01T12:30:00Z","body":"{\"id\":1}"}
+{"queue":"orders","id":"m-1","headers":{"tenant":"acme"},"body_base64":"/w=="}
+`
+	if buf.String() != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", buf.String(), want)
+	}
+}
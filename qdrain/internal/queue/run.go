@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// Options control a run over a source
+type Options struct {
+	Filter *Filter
+
+	// Max stops the run after this many matching messages; 0 reads on
+	// until the queue is empty
+	Max int
+
+	// Drain removes the matching messages from the queue; the others are
+	// left in place
+	Drain bool
+
+	// Follow keeps waiting for new messages until the context ends, polling
+	// every Wait
+	Follow bool
+	Wait   time.Duration
+}
+
+// Stats counts the messages of a run
+type Stats struct {
+	Read    int
+	Matched int
+	Removed int
+}
+
+// Run reads messages from src, passing those that match to emit and removing
+// them when draining. It stops when the queue is empty, Max is reached, the
+// context ends or emit fails.
+func Run(ctx context.Context, src Source, opts Options, emit func(*Message) error) (Stats, error) {
+	var stats Stats
+	var wait time.Duration
+	if opts.Follow {
+		wait = opts.Wait
+	}
+
+	for ctx.Err() == nil {
+		messages, err := src.Next(ctx, wait)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return stats, err
+		}
+		if len(messages) == 0 && !opts.Follow {
+			break
+		}
+
+		for _, m := range messages {
+			stats.Read++
+			if !opts.Filter.Match(m) {
+				continue
+			}
+			stats.Matched++
+			if err := emit(m); err != nil {
+				return stats, err
+			}
+			if opts.Drain {
+				if err := src.Remove(ctx, m); err != nil {
+					return stats, err
+				}
+				stats.Removed++
+			}
+			if opts.Max > 0 && stats.Matched >= opts.Max {
+				return stats, nil
+			}
+		}
+	}
+	return stats, nil
+}
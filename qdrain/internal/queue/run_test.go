@@ -0,0 +1,137 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// fakeSource returns batches of messages in turn, then nothing
+type fakeSource struct {
+	batches [][]*Message
+	polls   int
+	waits   []time.Duration
+	removed []string
+}
+
+func (s *fakeSource) Next(ctx context.Context, wait time.Duration) ([]*Message, error) {
+	s.polls++
+	s.waits = append(s.waits, wait)
+	if len(s.batches) == 0 {
+		return nil, ctx.Err()
+	}
+	batch := s.batches[0]
+	s.batches = s.batches[1:]
+	return batch, nil
+}
+
+func (s *fakeSource) Remove(ctx context.Context, m *Message) error {
+	s.removed = append(s.removed, m.ID)
+	return nil
+}
+
+func (s *fakeSource) Close() error { return nil }
+
+func messages(ids ...string) []*Message {
+	var batch []*Message
+	for _, id := range ids {
+		batch = append(batch, &Message{ID: id, Body: []byte("message " + id)})
+	}
+	return batch
+}
+
+func collect(ids *[]string) func(*Message) error {
+	return func(m *Message) error {
+		*ids = append(*ids, m.ID)
+		return nil
+	}
+}
+
+func TestRun_ReadsUntilEmpty(t *testing.T) {
+	src := &fakeSource{batches: [][]*Message{messages("1", "2"), messages("3")}}
+	var emitted []string
+
+	stats, err := Run(context.Background(), src, Options{Wait: time.Second}, collect(&emitted))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fmt.Sprint(emitted) != "[1 2 3]" || stats != (Stats{Read: 3, Matched: 3}) {
+		t.Errorf("Got %v, %+v", emitted, stats)
+	}
+	if len(src.removed) != 0 {
+		t.Errorf("Removed %v without --drain", src.removed)
+	}
+	for _, wait := range src.waits {
+		if wait != 0 {
+			t.Errorf("Waited %v without following", wait)
+		}
+	}
+}
+
+func TestRun_DrainsMatching(t *testing.T) {
+	src := &fakeSource{batches: [][]*Message{messages("1", "2", "3")}}
+	var emitted []string
+	opts := Options{Filter: &Filter{Grep: regexp.MustCompile(`[13]$`)}, Drain: true}
+
+	stats, err := Run(context.Background(), src, opts, collect(&emitted))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fmt.Sprint(src.removed) != "[1 3]" || stats != (Stats{Read: 3, Matched: 2, Removed: 2}) {
+		t.Errorf("Got removed %v, %+v", src.removed, stats)
+	}
+}
+
+func TestRun_StopsAtMax(t *testing.T) {
+	src := &fakeSource{batches: [][]*Message{messages("1", "2"), messages("3")}}
+	var emitted []string
+
+	stats, err := Run(context.Background(), src, Options{Max: 2, Drain: true}, collect(&emitted))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fmt.Sprint(emitted) != "[1 2]" || src.polls != 1 || stats.Removed != 2 {
+		t.Errorf("Got %v after %d polls, %+v", emitted, src.polls, stats)
+	}
+}
+
+func TestRun_FollowUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	src := &fakeSource{batches: [][]*Message{messages("1"), nil, nil, messages("2")}}
+	var emitted []string
+	emit := func(m *Message) error {
+		emitted = append(emitted, m.ID)
+		if m.ID == "2" {
+			cancel()
+		}
+		return nil
+	}
+
+	stats, err := Run(ctx, src, Options{Follow: true, Wait: time.Second}, emit)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fmt.Sprint(emitted) != "[1 2]" || stats.Read != 2 {
+		t.Errorf("Got %v, %+v", emitted, stats)
+	}
+	if src.waits[0] != time.Second {
+		t.Errorf("Waited %v per poll, want 1s", src.waits[0])
+	}
+}
+
+func TestRun_EmitError(t *testing.T) {
+	src := &fakeSource{batches: [][]*Message{messages("1", "2")}}
+	broken := errors.New("broken pipe")
+
+	stats, err := Run(context.Background(), src, Options{Drain: true}, func(*Message) error { return broken })
+	if !errors.Is(err, broken) {
+		t.Errorf("Got error %v, want %v", err, broken)
+	}
+	if len(src.removed) != 0 || stats.Matched != 1 {
+		t.Errorf("Removed %v after failing to show it, %+v", src.removed, stats)
+	}
+}
@@ -0,0 +1,361 @@
+// Package queue reads messages from Kafka topics, AMQP queues and SQS queues
+// through one interface, so they can be shown, filtered, drained and purged
+// the same way.
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/queues"
+)
+
+// Kinds of queue
+const (
+	KindKafka = "kafka"
+	KindAMQP  = "amqp"
+	KindSQS   = "sqs"
+)
+
+// clientID identifies qdrain to brokers
+const clientID = "qdrain"
+
+// sqsAccount is the account ID local emulators use in queue URLs
+const sqsAccount = "000000000000"
+
+// peekVisibility is how long SQS messages that were read but not drained stay
+// hidden, so a run does not see them twice. They are made visible again when
+// the source closes.
+const peekVisibility = 60 * time.Second
+
+// ErrNotRemovable is returned when draining a Kafka topic, whose records can
+// only be deleted all at once
+var ErrNotRemovable = errors.New("kafka records cannot be removed one at a time; use --purge to delete a topic's records")
+
+// Target names a queue, such as kafka:orders, amqp:orders or sqs:orders
+type Target struct {
+	Kind string
+	Name string
+}
+
+func (t Target) String() string {
+	return t.Kind + ":" + t.Name
+}
+
+// ParseTarget reads a <kind>:<name> argument. SQS names may also be full
+// queue URLs.
+func ParseTarget(arg string) (Target, error) {
+	kind, name, ok := strings.Cut(arg, ":")
+	if !ok || name == "" {
+		return Target{}, fmt.Errorf("invalid queue %q: must be kafka:<topic>, amqp:<queue> or sqs:<queue>", arg)
+	}
+	switch kind {
+	case KindKafka, KindAMQP, KindSQS:
+		return Target{Kind: kind, Name: name}, nil
+	default:
+		return Target{}, fmt.Errorf("invalid queue %q: unknown kind %q (want kafka, amqp or sqs)", arg, kind)
+	}
+}
+
+// Connection holds where each kind of broker is and how long calls may take
+type Connection struct {
+	// Brokers are Kafka bootstrap addresses
+	Brokers []string
+
+	AMQPURL string
+
+	// SQSEndpoint is the base of SQS queue URLs given by name; Region
+	// defaults to AWS_REGION, then us-east-1
+	SQSEndpoint string
+	Region      string
+
+	Timeout time.Duration
+}
+
+// QueueURL returns the SQS queue URL for a name or URL
+func (c Connection) QueueURL(name string) string {
+	if strings.Contains(name, "://") {
+		return name
+	}
+	return strings.TrimSuffix(c.SQSEndpoint, "/") + "/" + sqsAccount + "/" + name
+}
+
+// Message is a message read from any kind of queue
+type Message struct {
+	Queue string
+
+	// Partition and Offset locate Kafka records
+	Partition *int32
+	Offset    int64
+
+	// ID is the SQS message ID or AMQP message-id property
+	ID string
+
+	// Key is the Kafka record key, AMQP routing key or SQS message group ID
+	Key string
+
+	Time time.Time
+
+	// Headers are Kafka record headers, AMQP headers and properties, or SQS
+	// message attributes
+	Headers map[string]string
+
+	Body []byte
+
+	// Redelivered is set for AMQP messages taken before and returned, and
+	// SQS messages received before
+	Redelivered bool
+
+	handle string
+}
+
+// Source reads messages from one queue
+type Source interface {
+	// Next returns the next messages, waiting up to wait for some to arrive.
+	// An empty result means none were available.
+	Next(ctx context.Context, wait time.Duration) ([]*Message, error)
+
+	// Remove deletes a message returned by Next from the queue
+	Remove(ctx context.Context, m *Message) error
+
+	// Close releases the messages read but not removed
+	Close() error
+}
+
+// Open connects to the target's broker. Kafka topics are read from start:
+// queues.KafkaOffsetEarliest, queues.KafkaOffsetLatest or an offset.
+func Open(ctx context.Context, target Target, conn Connection, start int64) (Source, error) {
+	switch target.Kind {
+	case KindKafka:
+		consumer, err := queues.NewKafkaClient(conn.Timeout, clientID).NewConsumer(ctx, conn.Brokers, target.Name, start)
+		if err != nil {
+			return nil, err
+		}
+		return &kafkaSource{consumer: consumer}, nil
+	case KindAMQP:
+		consumer, err := queues.NewAMQPClient(conn.Timeout, clientID).NewConsumer(ctx, conn.AMQPURL, target.Name)
+		if err != nil {
+			return nil, err
+		}
+		return &amqpSource{queue: target.Name, consumer: consumer, held: make(map[string]*queues.AMQPDelivery)}, nil
+	case KindSQS:
+		return &sqsSource{
+			client:   queues.NewSQSClient(conn.Timeout),
+			queue:    target.Name[strings.LastIndex(target.Name, "/")+1:],
+			queueURL: conn.QueueURL(target.Name),
+			region:   conn.Region,
+			held:     make(map[string]string),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown queue kind %q", target.Kind)
+	}
+}
+
+// Purge deletes every message in the target and returns how many there were,
+// or -1 when the broker does not say
+func Purge(ctx context.Context, target Target, conn Connection) (int64, error) {
+	switch target.Kind {
+	case KindKafka:
+		return queues.NewKafkaClient(conn.Timeout, clientID).DeleteRecords(ctx, conn.Brokers, target.Name)
+	case KindAMQP:
+		n, err := queues.NewAMQPClient(conn.Timeout, clientID).Purge(ctx, conn.AMQPURL, target.Name)
+		return int64(n), err
+	case KindSQS:
+		return -1, queues.NewSQSClient(conn.Timeout).PurgeQueue(ctx, conn.Region, conn.QueueURL(target.Name))
+	default:
+		return 0, fmt.Errorf("unknown queue kind %q", target.Kind)
+	}
+}
+
+// kafkaSource reads a topic's partitions without a consumer group
+type kafkaSource struct {
+	consumer *queues.KafkaConsumer
+}
+
+func (s *kafkaSource) Next(ctx context.Context, wait time.Duration) ([]*Message, error) {
+	records, err := s.consumer.Poll(ctx, wait)
+	messages := make([]*Message, len(records))
+	for i := range records {
+		messages[i] = fromRecord(&records[i])
+	}
+	return messages, err
+}
+
+func (s *kafkaSource) Remove(ctx context.Context, m *Message) error {
+	return ErrNotRemovable
+}
+
+func (s *kafkaSource) Close() error {
+	return s.consumer.Close()
+}
+
+func fromRecord(r *queues.KafkaRecord) *Message {
+	partition := r.Partition
+	m := &Message{
+		Queue:     r.Topic,
+		Partition: &partition,
+		Offset:    r.Offset,
+		Time:      r.Timestamp,
+		Headers:   r.Headers,
+		Body:      r.Value,
+	}
+	if r.Key != nil {
+		m.Key = string(r.Key)
+	}
+	return m
+}
+
+// amqpSource takes messages one at a time, holding them unacknowledged until
+// they are removed or the source closes
+type amqpSource struct {
+	queue    string
+	consumer *queues.AMQPConsumer
+
+	// held maps each message's handle to its delivery, for Remove
+	held map[string]*queues.AMQPDelivery
+	n    int
+}
+
+func (s *amqpSource) Next(ctx context.Context, wait time.Duration) ([]*Message, error) {
+	delivery, err := s.consumer.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		// basic.get does not wait, so pause before the caller asks again
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(wait):
+			}
+		}
+		return nil, nil
+	}
+
+	s.n++
+	m := fromDelivery(s.queue, delivery)
+	m.handle = fmt.Sprint(s.n)
+	s.held[m.handle] = delivery
+	return []*Message{m}, nil
+}
+
+func (s *amqpSource) Remove(ctx context.Context, m *Message) error {
+	delivery, ok := s.held[m.handle]
+	if !ok {
+		return fmt.Errorf("message %s was not read from %s", m.handle, s.queue)
+	}
+	delete(s.held, m.handle)
+	return s.consumer.Ack(delivery)
+}
+
+func (s *amqpSource) Close() error {
+	return s.consumer.Close()
+}
+
+// fromDelivery converts an AMQP delivery, adding the properties worth
+// filtering on to the headers
+func fromDelivery(queue string, d *queues.AMQPDelivery) *Message {
+	p := d.Properties
+	m := &Message{
+		Queue:       queue,
+		ID:          p.MessageID,
+		Key:         d.RoutingKey,
+		Time:        p.Timestamp,
+		Headers:     make(map[string]string),
+		Body:        d.Body,
+		Redelivered: d.Redelivered,
+	}
+	for name, value := range p.Headers {
+		m.Headers[name] = value
+	}
+	for name, value := range map[string]string{
+		"content_type":   p.ContentType,
+		"correlation_id": p.CorrelationID,
+		"reply_to":       p.ReplyTo,
+		"type":           p.Type,
+		"app_id":         p.AppID,
+	} {
+		if value != "" {
+			m.Headers[name] = value
+		}
+	}
+	if len(m.Headers) == 0 {
+		m.Headers = nil
+	}
+	return m
+}
+
+// sqsSource receives messages, keeping those it does not remove hidden until
+// it closes
+type sqsSource struct {
+	client   *queues.SQSClient
+	queue    string
+	queueURL string
+	region   string
+
+	// held maps the IDs of messages read but not removed to their latest
+	// receipt handles
+	held map[string]string
+}
+
+func (s *sqsSource) Next(ctx context.Context, wait time.Duration) ([]*Message, error) {
+	// Short polls sample a subset of SQS servers and can miss messages, so
+	// always wait at least a second
+	wait = max(wait, time.Second)
+	received, err := s.client.ReceiveMessages(ctx, s.region, s.queueURL, 10, wait, peekVisibility)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*Message
+	for i := range received {
+		r := &received[i]
+		_, seen := s.held[r.MessageID]
+		s.held[r.MessageID] = r.ReceiptHandle
+		if seen {
+			continue
+		}
+		messages = append(messages, fromSQS(s.queue, r))
+	}
+	return messages, nil
+}
+
+func (s *sqsSource) Remove(ctx context.Context, m *Message) error {
+	handle, ok := s.held[m.ID]
+	if !ok {
+		return fmt.Errorf("message %s was not read from %s", m.ID, s.queue)
+	}
+	if err := s.client.DeleteMessage(ctx, s.region, s.queueURL, handle); err != nil {
+		return err
+	}
+	delete(s.held, m.ID)
+	return nil
+}
+
+// Close makes the messages that were read but not removed visible again
+func (s *sqsSource) Close() error {
+	ctx := context.Background()
+	var firstErr error
+	for id, handle := range s.held {
+		if err := s.client.ChangeMessageVisibility(ctx, s.region, s.queueURL, handle, 0); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to release message %s: %w", id, err)
+		}
+	}
+	s.held = make(map[string]string)
+	return firstErr
+}
+
+func fromSQS(queue string, r *queues.SQSMessage) *Message {
+	return &Message{
+		Queue:       queue,
+		ID:          r.MessageID,
+		Key:         r.GroupID,
+		Time:        r.SentAt,
+		Headers:     r.Attributes,
+		Body:        []byte(r.Body),
+		Redelivered: r.ReceiveCount > 1,
+	}
+}
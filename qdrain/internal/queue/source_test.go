@@ -0,0 +1,110 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/queues"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    Target
+		wantErr bool
+	}{
+		{arg: "kafka:orders", want: Target{Kind: KindKafka, Name: "orders"}},
+		{arg: "amqp:orders.created", want: Target{Kind: KindAMQP, Name: "orders.created"}},
+		{arg: "sqs:http://localhost:4566/000000000000/orders", want: Target{Kind: KindSQS, Name: "http://localhost:4566/000000000000/orders"}},
+		{arg: "orders", wantErr: true},
+		{arg: "kafka:", wantErr: true},
+		{arg: "nats:orders", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTarget(tt.arg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTarget(%q) error = %v, wantErr %v", tt.arg, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTarget(%q) = %+v, want %+v", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestConnection_QueueURL(t *testing.T) {
+	conn := Connection{SQSEndpoint: "http://localhost:4566/"}
+	if got := conn.QueueURL("orders"); got != "http://localhost:4566/000000000000/orders" {
+		t.Errorf("Got %q", got)
+	}
+	url := "https://sqs.eu-west-1.amazonaws.com/123456789012/orders"
+	if got := conn.QueueURL(url); got != url {
+		t.Errorf("Got %q, want the URL unchanged", got)
+	}
+}
+
+func TestFromRecord(t *testing.T) {
+	sent := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := fromRecord(&queues.KafkaRecord{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    41,
+		Timestamp: sent,
+		Key:       []byte("order-1"),
+		Value:     []byte(`{"id":1}`),
+		Headers:   map[string]string{"source": "scheduler"},
+	})
+
+	if m.Queue != "orders" || m.Partition == nil || *m.Partition != 2 || m.Offset != 41 {
+		t.Errorf("Got position %s[%v]@%d", m.Queue, m.Partition, m.Offset)
+	}
+	if m.Key != "order-1" || !m.Time.Equal(sent) || m.Headers["source"] != "scheduler" || string(m.Body) != `{"id":1}` {
+		t.Errorf("Got %+v", m)
+	}
+}
+
+func TestFromDelivery(t *testing.T) {
+	m := fromDelivery("orders", &queues.AMQPDelivery{
+		RoutingKey:  "order.created",
+		Redelivered: true,
+		Properties: queues.AMQPProperties{
+			ContentType:   "application/json",
+			CorrelationID: "abc",
+			MessageID:     "m-1",
+			Headers:       map[string]string{"source": "scheduler"},
+		},
+		Body: []byte("{}"),
+	})
+
+	if m.Queue != "orders" || m.Key != "order.created" || m.ID != "m-1" || !m.Redelivered {
+		t.Errorf("Got %+v", m)
+	}
+	want := map[string]string{"source": "scheduler", "content_type": "application/json", "correlation_id": "abc"}
+	if len(m.Headers) != len(want) {
+		t.Errorf("Got headers %v, want %v", m.Headers, want)
+	}
+	for name, value := range want {
+		if m.Headers[name] != value {
+			t.Errorf("Got header %s = %q, want %q", name, m.Headers[name], value)
+		}
+	}
+
+	if m := fromDelivery("orders", &queues.AMQPDelivery{}); m.Headers != nil {
+		t.Errorf("Got headers %v for a delivery without properties", m.Headers)
+	}
+}
+
+func TestFromSQS(t *testing.T) {
+	m := fromSQS("orders", &queues.SQSMessage{
+		MessageID:    "m-1",
+		Body:         "hello",
+		Attributes:   map[string]string{"source": "scheduler"},
+		ReceiveCount: 2,
+		GroupID:      "customer-1",
+	})
+
+	if m.ID != "m-1" || m.Key != "customer-1" || string(m.Body) != "hello" || !m.Redelivered || m.Headers["source"] != "scheduler" {
+		t.Errorf("Got %+v", m)
+	}
+}
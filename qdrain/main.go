@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/queues"
+	"local-dev-tools/qdrain/internal/queue"
+)
+
+// Process exit codes
+const (
+	exitOK = 0
+	// exitNoMatch means no message matched, like grep
+	exitNoMatch = 1
+	// exitUsage means the flags were invalid
+	exitUsage = 2
+	// exitFailed means the broker could not be reached or failed a call
+	exitFailed = 3
+)
+
+// pollInterval is how long --follow waits for new messages per poll
+const pollInterval = time.Second
+
+// stringList collects a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run shows, drains or purges one queue and returns the process exit code
+func run(args []string) int {
+	fs := flag.NewFlagSet("qdrain", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] kafka:<topic> | amqp:<queue> | sqs:<queue>\n\nShows the messages in a queue without removing them, unless --drain or --purge is given.\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	brokers := fs.String("brokers", "localhost:9092", "Comma-separated Kafka bootstrap brokers")
+	amqpURL := fs.String("amqp-url", queues.DefaultAMQPURL, "AMQP broker URL")
+	sqsEndpoint := fs.String("sqs-endpoint", queues.DefaultSQSEndpoint, "Endpoint for SQS queues given by name")
+	region := fs.String("region", "", "SQS region (default $AWS_REGION, then us-east-1)")
+	from := fs.String("from", "earliest", "Where to start reading Kafka topics: earliest, latest or an offset")
+	max := fs.Int("max", 0, "Stop after this many matching messages (0 for all)")
+	drain := fs.Bool("drain", false, "Remove the matching messages from the queue (AMQP and SQS)")
+	purge := fs.Bool("purge", false, "Delete every message in the queue without showing them")
+	follow := fs.Bool("follow", false, "Keep waiting for new messages until interrupted")
+	asJSON := fs.Bool("json", false, "Print one JSON object per message")
+	grep := fs.String("grep", "", "Only show messages whose body matches this regular expression")
+	key := fs.String("key", "", "Only show messages whose key or routing key matches this glob")
+	timeout := fs.Duration("timeout", 10*time.Second, "Timeout for each broker call")
+	var headers stringList
+	fs.Var(&headers, "header", "Only show messages with a header matching name=glob (repeatable)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	target, err := queue.ParseTarget(fs.Arg(0))
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitUsage
+	}
+	conn := queue.Connection{
+		Brokers:     splitList(*brokers),
+		AMQPURL:     *amqpURL,
+		SQSEndpoint: *sqsEndpoint,
+		Region:      *region,
+		Timeout:     *timeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if *purge {
+		if *drain || *follow || *grep != "" || *key != "" || len(headers) > 0 {
+			log.Printf("Error: --purge deletes every message and cannot be combined with --drain, --follow or filters")
+			return exitUsage
+		}
+		n, err := queue.Purge(ctx, target, conn)
+		if err != nil {
+			log.Printf("Error purging %s: %v", target, err)
+			return exitFailed
+		}
+		if n < 0 {
+			fmt.Fprintf(os.Stderr, "Purged %s\n", target)
+		} else {
+			fmt.Fprintf(os.Stderr, "Purged %d messages from %s\n", n, target)
+		}
+		return exitOK
+	}
+
+	if *drain && target.Kind == queue.KindKafka {
+		log.Printf("Error: %v", queue.ErrNotRemovable)
+		return exitUsage
+	}
+	if *follow && !*drain && target.Kind != queue.KindKafka {
+		log.Printf("Error: --follow on %s queues needs --drain, since messages shown are held from other consumers until qdrain exits", target.Kind)
+		return exitUsage
+	}
+	start, err := parseStart(*from)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitUsage
+	}
+
+	filter := &queue.Filter{Key: *key}
+	if err := filter.Validate(); err != nil {
+		log.Printf("Error: %v", err)
+		return exitUsage
+	}
+	if *grep != "" {
+		if filter.Grep, err = regexp.Compile(*grep); err != nil {
+			log.Printf("Error: invalid --grep: %v", err)
+			return exitUsage
+		}
+	}
+	for _, h := range headers {
+		match, err := queue.ParseHeaderMatch(h)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitUsage
+		}
+		filter.Headers = append(filter.Headers, match)
+	}
+
+	src, err := queue.Open(ctx, target, conn, start)
+	if err != nil {
+		log.Printf("Error opening %s: %v", target, err)
+		return exitFailed
+	}
+
+	printer := queue.NewPrinter(os.Stdout, *asJSON)
+	stats, runErr := queue.Run(ctx, src, queue.Options{
+		Filter: filter,
+		Max:    *max,
+		Drain:  *drain,
+		Follow: *follow,
+		Wait:   pollInterval,
+	}, printer.Print)
+	if err := src.Close(); err != nil {
+		log.Printf("Error closing %s: %v", target, err)
+	}
+
+	summary := fmt.Sprintf("%s: %d read, %d matched", target, stats.Read, stats.Matched)
+	if *drain {
+		summary += fmt.Sprintf(", %d removed", stats.Removed)
+	}
+	fmt.Fprintln(os.Stderr, summary)
+
+	switch {
+	case runErr != nil && !errors.Is(runErr, context.Canceled):
+		log.Printf("Error reading %s: %v", target, runErr)
+		return exitFailed
+	case stats.Matched == 0:
+		return exitNoMatch
+	default:
+		return exitOK
+	}
+}
+
+// parseStart reads --from
+func parseStart(from string) (int64, error) {
+	switch from {
+	case "earliest":
+		return queues.KafkaOffsetEarliest, nil
+	case "latest":
+		return queues.KafkaOffsetLatest, nil
+	}
+	offset, err := strconv.ParseInt(from, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid --from %q: must be earliest, latest or an offset", from)
+	}
+	return offset, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
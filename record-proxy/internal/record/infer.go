@@ -0,0 +1,269 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Request is a scheduler request in the config file format
+type Request struct {
+	Name     string      `yaml:"name"`
+	Tags     []string    `yaml:"tags,omitempty"`
+	Schedule Schedule    `yaml:"schedule"`
+	HTTP     HTTPRequest `yaml:"http"`
+}
+
+// Schedule is the subset of scheduler schedules the recorder infers
+type Schedule struct {
+	Relative string `yaml:"relative,omitempty"`
+
+	// Repeat makes a relative schedule fire every interval rather than once
+	Repeat bool `yaml:"repeat,omitempty"`
+
+	Template string `yaml:"template,omitempty"`
+	Jitter   string `yaml:"jitter,omitempty"`
+}
+
+// HTTPRequest is the http section of a scheduler request
+type HTTPRequest struct {
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    interface{}       `yaml:"body,omitempty"`
+}
+
+// InferOptions controls how recorded exchanges become requests
+type InferOptions struct {
+	// BaseURL replaces the target in recorded URLs
+	BaseURL string
+
+	// MinRepeats is how many identical requests at a steady interval make
+	// a periodic request; defaults to 3
+	MinRepeats int
+
+	// Tolerance is how far, as a fraction of the interval, each gap may
+	// stray from it and still count as steady; defaults to 0.2
+	Tolerance float64
+}
+
+// droppedHeaders are set by the client, proxy or transport rather than the
+// application, and are left out of recorded requests
+var droppedHeaders = map[string]bool{
+	"Accept-Encoding": true, "Accept-Language": true, "Cache-Control": true,
+	"Connection": true, "Content-Length": true, "Dnt": true, "Host": true,
+	"If-Modified-Since": true, "If-None-Match": true, "Keep-Alive": true,
+	"Origin": true, "Pragma": true, "Priority": true, "Referer": true,
+	"Te": true, "Trailer": true, "Transfer-Encoding": true, "Upgrade": true,
+	"Upgrade-Insecure-Requests": true,
+}
+
+// secretHeaders are written as env templates so credentials never end up in
+// the config file
+var secretHeaders = map[string]string{
+	"Authorization": "AUTHORIZATION",
+	"Cookie":        "COOKIE",
+	"X-Api-Key":     "API_KEY",
+}
+
+// Infer groups identical requests and gives each group a schedule. A group
+// repeated at a steady interval becomes one request with a relative schedule
+// of that interval; any other request is replayed once, at the same offset
+// from the start as it was recorded.
+func Infer(exchanges []Exchange, options InferOptions) []Request {
+	if len(exchanges) == 0 {
+		return nil
+	}
+	if options.MinRepeats < 2 {
+		options.MinRepeats = 3
+	}
+	if options.Tolerance <= 0 {
+		options.Tolerance = 0.2
+	}
+	start := exchanges[0].Time
+
+	type group struct {
+		first     Exchange
+		times     []time.Time
+		exchanges []Exchange
+	}
+	var order []string
+	groups := make(map[string]*group)
+	for _, exchange := range exchanges {
+		key := exchange.Method + " " + exchange.Path + "?" + exchange.RawQuery + "\x00" + string(exchange.Body)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{first: exchange}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.times = append(g.times, exchange.Time)
+		g.exchanges = append(g.exchanges, exchange)
+	}
+
+	type timed struct {
+		at      time.Time
+		request Request
+	}
+	var requests []timed
+	for _, key := range order {
+		g := groups[key]
+		if len(g.times) >= options.MinRepeats {
+			if interval, deviation, ok := steadyInterval(g.times, options.Tolerance); ok {
+				request := newRequest(g.first, options.BaseURL)
+				request.Tags = append([]string{"recorded", "periodic"}, request.Tags...)
+				request.Schedule.Relative = interval.String()
+				request.Schedule.Repeat = true
+				if deviation > 0 {
+					request.Schedule.Jitter = "±" + deviation.String()
+				}
+				requests = append(requests, timed{g.times[0], request})
+				continue
+			}
+		}
+		for _, exchange := range g.exchanges {
+			request := newRequest(exchange, options.BaseURL)
+			request.Tags = append([]string{"recorded"}, request.Tags...)
+			offset := int(exchange.Time.Sub(start).Round(time.Second) / time.Second)
+			request.Schedule.Template = fmt.Sprintf("{{ addSeconds %d now | unix }}", offset)
+			requests = append(requests, timed{exchange.Time, request})
+		}
+	}
+
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].at.Before(requests[j].at)
+	})
+
+	result := make([]Request, len(requests))
+	seen := make(map[string]int)
+	for i, r := range requests {
+		seen[r.request.Name]++
+		if n := seen[r.request.Name]; n > 1 {
+			r.request.Name = fmt.Sprintf("%s #%d", r.request.Name, n)
+		}
+		result[i] = r.request
+	}
+	return result
+}
+
+// steadyInterval returns the median gap between times, rounded to the second,
+// when every gap is within tolerance of it. The deviation is the largest
+// difference from the median, rounded to the second.
+func steadyInterval(times []time.Time, tolerance float64) (interval, deviation time.Duration, ok bool) {
+	gaps := make([]time.Duration, len(times)-1)
+	for i := 1; i < len(times); i++ {
+		gaps[i-1] = times[i].Sub(times[i-1])
+	}
+	sorted := append([]time.Duration(nil), gaps...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	median := sorted[len(sorted)/2]
+	if median < time.Second {
+		return 0, 0, false
+	}
+
+	for _, gap := range gaps {
+		diff := gap - median
+		if diff < 0 {
+			diff = -diff
+		}
+		if float64(diff) > tolerance*float64(median) {
+			return 0, 0, false
+		}
+		deviation = max(deviation, diff)
+	}
+	return median.Round(time.Second), deviation.Round(time.Second), true
+}
+
+// newRequest converts an exchange to a request without a schedule
+func newRequest(exchange Exchange, baseURL string) Request {
+	// The base URL may itself be a template, such as {{ env "BASE_URL" }}
+	uri := exchange.Path
+	if exchange.RawQuery != "" {
+		uri += "?" + exchange.RawQuery
+	}
+
+	request := Request{
+		Name: exchange.Method + " " + exchange.Path,
+		HTTP: HTTPRequest{
+			Method:  exchange.Method,
+			URL:     strings.TrimSuffix(baseURL, "/") + escapeTemplate(uri),
+			Headers: recordedHeaders(exchange.Header),
+		},
+	}
+
+	switch {
+	case exchange.Truncated:
+		request.Tags = append(request.Tags, "body-omitted")
+	case len(exchange.Body) == 0:
+	case !utf8.Valid(exchange.Body):
+		request.Tags = append(request.Tags, "body-omitted")
+	default:
+		request.HTTP.Body = recordedBody(exchange.Body, exchange.Header.Get("Content-Type"))
+	}
+	return request
+}
+
+// recordedHeaders keeps the headers the application set, with credentials
+// replaced by env templates
+func recordedHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string)
+	for key, values := range header {
+		key = http.CanonicalHeaderKey(key)
+		switch {
+		case droppedHeaders[key], strings.HasPrefix(key, "Sec-"),
+			strings.HasPrefix(key, "Proxy-"), strings.HasPrefix(key, "X-Forwarded-"):
+		case secretHeaders[key] != "":
+			headers[key] = fmt.Sprintf("{{ env %q }}", secretHeaders[key])
+		default:
+			headers[key] = escapeTemplate(strings.Join(values, ", "))
+		}
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// recordedBody decodes JSON bodies into structured data, which the scheduler
+// encodes as JSON again; other bodies are kept as strings
+func recordedBody(body []byte, contentType string) interface{} {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err == nil {
+			return escapeValue(value)
+		}
+	}
+	return escapeTemplate(string(body))
+}
+
+// escapeValue escapes template delimiters in every string of a decoded body
+func escapeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return escapeTemplate(v)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = escapeValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = escapeValue(item)
+		}
+	}
+	return value
+}
+
+// escapeTemplate keeps recorded text from being evaluated as a template by
+// the scheduler
+func escapeTemplate(s string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return strings.ReplaceAll(s, "{{", `{{ "{{" }}`)
+}
@@ -0,0 +1,125 @@
+package record
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInfer(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	health := func(d time.Duration) Exchange {
+		return Exchange{Time: at(d), Method: "GET", Path: "/health", Header: http.Header{}}
+	}
+
+	exchanges := []Exchange{
+		health(0),
+		{Time: at(2 * time.Second), Method: "POST", Path: "/orders", Header: http.Header{
+			"Content-Type":    {"application/json"},
+			"Authorization":   {"Bearer secret"},
+			"Accept-Encoding": {"gzip"},
+			"Sec-Fetch-Mode":  {"cors"},
+		}, Body: []byte(`{"item":"{{ not a template }}","qty":2}`)},
+		health(30 * time.Second),
+		health(61 * time.Second),
+		{Time: at(65 * time.Second), Method: "GET", Path: "/orders", RawQuery: "page=2", Header: http.Header{}},
+		{Time: at(70 * time.Second), Method: "GET", Path: "/orders", RawQuery: "page=2", Header: http.Header{}},
+		health(90 * time.Second),
+	}
+
+	requests := Infer(exchanges, InferOptions{BaseURL: "{{ env \"BASE_URL\" }}/"})
+	if len(requests) != 4 {
+		t.Fatalf("Expected 4 requests, got %+v", requests)
+	}
+
+	periodic := requests[0]
+	if periodic.Name != "GET /health" || periodic.Schedule.Relative != "30s" || !periodic.Schedule.Repeat || periodic.Schedule.Jitter != "±1s" {
+		t.Errorf("Unexpected periodic request %+v", periodic)
+	}
+	if !reflect.DeepEqual(periodic.Tags, []string{"recorded", "periodic"}) {
+		t.Errorf("Unexpected tags %v", periodic.Tags)
+	}
+
+	order := requests[1]
+	if order.Schedule.Template != "{{ addSeconds 2 now | unix }}" || order.HTTP.URL != `{{ env "BASE_URL" }}/orders` {
+		t.Errorf("Unexpected one-off request %+v", order)
+	}
+	wantHeaders := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": `{{ env "AUTHORIZATION" }}`,
+	}
+	if !reflect.DeepEqual(order.HTTP.Headers, wantHeaders) {
+		t.Errorf("Expected headers %v, got %v", wantHeaders, order.HTTP.Headers)
+	}
+	wantBody := map[string]interface{}{"item": `{{ "{{" }} not a template }}`, "qty": float64(2)}
+	if !reflect.DeepEqual(order.HTTP.Body, wantBody) {
+		t.Errorf("Expected body %v, got %v", wantBody, order.HTTP.Body)
+	}
+
+	// Two repeats are not enough to infer a period, so each is replayed once
+	if requests[2].Name != "GET /orders" || requests[3].Name != "GET /orders #2" {
+		t.Errorf("Expected unique names, got %q and %q", requests[2].Name, requests[3].Name)
+	}
+	if requests[3].Schedule.Template != "{{ addSeconds 70 now | unix }}" || requests[3].HTTP.URL != `{{ env "BASE_URL" }}/orders?page=2` {
+		t.Errorf("Unexpected repeated request %+v", requests[3])
+	}
+}
+
+func TestInfer_IrregularAndBodies(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	var exchanges []Exchange
+	for _, offset := range []int{0, 5, 40, 42} {
+		exchanges = append(exchanges, Exchange{
+			Time:   start.Add(time.Duration(offset) * time.Second),
+			Method: "PUT",
+			Path:   "/sync",
+			Header: http.Header{"Content-Type": {"text/plain"}},
+			Body:   []byte("ping"),
+		})
+	}
+	exchanges = append(exchanges,
+		Exchange{Time: start.Add(50 * time.Second), Method: "POST", Path: "/upload", Header: http.Header{}, Body: []byte{0xff, 0xfe}},
+		Exchange{Time: start.Add(51 * time.Second), Method: "POST", Path: "/upload", Header: http.Header{}, Truncated: true},
+	)
+
+	requests := Infer(exchanges, InferOptions{BaseURL: "http://localhost:3000"})
+	if len(requests) != 6 {
+		t.Fatalf("Expected irregular repeats to be replayed individually, got %+v", requests)
+	}
+	if requests[0].HTTP.Body != "ping" || requests[0].Schedule.Relative != "" || requests[0].Schedule.Repeat {
+		t.Errorf("Unexpected request %+v", requests[0])
+	}
+	for _, request := range requests[4:] {
+		if request.HTTP.Body != nil || !reflect.DeepEqual(request.Tags, []string{"recorded", "body-omitted"}) {
+			t.Errorf("Expected the body to be omitted, got %+v", request)
+		}
+	}
+
+	if Infer(nil, InferOptions{}) != nil {
+		t.Error("Expected no requests from an empty recording")
+	}
+}
+
+func TestSteadyInterval(t *testing.T) {
+	start := time.Unix(0, 0)
+	times := func(offsets ...time.Duration) []time.Time {
+		var result []time.Time
+		for _, offset := range offsets {
+			result = append(result, start.Add(offset))
+		}
+		return result
+	}
+
+	interval, deviation, ok := steadyInterval(times(0, 5*time.Minute, 10*time.Minute+20*time.Second, 15*time.Minute), 0.2)
+	if !ok || interval != 5*time.Minute || deviation != 20*time.Second {
+		t.Errorf("Expected 5m ±20s, got %v ±%v (%v)", interval, deviation, ok)
+	}
+	if _, _, ok := steadyInterval(times(0, 100*time.Millisecond, 200*time.Millisecond), 0.2); ok {
+		t.Error("Expected sub-second intervals to be rejected")
+	}
+	if _, _, ok := steadyInterval(times(0, 10*time.Second, 30*time.Second, 40*time.Second), 0.2); ok {
+		t.Error("Expected uneven gaps to be rejected")
+	}
+}
@@ -0,0 +1,162 @@
+// Package record captures traffic passing through a reverse proxy and turns
+// it into dynamic-request-scheduler requests with inferred schedules.
+package record
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBodySize bounds the request bodies kept; larger bodies are proxied but
+// recorded without a body
+const maxBodySize = 1 << 20
+
+// staticExtensions are skipped unless static assets are recorded
+var staticExtensions = map[string]bool{
+	".js": true, ".mjs": true, ".css": true, ".map": true, ".png": true, ".jpg": true,
+	".jpeg": true, ".gif": true, ".svg": true, ".ico": true, ".webp": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".html": true, ".htm": true,
+}
+
+// Exchange is one request captured on its way to the target
+type Exchange struct {
+	Time     time.Time
+	Method   string
+	Path     string
+	RawQuery string
+	Header   http.Header
+	Body     []byte
+
+	// Truncated is set when the body was too large to keep
+	Truncated bool
+
+	// Status is the target's response status, 502 when it was unreachable
+	Status int
+}
+
+// Options controls which requests are recorded
+type Options struct {
+	// Static records requests for assets such as scripts, styles and images
+	Static bool
+
+	// Exclude skips paths with any of these prefixes
+	Exclude []string
+}
+
+// Recorder is a reverse proxy that records every request it forwards
+type Recorder struct {
+	proxy   *httputil.ReverseProxy
+	options Options
+	logf    func(format string, args ...interface{})
+	now     func() time.Time
+
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// NewRecorder creates a recorder forwarding to target. Each recorded request
+// is logged through logf unless it is nil.
+func NewRecorder(target string, options Options, logf func(format string, args ...interface{})) (*Recorder, error) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid target %q: must be an http:// or https:// URL", target)
+	}
+
+	r := &Recorder{options: options, logf: logf, now: time.Now}
+	r.proxy = httputil.NewSingleHostReverseProxy(u)
+	r.proxy.FlushInterval = -1
+	r.proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		http.Error(w, fmt.Sprintf("record-proxy: %v", err), http.StatusBadGateway)
+	}
+	return r, nil
+}
+
+// ServeHTTP records the request, then forwards it to the target
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !r.shouldRecord(req) {
+		r.proxy.ServeHTTP(w, req)
+		return
+	}
+
+	exchange := Exchange{
+		Time:     r.now(),
+		Method:   req.Method,
+		Path:     req.URL.Path,
+		RawQuery: req.URL.RawQuery,
+		Header:   req.Header.Clone(),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxBodySize+1))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("record-proxy: failed to read request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		// Forward what was read followed by anything left unread
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+		if len(body) > maxBodySize {
+			exchange.Truncated = true
+		} else {
+			exchange.Body = body
+		}
+	}
+
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	r.proxy.ServeHTTP(recorder, req)
+	exchange.Status = recorder.status
+
+	r.mu.Lock()
+	r.exchanges = append(r.exchanges, exchange)
+	count := len(r.exchanges)
+	r.mu.Unlock()
+
+	if r.logf != nil {
+		r.logf("#%d %s %s -> %d", count, req.Method, req.URL.RequestURI(), exchange.Status)
+	}
+}
+
+// Exchanges returns the requests recorded so far, in arrival order
+func (r *Recorder) Exchanges() []Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Exchange(nil), r.exchanges...)
+}
+
+// shouldRecord applies the static asset and exclusion filters
+func (r *Recorder) shouldRecord(req *http.Request) bool {
+	for _, prefix := range r.options.Exclude {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return false
+		}
+	}
+	if !r.options.Static && staticExtensions[strings.ToLower(path.Ext(req.URL.Path))] {
+		return false
+	}
+	// CORS preflights are sent by browsers on their own
+	return !(req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "")
+}
+
+// statusRecorder captures the status code written by the proxy
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,94 @@
+package record
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	var upstreamBody string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		upstreamBody = string(data)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer upstream.Close()
+
+	recorder, err := NewRecorder(upstream.URL, Options{Exclude: []string{"/metrics"}}, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	resp, err := http.Post(proxy.URL+"/orders?source=web", "application/json", strings.NewReader(`{"id":1}`))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if upstreamBody != `{"id":1}` {
+		t.Errorf("Expected the body to be forwarded, got %q", upstreamBody)
+	}
+
+	for _, path := range []string{"/missing", "/metrics/cpu", "/app.js", "/logo.PNG"} {
+		resp, err := http.Get(proxy.URL + path)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	exchanges := recorder.Exchanges()
+	if len(exchanges) != 2 {
+		t.Fatalf("Expected 2 recorded exchanges, got %+v", exchanges)
+	}
+	first := exchanges[0]
+	if first.Method != "POST" || first.Path != "/orders" || first.RawQuery != "source=web" || string(first.Body) != `{"id":1}` || first.Status != 200 {
+		t.Errorf("Unexpected exchange %+v", first)
+	}
+	if first.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected request headers to be recorded, got %v", first.Header)
+	}
+	if exchanges[1].Path != "/missing" || exchanges[1].Status != 404 {
+		t.Errorf("Unexpected exchange %+v", exchanges[1])
+	}
+}
+
+func TestRecorder_LargeBody(t *testing.T) {
+	var received int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received = len(data)
+	}))
+	defer upstream.Close()
+
+	recorder, _ := NewRecorder(upstream.URL, Options{}, nil)
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	resp, err := http.Post(proxy.URL+"/upload", "application/octet-stream", strings.NewReader(strings.Repeat("x", maxBodySize+10)))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if received != maxBodySize+10 {
+		t.Errorf("Expected the whole body to be forwarded, got %d bytes", received)
+	}
+	if exchange := recorder.Exchanges()[0]; !exchange.Truncated || exchange.Body != nil {
+		t.Errorf("Expected the body to be dropped, got truncated=%v with %d bytes", exchange.Truncated, len(exchange.Body))
+	}
+}
+
+func TestNewRecorder_InvalidTarget(t *testing.T) {
+	for _, target := range []string{"", "localhost:3000", "tcp://localhost:5432"} {
+		if _, err := NewRecorder(target, Options{}, nil); err == nil {
+			t.Errorf("Expected %q to be rejected", target)
+		}
+	}
+}
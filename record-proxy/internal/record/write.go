@@ -0,0 +1,45 @@
+package record
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config is the top level of a scheduler config file
+type config struct {
+	Requests []Request `yaml:"requests"`
+}
+
+// Marshal renders requests as a scheduler config file, headed by a comment
+// describing the recording
+func Marshal(requests []Request, target string, recorded int, at time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Recorded from %s by record-proxy on %s\n", target, at.Format(time.RFC3339))
+	fmt.Fprintf(&buf, "# %d requests captured, %d scheduled requests inferred\n", recorded, len(requests))
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config{Requests: requests}); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteFile writes requests as a scheduler config file
+func WriteFile(path string, requests []Request, target string, recorded int, at time.Time) error {
+	data, err := Marshal(requests, target, recorded, at)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
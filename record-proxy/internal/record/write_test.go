@@ -0,0 +1,55 @@
+package record
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteFile(t *testing.T) {
+	requests := []Request{{
+		Name:     "GET /health",
+		Tags:     []string{"recorded", "periodic"},
+		Schedule: Schedule{Relative: "30s", Repeat: true},
+		HTTP:     HTTPRequest{Method: "GET", URL: "http://localhost:3000/health"},
+	}, {
+		Name:     "POST /orders",
+		Schedule: Schedule{Template: "{{ addSeconds 2 now | unix }}"},
+		HTTP:     HTTPRequest{Method: "POST", URL: "http://localhost:3000/orders", Body: map[string]interface{}{"qty": 2}},
+	}}
+
+	path := filepath.Join(t.TempDir(), "recorded.yaml")
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := WriteFile(path, requests, "http://localhost:3000", 5, at); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "# Recorded from http://localhost:3000 by record-proxy on 2025-01-01T12:00:00Z\n# 5 requests captured, 2 scheduled requests inferred\n") {
+		t.Errorf("Unexpected header:\n%s", data)
+	}
+
+	var decoded struct {
+		Requests []map[string]interface{} `yaml:"requests"`
+	}
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Written config is not valid YAML: %v", err)
+	}
+	if len(decoded.Requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %v", decoded.Requests)
+	}
+	if schedule := decoded.Requests[0]["schedule"].(map[string]interface{}); schedule["relative"] != "30s" || schedule["repeat"] != true {
+		t.Errorf("Expected a repeating relative schedule, got %v", schedule)
+	}
+	schedule := decoded.Requests[1]["schedule"].(map[string]interface{})
+	if schedule["template"] != "{{ addSeconds 2 now | unix }}" || len(schedule) != 1 {
+		t.Errorf("Expected only the template schedule, got %v", schedule)
+	}
+	if _, ok := decoded.Requests[1]["tags"]; ok {
+		t.Error("Expected empty tags to be omitted")
+	}
+}
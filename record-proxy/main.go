@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"local-dev-tools/record-proxy/internal/record"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run records traffic until interrupted, then writes the inferred config and
+// returns the process exit code
+func run() int {
+	target := flag.String("target", "", "Service to record traffic against (e.g. http://localhost:3000)")
+	listen := flag.String("listen", ":8082", "Address to listen on")
+	output := flag.String("output", "recorded.yaml", "Scheduler config file written on shutdown")
+	baseURL := flag.String("base-url", "", "Base URL used in the written requests (defaults to --target)")
+	minRepeats := flag.Int("min-repeats", 3, "Identical requests at a steady interval needed to infer a periodic schedule")
+	static := flag.Bool("static", false, "Record requests for static assets such as scripts, styles and images")
+	exclude := flag.String("exclude", "", "Comma-separated path prefixes not to record (e.g. /metrics,/debug)")
+	quiet := flag.Bool("quiet", false, "Do not log each recorded request")
+	flag.Parse()
+
+	if *target == "" {
+		log.Printf("Error: --target is required")
+		return 2
+	}
+	if *minRepeats < 2 {
+		log.Printf("Error: --min-repeats must be at least 2")
+		return 2
+	}
+	if *baseURL == "" {
+		*baseURL = *target
+	}
+
+	options := record.Options{Static: *static}
+	for _, prefix := range strings.Split(*exclude, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			options.Exclude = append(options.Exclude, prefix)
+		}
+	}
+
+	logf := log.Printf
+	if *quiet {
+		logf = nil
+	}
+	recorder, err := record.NewRecorder(*target, options, logf)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 2
+	}
+
+	server := &http.Server{Addr: *listen, Handler: recorder}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		fmt.Println("\nShutting down...")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	fmt.Printf("Recording %s -> %s (press Ctrl+C to write %s)\n", *listen, *target, *output)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Server error: %v", err)
+		return 3
+	}
+
+	exchanges := recorder.Exchanges()
+	if len(exchanges) == 0 {
+		fmt.Println("No requests recorded; nothing written")
+		return 0
+	}
+	requests := record.Infer(exchanges, record.InferOptions{BaseURL: *baseURL, MinRepeats: *minRepeats})
+	if err := record.WriteFile(*output, requests, *target, len(exchanges), time.Now()); err != nil {
+		log.Printf("Error: %v", err)
+		return 3
+	}
+	fmt.Printf("Wrote %d requests inferred from %d recorded to %s\n", len(requests), len(exchanges), *output)
+	return 0
+}
@@ -0,0 +1,113 @@
+package s3
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errMalformedChunk is returned for aws-chunked bodies that cannot be
+// decoded
+var errMalformedChunk = errors.New("malformed aws-chunked body")
+
+// maxChunkHeader bounds a chunk's header line, which holds its size and
+// signature
+const maxChunkHeader = 4096
+
+// isAWSChunked reports whether the body of a request is in the aws-chunked
+// encoding that SDKs use for streaming signed uploads
+func isAWSChunked(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") ||
+		strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked")
+}
+
+// chunkedReader decodes an aws-chunked body: chunks of a hexadecimal size,
+// optional ;chunk-signature=... and CRLF, then that many bytes and CRLF,
+// ending with a chunk of size zero. Signatures and the trailing checksums
+// that may follow the last chunk are ignored.
+type chunkedReader struct {
+	r         *bufio.Reader
+	remaining int64
+	started   bool
+	done      bool
+}
+
+func newChunkedReader(r io.Reader) *chunkedReader {
+	return &chunkedReader{r: bufio.NewReader(r)}
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	if c.done {
+		return 0, io.EOF
+	}
+	if c.remaining == 0 {
+		if err := c.next(); err != nil {
+			return 0, err
+		}
+		if c.done {
+			return 0, io.EOF
+		}
+	}
+
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// next reads the CRLF ending the previous chunk and the header of the next
+func (c *chunkedReader) next() error {
+	if c.started {
+		line, err := c.line()
+		if err != nil {
+			return err
+		}
+		if line != "" {
+			return fmt.Errorf("%w: chunk longer than its size", errMalformedChunk)
+		}
+	}
+	c.started = true
+
+	header, err := c.line()
+	if err != nil {
+		return err
+	}
+	size, _, _ := strings.Cut(header, ";")
+	n, err := strconv.ParseInt(strings.TrimSpace(size), 16, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("%w: bad chunk size %q", errMalformedChunk, size)
+	}
+	c.remaining = n
+	c.done = n == 0
+	return nil
+}
+
+// line reads a CRLF-terminated line without its terminator
+func (c *chunkedReader) line() (string, error) {
+	var line []byte
+	for {
+		part, isPrefix, err := c.r.ReadLine()
+		if err == io.EOF {
+			return "", io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return "", err
+		}
+		line = append(line, part...)
+		if len(line) > maxChunkHeader {
+			return "", fmt.Errorf("%w: chunk header too long", errMalformedChunk)
+		}
+		if !isPrefix {
+			return string(line), nil
+		}
+	}
+}
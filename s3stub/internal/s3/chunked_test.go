@@ -0,0 +1,63 @@
+package s3
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestChunkedReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "signed",
+			body: "5;chunk-signature=aaaa\r\nhello\r\n6;chunk-signature=bbbb\r\n world\r\n0;chunk-signature=cccc\r\n\r\n",
+			want: "hello world",
+		},
+		{
+			name: "unsigned with trailer",
+			body: "b\r\nhello world\r\n0\r\nx-amz-checksum-crc32:DUoRhQ==\r\n\r\n",
+			want: "hello world",
+		},
+		{name: "empty", body: "0;chunk-signature=aaaa\r\n\r\n", want: ""},
+		{name: "truncated", body: "b\r\nhello", wantErr: io.ErrUnexpectedEOF},
+		{name: "bad size", body: "zz\r\nhello\r\n", wantErr: errMalformedChunk},
+		{name: "longer than size", body: "2\r\nhello\r\n0\r\n\r\n", wantErr: errMalformedChunk},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(newChunkedReader(strings.NewReader(tt.body)))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.want {
+				t.Errorf("Got %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestIsAWSChunked(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodPut, "/bucket/key", nil)
+	if isAWSChunked(r) {
+		t.Error("Plain request detected as aws-chunked")
+	}
+	r.Header.Set("X-Amz-Content-Sha256", "STREAMING-AWS4-HMAC-SHA256-PAYLOAD")
+	if !isAWSChunked(r) {
+		t.Error("Streaming payload not detected")
+	}
+	r.Header.Del("X-Amz-Content-Sha256")
+	r.Header.Set("Content-Encoding", "gzip,aws-chunked")
+	if !isAWSChunked(r) {
+		t.Error("aws-chunked encoding not detected")
+	}
+}
@@ -0,0 +1,119 @@
+// Package s3 is a minimal S3-compatible object store for local development.
+// It keeps buckets and objects on disk and speaks enough of the S3 REST API
+// for SDKs, the AWS CLI and plain HTTP requests to put, get, list, copy and
+// delete objects.
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults for settings the config leaves out
+const (
+	DefaultListen  = ":9000"
+	DefaultDataDir = "s3data"
+	DefaultRegion  = "us-east-1"
+	DefaultDomain  = "localhost"
+)
+
+// ErrInvalidBucketName is wrapped by the errors of ValidateBucketName
+var ErrInvalidBucketName = errors.New("invalid bucket name")
+
+// bucketNamePattern is S3's rule for bucket names, less the rarely hit
+// exceptions such as names shaped like IP addresses
+var bucketNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// Config is the stub's configuration file
+type Config struct {
+	// Listen defaults to :9000
+	Listen string `yaml:"listen,omitempty"`
+
+	// DataDir is where buckets are stored, relative to the config file
+	DataDir string `yaml:"data_dir,omitempty"`
+
+	// Region is reported as every bucket's location
+	Region string `yaml:"region,omitempty"`
+
+	// Domain enables virtual-hosted-style requests: a Host of
+	// <bucket>.<domain> addresses that bucket
+	Domain string `yaml:"domain,omitempty"`
+
+	// Buckets are created at startup if they do not exist
+	Buckets []BucketConfig `yaml:"buckets,omitempty"`
+}
+
+// BucketConfig is a bucket to create at startup
+type BucketConfig struct {
+	Name string `yaml:"name"`
+
+	// Seed is a directory whose files are uploaded into an empty bucket at
+	// startup, keyed by their path relative to it
+	Seed string `yaml:"seed,omitempty"`
+}
+
+// LoadConfig reads a YAML or JSON config file, resolving its paths against
+// the file's directory. It is validated once flags have been applied.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	if config.DataDir != "" && !filepath.IsAbs(config.DataDir) {
+		config.DataDir = filepath.Join(dir, config.DataDir)
+	}
+	for i := range config.Buckets {
+		if seed := config.Buckets[i].Seed; seed != "" && !filepath.IsAbs(seed) {
+			config.Buckets[i].Seed = filepath.Join(dir, seed)
+		}
+	}
+	return &config, nil
+}
+
+// Validate checks the bucket names and fills defaults
+func (c *Config) Validate() error {
+	if c.Listen == "" {
+		c.Listen = DefaultListen
+	}
+	if c.DataDir == "" {
+		c.DataDir = DefaultDataDir
+	}
+	if c.Region == "" {
+		c.Region = DefaultRegion
+	}
+	if c.Domain == "" {
+		c.Domain = DefaultDomain
+	}
+
+	names := make(map[string]bool)
+	for i, bucket := range c.Buckets {
+		if err := ValidateBucketName(bucket.Name); err != nil {
+			return fmt.Errorf("bucket %d: %w", i, err)
+		}
+		if names[bucket.Name] {
+			return fmt.Errorf("bucket %d: duplicate name %q", i, bucket.Name)
+		}
+		names[bucket.Name] = true
+	}
+	return nil
+}
+
+// ValidateBucketName checks a name against S3's bucket naming rules
+func ValidateBucketName(name string) error {
+	if !bucketNamePattern.MatchString(name) {
+		return fmt.Errorf("%w %q: must be 3-63 lowercase letters, digits, dots and hyphens, starting and ending with a letter or digit", ErrInvalidBucketName, name)
+	}
+	return nil
+}
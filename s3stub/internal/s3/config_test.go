@@ -0,0 +1,73 @@
+package s3
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s3stub.yaml")
+	os.WriteFile(path, []byte(`
+listen: :9100
+data_dir: data
+buckets:
+  - name: uploads
+  - name: fixtures
+    seed: seed/fixtures
+  - name: absolute
+    seed: /srv/files
+`), 0o644)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if config.Listen != ":9100" || config.Region != DefaultRegion || config.Domain != DefaultDomain {
+		t.Errorf("Got settings %s %s %s", config.Listen, config.Region, config.Domain)
+	}
+	if config.DataDir != filepath.Join(dir, "data") {
+		t.Errorf("Expected the data directory relative to the config, got %s", config.DataDir)
+	}
+	if config.Buckets[1].Seed != filepath.Join(dir, "seed/fixtures") || config.Buckets[2].Seed != "/srv/files" {
+		t.Errorf("Got seeds %q %q", config.Buckets[1].Seed, config.Buckets[2].Seed)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		buckets []BucketConfig
+		wantErr string
+	}{
+		{name: "valid", buckets: []BucketConfig{{Name: "my-bucket.v2"}}},
+		{name: "uppercase", buckets: []BucketConfig{{Name: "MyBucket"}}, wantErr: "invalid bucket name"},
+		{name: "too short", buckets: []BucketConfig{{Name: "ab"}}, wantErr: "invalid bucket name"},
+		{name: "trailing hyphen", buckets: []BucketConfig{{Name: "bucket-"}}, wantErr: "invalid bucket name"},
+		{name: "duplicate", buckets: []BucketConfig{{Name: "assets"}, {Name: "assets"}}, wantErr: "duplicate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Buckets: tt.buckets}
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				if config.Listen != DefaultListen || config.DataDir != DefaultDataDir {
+					t.Errorf("Expected defaults, got %+v", config)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
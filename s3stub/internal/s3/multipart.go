@@ -0,0 +1,180 @@
+package s3
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Errors returned for multipart uploads
+var (
+	ErrNoSuchUpload     = errors.New("the specified multipart upload does not exist")
+	ErrInvalidPart      = errors.New("one or more of the specified parts could not be found or its entity tag did not match")
+	ErrInvalidPartOrder = errors.New("the list of parts was not in ascending order")
+)
+
+// maxPartNumber is the highest part number S3 accepts
+const maxPartNumber = 10000
+
+// upload is what is kept of a multipart upload until it completes
+type upload struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	Attributes
+}
+
+// Part names an uploaded part when completing an upload
+type Part struct {
+	Number int
+	ETag   string
+}
+
+// CreateUpload starts a multipart upload and returns its ID. Uploads are
+// kept under the data directory's .uploads, which no bucket name can clash
+// with, until completed or aborted.
+func (s *Store) CreateUpload(bucket, key string, attrs Attributes) (string, error) {
+	if _, err := fileName(key); err != nil {
+		return "", err
+	}
+	if !s.BucketExists(bucket) {
+		return "", ErrNoSuchBucket
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(id)
+	dir := filepath.Join(s.dir, ".uploads", uploadID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(upload{Bucket: bucket, Key: key, Attributes: attrs})
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "upload.json"), data, 0o644); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+// PutPart stores a part of an upload, replacing any earlier part with the
+// same number, and returns its ETag
+func (s *Store) PutPart(bucket, key, uploadID string, number int, r io.Reader) (string, error) {
+	dir, _, err := s.upload(bucket, key, uploadID)
+	if err != nil {
+		return "", err
+	}
+	if number < 1 || number > maxPartNumber {
+		return "", fmt.Errorf("%w: part numbers must be from 1 to %d", ErrInvalidPart, maxPartNumber)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".part-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := md5.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, partName(number))); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)) + `"`, nil
+}
+
+// CompleteUpload joins the listed parts into the upload's object. Its ETag
+// is, as in S3, the MD5 of the parts' MD5s followed by the part count.
+func (s *Store) CompleteUpload(bucket, key, uploadID string, parts []Part) (*Object, error) {
+	dir, up, err := s.upload(bucket, key, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, ErrInvalidPart
+	}
+
+	hash := md5.New()
+	readers := make([]io.Reader, 0, len(parts))
+	for i, part := range parts {
+		if i > 0 && part.Number <= parts[i-1].Number {
+			return nil, ErrInvalidPartOrder
+		}
+		path := filepath.Join(dir, partName(part.Number))
+		etag, err := fileETag(path)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && strings.Trim(part.ETag, `"`) != strings.Trim(etag, `"`)) {
+			return nil, fmt.Errorf("%w: part %d", ErrInvalidPart, part.Number)
+		}
+		if err != nil {
+			return nil, err
+		}
+		sum, _ := hex.DecodeString(strings.Trim(etag, `"`))
+		hash.Write(sum)
+
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+
+	etag := fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(hash.Sum(nil)), len(parts))
+	object, err := s.write(bucket, key, io.MultiReader(readers...), objectMeta{Attributes: up.Attributes, ETag: etag})
+	if err != nil {
+		return nil, err
+	}
+	return object, os.RemoveAll(dir)
+}
+
+// AbortUpload discards an upload and its parts
+func (s *Store) AbortUpload(bucket, key, uploadID string) error {
+	dir, _, err := s.upload(bucket, key, uploadID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// upload looks up an upload, which must be for the given object
+func (s *Store) upload(bucket, key, uploadID string) (string, *upload, error) {
+	if _, err := hex.DecodeString(uploadID); err != nil || uploadID == "" {
+		return "", nil, ErrNoSuchUpload
+	}
+	dir := filepath.Join(s.dir, ".uploads", uploadID)
+	data, err := os.ReadFile(filepath.Join(dir, "upload.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil, ErrNoSuchUpload
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	var up upload
+	if err := json.Unmarshal(data, &up); err != nil {
+		return "", nil, err
+	}
+	if up.Bucket != bucket || up.Key != key {
+		return "", nil, ErrNoSuchUpload
+	}
+	return dir, &up, nil
+}
+
+func partName(number int) string {
+	return fmt.Sprintf("%05d", number)
+}
@@ -0,0 +1,95 @@
+package s3
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStore_MultipartUpload(t *testing.T) {
+	store := newTestStore(t, "uploads")
+	id, err := store.CreateUpload("uploads", "big.bin", Attributes{ContentType: "application/octet-stream"})
+	if err != nil {
+		t.Fatalf("CreateUpload failed: %v", err)
+	}
+
+	// Parts may arrive out of order and be replaced
+	etag2, err := store.PutPart("uploads", "big.bin", id, 2, strings.NewReader("world"))
+	if err != nil {
+		t.Fatalf("PutPart failed: %v", err)
+	}
+	store.PutPart("uploads", "big.bin", id, 1, strings.NewReader("stale "))
+	etag1, _ := store.PutPart("uploads", "big.bin", id, 1, strings.NewReader("hello "))
+
+	object, err := store.CompleteUpload("uploads", "big.bin", id, []Part{{1, etag1}, {2, strings.Trim(etag2, `"`)}})
+	if err != nil {
+		t.Fatalf("CompleteUpload failed: %v", err)
+	}
+	if object.Size != 11 || !strings.HasSuffix(object.ETag, `-2"`) || object.ContentType != "application/octet-stream" {
+		t.Errorf("Got object %+v", object)
+	}
+	f, _, _ := store.Get("uploads", "big.bin")
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "hello world" {
+		t.Errorf("Got data %q", data)
+	}
+
+	// The upload is gone once complete
+	if _, err := os.Stat(filepath.Join(store.dir, ".uploads", id)); !os.IsNotExist(err) {
+		t.Errorf("Expected the upload removed, got %v", err)
+	}
+	if _, err := store.PutPart("uploads", "big.bin", id, 3, strings.NewReader("")); !errors.Is(err, ErrNoSuchUpload) {
+		t.Errorf("Expected ErrNoSuchUpload, got %v", err)
+	}
+
+	// Upload directories are not buckets
+	if buckets, _ := store.Buckets(); len(buckets) != 1 {
+		t.Errorf("Got buckets %+v", buckets)
+	}
+}
+
+func TestStore_CompleteUploadErrors(t *testing.T) {
+	store := newTestStore(t, "uploads")
+	id, _ := store.CreateUpload("uploads", "big.bin", Attributes{})
+	etag1, _ := store.PutPart("uploads", "big.bin", id, 1, strings.NewReader("a"))
+	etag2, _ := store.PutPart("uploads", "big.bin", id, 2, strings.NewReader("b"))
+
+	tests := []struct {
+		name    string
+		parts   []Part
+		wantErr error
+	}{
+		{name: "no parts", wantErr: ErrInvalidPart},
+		{name: "missing part", parts: []Part{{1, etag1}, {3, etag2}}, wantErr: ErrInvalidPart},
+		{name: "wrong etag", parts: []Part{{1, etag2}}, wantErr: ErrInvalidPart},
+		{name: "out of order", parts: []Part{{2, etag2}, {1, etag1}}, wantErr: ErrInvalidPartOrder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := store.CompleteUpload("uploads", "big.bin", id, tt.parts); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := store.PutPart("uploads", "big.bin", id, 0, strings.NewReader("")); !errors.Is(err, ErrInvalidPart) {
+		t.Errorf("Expected ErrInvalidPart for part 0, got %v", err)
+	}
+	if _, err := store.CompleteUpload("uploads", "other.bin", id, []Part{{1, etag1}}); !errors.Is(err, ErrNoSuchUpload) {
+		t.Errorf("Expected ErrNoSuchUpload for another key, got %v", err)
+	}
+	if _, err := store.PutPart("uploads", "big.bin", "../../etc", 1, strings.NewReader("")); !errors.Is(err, ErrNoSuchUpload) {
+		t.Errorf("Expected ErrNoSuchUpload for a path, got %v", err)
+	}
+
+	if err := store.AbortUpload("uploads", "big.bin", id); err != nil {
+		t.Fatalf("AbortUpload failed: %v", err)
+	}
+	if _, err := store.Stat("uploads", "big.bin"); !errors.Is(err, ErrNoSuchKey) {
+		t.Errorf("Expected no object after abort, got %v", err)
+	}
+}
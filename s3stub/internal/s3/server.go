@@ -0,0 +1,601 @@
+package s3
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// xmlns is the namespace of S3's XML responses
+const xmlns = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// timeFormat is how S3 formats times in XML
+const timeFormat = "2006-01-02T15:04:05.000Z"
+
+// Limits S3 applies to listings and multi-object deletes
+const (
+	maxListKeys       = 1000
+	maxDeleteObjects  = 1000
+	maxDeleteBodySize = 2 << 20
+)
+
+// storedHeaders are the standard headers kept with an object and returned
+// when it is read, besides Content-Type
+var storedHeaders = []string{"Cache-Control", "Content-Disposition", "Content-Encoding", "Content-Language", "Expires"}
+
+// unsupported are the subresources of buckets and objects the stub does
+// not implement, such as ?acl or ?versioning
+var unsupported = []string{
+	"accelerate", "acl", "analytics", "attributes", "cors", "encryption", "intelligent-tiering",
+	"inventory", "legal-hold", "lifecycle", "logging", "metrics", "notification", "object-lock",
+	"ownershipControls", "policy", "policyStatus", "publicAccessBlock", "replication",
+	"requestPayment", "restore", "retention", "select", "tagging", "torrent", "versioning",
+	"versions", "website",
+}
+
+// apiError is an S3 error response
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+var (
+	errNotImplemented   = &apiError{http.StatusNotImplemented, "NotImplemented", "A header or query you provided implies functionality that is not implemented"}
+	errMethodNotAllowed = &apiError{http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource"}
+	errMalformedXML     = &apiError{http.StatusBadRequest, "MalformedXML", "The XML you provided was not well-formed or did not validate against our published schema"}
+)
+
+// Server serves the S3 REST API over a store. It accepts any credentials
+// and does not check signatures.
+type Server struct {
+	store  *Store
+	region string
+	domain string
+	logf   func(format string, args ...interface{})
+
+	// requests numbers the requests for their x-amz-request-id
+	requests atomic.Uint64
+}
+
+// NewServer creates a server for a validated config. logf receives one line
+// per request and may be nil.
+func NewServer(store *Store, config *Config, logf func(format string, args ...interface{})) *Server {
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+	return &Server{store: store, region: config.Region, domain: config.Domain, logf: logf}
+}
+
+// ServeHTTP routes the request by its bucket and key, taken from the Host
+// for virtual-hosted-style requests and from the path otherwise
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	h.Set("Server", "s3stub")
+	h.Set("X-Amz-Request-Id", fmt.Sprintf("%016X", s.requests.Add(1)))
+
+	// Allow browsers to use presigned URLs from any local frontend
+	if r.Header.Get("Origin") != "" {
+		h.Set("Access-Control-Allow-Origin", "*")
+		h.Set("Access-Control-Expose-Headers", "ETag, X-Amz-Request-Id")
+		if r.Method == http.MethodOptions {
+			h.Set("Access-Control-Allow-Methods", "GET, HEAD, PUT, POST, DELETE")
+			h.Set("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
+			h.Set("Access-Control-Max-Age", "3600")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	bucket, key := s.route(r)
+	if err := s.handle(rec, r, bucket, key); err != nil {
+		s.writeError(rec, r, err)
+	}
+	s.logf("%s %s %d", r.Method, r.URL.RequestURI(), rec.status)
+}
+
+// route splits a request into its bucket and key, either of which may be
+// empty
+func (s *Server) route(r *http.Request) (bucket, key string) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if s.domain != "" && strings.HasSuffix(host, "."+s.domain) {
+		return strings.TrimSuffix(host, "."+s.domain), path
+	}
+	bucket, key, _ = strings.Cut(path, "/")
+	return bucket, key
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	query := r.URL.Query()
+	for _, name := range unsupported {
+		if query.Has(name) {
+			return errNotImplemented
+		}
+	}
+
+	switch {
+	case bucket == "":
+		if r.Method != http.MethodGet {
+			return errMethodNotAllowed
+		}
+		return s.listBuckets(w)
+	case key == "":
+		return s.handleBucket(w, r, bucket, query)
+	default:
+		return s.handleObject(w, r, bucket, key, query)
+	}
+}
+
+func (s *Server) handleBucket(w http.ResponseWriter, r *http.Request, bucket string, query url.Values) error {
+	switch r.Method {
+	case http.MethodPut:
+		if err := s.store.CreateBucket(bucket); err != nil {
+			return err
+		}
+		w.Header().Set("Location", "/"+bucket)
+		w.WriteHeader(http.StatusOK)
+		return nil
+	case http.MethodDelete:
+		if err := s.store.DeleteBucket(bucket); err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	case http.MethodHead:
+		if !s.store.BucketExists(bucket) {
+			return ErrNoSuchBucket
+		}
+		w.Header().Set("X-Amz-Bucket-Region", s.region)
+		w.WriteHeader(http.StatusOK)
+		return nil
+	case http.MethodGet:
+		switch {
+		case query.Has("location"):
+			if !s.store.BucketExists(bucket) {
+				return ErrNoSuchBucket
+			}
+			writeXML(w, http.StatusOK, locationConstraint{Xmlns: xmlns, Region: s.region})
+			return nil
+		case query.Has("uploads"):
+			return errNotImplemented
+		case query.Get("list-type") == "2":
+			return s.listObjectsV2(w, bucket, query)
+		default:
+			return s.listObjects(w, bucket, query)
+		}
+	case http.MethodPost:
+		if query.Has("delete") {
+			return s.deleteObjects(w, r, bucket)
+		}
+		return errNotImplemented
+	}
+	return errMethodNotAllowed
+}
+
+func (s *Server) handleObject(w http.ResponseWriter, r *http.Request, bucket, key string, query url.Values) error {
+	uploadID := query.Get("uploadId")
+	switch r.Method {
+	case http.MethodPut:
+		copySource := r.Header.Get("X-Amz-Copy-Source")
+		switch {
+		case uploadID != "" && copySource != "":
+			return errNotImplemented
+		case uploadID != "":
+			return s.putPart(w, r, bucket, key, uploadID, query)
+		case copySource != "":
+			return s.copyObject(w, r, bucket, key, copySource)
+		default:
+			return s.putObject(w, r, bucket, key)
+		}
+	case http.MethodGet, http.MethodHead:
+		if uploadID != "" {
+			return errNotImplemented
+		}
+		return s.getObject(w, r, bucket, key, query)
+	case http.MethodDelete:
+		var err error
+		if uploadID != "" {
+			err = s.store.AbortUpload(bucket, key, uploadID)
+		} else {
+			err = s.store.Delete(bucket, key)
+		}
+		if err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	case http.MethodPost:
+		switch {
+		case query.Has("uploads"):
+			return s.createUpload(w, r, bucket, key)
+		case uploadID != "":
+			return s.completeUpload(w, r, bucket, key, uploadID)
+		}
+		return errNotImplemented
+	}
+	return errMethodNotAllowed
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter) error {
+	buckets, err := s.store.Buckets()
+	if err != nil {
+		return err
+	}
+	result := listAllMyBucketsResult{Xmlns: xmlns, Owner: stubOwner}
+	for _, bucket := range buckets {
+		result.Buckets = append(result.Buckets, bucketEntry{Name: bucket.Name, CreationDate: bucket.Created.UTC().Format(timeFormat)})
+	}
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+func (s *Server) listObjects(w http.ResponseWriter, bucket string, query url.Values) error {
+	maxKeys, err := parseMaxKeys(query)
+	if err != nil {
+		return err
+	}
+	marker := query.Get("marker")
+	list, err := s.store.List(bucket, ListOptions{Prefix: query.Get("prefix"), Delimiter: query.Get("delimiter"), StartAfter: marker, MaxKeys: maxKeys})
+	if err != nil {
+		return err
+	}
+
+	result := newListBucketResult(bucket, query, maxKeys, list)
+	result.Marker = &marker
+	if list.IsTruncated {
+		result.NextMarker = list.NextMarker
+	}
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+func (s *Server) listObjectsV2(w http.ResponseWriter, bucket string, query url.Values) error {
+	maxKeys, err := parseMaxKeys(query)
+	if err != nil {
+		return err
+	}
+
+	// Continuation tokens are the last key or common prefix returned, and
+	// take precedence over start-after
+	start := query.Get("start-after")
+	token := query.Get("continuation-token")
+	if token != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			return &apiError{http.StatusBadRequest, "InvalidArgument", "The continuation token provided is incorrect"}
+		}
+		start = string(decoded)
+	}
+	list, err := s.store.List(bucket, ListOptions{Prefix: query.Get("prefix"), Delimiter: query.Get("delimiter"), StartAfter: start, MaxKeys: maxKeys})
+	if err != nil {
+		return err
+	}
+
+	result := newListBucketResult(bucket, query, maxKeys, list)
+	keyCount := len(list.Objects) + len(list.CommonPrefixes)
+	result.KeyCount = &keyCount
+	result.ContinuationToken = token
+	result.StartAfter = query.Get("start-after")
+	if list.IsTruncated {
+		result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(list.NextMarker))
+	}
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+func newListBucketResult(bucket string, query url.Values, maxKeys int, list *ListResult) *listBucketResult {
+	result := &listBucketResult{
+		Xmlns:       xmlns,
+		Name:        bucket,
+		Prefix:      query.Get("prefix"),
+		Delimiter:   query.Get("delimiter"),
+		MaxKeys:     maxKeys,
+		IsTruncated: list.IsTruncated,
+	}
+	for _, object := range list.Objects {
+		result.Contents = append(result.Contents, listEntry{
+			Key:          object.Key,
+			LastModified: object.LastModified.Format(timeFormat),
+			ETag:         object.ETag,
+			Size:         object.Size,
+			StorageClass: "STANDARD",
+		})
+	}
+	for _, prefix := range list.CommonPrefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: prefix})
+	}
+	return result
+}
+
+func parseMaxKeys(query url.Values) (int, error) {
+	value := query.Get("max-keys")
+	if value == "" {
+		return maxListKeys, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, &apiError{http.StatusBadRequest, "InvalidArgument", "max-keys must be a non-negative integer"}
+	}
+	return min(n, maxListKeys), nil
+}
+
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	object, err := s.store.Put(bucket, key, requestBody(r), attributesFrom(r.Header))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("ETag", object.ETag)
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, bucket, key, copySource string) error {
+	// The source is /bucket/key or bucket/key, URL-encoded, and may name a
+	// version, which is ignored as there is only ever one
+	source, _, _ := strings.Cut(copySource, "?")
+	source, err := url.PathUnescape(source)
+	if err != nil {
+		return &apiError{http.StatusBadRequest, "InvalidArgument", "Invalid copy source encoding"}
+	}
+	sourceBucket, sourceKey, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+	if sourceBucket == "" || sourceKey == "" {
+		return &apiError{http.StatusBadRequest, "InvalidArgument", "Copy Source must mention the source bucket and key: sourcebucket/sourcekey"}
+	}
+
+	replace := strings.EqualFold(r.Header.Get("X-Amz-Metadata-Directive"), "REPLACE")
+	if sourceBucket == bucket && sourceKey == key && !replace {
+		return &apiError{http.StatusBadRequest, "InvalidRequest", "This copy request is illegal because it is trying to copy an object to itself without changing the object's metadata"}
+	}
+
+	f, original, err := s.store.Get(sourceBucket, sourceKey)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	attrs := original.Attributes
+	if replace {
+		attrs = attributesFrom(r.Header)
+	}
+	object, err := s.store.Put(bucket, key, f, attrs)
+	if err != nil {
+		return err
+	}
+	writeXML(w, http.StatusOK, copyObjectResult{Xmlns: xmlns, LastModified: object.LastModified.Format(timeFormat), ETag: object.ETag})
+	return nil
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, bucket, key string, query url.Values) error {
+	f, object, err := s.store.Get(bucket, key)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := w.Header()
+	h.Set("ETag", object.ETag)
+	h.Set("Content-Type", object.ContentType)
+	for name, value := range object.Headers {
+		h.Set(name, value)
+	}
+	for name, value := range object.Metadata {
+		h.Set("X-Amz-Meta-"+name, value)
+	}
+
+	// Presigned URLs can override the headers returned
+	for _, name := range append([]string{"Content-Type"}, storedHeaders...) {
+		if value := query.Get("response-" + strings.ToLower(name)); value != "" {
+			h.Set(name, value)
+		}
+	}
+
+	// ServeContent handles ranges and conditional requests
+	http.ServeContent(w, r, "", object.LastModified, f)
+	return nil
+}
+
+func (s *Server) deleteObjects(w http.ResponseWriter, r *http.Request, bucket string) error {
+	if !s.store.BucketExists(bucket) {
+		return ErrNoSuchBucket
+	}
+	var request deleteRequest
+	if err := xml.NewDecoder(io.LimitReader(requestBody(r), maxDeleteBodySize)).Decode(&request); err != nil || len(request.Objects) > maxDeleteObjects {
+		return errMalformedXML
+	}
+
+	result := deleteResult{Xmlns: xmlns}
+	for _, object := range request.Objects {
+		if err := s.store.Delete(bucket, object.Key); err != nil {
+			apiErr := errorFor(err)
+			result.Errors = append(result.Errors, deleteError{Key: object.Key, Code: apiErr.Code, Message: apiErr.Message})
+		} else if !request.Quiet {
+			result.Deleted = append(result.Deleted, deletedEntry{Key: object.Key})
+		}
+	}
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+func (s *Server) createUpload(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	uploadID, err := s.store.CreateUpload(bucket, key, attributesFrom(r.Header))
+	if err != nil {
+		return err
+	}
+	writeXML(w, http.StatusOK, initiateMultipartUploadResult{Xmlns: xmlns, Bucket: bucket, Key: key, UploadID: uploadID})
+	return nil
+}
+
+func (s *Server) putPart(w http.ResponseWriter, r *http.Request, bucket, key, uploadID string, query url.Values) error {
+	number, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil {
+		return &apiError{http.StatusBadRequest, "InvalidArgument", "Part number must be an integer between 1 and 10000, inclusive"}
+	}
+	etag, err := s.store.PutPart(bucket, key, uploadID, number, requestBody(r))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (s *Server) completeUpload(w http.ResponseWriter, r *http.Request, bucket, key, uploadID string) error {
+	var request completeMultipartUpload
+	if err := xml.NewDecoder(io.LimitReader(r.Body, maxDeleteBodySize)).Decode(&request); err != nil {
+		return errMalformedXML
+	}
+	parts := make([]Part, len(request.Parts))
+	for i, part := range request.Parts {
+		parts[i] = Part{Number: part.PartNumber, ETag: part.ETag}
+	}
+
+	object, err := s.store.CompleteUpload(bucket, key, uploadID, parts)
+	if err != nil {
+		return err
+	}
+	location := (&url.URL{Scheme: "http", Host: r.Host, Path: "/" + bucket + "/" + key}).String()
+	writeXML(w, http.StatusOK, completeMultipartUploadResult{Xmlns: xmlns, Location: location, Bucket: bucket, Key: key, ETag: object.ETag})
+	return nil
+}
+
+// requestBody returns the request's body, decoding it if it is aws-chunked
+func requestBody(r *http.Request) io.Reader {
+	if isAWSChunked(r) {
+		return newChunkedReader(r.Body)
+	}
+	return r.Body
+}
+
+// attributesFrom takes an object's attributes from the headers of the
+// request creating it
+func attributesFrom(header http.Header) Attributes {
+	attrs := Attributes{ContentType: header.Get("Content-Type")}
+	if attrs.ContentType == "" {
+		attrs.ContentType = "binary/octet-stream"
+	}
+
+	for _, name := range storedHeaders {
+		value := header.Get(name)
+		if name == "Content-Encoding" {
+			value = withoutAWSChunked(value)
+		}
+		if value != "" {
+			if attrs.Headers == nil {
+				attrs.Headers = make(map[string]string)
+			}
+			attrs.Headers[name] = value
+		}
+	}
+
+	for name, values := range header {
+		name = strings.ToLower(name)
+		if meta, ok := strings.CutPrefix(name, "x-amz-meta-"); ok && meta != "" {
+			if attrs.Metadata == nil {
+				attrs.Metadata = make(map[string]string)
+			}
+			attrs.Metadata[meta] = strings.Join(values, ",")
+		}
+	}
+	return attrs
+}
+
+// withoutAWSChunked removes the aws-chunked transfer coding from a
+// Content-Encoding, as S3 does before storing it
+func withoutAWSChunked(encoding string) string {
+	var kept []string
+	for _, coding := range strings.Split(encoding, ",") {
+		if coding = strings.TrimSpace(coding); coding != "" && coding != "aws-chunked" {
+			kept = append(kept, coding)
+		}
+	}
+	return strings.Join(kept, ",")
+}
+
+// errorFor maps an error to the S3 error response for it
+func errorFor(err error) *apiError {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	status, code := http.StatusInternalServerError, "InternalError"
+	switch {
+	case errors.Is(err, ErrNoSuchBucket):
+		status, code = http.StatusNotFound, "NoSuchBucket"
+	case errors.Is(err, ErrNoSuchKey):
+		status, code = http.StatusNotFound, "NoSuchKey"
+	case errors.Is(err, ErrNoSuchUpload):
+		status, code = http.StatusNotFound, "NoSuchUpload"
+	case errors.Is(err, ErrBucketExists):
+		status, code = http.StatusConflict, "BucketAlreadyOwnedByYou"
+	case errors.Is(err, ErrBucketNotEmpty):
+		status, code = http.StatusConflict, "BucketNotEmpty"
+	case errors.Is(err, ErrInvalidBucketName):
+		status, code = http.StatusBadRequest, "InvalidBucketName"
+	case errors.Is(err, ErrInvalidKey):
+		status, code = http.StatusBadRequest, "InvalidArgument"
+	case errors.Is(err, ErrKeyTooLong):
+		status, code = http.StatusBadRequest, "KeyTooLongError"
+	case errors.Is(err, ErrInvalidPart):
+		status, code = http.StatusBadRequest, "InvalidPart"
+	case errors.Is(err, ErrInvalidPartOrder):
+		status, code = http.StatusBadRequest, "InvalidPartOrder"
+	case errors.Is(err, errMalformedChunk), errors.Is(err, io.ErrUnexpectedEOF):
+		status, code = http.StatusBadRequest, "IncompleteBody"
+	}
+	return &apiError{Status: status, Code: code, Message: err.Error()}
+}
+
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := errorFor(err)
+	if apiErr.Status == http.StatusInternalServerError {
+		s.logf("Failed to handle %s %s: %v", r.Method, r.URL.Path, err)
+	}
+
+	// Responses to HEAD have no body to hold the error
+	if r.Method == http.MethodHead {
+		w.WriteHeader(apiErr.Status)
+		return
+	}
+	writeXML(w, apiErr.Status, errorResponse{
+		Code:      apiErr.Code,
+		Message:   apiErr.Message,
+		Resource:  r.URL.Path,
+		RequestID: w.Header().Get("X-Amz-Request-Id"),
+	})
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(v)
+}
+
+// statusRecorder keeps the status written for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,302 @@
+package s3
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T, buckets ...string) (*Server, *Store) {
+	t.Helper()
+	store := newTestStore(t, buckets...)
+	config := &Config{}
+	config.Validate()
+	return NewServer(store, config, nil), store
+}
+
+func do(server *Server, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, r)
+	return rec
+}
+
+func errorCode(rec *httptest.ResponseRecorder) string {
+	var resp errorResponse
+	xml.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp.Code
+}
+
+func TestServer_Objects(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	if rec := do(server, http.MethodPut, "/uploads", "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("CreateBucket returned %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(server, http.MethodPut, "/uploads", "", nil); rec.Code != http.StatusConflict || errorCode(rec) != "BucketAlreadyOwnedByYou" {
+		t.Errorf("Expected BucketAlreadyOwnedByYou, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec := do(server, http.MethodPut, "/uploads/docs/hello%20world.txt", "hello world", http.Header{
+		"Content-Type":     {"text/plain"},
+		"Cache-Control":    {"max-age=60"},
+		"X-Amz-Meta-Owner": {"alice"},
+	})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"5eb63bbbe01eeed093cb22bb8f5acdc3"` {
+		t.Fatalf("PutObject returned %d %v", rec.Code, rec.Header())
+	}
+
+	rec = do(server, http.MethodGet, "/uploads/docs/hello%20world.txt", "", nil)
+	h := rec.Header()
+	if rec.Body.String() != "hello world" || h.Get("Content-Type") != "text/plain" || h.Get("Cache-Control") != "max-age=60" || h.Get("X-Amz-Meta-Owner") != "alice" {
+		t.Errorf("GetObject returned %d %v %q", rec.Code, h, rec.Body.String())
+	}
+
+	rec = do(server, http.MethodGet, "/uploads/docs/hello%20world.txt", "", http.Header{"Range": {"bytes=6-"}})
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "world" {
+		t.Errorf("Range returned %d %q", rec.Code, rec.Body.String())
+	}
+	rec = do(server, http.MethodGet, "/uploads/docs/hello%20world.txt", "", http.Header{"If-None-Match": {`"5eb63bbbe01eeed093cb22bb8f5acdc3"`}})
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match returned %d", rec.Code)
+	}
+	rec = do(server, http.MethodGet, "/uploads/docs/hello%20world.txt?response-content-disposition=attachment", "", nil)
+	if rec.Header().Get("Content-Disposition") != "attachment" {
+		t.Errorf("Expected the presigned override, got %v", rec.Header())
+	}
+
+	rec = do(server, http.MethodHead, "/uploads/docs/hello%20world.txt", "", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Length") != "11" || rec.Body.Len() != 0 {
+		t.Errorf("HeadObject returned %d %v %q", rec.Code, rec.Header(), rec.Body.String())
+	}
+	rec = do(server, http.MethodHead, "/uploads/missing", "", nil)
+	if rec.Code != http.StatusNotFound || rec.Body.Len() != 0 {
+		t.Errorf("HeadObject of a missing key returned %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = do(server, http.MethodGet, "/uploads/missing", "", nil)
+	if rec.Code != http.StatusNotFound || errorCode(rec) != "NoSuchKey" {
+		t.Errorf("Expected NoSuchKey, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec = do(server, http.MethodGet, "/other/key", "", nil)
+	if rec.Code != http.StatusNotFound || errorCode(rec) != "NoSuchBucket" {
+		t.Errorf("Expected NoSuchBucket, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(server, http.MethodDelete, "/uploads", "", nil); rec.Code != http.StatusConflict || errorCode(rec) != "BucketNotEmpty" {
+		t.Errorf("Expected BucketNotEmpty, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(server, http.MethodDelete, "/uploads/docs/hello%20world.txt", "", nil); rec.Code != http.StatusNoContent {
+		t.Errorf("DeleteObject returned %d", rec.Code)
+	}
+	if rec := do(server, http.MethodDelete, "/uploads", "", nil); rec.Code != http.StatusNoContent {
+		t.Errorf("DeleteBucket returned %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServer_VirtualHostedStyle(t *testing.T) {
+	server, _ := newTestServer(t, "assets")
+
+	r := httptest.NewRequest(http.MethodPut, "/css/site.css", strings.NewReader("body{}"))
+	r.Host = "assets.localhost:9000"
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PutObject returned %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(server, http.MethodGet, "/assets/css/site.css", "", nil); rec.Body.String() != "body{}" {
+		t.Errorf("Expected the object by path, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServer_List(t *testing.T) {
+	server, store := newTestServer(t, "photos")
+	put(t, store, "photos", "2023/a.jpg", "2023/b.jpg", "2024/c.jpg", "index.html")
+
+	var result listBucketResult
+	rec := do(server, http.MethodGet, "/photos?list-type=2&delimiter=/&max-keys=2", "", nil)
+	xml.Unmarshal(rec.Body.Bytes(), &result)
+	if rec.Code != http.StatusOK || len(result.CommonPrefixes) != 2 || !result.IsTruncated || *result.KeyCount != 2 || result.NextContinuationToken == "" {
+		t.Fatalf("First page returned %d %s", rec.Code, rec.Body.String())
+	}
+
+	next := result.NextContinuationToken
+	result = listBucketResult{}
+	rec = do(server, http.MethodGet, "/photos?list-type=2&delimiter=/&max-keys=2&continuation-token="+next, "", nil)
+	xml.Unmarshal(rec.Body.Bytes(), &result)
+	if len(result.Contents) != 1 || result.Contents[0].Key != "index.html" || result.IsTruncated || result.ContinuationToken != next {
+		t.Errorf("Second page returned %s", rec.Body.String())
+	}
+
+	result = listBucketResult{}
+	rec = do(server, http.MethodGet, "/photos?prefix=2023/&marker=2023/a.jpg", "", nil)
+	xml.Unmarshal(rec.Body.Bytes(), &result)
+	if len(result.Contents) != 1 || result.Contents[0].Key != "2023/b.jpg" || result.Contents[0].Size != 10 || result.KeyCount != nil {
+		t.Errorf("Version 1 listing returned %s", rec.Body.String())
+	}
+
+	if rec := do(server, http.MethodGet, "/photos?max-keys=lots", "", nil); errorCode(rec) != "InvalidArgument" {
+		t.Errorf("Expected InvalidArgument, got %s", rec.Body.String())
+	}
+
+	var buckets listAllMyBucketsResult
+	rec = do(server, http.MethodGet, "/", "", nil)
+	xml.Unmarshal(rec.Body.Bytes(), &buckets)
+	if len(buckets.Buckets) != 1 || buckets.Buckets[0].Name != "photos" {
+		t.Errorf("ListBuckets returned %s", rec.Body.String())
+	}
+	if rec := do(server, http.MethodGet, "/photos?location", "", nil); !strings.Contains(rec.Body.String(), ">us-east-1<") {
+		t.Errorf("GetBucketLocation returned %s", rec.Body.String())
+	}
+}
+
+func TestServer_CopyObject(t *testing.T) {
+	server, store := newTestServer(t, "src", "dst")
+	store.Put("src", "a b.txt", strings.NewReader("data"), Attributes{ContentType: "text/plain", Metadata: map[string]string{"v": "1"}})
+
+	rec := do(server, http.MethodPut, "/dst/copy.txt", "", http.Header{"X-Amz-Copy-Source": {"src/a%20b.txt"}})
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<CopyObjectResult") {
+		t.Fatalf("CopyObject returned %d %s", rec.Code, rec.Body.String())
+	}
+	object, err := store.Stat("dst", "copy.txt")
+	if err != nil || object.ContentType != "text/plain" || object.Metadata["v"] != "1" {
+		t.Errorf("Expected the attributes copied, got %+v, %v", object, err)
+	}
+
+	rec = do(server, http.MethodPut, "/dst/copy.txt", "", http.Header{
+		"X-Amz-Copy-Source":        {"/dst/copy.txt"},
+		"X-Amz-Metadata-Directive": {"REPLACE"},
+		"Content-Type":             {"text/markdown"},
+	})
+	if object, _ := store.Stat("dst", "copy.txt"); rec.Code != http.StatusOK || object.ContentType != "text/markdown" || object.Metadata != nil {
+		t.Errorf("Expected the attributes replaced, got %d %+v", rec.Code, object)
+	}
+
+	rec = do(server, http.MethodPut, "/dst/copy.txt", "", http.Header{"X-Amz-Copy-Source": {"/dst/copy.txt"}})
+	if errorCode(rec) != "InvalidRequest" {
+		t.Errorf("Expected copying onto itself rejected, got %s", rec.Body.String())
+	}
+	rec = do(server, http.MethodPut, "/dst/x", "", http.Header{"X-Amz-Copy-Source": {"/src/missing"}})
+	if errorCode(rec) != "NoSuchKey" {
+		t.Errorf("Expected NoSuchKey, got %s", rec.Body.String())
+	}
+}
+
+func TestServer_DeleteObjects(t *testing.T) {
+	server, store := newTestServer(t, "uploads")
+	put(t, store, "uploads", "a", "b")
+
+	rec := do(server, http.MethodPost, "/uploads?delete", `<Delete><Object><Key>a</Key></Object><Object><Key>missing</Key></Object></Delete>`, nil)
+	var result deleteResult
+	xml.Unmarshal(rec.Body.Bytes(), &result)
+	if rec.Code != http.StatusOK || len(result.Deleted) != 2 || len(result.Errors) != 0 {
+		t.Errorf("DeleteObjects returned %d %s", rec.Code, rec.Body.String())
+	}
+	if _, err := store.Stat("uploads", "a"); err == nil {
+		t.Error("Expected a deleted")
+	}
+
+	rec = do(server, http.MethodPost, "/uploads?delete", `<Delete><Quiet>true</Quiet><Object><Key>b</Key></Object></Delete>`, nil)
+	if strings.Contains(rec.Body.String(), "<Deleted>") {
+		t.Errorf("Expected a quiet result, got %s", rec.Body.String())
+	}
+	if rec := do(server, http.MethodPost, "/uploads?delete", `<Delete>`, nil); errorCode(rec) != "MalformedXML" {
+		t.Errorf("Expected MalformedXML, got %s", rec.Body.String())
+	}
+}
+
+func TestServer_MultipartUpload(t *testing.T) {
+	server, _ := newTestServer(t, "uploads")
+
+	var initiated initiateMultipartUploadResult
+	rec := do(server, http.MethodPost, "/uploads/big.bin?uploads", "", http.Header{"Content-Type": {"video/mp4"}})
+	xml.Unmarshal(rec.Body.Bytes(), &initiated)
+	if rec.Code != http.StatusOK || initiated.UploadID == "" {
+		t.Fatalf("CreateMultipartUpload returned %d %s", rec.Code, rec.Body.String())
+	}
+
+	var complete strings.Builder
+	complete.WriteString("<CompleteMultipartUpload>")
+	for i, data := range []string{"hello ", "world"} {
+		n := string(rune('1' + i))
+		rec := do(server, http.MethodPut, "/uploads/big.bin?partNumber="+n+"&uploadId="+initiated.UploadID, data, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("UploadPart returned %d %s", rec.Code, rec.Body.String())
+		}
+		complete.WriteString("<Part><PartNumber>" + n + "</PartNumber><ETag>" + rec.Header().Get("ETag") + "</ETag></Part>")
+	}
+	complete.WriteString("</CompleteMultipartUpload>")
+
+	rec = do(server, http.MethodPost, "/uploads/big.bin?uploadId="+initiated.UploadID, complete.String(), nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "-2&#34;</ETag>") {
+		t.Fatalf("CompleteMultipartUpload returned %d %s", rec.Code, rec.Body.String())
+	}
+	rec = do(server, http.MethodGet, "/uploads/big.bin", "", nil)
+	if rec.Body.String() != "hello world" || rec.Header().Get("Content-Type") != "video/mp4" {
+		t.Errorf("GetObject returned %v %q", rec.Header(), rec.Body.String())
+	}
+
+	rec = do(server, http.MethodDelete, "/uploads/big.bin?uploadId="+initiated.UploadID, "", nil)
+	if errorCode(rec) != "NoSuchUpload" {
+		t.Errorf("Expected NoSuchUpload after completing, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServer_AWSChunkedUpload(t *testing.T) {
+	server, store := newTestServer(t, "uploads")
+	body := "5;chunk-signature=aaaa\r\nhello\r\n0;chunk-signature=bbbb\r\n\r\n"
+	rec := do(server, http.MethodPut, "/uploads/a.txt", body, http.Header{
+		"X-Amz-Content-Sha256":         {"STREAMING-AWS4-HMAC-SHA256-PAYLOAD"},
+		"X-Amz-Decoded-Content-Length": {"5"},
+		"Content-Encoding":             {"aws-chunked"},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PutObject returned %d %s", rec.Code, rec.Body.String())
+	}
+
+	f, object, _ := store.Get("uploads", "a.txt")
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "hello" || object.Headers["Content-Encoding"] != "" {
+		t.Errorf("Got %q %+v", data, object)
+	}
+
+	rec = do(server, http.MethodPut, "/uploads/b.txt", "5\r\nhel", http.Header{"Content-Encoding": {"aws-chunked"}})
+	if errorCode(rec) != "IncompleteBody" {
+		t.Errorf("Expected IncompleteBody, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServer_Unsupported(t *testing.T) {
+	server, store := newTestServer(t, "uploads")
+	put(t, store, "uploads", "a")
+
+	for _, target := range []string{"/uploads?versioning", "/uploads/a?tagging", "/uploads?uploads"} {
+		if rec := do(server, http.MethodGet, target, "", nil); rec.Code != http.StatusNotImplemented || errorCode(rec) != "NotImplemented" {
+			t.Errorf("Expected NotImplemented for %s, got %d", target, rec.Code)
+		}
+	}
+	if rec := do(server, http.MethodDelete, "/", "", nil); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected MethodNotAllowed, got %d", rec.Code)
+	}
+}
+
+func TestServer_CORS(t *testing.T) {
+	server, _ := newTestServer(t, "uploads")
+	rec := do(server, http.MethodOptions, "/uploads/a.png", "", http.Header{
+		"Origin":                         {"http://localhost:5173"},
+		"Access-Control-Request-Method":  {"PUT"},
+		"Access-Control-Request-Headers": {"content-type"},
+	})
+	h := rec.Header()
+	if rec.Code != http.StatusOK || h.Get("Access-Control-Allow-Origin") != "*" || h.Get("Access-Control-Allow-Headers") != "content-type" {
+		t.Errorf("Preflight returned %d %v", rec.Code, h)
+	}
+}
@@ -0,0 +1,478 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Errors returned by the store, each mapped to an S3 error code
+var (
+	ErrNoSuchBucket   = errors.New("the specified bucket does not exist")
+	ErrBucketExists   = errors.New("the requested bucket name is already owned by you")
+	ErrBucketNotEmpty = errors.New("the bucket you tried to delete is not empty")
+	ErrNoSuchKey      = errors.New("the specified key does not exist")
+	ErrInvalidKey     = errors.New("object keys must be 1 to 1024 bytes of UTF-8")
+	ErrKeyTooLong     = errors.New("the object key is too long to store on disk")
+)
+
+// maxFileName bounds escaped keys, leaving room for the .json of their
+// metadata file within the usual 255 byte limit on file names
+const maxFileName = 250
+
+// Attributes are what a client sets on an object besides its data
+type Attributes struct {
+	ContentType string `json:"content_type,omitempty"`
+
+	// Headers are the other standard headers kept with an object, such as
+	// Cache-Control and Content-Disposition
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Metadata are the x-amz-meta-* headers, keyed by lowercase name
+	// without the prefix
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Object describes a stored object
+type Object struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+	Attributes
+}
+
+// objectMeta is what is kept alongside an object's data
+type objectMeta struct {
+	Attributes
+	ETag string `json:"etag"`
+}
+
+// Bucket describes a bucket
+type Bucket struct {
+	Name    string
+	Created time.Time
+}
+
+// Store keeps buckets as directories under its root. Each bucket holds an
+// objects directory of data files and a meta directory of JSON metadata,
+// both named by the escaped object key, so keys need not be valid paths.
+type Store struct {
+	dir string
+
+	// mu makes replacing an object's data and metadata appear atomic to
+	// readers
+	mu sync.RWMutex
+}
+
+// NewStore opens or creates a store rooted at dir
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// CreateBucket creates an empty bucket
+func (s *Store) CreateBucket(name string) error {
+	if err := ValidateBucketName(name); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.bucketExists(name) {
+		return ErrBucketExists
+	}
+	for _, sub := range []string{"objects", "meta"} {
+		if err := os.MkdirAll(filepath.Join(s.dir, name, sub), 0o755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteBucket removes an empty bucket
+func (s *Store) DeleteBucket(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.bucketExists(name) {
+		return ErrNoSuchBucket
+	}
+	names, err := s.objectNames(name)
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		return ErrBucketNotEmpty
+	}
+	return os.RemoveAll(filepath.Join(s.dir, name))
+}
+
+// BucketExists reports whether the bucket exists
+func (s *Store) BucketExists(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bucketExists(name)
+}
+
+func (s *Store) bucketExists(name string) bool {
+	if ValidateBucketName(name) != nil {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(s.dir, name, "objects"))
+	return err == nil && info.IsDir()
+}
+
+// Buckets lists the buckets by name
+func (s *Store) Buckets() ([]Bucket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var buckets []Bucket
+	for _, entry := range entries {
+		if !entry.IsDir() || !s.bucketExists(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, Bucket{Name: entry.Name(), Created: info.ModTime()})
+	}
+	return buckets, nil
+}
+
+// Put stores the data read from r as an object, replacing any object with
+// the same key
+func (s *Store) Put(bucket, key string, r io.Reader, attrs Attributes) (*Object, error) {
+	return s.write(bucket, key, r, objectMeta{Attributes: attrs})
+}
+
+// write stores an object, computing its ETag as the MD5 of its data unless
+// meta already has one
+func (s *Store) write(bucket, key string, r io.Reader, meta objectMeta) (*Object, error) {
+	name, err := fileName(key)
+	if err != nil {
+		return nil, err
+	}
+	if !s.BucketExists(bucket) {
+		return nil, ErrNoSuchBucket
+	}
+
+	// Write beside the destination, then rename over it; temporary names
+	// start with a dot, which escaped keys never do
+	objects := filepath.Join(s.dir, bucket, "objects")
+	tmp, err := os.CreateTemp(objects, ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := md5.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.ETag == "" {
+		meta.ETag = `"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.bucketExists(bucket) {
+		return nil, ErrNoSuchBucket
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, bucket, "meta", name+".json"), data, 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(objects, name)); err != nil {
+		return nil, err
+	}
+	return s.stat(bucket, key, name)
+}
+
+// Get opens an object for reading; the caller closes the file
+func (s *Store) Get(bucket, key string) (*os.File, *Object, error) {
+	name, err := fileName(key)
+	if err != nil {
+		return nil, nil, ErrNoSuchKey
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.bucketExists(bucket) {
+		return nil, nil, ErrNoSuchBucket
+	}
+	object, err := s.stat(bucket, key, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(filepath.Join(s.dir, bucket, "objects", name))
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, object, nil
+}
+
+// Stat describes an object
+func (s *Store) Stat(bucket, key string) (*Object, error) {
+	name, err := fileName(key)
+	if err != nil {
+		return nil, ErrNoSuchKey
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.bucketExists(bucket) {
+		return nil, ErrNoSuchBucket
+	}
+	return s.stat(bucket, key, name)
+}
+
+// stat reads an object's metadata. Files placed in the objects directory by
+// hand have none, so their type is guessed and their ETag computed.
+func (s *Store) stat(bucket, key, name string) (*Object, error) {
+	path := filepath.Join(s.dir, bucket, "objects", name)
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoSuchKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var meta objectMeta
+	data, err := os.ReadFile(filepath.Join(s.dir, bucket, "meta", name+".json"))
+	if err == nil {
+		err = json.Unmarshal(data, &meta)
+	}
+	if err != nil {
+		if meta.ETag, err = fileETag(path); err != nil {
+			return nil, err
+		}
+		meta.ContentType = mime.TypeByExtension(filepath.Ext(key))
+	}
+
+	return &Object{
+		Key:          key,
+		Size:         info.Size(),
+		ETag:         meta.ETag,
+		LastModified: info.ModTime().UTC(),
+		Attributes:   meta.Attributes,
+	}, nil
+}
+
+// Delete removes an object. Deleting a missing object succeeds, as in S3.
+func (s *Store) Delete(bucket, key string) error {
+	name, err := fileName(key)
+	if err != nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.bucketExists(bucket) {
+		return ErrNoSuchBucket
+	}
+	for _, path := range []string{filepath.Join(s.dir, bucket, "objects", name), filepath.Join(s.dir, bucket, "meta", name+".json")} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListOptions select and page the objects returned by List
+type ListOptions struct {
+	Prefix string
+
+	// Delimiter rolls keys sharing the text up to the next delimiter after
+	// the prefix into a single common prefix
+	Delimiter string
+
+	// StartAfter skips keys and common prefixes up to and including it
+	StartAfter string
+
+	// MaxKeys bounds the objects and common prefixes returned together
+	MaxKeys int
+}
+
+// ListResult is a page of objects and common prefixes, in key order
+type ListResult struct {
+	Objects        []Object
+	CommonPrefixes []string
+
+	// IsTruncated is set when more remain after NextMarker, the last key or
+	// common prefix returned
+	IsTruncated bool
+	NextMarker  string
+}
+
+// List returns the bucket's objects matching opts
+func (s *Store) List(bucket string, opts ListOptions) (*ListResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.bucketExists(bucket) {
+		return nil, ErrNoSuchBucket
+	}
+	names, err := s.objectNames(bucket)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]string, len(names))
+	sorted := make([]string, 0, len(names))
+	for _, name := range names {
+		key, err := url.QueryUnescape(name)
+		if err != nil || !strings.HasPrefix(key, opts.Prefix) {
+			continue
+		}
+		keys[key] = name
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	result := &ListResult{}
+	for _, key := range sorted {
+		commonPrefix := ""
+		if opts.Delimiter != "" {
+			if i := strings.Index(key[len(opts.Prefix):], opts.Delimiter); i >= 0 {
+				commonPrefix = key[:len(opts.Prefix)+i+len(opts.Delimiter)]
+			}
+		}
+
+		// A common prefix sorts before its keys, so one already returned,
+		// or skipped by StartAfter, is at or before the start
+		marker := key
+		if commonPrefix != "" {
+			marker = commonPrefix
+		}
+		if marker <= opts.StartAfter || marker == result.NextMarker {
+			continue
+		}
+		if len(result.Objects)+len(result.CommonPrefixes) == opts.MaxKeys {
+			result.IsTruncated = true
+			break
+		}
+
+		if commonPrefix != "" {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix)
+		} else {
+			object, err := s.stat(bucket, key, keys[key])
+			if err != nil {
+				continue
+			}
+			result.Objects = append(result.Objects, *object)
+		}
+		result.NextMarker = marker
+	}
+	return result, nil
+}
+
+// objectNames returns the file names of the bucket's objects
+func (s *Store) objectNames(bucket string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, bucket, "objects"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// Seed uploads the files under dir into the bucket when it is empty, keyed
+// by their slash-separated path relative to dir, and returns how many
+func (s *Store) Seed(bucket, dir string) (int, error) {
+	s.mu.RLock()
+	names, err := s.objectNames(bucket)
+	s.mu.RUnlock()
+	if err != nil || len(names) > 0 {
+		return 0, err
+	}
+
+	count := 0
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		key := filepath.ToSlash(rel)
+		if _, err := s.Put(bucket, key, f, Attributes{ContentType: mime.TypeByExtension(filepath.Ext(key))}); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// fileName escapes a key into a file name. A leading dot is escaped too, so
+// keys cannot name hidden or temporary files.
+func fileName(key string) (string, error) {
+	if key == "" || len(key) > 1024 || !utf8.ValidString(key) {
+		return "", ErrInvalidKey
+	}
+	name := url.QueryEscape(key)
+	if strings.HasPrefix(name, ".") {
+		name = "%2E" + name[1:]
+	}
+	if len(name) > maxFileName {
+		return "", ErrKeyTooLong
+	}
+	return name, nil
+}
+
+// fileETag computes the ETag of a file without stored metadata
+func fileETag(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)) + `"`, nil
+}
@@ -0,0 +1,233 @@
+package s3
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestStore(t *testing.T, buckets ...string) *Store {
+	t.Helper()
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bucket := range buckets {
+		if err := store.CreateBucket(bucket); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func put(t *testing.T, store *Store, bucket string, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		if _, err := store.Put(bucket, key, strings.NewReader(key), Attributes{}); err != nil {
+			t.Fatalf("Put %s failed: %v", key, err)
+		}
+	}
+}
+
+func TestStore_Buckets(t *testing.T) {
+	store := newTestStore(t, "uploads", "assets")
+
+	if err := store.CreateBucket("uploads"); !errors.Is(err, ErrBucketExists) {
+		t.Errorf("Expected ErrBucketExists, got %v", err)
+	}
+	if err := store.CreateBucket("Bad_Name"); !errors.Is(err, ErrInvalidBucketName) {
+		t.Errorf("Expected ErrInvalidBucketName, got %v", err)
+	}
+
+	buckets, err := store.Buckets()
+	if err != nil || len(buckets) != 2 || buckets[0].Name != "assets" || buckets[1].Name != "uploads" {
+		t.Errorf("Got buckets %+v, %v", buckets, err)
+	}
+
+	put(t, store, "assets", "logo.png")
+	if err := store.DeleteBucket("assets"); !errors.Is(err, ErrBucketNotEmpty) {
+		t.Errorf("Expected ErrBucketNotEmpty, got %v", err)
+	}
+	if err := store.DeleteBucket("uploads"); err != nil || store.BucketExists("uploads") {
+		t.Errorf("Expected uploads deleted, got %v", err)
+	}
+	if err := store.DeleteBucket("uploads"); !errors.Is(err, ErrNoSuchBucket) {
+		t.Errorf("Expected ErrNoSuchBucket, got %v", err)
+	}
+}
+
+func TestStore_PutGet(t *testing.T) {
+	store := newTestStore(t, "uploads")
+	attrs := Attributes{
+		ContentType: "text/plain",
+		Headers:     map[string]string{"Cache-Control": "no-cache"},
+		Metadata:    map[string]string{"owner": "alice"},
+	}
+
+	object, err := store.Put("uploads", "docs/hello.txt", strings.NewReader("hello world"), attrs)
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if object.Size != 11 || object.ETag != `"5eb63bbbe01eeed093cb22bb8f5acdc3"` || !reflect.DeepEqual(object.Attributes, attrs) {
+		t.Errorf("Got object %+v", object)
+	}
+
+	f, got, err := store.Get("uploads", "docs/hello.txt")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "hello world" || got.ETag != object.ETag || got.Metadata["owner"] != "alice" {
+		t.Errorf("Got %q %+v", data, got)
+	}
+
+	if _, err := store.Put("missing", "key", strings.NewReader(""), Attributes{}); !errors.Is(err, ErrNoSuchBucket) {
+		t.Errorf("Expected ErrNoSuchBucket, got %v", err)
+	}
+	if _, err := store.Stat("uploads", "docs/missing.txt"); !errors.Is(err, ErrNoSuchKey) {
+		t.Errorf("Expected ErrNoSuchKey, got %v", err)
+	}
+}
+
+func TestStore_Keys(t *testing.T) {
+	store := newTestStore(t, "uploads")
+	keys := []string{".env", "a/../b", "spaces and ?&#.txt", "ünïcode/ファイル", "trailing/"}
+	put(t, store, "uploads", keys...)
+
+	for _, key := range keys {
+		if _, err := store.Stat("uploads", key); err != nil {
+			t.Errorf("Stat %q failed: %v", key, err)
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Join(store.dir, "uploads", "objects"))
+	if len(entries) != len(keys) {
+		t.Errorf("Expected one file per key, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			t.Errorf("Key stored as hidden file %s", entry.Name())
+		}
+	}
+
+	for _, key := range []string{"", strings.Repeat("k", 1025), "\xff"} {
+		if _, err := store.Put("uploads", key, strings.NewReader(""), Attributes{}); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expected ErrInvalidKey for %q, got %v", key, err)
+		}
+	}
+	if _, err := store.Put("uploads", strings.Repeat("ü", 200), strings.NewReader(""), Attributes{}); !errors.Is(err, ErrKeyTooLong) {
+		t.Errorf("Expected ErrKeyTooLong, got %v", err)
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	store := newTestStore(t, "uploads")
+	put(t, store, "uploads", "a.txt")
+
+	if err := store.Delete("uploads", "a.txt"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Stat("uploads", "a.txt"); !errors.Is(err, ErrNoSuchKey) {
+		t.Errorf("Expected the object gone, got %v", err)
+	}
+	if err := store.Delete("uploads", "a.txt"); err != nil {
+		t.Errorf("Deleting a missing object should succeed, got %v", err)
+	}
+	if err := store.Delete("missing", "a.txt"); !errors.Is(err, ErrNoSuchBucket) {
+		t.Errorf("Expected ErrNoSuchBucket, got %v", err)
+	}
+}
+
+func TestStore_List(t *testing.T) {
+	store := newTestStore(t, "photos")
+	put(t, store, "photos", "2023/a.jpg", "2023/b.jpg", "2024/c.jpg", "2024/d/e.jpg", "index.html", "zebra.png")
+
+	keys := func(result *ListResult) []string {
+		var keys []string
+		for _, object := range result.Objects {
+			keys = append(keys, object.Key)
+		}
+		return keys
+	}
+
+	tests := []struct {
+		name          string
+		opts          ListOptions
+		wantKeys      []string
+		wantPrefixes  []string
+		wantTruncated bool
+	}{
+		{name: "all", opts: ListOptions{MaxKeys: 1000}, wantKeys: []string{"2023/a.jpg", "2023/b.jpg", "2024/c.jpg", "2024/d/e.jpg", "index.html", "zebra.png"}},
+		{name: "prefix", opts: ListOptions{Prefix: "2024/", MaxKeys: 1000}, wantKeys: []string{"2024/c.jpg", "2024/d/e.jpg"}},
+		{name: "delimiter", opts: ListOptions{Delimiter: "/", MaxKeys: 1000}, wantKeys: []string{"index.html", "zebra.png"}, wantPrefixes: []string{"2023/", "2024/"}},
+		{name: "prefix and delimiter", opts: ListOptions{Prefix: "2024/", Delimiter: "/", MaxKeys: 1000}, wantKeys: []string{"2024/c.jpg"}, wantPrefixes: []string{"2024/d/"}},
+		{name: "paged", opts: ListOptions{MaxKeys: 2}, wantKeys: []string{"2023/a.jpg", "2023/b.jpg"}, wantTruncated: true},
+		{name: "start after", opts: ListOptions{StartAfter: "2024/c.jpg", MaxKeys: 1000}, wantKeys: []string{"2024/d/e.jpg", "index.html", "zebra.png"}},
+		{name: "start after prefix", opts: ListOptions{Delimiter: "/", StartAfter: "2023/", MaxKeys: 2}, wantKeys: []string{"index.html"}, wantPrefixes: []string{"2024/"}, wantTruncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := store.List("photos", tt.opts)
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if !reflect.DeepEqual(keys(result), tt.wantKeys) || !reflect.DeepEqual(result.CommonPrefixes, tt.wantPrefixes) || result.IsTruncated != tt.wantTruncated {
+				t.Errorf("Got %v %v truncated %v", keys(result), result.CommonPrefixes, result.IsTruncated)
+			}
+		})
+	}
+
+	// Paging by NextMarker visits every key once
+	var all []string
+	opts := ListOptions{MaxKeys: 4}
+	for {
+		result, _ := store.List("photos", opts)
+		all = append(all, keys(result)...)
+		if !result.IsTruncated {
+			break
+		}
+		opts.StartAfter = result.NextMarker
+	}
+	if len(all) != 6 {
+		t.Errorf("Expected 6 keys over the pages, got %v", all)
+	}
+}
+
+func TestStore_Seed(t *testing.T) {
+	store := newTestStore(t, "fixtures")
+	seed := t.TempDir()
+	os.MkdirAll(filepath.Join(seed, "images"), 0o755)
+	os.WriteFile(filepath.Join(seed, "data.json"), []byte(`{}`), 0o644)
+	os.WriteFile(filepath.Join(seed, "images", "logo.png"), []byte("png"), 0o644)
+
+	count, err := store.Seed("fixtures", seed)
+	if err != nil || count != 2 {
+		t.Fatalf("Expected 2 objects seeded, got %d, %v", count, err)
+	}
+	object, err := store.Stat("fixtures", "images/logo.png")
+	if err != nil || object.ContentType != "image/png" {
+		t.Errorf("Got %+v, %v", object, err)
+	}
+
+	// A bucket with objects is left alone
+	if count, err := store.Seed("fixtures", seed); err != nil || count != 0 {
+		t.Errorf("Expected no objects seeded again, got %d, %v", count, err)
+	}
+}
+
+func TestStore_FileWithoutMetadata(t *testing.T) {
+	store := newTestStore(t, "uploads")
+	os.WriteFile(filepath.Join(store.dir, "uploads", "objects", "report.json"), []byte("hello world"), 0o644)
+
+	object, err := store.Stat("uploads", "report.json")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if object.ETag != `"5eb63bbbe01eeed093cb22bb8f5acdc3"` || object.ContentType != "application/json" {
+		t.Errorf("Got %+v", object)
+	}
+}
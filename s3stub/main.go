@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"local-dev-tools/s3stub/internal/s3"
+)
+
+// Process exit codes
+const (
+	exitOK = 0
+	// exitConfigError means the flags or config file were invalid
+	exitConfigError = 2
+	// exitRuntimeError means the data directory or listener failed
+	exitRuntimeError = 3
+)
+
+// bucketFlags collects repeated --bucket flags
+type bucketFlags []string
+
+func (b *bucketFlags) String() string { return strings.Join(*b, ",") }
+
+func (b *bucketFlags) Set(value string) error {
+	*b = append(*b, value)
+	return nil
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// run serves the S3 API until interrupted and returns the process exit
+// code
+func run() int {
+	configPath := flag.String("config", "", "Config file (YAML or JSON)")
+	listen := flag.String("listen", s3.DefaultListen, "Address to serve the S3 API on")
+	dataDir := flag.String("data-dir", s3.DefaultDataDir, "Directory to store buckets in")
+	region := flag.String("region", s3.DefaultRegion, "Region reported for buckets")
+	domain := flag.String("domain", s3.DefaultDomain, "Domain for virtual-hosted-style requests to <bucket>.<domain>")
+	var buckets bucketFlags
+	flag.Var(&buckets, "bucket", "Bucket to create at startup (repeatable)")
+	quiet := flag.Bool("quiet", false, "Do not log each request")
+	flag.Parse()
+
+	config := &s3.Config{}
+	if *configPath != "" {
+		var err error
+		if config, err = s3.LoadConfig(*configPath); err != nil {
+			log.Printf("Error loading config: %v", err)
+			return exitConfigError
+		}
+	}
+
+	// Flags given explicitly take precedence over the config file
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "listen":
+			config.Listen = *listen
+		case "data-dir":
+			config.DataDir = *dataDir
+		case "region":
+			config.Region = *region
+		case "domain":
+			config.Domain = *domain
+		}
+	})
+	for _, name := range buckets {
+		config.Buckets = append(config.Buckets, s3.BucketConfig{Name: name})
+	}
+	if err := config.Validate(); err != nil {
+		log.Printf("Error: %v", err)
+		return exitConfigError
+	}
+
+	store, err := s3.NewStore(config.DataDir)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+	for _, bucket := range config.Buckets {
+		if err := store.CreateBucket(bucket.Name); err != nil && !errors.Is(err, s3.ErrBucketExists) {
+			log.Printf("Error creating bucket %s: %v", bucket.Name, err)
+			return exitRuntimeError
+		}
+		if bucket.Seed == "" {
+			continue
+		}
+		seeded, err := store.Seed(bucket.Name, bucket.Seed)
+		if err != nil {
+			log.Printf("Error seeding bucket %s: %v", bucket.Name, err)
+			return exitRuntimeError
+		}
+		if seeded > 0 {
+			log.Printf("Seeded %s with %d objects from %s", bucket.Name, seeded, bucket.Seed)
+		}
+	}
+
+	listener, err := net.Listen("tcp", config.Listen)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+
+	logf := log.Printf
+	if *quiet {
+		logf = nil
+	}
+	server := &http.Server{Handler: s3.NewServer(store, config, logf), ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving S3 at http://%s from %s", endpointAddress(listener.Addr()), config.DataDir)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Error: %v", err)
+		return exitRuntimeError
+	}
+	log.Printf("Stopped")
+	return exitOK
+}
+
+// endpointAddress returns a usable address for the listener, replacing an
+// unspecified host with localhost
+func endpointAddress(addr net.Addr) string {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok || !tcp.IP.IsUnspecified() {
+		return addr.String()
+	}
+	return net.JoinHostPort("localhost", fmt.Sprint(tcp.Port))
+}
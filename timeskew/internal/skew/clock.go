@@ -0,0 +1,80 @@
+// Package skew shifts the timestamps in HTTP traffic onto a virtual clock,
+// so services can be run against a future or past time without changing the
+// system clock.
+package skew
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clock maps real instants onto a virtual timeline that starts Offset away
+// from the real time at Start and runs Scale times as fast
+type Clock struct {
+	Start  time.Time
+	Offset time.Duration
+	Scale  float64
+}
+
+// NewClock creates a clock starting now
+func NewClock(offset time.Duration, scale float64) *Clock {
+	return &Clock{Start: time.Now(), Offset: offset, Scale: scale}
+}
+
+// Map returns the virtual time of a real instant. Instants before or after
+// Start are mapped too, so a deadline an hour from now moves with the clock
+// and, when scaled, lands Scale hours from virtual now.
+func (c *Clock) Map(t time.Time) time.Time {
+	// Only the speed-up goes through floating point, so an unscaled clock
+	// maps exactly however far the instant is from Start
+	virtual := t.Add(c.Offset)
+	if c.Scale != 1 {
+		virtual = virtual.Add(time.Duration(float64(t.Sub(c.Start)) * (c.Scale - 1)))
+	}
+	return virtual.In(t.Location())
+}
+
+// Now returns the current virtual time
+func (c *Clock) Now() time.Time {
+	return c.Map(time.Now())
+}
+
+// ParseOffset parses a signed Go duration that may start with a number of
+// days, such as 30d, -2h or 1d12h
+func ParseOffset(value string) (time.Duration, error) {
+	s := strings.TrimPrefix(value, "+")
+	sign := time.Duration(1)
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		sign, s = -1, rest
+	}
+
+	var days time.Duration
+	if i := strings.IndexByte(s, 'd'); i > 0 {
+		n, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid offset %q (expected a duration such as 30d, 2h or -90m)", value)
+		}
+		days, s = time.Duration(n*float64(24*time.Hour)), s[i+1:]
+	}
+
+	var rest time.Duration
+	if s != "" {
+		var err error
+		if rest, err = time.ParseDuration(s); err != nil || rest < 0 {
+			return 0, fmt.Errorf("invalid offset %q (expected a duration such as 30d, 2h or -90m)", value)
+		}
+	}
+	return sign * (days + rest), nil
+}
+
+// ParseScale parses a positive speed-up factor such as 60x, as the
+// scheduler's --time-scale does
+func ParseScale(value string) (float64, error) {
+	scale, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(value), "x"), 64)
+	if err != nil || scale <= 0 {
+		return 0, fmt.Errorf("invalid time scale %q (expected a positive factor such as 60x)", value)
+	}
+	return scale, nil
+}
@@ -0,0 +1,69 @@
+package skew
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClock_Map(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		clock Clock
+		in    time.Time
+		want  time.Time
+	}{
+		{name: "offset", clock: Clock{Start: start, Offset: 30 * 24 * time.Hour, Scale: 1}, in: start, want: start.AddDate(0, 0, 30)},
+		{name: "far past exact", clock: Clock{Start: start, Offset: time.Hour, Scale: 1}, in: time.Date(1999, 1, 1, 0, 0, 0, 1, time.UTC), want: time.Date(1999, 1, 1, 1, 0, 0, 1, time.UTC)},
+		{name: "scaled future", clock: Clock{Start: start, Scale: 60}, in: start.Add(time.Minute), want: start.Add(time.Hour)},
+		{name: "scaled past", clock: Clock{Start: start, Offset: time.Hour, Scale: 2}, in: start.Add(-time.Hour), want: start.Add(-time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.clock.Map(tt.in); !got.Equal(tt.want) {
+				t.Errorf("Map(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	// The instant's zone is kept
+	berlin := time.FixedZone("CEST", 2*60*60)
+	clock := Clock{Start: start, Offset: time.Hour, Scale: 1}
+	if got := clock.Map(start.In(berlin)); got.Location() != berlin {
+		t.Errorf("Expected the zone kept, got %v", got)
+	}
+}
+
+func TestParseOffset(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "2h", want: 2 * time.Hour},
+		{in: "+30d", want: 30 * 24 * time.Hour},
+		{in: "-1d12h", want: -36 * time.Hour},
+		{in: "-90m", want: -90 * time.Minute},
+		{in: "1.5d", want: 36 * time.Hour},
+		{in: "soon", wantErr: true},
+		{in: "1d-2h", wantErr: true},
+		{in: "d", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseOffset(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseOffset(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestParseScale(t *testing.T) {
+	if scale, err := ParseScale("60x"); err != nil || scale != 60 {
+		t.Errorf("Got %v, %v", scale, err)
+	}
+	for _, in := range []string{"0", "-2x", "fast"} {
+		if _, err := ParseScale(in); err == nil {
+			t.Errorf("Expected an error for %q", in)
+		}
+	}
+}
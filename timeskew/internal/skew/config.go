@@ -0,0 +1,132 @@
+package skew
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultListen is the proxy's address when the config leaves it out
+const DefaultListen = ":8083"
+
+// DefaultHeaders are the headers rewritten when the config lists none
+var DefaultHeaders = []string{"Date", "Expires", "Last-Modified"}
+
+// What to rewrite
+const (
+	RewriteResponses = "responses"
+	RewriteRequests  = "requests"
+	RewriteBoth      = "both"
+)
+
+// Config is the proxy's configuration file
+type Config struct {
+	// Target is the service being fronted, http://host:port
+	Target string `yaml:"target"`
+
+	// Listen defaults to :8083
+	Listen string `yaml:"listen,omitempty"`
+
+	// Offset shifts the virtual clock from real time, e.g. 30d or -2h
+	Offset string `yaml:"offset,omitempty"`
+
+	// At starts the virtual clock at an RFC 3339 time instead of an offset
+	At string `yaml:"at,omitempty"`
+
+	// Scale runs the virtual clock faster than real time, e.g. 60x
+	Scale string `yaml:"scale,omitempty"`
+
+	// Rewrite is responses (the default), requests or both
+	Rewrite string `yaml:"rewrite,omitempty"`
+
+	// Headers hold HTTP dates to rewrite; Date, Expires and Last-Modified
+	// when empty
+	Headers []string `yaml:"headers,omitempty"`
+
+	// Fields are names of JSON fields, at any depth, whose timestamps are
+	// rewritten; "*" rewrites every RFC 3339 string
+	Fields []string `yaml:"fields,omitempty"`
+
+	offset time.Duration
+	at     time.Time
+	scale  float64
+}
+
+// LoadConfig reads a YAML or JSON config file. It is validated once flags
+// have been applied.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return &config, nil
+}
+
+// Validate checks the target and clock settings and fills defaults
+func (c *Config) Validate() error {
+	if c.Target == "" {
+		return fmt.Errorf("target is required (e.g. http://localhost:3000)")
+	}
+	u, err := url.Parse(c.Target)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid target %q: use http:// or https://host:port", c.Target)
+	}
+	if c.Listen == "" {
+		c.Listen = DefaultListen
+	}
+
+	switch {
+	case c.Offset != "" && c.At != "":
+		return fmt.Errorf("offset and at cannot both be set")
+	case c.Offset != "":
+		if c.offset, err = ParseOffset(c.Offset); err != nil {
+			return err
+		}
+	case c.At != "":
+		if c.at, err = time.Parse(time.RFC3339, c.At); err != nil {
+			return fmt.Errorf("invalid at %q (expected RFC 3339, e.g. 2030-01-01T09:00:00Z)", c.At)
+		}
+	}
+
+	c.scale = 1
+	if c.Scale != "" {
+		if c.scale, err = ParseScale(c.Scale); err != nil {
+			return err
+		}
+	}
+
+	switch c.Rewrite {
+	case "":
+		c.Rewrite = RewriteResponses
+	case RewriteResponses, RewriteRequests, RewriteBoth:
+	default:
+		return fmt.Errorf("invalid rewrite %q (expected responses, requests or both)", c.Rewrite)
+	}
+
+	if len(c.Headers) == 0 {
+		c.Headers = append([]string(nil), DefaultHeaders...)
+	}
+	for i, name := range c.Headers {
+		c.Headers[i] = http.CanonicalHeaderKey(name)
+	}
+	return nil
+}
+
+// Clock returns a virtual clock starting now with the configured offset or
+// start time
+func (c *Config) Clock() *Clock {
+	clock := NewClock(c.offset, c.scale)
+	if !c.at.IsZero() {
+		clock.Offset = c.at.Sub(clock.Start)
+	}
+	return clock
+}
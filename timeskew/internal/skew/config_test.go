@@ -0,0 +1,81 @@
+package skew
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timeskew.yaml")
+	os.WriteFile(path, []byte(`
+target: http://localhost:3000
+offset: 30d
+scale: 60x
+rewrite: both
+headers: [date, x-expires-at]
+fields: [created_at, exp]
+`), 0o644)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if config.Listen != DefaultListen || config.offset != 30*24*time.Hour || config.scale != 60 || config.Rewrite != RewriteBoth {
+		t.Errorf("Got settings %+v", config)
+	}
+	if !reflect.DeepEqual(config.Headers, []string{"Date", "X-Expires-At"}) {
+		t.Errorf("Expected canonical headers, got %v", config.Headers)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{name: "no target", config: Config{}, wantErr: "target is required"},
+		{name: "tcp target", config: Config{Target: "tcp://localhost:5432"}, wantErr: "invalid target"},
+		{name: "offset and at", config: Config{Target: "http://localhost:3000", Offset: "1h", At: "2030-01-01T00:00:00Z"}, wantErr: "cannot both"},
+		{name: "bad offset", config: Config{Target: "http://localhost:3000", Offset: "later"}, wantErr: "invalid offset"},
+		{name: "bad at", config: Config{Target: "http://localhost:3000", At: "2030-01-01"}, wantErr: "invalid at"},
+		{name: "bad scale", config: Config{Target: "http://localhost:3000", Scale: "0x"}, wantErr: "invalid time scale"},
+		{name: "bad rewrite", config: Config{Target: "http://localhost:3000", Rewrite: "bodies"}, wantErr: "invalid rewrite"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	config := Config{Target: "http://localhost:3000"}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if config.Rewrite != RewriteResponses || config.scale != 1 || !reflect.DeepEqual(config.Headers, DefaultHeaders) {
+		t.Errorf("Expected defaults, got %+v", config)
+	}
+}
+
+func TestConfig_Clock(t *testing.T) {
+	config := Config{Target: "http://localhost:3000", At: "2030-01-01T09:00:00Z"}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	clock := config.Clock()
+	want := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	if got := clock.Map(clock.Start); !got.Equal(want) {
+		t.Errorf("Expected the clock to start at %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,181 @@
+package skew
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// maxBodySize bounds the JSON bodies buffered for rewriting; larger ones
+// pass through unchanged
+const maxBodySize = 10 << 20
+
+// rewritesKey carries a request's count of rewritten timestamps from the
+// request to its response
+type rewritesKey struct{}
+
+// Proxy forwards HTTP requests to the target, rewriting the timestamps in
+// requests, responses or both onto its clock
+type Proxy struct {
+	config   *Config
+	clock    *Clock
+	rewriter *Rewriter
+	proxy    *httputil.ReverseProxy
+	logf     func(format string, args ...interface{})
+}
+
+// NewProxy creates a proxy for a validated config. logf receives one line
+// per request and may be nil.
+func NewProxy(config *Config, clock *Clock, logf func(format string, args ...interface{})) (*Proxy, error) {
+	target, err := url.Parse(config.Target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target: %w", err)
+	}
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+
+	p := &Proxy{
+		config:   config,
+		clock:    clock,
+		rewriter: NewRewriter(clock, config.Headers, config.Fields),
+		logf:     logf,
+	}
+	p.proxy = httputil.NewSingleHostReverseProxy(target)
+	director := p.proxy.Director
+	p.proxy.Director = func(r *http.Request) {
+		director(r)
+		// Drop the client's encodings so the transport asks for gzip, which
+		// it decompresses itself, leaving bodies that can be rewritten
+		if p.rewritesResponses() && p.rewriter.RewritesBodies() {
+			r.Header.Del("Accept-Encoding")
+		}
+	}
+	p.proxy.ModifyResponse = p.modifyResponse
+	p.proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		http.Error(w, fmt.Sprintf("timeskew: upstream error: %v", err), http.StatusBadGateway)
+	}
+	return p, nil
+}
+
+// ServeHTTP rewrites the request if configured and proxies it
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rewrites := new(atomic.Int64)
+	r = r.WithContext(context.WithValue(r.Context(), rewritesKey{}, rewrites))
+
+	if p.config.Rewrite != RewriteResponses {
+		rewrites.Add(int64(p.rewriter.Header(r.Header)))
+		body, length, changed, err := p.rewriteBody(r.Header, r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("timeskew: failed to read request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		r.Body = body
+		if length >= 0 {
+			r.ContentLength = length
+		}
+		rewrites.Add(int64(changed))
+	}
+
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	p.proxy.ServeHTTP(recorder, r)
+	p.logf("%s %s -> %d (%d rewritten, %v)", r.Method, r.URL.Path, recorder.status, rewrites.Load(), time.Since(start).Round(time.Millisecond))
+}
+
+func (p *Proxy) rewritesResponses() bool {
+	return p.config.Rewrite != RewriteRequests
+}
+
+// modifyResponse rewrites the response if configured
+func (p *Proxy) modifyResponse(resp *http.Response) error {
+	if !p.rewritesResponses() {
+		return nil
+	}
+	rewrites, _ := resp.Request.Context().Value(rewritesKey{}).(*atomic.Int64)
+	if rewrites == nil {
+		rewrites = new(atomic.Int64)
+	}
+
+	// Servers add a real Date to responses without one
+	if resp.Header.Get("Date") == "" && p.rewriter.rewritesHeader("Date") {
+		resp.Header.Set("Date", p.clock.Now().UTC().Format(http.TimeFormat))
+	}
+	rewrites.Add(int64(p.rewriter.Header(resp.Header)))
+	body, length, changed, err := p.rewriteBody(resp.Header, resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = body
+	if length >= 0 {
+		resp.ContentLength = length
+		resp.Header.Set("Content-Length", strconv.FormatInt(length, 10))
+	}
+	rewrites.Add(int64(changed))
+	return nil
+}
+
+// rewriteBody rewrites an uncompressed JSON body of up to maxBodySize. It
+// returns the body to send on, its new length or -1 when it is unchanged,
+// and how many timestamps were rewritten. Bodies that fail to parse are
+// sent on as they were.
+func (p *Proxy) rewriteBody(header http.Header, body io.ReadCloser) (io.ReadCloser, int64, int, error) {
+	if !p.rewriter.RewritesBodies() || body == nil || body == http.NoBody || !isJSON(header.Get("Content-Type")) || header.Get("Content-Encoding") != "" {
+		return body, -1, 0, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxBodySize+1))
+	if err != nil {
+		body.Close()
+		return nil, -1, 0, err
+	}
+	if len(data) > maxBodySize {
+		return readCloser{io.MultiReader(bytes.NewReader(data), body), body}, -1, 0, nil
+	}
+	body.Close()
+
+	rewritten, changed, err := p.rewriter.JSON(data)
+	if err != nil || changed == 0 {
+		return io.NopCloser(bytes.NewReader(data)), int64(len(data)), 0, nil
+	}
+	return io.NopCloser(bytes.NewReader(rewritten)), int64(len(rewritten)), changed, nil
+}
+
+// isJSON reports whether a content type is JSON or newline-delimited JSON
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "application/x-ndjson" || strings.HasSuffix(mediaType, "+json")
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// statusRecorder captures the response status for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController flush the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
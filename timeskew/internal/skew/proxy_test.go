@@ -0,0 +1,110 @@
+package skew
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestProxy(t *testing.T, upstream http.HandlerFunc, config *Config) *httptest.Server {
+	t.Helper()
+	backend := httptest.NewServer(upstream)
+	t.Cleanup(backend.Close)
+
+	config.Target = backend.URL
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	clock := &Clock{Start: time.Now(), Offset: 24 * time.Hour, Scale: 1}
+	proxy, err := NewProxy(config, clock, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(proxy)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProxy_RewritesResponses(t *testing.T) {
+	var acceptEncoding string
+	server := newTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Last-Modified", "Wed, 01 May 2024 10:00:00 GMT")
+		io.WriteString(w, `{"created_at": "2024-05-01T10:00:00Z"}`)
+	}, &Config{Fields: []string{"created_at"}})
+
+	before := time.Now().Add(24 * time.Hour).Add(-time.Second)
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/orders/1", nil)
+	req.Header.Set("Accept-Encoding", "br")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != `{"created_at":"2024-05-02T10:00:00Z"}` || resp.ContentLength != int64(len(body)) {
+		t.Errorf("Got body %s with length %d", body, resp.ContentLength)
+	}
+	if resp.Header.Get("Last-Modified") != "Thu, 02 May 2024 10:00:00 GMT" {
+		t.Errorf("Got Last-Modified %s", resp.Header.Get("Last-Modified"))
+	}
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err != nil || date.Before(before) {
+		t.Errorf("Expected a Date a day ahead, got %s", resp.Header.Get("Date"))
+	}
+	if strings.Contains(acceptEncoding, "br") {
+		t.Errorf("Expected the client's encodings dropped, got Accept-Encoding %q", acceptEncoding)
+	}
+}
+
+func TestProxy_RewritesRequests(t *testing.T) {
+	var got string
+	server := newTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"at": "2024-05-01T10:00:00Z"}`)
+	}, &Config{Rewrite: RewriteRequests, Fields: []string{"at"}})
+
+	resp, err := http.Post(server.URL, "application/json", strings.NewReader(`{"at": "2024-05-01T10:00:00Z"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if got != `{"at":"2024-05-02T10:00:00Z"}` {
+		t.Errorf("Upstream got %s", got)
+	}
+	if string(body) != `{"at": "2024-05-01T10:00:00Z"}` {
+		t.Errorf("Expected the response untouched, got %s", body)
+	}
+}
+
+func TestProxy_PassesOtherBodies(t *testing.T) {
+	server := newTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"at": "2024-05-01T10:00:00Z"`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, `{"at": "2024-05-01T10:00:00Z"}`)
+	}, &Config{Fields: []string{"at"}})
+
+	for _, path := range []string{"/text", "/broken"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !strings.HasPrefix(string(body), `{"at": "2024-05-01T10:00:00Z"`) {
+			t.Errorf("Expected %s passed through, got %s", path, body)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package skew
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bounds of the integers taken as Unix timestamps: seconds from 1973 to
+// 5138, and milliseconds beyond that until 5138 again. Other numbers, such
+// as counts and IDs, are left alone.
+const (
+	minUnixSeconds = 1e8
+	minUnixMillis  = 1e11
+	maxUnixMillis  = 1e14
+)
+
+// Rewriter maps the timestamps in headers and JSON bodies onto a virtual
+// clock
+type Rewriter struct {
+	clock   *Clock
+	headers []string
+
+	// fields are the JSON field names whose values are rewritten, or all
+	// RFC 3339 strings when anyField is set
+	fields   map[string]bool
+	anyField bool
+}
+
+// NewRewriter creates a rewriter for the given canonical header names and
+// JSON field names
+func NewRewriter(clock *Clock, headers, fields []string) *Rewriter {
+	r := &Rewriter{clock: clock, headers: headers, fields: make(map[string]bool)}
+	for _, field := range fields {
+		if field == "*" {
+			r.anyField = true
+		}
+		r.fields[field] = true
+	}
+	return r
+}
+
+// RewritesBodies reports whether any JSON fields are rewritten
+func (r *Rewriter) RewritesBodies() bool {
+	return len(r.fields) > 0
+}
+
+// Header rewrites the HTTP dates in the rewriter's headers and returns how
+// many it changed. Values that are not dates, such as Expires: 0, are kept.
+func (r *Rewriter) Header(h http.Header) int {
+	changed := 0
+	for _, name := range r.headers {
+		values := h[name]
+		for i, value := range values {
+			t, err := http.ParseTime(value)
+			if err != nil {
+				continue
+			}
+			values[i] = r.clock.Map(t).UTC().Format(http.TimeFormat)
+			changed++
+		}
+	}
+	return changed
+}
+
+func (r *Rewriter) rewritesHeader(name string) bool {
+	for _, header := range r.headers {
+		if header == name {
+			return true
+		}
+	}
+	return false
+}
+
+// JSON rewrites the timestamps of the rewriter's fields in a JSON document,
+// or a stream of them, and returns the result and how many it changed. Keys
+// keep their order, but insignificant whitespace is dropped.
+func (r *Rewriter) JSON(data []byte) ([]byte, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	w := &jsonWriter{}
+	changed := 0
+
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			if len(w.stack) > 0 {
+				return nil, 0, io.ErrUnexpectedEOF
+			}
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		switch t := token.(type) {
+		case json.Delim:
+			w.delim(t)
+		case string:
+			if w.expectingKey() {
+				w.key(t)
+				continue
+			}
+			if s, ok := r.timestamp(w.field(), t); ok {
+				t = s
+				changed++
+			}
+			w.value(quote(t))
+		case json.Number:
+			value := t.String()
+			if s, ok := r.unix(w.field(), t); ok {
+				value = s
+				changed++
+			}
+			w.value(value)
+		case bool:
+			w.value(strconv.FormatBool(t))
+		case nil:
+			w.value("null")
+		}
+	}
+	return w.buf.Bytes(), changed, nil
+}
+
+// timestamp rewrites a string holding an RFC 3339 time, an HTTP date or,
+// for named fields, a date, keeping its layout
+func (r *Rewriter) timestamp(field, value string) (string, bool) {
+	named := r.fields[field]
+	if !named && !r.anyField {
+		return "", false
+	}
+
+	layouts := []string{time.RFC3339Nano}
+	if named {
+		layouts = append(layouts, http.TimeFormat, time.DateOnly)
+	}
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		switch layout {
+		case time.RFC3339Nano:
+			if !strings.Contains(value, ".") {
+				layout = time.RFC3339
+			}
+		case http.TimeFormat:
+			return r.clock.Map(t).UTC().Format(layout), true
+		}
+		return r.clock.Map(t).Format(layout), true
+	}
+	return "", false
+}
+
+// unix rewrites an integer Unix timestamp in seconds or milliseconds in a
+// named field
+func (r *Rewriter) unix(field string, value json.Number) (string, bool) {
+	if !r.fields[field] {
+		return "", false
+	}
+	n, err := value.Int64()
+	if err != nil || n < minUnixSeconds || n >= maxUnixMillis {
+		return "", false
+	}
+	if n < minUnixMillis {
+		return strconv.FormatInt(r.clock.Map(time.Unix(n, 0)).Unix(), 10), true
+	}
+	return strconv.FormatInt(r.clock.Map(time.UnixMilli(n)).UnixMilli(), 10), true
+}
+
+// jsonWriter writes a token stream back out as compact JSON, tracking the
+// field each value belongs to
+type jsonWriter struct {
+	buf    bytes.Buffer
+	stack  []jsonFrame
+	values int
+}
+
+// jsonFrame is an open object or array
+type jsonFrame struct {
+	object bool
+	// items counts the keys and values written in the frame
+	items int
+	// name is the field the frame's values belong to: the last key in an
+	// object, or the array's own field
+	name string
+}
+
+func (w *jsonWriter) top() *jsonFrame {
+	if len(w.stack) == 0 {
+		return nil
+	}
+	return &w.stack[len(w.stack)-1]
+}
+
+func (w *jsonWriter) expectingKey() bool {
+	top := w.top()
+	return top != nil && top.object && top.items%2 == 0
+}
+
+// field returns the name of the field the next value belongs to
+func (w *jsonWriter) field() string {
+	if top := w.top(); top != nil {
+		return top.name
+	}
+	return ""
+}
+
+// separate writes what goes before the next key or value
+func (w *jsonWriter) separate() {
+	top := w.top()
+	switch {
+	case top == nil:
+		if w.values > 0 {
+			w.buf.WriteByte('\n')
+		}
+	case top.object && top.items%2 == 1:
+		w.buf.WriteByte(':')
+	case top.items > 0:
+		w.buf.WriteByte(',')
+	}
+}
+
+// wrote counts a complete key or value in the enclosing frame
+func (w *jsonWriter) wrote() {
+	if top := w.top(); top != nil {
+		top.items++
+	} else {
+		w.values++
+	}
+}
+
+func (w *jsonWriter) key(name string) {
+	w.separate()
+	w.buf.WriteString(quote(name))
+	top := w.top()
+	top.name = name
+	top.items++
+}
+
+func (w *jsonWriter) value(text string) {
+	w.separate()
+	w.buf.WriteString(text)
+	w.wrote()
+}
+
+func (w *jsonWriter) delim(d json.Delim) {
+	switch d {
+	case '{', '[':
+		w.separate()
+		w.buf.WriteByte(byte(d))
+		w.stack = append(w.stack, jsonFrame{object: d == '{', name: w.field()})
+	default:
+		w.buf.WriteByte(byte(d))
+		w.stack = w.stack[:len(w.stack)-1]
+		w.wrote()
+	}
+}
+
+// quote encodes a JSON string without escaping HTML characters
+func quote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
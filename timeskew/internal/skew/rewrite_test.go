@@ -0,0 +1,99 @@
+package skew
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func testRewriter(fields ...string) *Rewriter {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	return NewRewriter(&Clock{Start: start, Offset: 24 * time.Hour, Scale: 1}, DefaultHeaders, fields)
+}
+
+func TestRewriter_Header(t *testing.T) {
+	r := testRewriter()
+	h := http.Header{
+		"Date":          {"Wed, 01 May 2024 10:00:00 GMT"},
+		"Expires":       {"0"},
+		"Last-Modified": {"Tuesday, 30-Apr-24 10:00:00 GMT"},
+		"X-Other":       {"Wed, 01 May 2024 10:00:00 GMT"},
+	}
+	if changed := r.Header(h); changed != 2 {
+		t.Errorf("Expected 2 headers changed, got %d", changed)
+	}
+	if h.Get("Date") != "Thu, 02 May 2024 10:00:00 GMT" || h.Get("Last-Modified") != "Wed, 01 May 2024 10:00:00 GMT" {
+		t.Errorf("Got %v", h)
+	}
+	if h.Get("Expires") != "0" || h.Get("X-Other") != "Wed, 01 May 2024 10:00:00 GMT" {
+		t.Errorf("Expected other values kept, got %v", h)
+	}
+}
+
+func TestRewriter_JSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		fields      []string
+		in          string
+		want        string
+		wantChanged int
+	}{
+		{
+			name:        "named fields",
+			fields:      []string{"created_at", "exp", "day"},
+			in:          `{"id": 1714557600, "created_at": "2024-05-01T10:00:00Z", "token": {"exp": 1714557600, "iat": 1714557600}, "day": "2024-05-01"}`,
+			want:        `{"id":1714557600,"created_at":"2024-05-02T10:00:00Z","token":{"exp":1714644000,"iat":1714557600},"day":"2024-05-02"}`,
+			wantChanged: 3,
+		},
+		{
+			name:        "milliseconds and zones",
+			fields:      []string{"ts"},
+			in:          `[{"ts": 1714557600000}, {"ts": "2024-05-01T12:00:00.250+02:00"}]`,
+			want:        `[{"ts":1714644000000},{"ts":"2024-05-02T12:00:00.25+02:00"}]`,
+			wantChanged: 2,
+		},
+		{
+			name:        "arrays inherit the field",
+			fields:      []string{"dates"},
+			in:          `{"dates": ["2024-05-01T10:00:00Z", "not a date", 5]}`,
+			want:        `{"dates":["2024-05-02T10:00:00Z","not a date",5]}`,
+			wantChanged: 1,
+		},
+		{
+			name:        "any field",
+			fields:      []string{"*"},
+			in:          `{"a": "2024-05-01T10:00:00Z", "b": {"c": "2024-05-01T10:00:00Z"}, "n": 1714557600, "d": "2024-05-01"}`,
+			want:        `{"a":"2024-05-02T10:00:00Z","b":{"c":"2024-05-02T10:00:00Z"},"n":1714557600,"d":"2024-05-01"}`,
+			wantChanged: 2,
+		},
+		{
+			name:        "kept as is",
+			fields:      []string{"at"},
+			in:          `{"html": "<b>&amp;</b>", "ok": true, "none": null, "big": 12345678901234567890, "at": 1.5, "empty": {}, "list": []}`,
+			want:        `{"html":"<b>&amp;</b>","ok":true,"none":null,"big":12345678901234567890,"at":1.5,"empty":{},"list":[]}`,
+			wantChanged: 0,
+		},
+		{
+			name:        "stream",
+			fields:      []string{"at"},
+			in:          "{\"at\": \"2024-05-01T10:00:00Z\"}\n{\"at\": \"2024-05-01T11:00:00Z\"}\n",
+			want:        "{\"at\":\"2024-05-02T10:00:00Z\"}\n{\"at\":\"2024-05-02T11:00:00Z\"}",
+			wantChanged: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := testRewriter(tt.fields...).JSON([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("JSON failed: %v", err)
+			}
+			if string(got) != tt.want || changed != tt.wantChanged {
+				t.Errorf("Got %s (%d changed), want %s (%d)", got, changed, tt.want, tt.wantChanged)
+			}
+		})
+	}
+
+	if _, _, err := testRewriter("at").JSON([]byte(`{"at": `)); err == nil {
+		t.Error("Expected an error for truncated JSON")
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"local-dev-tools/timeskew/internal/skew"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run proxies requests until interrupted and returns the process exit code
+func run() int {
+	configPath := flag.String("config", "", "Path to a config file (YAML or JSON)")
+	target := flag.String("target", "", "Service to front, http://host:port")
+	listen := flag.String("listen", skew.DefaultListen, "Address to listen on")
+	offset := flag.String("offset", "", "Shift of the virtual clock from real time (e.g. 30d, 2h, -90m)")
+	at := flag.String("at", "", "Start the virtual clock at this time instead (RFC 3339, e.g. 2030-01-01T09:00:00Z)")
+	scale := flag.String("scale", "", "Run the virtual clock this many times faster than real time (e.g. 60x)")
+	rewrite := flag.String("rewrite", skew.RewriteResponses, "What to rewrite: responses, requests or both")
+	headers := flag.String("headers", strings.Join(skew.DefaultHeaders, ","), "Comma-separated headers holding HTTP dates to rewrite")
+	fields := flag.String("fields", "", "Comma-separated JSON fields whose timestamps to rewrite, or * for every RFC 3339 string")
+	quiet := flag.Bool("quiet", false, "Do not log each request")
+	flag.Parse()
+
+	config := &skew.Config{}
+	if *configPath != "" {
+		var err error
+		if config, err = skew.LoadConfig(*configPath); err != nil {
+			log.Printf("Error loading config: %v", err)
+			return 2
+		}
+	}
+
+	// Flags given explicitly take precedence over the config file
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "target":
+			config.Target = *target
+		case "listen":
+			config.Listen = *listen
+		case "offset":
+			config.Offset, config.At = *offset, ""
+		case "at":
+			config.At, config.Offset = *at, ""
+		case "scale":
+			config.Scale = *scale
+		case "rewrite":
+			config.Rewrite = *rewrite
+		case "headers":
+			config.Headers = splitList(*headers)
+		case "fields":
+			config.Fields = splitList(*fields)
+		}
+	})
+	if err := config.Validate(); err != nil {
+		log.Printf("Error: %v", err)
+		return 2
+	}
+
+	logf := log.Printf
+	if *quiet {
+		logf = nil
+	}
+	clock := config.Clock()
+	proxy, err := skew.NewProxy(config, clock, logf)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 2
+	}
+
+	listener, err := net.Listen("tcp", config.Listen)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 3
+	}
+
+	fmt.Printf("Proxying %s -> %s (virtual time %s", config.Listen, config.Target, clock.Now().Format(time.RFC3339))
+	if clock.Scale != 1 {
+		fmt.Printf(" at %gx", clock.Scale)
+	}
+	fmt.Printf("; rewriting %s)\n", config.Rewrite)
+
+	server := &http.Server{Handler: proxy}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		fmt.Println("\nShutting down...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Proxy error: %v", err)
+		return 3
+	}
+	return 0
+}
+
+// splitList splits a comma-separated flag, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
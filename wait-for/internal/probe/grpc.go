@@ -0,0 +1,155 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/healthcheck"
+)
+
+// healthCheckPath is the method of the standard gRPC health service
+const healthCheckPath = "/grpc.health.v1.Health/Check"
+
+// Serving statuses of grpc.health.v1.HealthCheckResponse
+var servingStatuses = map[uint64]string{
+	0: "UNKNOWN",
+	1: "SERVING",
+	2: "NOT_SERVING",
+	3: "SERVICE_UNKNOWN",
+}
+
+// gRPC status codes worth naming in errors
+var grpcCodes = map[string]string{
+	"4":  "DEADLINE_EXCEEDED",
+	"5":  "NOT_FOUND",
+	"12": "UNIMPLEMENTED",
+	"14": "UNAVAILABLE",
+}
+
+// checkGRPC calls grpc.health.v1.Health/Check and is ready when the server
+// reports SERVING. The request and response messages are small enough to
+// encode by hand rather than pulling in a protobuf runtime.
+func (p *Prober) checkGRPC(ctx context.Context, target *Target) error {
+	scheme, client := "http", p.h2c
+	if target.TLS {
+		scheme, client = "https", p.h2
+	}
+
+	// HealthCheckRequest { string service = 1; }, length-prefixed
+	var message []byte
+	if target.Service != "" {
+		message = append([]byte{0x0a}, binary.AppendUvarint(nil, uint64(len(target.Service)))...)
+		message = append(message, target.Service...)
+	}
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	frame = append(frame, message...)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, scheme+"://"+target.GRPC+healthCheckPath, bytes.NewReader(frame))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return healthcheck.Simplify(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %s (is this a gRPC server?)", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Errors without a message are sent as headers only
+	code := resp.Trailer.Get("Grpc-Status")
+	grpcMessage := resp.Trailer.Get("Grpc-Message")
+	if code == "" {
+		code, grpcMessage = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	if code != "0" {
+		if code == "" {
+			return fmt.Errorf("response has no grpc-status")
+		}
+		if name, ok := grpcCodes[code]; ok {
+			code += " " + name
+		}
+		if code == "12 UNIMPLEMENTED" {
+			return fmt.Errorf("the server does not implement the grpc.health.v1 health service")
+		}
+		if grpcMessage != "" {
+			return fmt.Errorf("gRPC status %s: %s", code, grpcMessage)
+		}
+		return fmt.Errorf("gRPC status %s", code)
+	}
+
+	status, err := servingStatus(body)
+	if err != nil {
+		return err
+	}
+	if status != 1 {
+		name, ok := servingStatuses[status]
+		if !ok {
+			name = strconv.FormatUint(status, 10)
+		}
+		return fmt.Errorf("health status %s", name)
+	}
+	return nil
+}
+
+// servingStatus decodes HealthCheckResponse { ServingStatus status = 1; }
+// from a length-prefixed response message
+func servingStatus(body []byte) (uint64, error) {
+	if len(body) < 5 {
+		return 0, fmt.Errorf("malformed gRPC response")
+	}
+	if body[0] != 0 {
+		return 0, fmt.Errorf("compressed gRPC responses are not supported")
+	}
+	size := binary.BigEndian.Uint32(body[1:5])
+	if uint32(len(body)-5) < size {
+		return 0, fmt.Errorf("truncated gRPC response")
+	}
+	message := body[5 : 5+size]
+
+	// An absent field is the default value, UNKNOWN
+	var status uint64
+	for len(message) > 0 {
+		key, n := binary.Uvarint(message)
+		if n <= 0 {
+			return 0, fmt.Errorf("malformed health check response")
+		}
+		message = message[n:]
+
+		switch key & 7 {
+		case 0:
+			value, n := binary.Uvarint(message)
+			if n <= 0 {
+				return 0, fmt.Errorf("malformed health check response")
+			}
+			message = message[n:]
+			if key>>3 == 1 {
+				status = value
+			}
+		case 2:
+			length, n := binary.Uvarint(message)
+			if n <= 0 || uint64(len(message)-n) < length {
+				return 0, fmt.Errorf("malformed health check response")
+			}
+			message = message[n+int(length):]
+		default:
+			return 0, fmt.Errorf("malformed health check response")
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,69 @@
+//go:build go1.24
+
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newHealthServer serves grpc.health.v1.Health/Check over cleartext HTTP/2,
+// answering with the status configured for the requested service
+func newHealthServer(t *testing.T, statuses map[string]byte) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || r.URL.Path != healthCheckPath || r.Header.Get("Content-Type") != "application/grpc" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		service := ""
+		if len(body) > 7 {
+			service = string(body[7:])
+		}
+
+		w.Header().Set("Content-Type", "application/grpc")
+		status, ok := statuses[service]
+		if !ok {
+			// Errors are sent as trailers-only responses
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "unknown service")
+			return
+		}
+
+		w.Header().Set("Trailer", "Grpc-Status")
+		message := []byte{0x08, status}
+		frame := make([]byte, 5)
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+		w.Write(append(frame, message...))
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProber_GRPC(t *testing.T) {
+	server := newHealthServer(t, map[string]byte{"": 1, "orders.v1.Orders": 2})
+	addr := strings.TrimPrefix(server.URL, "http://")
+	prober := NewProber(time.Second)
+
+	if err := prober.Check(context.Background(), &Target{GRPC: addr}); err != nil {
+		t.Errorf("Expected the server to be serving, got %v", err)
+	}
+	if err := prober.Check(context.Background(), &Target{GRPC: addr, Service: "orders.v1.Orders"}); err == nil || err.Error() != "health status NOT_SERVING" {
+		t.Errorf("Expected NOT_SERVING, got %v", err)
+	}
+	if err := prober.Check(context.Background(), &Target{GRPC: addr, Service: "users.v1.Users"}); err == nil || err.Error() != "gRPC status 5 NOT_FOUND: unknown service" {
+		t.Errorf("Expected NOT_FOUND, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProber_GRPCNotGRPC(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	err := NewProber(time.Second).Check(context.Background(), &Target{GRPC: strings.TrimPrefix(server.URL, "http://")})
+	if err == nil {
+		t.Error("Expected a plain HTTP/1 server to fail the check")
+	}
+}
+
+func TestServingStatus(t *testing.T) {
+	tests := []struct {
+		body []byte
+		want uint64
+	}{
+		{[]byte{0, 0, 0, 0, 2, 0x08, 1}, 1},
+		{[]byte{0, 0, 0, 0, 0}, 0},
+		// Unknown length-delimited fields are skipped
+		{[]byte{0, 0, 0, 0, 6, 0x12, 2, 'h', 'i', 0x08, 3}, 3},
+	}
+	for _, tt := range tests {
+		if got, err := servingStatus(tt.body); err != nil || got != tt.want {
+			t.Errorf("servingStatus(%v) = %d, %v; want %d", tt.body, got, err, tt.want)
+		}
+	}
+
+	for _, body := range [][]byte{{0, 0}, {1, 0, 0, 0, 0}, {0, 0, 0, 0, 9, 0x08}, {0, 0, 0, 0, 1, 0x08}} {
+		if _, err := servingStatus(body); err == nil {
+			t.Errorf("Expected %v to be rejected", body)
+		}
+	}
+}
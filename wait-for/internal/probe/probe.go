@@ -0,0 +1,63 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/healthcheck"
+)
+
+// Prober runs single health checks, each bounded by a timeout
+type Prober struct {
+	timeout time.Duration
+	http    *http.Client
+	h2c     *http.Client
+	h2      *http.Client
+}
+
+// NewProber creates a prober whose checks each complete within timeout
+func NewProber(timeout time.Duration) *Prober {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &Prober{
+		timeout: timeout,
+		http:    &http.Client{Transport: healthcheck.NewTransport()},
+		h2c:     &http.Client{Transport: newGRPCTransport(false)},
+		h2:      &http.Client{Transport: newGRPCTransport(true)},
+	}
+}
+
+// Check probes the target once, returning nil when it is ready
+func (p *Prober) Check(ctx context.Context, target *Target) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var err error
+	switch target.Kind() {
+	case KindHTTP:
+		err = p.checkHTTP(ctx, target)
+	case KindGRPC:
+		err = p.checkGRPC(ctx, target)
+	default:
+		err = p.checkTCP(ctx, target)
+	}
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("no response within %v", p.timeout)
+	}
+	return err
+}
+
+// checkTCP is ready once the address accepts a connection
+func (p *Prober) checkTCP(ctx context.Context, target *Target) error {
+	return healthcheck.TCP(ctx, target.TCP)
+}
+
+// checkHTTP is ready on an expected status, any 2xx by default
+func (p *Prober) checkHTTP(ctx context.Context, target *Target) error {
+	_, err := healthcheck.HTTP(ctx, p.http, target.HTTP, target.ExpectStatus)
+	return err
+}
@@ -0,0 +1,83 @@
+package probe
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/requests"
+)
+
+func TestProber_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ready":
+		case "/starting":
+			http.Error(w, "warming caches", http.StatusServiceUnavailable)
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" || string(body) != `{"ping":true}` {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	prober := NewProber(time.Second)
+	check := func(target Target) error {
+		if err := target.Validate(); err != nil {
+			t.Fatalf("Invalid target: %v", err)
+		}
+		return prober.Check(context.Background(), &target)
+	}
+
+	if err := check(Target{HTTP: &requests.HTTP{URL: requests.LiteralString(server.URL + "/ready")}}); err != nil {
+		t.Errorf("Expected ready, got %v", err)
+	}
+	if err := check(Target{HTTP: &requests.HTTP{URL: requests.LiteralString(server.URL + "/starting")}}); err == nil || err.Error() != "status 503 Service Unavailable: warming caches" {
+		t.Errorf("Expected not ready with the response body, got %v", err)
+	}
+	if err := check(Target{HTTP: &requests.HTTP{Method: "POST", URL: requests.LiteralString(server.URL + "/echo"), Body: requests.LiteralBody(map[string]interface{}{"ping": true})}}); err != nil {
+		t.Errorf("Expected the JSON body to be sent, got %v", err)
+	}
+	if err := check(Target{HTTP: &requests.HTTP{URL: requests.LiteralString(server.URL + "/admin")}, ExpectStatus: []int{401}}); err != nil {
+		t.Errorf("Expected 401 to be accepted, got %v", err)
+	}
+}
+
+func TestProber_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+
+	prober := NewProber(time.Second)
+	if err := prober.Check(context.Background(), &Target{TCP: addr}); err != nil {
+		t.Errorf("Expected ready, got %v", err)
+	}
+
+	listener.Close()
+	if err := prober.Check(context.Background(), &Target{TCP: addr}); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("Expected connection refused, got %v", err)
+	}
+}
+
+func TestProber_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	err := NewProber(50*time.Millisecond).Check(context.Background(), &Target{HTTP: &requests.HTTP{Method: "GET", URL: requests.LiteralString(server.URL)}})
+	if err == nil || err.Error() != "no response within 50ms" {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+}
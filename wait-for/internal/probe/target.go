@@ -0,0 +1,237 @@
+// Package probe checks HTTP, TCP and gRPC health targets until they are
+// ready, for gating the start of tools that depend on a local stack.
+package probe
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/requests"
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+// Target kinds returned by Target.Kind
+const (
+	KindHTTP = "http"
+	KindTCP  = "tcp"
+	KindGRPC = "grpc"
+)
+
+// Config is a targets file
+type Config struct {
+	// Timeout and Interval override the command line defaults when set
+	Timeout  string `yaml:"timeout,omitempty"`
+	Interval string `yaml:"interval,omitempty"`
+
+	Targets []Target `yaml:"targets"`
+}
+
+// Target is one dependency to wait for. Exactly one of HTTP, TCP and GRPC
+// is set.
+type Target struct {
+	Name string `yaml:"name,omitempty"`
+
+	// HTTP is a request in the scheduler's http format; templates are
+	// resolved once, before probing starts
+	HTTP *requests.HTTP `yaml:"http,omitempty"`
+
+	// ExpectStatus lists the ready status codes; any 2xx when empty
+	ExpectStatus []int `yaml:"expect_status,omitempty"`
+
+	// TCP is a host:port that must accept connections
+	TCP string `yaml:"tcp,omitempty"`
+
+	// GRPC is a host:port serving the standard grpc.health.v1 health service
+	GRPC string `yaml:"grpc,omitempty"`
+
+	// Service is the gRPC service to check; the server's overall health when
+	// empty
+	Service string `yaml:"service,omitempty"`
+
+	// TLS connects to the gRPC server over TLS instead of cleartext HTTP/2
+	TLS bool `yaml:"tls,omitempty"`
+}
+
+// Kind returns the kind of probe the target needs
+func (t *Target) Kind() string {
+	switch {
+	case t.HTTP != nil:
+		return KindHTTP
+	case t.GRPC != "":
+		return KindGRPC
+	default:
+		return KindTCP
+	}
+}
+
+// Address returns what the target probes, for progress output
+func (t *Target) Address() string {
+	switch t.Kind() {
+	case KindHTTP:
+		return t.HTTP.URL.Raw()
+	case KindGRPC:
+		scheme := "grpc://"
+		if t.TLS {
+			scheme = "grpcs://"
+		}
+		if t.Service != "" {
+			return scheme + t.GRPC + "/" + t.Service
+		}
+		return scheme + t.GRPC
+	default:
+		return "tcp://" + t.TCP
+	}
+}
+
+// Label names the target in progress output, with its address when the
+// name differs
+func (t *Target) Label() string {
+	if address := t.Address(); t.Name != address {
+		return t.Name + " (" + address + ")"
+	}
+	return t.Name
+}
+
+// Validate checks that exactly one probe is configured and fills defaults
+func (t *Target) Validate() error {
+	set := 0
+	for _, ok := range []bool{t.HTTP != nil, t.TCP != "", t.GRPC != ""} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of http, tcp or grpc must be set")
+	}
+
+	switch t.Kind() {
+	case KindHTTP:
+		if t.HTTP.Method == "" {
+			t.HTTP.Method = "GET"
+		}
+		if err := t.HTTP.Validate(); err != nil {
+			return err
+		}
+		for _, status := range t.ExpectStatus {
+			if status < 100 || status > 599 {
+				return fmt.Errorf("expect_status %d is not an HTTP status code", status)
+			}
+		}
+	case KindTCP:
+		if err := validateAddress(t.TCP); err != nil {
+			return fmt.Errorf("tcp: %w", err)
+		}
+	case KindGRPC:
+		if err := validateAddress(t.GRPC); err != nil {
+			return fmt.Errorf("grpc: %w", err)
+		}
+	}
+	if t.Kind() != KindHTTP && len(t.ExpectStatus) > 0 {
+		return fmt.Errorf("expect_status only applies to http targets")
+	}
+	if t.Kind() != KindGRPC && (t.Service != "" || t.TLS) {
+		return fmt.Errorf("service and tls only apply to grpc targets")
+	}
+
+	if t.Name == "" {
+		t.Name = t.Address()
+	}
+	return nil
+}
+
+// Resolve evaluates the templates of an HTTP target with engine
+func (t *Target) Resolve(engine *templating.Engine) error {
+	if t.HTTP == nil {
+		return nil
+	}
+	resolved, err := requests.ResolveHTTP(engine, *t.HTTP)
+	if err != nil {
+		return err
+	}
+	if t.Name == t.HTTP.URL.Raw() {
+		t.Name = resolved.URL
+	}
+	t.HTTP = &requests.HTTP{
+		Method:  resolved.Method,
+		URL:     requests.LiteralString(resolved.URL),
+		Headers: requests.LiteralHeaders(resolved.Headers),
+		Body:    requests.LiteralBody(resolved.Body),
+	}
+	return nil
+}
+
+// ParseTarget reads a target given on the command line: an http:// or
+// https:// URL, tcp://host:port, grpc://host:port[/service] (grpcs:// for
+// TLS), or a bare host:port, which is probed over TCP
+func ParseTarget(arg string) (Target, error) {
+	if !strings.Contains(arg, "://") {
+		arg = "tcp://" + arg
+	}
+	u, err := url.Parse(arg)
+	if err != nil {
+		return Target{}, fmt.Errorf("invalid target %q: %w", arg, err)
+	}
+
+	var target Target
+	switch u.Scheme {
+	case "http", "https":
+		target.HTTP = &requests.HTTP{Method: "GET", URL: requests.LiteralString(arg)}
+	case "tcp":
+		target.TCP = u.Host
+	case "grpc", "grpcs":
+		target.GRPC = u.Host
+		target.Service = strings.Trim(u.Path, "/")
+		target.TLS = u.Scheme == "grpcs"
+	default:
+		return Target{}, fmt.Errorf("invalid target %q: scheme must be http, https, tcp, grpc or grpcs", arg)
+	}
+	if err := target.Validate(); err != nil {
+		return Target{}, fmt.Errorf("invalid target %q: %w", arg, err)
+	}
+	return target, nil
+}
+
+// LoadConfig reads and validates a targets file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	for _, field := range []struct{ name, value string }{{"timeout", config.Timeout}, {"interval", config.Interval}} {
+		if field.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(field.value); err != nil || d <= 0 {
+			return nil, fmt.Errorf("%s must be a positive duration (e.g. 2m)", field.name)
+		}
+	}
+	for i := range config.Targets {
+		if err := config.Targets[i].Validate(); err != nil {
+			return nil, fmt.Errorf("target %d: %w", i, err)
+		}
+	}
+	return &config, nil
+}
+
+// validateAddress checks a host:port pair
+func validateAddress(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || port == "" {
+		return fmt.Errorf("address %q must be host:port", addr)
+	}
+	if host == "" {
+		return fmt.Errorf("address %q is missing a host", addr)
+	}
+	return nil
+}
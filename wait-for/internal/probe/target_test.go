@@ -0,0 +1,100 @@
+package probe
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := map[string]struct {
+		kind, address string
+	}{
+		"localhost:5432":                      {KindTCP, "tcp://localhost:5432"},
+		"tcp://db:5432":                       {KindTCP, "tcp://db:5432"},
+		"http://localhost:3000/health":        {KindHTTP, "http://localhost:3000/health"},
+		"https://api.local/ready":             {KindHTTP, "https://api.local/ready"},
+		"grpc://localhost:50051":              {KindGRPC, "grpc://localhost:50051"},
+		"grpcs://orders:443/orders.v1.Orders": {KindGRPC, "grpcs://orders:443/orders.v1.Orders"},
+	}
+	for arg, want := range tests {
+		target, err := ParseTarget(arg)
+		if err != nil {
+			t.Errorf("ParseTarget(%q) failed: %v", arg, err)
+			continue
+		}
+		if target.Kind() != want.kind || target.Address() != want.address || target.Name != want.address {
+			t.Errorf("ParseTarget(%q) = %s %s named %q, want %s %s", arg, target.Kind(), target.Address(), target.Name, want.kind, want.address)
+		}
+	}
+
+	for _, arg := range []string{"localhost", "redis://localhost:6379", "tcp://:5432", "grpc://localhost"} {
+		if _, err := ParseTarget(arg); err == nil {
+			t.Errorf("Expected %q to be rejected", arg)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.yaml")
+	os.WriteFile(path, []byte(`
+timeout: 90s
+targets:
+  - name: api
+    http:
+      url: '{{ env "WAIT_FOR_API" }}/health'
+      headers:
+        Authorization: 'Bearer {{ env "WAIT_FOR_TOKEN" }}'
+    expect_status: [200, 401]
+  - name: postgres
+    tcp: localhost:5432
+  - grpc: localhost:50051
+    service: orders.v1.Orders
+`), 0o644)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.Timeout != "90s" || len(config.Targets) != 3 {
+		t.Fatalf("Unexpected config %+v", config)
+	}
+	api := config.Targets[0]
+	if api.HTTP.Method != "GET" || api.Label() != `api ({{ env "WAIT_FOR_API" }}/health)` {
+		t.Errorf("Expected GET by default, got %+v labelled %q", api.HTTP, api.Label())
+	}
+	if grpc := config.Targets[2]; grpc.Name != "grpc://localhost:50051/orders.v1.Orders" || grpc.Label() != grpc.Name {
+		t.Errorf("Expected the address as the default name, got %q", grpc.Label())
+	}
+
+	t.Setenv("WAIT_FOR_API", "http://localhost:3000")
+	t.Setenv("WAIT_FOR_TOKEN", "abc")
+	if err := api.Resolve(templating.New(nil, 1)); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if api.HTTP.URL.GetValue() != "http://localhost:3000/health" || api.HTTP.Headers["Authorization"].GetValue() != "Bearer abc" {
+		t.Errorf("Unexpected resolved target %+v", api.HTTP)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"exactly one of":       "targets: [{tcp: 'localhost:1', grpc: 'localhost:2'}]",
+		"must be host:port":    "targets: [{tcp: localhost}]",
+		"invalid HTTP method":  "targets: [{http: {method: FETCH, url: 'http://localhost'}}]",
+		"expect_status only":   "targets: [{tcp: 'localhost:1', expect_status: [200]}]",
+		"service and tls only": "targets: [{tcp: 'localhost:1', tls: true}]",
+		"not an HTTP status":   "targets: [{http: {url: 'http://localhost'}, expect_status: [42]}]",
+		"timeout must be":      "timeout: soon\ntargets: []",
+	}
+	for want, content := range tests {
+		path := filepath.Join(t.TempDir(), "targets.yaml")
+		os.WriteFile(path, []byte(content), 0o644)
+		if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %v", want, err)
+		}
+	}
+}
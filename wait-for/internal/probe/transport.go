@@ -0,0 +1,25 @@
+//go:build go1.24
+
+package probe
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/healthcheck"
+)
+
+// newGRPCTransport returns an HTTP/2-only transport for gRPC checks, over
+// TLS or cleartext (h2c). Go 1.24 added the http.Protocols that make this
+// possible without golang.org/x/net.
+func newGRPCTransport(useTLS bool) http.RoundTripper {
+	transport := healthcheck.NewTransport()
+	transport.Protocols = new(http.Protocols)
+	if useTLS {
+		transport.TLSClientConfig = &tls.Config{NextProtos: []string{"h2"}}
+		transport.Protocols.SetHTTP2(true)
+	} else {
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	}
+	return transport
+}
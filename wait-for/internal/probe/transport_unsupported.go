@@ -0,0 +1,24 @@
+//go:build !go1.24
+
+package probe
+
+import (
+	"errors"
+	"net/http"
+)
+
+// errUnsupported is returned for every gRPC check by builds without HTTP/2
+// support
+var errUnsupported = errors.New("gRPC checks need a build with Go 1.24 or later")
+
+// newGRPCTransport returns a transport that fails every request, as HTTP/2
+// without TLS needs Go 1.24
+func newGRPCTransport(bool) http.RoundTripper {
+	return unsupportedTransport{}
+}
+
+type unsupportedTransport struct{}
+
+func (unsupportedTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errUnsupported
+}
@@ -0,0 +1,120 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// stillWaitingEvery is how often a target that keeps failing the same way is
+// reported again
+const stillWaitingEvery = 10 * time.Second
+
+// Result is the outcome of waiting for one target
+type Result struct {
+	Target   Target
+	Ready    bool
+	Attempts int
+	Elapsed  time.Duration
+
+	// Err is the last failed check of a target that never became ready
+	Err error
+}
+
+// Waiter probes targets until they are ready, reporting progress to Output
+type Waiter struct {
+	Prober *Prober
+
+	// Interval is the pause between failed checks of a target
+	Interval time.Duration
+
+	// Output receives one line per change in a target's state; nil discards
+	Output io.Writer
+
+	mu sync.Mutex
+}
+
+// Wait probes every target concurrently until all are ready or ctx is done,
+// returning a result per target in the order given
+func (w *Waiter) Wait(ctx context.Context, targets []Target) []Result {
+	results := make([]Result, len(targets))
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = w.waitFor(ctx, targets[i])
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// waitFor probes a single target until it is ready or ctx is done
+func (w *Waiter) waitFor(ctx context.Context, target Target) Result {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	start := time.Now()
+	result := Result{Target: target}
+	var lastError string
+	var lastReport time.Time
+
+	for {
+		result.Attempts++
+		err := w.Prober.Check(ctx, &target)
+		result.Elapsed = time.Since(start)
+		if err == nil {
+			result.Ready, result.Err = true, nil
+			w.printf("✓ %s is ready after %s (%s)\n", target.Name, result.Elapsed.Round(time.Millisecond), plural(result.Attempts, "attempt"))
+			return result
+		}
+		if ctx.Err() != nil {
+			// The check was cut short by the deadline; keep the previous error
+			if result.Err == nil {
+				result.Err = err
+			}
+			return result
+		}
+		result.Err = err
+
+		switch {
+		case lastError == "":
+			w.printf("… waiting for %s: %v\n", target.Label(), err)
+			lastReport = time.Now()
+		case err.Error() != lastError || time.Since(lastReport) >= stillWaitingEvery:
+			w.printf("… %s still not ready after %s: %v\n", target.Name, result.Elapsed.Round(time.Second), err)
+			lastReport = time.Now()
+		}
+		lastError = err.Error()
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return result
+		}
+	}
+}
+
+// printf writes a progress line, keeping lines from concurrent targets whole
+func (w *Waiter) printf(format string, args ...interface{}) {
+	if w.Output == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(w.Output, format, args...)
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
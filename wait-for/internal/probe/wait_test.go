@@ -0,0 +1,59 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/requests"
+)
+
+func TestWaiter_Wait(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	targets := []Target{
+		{Name: "api", HTTP: &requests.HTTP{Method: "GET", URL: requests.LiteralString(server.URL)}},
+		{Name: "db", TCP: "127.0.0.1:1"},
+	}
+	var out bytes.Buffer
+	waiter := &Waiter{Prober: NewProber(time.Second), Interval: 20 * time.Millisecond, Output: &out}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	results := waiter.Wait(ctx, targets)
+
+	api, db := results[0], results[1]
+	if !api.Ready || api.Attempts != 3 || api.Err != nil {
+		t.Errorf("Expected api ready on the third attempt, got %+v", api)
+	}
+	if db.Ready || db.Err == nil || !strings.Contains(db.Err.Error(), "connection refused") || db.Attempts < 2 {
+		t.Errorf("Expected db not ready, got %+v", db)
+	}
+
+	output := out.String()
+	for _, want := range []string{
+		"… waiting for api (" + server.URL + "): status 503 Service Unavailable\n",
+		"✓ api is ready after",
+		"(3 attempts)\n",
+		"… waiting for db (tcp://127.0.0.1:1): connect: connection refused\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output:\n%s", want, output)
+		}
+	}
+	// Repeated identical failures are not reported again within 10s
+	if strings.Count(output, "db") != 1 {
+		t.Errorf("Expected a single line for db, got:\n%s", output)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"local-dev-tools/dynamic-request-scheduler/pkg/templating"
+	"local-dev-tools/wait-for/internal/probe"
+)
+
+// Process exit codes
+const (
+	exitOK = 0
+	// exitNotReady means at least one target was not ready before the timeout
+	exitNotReady = 1
+	// exitConfigError means the targets or flags were invalid
+	exitConfigError = 2
+	// exitRuntimeError means the command to run once ready could not be started
+	exitRuntimeError = 3
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run waits for the targets, then runs the command given after -- if any,
+// and returns the process exit code
+func run() int {
+	configPath := flag.String("config", "", "Path to a targets file (YAML or JSON)")
+	timeout := flag.Duration("timeout", 2*time.Minute, "Give up when the targets are not all ready after this long")
+	interval := flag.Duration("interval", time.Second, "Pause between failed checks of a target")
+	attemptTimeout := flag.Duration("attempt-timeout", 5*time.Second, "Timeout of a single check")
+	quiet := flag.Bool("quiet", false, "Only report targets that are not ready")
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, "Usage: %s [options] [target ...] [-- command [args...]]\n\n", os.Args[0])
+		fmt.Fprintln(out, "Targets are http:// or https:// URLs, tcp://host:port, grpc://host:port[/service]")
+		fmt.Fprintln(out, "(grpcs:// for TLS) or a bare host:port, which is checked over TCP.")
+		fmt.Fprintln(out)
+		flag.PrintDefaults()
+	}
+	// Split off the command first: flag.Parse would swallow a leading --
+	args, command := splitCommand(os.Args[1:])
+	flag.CommandLine.Parse(args)
+	args = flag.Args()
+
+	var targets []probe.Target
+	if *configPath != "" {
+		config, err := probe.LoadConfig(*configPath)
+		if err != nil {
+			log.Printf("Error loading config: %v", err)
+			return exitConfigError
+		}
+		targets = config.Targets
+
+		// The file's settings apply unless overridden on the command line
+		set := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if config.Timeout != "" && !set["timeout"] {
+			*timeout, _ = time.ParseDuration(config.Timeout)
+		}
+		if config.Interval != "" && !set["interval"] {
+			*interval, _ = time.ParseDuration(config.Interval)
+		}
+	}
+	for _, arg := range args {
+		target, err := probe.ParseTarget(arg)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitConfigError
+		}
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		log.Printf("Error: no targets given (pass them as arguments or with --config)")
+		return exitConfigError
+	}
+	if *timeout <= 0 || *interval <= 0 || *attemptTimeout <= 0 {
+		log.Printf("Error: --timeout, --interval and --attempt-timeout must be positive")
+		return exitConfigError
+	}
+
+	engine := templating.New(nil, 0)
+	for i := range targets {
+		if err := targets[i].Resolve(engine); err != nil {
+			log.Printf("Error resolving target %s: %v", targets[i].Name, err)
+			return exitConfigError
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	waiter := &probe.Waiter{Prober: probe.NewProber(*attemptTimeout), Interval: *interval}
+	if !*quiet {
+		waiter.Output = os.Stdout
+	}
+
+	start := time.Now()
+	results := waiter.Wait(ctx, targets)
+	cancel()
+	stop()
+
+	var notReady int
+	for _, result := range results {
+		if !result.Ready {
+			notReady++
+			fmt.Fprintf(os.Stderr, "✗ %s is not ready after %s: %v\n", result.Target.Label(),
+				result.Elapsed.Round(time.Second), result.Err)
+		}
+	}
+	if notReady > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d targets not ready within %v\n", notReady, len(targets), *timeout)
+		return exitNotReady
+	}
+	if !*quiet {
+		fmt.Printf("All %d targets ready in %s\n", len(targets), time.Since(start).Round(time.Millisecond))
+	}
+
+	if len(command) == 0 {
+		return exitOK
+	}
+	return runCommand(command)
+}
+
+// splitCommand separates targets from the command following --
+func splitCommand(args []string) (targets, command []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+// runCommand runs the command once the targets are ready, forwarding
+// termination signals to it, and returns its exit code
+func runCommand(command []string) int {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	if err := cmd.Start(); err != nil {
+		log.Printf("Error starting %s: %v", command[0], err)
+		return exitRuntimeError
+	}
+	go func() {
+		for sig := range sigChan {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		log.Printf("Error running %s: %v", command[0], err)
+		return exitRuntimeError
+	}
+	return exitOK
+}